@@ -8,7 +8,9 @@ package cmd
 import (
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"strings"
 	"text/tabwriter"
 
 	"code.gitea.io/gitea/models"
@@ -30,6 +32,7 @@ var (
 			subcmdCreateUser,
 			subcmdChangePassword,
 			subcmdRepoSyncReleases,
+			subcmdGenerateRepos,
 			subcmdRegenerate,
 			subcmdAuth,
 		},
@@ -77,6 +80,11 @@ var (
 				Name:  "access-token",
 				Usage: "Generate access token for the user",
 			},
+			cli.StringFlag{
+				Name:  "access-token-scopes",
+				Value: "all",
+				Usage: "Comma separated scopes of the generated access token: all, repo:read, repo:write, admin, org, user, package",
+			},
 		},
 	}
 
@@ -104,6 +112,59 @@ var (
 		Action: runRepoSyncReleases,
 	}
 
+	subcmdGenerateRepos = cli.Command{
+		Name:      "generate-repos",
+		Usage:     "Generate repositories from a template repository",
+		ArgsUsage: "[name...]",
+		Action:    runGenerateRepos,
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "template,t",
+				Usage: "The template repository, as owner/name",
+			},
+			cli.StringFlag{
+				Name:  "owner,o",
+				Usage: "The user or organization owning the generated repositories",
+			},
+			cli.StringFlag{
+				Name:  "doer",
+				Usage: "The user generating the repositories, defaults to the owner if it is a user",
+			},
+			cli.StringFlag{
+				Name:  "file,f",
+				Usage: "A file listing the names of the repositories to generate, one per line",
+			},
+			cli.StringFlag{
+				Name:  "description",
+				Usage: "Description of the generated repositories",
+			},
+			cli.BoolFlag{
+				Name:  "private",
+				Usage: "Make the generated repositories private",
+			},
+			cli.BoolTFlag{
+				Name:  "git-content",
+				Usage: "Copy the git content of the default branch (default: true)",
+			},
+			cli.BoolFlag{
+				Name:  "topics",
+				Usage: "Copy the topics",
+			},
+			cli.BoolFlag{
+				Name:  "webhooks",
+				Usage: "Copy the webhooks",
+			},
+			cli.BoolFlag{
+				Name:  "avatar",
+				Usage: "Copy the avatar",
+			},
+			cli.BoolFlag{
+				Name:  "labels",
+				Usage: "Copy the issue labels",
+			},
+		},
+	}
+
 	subcmdRegenerate = cli.Command{
 		Name:  "regenerate",
 		Usage: "Regenerate specific files",
@@ -291,6 +352,11 @@ func runCreateUser(c *cli.Context) error {
 		return errors.New("must set either password or random-password flag")
 	}
 
+	tokenScope, err := models.NormalizeAccessTokenScope(strings.Split(c.String("access-token-scopes"), ","))
+	if err != nil {
+		return err
+	}
+
 	if err := initDB(); err != nil {
 		return err
 	}
@@ -324,8 +390,9 @@ func runCreateUser(c *cli.Context) error {
 
 	if c.Bool("access-token") {
 		t := &models.AccessToken{
-			Name: "gitea-admin",
-			UID:  u.ID,
+			Name:  "gitea-admin",
+			UID:   u.ID,
+			Scope: tokenScope,
 		}
 
 		if err := models.NewAccessToken(t); err != nil {
@@ -391,6 +458,98 @@ func runRepoSyncReleases(c *cli.Context) error {
 	return nil
 }
 
+func readRepoNames(c *cli.Context) ([]string, error) {
+	names := make([]string, 0, c.NArg())
+	names = append(names, c.Args()...)
+	if c.IsSet("file") {
+		data, err := ioutil.ReadFile(c.String("file"))
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+				names = append(names, line)
+			}
+		}
+	}
+	return names, nil
+}
+
+func runGenerateRepos(c *cli.Context) error {
+	if !c.IsSet("template") {
+		return fmt.Errorf("--template flag is missing")
+	}
+	if !c.IsSet("owner") {
+		return fmt.Errorf("--owner flag is missing")
+	}
+	templateName := strings.SplitN(c.String("template"), "/", 2)
+	if len(templateName) != 2 {
+		return fmt.Errorf("--template must be formatted as owner/name")
+	}
+
+	names, err := readRepoNames(c)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return errors.New("no repository name given")
+	}
+
+	if err := initDB(); err != nil {
+		return err
+	}
+
+	templateRepo, err := models.GetRepositoryByOwnerAndName(templateName[0], templateName[1])
+	if err != nil {
+		return fmt.Errorf("GetRepositoryByOwnerAndName: %v", err)
+	}
+	if !templateRepo.IsTemplate {
+		return fmt.Errorf("%s is not a template repository", templateRepo.FullName())
+	}
+
+	owner, err := models.GetUserByName(c.String("owner"))
+	if err != nil {
+		return fmt.Errorf("GetUserByName: %v", err)
+	}
+	doer := owner
+	if c.IsSet("doer") {
+		if doer, err = models.GetUserByName(c.String("doer")); err != nil {
+			return fmt.Errorf("GetUserByName: %v", err)
+		}
+	} else if owner.IsOrganization() {
+		return fmt.Errorf("--doer flag is required when the owner is an organization")
+	}
+
+	opts := models.GenerateRepoOptions{
+		Description: c.String("description"),
+		Private:     c.Bool("private"),
+		GitContent:  c.BoolT("git-content"),
+		Topics:      c.Bool("topics"),
+		Webhooks:    c.Bool("webhooks"),
+		Avatar:      c.Bool("avatar"),
+		IssueLabels: c.Bool("labels"),
+	}
+	if !opts.IsValid() {
+		return errors.New("at least one template item must be selected")
+	}
+
+	var failed int
+	for _, name := range names {
+		opts.Name = name
+		repo, err := models.GenerateRepository(doer, owner, templateRepo, opts)
+		if err != nil {
+			failed++
+			fmt.Printf("Failed to generate %s/%s: %v\n", owner.Name, name, err)
+			continue
+		}
+		fmt.Printf("Repository '%s' has been generated\n", repo.FullName())
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d repositories could not be generated", failed, len(names))
+	}
+	return nil
+}
+
 func getReleaseCount(id int64) (int64, error) {
 	return models.GetReleaseCountByRepoID(
 		id,
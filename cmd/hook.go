@@ -66,6 +66,7 @@ func runHookPreReceive(c *cli.Context) error {
 	reponame := os.Getenv(models.EnvRepoName)
 	userID, _ := strconv.ParseInt(os.Getenv(models.EnvPusherID), 10, 64)
 	prID, _ := strconv.ParseInt(os.Getenv(models.ProtectedBranchPRID), 10, 64)
+	pushOptions := getGitPushOptions()
 
 	buf := bytes.NewBuffer(nil)
 	scanner := bufio.NewScanner(os.Stdin)
@@ -87,8 +88,8 @@ func runHookPreReceive(c *cli.Context) error {
 		newCommitID := string(fields[1])
 		refFullName := string(fields[2])
 
-		// If the ref is a branch, check if it's protected
-		if strings.HasPrefix(refFullName, git.BranchPrefix) {
+		// If the ref is a branch, check if it's protected, the AGit flow refs are checked too
+		if strings.HasPrefix(refFullName, git.BranchPrefix) || strings.HasPrefix(refFullName, git.ForPrefix) {
 			statusCode, msg := private.HookPreReceive(username, reponame, private.HookOptions{
 				OldCommitID:                     oldCommitID,
 				NewCommitID:                     newCommitID,
@@ -97,6 +98,7 @@ func runHookPreReceive(c *cli.Context) error {
 				GitAlternativeObjectDirectories: os.Getenv(private.GitAlternativeObjectDirectories),
 				GitObjectDirectory:              os.Getenv(private.GitObjectDirectory),
 				ProtectedBranchID:               prID,
+				GitPushOptions:                  pushOptions,
 			})
 			switch statusCode {
 			case http.StatusInternalServerError:
@@ -110,6 +112,17 @@ func runHookPreReceive(c *cli.Context) error {
 	return nil
 }
 
+// getGitPushOptions returns the options given with git push -o, git passes them to the hooks
+// in the environment when the repository has receive.advertisePushOptions set.
+func getGitPushOptions() []string {
+	count, _ := strconv.Atoi(os.Getenv(private.GitPushOptionCount))
+	options := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		options = append(options, os.Getenv(fmt.Sprintf("GIT_PUSH_OPTION_%d", i)))
+	}
+	return options
+}
+
 func runHookUpdate(c *cli.Context) error {
 	if len(os.Getenv("SSH_ORIGINAL_COMMAND")) == 0 {
 		return nil
@@ -133,6 +146,7 @@ func runHookPostReceive(c *cli.Context) error {
 	repoName := os.Getenv(models.EnvRepoName)
 	pusherID, _ := strconv.ParseInt(os.Getenv(models.EnvPusherID), 10, 64)
 	pusherName := os.Getenv(models.EnvPusherName)
+	pushOptions := getGitPushOptions()
 
 	buf := bytes.NewBuffer(nil)
 	scanner := bufio.NewScanner(os.Stdin)
@@ -155,11 +169,12 @@ func runHookPostReceive(c *cli.Context) error {
 		refFullName := string(fields[2])
 
 		res, err := private.HookPostReceive(repoUser, repoName, private.HookOptions{
-			OldCommitID: oldCommitID,
-			NewCommitID: newCommitID,
-			RefFullName: refFullName,
-			UserID:      pusherID,
-			UserName:    pusherName,
+			OldCommitID:    oldCommitID,
+			NewCommitID:    newCommitID,
+			RefFullName:    refFullName,
+			UserID:         pusherID,
+			UserName:       pusherName,
+			GitPushOptions: pushOptions,
 		})
 
 		if res == nil {
@@ -171,7 +186,14 @@ func runHookPostReceive(c *cli.Context) error {
 		}
 
 		fmt.Fprintln(os.Stderr, "")
-		if res["create"] == true {
+		if res["agit"] == true {
+			if res["create"] == true {
+				fmt.Fprintf(os.Stderr, "Created a new pull request for '%s':\n", res["branch"])
+			} else {
+				fmt.Fprintf(os.Stderr, "Updated the pull request for '%s':\n", res["branch"])
+			}
+			fmt.Fprintf(os.Stderr, "  %s\n", res["url"])
+		} else if res["create"] == true {
 			fmt.Fprintf(os.Stderr, "Create a new pull request for '%s':\n", res["branch"])
 			fmt.Fprintf(os.Stderr, "  %s\n", res["url"])
 		} else {
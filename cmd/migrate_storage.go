@@ -0,0 +1,94 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
+
+	"github.com/urfave/cli"
+)
+
+// CmdMigrateStorage represents the available migrate-storage sub-command.
+var CmdMigrateStorage = cli.Command{
+	Name:        "migrate-storage",
+	Usage:       "Migrate the storage",
+	Description: "This is a command for copying the data kept on the local disk into the configured storage",
+	Action:      runMigrateStorage,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "type, t",
+			Value: "all",
+			Usage: "Kind of data to migrate: lfs, attachments, avatars, repo-avatars or all",
+		},
+	},
+}
+
+type storageMigration struct {
+	name    string
+	srcPath string
+	cfg     setting.Storage
+	dst     *storage.ObjectStorage
+}
+
+func runMigrateStorage(ctx *cli.Context) error {
+	setting.NewContext()
+	if err := storage.Init(); err != nil {
+		return err
+	}
+
+	migrations := []storageMigration{
+		{"lfs", setting.LFS.ContentPath, setting.LFSStorage, &storage.LFS},
+		{"attachments", setting.AttachmentPath, setting.AttachmentStorage, &storage.Attachments},
+		{"avatars", setting.AvatarUploadPath, setting.AvatarStorage, &storage.Avatars},
+		{"repo-avatars", setting.RepositoryAvatarUploadPath, setting.RepoAvatarStorage, &storage.RepoAvatars},
+	}
+
+	tp := ctx.String("type")
+	found := false
+	for _, m := range migrations {
+		if tp != "all" && tp != m.name {
+			continue
+		}
+		found = true
+
+		if m.cfg.Type == setting.LocalStorageType && filepath.Clean(m.cfg.Path) == filepath.Clean(m.srcPath) {
+			fmt.Printf("Skipping %s: storage is the local directory %s\n", m.name, m.srcPath)
+			continue
+		}
+		count, err := migrateStorage(*m.dst, m.srcPath)
+		if err != nil {
+			return fmt.Errorf("migrate %s: %v", m.name, err)
+		}
+		fmt.Printf("Migrated %d %s objects from %s\n", count, m.name, m.srcPath)
+	}
+	if !found {
+		return fmt.Errorf("unknown storage type: %s", tp)
+	}
+	return nil
+}
+
+// migrateStorage copies all the objects kept in the local directory srcPath into dst
+func migrateStorage(dst storage.ObjectStorage, srcPath string) (int, error) {
+	src, err := storage.NewLocalStorage(srcPath)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+	err = src.IterateObjects(func(p string, obj storage.Object) error {
+		log.Trace("Migrating %s", p)
+		if _, err := dst.Save(p, obj); err != nil {
+			return fmt.Errorf("save %s: %v", p, err)
+		}
+		count++
+		return nil
+	})
+	return count, err
+}
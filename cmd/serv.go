@@ -17,6 +17,7 @@ import (
 	"time"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/pprof"
 	"code.gitea.io/gitea/modules/private"
@@ -231,6 +232,14 @@ func runServ(c *cli.Context) error {
 		verb = strings.Replace(verb, "-", " ", 1)
 	}
 
+	// The wire protocol requested by the client is received in GIT_PROTOCOL, OpenSSH
+	// only passes it on when sshd_config has AcceptEnv GIT_PROTOCOL.
+	if protocol, ok := os.LookupEnv(git.EnvProtocol); ok && (!setting.Git.EnableAutoGitWireProtocol || !git.IsValidProtocol(protocol)) {
+		if err = os.Unsetenv(git.EnvProtocol); err != nil {
+			fail("Internal error", "Failed to unset %s: %v", git.EnvProtocol, err)
+		}
+	}
+
 	var gitcmd *exec.Cmd
 	verbs := strings.Split(verb, " ")
 	if len(verbs) == 2 {
@@ -0,0 +1,91 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGoProxy(t *testing.T) {
+	prepareTestEnv(t)
+	repo := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+	modulePath := "localhost:3003/user2/repo1"
+	url := "/api/go/" + modulePath
+
+	var info struct {
+		Version string
+	}
+
+	t.Run("Untagged", func(t *testing.T) {
+		// the tag v1.1 of the repository is not a module version
+		resp := MakeRequest(t, NewRequest(t, "GET", url+"/@v/list"), http.StatusOK)
+		assert.Empty(t, resp.Body.String())
+
+		resp = MakeRequest(t, NewRequest(t, "GET", url+"/@latest"), http.StatusOK)
+		DecodeJSON(t, resp, &info)
+		assert.Regexp(t, `^v0\.0\.0-\d{14}-[0-9a-f]{12}$`, info.Version)
+		pseudo := info.Version
+
+		resp = MakeRequest(t, NewRequest(t, "GET", url+"/@v/master.info"), http.StatusOK)
+		DecodeJSON(t, resp, &info)
+		assert.Equal(t, pseudo, info.Version)
+
+		resp = MakeRequest(t, NewRequest(t, "GET", url+"/@v/"+pseudo+".mod"), http.StatusOK)
+		assert.Equal(t, "module "+modulePath+"\n", resp.Body.String())
+	})
+
+	t.Run("Tagged", func(t *testing.T) {
+		_, err := git.NewCommand("tag", "v1.0.0", "master").RunInDir(repo.RepoPath())
+		assert.NoError(t, err)
+
+		resp := MakeRequest(t, NewRequest(t, "GET", url+"/@v/list"), http.StatusOK)
+		assert.Equal(t, "v1.0.0\n", resp.Body.String())
+
+		resp = MakeRequest(t, NewRequest(t, "GET", url+"/@latest"), http.StatusOK)
+		DecodeJSON(t, resp, &info)
+		assert.Equal(t, "v1.0.0", info.Version)
+
+		resp = MakeRequest(t, NewRequest(t, "GET", url+"/@v/v1.0.0.zip"), http.StatusOK)
+		assert.Equal(t, "application/zip", resp.Header().Get("Content-Type"))
+		r, err := zip.NewReader(bytes.NewReader(resp.Body.Bytes()), int64(resp.Body.Len()))
+		assert.NoError(t, err)
+		if assert.Len(t, r.File, 1) {
+			assert.Equal(t, modulePath+"@v1.0.0/README.md", r.File[0].Name)
+		}
+
+		MakeRequest(t, NewRequest(t, "GET", url+"/@v/v1.0.1.info"), http.StatusNotFound)
+		MakeRequest(t, NewRequest(t, "GET", url+"/@v/v1.0.1.zip"), http.StatusNotFound)
+		MakeRequest(t, NewRequest(t, "GET", url+"/v2/@v/list"), http.StatusOK)
+	})
+
+	t.Run("Private", func(t *testing.T) {
+		privateURL := "/api/go/localhost:3003/user2/repo16/@latest"
+		MakeRequest(t, NewRequest(t, "GET", privateURL), http.StatusUnauthorized)
+		// the existence of the private repositories is not disclosed
+		MakeRequest(t, NewRequest(t, "GET", "/api/go/localhost:3003/user2/unknown/@latest"), http.StatusUnauthorized)
+
+		req := AddBasicAuthHeader(NewRequest(t, "GET", privateURL), "user4")
+		MakeRequest(t, req, http.StatusNotFound)
+
+		req = AddBasicAuthHeader(NewRequest(t, "GET", privateURL), "user2")
+		resp := MakeRequest(t, req, http.StatusOK)
+		DecodeJSON(t, resp, &info)
+		assert.NotEmpty(t, info.Version)
+	})
+
+	t.Run("NotHosted", func(t *testing.T) {
+		MakeRequest(t, NewRequest(t, "GET", "/api/go/example.com/user2/repo1/@latest"), http.StatusNotFound)
+		// the upper case letters must be escaped
+		MakeRequest(t, NewRequest(t, "GET", "/api/go/localhost:3003/User2/repo1/@v/list"), http.StatusNotFound)
+	})
+}
@@ -0,0 +1,66 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIOrgLabels(t *testing.T) {
+	prepareTestEnv(t)
+
+	session := loginUser(t, "user2")
+	token := getTokenForLoggedInUser(t, session)
+
+	req := NewRequestf(t, "GET", "/api/v1/orgs/user3/labels?token=%s", token)
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	var apiLabels []*api.Label
+	DecodeJSON(t, resp, &apiLabels)
+	assert.Len(t, apiLabels, models.GetCount(t, &models.Label{OrgID: 3}))
+
+	req = NewRequestWithJSON(t, "POST", "/api/v1/orgs/user3/labels?token="+token, &api.CreateLabelOption{
+		Name:  "orglabel",
+		Color: "#123456",
+	})
+	resp = session.MakeRequest(t, req, http.StatusCreated)
+	var apiLabel api.Label
+	DecodeJSON(t, resp, &apiLabel)
+	models.AssertExistsAndLoadBean(t, &models.Label{ID: apiLabel.ID, OrgID: 3, Name: "orglabel"})
+
+	req = NewRequestf(t, "DELETE", "/api/v1/orgs/user3/labels/%d?token=%s", apiLabel.ID, token)
+	session.MakeRequest(t, req, http.StatusNoContent)
+	models.AssertNotExistsBean(t, &models.Label{ID: apiLabel.ID})
+}
+
+func TestAPIAddIssueOrgLabels(t *testing.T) {
+	prepareTestEnv(t)
+
+	repo := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 3}).(*models.Repository)
+	issue := models.AssertExistsAndLoadBean(t, &models.Issue{RepoID: repo.ID}).(*models.Issue)
+	label := models.AssertExistsAndLoadBean(t, &models.Label{OrgID: repo.OwnerID}).(*models.Label)
+	owner := models.AssertExistsAndLoadBean(t, &models.User{ID: repo.OwnerID}).(*models.User)
+
+	session := loginUser(t, "user2")
+	token := getTokenForLoggedInUser(t, session)
+	urlStr := fmt.Sprintf("/api/v1/repos/%s/%s/issues/%d/labels?token=%s",
+		owner.Name, repo.Name, issue.Index, token)
+	req := NewRequestWithJSON(t, "PUT", urlStr, &api.IssueLabelsOption{
+		Labels: []int64{label.ID},
+	})
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	var apiLabels []*api.Label
+	DecodeJSON(t, resp, &apiLabels)
+	if assert.Len(t, apiLabels, 1) {
+		assert.EqualValues(t, label.ID, apiLabels[0].ID)
+	}
+	models.AssertExistsAndLoadBean(t, &models.IssueLabel{IssueID: issue.ID, LabelID: label.ID})
+}
@@ -0,0 +1,252 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func containerDigest(content []byte) string {
+	hash := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(hash[:])
+}
+
+func getContainerToken(t *testing.T, userName string) string {
+	req := NewRequest(t, "GET", "/v2/token")
+	if len(userName) > 0 {
+		req = AddBasicAuthHeader(req, userName)
+	}
+	resp := MakeRequest(t, req, http.StatusOK)
+
+	var token struct {
+		Token string `json:"token"`
+	}
+	DecodeJSON(t, resp, &token)
+	assert.NotEmpty(t, token.Token)
+	return "Bearer " + token.Token
+}
+
+func TestPackageContainer(t *testing.T) {
+	prepareTestEnv(t)
+	user := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+
+	config := []byte(`{"architecture":"amd64","os":"linux"}`)
+	layer := []byte("layer content")
+	configDigest, layerDigest := containerDigest(config), containerDigest(layer)
+	manifest := []byte(fmt.Sprintf(`{"schemaVersion":2,"mediaType":"application/vnd.docker.distribution.manifest.v2+json",`+
+		`"config":{"mediaType":"application/vnd.docker.container.image.v1+json","digest":"%s","size":%d},`+
+		`"layers":[{"mediaType":"application/vnd.docker.image.rootfs.diff.tar.gzip","digest":"%s","size":%d}]}`,
+		configDigest, len(config), layerDigest, len(layer)))
+	manifestDigest := containerDigest(manifest)
+
+	url := fmt.Sprintf("/v2/%s/test-image", user.Name)
+	anonymousToken := getContainerToken(t, "")
+	userToken := getContainerToken(t, user.Name)
+
+	t.Run("Authenticate", func(t *testing.T) {
+		resp := MakeRequest(t, NewRequest(t, "GET", "/v2"), http.StatusUnauthorized)
+		assert.True(t, strings.HasPrefix(resp.Header().Get("WWW-Authenticate"), "Bearer realm="))
+
+		req := NewRequest(t, "GET", "/v2")
+		req.Header.Set("Authorization", userToken)
+		MakeRequest(t, req, http.StatusOK)
+
+		req = NewRequest(t, "GET", "/v2")
+		req.Header.Set("Authorization", "Bearer invalid")
+		MakeRequest(t, req, http.StatusUnauthorized)
+	})
+
+	t.Run("UploadBlob", func(t *testing.T) {
+		req := NewRequestWithBody(t, "POST", url+"/blobs/uploads?digest="+configDigest, bytes.NewReader(config))
+		req.Header.Set("Authorization", anonymousToken)
+		MakeRequest(t, req, http.StatusUnauthorized)
+
+		req = NewRequestWithBody(t, "POST", url+"/blobs/uploads?digest="+configDigest, bytes.NewReader(config))
+		req.Header.Set("Authorization", getContainerToken(t, "user4"))
+		MakeRequest(t, req, http.StatusForbidden)
+
+		// monolithic upload
+		req = NewRequestWithBody(t, "POST", url+"/blobs/uploads?digest="+configDigest, bytes.NewReader(config))
+		req.Header.Set("Authorization", userToken)
+		resp := MakeRequest(t, req, http.StatusCreated)
+		assert.Equal(t, configDigest, resp.Header().Get("Docker-Content-Digest"))
+
+		// chunked upload
+		req = NewRequest(t, "POST", url+"/blobs/uploads")
+		req.Header.Set("Authorization", userToken)
+		resp = MakeRequest(t, req, http.StatusAccepted)
+		location := resp.Header().Get("Location")
+		assert.NotEmpty(t, location)
+
+		req = NewRequestWithBody(t, "PATCH", location, bytes.NewReader(layer[:5]))
+		req.Header.Set("Authorization", userToken)
+		resp = MakeRequest(t, req, http.StatusAccepted)
+		assert.Equal(t, "0-4", resp.Header().Get("Range"))
+
+		// the upload can not be continued for another image
+		req = NewRequestWithBody(t, "PATCH", strings.Replace(location, "/test-image/", "/other-image/", 1), bytes.NewReader(layer[5:]))
+		req.Header.Set("Authorization", userToken)
+		MakeRequest(t, req, http.StatusNotFound)
+
+		req = NewRequestWithBody(t, "PATCH", location, bytes.NewReader(layer[5:]))
+		req.Header.Set("Authorization", userToken)
+		req.Header.Set("Content-Range", "0-7")
+		MakeRequest(t, req, http.StatusRequestedRangeNotSatisfiable)
+
+		req = NewRequestWithBody(t, "PUT", location+"?digest="+configDigest, bytes.NewReader(layer[5:]))
+		req.Header.Set("Authorization", userToken)
+		MakeRequest(t, req, http.StatusBadRequest)
+
+		req = NewRequest(t, "POST", url+"/blobs/uploads")
+		req.Header.Set("Authorization", userToken)
+		location = MakeRequest(t, req, http.StatusAccepted).Header().Get("Location")
+		for _, chunk := range [][]byte{layer[:5], layer[5:]} {
+			req = NewRequestWithBody(t, "PATCH", location, bytes.NewReader(chunk))
+			req.Header.Set("Authorization", userToken)
+			MakeRequest(t, req, http.StatusAccepted)
+		}
+		req = NewRequest(t, "PUT", location+"?digest="+layerDigest)
+		req.Header.Set("Authorization", userToken)
+		MakeRequest(t, req, http.StatusCreated)
+
+		req = NewRequest(t, "HEAD", url+"/blobs/"+layerDigest)
+		req.Header.Set("Authorization", userToken)
+		resp = MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, fmt.Sprint(len(layer)), resp.Header().Get("Content-Length"))
+
+		// the blobs are not visible from the other images
+		req = NewRequest(t, "HEAD", fmt.Sprintf("/v2/%s/other-image/blobs/%s", user.Name, layerDigest))
+		req.Header.Set("Authorization", userToken)
+		MakeRequest(t, req, http.StatusNotFound)
+	})
+
+	t.Run("UploadManifest", func(t *testing.T) {
+		unknown := bytes.Replace(manifest, []byte(layerDigest), []byte(containerDigest([]byte("unknown"))), 1)
+		req := NewRequestWithBody(t, "PUT", url+"/manifests/latest", bytes.NewReader(unknown))
+		req.Header.Set("Authorization", userToken)
+		req.Header.Set("Content-Type", "application/vnd.docker.distribution.manifest.v2+json")
+		resp := MakeRequest(t, req, http.StatusBadRequest)
+		assert.Contains(t, resp.Body.String(), "MANIFEST_BLOB_UNKNOWN")
+
+		for _, tag := range []string{"latest", "v1"} {
+			req = NewRequestWithBody(t, "PUT", url+"/manifests/"+tag, bytes.NewReader(manifest))
+			req.Header.Set("Authorization", userToken)
+			req.Header.Set("Content-Type", "application/vnd.docker.distribution.manifest.v2+json")
+			resp = MakeRequest(t, req, http.StatusCreated)
+			assert.Equal(t, manifestDigest, resp.Header().Get("Docker-Content-Digest"))
+		}
+
+		p := models.AssertExistsAndLoadBean(t, &models.Package{OwnerID: user.ID, Type: models.PackageContainer, LowerName: "test-image"}).(*models.Package)
+		models.AssertNotExistsBean(t, &models.PackageVersion{PackageID: p.ID, Version: models.ContainerUploadVersion})
+	})
+
+	t.Run("GetManifest", func(t *testing.T) {
+		for _, reference := range []string{"latest", manifestDigest} {
+			req := NewRequest(t, "GET", url+"/manifests/"+reference)
+			req.Header.Set("Authorization", anonymousToken)
+			resp := MakeRequest(t, req, http.StatusOK)
+			assert.Equal(t, "application/vnd.docker.distribution.manifest.v2+json", resp.Header().Get("Content-Type"))
+			assert.Equal(t, manifestDigest, resp.Header().Get("Docker-Content-Digest"))
+			assert.Equal(t, manifest, resp.Body.Bytes())
+		}
+
+		req := NewRequest(t, "GET", url+"/blobs/"+layerDigest)
+		req.Header.Set("Authorization", anonymousToken)
+		resp := MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, layer, resp.Body.Bytes())
+
+		req = NewRequest(t, "GET", url+"/manifests/unknown")
+		req.Header.Set("Authorization", anonymousToken)
+		MakeRequest(t, req, http.StatusNotFound)
+	})
+
+	t.Run("ListTags", func(t *testing.T) {
+		var tags struct {
+			Name string   `json:"name"`
+			Tags []string `json:"tags"`
+		}
+		req := NewRequest(t, "GET", url+"/tags/list")
+		req.Header.Set("Authorization", anonymousToken)
+		DecodeJSON(t, MakeRequest(t, req, http.StatusOK), &tags)
+		assert.Equal(t, user.Name+"/test-image", tags.Name)
+		assert.Equal(t, []string{"latest", "v1"}, tags.Tags)
+
+		req = NewRequest(t, "GET", url+"/tags/list?n=1&last=latest")
+		req.Header.Set("Authorization", anonymousToken)
+		DecodeJSON(t, MakeRequest(t, req, http.StatusOK), &tags)
+		assert.Equal(t, []string{"v1"}, tags.Tags)
+	})
+
+	t.Run("RestrictedToken", func(t *testing.T) {
+		req := NewRequestWithJSON(t, "POST", "/api/v1/users/user2/tokens", &api.CreateAccessTokenOption{
+			Name:         "test-image-restricted",
+			Repositories: []string{"user2/repo1"},
+		})
+		req = AddBasicAuthHeader(req, user.Name)
+		var restricted api.AccessToken
+		DecodeJSON(t, MakeRequest(t, req, http.StatusCreated), &restricted)
+
+		// the bearer token issued for a restricted token is read only
+		req = NewRequest(t, "GET", "/v2/token")
+		req.SetBasicAuth(user.Name, restricted.Token)
+		var token struct {
+			Token string `json:"token"`
+		}
+		DecodeJSON(t, MakeRequest(t, req, http.StatusOK), &token)
+		restrictedToken := "Bearer " + token.Token
+
+		req = NewRequest(t, "GET", url+"/manifests/latest")
+		req.Header.Set("Authorization", restrictedToken)
+		MakeRequest(t, req, http.StatusOK)
+
+		req = NewRequestWithBody(t, "POST", url+"/blobs/uploads?digest="+configDigest, bytes.NewReader(config))
+		req.Header.Set("Authorization", restrictedToken)
+		MakeRequest(t, req, http.StatusForbidden)
+
+		req = NewRequest(t, "DELETE", url+"/manifests/v1")
+		req.Header.Set("Authorization", restrictedToken)
+		MakeRequest(t, req, http.StatusForbidden)
+
+		req = NewRequestWithBody(t, "POST", url+"/blobs/uploads?digest="+configDigest, bytes.NewReader(config))
+		req.SetBasicAuth(user.Name, restricted.Token)
+		MakeRequest(t, req, http.StatusForbidden)
+	})
+
+	t.Run("View", func(t *testing.T) {
+		session := loginUser(t, user.Name)
+		req := NewRequest(t, "GET", fmt.Sprintf("/%s/-/packages/container/test-image", user.Name))
+		resp := session.MakeRequest(t, req, http.StatusOK)
+		assert.Contains(t, resp.Body.String(), "docker pull")
+	})
+
+	t.Run("DeleteManifest", func(t *testing.T) {
+		req := NewRequest(t, "DELETE", url+"/manifests/v1")
+		req.Header.Set("Authorization", userToken)
+		MakeRequest(t, req, http.StatusAccepted)
+
+		req = NewRequest(t, "GET", url+"/manifests/v1")
+		req.Header.Set("Authorization", userToken)
+		MakeRequest(t, req, http.StatusNotFound)
+
+		req = NewRequest(t, "DELETE", url+"/manifests/"+manifestDigest)
+		req.Header.Set("Authorization", userToken)
+		MakeRequest(t, req, http.StatusAccepted)
+
+		req = NewRequest(t, "GET", url+"/manifests/latest")
+		req.Header.Set("Authorization", userToken)
+		MakeRequest(t, req, http.StatusNotFound)
+	})
+}
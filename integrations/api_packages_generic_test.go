@@ -0,0 +1,154 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackageGeneric(t *testing.T) {
+	prepareTestEnv(t)
+	user := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+
+	content := []byte{1, 2, 3, 4}
+	url := fmt.Sprintf("/api/packages/%s/generic/test-package/1.2.3/file.bin", user.Name)
+
+	t.Run("Upload", func(t *testing.T) {
+		req := NewRequestWithBody(t, "PUT", url, bytes.NewReader(content))
+		MakeRequest(t, req, http.StatusUnauthorized)
+
+		req = NewRequestWithBody(t, "PUT", url, bytes.NewReader(content))
+		req = AddBasicAuthHeader(req, "user4")
+		MakeRequest(t, req, http.StatusForbidden)
+
+		req = NewRequestWithBody(t, "PUT", url, bytes.NewReader(content))
+		req = AddBasicAuthHeader(req, user.Name)
+		MakeRequest(t, req, http.StatusCreated)
+
+		p := models.AssertExistsAndLoadBean(t, &models.Package{OwnerID: user.ID, LowerName: "test-package"}).(*models.Package)
+		v := models.AssertExistsAndLoadBean(t, &models.PackageVersion{PackageID: p.ID, Version: "1.2.3"}).(*models.PackageVersion)
+		f := models.AssertExistsAndLoadBean(t, &models.PackageFile{VersionID: v.ID, Name: "file.bin"}).(*models.PackageFile)
+		models.AssertExistsAndLoadBean(t, &models.PackageBlob{ID: f.BlobID, Size: int64(len(content))})
+
+		// a file cannot be replaced
+		req = NewRequestWithBody(t, "PUT", url, bytes.NewReader(content))
+		req = AddBasicAuthHeader(req, user.Name)
+		MakeRequest(t, req, http.StatusConflict)
+
+		req = NewRequestWithBody(t, "PUT", fmt.Sprintf("/api/packages/%s/generic/test-package/1.2.3/-invalid", user.Name), bytes.NewReader(content))
+		req = AddBasicAuthHeader(req, user.Name)
+		MakeRequest(t, req, http.StatusBadRequest)
+	})
+
+	t.Run("Deduplicate", func(t *testing.T) {
+		req := NewRequestWithBody(t, "PUT", fmt.Sprintf("/api/packages/%s/generic/test-package/1.2.4/file.bin", user.Name), bytes.NewReader(content))
+		req = AddBasicAuthHeader(req, user.Name)
+		MakeRequest(t, req, http.StatusCreated)
+
+		models.AssertCount(t, &models.PackageBlob{Size: int64(len(content))}, 1)
+	})
+
+	t.Run("Download", func(t *testing.T) {
+		req := NewRequest(t, "GET", url)
+		resp := MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, content, resp.Body.Bytes())
+
+		p := models.AssertExistsAndLoadBean(t, &models.Package{OwnerID: user.ID, LowerName: "test-package"}).(*models.Package)
+		models.AssertExistsAndLoadBean(t, &models.PackageVersion{PackageID: p.ID, Version: "1.2.3", DownloadCount: 1})
+
+		req = NewRequest(t, "GET", fmt.Sprintf("/api/packages/%s/generic/test-package/1.2.3/missing.bin", user.Name))
+		MakeRequest(t, req, http.StatusNotFound)
+	})
+
+	t.Run("TokenScope", func(t *testing.T) {
+		createToken := func(scope string) string {
+			req := NewRequestWithJSON(t, "POST", "/api/v1/users/user2/tokens", &api.CreateAccessTokenOption{
+				Name:   "test-package-" + scope,
+				Scopes: []string{scope},
+			})
+			req = AddBasicAuthHeader(req, user.Name)
+			resp := MakeRequest(t, req, http.StatusCreated)
+			var token api.AccessToken
+			DecodeJSON(t, resp, &token)
+			return token.Token
+		}
+
+		tokenURL := fmt.Sprintf("/api/packages/%s/generic/test-package/1.2.3/token.bin", user.Name)
+
+		req := NewRequestWithBody(t, "PUT", tokenURL, bytes.NewReader(content))
+		req.SetBasicAuth(user.Name, createToken("repo:write"))
+		MakeRequest(t, req, http.StatusForbidden)
+
+		req = NewRequestWithBody(t, "PUT", tokenURL, bytes.NewReader(content))
+		req.SetBasicAuth(user.Name, createToken("package"))
+		MakeRequest(t, req, http.StatusCreated)
+
+		// a token restricted to some repositories can only read the packages
+		req = NewRequestWithJSON(t, "POST", "/api/v1/users/user2/tokens", &api.CreateAccessTokenOption{
+			Name:         "test-package-restricted",
+			Repositories: []string{"user2/repo1"},
+		})
+		req = AddBasicAuthHeader(req, user.Name)
+		var restricted api.AccessToken
+		DecodeJSON(t, MakeRequest(t, req, http.StatusCreated), &restricted)
+
+		req = NewRequestWithBody(t, "PUT", fmt.Sprintf("/api/packages/%s/generic/test-package/1.2.3/restricted.bin", user.Name), bytes.NewReader(content))
+		req.SetBasicAuth(user.Name, restricted.Token)
+		MakeRequest(t, req, http.StatusForbidden)
+
+		req = NewRequest(t, "DELETE", tokenURL)
+		req.SetBasicAuth(user.Name, restricted.Token)
+		MakeRequest(t, req, http.StatusForbidden)
+
+		req = NewRequest(t, "GET", tokenURL)
+		req.SetBasicAuth(user.Name, restricted.Token)
+		MakeRequest(t, req, http.StatusOK)
+	})
+
+	t.Run("UI", func(t *testing.T) {
+		req := NewRequestf(t, "GET", "/%s?tab=packages", user.Name)
+		resp := MakeRequest(t, req, http.StatusOK)
+		assert.Contains(t, resp.Body.String(), fmt.Sprintf("/%s/-/packages/generic/test-package", user.Name))
+
+		req = NewRequestf(t, "GET", "/%s/-/packages/generic/test-package", user.Name)
+		resp = MakeRequest(t, req, http.StatusOK)
+		assert.Contains(t, resp.Body.String(), "file.bin")
+
+		req = NewRequestf(t, "GET", "/%s/-/packages/generic/missing", user.Name)
+		MakeRequest(t, req, http.StatusNotFound)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		req := NewRequest(t, "DELETE", url)
+		req = AddBasicAuthHeader(req, "user4")
+		MakeRequest(t, req, http.StatusForbidden)
+
+		req = NewRequest(t, "DELETE", url)
+		req = AddBasicAuthHeader(req, user.Name)
+		MakeRequest(t, req, http.StatusNoContent)
+
+		req = NewRequest(t, "GET", url)
+		MakeRequest(t, req, http.StatusNotFound)
+
+		// the content is still referenced by the version 1.2.4
+		models.AssertCount(t, &models.PackageBlob{Size: int64(len(content))}, 1)
+
+		req = NewRequest(t, "DELETE", fmt.Sprintf("/api/packages/%s/generic/test-package/1.2.4", user.Name))
+		req = AddBasicAuthHeader(req, user.Name)
+		MakeRequest(t, req, http.StatusNoContent)
+
+		req = NewRequest(t, "DELETE", fmt.Sprintf("/api/packages/%s/generic/test-package/1.2.4", user.Name))
+		req = AddBasicAuthHeader(req, user.Name)
+		MakeRequest(t, req, http.StatusNotFound)
+	})
+}
@@ -297,6 +297,16 @@ func TestAPIRepoMigrate(t *testing.T) {
 			RepoName:  testCase.repoName,
 		})
 		session.MakeRequest(t, req, testCase.expectedStatus)
+
+		if testCase.expectedStatus == http.StatusCreated {
+			// the data is migrated in the background, the status of the migration can be polled
+			owner := models.AssertExistsAndLoadBean(t, &models.User{ID: testCase.userID}).(*models.User)
+			req = NewRequestf(t, "GET", "/api/v1/repos/%s/%s/migration?token=%s", owner.Name, testCase.repoName, token)
+			resp := session.MakeRequest(t, req, http.StatusOK)
+			var task api.RepoTask
+			DecodeJSON(t, resp, &task)
+			assert.NotEmpty(t, task.Status)
+		}
 	}
 }
 
@@ -10,6 +10,8 @@ import (
 
 	"code.gitea.io/gitea/models"
 	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
 )
 
 // TestAPICreateAndDeleteToken tests that token that was just created can be deleted
@@ -48,3 +50,125 @@ func TestAPIDeleteMissingToken(t *testing.T) {
 	req = AddBasicAuthHeader(req, user.Name)
 	MakeRequest(t, req, http.StatusNotFound)
 }
+
+// TestAPITokenScopes ensures that the scopes and repository restrictions of a token are enforced
+func TestAPITokenScopes(t *testing.T) {
+	prepareTestEnv(t)
+	user := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+
+	req := NewRequestWithJSON(t, "POST", "/api/v1/users/user2/tokens", &api.CreateAccessTokenOption{
+		Name:         "test-scoped-key",
+		Scopes:       []string{"repo:read"},
+		Repositories: []string{"user2/repo1"},
+	})
+	req = AddBasicAuthHeader(req, user.Name)
+	resp := MakeRequest(t, req, http.StatusCreated)
+
+	var token api.AccessToken
+	DecodeJSON(t, resp, &token)
+	assert.Equal(t, []string{"repo:read"}, token.Scopes)
+	assert.Equal(t, []string{"user2/repo1"}, token.Repositories)
+
+	req = NewRequestf(t, "GET", "/api/v1/repos/user2/repo1?token=%s", token.Token)
+	MakeRequest(t, req, http.StatusOK)
+	req = NewRequestf(t, "GET", "/api/v1/repos/user2/repo2?token=%s", token.Token)
+	MakeRequest(t, req, http.StatusForbidden)
+	req = NewRequestf(t, "GET", "/api/v1/repositories/2?token=%s", token.Token)
+	MakeRequest(t, req, http.StatusForbidden)
+	req = NewRequestWithJSON(t, "PATCH", "/api/v1/repos/user2/repo1?token="+token.Token, &api.EditRepoOption{})
+	MakeRequest(t, req, http.StatusForbidden)
+	req = NewRequestf(t, "GET", "/api/v1/user?token=%s", token.Token)
+	MakeRequest(t, req, http.StatusForbidden)
+	req = NewRequestf(t, "GET", "/api/v1/users/user2?token=%s", token.Token)
+	MakeRequest(t, req, http.StatusForbidden)
+	req = NewRequestWithJSON(t, "POST", "/api/v1/user/repos?token="+token.Token, &api.CreateRepoOption{Name: "repo-from-token"})
+	MakeRequest(t, req, http.StatusForbidden)
+
+	// the repositories are listed only if the token can access them
+	for _, url := range []string{"/api/v1/users/user2/repos", "/api/v1/user/repos"} {
+		req = NewRequestf(t, "GET", "%s?token=%s", url, token.Token)
+		resp = MakeRequest(t, req, http.StatusOK)
+		var repos []*api.Repository
+		DecodeJSON(t, resp, &repos)
+		if assert.Len(t, repos, 1, url) {
+			assert.Equal(t, "user2/repo1", repos[0].FullName)
+		}
+	}
+	req = NewRequestf(t, "GET", "/api/v1/repos/search?uid=2&token=%s", token.Token)
+	resp = MakeRequest(t, req, http.StatusOK)
+	var results api.SearchResults
+	DecodeJSON(t, resp, &results)
+	if assert.Len(t, results.Data, 1) {
+		assert.Equal(t, "user2/repo1", results.Data[0].FullName)
+	}
+
+	// the web routes don't check the scopes, so they don't accept the token
+	req = NewRequest(t, "GET", "/user/settings")
+	req.SetBasicAuth(token.Token, "x-oauth-basic")
+	resp = MakeRequest(t, req, http.StatusFound)
+	assert.Contains(t, resp.HeaderMap.Get("Location"), "/user/login")
+
+	// a restricted token cannot create other tokens
+	req = NewRequestWithJSON(t, "POST", "/api/v1/users/user2/tokens", map[string]string{
+		"name": "test-key-from-token",
+	})
+	req.SetBasicAuth(token.Token, "x-oauth-basic")
+	MakeRequest(t, req, http.StatusForbidden)
+
+	// nor access other repositories over git
+	req = NewRequest(t, "GET", "/user2/repo2.git/info/refs?service=git-upload-pack")
+	req.SetBasicAuth(user.Name, token.Token)
+	MakeRequest(t, req, http.StatusForbidden)
+
+	req = NewRequestWithJSON(t, "POST", "/api/v1/users/user2/tokens", &api.CreateAccessTokenOption{
+		Name:   "test-invalid-key",
+		Scopes: []string{"repo"},
+	})
+	req = AddBasicAuthHeader(req, user.Name)
+	MakeRequest(t, req, http.StatusUnprocessableEntity)
+}
+
+func TestAPITokenRestrictedProjects(t *testing.T) {
+	prepareTestEnv(t)
+	user := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+	createToken := func(name, repo string) string {
+		req := NewRequestWithJSON(t, "POST", "/api/v1/users/user2/tokens", &api.CreateAccessTokenOption{
+			Name:         name,
+			Scopes:       []string{"repo:read", "repo:write"},
+			Repositories: []string{repo},
+		})
+		req = AddBasicAuthHeader(req, user.Name)
+		var token api.AccessToken
+		DecodeJSON(t, MakeRequest(t, req, http.StatusCreated), &token)
+		return token.Token
+	}
+
+	repo := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+	units := []models.RepoUnit{{RepoID: repo.ID, Type: models.UnitTypeProjects}}
+	for _, tp := range models.AllRepoUnitTypes {
+		if unit, err := repo.GetUnit(tp); err == nil {
+			units = append(units, *unit)
+		}
+	}
+	assert.NoError(t, models.UpdateRepositoryUnits(repo, units))
+
+	// the project of user2/repo1 cannot be accessed with a token restricted to user2/repo2
+	token := createToken("test-repo2-key", "user2/repo2")
+	req := NewRequestf(t, "GET", "/api/v1/projects/1?token=%s", token)
+	MakeRequest(t, req, http.StatusForbidden)
+	req = NewRequestWithJSON(t, "PATCH", "/api/v1/projects/1?token="+token, &api.EditProjectOption{})
+	MakeRequest(t, req, http.StatusForbidden)
+	req = NewRequestf(t, "DELETE", "/api/v1/projects/1?token=%s", token)
+	MakeRequest(t, req, http.StatusForbidden)
+	models.AssertExistsAndLoadBean(t, &models.Project{ID: 1})
+
+	token = createToken("test-repo1-key", "user2/repo1")
+	req = NewRequestf(t, "GET", "/api/v1/projects/1?token=%s", token)
+	MakeRequest(t, req, http.StatusOK)
+	// nor the organization projects
+	req = NewRequestf(t, "GET", "/api/v1/projects/3?token=%s", token)
+	MakeRequest(t, req, http.StatusForbidden)
+	// nor the issues of the other repositories
+	req = NewRequestWithJSON(t, "POST", "/api/v1/projects/1/cards?token="+token, &api.MoveProjectCardOption{IssueID: 6})
+	MakeRequest(t, req, http.StatusForbidden)
+}
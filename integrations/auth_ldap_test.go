@@ -94,10 +94,10 @@ func getLDAPServerHost() string {
 	return host
 }
 
-func addAuthSourceLDAP(t *testing.T, sshKeyAttribute string) {
+func addAuthSourceLDAP(t *testing.T, sshKeyAttribute string, groupTeamMap ...string) {
 	session := loginUser(t, "user1")
 	csrf := GetCSRF(t, session, "/admin/auths/new")
-	req := NewRequestWithValues(t, "POST", "/admin/auths/new", map[string]string{
+	values := map[string]string{
 		"_csrf":                    csrf,
 		"type":                     "2",
 		"name":                     "ldap",
@@ -115,7 +115,16 @@ func addAuthSourceLDAP(t *testing.T, sshKeyAttribute string) {
 		"attribute_ssh_public_key": sshKeyAttribute,
 		"is_sync_enabled":          "on",
 		"is_active":                "on",
-	})
+	}
+	if len(groupTeamMap) > 0 {
+		values["groups_enabled"] = "on"
+		values["group_dn"] = "ou=people,dc=planetexpress,dc=com"
+		values["group_member_uid"] = "member"
+		values["user_uid"] = "dn"
+		values["group_team_map"] = groupTeamMap[0]
+		values["group_team_map_removal"] = "on"
+	}
+	req := NewRequestWithValues(t, "POST", "/admin/auths/new", values)
 	session.MakeRequest(t, req, http.StatusFound)
 }
 
@@ -230,3 +239,33 @@ func TestLDAPUserSSHKeySync(t *testing.T) {
 		assert.ElementsMatch(t, u.SSHKeys, syncedKeys)
 	}
 }
+
+func TestLDAPGroupTeamSync(t *testing.T) {
+	if skipLDAPTests() {
+		t.Skip()
+		return
+	}
+	prepareTestEnv(t)
+	addAuthSourceLDAP(t, "", `{"cn=ship_crew,ou=people,dc=planetexpress,dc=com": {"user3": ["team1"]}, "cn=admin_staff,ou=people,dc=planetexpress,dc=com": {"user3": ["test_team"]}}`)
+	models.SyncExternalUsers()
+
+	shipCrew := map[string]bool{"fry": true, "leela": true, "bender": true}
+	for _, u := range gitLDAPUsers {
+		user := models.AssertExistsAndLoadBean(t, &models.User{Name: u.UserName}).(*models.User)
+		isMember, err := models.IsTeamMember(3, 2, user.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, shipCrew[u.UserName], isMember, "%s in team1", u.UserName)
+		isMember, err = models.IsTeamMember(3, 7, user.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, u.IsAdmin, isMember, "%s in test_team", u.UserName)
+	}
+
+	// the memberships are also synced at sign in
+	fry := models.AssertExistsAndLoadBean(t, &models.User{Name: "fry"}).(*models.User)
+	team := models.AssertExistsAndLoadBean(t, &models.Team{ID: 2}).(*models.Team)
+	assert.NoError(t, models.RemoveTeamMember(team, fry.ID))
+	loginUserWithPassword(t, "fry", "fry")
+	isMember, err := models.IsTeamMember(3, 2, fry.ID)
+	assert.NoError(t, err)
+	assert.True(t, isMember)
+}
@@ -0,0 +1,94 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAGitPullRequest(t *testing.T) {
+	onGiteaRun(t, testAGitPullRequest)
+}
+
+func testAGitPullRequest(t *testing.T, u *url.URL) {
+	repo := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+	u.Path = "user2/repo1.git"
+	u.User = url.UserPassword("user2", userPassword)
+
+	dstPath, err := ioutil.TempDir("", "repo1")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dstPath)
+	t.Run("Clone", doGitClone(dstPath, u))
+
+	var pr *models.PullRequest
+	t.Run("Create", func(t *testing.T) {
+		doAddChangesToCheckout(dstPath, "README.md")(t)
+		_, err := git.NewCommand("push", "-o", "title=AGit title", "-o", "description=AGit description",
+			"origin", "HEAD:refs/for/master/agit-topic").RunInDir(dstPath)
+		assert.NoError(t, err)
+
+		pr = models.AssertExistsAndLoadBean(t, &models.PullRequest{
+			BaseRepoID: repo.ID,
+			HeadRepoID: repo.ID,
+			HeadBranch: "agit-topic",
+			BaseBranch: "master",
+			Flow:       models.PullRequestFlowAGit,
+		}).(*models.PullRequest)
+		issue := models.AssertExistsAndLoadBean(t, &models.Issue{ID: pr.IssueID}).(*models.Issue)
+		assert.Equal(t, "AGit title", issue.Title)
+		assert.Equal(t, "AGit description", issue.Content)
+
+		headCommitID, err := git.NewCommand("rev-parse", "HEAD").RunInDir(dstPath)
+		assert.NoError(t, err)
+		prCommitID, err := pr.GetHeadCommitID()
+		assert.NoError(t, err)
+		assert.Equal(t, strings.TrimSpace(headCommitID), prCommitID)
+
+		// neither the pushed ref nor a branch are left in the repository
+		refs, err := git.NewCommand("for-each-ref", "--format=%(refname)").RunInDir(repo.RepoPath())
+		assert.NoError(t, err)
+		assert.NotContains(t, refs, git.ForPrefix)
+		assert.NotContains(t, refs, "agit-topic")
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		doAddChangesToCheckout(dstPath, "README.md")(t)
+		_, err := git.NewCommand("push", "origin", "HEAD:refs/for/master/agit-topic").RunInDir(dstPath)
+		assert.NoError(t, err)
+
+		models.AssertCount(t, &models.PullRequest{HeadBranch: "agit-topic"}, 1)
+		headCommitID, err := git.NewCommand("rev-parse", "HEAD").RunInDir(dstPath)
+		assert.NoError(t, err)
+		prCommitID, err := pr.GetHeadCommitID()
+		assert.NoError(t, err)
+		assert.Equal(t, strings.TrimSpace(headCommitID), prCommitID)
+	})
+
+	t.Run("TopicMissing", func(t *testing.T) {
+		_, err := git.NewCommand("push", "origin", "HEAD:refs/for/master").RunInDir(dstPath)
+		assert.Error(t, err)
+		_, err = git.NewCommand("push", "origin", "HEAD:refs/for/unknown/agit-topic").RunInDir(dstPath)
+		assert.Error(t, err)
+	})
+
+	t.Run("Merge", func(t *testing.T) {
+		session := loginUser(t, "user2")
+		assert.NoError(t, pr.LoadIssue())
+		testPullMerge(t, session, "user2", "repo1", strconv.FormatInt(pr.Issue.Index, 10), models.MergeStyleMerge)
+
+		pr = models.AssertExistsAndLoadBean(t, &models.PullRequest{ID: pr.ID}).(*models.PullRequest)
+		assert.True(t, pr.HasMerged)
+	})
+}
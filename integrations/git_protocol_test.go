@@ -0,0 +1,69 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"code.gitea.io/gitea/modules/git"
+
+	"github.com/Unknwon/com"
+	"github.com/mcuadros/go-version"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGitProtocol(t *testing.T) {
+	gitVersion, err := git.BinVersion()
+	assert.NoError(t, err)
+	if version.Compare(gitVersion, "2.22", "<") {
+		t.Skip("the partial clones are allowed when git version >= 2.22")
+	}
+	onGiteaRun(t, testGitProtocol)
+}
+
+func testGitProtocol(t *testing.T, u *url.URL) {
+	u.Path = "user2/repo1.git"
+	t.Run("HTTP", doGitPartialClone(u))
+
+	ctx := NewAPITestContext(t, "user2", "repo1")
+	keyname := "git-protocol-test"
+	withKeyFile(t, keyname, func(keyFile string) {
+		t.Run("CreateUserKey", doAPICreateUserKey(ctx, keyname, keyFile))
+		t.Run("SSH", doGitPartialClone(createSSHUrl(ctx.GitPath(), u)))
+	})
+}
+
+// doGitPartialClone clones the repository without its blobs, the wire protocol version 2
+// must be negotiated and the blobs must be fetched when they are checked out.
+func doGitPartialClone(u *url.URL) func(*testing.T) {
+	return func(t *testing.T) {
+		dstPath, err := ioutil.TempDir("", "repo1")
+		assert.NoError(t, err)
+		defer os.RemoveAll(dstPath)
+		tracePath := filepath.Join(dstPath, "packet.trace")
+		clonePath := filepath.Join(dstPath, "repo1")
+
+		env := append(os.Environ(), "GIT_TRACE_PACKET="+tracePath)
+		_, err = git.NewCommand("-c", "protocol.version=2", "clone", "--filter=blob:none", "--no-checkout", u.String(), clonePath).
+			RunInDirWithEnv(dstPath, env)
+		assert.NoError(t, err)
+
+		trace, err := ioutil.ReadFile(tracePath)
+		assert.NoError(t, err)
+		assert.Contains(t, string(trace), "version 2")
+
+		objects, err := git.NewCommand("rev-list", "--objects", "--all", "--missing=print").RunInDir(clonePath)
+		assert.NoError(t, err)
+		assert.Contains(t, objects, "?", "the blobs are left out")
+
+		_, err = git.NewCommand("checkout", "master").RunInDir(clonePath)
+		assert.NoError(t, err)
+		assert.True(t, com.IsExist(filepath.Join(clonePath, "README.md")))
+	}
+}
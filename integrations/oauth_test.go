@@ -6,10 +6,13 @@ package integrations
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
 
+	"code.gitea.io/gitea/modules/auth/oauth2"
 	"code.gitea.io/gitea/modules/setting"
 
+	"github.com/dgrijalva/jwt-go"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -241,3 +244,133 @@ func TestRefreshTokenInvalidation(t *testing.T) {
 	MakeRequest(t, refreshReq, 200)
 	MakeRequest(t, refreshReq, 400)
 }
+
+func TestOpenIDConnectTokenExchange(t *testing.T) {
+	prepareTestEnv(t)
+	req := NewRequestWithValues(t, "POST", "/login/oauth/access_token", map[string]string{
+		"grant_type":    "authorization_code",
+		"client_id":     "da7da3ba-9a13-4167-856f-3899de0b0138",
+		"client_secret": "4MK8Na6R55smdCY0WuCCumZ6hjRPnGY5saWVRHHjJiA=",
+		"redirect_uri":  "a",
+		"code":          "authcode-openid",
+		"code_verifier": "N1Zo9-8Rfwhkt68r1r29ty8YwIraXR8eh_1Qwxg7yQXsonBt",
+	})
+	resp := MakeRequest(t, req, 200)
+	type response struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		Scope        string `json:"scope"`
+		IDToken      string `json:"id_token"`
+	}
+	parsed := new(response)
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), parsed))
+	assert.Equal(t, "openid profile email", parsed.Scope)
+
+	// the id_token is verified by the published key
+	req = NewRequest(t, "GET", "/login/oauth/keys")
+	resp = MakeRequest(t, req, 200)
+	var keys struct {
+		Keys []map[string]string `json:"keys"`
+	}
+	DecodeJSON(t, resp, &keys)
+	assert.Len(t, keys.Keys, 1)
+	assert.Equal(t, "RS256", keys.Keys[0]["alg"])
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(parsed.IDToken, claims, func(token *jwt.Token) (interface{}, error) {
+		assert.Equal(t, keys.Keys[0]["kid"], token.Header["kid"])
+		return oauth2.DefaultSigningKey.PublicKey(), nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, strings.TrimSuffix(setting.AppURL, "/"), claims["iss"])
+	assert.Equal(t, "1", claims["sub"])
+	assert.Equal(t, "da7da3ba-9a13-4167-856f-3899de0b0138", claims["aud"])
+	assert.Equal(t, "n-0S6_WzA2Mj", claims["nonce"])
+	assert.Equal(t, "user1", claims["preferred_username"])
+	assert.Equal(t, "user1@example.com", claims["email"])
+	assert.NotContains(t, claims, "groups")
+
+	// userinfo endpoint
+	req = NewRequest(t, "GET", "/login/oauth/userinfo")
+	req.Header.Add("Authorization", "Bearer "+parsed.AccessToken)
+	resp = MakeRequest(t, req, 200)
+	var info map[string]interface{}
+	DecodeJSON(t, resp, &info)
+	assert.Equal(t, "1", info["sub"])
+	assert.Equal(t, "user1", info["preferred_username"])
+	assert.Equal(t, "user1@example.com", info["email"])
+
+	req = NewRequest(t, "GET", "/login/oauth/userinfo")
+	req.Header.Add("Authorization", "Bearer invalid")
+	MakeRequest(t, req, 401)
+
+	// the refreshed tokens keep the scope
+	req = NewRequestWithValues(t, "POST", "/login/oauth/access_token", map[string]string{
+		"grant_type":    "refresh_token",
+		"client_id":     "da7da3ba-9a13-4167-856f-3899de0b0138",
+		"client_secret": "4MK8Na6R55smdCY0WuCCumZ6hjRPnGY5saWVRHHjJiA=",
+		"refresh_token": parsed.RefreshToken,
+	})
+	resp = MakeRequest(t, req, 200)
+	refreshed := new(response)
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), refreshed))
+	assert.Equal(t, "openid profile email", refreshed.Scope)
+	assert.NotEmpty(t, refreshed.IDToken)
+
+	// an access token can not be used as refresh token
+	req = NewRequestWithValues(t, "POST", "/login/oauth/access_token", map[string]string{
+		"grant_type":    "refresh_token",
+		"client_id":     "da7da3ba-9a13-4167-856f-3899de0b0138",
+		"client_secret": "4MK8Na6R55smdCY0WuCCumZ6hjRPnGY5saWVRHHjJiA=",
+		"refresh_token": refreshed.AccessToken,
+	})
+	MakeRequest(t, req, 400)
+}
+
+func TestUserInfoWithoutOpenIDScope(t *testing.T) {
+	prepareTestEnv(t)
+	req := NewRequestWithValues(t, "POST", "/login/oauth/access_token", map[string]string{
+		"grant_type":    "authorization_code",
+		"client_id":     "da7da3ba-9a13-4167-856f-3899de0b0138",
+		"client_secret": "4MK8Na6R55smdCY0WuCCumZ6hjRPnGY5saWVRHHjJiA=",
+		"redirect_uri":  "a",
+		"code":          "authcode",
+		"code_verifier": "N1Zo9-8Rfwhkt68r1r29ty8YwIraXR8eh_1Qwxg7yQXsonBt",
+	})
+	resp := MakeRequest(t, req, 200)
+	parsed := make(map[string]interface{})
+	DecodeJSON(t, resp, &parsed)
+	assert.NotContains(t, parsed, "id_token")
+
+	req = NewRequest(t, "GET", "/login/oauth/userinfo")
+	req.Header.Add("Authorization", "Bearer "+parsed["access_token"].(string))
+	MakeRequest(t, req, 403)
+}
+
+func TestAuthorizeWithScope(t *testing.T) {
+	prepareTestEnv(t)
+	ctx := loginUser(t, "user1")
+	// the existing grant of user1 does not include the openid scope
+	req := NewRequest(t, "GET", defaultAuthorize+"&scope=openid%20email")
+	resp := ctx.MakeRequest(t, req, 200)
+	htmlDoc := NewHTMLParser(t, resp.Body)
+	htmlDoc.AssertElement(t, "#authorize-app", true)
+
+	req = NewRequest(t, "GET", defaultAuthorize+"&scope=openid%20address")
+	resp = ctx.MakeRequest(t, req, 302)
+	u, err := resp.Result().Location()
+	assert.NoError(t, err)
+	assert.Equal(t, "invalid_scope", u.Query().Get("error"))
+}
+
+func TestOpenIDConnectDiscovery(t *testing.T) {
+	prepareTestEnv(t)
+	req := NewRequest(t, "GET", "/.well-known/openid-configuration")
+	resp := MakeRequest(t, req, 200)
+	var discovery map[string]interface{}
+	DecodeJSON(t, resp, &discovery)
+	assert.Equal(t, strings.TrimSuffix(setting.AppURL, "/"), discovery["issuer"])
+	assert.Equal(t, setting.AppURL+"login/oauth/keys", discovery["jwks_uri"])
+	assert.Equal(t, setting.AppURL+"login/oauth/userinfo", discovery["userinfo_endpoint"])
+	assert.Contains(t, discovery["scopes_supported"], "openid")
+}
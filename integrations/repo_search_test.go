@@ -48,4 +48,12 @@ func TestSearchRepo(t *testing.T) {
 
 	filenames := resultFilenames(t, NewHTMLParser(t, resp.Body))
 	assert.EqualValues(t, []string{"README.md"}, filenames)
+
+	req = NewRequestf(t, "GET", "/user2/repo1/search?q=Description&path=readme")
+	resp = MakeRequest(t, req, http.StatusOK)
+	assert.EqualValues(t, []string{"README.md"}, resultFilenames(t, NewHTMLParser(t, resp.Body)))
+
+	req = NewRequestf(t, "GET", "/user2/repo1/search?q=Description&l=go")
+	resp = MakeRequest(t, req, http.StatusOK)
+	assert.Empty(t, resultFilenames(t, NewHTMLParser(t, resp.Body)))
 }
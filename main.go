@@ -66,6 +66,7 @@ arguments - which can alternatively be run by running the subcommand web.`
 		cmd.CmdAdmin,
 		cmd.CmdGenerate,
 		cmd.CmdMigrate,
+		cmd.CmdMigrateStorage,
 		cmd.CmdKeys,
 		cmd.CmdConvert,
 	}
@@ -0,0 +1,755 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/modules/actions"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/generate"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
+
+	gouuid "github.com/satori/go.uuid"
+)
+
+// ActionStatus represents the status of a workflow run or of a job
+type ActionStatus int
+
+// enumerates all the statuses of runs and jobs
+const (
+	ActionStatusWaiting   ActionStatus = iota // 0
+	ActionStatusRunning                       // 1
+	ActionStatusSuccess                       // 2
+	ActionStatusFailure                       // 3
+	ActionStatusCancelled                     // 4
+)
+
+var actionStatusNames = map[ActionStatus]string{
+	ActionStatusWaiting:   "waiting",
+	ActionStatusRunning:   "running",
+	ActionStatusSuccess:   "success",
+	ActionStatusFailure:   "failure",
+	ActionStatusCancelled: "cancelled",
+}
+
+// String returns the name of the status
+func (s ActionStatus) String() string {
+	return actionStatusNames[s]
+}
+
+// ActionStatusFromString returns the status with the given name
+func ActionStatusFromString(name string) (ActionStatus, bool) {
+	for s, n := range actionStatusNames {
+		if n == name {
+			return s, true
+		}
+	}
+	return 0, false
+}
+
+// IsDone returns true if the run or job has finished
+func (s ActionStatus) IsDone() bool {
+	return s == ActionStatusSuccess || s == ActionStatusFailure || s == ActionStatusCancelled
+}
+
+// CommitStatusState returns the state of the commit status reporting the status
+func (s ActionStatus) CommitStatusState() CommitStatusState {
+	switch s {
+	case ActionStatusSuccess:
+		return CommitStatusSuccess
+	case ActionStatusFailure:
+		return CommitStatusFailure
+	case ActionStatusCancelled:
+		return CommitStatusError
+	}
+	return CommitStatusPending
+}
+
+// ActionRun represents a run of a workflow triggered by an event
+type ActionRun struct {
+	ID            int64       `xorm:"pk autoincr"`
+	RepoID        int64       `xorm:"INDEX"`
+	Repo          *Repository `xorm:"-"`
+	WorkflowID    string      // name of the workflow file
+	Title         string
+	TriggerUserID int64
+	TriggerUser   *User `xorm:"-"`
+	Ref           string
+	CommitSHA     string `xorm:"VARCHAR(40)"`
+	Event         actions.Event
+	// PullRequestID is the pull request which triggered the run, if any
+	PullRequestID int64
+	Status        ActionStatus `xorm:"INDEX"`
+	// NeedApproval is true while the jobs of the run wait for a maintainer to approve them
+	NeedApproval bool
+	ApprovedBy   int64
+
+	StartedUnix util.TimeStamp
+	StoppedUnix util.TimeStamp
+	CreatedUnix util.TimeStamp `xorm:"created"`
+	UpdatedUnix util.TimeStamp `xorm:"updated"`
+}
+
+// LoadAttributes loads the repository and the user who triggered the run
+func (run *ActionRun) LoadAttributes() error {
+	return run.loadAttributes(x)
+}
+
+func (run *ActionRun) loadAttributes(e Engine) (err error) {
+	if run.Repo == nil {
+		if run.Repo, err = getRepositoryByID(e, run.RepoID); err != nil {
+			return err
+		}
+	}
+	if run.TriggerUser == nil {
+		if run.TriggerUser, err = getUserByID(e, run.TriggerUserID); err != nil {
+			if !IsErrUserNotExist(err) {
+				return err
+			}
+			run.TriggerUser = NewGhostUser()
+		}
+	}
+	return nil
+}
+
+// Link returns the link to the run page
+func (run *ActionRun) Link() string {
+	if run.Repo == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s/actions/runs/%d", run.Repo.Link(), run.ID)
+}
+
+// RefName returns the short name of the ref the run was triggered on
+func (run *ActionRun) RefName() string {
+	return git.RefEndName(run.Ref)
+}
+
+// Duration returns the time the run has been running for
+func (run *ActionRun) Duration() time.Duration {
+	return actionDuration(run.StartedUnix, run.StoppedUnix)
+}
+
+func actionDuration(started, stopped util.TimeStamp) time.Duration {
+	if started == 0 {
+		return 0
+	}
+	if stopped == 0 {
+		stopped = util.TimeStampNow()
+	}
+	return time.Duration(stopped-started) * time.Second
+}
+
+// ActionRunJob represents a job of a workflow run
+type ActionRunJob struct {
+	ID       int64      `xorm:"pk autoincr"`
+	RunID    int64      `xorm:"INDEX"`
+	Run      *ActionRun `xorm:"-"`
+	RepoID   int64      `xorm:"INDEX"`
+	JobID    string     // id of the job in the workflow file
+	Name     string
+	RunsOn   []string          `xorm:"TEXT JSON"`
+	Env      map[string]string `xorm:"TEXT JSON"`
+	Steps    []*actions.Step   `xorm:"TEXT JSON"`
+	RunnerID int64             `xorm:"INDEX"`
+	Status   ActionStatus      `xorm:"INDEX"`
+	// Token authenticates the clones of the repository while the job is running
+	Token          string `xorm:"-"`
+	TokenHash      string
+	TokenSalt      string
+	TokenLastEight string `xorm:"token_last_eight INDEX"`
+	// LogLength is the number of lines of log received from the runner
+	LogLength int64
+
+	StartedUnix util.TimeStamp
+	StoppedUnix util.TimeStamp
+	CreatedUnix util.TimeStamp `xorm:"created"`
+	UpdatedUnix util.TimeStamp `xorm:"INDEX updated"`
+}
+
+// LoadRun loads the run of the job along with its attributes
+func (job *ActionRunJob) LoadRun() error {
+	return job.loadRun(x)
+}
+
+func (job *ActionRunJob) loadRun(e Engine) (err error) {
+	if job.Run == nil {
+		if job.Run, err = getActionRunByID(e, job.RunID); err != nil {
+			return err
+		}
+	}
+	return job.Run.loadAttributes(e)
+}
+
+// Duration returns the time the job has been running for
+func (job *ActionRunJob) Duration() time.Duration {
+	return actionDuration(job.StartedUnix, job.StoppedUnix)
+}
+
+// CommitStatusContext returns the context of the commit status reporting the job
+func (job *ActionRunJob) CommitStatusContext() string {
+	return fmt.Sprintf("%s / %s (%s)", job.Run.Title, job.Name, job.Run.Event)
+}
+
+// ActionRunJobLog represents a chunk of consecutive lines of the log of a job
+type ActionRunJobLog struct {
+	ID    int64 `xorm:"pk autoincr"`
+	JobID int64 `xorm:"INDEX"`
+	// Offset is the index of the first line of the chunk
+	Offset  int64
+	Content string `xorm:"LONGTEXT"`
+}
+
+func getActionRunByID(e Engine, id int64) (*ActionRun, error) {
+	run := &ActionRun{}
+	has, err := e.ID(id).Get(run)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrActionRunNotExist{ID: id}
+	}
+	return run, nil
+}
+
+// GetActionRunByRepoAndID returns the run of the repository with the given id
+func GetActionRunByRepoAndID(repoID, id int64) (*ActionRun, error) {
+	run, err := getActionRunByID(x, id)
+	if err != nil {
+		return nil, err
+	} else if run.RepoID != repoID {
+		return nil, ErrActionRunNotExist{ID: id}
+	}
+	return run, nil
+}
+
+// FindActionRunOptions represents the options to list the runs of a repository
+type FindActionRunOptions struct {
+	Page     int
+	PageSize int
+	RepoID   int64
+	Status   ActionStatus
+	// FilterStatus is true to only list the runs having Status
+	FilterStatus bool
+}
+
+// FindActionRuns returns the runs of a repository, most recent first, and their total number
+func FindActionRuns(opts FindActionRunOptions) ([]*ActionRun, int64, error) {
+	sess := x.Where("repo_id = ?", opts.RepoID)
+	if opts.FilterStatus {
+		sess.And("status = ?", opts.Status)
+	}
+	count, err := sess.Count(new(ActionRun))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sess = x.Where("repo_id = ?", opts.RepoID)
+	if opts.FilterStatus {
+		sess.And("status = ?", opts.Status)
+	}
+	runs := make([]*ActionRun, 0, opts.PageSize)
+	if opts.Page > 0 && opts.PageSize > 0 {
+		sess.Limit(opts.PageSize, (opts.Page-1)*opts.PageSize)
+	}
+	if err = sess.Desc("id").Find(&runs); err != nil {
+		return nil, 0, err
+	}
+	for _, run := range runs {
+		if err = run.LoadAttributes(); err != nil {
+			return nil, 0, err
+		}
+	}
+	return runs, count, nil
+}
+
+// GetActionRunJobs returns the jobs of the run
+func GetActionRunJobs(runID int64) ([]*ActionRunJob, error) {
+	return getActionRunJobs(x, runID)
+}
+
+func getActionRunJobs(e Engine, runID int64) ([]*ActionRunJob, error) {
+	jobs := make([]*ActionRunJob, 0, 5)
+	return jobs, e.Where("run_id = ?", runID).Asc("id").Find(&jobs)
+}
+
+// GetActionRunJobByID returns the job with the given id
+func GetActionRunJobByID(id int64) (*ActionRunJob, error) {
+	job := &ActionRunJob{}
+	has, err := x.ID(id).Get(job)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrActionRunJobNotExist{ID: id}
+	}
+	return job, nil
+}
+
+// GetActionRunJobLog returns the full log of the job
+func GetActionRunJobLog(jobID int64) (string, error) {
+	chunks := make([]*ActionRunJobLog, 0, 10)
+	if err := x.Where("job_id = ?", jobID).Asc("offset").Find(&chunks); err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	for _, chunk := range chunks {
+		sb.WriteString(chunk.Content)
+	}
+	return sb.String(), nil
+}
+
+// AppendActionRunJobLog appends lines to the log of the job. offset is the
+// index of the first line, lines already received are ignored so that runners
+// can safely resend them. It returns the number of lines received so far.
+func AppendActionRunJobLog(job *ActionRunJob, offset int64, lines []string) (int64, error) {
+	if offset > job.LogLength {
+		return job.LogLength, ErrActionRunJobLogOffset{Offset: offset, Expected: job.LogLength}
+	}
+	skip := job.LogLength - offset
+	if skip >= int64(len(lines)) {
+		return job.LogLength, nil
+	}
+	lines = lines[skip:]
+
+	var sb strings.Builder
+	for _, line := range lines {
+		sb.WriteString(strings.TrimRight(line, "\n"))
+		sb.WriteByte('\n')
+	}
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return 0, err
+	}
+	if _, err := sess.Insert(&ActionRunJobLog{
+		JobID:   job.ID,
+		Offset:  job.LogLength,
+		Content: sb.String(),
+	}); err != nil {
+		return 0, err
+	}
+	job.LogLength += int64(len(lines))
+	if _, err := sess.ID(job.ID).Cols("log_length").Update(job); err != nil {
+		return 0, err
+	}
+	return job.LogLength, sess.Commit()
+}
+
+// actionWorkflow is a workflow file read from a commit
+type actionWorkflow struct {
+	id       string
+	workflow *actions.Workflow
+}
+
+func readActionWorkflows(commit *git.Commit) ([]*actionWorkflow, error) {
+	tree, err := commit.SubTree(actions.WorkflowsDir)
+	if err != nil {
+		if git.IsErrNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	entries, err := tree.ListEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	workflows := make([]*actionWorkflow, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsRegular() || !actions.IsWorkflowFile(entry.Name()) {
+			continue
+		}
+		reader, err := entry.Blob().DataAsync()
+		if err != nil {
+			return nil, err
+		}
+		content, err := ioutil.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			return nil, err
+		}
+		w, err := actions.ParseWorkflow(entry.Name(), content)
+		if err != nil {
+			log.Warn("Ignoring invalid workflow %s of commit %s: %v", path.Join(actions.WorkflowsDir, entry.Name()), commit.ID, err)
+			continue
+		}
+		workflows = append(workflows, &actionWorkflow{id: entry.Name(), workflow: w})
+	}
+	return workflows, nil
+}
+
+// ActionTriggerOptions represents an event triggering the workflows of a repository
+type ActionTriggerOptions struct {
+	Repo      *Repository
+	Doer      *User
+	Event     actions.Event
+	Ref       string
+	CommitSHA string
+	// FilterRef is the ref the workflows are filtered on, the base branch for pull requests
+	FilterRef     string
+	PullRequestID int64
+	NeedApproval  bool
+}
+
+// TriggerActionWorkflows reads the workflows of the commit and creates a run
+// with waiting jobs for every workflow triggered by the event.
+func TriggerActionWorkflows(opts ActionTriggerOptions) error {
+	if !setting.Actions.Enabled || !opts.Repo.UnitEnabled(UnitTypeActions) {
+		return nil
+	}
+	if opts.FilterRef == "" {
+		opts.FilterRef = opts.Ref
+	}
+
+	gitRepo, err := git.OpenRepository(opts.Repo.RepoPath())
+	if err != nil {
+		return fmt.Errorf("OpenRepository: %v", err)
+	}
+	commit, err := gitRepo.GetCommit(opts.CommitSHA)
+	if err != nil {
+		return fmt.Errorf("GetCommit: %v", err)
+	}
+	workflows, err := readActionWorkflows(commit)
+	if err != nil {
+		return fmt.Errorf("readActionWorkflows: %v", err)
+	}
+
+	for _, w := range workflows {
+		if !w.workflow.Match(opts.Event, opts.FilterRef) {
+			continue
+		}
+		if err := createActionRun(opts, w); err != nil {
+			return fmt.Errorf("createActionRun [%s]: %v", w.id, err)
+		}
+	}
+	return nil
+}
+
+// TriggerPullRequestActionWorkflows triggers the workflows of the base
+// repository of the pull request on its head commit. The workflows of a pull
+// request from a fork run the code of the fork, they wait for a maintainer to
+// approve them unless the poster can write to the base repository.
+func TriggerPullRequestActionWorkflows(doer *User, pr *PullRequest) error {
+	if !setting.Actions.Enabled {
+		return nil
+	}
+	if err := pr.GetBaseRepo(); err != nil {
+		return fmt.Errorf("GetBaseRepo: %v", err)
+	}
+	sha, err := pr.GetHeadCommitID()
+	if err != nil {
+		return fmt.Errorf("GetHeadCommitID: %v", err)
+	}
+	var needApproval bool
+	if pr.HeadRepoID != pr.BaseRepoID {
+		perm, err := GetUserRepoPermission(pr.BaseRepo, doer)
+		if err != nil {
+			return fmt.Errorf("GetUserRepoPermission: %v", err)
+		}
+		needApproval = !perm.CanWrite(UnitTypeCode)
+	}
+	return TriggerActionWorkflows(ActionTriggerOptions{
+		Repo:          pr.BaseRepo,
+		Doer:          doer,
+		Event:         actions.EventPullRequest,
+		Ref:           pr.GetGitRefName(),
+		CommitSHA:     sha,
+		FilterRef:     git.BranchPrefix + pr.BaseBranch,
+		PullRequestID: pr.ID,
+		NeedApproval:  needApproval,
+	})
+}
+
+func createActionRun(opts ActionTriggerOptions, w *actionWorkflow) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	run := &ActionRun{
+		RepoID:        opts.Repo.ID,
+		Repo:          opts.Repo,
+		WorkflowID:    w.id,
+		Title:         w.workflow.Name,
+		TriggerUserID: opts.Doer.ID,
+		TriggerUser:   opts.Doer,
+		Ref:           opts.Ref,
+		CommitSHA:     opts.CommitSHA,
+		Event:         opts.Event,
+		PullRequestID: opts.PullRequestID,
+		Status:        ActionStatusWaiting,
+		NeedApproval:  opts.NeedApproval,
+	}
+	if _, err := sess.Insert(run); err != nil {
+		return err
+	}
+
+	jobs := make([]*ActionRunJob, 0, len(w.workflow.Jobs))
+	for _, j := range w.workflow.Jobs {
+		env := make(map[string]string, len(w.workflow.Env)+len(j.Env))
+		for k, v := range w.workflow.Env {
+			env[k] = v
+		}
+		for k, v := range j.Env {
+			env[k] = v
+		}
+		job := &ActionRunJob{
+			RunID:  run.ID,
+			Run:    run,
+			RepoID: run.RepoID,
+			JobID:  j.ID,
+			Name:   j.Name,
+			RunsOn: j.RunsOn,
+			Env:    env,
+			Steps:  j.Steps,
+			Status: ActionStatusWaiting,
+		}
+		if _, err := sess.Insert(job); err != nil {
+			return err
+		}
+		jobs = append(jobs, job)
+	}
+	if err := sess.Commit(); err != nil {
+		return err
+	}
+
+	for _, job := range jobs {
+		if err := createActionRunJobCommitStatus(job); err != nil {
+			log.Error("createActionRunJobCommitStatus [job_id: %d]: %v", job.ID, err)
+		}
+	}
+	return nil
+}
+
+func createActionRunJobCommitStatus(job *ActionRunJob) error {
+	if err := job.LoadRun(); err != nil {
+		return err
+	}
+	return NewCommitStatus(NewCommitStatusOptions{
+		Repo:    job.Run.Repo,
+		Creator: job.Run.TriggerUser,
+		SHA:     job.Run.CommitSHA,
+		CommitStatus: &CommitStatus{
+			State:       job.Status.CommitStatusState(),
+			TargetURL:   setting.AppURL + strings.TrimPrefix(job.Run.Link(), setting.AppSubURL+"/") + fmt.Sprintf("#job-%d", job.ID),
+			Description: fmt.Sprintf("Job %s", job.Status),
+			Context:     job.CommitStatusContext(),
+		},
+	})
+}
+
+// ApproveActionRun lets the runners claim the jobs of a run waiting for approval
+func ApproveActionRun(run *ActionRun, doer *User) error {
+	run.NeedApproval = false
+	run.ApprovedBy = doer.ID
+	_, err := x.ID(run.ID).And("need_approval = ?", true).Cols("need_approval", "approved_by").Update(run)
+	return err
+}
+
+// ClaimActionRunJob assigns the oldest waiting job the runner is able to run
+// to the runner. It returns nil if there is no such job.
+func ClaimActionRunJob(runner *ActionRunner) (*ActionRunJob, error) {
+	sess := x.Where("status = ?", ActionStatusWaiting).
+		And("run_id IN (SELECT id FROM action_run WHERE need_approval = ?)", false)
+	if runner.RepoID != 0 {
+		sess.And("repo_id = ?", runner.RepoID)
+	}
+	jobs := make([]*ActionRunJob, 0, 10)
+	if err := sess.Asc("id").Find(&jobs); err != nil {
+		return nil, err
+	}
+
+	for _, job := range jobs {
+		if !runner.CanRunJob(job) {
+			continue
+		}
+		salt, err := generate.GetRandomString(10)
+		if err != nil {
+			return nil, err
+		}
+		job.RunnerID = runner.ID
+		job.Status = ActionStatusRunning
+		job.StartedUnix = util.TimeStampNow()
+		job.Token = base.EncodeSha1(gouuid.NewV4().String())
+		job.TokenSalt = salt
+		job.TokenHash = hashToken(job.Token, job.TokenSalt)
+		job.TokenLastEight = job.Token[len(job.Token)-8:]
+		// Only the first runner changing the status of the job gets it
+		affected, err := x.ID(job.ID).And("status = ?", ActionStatusWaiting).
+			Cols("runner_id", "status", "started_unix", "token_hash", "token_salt", "token_last_eight").Update(job)
+		if err != nil {
+			return nil, err
+		} else if affected == 0 {
+			continue
+		}
+
+		if err := updateActionRunStatus(job); err != nil {
+			return nil, err
+		}
+		if err := createActionRunJobCommitStatus(job); err != nil {
+			log.Error("createActionRunJobCommitStatus [job_id: %d]: %v", job.ID, err)
+		}
+		return job, nil
+	}
+	return nil, nil
+}
+
+// GetRunningActionRunJobByToken returns the running job authenticated by the given token
+func GetRunningActionRunJobByToken(token string) (*ActionRunJob, error) {
+	if len(token) < 8 {
+		return nil, ErrActionRunJobNotExist{}
+	}
+	var jobs []*ActionRunJob
+	if err := x.Where("token_last_eight = ? AND status = ?", token[len(token)-8:], ActionStatusRunning).Find(&jobs); err != nil {
+		return nil, err
+	}
+	for _, job := range jobs {
+		if subtle.ConstantTimeCompare([]byte(job.TokenHash), []byte(hashToken(token, job.TokenSalt))) == 1 {
+			return job, nil
+		}
+	}
+	return nil, ErrActionRunJobNotExist{}
+}
+
+// UpdateActionRunJobStatus changes the status of the job and reports it as a commit status.
+// It fails with ErrActionRunJobDone if the job has been finished in the meantime.
+func UpdateActionRunJobStatus(job *ActionRunJob, status ActionStatus) error {
+	if job.Status == status {
+		return nil
+	}
+	if job.Status.IsDone() {
+		return ErrActionRunJobDone{ID: job.ID}
+	}
+
+	now := util.TimeStampNow()
+	if status.IsDone() {
+		job.StoppedUnix = now
+	}
+	if status == ActionStatusRunning && job.StartedUnix == 0 {
+		job.StartedUnix = now
+	}
+	job.Status = status
+	// a job cancelled while its runner reports its result must not be resurrected
+	affected, err := x.ID(job.ID).In("status", ActionStatusWaiting, ActionStatusRunning).
+		Cols("status", "started_unix", "stopped_unix").Update(job)
+	if err != nil {
+		return err
+	} else if affected == 0 {
+		return ErrActionRunJobDone{ID: job.ID}
+	}
+
+	if err := updateActionRunStatus(job); err != nil {
+		return err
+	}
+	return createActionRunJobCommitStatus(job)
+}
+
+// updateActionRunStatus updates the status of the run of the job from the statuses of all its jobs
+func updateActionRunStatus(job *ActionRunJob) error {
+	if err := job.LoadRun(); err != nil {
+		return err
+	}
+	jobs, err := getActionRunJobs(x, job.RunID)
+	if err != nil {
+		return err
+	}
+
+	run := job.Run
+	status := aggregateActionStatus(jobs)
+	if status == run.Status {
+		return nil
+	}
+	now := util.TimeStampNow()
+	if status != ActionStatusWaiting && run.StartedUnix == 0 {
+		run.StartedUnix = now
+	}
+	if status.IsDone() {
+		run.StoppedUnix = now
+	}
+	run.Status = status
+	_, err = x.ID(run.ID).Cols("status", "started_unix", "stopped_unix").Update(run)
+	return err
+}
+
+func aggregateActionStatus(jobs []*ActionRunJob) ActionStatus {
+	var waiting, done, failed, cancelled int
+	for _, job := range jobs {
+		switch job.Status {
+		case ActionStatusWaiting:
+			waiting++
+		case ActionStatusFailure:
+			failed++
+		case ActionStatusCancelled:
+			cancelled++
+		}
+		if job.Status.IsDone() {
+			done++
+		}
+	}
+	switch {
+	case waiting == len(jobs):
+		return ActionStatusWaiting
+	case done < len(jobs):
+		return ActionStatusRunning
+	case failed > 0:
+		return ActionStatusFailure
+	case cancelled > 0:
+		return ActionStatusCancelled
+	}
+	return ActionStatusSuccess
+}
+
+// CancelActionRun cancels all the jobs of the run which are not done yet
+func CancelActionRun(run *ActionRun) error {
+	jobs, err := GetActionRunJobs(run.ID)
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		if job.Status.IsDone() {
+			continue
+		}
+		job.Run = run
+		if err = UpdateActionRunJobStatus(job, ActionStatusCancelled); err != nil && !IsErrActionRunJobDone(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// StopZombieActionRunJobs marks the running jobs which have not been updated
+// by their runner for too long as failed.
+func StopZombieActionRunJobs() {
+	log.Trace("Doing: StopZombieActionRunJobs")
+
+	jobs := make([]*ActionRunJob, 0, 10)
+	deadline := util.TimeStampNow().AddDuration(-setting.Actions.ZombieTimeout)
+	if err := x.Where("status = ? AND updated_unix < ?", ActionStatusRunning, deadline).Find(&jobs); err != nil {
+		log.Error("StopZombieActionRunJobs: %v", err)
+		return
+	}
+	for _, job := range jobs {
+		log.Trace("StopZombieActionRunJobs: job %d has not been updated since %s", job.ID, job.UpdatedUnix.FormatLong())
+		if err := UpdateActionRunJobStatus(job, ActionStatusFailure); err != nil {
+			log.Error("UpdateActionRunJobStatus [job_id: %d]: %v", job.ID, err)
+		}
+	}
+}
+
+// TouchActionRunJob records that the runner of the job is still working on it
+func TouchActionRunJob(job *ActionRunJob) error {
+	_, err := x.ID(job.ID).Cols("updated_unix").Update(job)
+	return err
+}
@@ -0,0 +1,153 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/modules/actions"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testActionWorkflow = `
+on: push
+jobs:
+  test:
+    runs-on: linux
+    steps:
+      - run: make test
+  lint:
+    runs-on: [linux, docker]
+    steps:
+      - run: make lint
+`
+
+func TestActionRun(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	repo := AssertExistsAndLoadBean(t, &Repository{ID: 1}).(*Repository)
+	doer := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	w, err := actions.ParseWorkflow("ci.yml", []byte(testActionWorkflow))
+	assert.NoError(t, err)
+
+	const sha = "65f1bf27bc3bf70f64657658635e66094edbcb4d"
+	assert.NoError(t, createActionRun(ActionTriggerOptions{
+		Repo:      repo,
+		Doer:      doer,
+		Event:     actions.EventPush,
+		Ref:       "refs/heads/master",
+		CommitSHA: sha,
+	}, &actionWorkflow{id: "ci.yml", workflow: w}))
+
+	run := AssertExistsAndLoadBean(t, &ActionRun{RepoID: repo.ID, WorkflowID: "ci.yml"}).(*ActionRun)
+	assert.Equal(t, ActionStatusWaiting, run.Status)
+	jobs, err := GetActionRunJobs(run.ID)
+	assert.NoError(t, err)
+	assert.Len(t, jobs, 2)
+	AssertExistsAndLoadBean(t, &CommitStatus{RepoID: repo.ID, SHA: sha, Context: "ci / test (push)", State: CommitStatusPending})
+
+	token, err := GetActionRunnerToken(repo.ID)
+	assert.NoError(t, err)
+	_, err = RegisterActionRunner("invalid", "runner", nil)
+	assert.True(t, IsErrActionRunnerTokenNotExist(err))
+	runner, err := RegisterActionRunner(token.Token, "runner", []string{"linux"})
+	assert.NoError(t, err)
+	registered, err := GetActionRunnerByToken(runner.Token)
+	assert.NoError(t, err)
+	assert.EqualValues(t, runner.ID, registered.ID)
+
+	// The runner does not have the labels of the lint job
+	job, err := ClaimActionRunJob(runner)
+	assert.NoError(t, err)
+	if assert.NotNil(t, job) {
+		assert.Equal(t, "test", job.JobID)
+		assert.Equal(t, ActionStatusRunning, job.Status)
+	}
+	next, err := ClaimActionRunJob(runner)
+	assert.NoError(t, err)
+	assert.Nil(t, next)
+	authenticated, err := GetRunningActionRunJobByToken(job.Token)
+	assert.NoError(t, err)
+	assert.EqualValues(t, job.ID, authenticated.ID)
+	run = AssertExistsAndLoadBean(t, &ActionRun{ID: run.ID}).(*ActionRun)
+	assert.Equal(t, ActionStatusRunning, run.Status)
+
+	ack, err := AppendActionRunJobLog(job, 0, []string{"line 1", "line 2"})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, ack)
+	ack, err = AppendActionRunJobLog(job, 1, []string{"line 2", "line 3"})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, ack)
+	_, err = AppendActionRunJobLog(job, 5, []string{"line 6"})
+	assert.True(t, IsErrActionRunJobLogOffset(err))
+	content, err := GetActionRunJobLog(job.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "line 1\nline 2\nline 3\n", content)
+
+	assert.NoError(t, UpdateActionRunJobStatus(job, ActionStatusSuccess))
+	assert.True(t, IsErrActionRunJobDone(UpdateActionRunJobStatus(job, ActionStatusFailure)))
+	AssertExistsAndLoadBean(t, &CommitStatus{RepoID: repo.ID, SHA: sha, Context: "ci / test (push)", State: CommitStatusSuccess})
+	_, err = GetRunningActionRunJobByToken(job.Token)
+	assert.True(t, IsErrActionRunJobNotExist(err))
+
+	lint := AssertExistsAndLoadBean(t, &ActionRunJob{RunID: run.ID, JobID: "lint"}).(*ActionRunJob)
+	assert.NoError(t, CancelActionRun(run))
+	run = AssertExistsAndLoadBean(t, &ActionRun{ID: run.ID}).(*ActionRun)
+	assert.Equal(t, ActionStatusCancelled, run.Status)
+	AssertExistsAndLoadBean(t, &CommitStatus{RepoID: repo.ID, SHA: sha, Context: "ci / lint (push)", State: CommitStatusError})
+
+	// a result reported after the cancellation does not resurrect the job
+	assert.True(t, IsErrActionRunJobDone(UpdateActionRunJobStatus(lint, ActionStatusSuccess)))
+	AssertExistsAndLoadBean(t, &ActionRunJob{ID: lint.ID, Status: ActionStatusCancelled})
+}
+
+func TestApproveActionRun(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	repo := AssertExistsAndLoadBean(t, &Repository{ID: 1}).(*Repository)
+	doer := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	w, err := actions.ParseWorkflow("ci.yml", []byte(testActionWorkflow))
+	assert.NoError(t, err)
+	assert.NoError(t, createActionRun(ActionTriggerOptions{
+		Repo:         repo,
+		Doer:         doer,
+		Event:        actions.EventPullRequest,
+		Ref:          "refs/pull/2/head",
+		CommitSHA:    "65f1bf27bc3bf70f64657658635e66094edbcb4d",
+		NeedApproval: true,
+	}, &actionWorkflow{id: "ci.yml", workflow: w}))
+	run := AssertExistsAndLoadBean(t, &ActionRun{RepoID: repo.ID, WorkflowID: "ci.yml"}).(*ActionRun)
+	assert.True(t, run.NeedApproval)
+
+	token, err := GetActionRunnerToken(repo.ID)
+	assert.NoError(t, err)
+	runner, err := RegisterActionRunner(token.Token, "runner", []string{"linux"})
+	assert.NoError(t, err)
+	job, err := ClaimActionRunJob(runner)
+	assert.NoError(t, err)
+	assert.Nil(t, job)
+
+	assert.NoError(t, ApproveActionRun(run, doer))
+	AssertExistsAndLoadBean(t, &ActionRun{ID: run.ID, ApprovedBy: doer.ID}, Cond("need_approval = ?", false))
+	job, err = ClaimActionRunJob(runner)
+	assert.NoError(t, err)
+	assert.NotNil(t, job)
+}
+
+func TestAggregateActionStatus(t *testing.T) {
+	jobs := func(statuses ...ActionStatus) []*ActionRunJob {
+		res := make([]*ActionRunJob, 0, len(statuses))
+		for _, s := range statuses {
+			res = append(res, &ActionRunJob{Status: s})
+		}
+		return res
+	}
+	assert.Equal(t, ActionStatusWaiting, aggregateActionStatus(jobs(ActionStatusWaiting, ActionStatusWaiting)))
+	assert.Equal(t, ActionStatusRunning, aggregateActionStatus(jobs(ActionStatusSuccess, ActionStatusWaiting)))
+	assert.Equal(t, ActionStatusFailure, aggregateActionStatus(jobs(ActionStatusFailure, ActionStatusCancelled)))
+	assert.Equal(t, ActionStatusCancelled, aggregateActionStatus(jobs(ActionStatusSuccess, ActionStatusCancelled)))
+	assert.Equal(t, ActionStatusSuccess, aggregateActionStatus(jobs(ActionStatusSuccess, ActionStatusSuccess)))
+}
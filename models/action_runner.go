@@ -0,0 +1,197 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"crypto/subtle"
+	"time"
+
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/generate"
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/Unknwon/com"
+	gouuid "github.com/satori/go.uuid"
+)
+
+// ActionRunner represents a self-hosted runner executing the jobs of workflows
+type ActionRunner struct {
+	ID   int64  `xorm:"pk autoincr"`
+	UUID string `xorm:"uuid UNIQUE"`
+	Name string
+	// RepoID is zero for runners available to all the repositories
+	RepoID int64       `xorm:"INDEX"`
+	Repo   *Repository `xorm:"-"`
+	Labels []string    `xorm:"TEXT JSON"`
+
+	Token          string `xorm:"-"`
+	TokenHash      string `xorm:"UNIQUE"` // sha256 of token
+	TokenSalt      string
+	TokenLastEight string `xorm:"token_last_eight INDEX"`
+
+	LastOnlineUnix util.TimeStamp `xorm:"INDEX"`
+	CreatedUnix    util.TimeStamp `xorm:"created"`
+	UpdatedUnix    util.TimeStamp `xorm:"updated"`
+}
+
+// actionRunnerOfflineTimeout is the time after which a runner which has not
+// polled for jobs is considered offline
+const actionRunnerOfflineTimeout = time.Minute
+
+// IsOnline returns true if the runner polled for jobs recently
+func (r *ActionRunner) IsOnline() bool {
+	return r.LastOnlineUnix.AddDuration(actionRunnerOfflineTimeout) > util.TimeStampNow()
+}
+
+// CanRunJob returns true if the runner is allowed to run the job and has all
+// the labels the job requires.
+func (r *ActionRunner) CanRunJob(job *ActionRunJob) bool {
+	if r.RepoID != 0 && r.RepoID != job.RepoID {
+		return false
+	}
+	for _, label := range job.RunsOn {
+		if !com.IsSliceContainsStr(r.Labels, label) {
+			return false
+		}
+	}
+	return true
+}
+
+// ActionRunnerToken represents a token used by runners to register themselves
+type ActionRunnerToken struct {
+	ID    int64  `xorm:"pk autoincr"`
+	Token string `xorm:"UNIQUE"`
+	// RepoID is zero for the token registering runners available to all the repositories
+	RepoID   int64 `xorm:"INDEX"`
+	IsActive bool
+
+	CreatedUnix util.TimeStamp `xorm:"created"`
+	UpdatedUnix util.TimeStamp `xorm:"updated"`
+}
+
+// GetActionRunnerToken returns the active registration token of the
+// repository, a token is created if there is none.
+func GetActionRunnerToken(repoID int64) (*ActionRunnerToken, error) {
+	t := &ActionRunnerToken{}
+	has, err := x.Where("repo_id = ? AND is_active = ?", repoID, true).Get(t)
+	if err != nil {
+		return nil, err
+	} else if has {
+		return t, nil
+	}
+	return ResetActionRunnerToken(repoID)
+}
+
+// ResetActionRunnerToken deactivates the registration token of the repository
+// and creates a new one.
+func ResetActionRunnerToken(repoID int64) (*ActionRunnerToken, error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return nil, err
+	}
+
+	if _, err := sess.Where("repo_id = ?", repoID).Cols("is_active").Update(&ActionRunnerToken{IsActive: false}); err != nil {
+		return nil, err
+	}
+	t := &ActionRunnerToken{
+		Token:    base.EncodeSha1(gouuid.NewV4().String()),
+		RepoID:   repoID,
+		IsActive: true,
+	}
+	if _, err := sess.Insert(t); err != nil {
+		return nil, err
+	}
+	return t, sess.Commit()
+}
+
+// RegisterActionRunner registers a new runner with the given registration token
+func RegisterActionRunner(token, name string, labels []string) (*ActionRunner, error) {
+	t := &ActionRunnerToken{}
+	has, err := x.Where("token = ? AND is_active = ?", token, true).Get(t)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrActionRunnerTokenNotExist{Token: token}
+	}
+
+	salt, err := generate.GetRandomString(10)
+	if err != nil {
+		return nil, err
+	}
+	r := &ActionRunner{
+		UUID:           gouuid.NewV4().String(),
+		Name:           name,
+		RepoID:         t.RepoID,
+		Labels:         labels,
+		Token:          base.EncodeSha1(gouuid.NewV4().String()),
+		TokenSalt:      salt,
+		LastOnlineUnix: util.TimeStampNow(),
+	}
+	r.TokenHash = hashToken(r.Token, r.TokenSalt)
+	r.TokenLastEight = r.Token[len(r.Token)-8:]
+	if _, err = x.Insert(r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetActionRunnerByToken returns the runner authenticated by the given token
+func GetActionRunnerByToken(token string) (*ActionRunner, error) {
+	if len(token) < 8 {
+		return nil, ErrActionRunnerNotExist{}
+	}
+	var runners []*ActionRunner
+	if err := x.Where("token_last_eight = ?", token[len(token)-8:]).Find(&runners); err != nil {
+		return nil, err
+	}
+	for _, r := range runners {
+		if subtle.ConstantTimeCompare([]byte(r.TokenHash), []byte(hashToken(token, r.TokenSalt))) == 1 {
+			return r, nil
+		}
+	}
+	return nil, ErrActionRunnerNotExist{}
+}
+
+// GetActionRunnerByID returns the runner with the given id
+func GetActionRunnerByID(id int64) (*ActionRunner, error) {
+	r := &ActionRunner{}
+	has, err := x.ID(id).Get(r)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrActionRunnerNotExist{ID: id}
+	}
+	return r, nil
+}
+
+// GetActionRunnersByRepoID returns the runners registered for the repository,
+// zero returns the runners available to all the repositories.
+func GetActionRunnersByRepoID(repoID int64) ([]*ActionRunner, error) {
+	runners := make([]*ActionRunner, 0, 5)
+	return runners, x.Where("repo_id = ?", repoID).Asc("id").Find(&runners)
+}
+
+// UpdateActionRunnerOnline records that the runner has just contacted the server
+func UpdateActionRunnerOnline(r *ActionRunner) error {
+	r.LastOnlineUnix = util.TimeStampNow()
+	_, err := x.ID(r.ID).Cols("last_online_unix").Update(r)
+	return err
+}
+
+// DeleteActionRunner deletes a runner, the jobs it is running are marked as failed
+func DeleteActionRunner(r *ActionRunner) error {
+	jobs := make([]*ActionRunJob, 0, 1)
+	if err := x.Where("runner_id = ? AND status = ?", r.ID, ActionStatusRunning).Find(&jobs); err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		if err := UpdateActionRunJobStatus(job, ActionStatusFailure); err != nil {
+			return err
+		}
+	}
+	_, err := x.ID(r.ID).Delete(new(ActionRunner))
+	return err
+}
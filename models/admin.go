@@ -9,6 +9,7 @@ import (
 	"os"
 
 	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/storage"
 	"code.gitea.io/gitea/modules/util"
 
 	"github.com/Unknwon/com"
@@ -70,6 +71,18 @@ func removeAllWithNotice(e Engine, title, path string) {
 	}
 }
 
+// removeStorageWithNotice removes an object from the given storage and creates
+// a system notice when error occurs.
+func removeStorageWithNotice(e Engine, s storage.ObjectStorage, title, path string) {
+	if err := s.Delete(path); err != nil {
+		desc := fmt.Sprintf("%s [%s]: %v", title, path, err)
+		log.Warn(title+" [%s]: %v", path, err)
+		if err = createNotice(e, NoticeRepository, desc); err != nil {
+			log.Error("CreateRepositoryNotice: %v", err)
+		}
+	}
+}
+
 // CountNotices returns number of notices.
 func CountNotices() int64 {
 	count, _ := x.Count(new(Notice))
@@ -38,6 +38,9 @@ type ProtectedBranch struct {
 	ApprovalsWhitelistUserIDs []int64        `xorm:"JSON TEXT"`
 	ApprovalsWhitelistTeamIDs []int64        `xorm:"JSON TEXT"`
 	RequiredApprovals         int64          `xorm:"NOT NULL DEFAULT 0"`
+	DismissStaleApprovals     bool           `xorm:"NOT NULL DEFAULT false"`
+	EnableStatusCheck         bool           `xorm:"NOT NULL DEFAULT false"`
+	StatusCheckContexts       []string       `xorm:"JSON TEXT"`
 	CreatedUnix               util.TimeStamp `xorm:"created"`
 	UpdatedUnix               util.TimeStamp `xorm:"updated"`
 }
@@ -100,6 +103,7 @@ func (protectBranch *ProtectedBranch) HasEnoughApprovals(pr *PullRequest) bool {
 }
 
 // GetGrantedApprovalsCount returns the number of granted approvals for pr. A granted approval must be authored by a user in an approval whitelist.
+// When the stale approvals are dismissed, only the approvals of the current head commit are granted, before they are dismissed in the background.
 func (protectBranch *ProtectedBranch) GetGrantedApprovalsCount(pr *PullRequest) int64 {
 	reviews, err := GetReviewersByPullID(pr.IssueID)
 	if err != nil {
@@ -107,12 +111,28 @@ func (protectBranch *ProtectedBranch) GetGrantedApprovalsCount(pr *PullRequest)
 		return 0
 	}
 
+	var headApproverIDs []int64
+	if protectBranch.DismissStaleApprovals {
+		headCommitID, err := pr.GetHeadCommitID()
+		if err != nil {
+			log.Error("GetHeadCommitID: %v", err)
+			return 0
+		}
+		if headApproverIDs, err = getApproverIDsByCommit(x, pr.IssueID, headCommitID); err != nil {
+			log.Error("getApproverIDsByCommit: %v", err)
+			return 0
+		}
+	}
+
 	approvals := int64(0)
 	userIDs := make([]int64, 0)
 	for _, review := range reviews {
 		if review.Type != ReviewTypeApprove {
 			continue
 		}
+		if protectBranch.DismissStaleApprovals && !base.Int64sContains(headApproverIDs, review.ID) {
+			continue
+		}
 		if base.Int64sContains(protectBranch.ApprovalsWhitelistUserIDs, review.ID) {
 			approvals++
 			continue
@@ -127,6 +147,36 @@ func (protectBranch *ProtectedBranch) GetGrantedApprovalsCount(pr *PullRequest)
 	return approvalTeamCount + approvals
 }
 
+// IsStatusCheckSuccess returns true if every required status check context has
+// a successful commit status on the head commit of pr.
+func (protectBranch *ProtectedBranch) IsStatusCheckSuccess(pr *PullRequest) bool {
+	if !protectBranch.EnableStatusCheck || len(protectBranch.StatusCheckContexts) == 0 {
+		return true
+	}
+
+	sha, err := pr.GetHeadCommitID()
+	if err != nil {
+		log.Error("GetHeadCommitID: %v", err)
+		return false
+	}
+
+	// The head commit is available in the base repository as refs/pull/N/head,
+	// only statuses reported there are trusted.
+	statuses, err := GetLatestCommitStatusesByContexts(pr.BaseRepoID, sha, protectBranch.StatusCheckContexts)
+	if err != nil {
+		log.Error("GetLatestCommitStatusesByContexts: %v", err)
+		return false
+	}
+
+	var successes int
+	for _, status := range statuses {
+		if status.State == CommitStatusSuccess {
+			successes++
+		}
+	}
+	return successes == len(protectBranch.StatusCheckContexts)
+}
+
 // GetProtectedBranchByRepoID getting protected branch by repo ID
 func GetProtectedBranchByRepoID(repoID int64) ([]*ProtectedBranch, error) {
 	protectedBranches := make([]*ProtectedBranch, 0)
@@ -292,7 +342,9 @@ func (repo *Repository) IsProtectedBranchForMerging(pr *PullRequest, branchName
 	if err != nil {
 		return true, err
 	} else if has {
-		return !protectedBranch.CanUserMerge(doer.ID) || !protectedBranch.HasEnoughApprovals(pr), nil
+		return !protectedBranch.CanUserMerge(doer.ID) ||
+			!protectedBranch.HasEnoughApprovals(pr) ||
+			!protectedBranch.IsStatusCheckSuccess(pr), nil
 	}
 
 	return false, nil
@@ -5,8 +5,12 @@
 package models
 
 import (
+	"os"
+	"strings"
 	"testing"
 
+	"code.gitea.io/gitea/modules/git"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -78,3 +82,37 @@ func getDeletedBranch(t *testing.T, branch *DeletedBranch) *DeletedBranch {
 
 	return deletedBranch
 }
+
+func TestProtectedBranch_HasEnoughApprovals(t *testing.T) {
+	PrepareTestEnv(t)
+	pr := AssertExistsAndLoadBean(t, &PullRequest{ID: 2}).(*PullRequest)
+	assert.NoError(t, pr.GetBaseRepo())
+	repoPath := pr.BaseRepo.RepoPath()
+	headCommitID, err := git.NewCommand("rev-parse", "master").RunInDir(repoPath)
+	assert.NoError(t, err)
+	headCommitID = strings.TrimSpace(headCommitID)
+	_, err = git.NewCommand("update-ref", pr.GetGitRefName(), headCommitID).RunInDir(repoPath)
+	assert.NoError(t, err)
+
+	_, err = x.Insert(&Review{Type: ReviewTypeApprove, ReviewerID: 2, IssueID: pr.IssueID, CommitID: headCommitID})
+	assert.NoError(t, err)
+	protectBranch := &ProtectedBranch{
+		RequiredApprovals:         1,
+		ApprovalsWhitelistUserIDs: []int64{2},
+		DismissStaleApprovals:     true,
+	}
+	assert.True(t, protectBranch.HasEnoughApprovals(pr))
+
+	// push a new commit, the approval is stale before it is dismissed in the background
+	env := append(os.Environ(),
+		"GIT_AUTHOR_NAME=user2", "GIT_AUTHOR_EMAIL=user2@example.com",
+		"GIT_COMMITTER_NAME=user2", "GIT_COMMITTER_EMAIL=user2@example.com")
+	newCommitID, err := git.NewCommand("commit-tree", "-p", headCommitID, "-m", "new commit", "master^{tree}").RunInDirWithEnv(repoPath, env)
+	assert.NoError(t, err)
+	_, err = git.NewCommand("update-ref", pr.GetGitRefName(), strings.TrimSpace(newCommitID)).RunInDir(repoPath)
+	assert.NoError(t, err)
+	assert.False(t, protectBranch.HasEnoughApprovals(pr))
+
+	protectBranch.DismissStaleApprovals = false
+	assert.True(t, protectBranch.HasEnoughApprovals(pr))
+}
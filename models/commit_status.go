@@ -9,6 +9,7 @@ import (
 	"crypto/sha1"
 	"fmt"
 	"strings"
+	"time"
 
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
@@ -205,6 +206,59 @@ func GetLatestCommitStatus(repo *Repository, sha string, page int) ([]*CommitSta
 	return statuses, x.In("id", ids).Find(&statuses)
 }
 
+// GetLatestCommitStatusesByContexts returns the latest commit status of every given
+// context on the commit sha of the repository, contexts without a status are left out.
+func GetLatestCommitStatusesByContexts(repoID int64, sha string, contexts []string) ([]*CommitStatus, error) {
+	statuses := make([]*CommitStatus, 0, len(contexts))
+	if len(contexts) == 0 {
+		return statuses, nil
+	}
+
+	hashes := make([]string, 0, len(contexts))
+	for _, context := range contexts {
+		hashes = append(hashes, hashCommitStatusContext(context))
+	}
+	ids := make([]int64, 0, len(contexts))
+	err := x.Table(&CommitStatus{}).
+		Where("repo_id = ? AND sha = ?", repoID, sha).In("context_hash", hashes).
+		Select("max( id ) as id").
+		GroupBy("context_hash").Find(&ids)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return statuses, nil
+	}
+	return statuses, x.In("id", ids).Find(&statuses)
+}
+
+// FindRepoRecentCommitStatusContexts returns the contexts of the commit statuses
+// reported to a repository since the given duration
+func FindRepoRecentCommitStatusContexts(repoID int64, since time.Duration) ([]string, error) {
+	ids := make([]int64, 0, 10)
+	err := x.Table(&CommitStatus{}).
+		Where("repo_id = ?", repoID).
+		And("updated_unix >= ?", time.Now().Add(-since).Unix()).
+		Select("max( id ) as id").
+		GroupBy("context_hash").OrderBy("max( id ) desc").Find(&ids)
+	if err != nil {
+		return nil, err
+	}
+
+	contexts := make([]string, 0, len(ids))
+	if len(ids) == 0 {
+		return contexts, nil
+	}
+	statuses := make([]*CommitStatus, 0, len(ids))
+	if err = x.In("id", ids).Desc("id").Find(&statuses); err != nil {
+		return nil, err
+	}
+	for _, status := range statuses {
+		contexts = append(contexts, status.Context)
+	}
+	return contexts, nil
+}
+
 // NewCommitStatusOptions holds options for creating a CommitStatus
 type NewCommitStatusOptions struct {
 	Repo         *Repository
@@ -37,3 +37,30 @@ func TestGetCommitStatuses(t *testing.T) {
 		assert.Equal(t, statuses[4].State, CommitStatusError)
 	}
 }
+
+func TestGetLatestCommitStatusesByContexts(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	sha1 := "2345234523452345234523452345234523452345"
+
+	statuses, err := GetLatestCommitStatusesByContexts(1, sha1, []string{"ci/awesomeness", "cov/awesomeness", "unknown"})
+	assert.NoError(t, err)
+	if assert.Len(t, statuses, 2) {
+		assert.Equal(t, "cov/awesomeness", statuses[0].Context)
+		assert.Equal(t, CommitStatusSuccess, statuses[0].State)
+
+		assert.Equal(t, "ci/awesomeness", statuses[1].Context)
+		assert.Equal(t, CommitStatusFailure, statuses[1].State)
+	}
+
+	// statuses of other repositories are ignored
+	statuses, err = GetLatestCommitStatusesByContexts(2, sha1, []string{"ci/awesomeness"})
+	assert.NoError(t, err)
+	if assert.Len(t, statuses, 1) {
+		assert.Equal(t, CommitStatusSuccess, statuses[0].State)
+	}
+
+	statuses, err = GetLatestCommitStatusesByContexts(3, sha1, []string{"ci/awesomeness"})
+	assert.NoError(t, err)
+	assert.Len(t, statuses, 0)
+}
@@ -534,6 +534,21 @@ func (err ErrAccessTokenEmpty) Error() string {
 	return fmt.Sprintf("access token is empty")
 }
 
+// ErrAccessTokenScopeInvalid represents a "AccessTokenScopeInvalid" kind of error.
+type ErrAccessTokenScopeInvalid struct {
+	Scope string
+}
+
+// IsErrAccessTokenScopeInvalid checks if an error is a ErrAccessTokenScopeInvalid.
+func IsErrAccessTokenScopeInvalid(err error) bool {
+	_, ok := err.(ErrAccessTokenScopeInvalid)
+	return ok
+}
+
+func (err ErrAccessTokenScopeInvalid) Error() string {
+	return fmt.Sprintf("access token scope is invalid [scope: %s]", err.Scope)
+}
+
 // ________                            .__                __  .__
 // \_____  \_______  _________    ____ |__|____________ _/  |_|__| ____   ____
 //  /   |   \_  __ \/ ___\__  \  /    \|  \___   /\__  \\   __\  |/  _ \ /    \
@@ -884,6 +899,22 @@ func (err ErrUserDoesNotHaveAccessToRepo) Error() string {
 	return fmt.Sprintf("user doesn't have acces to repo [user_id: %d, repo_name: %s]", err.UserID, err.RepoName)
 }
 
+// ErrPushMirrorNotExist represents a "PushMirrorNotExist" kind of error.
+type ErrPushMirrorNotExist struct {
+	ID     int64
+	RepoID int64
+}
+
+// IsErrPushMirrorNotExist checks if an error is a ErrPushMirrorNotExist.
+func IsErrPushMirrorNotExist(err error) bool {
+	_, ok := err.(ErrPushMirrorNotExist)
+	return ok
+}
+
+func (err ErrPushMirrorNotExist) Error() string {
+	return fmt.Sprintf("push mirror does not exist [id: %d, repo_id: %d]", err.ID, err.RepoID)
+}
+
 // __________                             .__
 // \______   \____________    ____   ____ |  |__
 //  |    |  _/\_  __ \__  \  /    \_/ ___\|  |  \
@@ -1034,6 +1065,23 @@ func (err ErrWebhookNotExist) Error() string {
 	return fmt.Sprintf("webhook does not exist [id: %d]", err.ID)
 }
 
+// ErrHookTaskNotExist represents a "HookTaskNotExist" kind of error.
+type ErrHookTaskNotExist struct {
+	ID     int64
+	HookID int64
+	UUID   string
+}
+
+// IsErrHookTaskNotExist checks if an error is a ErrHookTaskNotExist.
+func IsErrHookTaskNotExist(err error) bool {
+	_, ok := err.(ErrHookTaskNotExist)
+	return ok
+}
+
+func (err ErrHookTaskNotExist) Error() string {
+	return fmt.Sprintf("hook task does not exist [id: %d, hook_id: %d, uuid: %s]", err.ID, err.HookID, err.UUID)
+}
+
 // .___
 // |   | ______ ________ __   ____
 // |   |/  ___//  ___/  |  \_/ __ \
@@ -1233,6 +1281,22 @@ func (err ErrLabelNotExist) Error() string {
 	return fmt.Sprintf("label does not exist [label_id: %d, repo_id: %d]", err.LabelID, err.RepoID)
 }
 
+// ErrOrgLabelNotExist represents a "OrgLabelNotExist" kind of error.
+type ErrOrgLabelNotExist struct {
+	LabelID int64
+	OrgID   int64
+}
+
+// IsErrOrgLabelNotExist checks if an error is a ErrOrgLabelNotExist.
+func IsErrOrgLabelNotExist(err error) bool {
+	_, ok := err.(ErrOrgLabelNotExist)
+	return ok
+}
+
+func (err ErrOrgLabelNotExist) Error() string {
+	return fmt.Sprintf("label does not exist [label_id: %d, org_id: %d]", err.LabelID, err.OrgID)
+}
+
 //    _____  .__.__                   __
 //   /     \ |__|  |   ____   _______/  |_  ____   ____   ____
 //  /  \ /  \|  |  | _/ __ \ /  ___/\   __\/  _ \ /    \_/ __ \
@@ -1604,3 +1668,316 @@ func IsErrOAuthApplicationNotFound(err error) bool {
 func (err ErrOAuthApplicationNotFound) Error() string {
 	return fmt.Sprintf("OAuth application not found [ID: %d]", err.ID)
 }
+
+// ErrOAuth2ScopeInvalid will be thrown if an OAuth2 application requests an unknown scope
+type ErrOAuth2ScopeInvalid struct {
+	Scope string
+}
+
+// IsErrOAuth2ScopeInvalid checks if an error is a ErrOAuth2ScopeInvalid.
+func IsErrOAuth2ScopeInvalid(err error) bool {
+	_, ok := err.(ErrOAuth2ScopeInvalid)
+	return ok
+}
+
+// Error returns the error message
+func (err ErrOAuth2ScopeInvalid) Error() string {
+	return fmt.Sprintf("OAuth2 scope is invalid [scope: %s]", err.Scope)
+}
+
+//    _____          __  .__
+//   /  _  \   _____/  |_|__| ____   ____   ______
+//  /  /_\  \_/ ___\   __\  |/  _ \ /    \ /  ___/
+// /    |    \  \___|  | |  (  <_> )   |  \\___ \
+// \____|__  /\___  >__| |__|\____/|___|  /____  >
+//         \/     \/                    \/     \/
+
+// ErrActionRunNotExist represents a "ActionRunNotExist" kind of error.
+type ErrActionRunNotExist struct {
+	ID int64
+}
+
+// IsErrActionRunNotExist checks if an error is a ErrActionRunNotExist.
+func IsErrActionRunNotExist(err error) bool {
+	_, ok := err.(ErrActionRunNotExist)
+	return ok
+}
+
+func (err ErrActionRunNotExist) Error() string {
+	return fmt.Sprintf("workflow run does not exist [id: %d]", err.ID)
+}
+
+// ErrActionRunJobNotExist represents a "ActionRunJobNotExist" kind of error.
+type ErrActionRunJobNotExist struct {
+	ID int64
+}
+
+// IsErrActionRunJobNotExist checks if an error is a ErrActionRunJobNotExist.
+func IsErrActionRunJobNotExist(err error) bool {
+	_, ok := err.(ErrActionRunJobNotExist)
+	return ok
+}
+
+func (err ErrActionRunJobNotExist) Error() string {
+	return fmt.Sprintf("workflow job does not exist [id: %d]", err.ID)
+}
+
+// ErrActionRunJobDone represents a "ActionRunJobDone" kind of error.
+type ErrActionRunJobDone struct {
+	ID int64
+}
+
+// IsErrActionRunJobDone checks if an error is a ErrActionRunJobDone.
+func IsErrActionRunJobDone(err error) bool {
+	_, ok := err.(ErrActionRunJobDone)
+	return ok
+}
+
+func (err ErrActionRunJobDone) Error() string {
+	return fmt.Sprintf("workflow job is already done [id: %d]", err.ID)
+}
+
+// ErrActionRunJobLogOffset represents a "ActionRunJobLogOffset" kind of error.
+type ErrActionRunJobLogOffset struct {
+	Offset   int64
+	Expected int64
+}
+
+// IsErrActionRunJobLogOffset checks if an error is a ErrActionRunJobLogOffset.
+func IsErrActionRunJobLogOffset(err error) bool {
+	_, ok := err.(ErrActionRunJobLogOffset)
+	return ok
+}
+
+func (err ErrActionRunJobLogOffset) Error() string {
+	return fmt.Sprintf("log lines are missing [offset: %d, expected: %d]", err.Offset, err.Expected)
+}
+
+// ErrActionRunnerNotExist represents a "ActionRunnerNotExist" kind of error.
+type ErrActionRunnerNotExist struct {
+	ID int64
+}
+
+// IsErrActionRunnerNotExist checks if an error is a ErrActionRunnerNotExist.
+func IsErrActionRunnerNotExist(err error) bool {
+	_, ok := err.(ErrActionRunnerNotExist)
+	return ok
+}
+
+func (err ErrActionRunnerNotExist) Error() string {
+	return fmt.Sprintf("runner does not exist [id: %d]", err.ID)
+}
+
+// ErrActionRunnerTokenNotExist represents a "ActionRunnerTokenNotExist" kind of error.
+type ErrActionRunnerTokenNotExist struct {
+	Token string
+}
+
+// IsErrActionRunnerTokenNotExist checks if an error is a ErrActionRunnerTokenNotExist.
+func IsErrActionRunnerTokenNotExist(err error) bool {
+	_, ok := err.(ErrActionRunnerTokenNotExist)
+	return ok
+}
+
+func (err ErrActionRunnerTokenNotExist) Error() string {
+	return "runner registration token does not exist"
+}
+
+// ___________              __
+// \__    ___/____    _____|  | __
+//   |    |  \__  \  /  ___/  |/ /
+//   |    |   / __ \_\___ \|    <
+//   |____|  (____  /____  >__|_ \
+//                \/     \/     \/
+
+// ErrTaskNotExist represents a "TaskNotExist" kind of error.
+type ErrTaskNotExist struct {
+	ID     int64
+	RepoID int64
+}
+
+// IsErrTaskNotExist checks if an error is a ErrTaskNotExist.
+func IsErrTaskNotExist(err error) bool {
+	_, ok := err.(ErrTaskNotExist)
+	return ok
+}
+
+func (err ErrTaskNotExist) Error() string {
+	return fmt.Sprintf("task does not exist [id: %d, repo_id: %d]", err.ID, err.RepoID)
+}
+
+// __________                   __               __
+// \______   \_______  ____    |__| ____   _____/  |_
+//  |     ___/\_  __ \/  _ \   |  |/ __ \_/ ___\   __\
+//  |    |     |  | \(  <_> )  |  \  ___/\  \___|  |
+//  |____|     |__|   \____/\__|  |\___  >\___  >__|
+//                       \______|    \/     \/
+
+// ErrProjectNotExist represents a "ProjectNotExist" kind of error.
+type ErrProjectNotExist struct {
+	ID      int64
+	RepoID  int64
+	OwnerID int64
+}
+
+// IsErrProjectNotExist checks if an error is a ErrProjectNotExist.
+func IsErrProjectNotExist(err error) bool {
+	_, ok := err.(ErrProjectNotExist)
+	return ok
+}
+
+func (err ErrProjectNotExist) Error() string {
+	return fmt.Sprintf("project does not exist [id: %d, repo_id: %d, owner_id: %d]", err.ID, err.RepoID, err.OwnerID)
+}
+
+// ErrProjectBoardNotExist represents a "ProjectBoardNotExist" kind of error.
+type ErrProjectBoardNotExist struct {
+	ID        int64
+	ProjectID int64
+}
+
+// IsErrProjectBoardNotExist checks if an error is a ErrProjectBoardNotExist.
+func IsErrProjectBoardNotExist(err error) bool {
+	_, ok := err.(ErrProjectBoardNotExist)
+	return ok
+}
+
+func (err ErrProjectBoardNotExist) Error() string {
+	return fmt.Sprintf("project board does not exist [id: %d, project_id: %d]", err.ID, err.ProjectID)
+}
+
+// ErrProjectIssueNotExist represents a "ProjectIssueNotExist" kind of error.
+type ErrProjectIssueNotExist struct {
+	ProjectID int64
+	IssueID   int64
+}
+
+// IsErrProjectIssueNotExist checks if an error is a ErrProjectIssueNotExist.
+func IsErrProjectIssueNotExist(err error) bool {
+	_, ok := err.(ErrProjectIssueNotExist)
+	return ok
+}
+
+func (err ErrProjectIssueNotExist) Error() string {
+	return fmt.Sprintf("issue is not in the project [project_id: %d, issue_id: %d]", err.ProjectID, err.IssueID)
+}
+
+// ErrProjectIssueNotAllowed represents a "ProjectIssueNotAllowed" kind of error.
+type ErrProjectIssueNotAllowed struct {
+	ProjectID int64
+	IssueID   int64
+}
+
+// IsErrProjectIssueNotAllowed checks if an error is a ErrProjectIssueNotAllowed.
+func IsErrProjectIssueNotAllowed(err error) bool {
+	_, ok := err.(ErrProjectIssueNotAllowed)
+	return ok
+}
+
+func (err ErrProjectIssueNotAllowed) Error() string {
+	return fmt.Sprintf("issue does not belong to the repositories of the project [project_id: %d, issue_id: %d]", err.ProjectID, err.IssueID)
+}
+
+// __________                   __
+// \______   \_____     ____  |  | __ _____      ____    ____
+//  |     ___/\__  \  _/ ___\ |  |/ / \__  \    / ___\ _/ __ \
+//  |    |      / __ \_\  \___ |    <   / __ \_ / /_/  >\  ___/
+//  |____|     (____  / \___  >|__|_ \ (____  / \___  /  \___  >
+//                  \/      \/      \/      \/ /_____/       \/
+
+// ErrPackageNotExist represents a "PackageNotExist" kind of error.
+type ErrPackageNotExist struct {
+	OwnerID int64
+	Type    PackageType
+	Name    string
+}
+
+// IsErrPackageNotExist checks if an error is a ErrPackageNotExist.
+func IsErrPackageNotExist(err error) bool {
+	_, ok := err.(ErrPackageNotExist)
+	return ok
+}
+
+func (err ErrPackageNotExist) Error() string {
+	return fmt.Sprintf("package does not exist [owner_id: %d, type: %s, name: %s]", err.OwnerID, err.Type, err.Name)
+}
+
+// ErrPackageVersionNotExist represents a "PackageVersionNotExist" kind of error.
+type ErrPackageVersionNotExist struct {
+	ID        int64
+	PackageID int64
+	Version   string
+}
+
+// IsErrPackageVersionNotExist checks if an error is a ErrPackageVersionNotExist.
+func IsErrPackageVersionNotExist(err error) bool {
+	_, ok := err.(ErrPackageVersionNotExist)
+	return ok
+}
+
+func (err ErrPackageVersionNotExist) Error() string {
+	return fmt.Sprintf("package version does not exist [id: %d, package_id: %d, version: %s]", err.ID, err.PackageID, err.Version)
+}
+
+// ErrPackageFileNotExist represents a "PackageFileNotExist" kind of error.
+type ErrPackageFileNotExist struct {
+	VersionID int64
+	Name      string
+}
+
+// IsErrPackageFileNotExist checks if an error is a ErrPackageFileNotExist.
+func IsErrPackageFileNotExist(err error) bool {
+	_, ok := err.(ErrPackageFileNotExist)
+	return ok
+}
+
+func (err ErrPackageFileNotExist) Error() string {
+	return fmt.Sprintf("package file does not exist [version_id: %d, name: %s]", err.VersionID, err.Name)
+}
+
+// ErrPackageFileAlreadyExist represents a "PackageFileAlreadyExist" kind of error.
+type ErrPackageFileAlreadyExist struct {
+	VersionID int64
+	Name      string
+}
+
+// IsErrPackageFileAlreadyExist checks if an error is a ErrPackageFileAlreadyExist.
+func IsErrPackageFileAlreadyExist(err error) bool {
+	_, ok := err.(ErrPackageFileAlreadyExist)
+	return ok
+}
+
+func (err ErrPackageFileAlreadyExist) Error() string {
+	return fmt.Sprintf("package file already exists [version_id: %d, name: %s]", err.VersionID, err.Name)
+}
+
+// ErrPackageBlobNotExist represents a "PackageBlobNotExist" kind of error.
+type ErrPackageBlobNotExist struct {
+	ID   int64
+	Hash string
+}
+
+// IsErrPackageBlobNotExist checks if an error is a ErrPackageBlobNotExist.
+func IsErrPackageBlobNotExist(err error) bool {
+	_, ok := err.(ErrPackageBlobNotExist)
+	return ok
+}
+
+func (err ErrPackageBlobNotExist) Error() string {
+	return fmt.Sprintf("package blob does not exist [id: %d, hash: %s]", err.ID, err.Hash)
+}
+
+// ErrPackageUploadNotExist represents a "PackageUploadNotExist" kind of error.
+type ErrPackageUploadNotExist struct {
+	UUID string
+}
+
+// IsErrPackageUploadNotExist checks if an error is a ErrPackageUploadNotExist.
+func IsErrPackageUploadNotExist(err error) bool {
+	_, ok := err.(ErrPackageUploadNotExist)
+	return ok
+}
+
+func (err ErrPackageUploadNotExist) Error() string {
+	return fmt.Sprintf("package upload does not exist [uuid: %s]", err.UUID)
+}
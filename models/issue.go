@@ -449,7 +449,7 @@ func (issue *Issue) sendLabelUpdatedWebhook(doer *User) {
 			log.Error("LoadIssue: %v", err)
 			return
 		}
-		err = PrepareWebhooks(issue.Repo, HookEventPullRequest, &api.PullRequestPayload{
+		err = PrepareWebhooks(issue.Repo, HookEventPullRequestLabel, &api.PullRequestPayload{
 			Action:      api.HookIssueLabelUpdated,
 			Index:       issue.Index,
 			PullRequest: issue.PullRequest.APIFormat(),
@@ -457,7 +457,7 @@ func (issue *Issue) sendLabelUpdatedWebhook(doer *User) {
 			Sender:      doer.APIFormat(),
 		})
 	} else {
-		err = PrepareWebhooks(issue.Repo, HookEventIssues, &api.IssuePayload{
+		err = PrepareWebhooks(issue.Repo, HookEventIssueLabel, &api.IssuePayload{
 			Action:     api.HookIssueLabelUpdated,
 			Index:      issue.Index,
 			Issue:      issue.APIFormat(),
@@ -606,7 +606,7 @@ func (issue *Issue) ClearLabels(doer *User) (err error) {
 			log.Error("LoadIssue: %v", err)
 			return
 		}
-		err = PrepareWebhooks(issue.Repo, HookEventPullRequest, &api.PullRequestPayload{
+		err = PrepareWebhooks(issue.Repo, HookEventPullRequestLabel, &api.PullRequestPayload{
 			Action:      api.HookIssueLabelCleared,
 			Index:       issue.Index,
 			PullRequest: issue.PullRequest.APIFormat(),
@@ -614,7 +614,7 @@ func (issue *Issue) ClearLabels(doer *User) (err error) {
 			Sender:      doer.APIFormat(),
 		})
 	} else {
-		err = PrepareWebhooks(issue.Repo, HookEventIssues, &api.IssuePayload{
+		err = PrepareWebhooks(issue.Repo, HookEventIssueLabel, &api.IssuePayload{
 			Action:     api.HookIssueLabelCleared,
 			Index:      issue.Index,
 			Issue:      issue.APIFormat(),
@@ -773,6 +773,12 @@ func (issue *Issue) changeStatus(e *xorm.Session, doer *User, isClosed bool) (er
 		return err
 	}
 
+	if isClosed {
+		if err = moveIssueByProjectTrigger(e, issue, ProjectBoardTriggerClosed); err != nil {
+			return err
+		}
+	}
+
 	// New action comment
 	if _, err = createStatusComment(e, doer, issue); err != nil {
 		return err
@@ -1149,7 +1155,7 @@ func newIssue(e *xorm.Session, doer *User, opts NewIssueOptions) (err error) {
 
 		for _, label := range labels {
 			// Silently drop invalid labels.
-			if label.RepoID != opts.Repo.ID {
+			if !label.IsUsableIn(opts.Repo) {
 				continue
 			}
 
@@ -1298,6 +1304,19 @@ func GetIssueIDsByRepoID(repoID int64) ([]int64, error) {
 	return getIssueIDsByRepoID(x, repoID)
 }
 
+// GetIssueIndexIDs returns the ids of all issues and pull requests of the repository mapped by their indexes
+func GetIssueIndexIDs(repoID int64) (map[int64]int64, error) {
+	var issues = make([]*Issue, 0, 10)
+	if err := x.Cols("id", "`index`").Where("repo_id = ?", repoID).Find(&issues); err != nil {
+		return nil, err
+	}
+	var ids = make(map[int64]int64, len(issues))
+	for _, issue := range issues {
+		ids[issue.Index] = issue.ID
+	}
+	return ids, nil
+}
+
 // GetIssuesByIDs return issues with the given IDs.
 func GetIssuesByIDs(issueIDs []int64) ([]*Issue, error) {
 	return getIssuesByIDs(x, issueIDs)
@@ -1629,6 +1648,7 @@ type UserIssueStatsOptions struct {
 	UserID      int64
 	RepoID      int64
 	UserRepoIDs []int64
+	LabelIDs    []int64
 	FilterMode  int
 	IsPull      bool
 	IsClosed    bool
@@ -1644,6 +1664,9 @@ func GetUserIssueStats(opts UserIssueStatsOptions) (*IssueStats, error) {
 	if opts.RepoID > 0 {
 		cond = cond.And(builder.Eq{"issue.repo_id": opts.RepoID})
 	}
+	for _, labelID := range opts.LabelIDs {
+		cond = cond.And(builder.In("issue.id", builder.Select("issue_id").From("issue_label").Where(builder.Eq{"label_id": labelID})))
+	}
 
 	switch opts.FilterMode {
 	case FilterModeAll:
@@ -84,6 +84,8 @@ const (
 	CommentTypeLock
 	// Unlocks a previously locked issue
 	CommentTypeUnlock
+	// Dismisses a review of a pull request
+	CommentTypeDismissReview
 )
 
 // CommentTag defines comment tag type
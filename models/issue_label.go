@@ -12,6 +12,7 @@ import (
 	"strings"
 
 	"github.com/go-xorm/xorm"
+	"xorm.io/builder"
 
 	api "code.gitea.io/gitea/modules/structs"
 )
@@ -58,10 +59,12 @@ func GetLabelTemplateFile(name string) ([][3]string, error) {
 	return list, nil
 }
 
-// Label represents a label of repository for issues.
+// Label represents a label for issues, which belongs to a repository or,
+// when RepoID is 0, to the organization OrgID and is shared by its repositories.
 type Label struct {
 	ID              int64 `xorm:"pk autoincr"`
 	RepoID          int64 `xorm:"INDEX"`
+	OrgID           int64 `xorm:"INDEX"`
 	Name            string
 	Description     string
 	Color           string `xorm:"VARCHAR(7)"`
@@ -83,6 +86,19 @@ func (label *Label) APIFormat() *api.Label {
 	}
 }
 
+// BelongsToOrg returns true if the label is shared by the repositories of an organization
+func (label *Label) BelongsToOrg() bool {
+	return label.RepoID == 0 && label.OrgID > 0
+}
+
+// IsUsableIn returns true if the label belongs to the repository or to its organization
+func (label *Label) IsUsableIn(repo *Repository) bool {
+	if label.BelongsToOrg() {
+		return label.OrgID == repo.OwnerID
+	}
+	return label.RepoID == repo.ID
+}
+
 // CalOpenIssues calculates the open issues of label.
 func (label *Label) CalOpenIssues() {
 	label.NumOpenIssues = label.NumIssues - label.NumClosedIssues
@@ -132,12 +148,12 @@ func newLabel(e Engine, label *Label) error {
 	return err
 }
 
-// NewLabel creates a new label for a repository
+// NewLabel creates a new label for a repository or an organization
 func NewLabel(label *Label) error {
 	return newLabel(x, label)
 }
 
-// NewLabels creates new labels for a repository.
+// NewLabels creates new labels for a repository or an organization.
 func NewLabels(labels ...*Label) error {
 	sess := x.NewSession()
 	defer sess.Close()
@@ -204,13 +220,24 @@ func GetLabelInRepoByName(repoID int64, labelName string) (*Label, error) {
 	return getLabelInRepoByName(x, repoID, labelName)
 }
 
+// repoLabelsCond returns the condition of the labels of a repository and of its organization
+func repoLabelsCond(repoID int64) builder.Cond {
+	return builder.Or(
+		builder.Eq{"repo_id": repoID},
+		builder.And(
+			builder.Eq{"repo_id": 0},
+			builder.In("org_id", builder.Select("owner_id").From("repository").Where(builder.Eq{"id": repoID})),
+		),
+	)
+}
+
 // GetLabelIDsInRepoByNames returns a list of labelIDs by names in a given
-// repository.
+// repository, including the labels of its organization.
 // it silently ignores label names that do not belong to the repository.
 func GetLabelIDsInRepoByNames(repoID int64, labelNames []string) ([]int64, error) {
 	labelIDs := make([]int64, 0, len(labelNames))
 	return labelIDs, x.Table("label").
-		Where("repo_id = ?", repoID).
+		Where(repoLabelsCond(repoID)).
 		In("name", labelNames).
 		Asc("name").
 		Cols("id").
@@ -222,12 +249,51 @@ func GetLabelInRepoByID(repoID, labelID int64) (*Label, error) {
 	return getLabelInRepoByID(x, repoID, labelID)
 }
 
-// GetLabelsInRepoByIDs returns a list of labels by IDs in given repository,
+// GetLabelInOrgByName returns a label by name in given organization.
+func GetLabelInOrgByName(orgID int64, labelName string) (*Label, error) {
+	if len(labelName) == 0 || orgID <= 0 {
+		return nil, ErrOrgLabelNotExist{0, orgID}
+	}
+
+	l := &Label{
+		Name:  labelName,
+		OrgID: orgID,
+	}
+	has, err := x.Where("repo_id = 0").Get(l)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrOrgLabelNotExist{0, orgID}
+	}
+	return l, nil
+}
+
+// GetLabelInOrgByID returns a label by ID in given organization.
+func GetLabelInOrgByID(orgID, labelID int64) (*Label, error) {
+	if labelID <= 0 || orgID <= 0 {
+		return nil, ErrOrgLabelNotExist{labelID, orgID}
+	}
+
+	l := &Label{
+		ID:    labelID,
+		OrgID: orgID,
+	}
+	has, err := x.Where("repo_id = 0").Get(l)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrOrgLabelNotExist{labelID, orgID}
+	}
+	return l, nil
+}
+
+// GetLabelsInRepoByIDs returns a list of labels by IDs in given repository, including
+// the labels of its organization,
 // it silently ignores label IDs that do not belong to the repository.
 func GetLabelsInRepoByIDs(repoID int64, labelIDs []int64) ([]*Label, error) {
 	labels := make([]*Label, 0, len(labelIDs))
 	return labels, x.
-		Where("repo_id = ?", repoID).
+		Where(repoLabelsCond(repoID)).
 		In("id", labelIDs).
 		Asc("name").
 		Find(&labels)
@@ -235,8 +301,23 @@ func GetLabelsInRepoByIDs(repoID int64, labelIDs []int64) ([]*Label, error) {
 
 // GetLabelsByRepoID returns all labels that belong to given repository by ID.
 func GetLabelsByRepoID(repoID int64, sortType string) ([]*Label, error) {
+	return getLabelsByCond(builder.Eq{"repo_id": repoID}, sortType)
+}
+
+// GetAvailableLabelsByRepoID returns all labels that can be used by the issues of given
+// repository, which are its own labels and the labels of its organization.
+func GetAvailableLabelsByRepoID(repoID int64, sortType string) ([]*Label, error) {
+	return getLabelsByCond(repoLabelsCond(repoID), sortType)
+}
+
+// GetLabelsByOrgID returns all labels that belong to given organization by ID.
+func GetLabelsByOrgID(orgID int64, sortType string) ([]*Label, error) {
+	return getLabelsByCond(builder.Eq{"repo_id": 0, "org_id": orgID}, sortType)
+}
+
+func getLabelsByCond(cond builder.Cond, sortType string) ([]*Label, error) {
 	labels := make([]*Label, 0, 10)
-	sess := x.Where("repo_id = ?", repoID)
+	sess := x.Where(cond)
 
 	switch sortType {
 	case "reversealphabetically":
@@ -284,7 +365,23 @@ func DeleteLabel(repoID, labelID int64) error {
 		}
 		return err
 	}
+	return deleteLabel(labelID)
+}
 
+// DeleteOrgLabel delete a label of given organization, it is removed from the issues of
+// all the repositories of the organization.
+func DeleteOrgLabel(orgID, labelID int64) error {
+	_, err := GetLabelInOrgByID(orgID, labelID)
+	if err != nil {
+		if IsErrOrgLabelNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	return deleteLabel(labelID)
+}
+
+func deleteLabel(labelID int64) (err error) {
 	sess := x.NewSession()
 	defer sess.Close()
 	if err = sess.Begin(); err != nil {
@@ -326,6 +423,25 @@ func hasIssueLabel(e Engine, issueID, labelID int64) bool {
 	return has
 }
 
+// updateOrgLabelsNumIssues recalculates the numbers of issues of the labels of an organization
+func updateOrgLabelsNumIssues(e Engine, orgID int64) error {
+	_, err := e.Exec("UPDATE `label` SET num_issues=(SELECT COUNT(*) FROM `issue_label` WHERE issue_label.label_id=label.id), "+
+		"num_closed_issues=(SELECT COUNT(*) FROM `issue_label` INNER JOIN `issue` ON issue.id=issue_label.issue_id "+
+		"WHERE issue_label.label_id=label.id AND issue.is_closed=?) WHERE repo_id=0 AND org_id=?", true, orgID)
+	return err
+}
+
+// removeOrgLabelsFromRepo removes the labels of an organization from the issues of
+// a repository which does not belong to the organization anymore.
+func removeOrgLabelsFromRepo(e Engine, orgID, repoID int64) error {
+	if _, err := e.Where(builder.In("issue_id", builder.Select("id").From("issue").Where(builder.Eq{"repo_id": repoID}))).
+		And(builder.In("label_id", builder.Select("id").From("label").Where(builder.Eq{"repo_id": 0, "org_id": orgID}))).
+		Delete(new(IssueLabel)); err != nil {
+		return err
+	}
+	return updateOrgLabelsNumIssues(e, orgID)
+}
+
 // HasIssueLabel returns true if issue has been labeled.
 func HasIssueLabel(issueID, labelID int64) bool {
 	return hasIssueLabel(x, issueID, labelID)
@@ -144,6 +144,72 @@ func TestGetLabelsByRepoID(t *testing.T) {
 	testSuccess(1, "default", []int64{1, 2})
 }
 
+func TestGetLabelsByOrgID(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	labels, err := GetLabelsByOrgID(3, "")
+	assert.NoError(t, err)
+	if assert.Len(t, labels, 1) {
+		assert.EqualValues(t, 3, labels[0].ID)
+	}
+
+	labels, err = GetLabelsByOrgID(NonexistentID, "")
+	assert.NoError(t, err)
+	assert.Len(t, labels, 0)
+}
+
+func TestGetAvailableLabelsByRepoID(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	testSuccess := func(repoID int64, expectedLabelIDs []int64) {
+		labels, err := GetAvailableLabelsByRepoID(repoID, "")
+		assert.NoError(t, err)
+		if assert.Len(t, labels, len(expectedLabelIDs)) {
+			for i, label := range labels {
+				assert.EqualValues(t, expectedLabelIDs[i], label.ID)
+			}
+		}
+	}
+	testSuccess(1, []int64{1, 2})
+	testSuccess(3, []int64{3})
+	testSuccess(2, []int64{})
+}
+
+func TestGetLabelInOrgByID(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	label, err := GetLabelInOrgByID(3, 3)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, label.ID)
+	assert.True(t, label.BelongsToOrg())
+
+	label, err = GetLabelInOrgByName(3, "orglabel3")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, label.ID)
+
+	_, err = GetLabelInOrgByID(3, 1)
+	assert.True(t, IsErrOrgLabelNotExist(err))
+
+	_, err = GetLabelInOrgByID(NonexistentID, NonexistentID)
+	assert.True(t, IsErrOrgLabelNotExist(err))
+}
+
+func TestLabel_IsUsableIn(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	repo1 := AssertExistsAndLoadBean(t, &Repository{ID: 1}).(*Repository)
+	repo3 := AssertExistsAndLoadBean(t, &Repository{ID: 3}).(*Repository)
+	label := AssertExistsAndLoadBean(t, &Label{ID: 1}).(*Label)
+	orgLabel := AssertExistsAndLoadBean(t, &Label{ID: 3}).(*Label)
+
+	assert.True(t, label.IsUsableIn(repo1))
+	assert.False(t, label.IsUsableIn(repo3))
+	assert.True(t, orgLabel.IsUsableIn(repo3))
+	assert.False(t, orgLabel.IsUsableIn(repo1))
+
+	labels, err := GetLabelsInRepoByIDs(3, []int64{1, 3})
+	assert.NoError(t, err)
+	if assert.Len(t, labels, 1) {
+		assert.EqualValues(t, 3, labels[0].ID)
+	}
+}
+
 func TestGetLabelsByIssueID(t *testing.T) {
 	assert.NoError(t, PrepareTestDatabase())
 	labels, err := GetLabelsByIssueID(1)
@@ -181,6 +247,19 @@ func TestDeleteLabel(t *testing.T) {
 	CheckConsistencyFor(t, &Label{}, &Repository{})
 }
 
+func TestDeleteOrgLabel(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	assert.NoError(t, DeleteOrgLabel(3, 1))
+	AssertExistsAndLoadBean(t, &Label{ID: 1})
+
+	assert.NoError(t, DeleteOrgLabel(3, 3))
+	AssertNotExistsBean(t, &Label{ID: 3})
+	AssertNotExistsBean(t, &IssueLabel{LabelID: 3})
+
+	assert.NoError(t, DeleteOrgLabel(NonexistentID, NonexistentID))
+	CheckConsistencyFor(t, &Label{}, &Repository{})
+}
+
 func TestHasIssueLabel(t *testing.T) {
 	assert.NoError(t, PrepareTestDatabase())
 	assert.True(t, HasIssueLabel(1, 1))
@@ -127,6 +127,12 @@ type OAuth2Config struct {
 	ClientSecret                  string
 	OpenIDConnectAutoDiscoveryURL string
 	CustomURLMapping              *oauth2.CustomURLMapping
+	OpenIDConnectOptions          *oauth2.OpenIDConnectOptions
+	AdminClaim                    string // claim granting the admin flag, synced at each login when set
+	AdminClaimValue               string // value of AdminClaim granting the admin flag, "true" when empty
+	GroupClaimName                string // claim listing the groups of the user, "groups" when empty
+	GroupTeamMap                  string // GroupTeamMapping in JSON format
+	GroupTeamMapRemoval           bool   // remove the users from the mapped teams of the groups they left
 }
 
 // FromDB fills up an OAuth2Config from serialized format.
@@ -139,6 +145,28 @@ func (cfg *OAuth2Config) ToDB() ([]byte, error) {
 	return json.Marshal(cfg)
 }
 
+// GetGroupClaimName returns the name of the claim listing the groups of the user.
+func (cfg *OAuth2Config) GetGroupClaimName() string {
+	if len(cfg.GroupClaimName) == 0 {
+		return "groups"
+	}
+	return cfg.GroupClaimName
+}
+
+// IsAdminByClaims returns true if the claims of the user grant the admin flag.
+func (cfg *OAuth2Config) IsAdminByClaims(claims map[string]interface{}) bool {
+	value := cfg.AdminClaimValue
+	if len(value) == 0 {
+		value = "true"
+	}
+	for _, v := range claimValues(claims, cfg.AdminClaim) {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
 // LoginSource represents an external way for authorizing users.
 type LoginSource struct {
 	ID            int64 `xorm:"pk autoincr"`
@@ -280,7 +308,7 @@ func CreateLoginSource(source *LoginSource) error {
 	_, err = x.Insert(source)
 	if err == nil && source.IsOAuth2() && source.IsActived {
 		oAuth2Config := source.OAuth2()
-		err = oauth2.RegisterProvider(source.Name, oAuth2Config.Provider, oAuth2Config.ClientID, oAuth2Config.ClientSecret, oAuth2Config.OpenIDConnectAutoDiscoveryURL, oAuth2Config.CustomURLMapping)
+		err = oauth2.RegisterProvider(source.Name, oAuth2Config.Provider, oAuth2Config.ClientID, oAuth2Config.ClientSecret, oAuth2Config.OpenIDConnectAutoDiscoveryURL, oAuth2Config.CustomURLMapping, oAuth2Config.OpenIDConnectOptions)
 		err = wrapOpenIDConnectInitializeError(err, source.Name, oAuth2Config)
 		if err != nil {
 			// remove the LoginSource in case of errors while registering OAuth2 providers
@@ -325,7 +353,7 @@ func UpdateSource(source *LoginSource) error {
 	_, err := x.ID(source.ID).AllCols().Update(source)
 	if err == nil && source.IsOAuth2() && source.IsActived {
 		oAuth2Config := source.OAuth2()
-		err = oauth2.RegisterProvider(source.Name, oAuth2Config.Provider, oAuth2Config.ClientID, oAuth2Config.ClientSecret, oAuth2Config.OpenIDConnectAutoDiscoveryURL, oAuth2Config.CustomURLMapping)
+		err = oauth2.RegisterProvider(source.Name, oAuth2Config.Provider, oAuth2Config.ClientID, oAuth2Config.ClientSecret, oAuth2Config.OpenIDConnectAutoDiscoveryURL, oAuth2Config.CustomURLMapping, oAuth2Config.OpenIDConnectOptions)
 		err = wrapOpenIDConnectInitializeError(err, source.Name, oAuth2Config)
 		if err != nil {
 			// restore original values since we cannot update the provider it self
@@ -404,6 +432,10 @@ func LoginViaLDAP(user *User, login, password string, source *LoginSource, autoR
 	var isAttributeSSHPublicKeySet = len(strings.TrimSpace(source.LDAP().AttributeSSHPublicKey)) > 0
 
 	if !autoRegister {
+		if err := syncLdapGroupsToTeams(user, source, sr); err != nil {
+			return user, err
+		}
+
 		if isAttributeSSHPublicKeySet && synchronizeLdapSSHPublicKeys(user, source, sr.SSHPublicKey) {
 			return user, RewriteAllPublicKeys()
 		}
@@ -438,6 +470,10 @@ func LoginViaLDAP(user *User, login, password string, source *LoginSource, autoR
 
 	err := CreateUser(user)
 
+	if err == nil {
+		err = syncLdapGroupsToTeams(user, source, sr)
+	}
+
 	if err == nil && isAttributeSSHPublicKeySet && addLdapSSHPublicKeys(user, source, sr.SSHPublicKey) {
 		err = RewriteAllPublicKeys()
 	}
@@ -445,6 +481,24 @@ func LoginViaLDAP(user *User, login, password string, source *LoginSource, autoR
 	return user, err
 }
 
+// syncLdapGroupsToTeams syncs the team memberships of the user with its LDAP groups,
+// they are kept as they are if the groups of the user couldn't be searched.
+func syncLdapGroupsToTeams(user *User, source *LoginSource, sr *ldap.SearchResult) error {
+	cfg := source.LDAP()
+	if !cfg.GroupsEnabled || len(cfg.GroupTeamMap) == 0 {
+		return nil
+	}
+	if sr.GroupsUnavailable {
+		log.Warn("syncLdapGroupsToTeams: the LDAP groups of %s are unavailable, the teams are not synced", user.Name)
+		return nil
+	}
+	mapping, err := ParseGroupTeamMapping(cfg.GroupTeamMap)
+	if err != nil {
+		return fmt.Errorf("ParseGroupTeamMapping: %v", err)
+	}
+	return SyncGroupsToTeams(user, sr.Groups, mapping, cfg.GroupTeamMapRemoval)
+}
+
 //   _________   __________________________
 //  /   _____/  /     \__    ___/\______   \
 //  \_____  \  /  \ /  \|    |    |     ___/
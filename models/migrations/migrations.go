@@ -236,6 +236,42 @@ var migrations = []Migration{
 	NewMigration("add original author/url migration info to issues, comments, and repo ", addOriginalMigrationInfo),
 	// v90 -> v91
 	NewMigration("change length of some repository columns", changeSomeColumnsLengthOfRepo),
+	// v91 -> v92
+	NewMigration("add push mirror table", addPushMirrorTable),
+	// v92 -> v93
+	NewMigration("add action runner, run and job tables", addActionTables),
+	// v93 -> v94
+	NewMigration("add commit id and dismissed columns to review", addReviewCommitAndDismissed),
+	// v94 -> v95
+	NewMigration("add status check and dismiss stale approvals to protected branch", addStatusCheckAndDismissStaleApprovalsToProtectedBranch),
+	// v95 -> v96
+	NewMigration("add task table and status column for repository table", addTaskTable),
+	// v96 -> v97
+	NewMigration("add retry columns to hook_task table", addHookTaskRetryColumns),
+	// v97 -> v98
+	NewMigration("add sub-events of issues and pull requests to webhooks", addWebhookSubEvents),
+	// v98 -> v99
+	NewMigration("add is_system_webhook column to webhook table", addWebhookIsSystemWebhookColumn),
+	// v99 -> v100
+	NewMigration("add template columns to repository table", addTemplateToRepo),
+	// v100 -> v101
+	NewMigration("add project tables and the projects unit to teams", addProjectTables),
+	// v101 -> v102
+	NewMigration("add org_id to label", addOrgIDToLabel),
+	// v102 -> v103
+	NewMigration("add scope and repository restrictions to access tokens", addScopeToAccessToken),
+	// v103 -> v104
+	NewMigration("add scope to oauth2 grants and authorization codes", addScopeToOAuth2Grant),
+	// v104 -> v105
+	NewMigration("add package tables", addPackageTables),
+	// v105 -> v106
+	NewMigration("add flow to pull request", addFlowToPullRequest),
+	// v106 -> v107
+	NewMigration("add approval to action runs and tokens to action run jobs", addApprovalAndJobTokenToActionRuns),
+	// v107 -> v108
+	NewMigration("add stage comments to task", addStageCommentsToTask),
+	// v108 -> v109
+	NewMigration("add package upload table", addPackageUploadTable),
 }
 
 // Migrate database to current version
@@ -0,0 +1,62 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/go-xorm/xorm"
+)
+
+func addProjectTables(x *xorm.Engine) error {
+	type Project struct {
+		ID             int64          `xorm:"pk autoincr"`
+		RepoID         int64          `xorm:"INDEX"`
+		OwnerID        int64          `xorm:"INDEX"`
+		CreatorID      int64          `xorm:"NOT NULL"`
+		Title          string         `xorm:"NOT NULL"`
+		Description    string         `xorm:"TEXT"`
+		IsClosed       bool           `xorm:"INDEX"`
+		CreatedUnix    util.TimeStamp `xorm:"INDEX created"`
+		UpdatedUnix    util.TimeStamp `xorm:"INDEX updated"`
+		ClosedDateUnix util.TimeStamp
+	}
+
+	type ProjectBoard struct {
+		ID          int64 `xorm:"pk autoincr"`
+		ProjectID   int64 `xorm:"INDEX NOT NULL"`
+		Title       string
+		Sorting     int
+		Trigger     int            `xorm:"board_trigger NOT NULL DEFAULT 0"`
+		CreatorID   int64          `xorm:"NOT NULL"`
+		CreatedUnix util.TimeStamp `xorm:"INDEX created"`
+		UpdatedUnix util.TimeStamp `xorm:"INDEX updated"`
+	}
+
+	type ProjectIssue struct {
+		ID             int64 `xorm:"pk autoincr"`
+		IssueID        int64 `xorm:"UNIQUE(s) INDEX NOT NULL"`
+		ProjectID      int64 `xorm:"UNIQUE(s) INDEX NOT NULL"`
+		ProjectBoardID int64 `xorm:"INDEX"`
+		Sorting        int   `xorm:"NOT NULL DEFAULT 0"`
+	}
+
+	if err := x.Sync2(new(Project), new(ProjectBoard), new(ProjectIssue)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+
+	// Teams with access to the issues of the repositories get access to their projects
+	const (
+		unitTypeIssues   = 2
+		unitTypeProjects = 9
+	)
+	if _, err := x.Exec("INSERT INTO team_unit (org_id, team_id, type) SELECT org_id, team_id, ? FROM team_unit WHERE type = ?",
+		unitTypeProjects, unitTypeIssues); err != nil {
+		return fmt.Errorf("add projects unit to teams: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"github.com/go-xorm/xorm"
+)
+
+// OAuth2GrantV103 describes the added scope column of oauth2_grant
+type OAuth2GrantV103 struct {
+	ID    int64  `xorm:"pk autoincr"`
+	Scope string `xorm:"TEXT"`
+}
+
+// TableName will be invoked by XORM to customize the table name
+func (*OAuth2GrantV103) TableName() string {
+	return "oauth2_grant"
+}
+
+// OAuth2AuthorizationCodeV103 describes the added columns of oauth2_authorization_code
+type OAuth2AuthorizationCodeV103 struct {
+	ID    int64  `xorm:"pk autoincr"`
+	Scope string `xorm:"TEXT"`
+	Nonce string `xorm:"TEXT"`
+}
+
+// TableName will be invoked by XORM to customize the table name
+func (*OAuth2AuthorizationCodeV103) TableName() string {
+	return "oauth2_authorization_code"
+}
+
+func addScopeToOAuth2Grant(x *xorm.Engine) error {
+	if err := x.Sync2(new(OAuth2GrantV103), new(OAuth2AuthorizationCodeV103)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/go-xorm/xorm"
+)
+
+// PackageV104 describes the package table
+type PackageV104 struct {
+	ID        int64  `xorm:"pk autoincr"`
+	OwnerID   int64  `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	RepoID    int64  `xorm:"INDEX"`
+	Type      string `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	Name      string `xorm:"NOT NULL"`
+	LowerName string `xorm:"UNIQUE(s) INDEX NOT NULL"`
+
+	CreatedUnix util.TimeStamp `xorm:"INDEX created"`
+	UpdatedUnix util.TimeStamp `xorm:"INDEX updated"`
+}
+
+// TableName will be invoked by XORM to customize the table name
+func (*PackageV104) TableName() string {
+	return "package"
+}
+
+// PackageVersionV104 describes the package_version table
+type PackageVersionV104 struct {
+	ID            int64  `xorm:"pk autoincr"`
+	PackageID     int64  `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	CreatorID     int64  `xorm:"NOT NULL"`
+	Version       string `xorm:"NOT NULL"`
+	LowerVersion  string `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	DownloadCount int64  `xorm:"NOT NULL DEFAULT 0"`
+
+	CreatedUnix util.TimeStamp `xorm:"INDEX created"`
+	UpdatedUnix util.TimeStamp `xorm:"INDEX updated"`
+}
+
+// TableName will be invoked by XORM to customize the table name
+func (*PackageVersionV104) TableName() string {
+	return "package_version"
+}
+
+// PackageFileV104 describes the package_file table
+type PackageFileV104 struct {
+	ID        int64  `xorm:"pk autoincr"`
+	VersionID int64  `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	BlobID    int64  `xorm:"INDEX NOT NULL"`
+	Name      string `xorm:"NOT NULL"`
+	LowerName string `xorm:"UNIQUE(s) INDEX NOT NULL"`
+
+	CreatedUnix util.TimeStamp `xorm:"INDEX created"`
+}
+
+// TableName will be invoked by XORM to customize the table name
+func (*PackageFileV104) TableName() string {
+	return "package_file"
+}
+
+// PackageBlobV104 describes the package_blob table
+type PackageBlobV104 struct {
+	ID          int64          `xorm:"pk autoincr"`
+	Size        int64          `xorm:"NOT NULL DEFAULT 0"`
+	HashSHA256  string         `xorm:"'hash_sha256' UNIQUE NOT NULL"`
+	CreatedUnix util.TimeStamp `xorm:"INDEX created"`
+}
+
+// TableName will be invoked by XORM to customize the table name
+func (*PackageBlobV104) TableName() string {
+	return "package_blob"
+}
+
+func addPackageTables(x *xorm.Engine) error {
+	if err := x.Sync2(new(PackageV104), new(PackageVersionV104), new(PackageFileV104), new(PackageBlobV104)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+	return nil
+}
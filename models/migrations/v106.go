@@ -0,0 +1,31 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"github.com/go-xorm/xorm"
+)
+
+func addApprovalAndJobTokenToActionRuns(x *xorm.Engine) error {
+	type ActionRun struct {
+		ID           int64 `xorm:"pk autoincr"`
+		NeedApproval bool
+		ApprovedBy   int64
+	}
+
+	type ActionRunJob struct {
+		ID             int64 `xorm:"pk autoincr"`
+		TokenHash      string
+		TokenSalt      string
+		TokenLastEight string `xorm:"token_last_eight INDEX"`
+	}
+
+	if err := x.Sync2(new(ActionRun), new(ActionRunJob)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+	return nil
+}
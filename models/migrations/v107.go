@@ -0,0 +1,23 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"github.com/go-xorm/xorm"
+)
+
+func addStageCommentsToTask(x *xorm.Engine) error {
+	type Task struct {
+		ID            int64
+		StageComments int
+	}
+
+	if err := x.Sync2(new(Task)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+	return nil
+}
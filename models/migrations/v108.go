@@ -0,0 +1,30 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/go-xorm/xorm"
+)
+
+func addPackageUploadTable(x *xorm.Engine) error {
+	type PackageUpload struct {
+		ID          int64          `xorm:"pk autoincr"`
+		UUID        string         `xorm:"uuid UNIQUE NOT NULL"`
+		OwnerID     int64          `xorm:"INDEX NOT NULL"`
+		CreatorID   int64          `xorm:"NOT NULL DEFAULT 0"`
+		LowerName   string         `xorm:"NOT NULL"`
+		CreatedUnix util.TimeStamp `xorm:"created"`
+		UpdatedUnix util.TimeStamp `xorm:"INDEX updated"`
+	}
+
+	if err := x.Sync2(new(PackageUpload)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/go-xorm/xorm"
+)
+
+func addActionTables(x *xorm.Engine) error {
+	type ActionRunner struct {
+		ID             int64  `xorm:"pk autoincr"`
+		UUID           string `xorm:"uuid UNIQUE"`
+		Name           string
+		RepoID         int64    `xorm:"INDEX"`
+		Labels         []string `xorm:"TEXT JSON"`
+		TokenHash      string   `xorm:"UNIQUE"`
+		TokenSalt      string
+		TokenLastEight string         `xorm:"token_last_eight INDEX"`
+		LastOnlineUnix util.TimeStamp `xorm:"INDEX"`
+		CreatedUnix    util.TimeStamp `xorm:"created"`
+		UpdatedUnix    util.TimeStamp `xorm:"updated"`
+	}
+
+	type ActionRunnerToken struct {
+		ID          int64  `xorm:"pk autoincr"`
+		Token       string `xorm:"UNIQUE"`
+		RepoID      int64  `xorm:"INDEX"`
+		IsActive    bool
+		CreatedUnix util.TimeStamp `xorm:"created"`
+		UpdatedUnix util.TimeStamp `xorm:"updated"`
+	}
+
+	type ActionRun struct {
+		ID            int64 `xorm:"pk autoincr"`
+		RepoID        int64 `xorm:"INDEX"`
+		WorkflowID    string
+		Title         string
+		TriggerUserID int64
+		Ref           string
+		CommitSHA     string `xorm:"VARCHAR(40)"`
+		Event         string
+		PullRequestID int64
+		Status        int `xorm:"INDEX"`
+		StartedUnix   util.TimeStamp
+		StoppedUnix   util.TimeStamp
+		CreatedUnix   util.TimeStamp `xorm:"created"`
+		UpdatedUnix   util.TimeStamp `xorm:"updated"`
+	}
+
+	type ActionRunJob struct {
+		ID          int64 `xorm:"pk autoincr"`
+		RunID       int64 `xorm:"INDEX"`
+		RepoID      int64 `xorm:"INDEX"`
+		JobID       string
+		Name        string
+		RunsOn      []string          `xorm:"TEXT JSON"`
+		Env         map[string]string `xorm:"TEXT JSON"`
+		Steps       string            `xorm:"TEXT"`
+		RunnerID    int64             `xorm:"INDEX"`
+		Status      int               `xorm:"INDEX"`
+		LogLength   int64
+		StartedUnix util.TimeStamp
+		StoppedUnix util.TimeStamp
+		CreatedUnix util.TimeStamp `xorm:"created"`
+		UpdatedUnix util.TimeStamp `xorm:"INDEX updated"`
+	}
+
+	type ActionRunJobLog struct {
+		ID      int64 `xorm:"pk autoincr"`
+		JobID   int64 `xorm:"INDEX"`
+		Offset  int64
+		Content string `xorm:"LONGTEXT"`
+	}
+
+	if err := x.Sync2(new(ActionRunner), new(ActionRunnerToken), new(ActionRun), new(ActionRunJob), new(ActionRunJobLog)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+
+	// Teams with access to the code of the repositories get access to their actions
+	const (
+		unitTypeCode    = 1
+		unitTypeActions = 8
+	)
+	if _, err := x.Exec("INSERT INTO team_unit (org_id, team_id, type) SELECT org_id, team_id, ? FROM team_unit WHERE type = ?",
+		unitTypeActions, unitTypeCode); err != nil {
+		return fmt.Errorf("add actions unit to teams: %v", err)
+	}
+	return nil
+}
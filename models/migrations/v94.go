@@ -0,0 +1,24 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"github.com/go-xorm/xorm"
+)
+
+func addStatusCheckAndDismissStaleApprovalsToProtectedBranch(x *xorm.Engine) error {
+	type ProtectedBranch struct {
+		DismissStaleApprovals bool     `xorm:"NOT NULL DEFAULT false"`
+		EnableStatusCheck     bool     `xorm:"NOT NULL DEFAULT false"`
+		StatusCheckContexts   []string `xorm:"JSON TEXT"`
+	}
+
+	if err := x.Sync2(new(ProtectedBranch)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-xorm/xorm"
+)
+
+func addWebhookSubEvents(x *xorm.Engine) error {
+	type HookEvent struct {
+		PushOnly       bool                   `json:"push_only"`
+		SendEverything bool                   `json:"send_everything"`
+		ChooseEvents   bool                   `json:"choose_events"`
+		Events         map[string]interface{} `json:"events"`
+	}
+
+	type Webhook struct {
+		ID     int64
+		Events string
+	}
+
+	// the sub-events were part of their parent events before, so they're chosen
+	// for the webhooks which chose the parent events
+	subEvents := map[string][]string{
+		"issues":       {"issue_assign", "issue_label", "issue_milestone"},
+		"pull_request": {"pull_request_assign", "pull_request_label", "pull_request_milestone", "pull_request_sync", "pull_request_comment"},
+	}
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	var hooks []*Webhook
+	if err := sess.Find(&hooks); err != nil {
+		return fmt.Errorf("find webhooks: %v", err)
+	}
+	for _, hook := range hooks {
+		event := &HookEvent{}
+		if err := json.Unmarshal([]byte(hook.Events), event); err != nil || !event.ChooseEvents || event.Events == nil {
+			continue
+		}
+		for parent, events := range subEvents {
+			chosen, _ := event.Events[parent].(bool)
+			for _, name := range events {
+				event.Events[name] = chosen
+			}
+		}
+
+		data, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("encode events of webhook %d: %v", hook.ID, err)
+		}
+		if _, err = sess.ID(hook.ID).Cols("events").Update(&Webhook{Events: string(data)}); err != nil {
+			return fmt.Errorf("update events of webhook %d: %v", hook.ID, err)
+		}
+	}
+	return sess.Commit()
+}
@@ -93,7 +93,16 @@ func init() {
 		new(Label),
 		new(IssueLabel),
 		new(Milestone),
+		new(Project),
+		new(ProjectBoard),
+		new(ProjectIssue),
 		new(Mirror),
+		new(PushMirror),
+		new(ActionRunner),
+		new(ActionRunnerToken),
+		new(ActionRun),
+		new(ActionRunJob),
+		new(ActionRunJobLog),
 		new(Release),
 		new(LoginSource),
 		new(Webhook),
@@ -131,6 +140,12 @@ func init() {
 		new(OAuth2Application),
 		new(OAuth2AuthorizationCode),
 		new(OAuth2Grant),
+		new(Task),
+		new(Package),
+		new(PackageVersion),
+		new(PackageFile),
+		new(PackageBlob),
+		new(PackageUpload),
 	)
 
 	gonicNames := []string{"SSL", "UID"}
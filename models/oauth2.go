@@ -5,9 +5,12 @@
 package models
 
 import (
+	"fmt"
 	"sort"
+	"strconv"
 
 	"code.gitea.io/gitea/modules/auth/oauth2"
+	"code.gitea.io/gitea/modules/setting"
 )
 
 // OAuth2Provider describes the display values of a single OAuth2 provider
@@ -102,11 +105,16 @@ func InitOAuth2() error {
 	if err := oauth2.Init(x); err != nil {
 		return err
 	}
+	if setting.OAuth2.Enable {
+		if err := oauth2.InitSigningKey(); err != nil {
+			return err
+		}
+	}
 	loginSources, _ := GetActiveOAuth2ProviderLoginSources()
 
 	for _, source := range loginSources {
 		oAuth2Config := source.OAuth2()
-		err := oauth2.RegisterProvider(source.Name, oAuth2Config.Provider, oAuth2Config.ClientID, oAuth2Config.ClientSecret, oAuth2Config.OpenIDConnectAutoDiscoveryURL, oAuth2Config.CustomURLMapping)
+		err := oauth2.RegisterProvider(source.Name, oAuth2Config.Provider, oAuth2Config.ClientID, oAuth2Config.ClientSecret, oAuth2Config.OpenIDConnectAutoDiscoveryURL, oAuth2Config.CustomURLMapping, oAuth2Config.OpenIDConnectOptions)
 		if err != nil {
 			return err
 		}
@@ -114,6 +122,63 @@ func InitOAuth2() error {
 	return nil
 }
 
+// SyncOAuth2User updates the admin flag and the team memberships of a user
+// from the claims returned by its OAuth2 login source, the last administrator
+// of the instance isn't demoted.
+func SyncOAuth2User(u *User, source *LoginSource, claims map[string]interface{}) error {
+	cfg := source.OAuth2()
+	if len(cfg.AdminClaim) > 0 {
+		if isAdmin := cfg.IsAdminByClaims(claims); isAdmin != u.IsAdmin {
+			// the last administrator is kept, like the first user who is made
+			// administrator when registering on a new instance
+			isLastAdmin := false
+			if !isAdmin {
+				count, err := x.Where("is_admin = ?", true).Count(new(User))
+				if err != nil {
+					return err
+				}
+				isLastAdmin = count <= 1
+			}
+			if !isLastAdmin {
+				u.IsAdmin = isAdmin
+				if err := UpdateUserCols(u, "is_admin"); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if len(cfg.GroupTeamMap) == 0 {
+		return nil
+	}
+	mapping, err := ParseGroupTeamMapping(cfg.GroupTeamMap)
+	if err != nil {
+		return fmt.Errorf("ParseGroupTeamMapping: %v", err)
+	}
+	return SyncGroupsToTeams(u, claimValues(claims, cfg.GetGroupClaimName()), mapping, cfg.GroupTeamMapRemoval)
+}
+
+// claimValues returns the values of a claim holding a string, a boolean or a list of strings
+func claimValues(claims map[string]interface{}, name string) []string {
+	switch v := claims[name].(type) {
+	case string:
+		return []string{v}
+	case bool:
+		return []string{strconv.FormatBool(v)}
+	case []string:
+		return v
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, value := range v {
+			if s, ok := value.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
 // wrapOpenIDConnectInitializeError is used to wrap the error but this cannot be done in modules/auth/oauth2
 // inside oauth2: import cycle not allowed models -> modules/auth/oauth2 -> models
 func wrapOpenIDConnectInitializeError(err error, providerName string, oAuth2Config *OAuth2Config) error {
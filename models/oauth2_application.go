@@ -9,11 +9,14 @@ import (
 	"encoding/base64"
 	"fmt"
 	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-xorm/xorm"
 	uuid "github.com/satori/go.uuid"
 
+	"code.gitea.io/gitea/modules/auth/oauth2"
 	"code.gitea.io/gitea/modules/secret"
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/util"
@@ -104,14 +107,15 @@ func (app *OAuth2Application) getGrantByUserID(e Engine, userID int64) (grant *O
 }
 
 // CreateGrant generates a grant for an user
-func (app *OAuth2Application) CreateGrant(userID int64) (*OAuth2Grant, error) {
-	return app.createGrant(x, userID)
+func (app *OAuth2Application) CreateGrant(userID int64, scope OAuth2Scope) (*OAuth2Grant, error) {
+	return app.createGrant(x, userID, scope)
 }
 
-func (app *OAuth2Application) createGrant(e Engine, userID int64) (*OAuth2Grant, error) {
+func (app *OAuth2Application) createGrant(e Engine, userID int64, scope OAuth2Scope) (*OAuth2Grant, error) {
 	grant := &OAuth2Grant{
 		ApplicationID: app.ID,
 		UserID:        userID,
+		Scope:         string(scope),
 	}
 	_, err := e.Insert(grant)
 	if err != nil {
@@ -264,7 +268,10 @@ type OAuth2AuthorizationCode struct {
 	CodeChallenge       string
 	CodeChallengeMethod string
 	RedirectURI         string
-	ValidUntil          util.TimeStamp `xorm:"index"`
+	// Scope is the scope the code was requested with
+	Scope      string         `xorm:"TEXT"`
+	Nonce      string         `xorm:"TEXT"`
+	ValidUntil util.TimeStamp `xorm:"index"`
 }
 
 // TableName sets the table name to `oauth2_authorization_code`
@@ -348,8 +355,10 @@ type OAuth2Grant struct {
 	Application   *OAuth2Application `xorm:"-"`
 	ApplicationID int64              `xorm:"INDEX unique(user_application)"`
 	Counter       int64              `xorm:"NOT NULL DEFAULT 1"`
-	CreatedUnix   util.TimeStamp     `xorm:"created"`
-	UpdatedUnix   util.TimeStamp     `xorm:"updated"`
+	// Scope is the union of the scopes the user consented to
+	Scope       string         `xorm:"TEXT"`
+	CreatedUnix util.TimeStamp `xorm:"created"`
+	UpdatedUnix util.TimeStamp `xorm:"updated"`
 }
 
 // TableName sets the table name to `oauth2_grant`
@@ -358,11 +367,11 @@ func (grant *OAuth2Grant) TableName() string {
 }
 
 // GenerateNewAuthorizationCode generates a new authorization code for a grant and saves it to the databse
-func (grant *OAuth2Grant) GenerateNewAuthorizationCode(redirectURI, codeChallenge, codeChallengeMethod string) (*OAuth2AuthorizationCode, error) {
-	return grant.generateNewAuthorizationCode(x, redirectURI, codeChallenge, codeChallengeMethod)
+func (grant *OAuth2Grant) GenerateNewAuthorizationCode(redirectURI, codeChallenge, codeChallengeMethod string, scope OAuth2Scope, nonce string) (*OAuth2AuthorizationCode, error) {
+	return grant.generateNewAuthorizationCode(x, redirectURI, codeChallenge, codeChallengeMethod, scope, nonce)
 }
 
-func (grant *OAuth2Grant) generateNewAuthorizationCode(e Engine, redirectURI, codeChallenge, codeChallengeMethod string) (code *OAuth2AuthorizationCode, err error) {
+func (grant *OAuth2Grant) generateNewAuthorizationCode(e Engine, redirectURI, codeChallenge, codeChallengeMethod string, scope OAuth2Scope, nonce string) (code *OAuth2AuthorizationCode, err error) {
 	var codeSecret string
 	if codeSecret, err = secret.New(); err != nil {
 		return &OAuth2AuthorizationCode{}, err
@@ -374,6 +383,8 @@ func (grant *OAuth2Grant) generateNewAuthorizationCode(e Engine, redirectURI, co
 		Code:                codeSecret,
 		CodeChallenge:       codeChallenge,
 		CodeChallengeMethod: codeChallengeMethod,
+		Scope:               string(scope),
+		Nonce:               nonce,
 	}
 	if _, err := e.Insert(code); err != nil {
 		return nil, err
@@ -381,6 +392,13 @@ func (grant *OAuth2Grant) generateNewAuthorizationCode(e Engine, redirectURI, co
 	return code, nil
 }
 
+// AddScope adds the scopes the user consented to to the grant
+func (grant *OAuth2Grant) AddScope(scope OAuth2Scope) error {
+	grant.Scope = string(OAuth2Scope(grant.Scope).Union(scope))
+	_, err := x.ID(grant.ID).Cols("scope").Update(grant)
+	return err
+}
+
 // IncreaseCounter increases the counter and updates the grant
 func (grant *OAuth2Grant) IncreaseCounter() error {
 	return grant.increaseCount(x)
@@ -473,6 +491,9 @@ type OAuth2Token struct {
 	GrantID int64           `json:"gnt"`
 	Type    OAuth2TokenType `json:"tt"`
 	Counter int64           `json:"cnt,omitempty"`
+	// Scope is the scope the token was requested with, the tokens issued before
+	// the scopes were supported have none.
+	Scope string `json:"scp,omitempty"`
 	jwt.StandardClaims
 }
 
@@ -501,3 +522,107 @@ func (token *OAuth2Token) SignToken() (string, error) {
 	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS512, token)
 	return jwtToken.SignedString(setting.OAuth2.JWTSecretBytes)
 }
+
+//////////////////////////////////////////////////////////////
+
+// OIDCUserInfo represents the claims about a user released to an OpenID Connect client
+type OIDCUserInfo struct {
+	// Scope profile
+	Name              string `json:"name,omitempty"`
+	PreferredUsername string `json:"preferred_username,omitempty"`
+	Profile           string `json:"profile,omitempty"`
+	Picture           string `json:"picture,omitempty"`
+	Website           string `json:"website,omitempty"`
+	Locale            string `json:"locale,omitempty"`
+	UpdatedAt         int64  `json:"updated_at,omitempty"`
+
+	// Scope email
+	Email         string `json:"email,omitempty"`
+	EmailVerified bool   `json:"email_verified,omitempty"`
+
+	// Scope groups
+	Groups []string `json:"groups,omitempty"`
+}
+
+// GetOIDCUserInfo returns the claims about the user released with the given scope
+func GetOIDCUserInfo(user *User, scope OAuth2Scope) (*OIDCUserInfo, error) {
+	info := new(OIDCUserInfo)
+	if scope.Has(OAuth2ScopeProfile) {
+		info.Name = user.DisplayName()
+		info.PreferredUsername = user.Name
+		info.Profile = user.HTMLURL()
+		info.Picture = user.AvatarLink()
+		info.Website = user.Website
+		info.Locale = user.Language
+		info.UpdatedAt = int64(user.UpdatedUnix)
+	}
+	if scope.Has(OAuth2ScopeEmail) {
+		info.Email = user.Email
+		info.EmailVerified = user.IsActive
+	}
+	if scope.Has(OAuth2ScopeGroups) {
+		orgs, err := GetOrgsByUserID(user.ID, true)
+		if err != nil {
+			return nil, fmt.Errorf("GetOrgsByUserID: %v", err)
+		}
+		orgNames := make(map[int64]string, len(orgs))
+		for _, org := range orgs {
+			orgNames[org.ID] = org.Name
+			info.Groups = append(info.Groups, org.Name)
+		}
+		teams, err := GetUserTeams(user.ID)
+		if err != nil {
+			return nil, fmt.Errorf("GetUserTeams: %v", err)
+		}
+		for _, team := range teams {
+			if orgName, ok := orgNames[team.OrgID]; ok {
+				info.Groups = append(info.Groups, orgName+":"+team.LowerName)
+			}
+		}
+	}
+	return info, nil
+}
+
+// OIDCToken represents an OpenID Connect id_token
+type OIDCToken struct {
+	jwt.StandardClaims
+	Nonce string `json:"nonce,omitempty"`
+	*OIDCUserInfo
+}
+
+// NewOIDCToken creates the id_token of a grant for the client app
+func NewOIDCToken(grant *OAuth2Grant, app *OAuth2Application, scope OAuth2Scope, nonce string) (*OIDCToken, error) {
+	user, err := GetUserByID(grant.UserID)
+	if err != nil {
+		return nil, err
+	}
+	info, err := GetOIDCUserInfo(user, scope)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	return &OIDCToken{
+		StandardClaims: jwt.StandardClaims{
+			Issuer:    OIDCIssuer(),
+			Subject:   strconv.FormatInt(user.ID, 10),
+			Audience:  app.ClientID,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(time.Duration(setting.OAuth2.AccessTokenExpirationTime) * time.Second).Unix(),
+		},
+		Nonce:        nonce,
+		OIDCUserInfo: info,
+	}, nil
+}
+
+// SignToken signs the id_token with the JWT signing key
+func (token *OIDCToken) SignToken() (string, error) {
+	if oauth2.DefaultSigningKey == nil {
+		return "", fmt.Errorf("the JWT signing key is not initialized")
+	}
+	return oauth2.DefaultSigningKey.SignToken(token)
+}
+
+// OIDCIssuer returns the issuer identifier of the OpenID Connect provider
+func OIDCIssuer() string {
+	return strings.TrimSuffix(setting.AppURL, "/")
+}
@@ -94,11 +94,12 @@ func TestOAuth2Application_GetGrantByUserID(t *testing.T) {
 func TestOAuth2Application_CreateGrant(t *testing.T) {
 	assert.NoError(t, PrepareTestDatabase())
 	app := AssertExistsAndLoadBean(t, &OAuth2Application{ID: 1}).(*OAuth2Application)
-	grant, err := app.CreateGrant(2)
+	grant, err := app.CreateGrant(2, "openid email")
 	assert.NoError(t, err)
 	assert.NotNil(t, grant)
 	assert.Equal(t, int64(2), grant.UserID)
 	assert.Equal(t, int64(1), grant.ApplicationID)
+	assert.Equal(t, "openid email", grant.Scope)
 }
 
 //////////////////// Grant
@@ -125,10 +126,21 @@ func TestOAuth2Grant_IncreaseCounter(t *testing.T) {
 func TestOAuth2Grant_GenerateNewAuthorizationCode(t *testing.T) {
 	assert.NoError(t, PrepareTestDatabase())
 	grant := AssertExistsAndLoadBean(t, &OAuth2Grant{ID: 1}).(*OAuth2Grant)
-	code, err := grant.GenerateNewAuthorizationCode("https://example2.com/callback", "CjvyTLSdR47G5zYenDA-eDWW4lRrO8yvjcWwbD_deOg", "S256")
+	code, err := grant.GenerateNewAuthorizationCode("https://example2.com/callback", "CjvyTLSdR47G5zYenDA-eDWW4lRrO8yvjcWwbD_deOg", "S256", "openid", "n-0S6_WzA2Mj")
 	assert.NoError(t, err)
 	assert.NotNil(t, code)
 	assert.True(t, len(code.Code) > 32) // secret length > 32
+	assert.Equal(t, "openid", code.Scope)
+	assert.Equal(t, "n-0S6_WzA2Mj", code.Nonce)
+}
+
+func TestOAuth2Grant_AddScope(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	grant := AssertExistsAndLoadBean(t, &OAuth2Grant{ID: 1}).(*OAuth2Grant)
+	assert.NoError(t, grant.AddScope("email openid"))
+	assert.NoError(t, grant.AddScope("openid repo:read"))
+	assert.Equal(t, "openid email repo:read", grant.Scope)
+	AssertExistsAndLoadBean(t, &OAuth2Grant{ID: 1, Scope: "openid email repo:read"})
 }
 
 func TestOAuth2Grant_TableName(t *testing.T) {
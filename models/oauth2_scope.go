@@ -0,0 +1,130 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"strings"
+)
+
+// Enumerate the OpenID Connect scopes, the other OAuth2 scopes are the access token scopes
+const (
+	OAuth2ScopeOpenID  = "openid"  // issue an id_token
+	OAuth2ScopeProfile = "profile" // name, username, avatar and website of the user
+	OAuth2ScopeEmail   = "email"   // primary email address of the user
+	OAuth2ScopeGroups  = "groups"  // organizations and teams of the user
+)
+
+// OpenIDConnectScopes lists the OpenID Connect scopes in their display order
+var OpenIDConnectScopes = []string{
+	OAuth2ScopeOpenID,
+	OAuth2ScopeProfile,
+	OAuth2ScopeEmail,
+	OAuth2ScopeGroups,
+}
+
+// OAuth2Scope is a space separated list of the scopes requested by or granted to an OAuth2 application
+type OAuth2Scope string
+
+// Names returns the names of the scopes
+func (s OAuth2Scope) Names() []string {
+	return strings.Fields(string(s))
+}
+
+// Has returns true if the scope contains name
+func (s OAuth2Scope) Has(name string) bool {
+	for _, n := range s.Names() {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Includes returns true if all the scopes of other are in s
+func (s OAuth2Scope) Includes(other OAuth2Scope) bool {
+	for _, name := range other.Names() {
+		if !s.Has(name) {
+			return false
+		}
+	}
+	return true
+}
+
+// Union returns the scopes in s or in other
+func (s OAuth2Scope) Union(other OAuth2Scope) OAuth2Scope {
+	scope, _ := NormalizeOAuth2Scope(string(s) + " " + string(other))
+	return scope
+}
+
+// AccessTokenScope returns the access token scopes separated by commas, an OAuth2
+// application is granted all of them when it requests no scope at all, and none of
+// them when it only requests OpenID Connect scopes.
+func (s OAuth2Scope) AccessTokenScope() string {
+	if len(s.Names()) == 0 {
+		return string(AccessTokenScopeAll)
+	}
+	var names []string
+	for _, name := range s.Names() {
+		if AccessTokenScope(name).IsValid() {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return string(AccessTokenScopeNone)
+	}
+	scope, _ := NormalizeAccessTokenScope(names)
+	return scope
+}
+
+// DescKeys returns the locale keys of the descriptions of the scopes
+func (s OAuth2Scope) DescKeys() []string {
+	names := s.Names()
+	keys := make([]string, len(names))
+	for i, name := range names {
+		if AccessTokenScope(name).IsValid() {
+			keys[i] = AccessTokenScope(name).DescKey()
+		} else {
+			keys[i] = "auth.oauth2_scope." + name
+		}
+	}
+	return keys
+}
+
+func isValidOAuth2Scope(name string) bool {
+	if AccessTokenScope(name).IsValid() {
+		return true
+	}
+	for _, scope := range OpenIDConnectScopes {
+		if name == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeOAuth2Scope validates a space separated list of scope names and
+// returns them without duplicates in their display order.
+func NormalizeOAuth2Scope(scope string) (OAuth2Scope, error) {
+	granted := make(map[string]bool)
+	for _, name := range strings.Fields(scope) {
+		if !isValidOAuth2Scope(name) {
+			return "", ErrOAuth2ScopeInvalid{Scope: name}
+		}
+		granted[name] = true
+	}
+
+	names := make([]string, 0, len(granted))
+	for _, name := range OpenIDConnectScopes {
+		if granted[name] {
+			names = append(names, name)
+		}
+	}
+	for _, name := range AccessTokenScopes {
+		if granted[string(name)] {
+			names = append(names, string(name))
+		}
+	}
+	return OAuth2Scope(strings.Join(names, " ")), nil
+}
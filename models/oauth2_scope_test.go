@@ -0,0 +1,48 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeOAuth2Scope(t *testing.T) {
+	for _, test := range []struct {
+		Scope    string
+		Expected OAuth2Scope
+	}{
+		{"", ""},
+		{"openid", "openid"},
+		{"user email  openid email", "openid email user"},
+		{"repo:read groups profile", "profile groups repo:read"},
+	} {
+		scope, err := NormalizeOAuth2Scope(test.Scope)
+		assert.NoError(t, err)
+		assert.Equal(t, test.Expected, scope)
+	}
+
+	_, err := NormalizeOAuth2Scope("openid address")
+	assert.True(t, IsErrOAuth2ScopeInvalid(err))
+}
+
+func TestOAuth2Scope_Includes(t *testing.T) {
+	scope := OAuth2Scope("openid email repo:read")
+	assert.True(t, scope.Has(OAuth2ScopeEmail))
+	assert.False(t, scope.Has(OAuth2ScopeProfile))
+	assert.True(t, scope.Includes(""))
+	assert.True(t, scope.Includes("repo:read openid"))
+	assert.False(t, scope.Includes("openid profile"))
+	assert.Equal(t, OAuth2Scope("openid profile email repo:read"), scope.Union("profile email"))
+}
+
+func TestOAuth2Scope_AccessTokenScope(t *testing.T) {
+	assert.Equal(t, "all", OAuth2Scope("").AccessTokenScope())
+	assert.Equal(t, "none", OAuth2Scope("openid email").AccessTokenScope())
+	assert.Equal(t, "repo:read,user", OAuth2Scope("openid user repo:read").AccessTokenScope())
+	assert.Equal(t, []string{"auth.oauth2_scope.openid", "settings.token_scope.repo_read"},
+		OAuth2Scope("openid repo:read").DescKeys())
+}
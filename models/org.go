@@ -12,9 +12,9 @@ import (
 	"strings"
 
 	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/storage"
 	"code.gitea.io/gitea/modules/structs"
 
-	"github.com/Unknwon/com"
 	"github.com/go-xorm/xorm"
 	"xorm.io/builder"
 )
@@ -186,7 +186,15 @@ func CreateOrganization(org, owner *User) (err error) {
 		return fmt.Errorf("insert team-user relation: %v", err)
 	}
 
-	return sess.Commit()
+	if err = prepareUserWebhooks(sess, org, owner, structs.HookUserCreated); err != nil {
+		return err
+	}
+
+	if err = sess.Commit(); err != nil {
+		return err
+	}
+	go HookQueue.Add(0)
+	return nil
 }
 
 // GetOrgByName returns organization by given name.
@@ -232,7 +240,21 @@ func DeleteOrganization(org *User) (err error) {
 		}
 	}
 
-	return sess.Commit()
+	blobs, err := deleteOwnerPackages(sess, org)
+	if err != nil {
+		return fmt.Errorf("deleteOwnerPackages: %v", err)
+	}
+
+	if err = prepareUserWebhooks(sess, org, nil, structs.HookUserDeleted); err != nil {
+		return err
+	}
+
+	if err = sess.Commit(); err != nil {
+		return err
+	}
+	RemovePackageBlobs(blobs)
+	go HookQueue.Add(0)
+	return nil
 }
 
 func deleteOrg(e *xorm.Session, u *User) error {
@@ -253,10 +275,15 @@ func deleteOrg(e *xorm.Session, u *User) error {
 		&OrgUser{OrgID: u.ID},
 		&TeamUser{OrgID: u.ID},
 		&TeamUnit{OrgID: u.ID},
+		&Label{OrgID: u.ID},
 	); err != nil {
 		return fmt.Errorf("deleteBeans: %v", err)
 	}
 
+	if err := deleteProjects(e, builder.Eq{"repo_id": 0, "owner_id": u.ID}); err != nil {
+		return fmt.Errorf("deleteProjects: %v", err)
+	}
+
 	if _, err = e.ID(u.ID).Delete(new(User)); err != nil {
 		return fmt.Errorf("Delete: %v", err)
 	}
@@ -271,11 +298,9 @@ func deleteOrg(e *xorm.Session, u *User) error {
 	}
 
 	if len(u.Avatar) > 0 {
-		avatarPath := u.CustomAvatarPath()
-		if com.IsExist(avatarPath) {
-			if err := os.Remove(avatarPath); err != nil {
-				return fmt.Errorf("Failed to remove %s: %v", avatarPath, err)
-			}
+		avatarPath := u.CustomAvatarRelativePath()
+		if err := storage.Avatars.Delete(avatarPath); err != nil {
+			return fmt.Errorf("Failed to remove %s: %v", avatarPath, err)
 		}
 	}
 
@@ -0,0 +1,680 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
+
+	"xorm.io/builder"
+)
+
+// PackageType represents the format of a package, it determines the API serving it
+type PackageType string
+
+// Enumerate all the package types
+const (
+	PackageGeneric   PackageType = "generic"   // files uploaded and downloaded by name
+	PackageContainer PackageType = "container" // OCI images served by the container registry
+)
+
+// PackageTypes lists all the package types
+var PackageTypes = []PackageType{
+	PackageGeneric,
+	PackageContainer,
+}
+
+// IsValid returns true if the package type exists
+func (t PackageType) IsValid() bool {
+	for _, typ := range PackageTypes {
+		if t == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// Package represents a package owned by a user or an organization, it can be
+// linked to one of the repositories of its owner.
+type Package struct {
+	ID        int64       `xorm:"pk autoincr"`
+	OwnerID   int64       `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	RepoID    int64       `xorm:"INDEX"`
+	Type      PackageType `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	Name      string      `xorm:"NOT NULL"`
+	LowerName string      `xorm:"UNIQUE(s) INDEX NOT NULL"`
+
+	Owner         *User           `xorm:"-"`
+	Repo          *Repository     `xorm:"-"`
+	LatestVersion *PackageVersion `xorm:"-"`
+
+	CreatedUnix util.TimeStamp `xorm:"INDEX created"`
+	UpdatedUnix util.TimeStamp `xorm:"INDEX updated"`
+}
+
+func (p *Package) loadAttributes(e Engine) (err error) {
+	if p.Owner == nil {
+		if p.Owner, err = getUserByID(e, p.OwnerID); err != nil {
+			return fmt.Errorf("getUserByID [%d]: %v", p.OwnerID, err)
+		}
+	}
+	if p.Repo == nil && p.RepoID > 0 {
+		if p.Repo, err = getRepositoryByID(e, p.RepoID); err != nil {
+			return fmt.Errorf("getRepositoryByID [%d]: %v", p.RepoID, err)
+		}
+	}
+	if p.LatestVersion == nil {
+		version := new(PackageVersion)
+		has, err := e.Where("package_id = ? AND lower_version <> ?", p.ID, ContainerUploadVersion).
+			Desc("created_unix", "id").
+			Get(version)
+		if err != nil {
+			return err
+		} else if has {
+			p.LatestVersion = version
+		}
+	}
+	return nil
+}
+
+// LoadAttributes loads the owner, the repository and the latest version of the package
+func (p *Package) LoadAttributes() error {
+	return p.loadAttributes(x)
+}
+
+// Link returns the relative link of the package page, the attributes must be loaded.
+func (p *Package) Link() string {
+	return fmt.Sprintf("%s/-/packages/%s/%s", p.Owner.HomeLink(), p.Type, url.PathEscape(p.Name))
+}
+
+// PackageVersion represents a version of a package, it holds the files uploaded for this version.
+type PackageVersion struct {
+	ID            int64  `xorm:"pk autoincr"`
+	PackageID     int64  `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	CreatorID     int64  `xorm:"NOT NULL"`
+	Version       string `xorm:"NOT NULL"`
+	LowerVersion  string `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	DownloadCount int64  `xorm:"NOT NULL DEFAULT 0"`
+
+	Creator *User          `xorm:"-"`
+	Files   []*PackageFile `xorm:"-"`
+
+	CreatedUnix util.TimeStamp `xorm:"INDEX created"`
+	UpdatedUnix util.TimeStamp `xorm:"INDEX updated"`
+}
+
+func (v *PackageVersion) loadAttributes(e Engine) (err error) {
+	if v.Creator == nil {
+		v.Creator, err = getUserByID(e, v.CreatorID)
+		if err != nil {
+			if !IsErrUserNotExist(err) {
+				return fmt.Errorf("getUserByID [%d]: %v", v.CreatorID, err)
+			}
+			v.Creator = NewGhostUser()
+		}
+	}
+	if v.Files == nil {
+		v.Files = make([]*PackageFile, 0, 5)
+		if err = e.Where("version_id = ?", v.ID).Asc("lower_name").Find(&v.Files); err != nil {
+			return err
+		}
+		for _, f := range v.Files {
+			if err = f.loadBlob(e); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// LoadAttributes loads the creator and the files of the version
+func (v *PackageVersion) LoadAttributes() error {
+	return v.loadAttributes(x)
+}
+
+// IncreaseDownloadCount increases the download count of the version
+func (v *PackageVersion) IncreaseDownloadCount() error {
+	if _, err := x.Exec("UPDATE `package_version` SET download_count = download_count + 1 WHERE id = ?", v.ID); err != nil {
+		return fmt.Errorf("increase package version download count [id: %d]: %v", v.ID, err)
+	}
+	return nil
+}
+
+// PackageFile represents a file of a package version, its content is a blob
+// which may be shared with other files.
+type PackageFile struct {
+	ID        int64  `xorm:"pk autoincr"`
+	VersionID int64  `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	BlobID    int64  `xorm:"INDEX NOT NULL"`
+	Name      string `xorm:"NOT NULL"`
+	LowerName string `xorm:"UNIQUE(s) INDEX NOT NULL"`
+
+	Blob *PackageBlob `xorm:"-"`
+
+	CreatedUnix util.TimeStamp `xorm:"INDEX created"`
+}
+
+func (f *PackageFile) loadBlob(e Engine) error {
+	if f.Blob != nil {
+		return nil
+	}
+	blob := new(PackageBlob)
+	if has, err := e.ID(f.BlobID).Get(blob); err != nil {
+		return err
+	} else if !has {
+		return fmt.Errorf("package blob does not exist [id: %d]", f.BlobID)
+	}
+	f.Blob = blob
+	return nil
+}
+
+// LoadBlob loads the blob holding the content of the file
+func (f *PackageFile) LoadBlob() error {
+	return f.loadBlob(x)
+}
+
+// PackageBlob represents the content of package files, it is stored once
+// for all the files having the same content.
+type PackageBlob struct {
+	ID          int64          `xorm:"pk autoincr"`
+	Size        int64          `xorm:"NOT NULL DEFAULT 0"`
+	HashSHA256  string         `xorm:"'hash_sha256' UNIQUE NOT NULL"`
+	CreatedUnix util.TimeStamp `xorm:"INDEX created"`
+}
+
+// RelativePath returns the path of the content of the blob in the package storage
+func (b *PackageBlob) RelativePath() string {
+	return PackageBlobRelativePath(b.HashSHA256)
+}
+
+// PackageBlobRelativePath returns the path of the content with the SHA256 hash in the package storage
+func PackageBlobRelativePath(hash string) string {
+	if len(hash) < 4 {
+		return hash
+	}
+	return path.Join(hash[0:2], hash[2:4], hash)
+}
+
+// GetPackageByName returns the package of the owner with the type and the name
+func GetPackageByName(ownerID int64, typ PackageType, name string) (*Package, error) {
+	return getPackageByName(x, ownerID, typ, name)
+}
+
+func getPackageByName(e Engine, ownerID int64, typ PackageType, name string) (*Package, error) {
+	p := new(Package)
+	has, err := e.Where("owner_id = ? AND type = ? AND lower_name = ?", ownerID, typ, strings.ToLower(name)).Get(p)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrPackageNotExist{OwnerID: ownerID, Type: typ, Name: name}
+	}
+	return p, nil
+}
+
+// GetPackageVersionByName returns the version of the package
+func GetPackageVersionByName(packageID int64, version string) (*PackageVersion, error) {
+	return getPackageVersionByName(x, packageID, version)
+}
+
+func getPackageVersionByName(e Engine, packageID int64, version string) (*PackageVersion, error) {
+	v := new(PackageVersion)
+	has, err := e.Where("package_id = ? AND lower_version = ?", packageID, strings.ToLower(version)).Get(v)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrPackageVersionNotExist{PackageID: packageID, Version: version}
+	}
+	return v, nil
+}
+
+// GetPackageVersionByID returns the version of the package with the id
+func GetPackageVersionByID(packageID, id int64) (*PackageVersion, error) {
+	v := new(PackageVersion)
+	has, err := x.Where("package_id = ? AND id = ?", packageID, id).Get(v)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrPackageVersionNotExist{ID: id, PackageID: packageID}
+	}
+	return v, nil
+}
+
+// GetPackageVersions returns the versions of the package, the most recent first.
+// The version holding the pending uploads of a container image is not listed.
+func GetPackageVersions(packageID int64) ([]*PackageVersion, error) {
+	versions := make([]*PackageVersion, 0, 10)
+	return versions, x.Where("package_id = ? AND lower_version <> ?", packageID, ContainerUploadVersion).
+		Desc("created_unix", "id").
+		Find(&versions)
+}
+
+// GetPackageFileByName returns the file of the version with its blob
+func GetPackageFileByName(versionID int64, name string) (*PackageFile, error) {
+	f := new(PackageFile)
+	has, err := x.Where("version_id = ? AND lower_name = ?", versionID, strings.ToLower(name)).Get(f)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrPackageFileNotExist{VersionID: versionID, Name: name}
+	}
+	return f, f.LoadBlob()
+}
+
+// GetPackageBlobByHash returns the blob with the SHA256 hash
+func GetPackageBlobByHash(hash string) (*PackageBlob, error) {
+	blob := new(PackageBlob)
+	has, err := x.Where("hash_sha256 = ?", hash).Get(blob)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, nil
+	}
+	return blob, nil
+}
+
+// PackageSearchOptions represents the options to list the packages of an owner
+type PackageSearchOptions struct {
+	OwnerID  int64
+	RepoID   int64
+	Type     PackageType
+	Keyword  string
+	Page     int
+	PageSize int
+}
+
+func (opts *PackageSearchOptions) toConds() builder.Cond {
+	cond := builder.NewCond()
+	if opts.OwnerID > 0 {
+		cond = cond.And(builder.Eq{"owner_id": opts.OwnerID})
+	}
+	if opts.RepoID > 0 {
+		cond = cond.And(builder.Eq{"repo_id": opts.RepoID})
+	}
+	if opts.Type != "" {
+		cond = cond.And(builder.Eq{"type": opts.Type})
+	}
+	if opts.Keyword != "" {
+		cond = cond.And(builder.Like{"lower_name", strings.ToLower(opts.Keyword)})
+	}
+	return cond
+}
+
+// SearchPackages returns the packages matching the options with their attributes,
+// the most recently updated first, and the total count of the matching packages.
+func SearchPackages(opts *PackageSearchOptions) ([]*Package, int64, error) {
+	if opts.Page <= 0 {
+		opts.Page = 1
+	}
+	if opts.PageSize <= 0 {
+		opts.PageSize = setting.UI.ExplorePagingNum
+	}
+
+	count, err := x.Where(opts.toConds()).Count(new(Package))
+	if err != nil {
+		return nil, 0, fmt.Errorf("Count: %v", err)
+	}
+
+	packages := make([]*Package, 0, opts.PageSize)
+	if err = x.Where(opts.toConds()).
+		Desc("updated_unix", "id").
+		Limit(opts.PageSize, (opts.Page-1)*opts.PageSize).
+		Find(&packages); err != nil {
+		return nil, 0, fmt.Errorf("Find: %v", err)
+	}
+	for _, p := range packages {
+		if err = p.loadAttributes(x); err != nil {
+			return nil, 0, err
+		}
+	}
+	return packages, count, nil
+}
+
+// AddPackageFileOptions represents the options to add a file to a package version
+type AddPackageFileOptions struct {
+	Owner    *User
+	Creator  *User
+	Type     PackageType
+	Name     string
+	Version  string
+	Filename string
+	Blob     *PackageBlob
+}
+
+// getOrCreatePackage returns the package of the owner, it is created if it does not exist
+func getOrCreatePackage(e Engine, owner *User, typ PackageType, name string) (*Package, error) {
+	p, err := getPackageByName(e, owner.ID, typ, name)
+	if IsErrPackageNotExist(err) {
+		p = &Package{
+			OwnerID:   owner.ID,
+			Type:      typ,
+			Name:      name,
+			LowerName: strings.ToLower(name),
+		}
+		if _, err = e.Insert(p); err != nil {
+			return nil, fmt.Errorf("insert package: %v", err)
+		}
+		return p, nil
+	}
+	return p, err
+}
+
+// getOrCreatePackageVersion returns the version of the package, it is created by creator if it does not exist
+func getOrCreatePackageVersion(e Engine, p *Package, creator *User, version string) (*PackageVersion, error) {
+	v, err := getPackageVersionByName(e, p.ID, version)
+	if IsErrPackageVersionNotExist(err) {
+		v = &PackageVersion{
+			PackageID:    p.ID,
+			CreatorID:    creator.ID,
+			Version:      version,
+			LowerVersion: strings.ToLower(version),
+		}
+		if _, err = e.Insert(v); err != nil {
+			return nil, fmt.Errorf("insert package version: %v", err)
+		}
+		return v, nil
+	}
+	return v, err
+}
+
+// getOrInsertPackageBlob returns the blob with the hash of blob, blob is inserted if there is none.
+// An existing blob is created again, so that the cleanup of the unreferenced blobs keeps it until
+// the caller references it. A blob which was loaded before, with its id, must still exist.
+func getOrInsertPackageBlob(e Engine, blob *PackageBlob) (*PackageBlob, error) {
+	existing := new(PackageBlob)
+	if has, err := e.Where("hash_sha256 = ?", blob.HashSHA256).Get(existing); err != nil {
+		return nil, err
+	} else if has {
+		if _, err = e.Table("package_blob").ID(existing.ID).
+			Update(map[string]interface{}{"created_unix": util.TimeStampNow()}); err != nil {
+			return nil, fmt.Errorf("update package blob: %v", err)
+		}
+		return existing, nil
+	} else if blob.ID > 0 {
+		// the cleanup deleted the blob and its content since it was loaded
+		return nil, ErrPackageBlobNotExist{ID: blob.ID, Hash: blob.HashSHA256}
+	}
+	blob = &PackageBlob{Size: blob.Size, HashSHA256: blob.HashSHA256}
+	if _, err := e.Insert(blob); err != nil {
+		return nil, fmt.Errorf("insert package blob: %v", err)
+	}
+	return blob, nil
+}
+
+// AddPackageBlob adds a blob which is not referenced by any file yet, a blob with the
+// same hash is returned instead if there is one.
+func AddPackageBlob(blob *PackageBlob) (*PackageBlob, error) {
+	return getOrInsertPackageBlob(x, blob)
+}
+
+// AddPackageFile adds a file to a package version, the package and the version are
+// created if they do not exist. A blob with the same hash as opts.Blob is reused.
+func AddPackageFile(opts *AddPackageFileOptions) (*PackageFile, error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return nil, err
+	}
+
+	p, err := getOrCreatePackage(sess, opts.Owner, opts.Type, opts.Name)
+	if err != nil {
+		return nil, err
+	}
+	v, err := getOrCreatePackageVersion(sess, p, opts.Creator, opts.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	has, err := sess.Where("version_id = ? AND lower_name = ?", v.ID, strings.ToLower(opts.Filename)).Exist(new(PackageFile))
+	if err != nil {
+		return nil, err
+	} else if has {
+		return nil, ErrPackageFileAlreadyExist{VersionID: v.ID, Name: opts.Filename}
+	}
+
+	blob, err := getOrInsertPackageBlob(sess, opts.Blob)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &PackageFile{
+		VersionID: v.ID,
+		BlobID:    blob.ID,
+		Name:      opts.Filename,
+		LowerName: strings.ToLower(opts.Filename),
+		Blob:      blob,
+	}
+	if _, err = sess.Insert(f); err != nil {
+		return nil, fmt.Errorf("insert package file: %v", err)
+	}
+
+	if _, err = sess.ID(v.ID).Cols("updated_unix").Update(v); err != nil {
+		return nil, fmt.Errorf("update package version: %v", err)
+	}
+	if _, err = sess.ID(p.ID).Cols("updated_unix").Update(p); err != nil {
+		return nil, fmt.Errorf("update package: %v", err)
+	}
+
+	return f, sess.Commit()
+}
+
+// deleteUnreferencedPackageBlobs deletes the blobs not referenced by any
+// file anymore and returns them, their content is still in the storage.
+func deleteUnreferencedPackageBlobs(e Engine, blobIDs []int64) ([]*PackageBlob, error) {
+	blobs := make([]*PackageBlob, 0, len(blobIDs))
+	if len(blobIDs) == 0 {
+		return blobs, nil
+	}
+	if err := e.Where(builder.In("id", blobIDs).
+		And(builder.NotIn("id", builder.Select("blob_id").From("package_file")))).
+		Find(&blobs); err != nil {
+		return nil, err
+	}
+	for _, blob := range blobs {
+		if _, err := e.ID(blob.ID).Delete(new(PackageBlob)); err != nil {
+			return nil, err
+		}
+	}
+	return blobs, nil
+}
+
+// DeleteUnreferencedPackageBlobs deletes the blobs created more than olderThan ago which
+// no file references and returns them, their content must then be removed from the storage.
+// The recent blobs are kept for the uploads which are not referenced yet.
+func DeleteUnreferencedPackageBlobs(olderThan time.Duration) ([]*PackageBlob, error) {
+	cond := builder.Lt{"created_unix": time.Now().Add(-olderThan).Unix()}.
+		And(builder.NotIn("id", builder.Select("blob_id").From("package_file")))
+	candidates := make([]*PackageBlob, 0, 10)
+	if err := x.Where(cond).Find(&candidates); err != nil {
+		return nil, err
+	}
+	blobs := make([]*PackageBlob, 0, len(candidates))
+	for _, blob := range candidates {
+		// a file may have been added or the blob reused since the blobs were listed
+		deleted, err := x.Where(builder.Eq{"id": blob.ID}.And(cond)).Delete(new(PackageBlob))
+		if err != nil {
+			return nil, err
+		} else if deleted > 0 {
+			blobs = append(blobs, blob)
+		}
+	}
+	return blobs, nil
+}
+
+// deletePackageVersions deletes the versions matching cond with their files
+// and returns the blobs not referenced anymore.
+func deletePackageVersions(e Engine, cond builder.Cond) ([]*PackageBlob, error) {
+	var versionIDs []int64
+	if err := e.Table("package_version").Where(cond).Cols("id").Find(&versionIDs); err != nil {
+		return nil, err
+	}
+	if len(versionIDs) == 0 {
+		return nil, nil
+	}
+
+	var blobIDs []int64
+	if err := e.Table("package_file").In("version_id", versionIDs).Distinct("blob_id").Find(&blobIDs); err != nil {
+		return nil, err
+	}
+	if _, err := e.In("version_id", versionIDs).Delete(new(PackageFile)); err != nil {
+		return nil, err
+	}
+	if _, err := e.In("id", versionIDs).Delete(new(PackageVersion)); err != nil {
+		return nil, err
+	}
+	return deleteUnreferencedPackageBlobs(e, blobIDs)
+}
+
+// deletePackageIfEmpty deletes the package if it does not have any version left
+func deletePackageIfEmpty(e Engine, packageID int64) error {
+	has, err := e.Where("package_id = ?", packageID).Exist(new(PackageVersion))
+	if err != nil || has {
+		return err
+	}
+	_, err = e.ID(packageID).Delete(new(Package))
+	return err
+}
+
+// deletePackages deletes the packages matching cond with all their versions
+// and returns the blobs not referenced anymore.
+func deletePackages(e Engine, cond builder.Cond) ([]*PackageBlob, error) {
+	var ids []int64
+	if err := e.Table("package").Where(cond).Cols("id").Find(&ids); err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	blobs, err := deletePackageVersions(e, builder.In("package_id", ids))
+	if err != nil {
+		return nil, err
+	}
+	if _, err = e.In("id", ids).Delete(new(Package)); err != nil {
+		return nil, err
+	}
+	return blobs, nil
+}
+
+// RemovePackageBlobs removes the content of the blobs deleted with the packages of a user
+// or an organization once the deletion is committed. The packages module sets it so that
+// the content is removed under its lock of the blobs.
+var RemovePackageBlobs = func(blobs []*PackageBlob) {}
+
+// deleteOwnerPackages deletes all the packages of a user or an organization and returns
+// the blobs not referenced anymore, their content must be removed after the commit.
+func deleteOwnerPackages(e Engine, owner *User) ([]*PackageBlob, error) {
+	return deletePackages(e, builder.Eq{"owner_id": owner.ID})
+}
+
+// DeletePackageFile deletes a file of a package version, the version and the package
+// are deleted with their last file. It returns the blob of the file when no other
+// file references it, its content must then be removed from the storage.
+func DeletePackageFile(p *Package, v *PackageVersion, f *PackageFile) ([]*PackageBlob, error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return nil, err
+	}
+
+	if _, err := sess.ID(f.ID).Delete(new(PackageFile)); err != nil {
+		return nil, err
+	}
+	blobs, err := deleteUnreferencedPackageBlobs(sess, []int64{f.BlobID})
+	if err != nil {
+		return nil, err
+	}
+
+	has, err := sess.Where("version_id = ?", v.ID).Exist(new(PackageFile))
+	if err != nil {
+		return nil, err
+	} else if !has {
+		if _, err = sess.ID(v.ID).Delete(new(PackageVersion)); err != nil {
+			return nil, err
+		}
+		if err = deletePackageIfEmpty(sess, p.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return blobs, sess.Commit()
+}
+
+// DeletePackageVersion deletes a version of a package with its files, the package is
+// deleted with its last version. It returns the blobs no file references anymore,
+// their content must then be removed from the storage.
+func DeletePackageVersion(p *Package, v *PackageVersion) ([]*PackageBlob, error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return nil, err
+	}
+
+	blobs, err := deletePackageVersions(sess, builder.Eq{"id": v.ID})
+	if err != nil {
+		return nil, err
+	}
+	if err = deletePackageIfEmpty(sess, p.ID); err != nil {
+		return nil, err
+	}
+
+	return blobs, sess.Commit()
+}
+
+// LinkPackageToRepo links the package to a repository of its owner, repoID 0 unlinks it
+func LinkPackageToRepo(p *Package, repoID int64) error {
+	if repoID > 0 {
+		repo, err := GetRepositoryByID(repoID)
+		if err != nil {
+			return err
+		}
+		if repo.OwnerID != p.OwnerID {
+			return ErrRepoNotExist{ID: repoID}
+		}
+		p.Repo = repo
+	} else {
+		p.Repo = nil
+	}
+	p.RepoID = repoID
+	_, err := x.ID(p.ID).Cols("repo_id").Update(p)
+	return err
+}
+
+// CanReadPackages returns true if the user can see the packages of the owner
+func CanReadPackages(owner *User, user *User) bool {
+	if owner.IsOrganization() {
+		return HasOrgVisible(owner, user)
+	}
+	return true
+}
+
+// CanWritePackages returns true if the user can publish and delete the packages of the owner,
+// the user must be the owner, an owner of the organization or a member of one of its teams
+// with write access.
+func CanWritePackages(owner *User, user *User) (bool, error) {
+	if user == nil {
+		return false, nil
+	}
+	if user.IsAdmin || user.ID == owner.ID {
+		return true, nil
+	}
+	if !owner.IsOrganization() {
+		return false, nil
+	}
+	teams, err := GetUserOrgTeams(owner.ID, user.ID)
+	if err != nil {
+		return false, err
+	}
+	for _, t := range teams {
+		if t.IsOwnerTeam() || t.Authorize >= AccessModeWrite {
+			return true, nil
+		}
+	}
+	return false, nil
+}
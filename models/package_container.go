@@ -0,0 +1,279 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"xorm.io/builder"
+)
+
+const (
+	// ContainerManifestFilename is the name of the file holding the manifest of a container image version
+	ContainerManifestFilename = "manifest.json"
+	// ContainerDigestPrefix is the prefix of the digests of the container blobs and manifests,
+	// only SHA256 digests are supported.
+	ContainerDigestPrefix = "sha256:"
+	// ContainerUploadVersion is the internal version holding the blobs uploaded to a container
+	// image until a manifest references them, it is not a valid tag.
+	ContainerUploadVersion = ".uploads"
+)
+
+// IsContainerDigest returns true if the reference of a container manifest is a digest instead of a tag
+func IsContainerDigest(reference string) bool {
+	return strings.HasPrefix(reference, ContainerDigestPrefix)
+}
+
+// containerBlobFile returns a new file of the version referencing the container blob by its digest
+func containerBlobFile(versionID int64, blob *PackageBlob) *PackageFile {
+	name := ContainerDigestPrefix + blob.HashSHA256
+	return &PackageFile{
+		VersionID: versionID,
+		BlobID:    blob.ID,
+		Name:      name,
+		LowerName: name,
+	}
+}
+
+// AddContainerUploadedBlob adds a blob uploaded by the creator to the container image, it can then
+// be referenced by the manifests of the image. The image is created if it does not exist.
+func AddContainerUploadedBlob(owner, creator *User, image string, blob *PackageBlob) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	p, err := getOrCreatePackage(sess, owner, PackageContainer, image)
+	if err != nil {
+		return err
+	}
+	v, err := getOrCreatePackageVersion(sess, p, creator, ContainerUploadVersion)
+	if err != nil {
+		return err
+	}
+	if blob, err = getOrInsertPackageBlob(sess, blob); err != nil {
+		return err
+	}
+
+	f := containerBlobFile(v.ID, blob)
+	if has, err := sess.Where("version_id = ? AND lower_name = ?", v.ID, f.LowerName).Exist(new(PackageFile)); err != nil {
+		return err
+	} else if !has {
+		if _, err = sess.Insert(f); err != nil {
+			return fmt.Errorf("insert package file: %v", err)
+		}
+	}
+
+	return sess.Commit()
+}
+
+// SetContainerManifestOptions represents the options to set the manifest of a container image version
+type SetContainerManifestOptions struct {
+	Owner   *User
+	Creator *User
+	Image   string
+	// Reference is the tag of the version or the digest of the manifest for the untagged versions
+	Reference string
+	Manifest  *PackageBlob
+	// Blobs are the layers, the configuration and the manifests referenced by the manifest
+	Blobs []*PackageBlob
+}
+
+// SetContainerManifest sets the manifest of a container image version, the package and the
+// version are created if they do not exist. The files of an existing version are replaced,
+// the blobs they referenced are left to the cleanup of the unreferenced blobs.
+func SetContainerManifest(opts *SetContainerManifestOptions) (*PackageVersion, error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return nil, err
+	}
+
+	p, err := getOrCreatePackage(sess, opts.Owner, PackageContainer, opts.Image)
+	if err != nil {
+		return nil, err
+	}
+	v, err := getOrCreatePackageVersion(sess, p, opts.Creator, opts.Reference)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = sess.Where("version_id = ?", v.ID).Delete(new(PackageFile)); err != nil {
+		return nil, err
+	}
+
+	manifest, err := getOrInsertPackageBlob(sess, opts.Manifest)
+	if err != nil {
+		return nil, err
+	}
+	files := []*PackageFile{{
+		VersionID: v.ID,
+		BlobID:    manifest.ID,
+		Name:      ContainerManifestFilename,
+		LowerName: ContainerManifestFilename,
+	}}
+	blobIDs := make([]int64, 0, len(opts.Blobs))
+	for _, blob := range opts.Blobs {
+		if blob, err = getOrInsertPackageBlob(sess, blob); err != nil {
+			return nil, err
+		}
+		f := containerBlobFile(v.ID, blob)
+		if !containsPackageFile(files, f.LowerName) {
+			files = append(files, f)
+			blobIDs = append(blobIDs, blob.ID)
+		}
+	}
+	if _, err = sess.Insert(&files); err != nil {
+		return nil, fmt.Errorf("insert package files: %v", err)
+	}
+
+	// the uploaded blobs are now referenced by the manifest
+	upload, err := getPackageVersionByName(sess, p.ID, ContainerUploadVersion)
+	if err == nil {
+		if _, err = sess.Where(builder.Eq{"version_id": upload.ID}.And(builder.In("blob_id", blobIDs))).
+			Delete(new(PackageFile)); err != nil {
+			return nil, err
+		}
+		if err = deletePackageVersionIfEmpty(sess, upload.ID); err != nil {
+			return nil, err
+		}
+	} else if !IsErrPackageVersionNotExist(err) {
+		return nil, err
+	}
+
+	if _, err = sess.ID(v.ID).Cols("updated_unix").Update(v); err != nil {
+		return nil, fmt.Errorf("update package version: %v", err)
+	}
+	if _, err = sess.ID(p.ID).Cols("updated_unix").Update(p); err != nil {
+		return nil, fmt.Errorf("update package: %v", err)
+	}
+
+	return v, sess.Commit()
+}
+
+func containsPackageFile(files []*PackageFile, lowerName string) bool {
+	for _, f := range files {
+		if f.LowerName == lowerName {
+			return true
+		}
+	}
+	return false
+}
+
+// deletePackageVersionIfEmpty deletes the version if it does not have any file left
+func deletePackageVersionIfEmpty(e Engine, versionID int64) error {
+	has, err := e.Where("version_id = ?", versionID).Exist(new(PackageFile))
+	if err != nil || has {
+		return err
+	}
+	_, err = e.ID(versionID).Delete(new(PackageVersion))
+	return err
+}
+
+// GetContainerManifest returns the version and the manifest file of a container image
+// by tag or by digest, the blob of the file is loaded.
+func GetContainerManifest(packageID int64, reference string) (*PackageVersion, *PackageFile, error) {
+	if !IsContainerDigest(reference) {
+		if strings.ToLower(reference) == ContainerUploadVersion {
+			return nil, nil, ErrPackageVersionNotExist{PackageID: packageID, Version: reference}
+		}
+		v, err := getPackageVersionByName(x, packageID, reference)
+		if err != nil {
+			return nil, nil, err
+		}
+		f, err := GetPackageFileByName(v.ID, ContainerManifestFilename)
+		if err != nil {
+			return nil, nil, err
+		}
+		return v, f, nil
+	}
+
+	f := new(PackageFile)
+	has, err := x.Table("package_file").
+		Join("INNER", "package_version", "package_version.id = package_file.version_id").
+		Join("INNER", "package_blob", "package_blob.id = package_file.blob_id").
+		Where(builder.Eq{
+			"package_version.package_id": packageID,
+			"package_file.lower_name":    ContainerManifestFilename,
+			"package_blob.hash_sha256":   strings.TrimPrefix(reference, ContainerDigestPrefix),
+		}).
+		OrderBy("package_version.created_unix DESC, package_version.id DESC").
+		Select("package_file.*").
+		Get(f)
+	if err != nil {
+		return nil, nil, err
+	} else if !has {
+		return nil, nil, ErrPackageVersionNotExist{PackageID: packageID, Version: reference}
+	}
+	if err = f.loadBlob(x); err != nil {
+		return nil, nil, err
+	}
+	v := new(PackageVersion)
+	if _, err = x.ID(f.VersionID).Get(v); err != nil {
+		return nil, nil, err
+	}
+	return v, f, nil
+}
+
+// GetContainerBlob returns the blob with the hash if a file of the container image references it,
+// either a manifest or a pending upload. It returns nil if there is no such blob.
+func GetContainerBlob(packageID int64, hash string) (*PackageBlob, error) {
+	blob := new(PackageBlob)
+	has, err := x.Table("package_blob").
+		Join("INNER", "package_file", "package_file.blob_id = package_blob.id").
+		Join("INNER", "package_version", "package_version.id = package_file.version_id").
+		Where("package_blob.hash_sha256 = ? AND package_version.package_id = ?", hash, packageID).
+		Select("package_blob.*").
+		Get(blob)
+	if err != nil || !has {
+		return nil, err
+	}
+	return blob, nil
+}
+
+// GetContainerTags returns the tags of a container image in lexical order,
+// the untagged versions are not listed.
+func GetContainerTags(packageID int64) ([]string, error) {
+	tags := make([]string, 0, 10)
+	return tags, x.Table("package_version").
+		Where(builder.Eq{"package_id": packageID}.
+			And(builder.Neq{"lower_version": ContainerUploadVersion}).
+			And(builder.Not{builder.Like{"lower_version", ContainerDigestPrefix + "%"}})).
+		Asc("version").
+		Cols("version").
+		Find(&tags)
+}
+
+// DeleteExpiredContainerUploads removes the uploaded blobs which no manifest referenced for longer
+// than olderThan from their container image, the images left without versions are deleted.
+// The blobs are left to the cleanup of the unreferenced blobs.
+func DeleteExpiredContainerUploads(olderThan time.Duration) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	uploads := make([]*PackageVersion, 0, 10)
+	if err := sess.Where("lower_version = ?", ContainerUploadVersion).Find(&uploads); err != nil {
+		return err
+	}
+	deadline := time.Now().Add(-olderThan).Unix()
+	for _, v := range uploads {
+		if _, err := sess.Where("version_id = ? AND created_unix < ?", v.ID, deadline).Delete(new(PackageFile)); err != nil {
+			return err
+		}
+		if err := deletePackageVersionIfEmpty(sess, v.ID); err != nil {
+			return err
+		}
+		if err := deletePackageIfEmpty(sess, v.PackageID); err != nil {
+			return err
+		}
+	}
+
+	return sess.Commit()
+}
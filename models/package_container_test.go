@@ -0,0 +1,88 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetContainerManifest(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	owner := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	layer := &PackageBlob{Size: 4, HashSHA256: "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"}
+	manifest := &PackageBlob{Size: 2, HashSHA256: "44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a"}
+
+	assert.NoError(t, AddContainerUploadedBlob(owner, owner, "image", layer))
+	p := AssertExistsAndLoadBean(t, &Package{OwnerID: 2, Type: PackageContainer, LowerName: "image"}).(*Package)
+	AssertExistsAndLoadBean(t, &PackageVersion{PackageID: p.ID, Version: ContainerUploadVersion})
+	blob, err := GetContainerBlob(p.ID, layer.HashSHA256)
+	assert.NoError(t, err)
+	assert.NotNil(t, blob)
+	// the blob is not visible from the other images
+	blob, err = GetContainerBlob(1, layer.HashSHA256)
+	assert.NoError(t, err)
+	assert.Nil(t, blob)
+
+	v, err := SetContainerManifest(&SetContainerManifestOptions{
+		Owner:     owner,
+		Creator:   owner,
+		Image:     "image",
+		Reference: "latest",
+		Manifest:  manifest,
+		Blobs:     []*PackageBlob{layer, layer},
+	})
+	assert.NoError(t, err)
+	AssertCount(t, &PackageFile{VersionID: v.ID}, 2)
+	// the uploaded blob is now referenced by the manifest
+	AssertNotExistsBean(t, &PackageVersion{PackageID: p.ID, Version: ContainerUploadVersion})
+	blob, err = GetContainerBlob(p.ID, layer.HashSHA256)
+	assert.NoError(t, err)
+	assert.NotNil(t, blob)
+
+	for _, reference := range []string{"latest", ContainerDigestPrefix + manifest.HashSHA256} {
+		found, f, err := GetContainerManifest(p.ID, reference)
+		assert.NoError(t, err)
+		assert.EqualValues(t, v.ID, found.ID)
+		assert.Equal(t, manifest.HashSHA256, f.Blob.HashSHA256)
+	}
+	_, _, err = GetContainerManifest(p.ID, ContainerUploadVersion)
+	assert.True(t, IsErrPackageVersionNotExist(err))
+
+	_, err = SetContainerManifest(&SetContainerManifestOptions{
+		Owner:     owner,
+		Creator:   owner,
+		Image:     "image",
+		Reference: ContainerDigestPrefix + manifest.HashSHA256,
+		Manifest:  manifest,
+	})
+	assert.NoError(t, err)
+	tags, err := GetContainerTags(p.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"latest"}, tags)
+}
+
+func TestDeleteExpiredContainerUploads(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	owner := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	layer := &PackageBlob{Size: 4, HashSHA256: "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"}
+	assert.NoError(t, AddContainerUploadedBlob(owner, owner, "image", layer))
+
+	assert.NoError(t, DeleteExpiredContainerUploads(time.Hour))
+	AssertExistsAndLoadBean(t, &Package{OwnerID: 2, Type: PackageContainer, LowerName: "image"})
+
+	assert.NoError(t, DeleteExpiredContainerUploads(-time.Hour))
+	AssertNotExistsBean(t, &Package{OwnerID: 2, Type: PackageContainer, LowerName: "image"})
+
+	blobs, err := DeleteUnreferencedPackageBlobs(-time.Hour)
+	assert.NoError(t, err)
+	if assert.Len(t, blobs, 1) {
+		assert.Equal(t, layer.HashSHA256, blobs[0].HashSHA256)
+	}
+	// the blob of the fixtures is still referenced
+	AssertExistsAndLoadBean(t, &PackageBlob{ID: 1})
+}
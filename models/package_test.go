@@ -0,0 +1,201 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetPackageByName(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	p, err := GetPackageByName(2, PackageGeneric, "Test-Package")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, p.ID)
+	assert.NoError(t, p.LoadAttributes())
+	assert.EqualValues(t, 1, p.Repo.ID)
+	assert.EqualValues(t, "1.0.0", p.LatestVersion.Version)
+	assert.Equal(t, setting.AppSubURL+"/user2/-/packages/generic/test-package", p.Link())
+
+	_, err = GetPackageByName(3, PackageGeneric, "test-package")
+	assert.True(t, IsErrPackageNotExist(err))
+	_, err = GetPackageByName(2, PackageType("other"), "test-package")
+	assert.True(t, IsErrPackageNotExist(err))
+
+	v, err := GetPackageVersionByName(1, "1.0.0")
+	assert.NoError(t, err)
+	assert.NoError(t, v.LoadAttributes())
+	assert.Len(t, v.Files, 1)
+	assert.EqualValues(t, 15, v.Files[0].Blob.Size)
+
+	_, err = GetPackageFileByName(v.ID, "missing.bin")
+	assert.True(t, IsErrPackageFileNotExist(err))
+}
+
+func TestSearchPackages(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	packages, count, err := SearchPackages(&PackageSearchOptions{OwnerID: 2})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, count)
+	assert.Len(t, packages, 1)
+
+	_, count, err = SearchPackages(&PackageSearchOptions{OwnerID: 2, Keyword: "other"})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, count)
+}
+
+func TestAddPackageFile(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	owner := AssertExistsAndLoadBean(t, &User{ID: 3}).(*User)
+	creator := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	blob := AssertExistsAndLoadBean(t, &PackageBlob{ID: 1}).(*PackageBlob)
+
+	opts := &AddPackageFileOptions{
+		Owner:    owner,
+		Creator:  creator,
+		Type:     PackageGeneric,
+		Name:     "org-package",
+		Version:  "2.0.0",
+		Filename: "file.bin",
+		Blob:     &PackageBlob{Size: blob.Size, HashSHA256: blob.HashSHA256},
+	}
+	f, err := AddPackageFile(opts)
+	assert.NoError(t, err)
+	// the content is shared with the file of the fixtures
+	assert.EqualValues(t, blob.ID, f.BlobID)
+	p := AssertExistsAndLoadBean(t, &Package{OwnerID: 3, LowerName: "org-package"}).(*Package)
+	AssertExistsAndLoadBean(t, &PackageVersion{PackageID: p.ID, Version: "2.0.0", CreatorID: 2})
+
+	_, err = AddPackageFile(opts)
+	assert.True(t, IsErrPackageFileAlreadyExist(err))
+
+	opts.Filename = "other.bin"
+	opts.Blob = &PackageBlob{Size: 4, HashSHA256: "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"}
+	f, err = AddPackageFile(opts)
+	assert.NoError(t, err)
+	AssertExistsAndLoadBean(t, &PackageBlob{ID: f.BlobID, HashSHA256: opts.Blob.HashSHA256})
+	AssertCount(t, &PackageVersion{PackageID: p.ID}, 1)
+}
+
+func TestDeletePackageFile(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	p := AssertExistsAndLoadBean(t, &Package{ID: 1}).(*Package)
+	v := AssertExistsAndLoadBean(t, &PackageVersion{ID: 1}).(*PackageVersion)
+	f := AssertExistsAndLoadBean(t, &PackageFile{ID: 1}).(*PackageFile)
+
+	blobs, err := DeletePackageFile(p, v, f)
+	assert.NoError(t, err)
+	if assert.Len(t, blobs, 1) {
+		assert.EqualValues(t, 1, blobs[0].ID)
+	}
+	AssertNotExistsBean(t, &PackageFile{ID: 1})
+	AssertNotExistsBean(t, &PackageBlob{ID: 1})
+	// the version and the package are deleted with their last file
+	AssertNotExistsBean(t, &PackageVersion{ID: 1})
+	AssertNotExistsBean(t, &Package{ID: 1})
+}
+
+func TestDeletePackageVersion(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	owner := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	blob := AssertExistsAndLoadBean(t, &PackageBlob{ID: 1}).(*PackageBlob)
+
+	// a second version sharing the content of the first one
+	f, err := AddPackageFile(&AddPackageFileOptions{
+		Owner:    owner,
+		Creator:  owner,
+		Type:     PackageGeneric,
+		Name:     "test-package",
+		Version:  "1.1.0",
+		Filename: "test-package.bin",
+		Blob:     &PackageBlob{Size: blob.Size, HashSHA256: blob.HashSHA256},
+	})
+	assert.NoError(t, err)
+
+	p := AssertExistsAndLoadBean(t, &Package{ID: 1}).(*Package)
+	v := AssertExistsAndLoadBean(t, &PackageVersion{ID: 1}).(*PackageVersion)
+	blobs, err := DeletePackageVersion(p, v)
+	assert.NoError(t, err)
+	assert.Len(t, blobs, 0)
+	AssertNotExistsBean(t, &PackageVersion{ID: 1})
+	AssertExistsAndLoadBean(t, &PackageBlob{ID: 1})
+	AssertExistsAndLoadBean(t, &Package{ID: 1})
+
+	v = AssertExistsAndLoadBean(t, &PackageVersion{ID: f.VersionID}).(*PackageVersion)
+	blobs, err = DeletePackageVersion(p, v)
+	assert.NoError(t, err)
+	assert.Len(t, blobs, 1)
+	AssertNotExistsBean(t, &PackageBlob{ID: 1})
+	AssertNotExistsBean(t, &Package{ID: 1})
+}
+
+func TestLinkPackageToRepo(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	p := AssertExistsAndLoadBean(t, &Package{ID: 1}).(*Package)
+
+	// repository 3 belongs to another owner
+	assert.True(t, IsErrRepoNotExist(LinkPackageToRepo(p, 3)))
+
+	assert.NoError(t, LinkPackageToRepo(p, 0))
+	AssertExistsAndLoadBean(t, &Package{ID: 1}, "repo_id=0")
+	assert.NoError(t, LinkPackageToRepo(p, 2))
+	AssertExistsAndLoadBean(t, &Package{ID: 1, RepoID: 2})
+}
+
+func TestCanWritePackages(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	user2 := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	org3 := AssertExistsAndLoadBean(t, &User{ID: 3}).(*User)
+	user4 := AssertExistsAndLoadBean(t, &User{ID: 4}).(*User)
+	user5 := AssertExistsAndLoadBean(t, &User{ID: 5}).(*User)
+
+	test := func(owner, user *User, expected bool) {
+		canWrite, err := CanWritePackages(owner, user)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, canWrite)
+	}
+	test(user2, user2, true)
+	test(user2, user4, false)
+	test(user2, nil, false)
+	// user4 is a member of a team with write access
+	test(org3, user4, true)
+	test(org3, user5, false)
+}
+
+func TestAddPackageBlob(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	blob, err := AddPackageBlob(&PackageBlob{Size: 4, HashSHA256: "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"})
+	assert.NoError(t, err)
+	makeOld := func() {
+		_, err := x.Table("package_blob").ID(blob.ID).Update(map[string]interface{}{"created_unix": 946684800})
+		assert.NoError(t, err)
+	}
+
+	// a reused blob is kept by the cleanup until it is referenced
+	makeOld()
+	reused, err := AddPackageBlob(&PackageBlob{Size: blob.Size, HashSHA256: blob.HashSHA256})
+	assert.NoError(t, err)
+	assert.EqualValues(t, blob.ID, reused.ID)
+	blobs, err := DeleteUnreferencedPackageBlobs(time.Hour)
+	assert.NoError(t, err)
+	assert.Len(t, blobs, 0)
+
+	makeOld()
+	blobs, err = DeleteUnreferencedPackageBlobs(time.Hour)
+	assert.NoError(t, err)
+	assert.Len(t, blobs, 1)
+
+	// a blob deleted since it was loaded can not be referenced anymore
+	owner := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	err = AddContainerUploadedBlob(owner, owner, "image", blob)
+	assert.True(t, IsErrPackageBlobNotExist(err))
+	AssertNotExistsBean(t, &PackageBlob{HashSHA256: blob.HashSHA256})
+}
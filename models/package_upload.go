@@ -0,0 +1,81 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/modules/util"
+
+	"xorm.io/builder"
+)
+
+// PackageUpload represents a chunked upload of a package blob in progress, it can only
+// be continued for the package of the owner it was started for and by the same user.
+type PackageUpload struct {
+	ID          int64          `xorm:"pk autoincr"`
+	UUID        string         `xorm:"uuid UNIQUE NOT NULL"`
+	OwnerID     int64          `xorm:"INDEX NOT NULL"`
+	CreatorID   int64          `xorm:"NOT NULL DEFAULT 0"`
+	LowerName   string         `xorm:"NOT NULL"`
+	CreatedUnix util.TimeStamp `xorm:"created"`
+	UpdatedUnix util.TimeStamp `xorm:"INDEX updated"`
+}
+
+// CreatePackageUpload records a chunked upload started by the creator for the package
+// of the owner with the name.
+func CreatePackageUpload(uuid string, owner, creator *User, name string) error {
+	upload := &PackageUpload{
+		UUID:      uuid,
+		OwnerID:   owner.ID,
+		LowerName: strings.ToLower(name),
+	}
+	if creator != nil {
+		upload.CreatorID = creator.ID
+	}
+	_, err := x.Insert(upload)
+	return err
+}
+
+// GetPackageUpload returns the chunked upload with the uuid if it was started by the creator
+// for the package of the owner with the name.
+func GetPackageUpload(uuid string, owner, creator *User, name string) (*PackageUpload, error) {
+	var creatorID int64
+	if creator != nil {
+		creatorID = creator.ID
+	}
+	upload := new(PackageUpload)
+	has, err := x.Where(builder.Eq{
+		"uuid":       uuid,
+		"owner_id":   owner.ID,
+		"creator_id": creatorID,
+		"lower_name": strings.ToLower(name),
+	}).Get(upload)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrPackageUploadNotExist{UUID: uuid}
+	}
+	return upload, nil
+}
+
+// UpdatePackageUpload records that the chunked upload received content
+func UpdatePackageUpload(upload *PackageUpload) error {
+	_, err := x.ID(upload.ID).Cols("updated_unix").Update(upload)
+	return err
+}
+
+// DeletePackageUpload deletes a finished or canceled chunked upload
+func DeletePackageUpload(uuid string) error {
+	_, err := x.Where("uuid = ?", uuid).Delete(new(PackageUpload))
+	return err
+}
+
+// DeleteExpiredPackageUploads deletes the chunked uploads which did not receive content for longer than olderThan
+func DeleteExpiredPackageUploads(olderThan time.Duration) error {
+	_, err := x.Where("updated_unix < ?", time.Now().Add(-olderThan).Unix()).Delete(new(PackageUpload))
+	return err
+}
@@ -0,0 +1,344 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/modules/setting"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/util"
+
+	"xorm.io/builder"
+)
+
+// Project represents a board of issues and pull requests, which belongs to
+// a repository or, when RepoID is 0, to the organization OwnerID.
+type Project struct {
+	ID          int64  `xorm:"pk autoincr"`
+	RepoID      int64  `xorm:"INDEX"`
+	OwnerID     int64  `xorm:"INDEX"`
+	CreatorID   int64  `xorm:"NOT NULL"`
+	Title       string `xorm:"NOT NULL"`
+	Description string `xorm:"TEXT"`
+	IsClosed    bool   `xorm:"INDEX"`
+
+	Repo                *Repository `xorm:"-"`
+	Owner               *User       `xorm:"-"`
+	Creator             *User       `xorm:"-"`
+	RenderedDescription string      `xorm:"-"`
+	NumIssues           int         `xorm:"-"`
+	NumClosedIssues     int         `xorm:"-"`
+	NumOpenIssues       int         `xorm:"-"`
+
+	CreatedUnix    util.TimeStamp `xorm:"INDEX created"`
+	UpdatedUnix    util.TimeStamp `xorm:"INDEX updated"`
+	ClosedDateUnix util.TimeStamp
+}
+
+// IsOrgProject returns true if the project belongs to an organization
+func (p *Project) IsOrgProject() bool {
+	return p.RepoID == 0
+}
+
+func (p *Project) loadAttributes(e Engine) (err error) {
+	if p.IsOrgProject() {
+		if p.Owner == nil {
+			if p.Owner, err = getUserByID(e, p.OwnerID); err != nil {
+				return fmt.Errorf("getUserByID [%d]: %v", p.OwnerID, err)
+			}
+		}
+	} else if p.Repo == nil {
+		if p.Repo, err = getRepositoryByID(e, p.RepoID); err != nil {
+			return fmt.Errorf("getRepositoryByID [%d]: %v", p.RepoID, err)
+		}
+	}
+	if p.Creator == nil {
+		p.Creator, err = getUserByID(e, p.CreatorID)
+		if err != nil {
+			if !IsErrUserNotExist(err) {
+				return fmt.Errorf("getUserByID [%d]: %v", p.CreatorID, err)
+			}
+			p.Creator = NewGhostUser()
+		}
+	}
+	return nil
+}
+
+// LoadAttributes loads the repository or organization and the creator of the project
+func (p *Project) LoadAttributes() error {
+	return p.loadAttributes(x)
+}
+
+// Link returns the relative link of the project, the attributes must be loaded.
+func (p *Project) Link() string {
+	if p.IsOrgProject() {
+		return fmt.Sprintf("%s/org/%s/projects/%d", setting.AppSubURL, p.Owner.Name, p.ID)
+	}
+	return fmt.Sprintf("%s/projects/%d", p.Repo.Link(), p.ID)
+}
+
+// HTMLURL returns the absolute URL of the project, the attributes must be loaded.
+func (p *Project) HTMLURL() string {
+	if p.IsOrgProject() {
+		return fmt.Sprintf("%sorg/%s/projects/%d", setting.AppURL, p.Owner.Name, p.ID)
+	}
+	return fmt.Sprintf("%s/projects/%d", p.Repo.HTMLURL(), p.ID)
+}
+
+// State returns string representation of project status.
+func (p *Project) State() api.StateType {
+	if p.IsClosed {
+		return api.StateClosed
+	}
+	return api.StateOpen
+}
+
+// APIFormat returns this Project in API format, the attributes must be loaded.
+func (p *Project) APIFormat() *api.Project {
+	apiProject := &api.Project{
+		ID:           p.ID,
+		Title:        p.Title,
+		Description:  p.Description,
+		State:        p.State(),
+		RepoID:       p.RepoID,
+		OwnerID:      p.OwnerID,
+		Creator:      p.Creator.APIFormat(),
+		OpenIssues:   p.NumOpenIssues,
+		ClosedIssues: p.NumClosedIssues,
+		HTMLURL:      p.HTMLURL(),
+		Created:      p.CreatedUnix.AsTime(),
+		Updated:      p.UpdatedUnix.AsTime(),
+	}
+	if p.IsClosed {
+		apiProject.Closed = p.ClosedDateUnix.AsTimePtr()
+	}
+	return apiProject
+}
+
+// NewProject creates a new project
+func NewProject(p *Project) error {
+	_, err := x.Insert(p)
+	return err
+}
+
+func getProjectByID(e Engine, id int64) (*Project, error) {
+	p := new(Project)
+	has, err := e.ID(id).Get(p)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrProjectNotExist{ID: id}
+	}
+	return p, nil
+}
+
+// GetProjectByID returns the project by given ID.
+func GetProjectByID(id int64) (*Project, error) {
+	return getProjectByID(x, id)
+}
+
+// GetProjectByRepoID returns the project in a repository.
+func GetProjectByRepoID(repoID, id int64) (*Project, error) {
+	p, err := getProjectByID(x, id)
+	if err != nil {
+		return nil, err
+	} else if p.RepoID != repoID {
+		return nil, ErrProjectNotExist{ID: id, RepoID: repoID}
+	}
+	return p, nil
+}
+
+// GetProjectByOwnerID returns the project of an organization.
+func GetProjectByOwnerID(ownerID, id int64) (*Project, error) {
+	p, err := getProjectByID(x, id)
+	if err != nil {
+		return nil, err
+	} else if !p.IsOrgProject() || p.OwnerID != ownerID {
+		return nil, ErrProjectNotExist{ID: id, OwnerID: ownerID}
+	}
+	return p, nil
+}
+
+// ProjectSearchOptions are options for FindProjects and CountProjects
+type ProjectSearchOptions struct {
+	RepoID   int64
+	OwnerID  int64
+	IsClosed util.OptionalBool
+	Page     int
+	PageSize int
+}
+
+func (opts *ProjectSearchOptions) toConds() builder.Cond {
+	var cond builder.Cond
+	if opts.RepoID > 0 {
+		cond = builder.Eq{"repo_id": opts.RepoID}
+	} else {
+		cond = builder.Eq{"repo_id": 0, "owner_id": opts.OwnerID}
+	}
+	switch opts.IsClosed {
+	case util.OptionalBoolTrue:
+		cond = cond.And(builder.Eq{"is_closed": true})
+	case util.OptionalBoolFalse:
+		cond = cond.And(builder.Eq{"is_closed": false})
+	}
+	return cond
+}
+
+// ProjectList is a list of projects offering additional functionality
+type ProjectList []*Project
+
+func (projects ProjectList) getProjectIDs() []int64 {
+	var ids = make([]int64, 0, len(projects))
+	for _, p := range projects {
+		ids = append(ids, p.ID)
+	}
+	return ids
+}
+
+func (projects ProjectList) loadIssueStats(e Engine) error {
+	type projectIssueStats struct {
+		ProjectID int64
+		IsClosed  bool
+		Count     int
+	}
+	if len(projects) == 0 {
+		return nil
+	}
+
+	rows, err := e.Table("project_issue").
+		Join("INNER", "issue", "issue.id = project_issue.issue_id").
+		Select("project_issue.project_id, issue.is_closed, count(*) AS count").
+		In("project_issue.project_id", projects.getProjectIDs()).
+		GroupBy("project_issue.project_id, issue.is_closed").
+		Rows(new(projectIssueStats))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var numIssues = make(map[int64]int, len(projects))
+	var numClosedIssues = make(map[int64]int, len(projects))
+	for rows.Next() {
+		var stats projectIssueStats
+		if err = rows.Scan(&stats); err != nil {
+			return err
+		}
+		numIssues[stats.ProjectID] += stats.Count
+		if stats.IsClosed {
+			numClosedIssues[stats.ProjectID] += stats.Count
+		}
+	}
+
+	for _, p := range projects {
+		p.NumIssues = numIssues[p.ID]
+		p.NumClosedIssues = numClosedIssues[p.ID]
+		p.NumOpenIssues = p.NumIssues - p.NumClosedIssues
+	}
+	return nil
+}
+
+// LoadAttributes loads the attributes and the issue numbers of every project in the list
+func (projects ProjectList) LoadAttributes() error {
+	for _, p := range projects {
+		if err := p.loadAttributes(x); err != nil {
+			return err
+		}
+	}
+	return projects.loadIssueStats(x)
+}
+
+// FindProjects returns the projects of a repository or an organization, most recent first.
+func FindProjects(opts ProjectSearchOptions) (ProjectList, error) {
+	sess := x.Where(opts.toConds()).Desc("id")
+	if opts.Page > 0 && opts.PageSize > 0 {
+		sess.Limit(opts.PageSize, (opts.Page-1)*opts.PageSize)
+	}
+	projects := make(ProjectList, 0, opts.PageSize)
+	return projects, sess.Find(&projects)
+}
+
+// CountProjects returns the number of projects matching the options
+func CountProjects(opts ProjectSearchOptions) (int64, error) {
+	return x.Where(opts.toConds()).Count(new(Project))
+}
+
+// UpdateProject updates the title and the description of the project
+func UpdateProject(p *Project) error {
+	_, err := x.ID(p.ID).Cols("title", "description").Update(p)
+	return err
+}
+
+// ChangeProjectStatus changes the project open/closed status.
+func ChangeProjectStatus(p *Project, isClosed bool) error {
+	p.IsClosed = isClosed
+	if isClosed {
+		p.ClosedDateUnix = util.TimeStampNow()
+	} else {
+		p.ClosedDateUnix = 0
+	}
+	_, err := x.ID(p.ID).Cols("is_closed", "closed_date_unix").Update(p)
+	return err
+}
+
+func deleteProjectByID(e Engine, id int64) error {
+	if _, err := e.Delete(&ProjectIssue{ProjectID: id}); err != nil {
+		return err
+	}
+	if _, err := e.Delete(&ProjectBoard{ProjectID: id}); err != nil {
+		return err
+	}
+	_, err := e.ID(id).Delete(new(Project))
+	return err
+}
+
+// DeleteProjectByID deletes a project with its boards.
+func DeleteProjectByID(id int64) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	if err := deleteProjectByID(sess, id); err != nil {
+		return err
+	}
+	return sess.Commit()
+}
+
+// deleteProjects deletes the projects matching the condition with their boards.
+func deleteProjects(e Engine, cond builder.Cond) error {
+	var ids []int64
+	if err := e.Table("project").Where(cond).Cols("id").Find(&ids); err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := deleteProjectByID(e, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CanWriteOrgProjects returns true if the user owns the organization or is a member of
+// one of its teams with write access to the projects unit.
+func CanWriteOrgProjects(org *User, user *User) (bool, error) {
+	if user == nil {
+		return false, nil
+	}
+	if user.IsAdmin {
+		return true, nil
+	}
+	teams, err := GetUserOrgTeams(org.ID, user.ID)
+	if err != nil {
+		return false, err
+	}
+	for _, t := range teams {
+		if t.IsOwnerTeam() || (t.Authorize >= AccessModeWrite && t.UnitEnabled(UnitTypeProjects)) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
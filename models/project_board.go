@@ -0,0 +1,138 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/util"
+)
+
+// ProjectBoardTrigger is an event moving the issues of a project to a board automatically
+type ProjectBoardTrigger int
+
+// Enumerate all the board triggers
+const (
+	ProjectBoardTriggerNone   ProjectBoardTrigger = iota // 0 no automatic move
+	ProjectBoardTriggerClosed                            // 1 issue or pull request closed
+	ProjectBoardTriggerMerged                            // 2 pull request merged
+)
+
+var projectBoardTriggerNames = map[ProjectBoardTrigger]string{
+	ProjectBoardTriggerNone:   "none",
+	ProjectBoardTriggerClosed: "closed",
+	ProjectBoardTriggerMerged: "merged",
+}
+
+func (t ProjectBoardTrigger) String() string {
+	return projectBoardTriggerNames[t]
+}
+
+// ToProjectBoardTrigger returns the trigger of the name, the name is empty for no trigger.
+func ToProjectBoardTrigger(name string) (ProjectBoardTrigger, bool) {
+	if name == "" {
+		return ProjectBoardTriggerNone, true
+	}
+	for t, n := range projectBoardTriggerNames {
+		if n == name {
+			return t, true
+		}
+	}
+	return ProjectBoardTriggerNone, false
+}
+
+// ProjectBoard is a column of a project, the issues without board are shown in
+// the uncategorized column.
+type ProjectBoard struct {
+	ID        int64 `xorm:"pk autoincr"`
+	ProjectID int64 `xorm:"INDEX NOT NULL"`
+	Title     string
+	Sorting   int
+	Trigger   ProjectBoardTrigger `xorm:"board_trigger NOT NULL DEFAULT 0"`
+	CreatorID int64               `xorm:"NOT NULL"`
+
+	Issues []*ProjectIssue `xorm:"-"`
+
+	CreatedUnix util.TimeStamp `xorm:"INDEX created"`
+	UpdatedUnix util.TimeStamp `xorm:"INDEX updated"`
+}
+
+// APIFormat returns this board in API format
+func (b *ProjectBoard) APIFormat() *api.ProjectBoard {
+	return &api.ProjectBoard{
+		ID:      b.ID,
+		Title:   b.Title,
+		Sorting: b.Sorting,
+		Trigger: b.Trigger.String(),
+	}
+}
+
+// NewProjectBoard adds a board at the end of the project
+func NewProjectBoard(b *ProjectBoard) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	var maxSorting int
+	if _, err := sess.SQL("SELECT COALESCE((SELECT MAX(sorting) FROM project_board WHERE project_id = ?),0)",
+		b.ProjectID).Get(&maxSorting); err != nil {
+		return err
+	}
+	b.Sorting = maxSorting + 1
+
+	if _, err := sess.Insert(b); err != nil {
+		return err
+	}
+	return sess.Commit()
+}
+
+func getProjectBoard(e Engine, projectID, id int64) (*ProjectBoard, error) {
+	b := &ProjectBoard{
+		ID:        id,
+		ProjectID: projectID,
+	}
+	has, err := e.Get(b)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrProjectBoardNotExist{ID: id, ProjectID: projectID}
+	}
+	return b, nil
+}
+
+// GetProjectBoard returns the board of a project
+func GetProjectBoard(projectID, id int64) (*ProjectBoard, error) {
+	return getProjectBoard(x, projectID, id)
+}
+
+// GetBoards returns the boards of the project in their order
+func (p *Project) GetBoards() ([]*ProjectBoard, error) {
+	boards := make([]*ProjectBoard, 0, 5)
+	return boards, x.Where("project_id=?", p.ID).Asc("sorting").Asc("id").Find(&boards)
+}
+
+// UpdateProjectBoard updates the title, the position and the trigger of a board
+func UpdateProjectBoard(b *ProjectBoard) error {
+	_, err := x.ID(b.ID).Cols("title", "sorting", "board_trigger").Update(b)
+	return err
+}
+
+// DeleteProjectBoard deletes a board, its issues are moved to the uncategorized column.
+func DeleteProjectBoard(b *ProjectBoard) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	if _, err := sess.Exec("UPDATE `project_issue` SET project_board_id = 0 WHERE project_board_id = ?", b.ID); err != nil {
+		return err
+	}
+	if _, err := sess.ID(b.ID).Delete(new(ProjectBoard)); err != nil {
+		return err
+	}
+	return sess.Commit()
+}
@@ -0,0 +1,221 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"fmt"
+
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+// ProjectIssue is the card of an issue or a pull request in a project, ordered by Sorting in its board.
+type ProjectIssue struct {
+	ID             int64 `xorm:"pk autoincr"`
+	IssueID        int64 `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	ProjectID      int64 `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	ProjectBoardID int64 `xorm:"INDEX"`
+	Sorting        int   `xorm:"NOT NULL DEFAULT 0"`
+
+	Issue *Issue `xorm:"-"`
+}
+
+// APIFormat returns this card in API format, the issue must be loaded.
+func (pi *ProjectIssue) APIFormat() *api.ProjectCard {
+	return &api.ProjectCard{
+		BoardID: pi.ProjectBoardID,
+		Sorting: pi.Sorting,
+		Issue:   pi.Issue.APIFormat(),
+	}
+}
+
+// canAddIssue returns true if the issue belongs to the repository of the project
+// or to a repository of the organization of the project.
+func (p *Project) canAddIssue(e Engine, issue *Issue) (bool, error) {
+	if !p.IsOrgProject() {
+		return issue.RepoID == p.RepoID, nil
+	}
+	if err := issue.loadRepo(e); err != nil {
+		return false, err
+	}
+	return issue.Repo.OwnerID == p.OwnerID, nil
+}
+
+// MoveIssueToProjectBoard adds the issue to the project if needed and moves its card at the
+// position in the board, the uncategorized column is the board 0.
+func MoveIssueToProjectBoard(p *Project, issue *Issue, boardID int64, position int) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	if ok, err := p.canAddIssue(sess, issue); err != nil {
+		return err
+	} else if !ok {
+		return ErrProjectIssueNotAllowed{ProjectID: p.ID, IssueID: issue.ID}
+	}
+	if boardID > 0 {
+		if _, err := getProjectBoard(sess, p.ID, boardID); err != nil {
+			return err
+		}
+	}
+
+	pi := &ProjectIssue{
+		ProjectID: p.ID,
+		IssueID:   issue.ID,
+	}
+	has, err := sess.Get(pi)
+	if err != nil {
+		return err
+	}
+
+	cards := make([]*ProjectIssue, 0, 10)
+	if err = sess.Where("project_id=? AND project_board_id=? AND issue_id<>?", p.ID, boardID, issue.ID).
+		Asc("sorting").Asc("id").Find(&cards); err != nil {
+		return err
+	}
+	if position < 0 || position > len(cards) {
+		position = len(cards)
+	}
+
+	for i, card := range cards {
+		sorting := i
+		if i >= position {
+			sorting++
+		}
+		if card.Sorting == sorting {
+			continue
+		}
+		card.Sorting = sorting
+		if _, err = sess.ID(card.ID).Cols("sorting").Update(card); err != nil {
+			return err
+		}
+	}
+
+	pi.ProjectBoardID = boardID
+	pi.Sorting = position
+	if has {
+		_, err = sess.ID(pi.ID).Cols("project_board_id", "sorting").Update(pi)
+	} else {
+		_, err = sess.Insert(pi)
+	}
+	if err != nil {
+		return err
+	}
+	return sess.Commit()
+}
+
+// RemoveIssueFromProject removes the card of the issue from the project
+func RemoveIssueFromProject(p *Project, issueID int64) error {
+	_, err := x.Delete(&ProjectIssue{ProjectID: p.ID, IssueID: issueID})
+	return err
+}
+
+// GetProjectIssue returns the card of the issue in the project
+func GetProjectIssue(projectID, issueID int64) (*ProjectIssue, error) {
+	pi := &ProjectIssue{
+		ProjectID: projectID,
+		IssueID:   issueID,
+	}
+	has, err := x.Get(pi)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrProjectIssueNotExist{ProjectID: projectID, IssueID: issueID}
+	}
+	return pi, nil
+}
+
+// GetProjectIssues returns the cards of the project which can be read by the doer, in
+// the order of their boards, with their issues loaded.
+func GetProjectIssues(p *Project, doer *User) ([]*ProjectIssue, error) {
+	pis := make([]*ProjectIssue, 0, 10)
+	if err := x.Where("project_id=?", p.ID).Asc("sorting").Asc("id").Find(&pis); err != nil {
+		return nil, err
+	}
+	if len(pis) == 0 {
+		return pis, nil
+	}
+
+	issueIDs := make([]int64, 0, len(pis))
+	for _, pi := range pis {
+		issueIDs = append(issueIDs, pi.IssueID)
+	}
+	issues := make(map[int64]*Issue, len(pis))
+	if err := x.In("id", issueIDs).Find(&issues); err != nil {
+		return nil, err
+	}
+	issueList := make(IssueList, 0, len(issues))
+	for _, issue := range issues {
+		issueList = append(issueList, issue)
+	}
+	if err := issueList.LoadAttributes(); err != nil {
+		return nil, err
+	}
+
+	perms := make(map[int64]Permission)
+	readable := pis[:0]
+	for _, pi := range pis {
+		issue, ok := issues[pi.IssueID]
+		if !ok {
+			continue
+		}
+		perm, ok := perms[issue.RepoID]
+		if !ok {
+			var err error
+			if perm, err = GetUserRepoPermission(issue.Repo, doer); err != nil {
+				return nil, fmt.Errorf("GetUserRepoPermission: %v", err)
+			}
+			perms[issue.RepoID] = perm
+		}
+		if !perm.CanReadIssuesOrPulls(issue.IsPull) {
+			continue
+		}
+		pi.Issue = issue
+		readable = append(readable, pi)
+	}
+	return readable, nil
+}
+
+// GetIssueProjects returns the projects the issue belongs to
+func GetIssueProjects(issueID int64) (ProjectList, error) {
+	projects := make(ProjectList, 0, 2)
+	return projects, x.Join("INNER", "project_issue", "project_issue.project_id = project.id").
+		Where("project_issue.issue_id=?", issueID).
+		Asc("project.id").
+		Find(&projects)
+}
+
+// moveIssueByProjectTrigger moves the cards of the issue to the first board having the
+// trigger in each of its projects, at the end of the board.
+func moveIssueByProjectTrigger(e Engine, issue *Issue, trigger ProjectBoardTrigger) error {
+	pis := make([]*ProjectIssue, 0, 2)
+	if err := e.Where("issue_id=?", issue.ID).Find(&pis); err != nil {
+		return err
+	}
+
+	for _, pi := range pis {
+		boards := make([]*ProjectBoard, 0, 1)
+		if err := e.Where("project_id=? AND board_trigger=?", pi.ProjectID, trigger).
+			Asc("sorting").Asc("id").Limit(1).Find(&boards); err != nil {
+			return err
+		}
+		if len(boards) == 0 || boards[0].ID == pi.ProjectBoardID {
+			continue
+		}
+
+		var maxSorting int
+		if _, err := e.SQL("SELECT COALESCE((SELECT MAX(sorting) FROM project_issue WHERE project_board_id = ?),0)",
+			boards[0].ID).Get(&maxSorting); err != nil {
+			return err
+		}
+		pi.ProjectBoardID = boards[0].ID
+		pi.Sorting = maxSorting + 1
+		if _, err := e.ID(pi.ID).Cols("project_board_id", "sorting").Update(pi); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func assertProjectBoardIssues(t *testing.T, boardID int64, expectedIssueIDs ...int64) {
+	pis := make([]*ProjectIssue, 0, len(expectedIssueIDs))
+	assert.NoError(t, x.Where("project_id=1 AND project_board_id=?", boardID).Asc("sorting").Find(&pis))
+	var issueIDs []int64
+	for _, pi := range pis {
+		issueIDs = append(issueIDs, pi.IssueID)
+	}
+	assert.Equal(t, expectedIssueIDs, issueIDs)
+}
+
+func TestMoveIssueToProjectBoard(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	p := AssertExistsAndLoadBean(t, &Project{ID: 1}).(*Project)
+	issue := AssertExistsAndLoadBean(t, &Issue{ID: 2}).(*Issue)
+
+	// move in the same board
+	assert.NoError(t, MoveIssueToProjectBoard(p, issue, 1, 0))
+	assertProjectBoardIssues(t, 1, 2, 1)
+
+	// move to another board
+	assert.NoError(t, MoveIssueToProjectBoard(p, issue, 2, 0))
+	assertProjectBoardIssues(t, 1, 1)
+	assertProjectBoardIssues(t, 2, 2, 5)
+
+	// add a new issue at the end
+	issue = AssertExistsAndLoadBean(t, &Issue{ID: 3}).(*Issue)
+	assert.NoError(t, MoveIssueToProjectBoard(p, issue, 2, -1))
+	assertProjectBoardIssues(t, 2, 2, 5, 3)
+
+	// unknown board
+	err := MoveIssueToProjectBoard(p, issue, 100, 0)
+	assert.True(t, IsErrProjectBoardNotExist(err))
+
+	// issue of another repository
+	issue = AssertExistsAndLoadBean(t, &Issue{ID: 4}).(*Issue)
+	err = MoveIssueToProjectBoard(p, issue, 1, 0)
+	assert.True(t, IsErrProjectIssueNotAllowed(err))
+}
+
+func TestMoveIssueToProjectBoard_Org(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	p := AssertExistsAndLoadBean(t, &Project{ID: 3}).(*Project)
+
+	// repo3 is owned by the organization
+	issue := AssertExistsAndLoadBean(t, &Issue{ID: 6}).(*Issue)
+	assert.NoError(t, MoveIssueToProjectBoard(p, issue, 0, -1))
+
+	issue = AssertExistsAndLoadBean(t, &Issue{ID: 1}).(*Issue)
+	err := MoveIssueToProjectBoard(p, issue, 0, -1)
+	assert.True(t, IsErrProjectIssueNotAllowed(err))
+}
+
+func TestRemoveIssueFromProject(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	p := AssertExistsAndLoadBean(t, &Project{ID: 1}).(*Project)
+
+	assert.NoError(t, RemoveIssueFromProject(p, 1))
+	AssertNotExistsBean(t, &ProjectIssue{ProjectID: 1, IssueID: 1})
+	_, err := GetProjectIssue(1, 1)
+	assert.True(t, IsErrProjectIssueNotExist(err))
+}
+
+func TestGetProjectIssues(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	p := AssertExistsAndLoadBean(t, &Project{ID: 3}).(*Project)
+	owner := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	pis, err := GetProjectIssues(p, owner)
+	assert.NoError(t, err)
+	if assert.Len(t, pis, 1) {
+		assert.EqualValues(t, 6, pis[0].Issue.ID)
+	}
+
+	// repo3 is private
+	pis, err = GetProjectIssues(p, nil)
+	assert.NoError(t, err)
+	assert.Len(t, pis, 0)
+}
+
+func TestGetIssueProjects(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	projects, err := GetIssueProjects(1)
+	assert.NoError(t, err)
+	if assert.Len(t, projects, 1) {
+		assert.EqualValues(t, 1, projects[0].ID)
+	}
+}
+
+func TestMoveIssueByProjectTrigger(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	doer := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+
+	issue := AssertExistsAndLoadBean(t, &Issue{ID: 1}).(*Issue)
+	assert.NoError(t, issue.ChangeStatus(doer, true))
+	assertProjectBoardIssues(t, 1, 2)
+	assertProjectBoardIssues(t, 2, 5, 1)
+
+	pull := AssertExistsAndLoadBean(t, &Issue{ID: 2}).(*Issue)
+	assert.NoError(t, moveIssueByProjectTrigger(x, pull, ProjectBoardTriggerMerged))
+	assertProjectBoardIssues(t, 1)
+	assertProjectBoardIssues(t, 3, 2)
+}
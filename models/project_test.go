@@ -0,0 +1,178 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewProject(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	p := &Project{
+		RepoID:    1,
+		CreatorID: 2,
+		Title:     "projectTitle",
+	}
+
+	assert.NoError(t, NewProject(p))
+	AssertExistsAndLoadBean(t, p)
+}
+
+func TestGetProjectByRepoID(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	p, err := GetProjectByRepoID(1, 1)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, p.ID)
+	assert.EqualValues(t, "First project", p.Title)
+
+	_, err = GetProjectByRepoID(2, 1)
+	assert.True(t, IsErrProjectNotExist(err))
+	_, err = GetProjectByRepoID(1, 3)
+	assert.True(t, IsErrProjectNotExist(err))
+	_, err = GetProjectByOwnerID(3, 1)
+	assert.True(t, IsErrProjectNotExist(err))
+
+	p, err = GetProjectByOwnerID(3, 3)
+	assert.NoError(t, err)
+	assert.True(t, p.IsOrgProject())
+}
+
+func TestFindProjects(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	test := func(opts ProjectSearchOptions, expectedIDs []int64) {
+		projects, err := FindProjects(opts)
+		assert.NoError(t, err)
+		ids := make([]int64, len(projects))
+		for i, p := range projects {
+			ids[i] = p.ID
+		}
+		assert.Equal(t, expectedIDs, ids)
+
+		count, err := CountProjects(opts)
+		assert.NoError(t, err)
+		assert.EqualValues(t, len(expectedIDs), count)
+	}
+	test(ProjectSearchOptions{RepoID: 1}, []int64{2, 1})
+	test(ProjectSearchOptions{RepoID: 1, IsClosed: util.OptionalBoolFalse}, []int64{1})
+	test(ProjectSearchOptions{RepoID: 1, IsClosed: util.OptionalBoolTrue}, []int64{2})
+	test(ProjectSearchOptions{OwnerID: 3}, []int64{3})
+	test(ProjectSearchOptions{OwnerID: 2}, []int64{})
+}
+
+func TestProjectList_LoadAttributes(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	projects, err := FindProjects(ProjectSearchOptions{RepoID: 1, IsClosed: util.OptionalBoolFalse})
+	assert.NoError(t, err)
+	assert.NoError(t, projects.LoadAttributes())
+	assert.Len(t, projects, 1)
+	assert.EqualValues(t, 3, projects[0].NumIssues)
+	assert.EqualValues(t, 1, projects[0].NumClosedIssues)
+	assert.EqualValues(t, 2, projects[0].NumOpenIssues)
+	assert.EqualValues(t, projects[0].Repo.Link()+"/projects/1", projects[0].Link())
+
+	org, err := GetProjectByID(3)
+	assert.NoError(t, err)
+	assert.NoError(t, org.LoadAttributes())
+	assert.EqualValues(t, setting.AppSubURL+"/org/user3/projects/3", org.Link())
+}
+
+func TestChangeProjectStatus(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	p := AssertExistsAndLoadBean(t, &Project{ID: 1}).(*Project)
+
+	assert.NoError(t, ChangeProjectStatus(p, true))
+	p = AssertExistsAndLoadBean(t, &Project{ID: 1}).(*Project)
+	assert.True(t, p.IsClosed)
+	assert.NotZero(t, p.ClosedDateUnix)
+
+	assert.NoError(t, ChangeProjectStatus(p, false))
+	p = AssertExistsAndLoadBean(t, &Project{ID: 1}).(*Project)
+	assert.False(t, p.IsClosed)
+	assert.Zero(t, p.ClosedDateUnix)
+}
+
+func TestDeleteProjectByID(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	assert.NoError(t, DeleteProjectByID(1))
+	AssertNotExistsBean(t, &Project{ID: 1})
+	AssertNotExistsBean(t, &ProjectBoard{ProjectID: 1})
+	AssertNotExistsBean(t, &ProjectIssue{ProjectID: 1})
+	AssertExistsAndLoadBean(t, &ProjectIssue{ProjectID: 3})
+}
+
+func TestCanWriteOrgProjects(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	org := AssertExistsAndLoadBean(t, &User{ID: 3}).(*User)
+
+	test := func(userID int64, expected bool) {
+		user := AssertExistsAndLoadBean(t, &User{ID: userID}).(*User)
+		canWrite, err := CanWriteOrgProjects(org, user)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, canWrite, "user %d", userID)
+	}
+	test(1, true)  // site admin
+	test(2, true)  // owner
+	test(5, false) // not a member
+
+	canWrite, err := CanWriteOrgProjects(org, nil)
+	assert.NoError(t, err)
+	assert.False(t, canWrite)
+}
+
+func TestNewProjectBoard(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	b := &ProjectBoard{
+		ProjectID: 1,
+		Title:     "boardTitle",
+		CreatorID: 2,
+	}
+	assert.NoError(t, NewProjectBoard(b))
+	AssertExistsAndLoadBean(t, &ProjectBoard{ID: b.ID, Sorting: 4})
+
+	b = &ProjectBoard{
+		ProjectID: 3,
+		Title:     "boardTitle",
+		CreatorID: 2,
+	}
+	assert.NoError(t, NewProjectBoard(b))
+	AssertExistsAndLoadBean(t, &ProjectBoard{ID: b.ID, Sorting: 1})
+}
+
+func TestDeleteProjectBoard(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	b, err := GetProjectBoard(1, 1)
+	assert.NoError(t, err)
+	assert.NoError(t, DeleteProjectBoard(b))
+	AssertNotExistsBean(t, &ProjectBoard{ID: 1})
+	AssertNotExistsBean(t, &ProjectIssue{ProjectBoardID: 1})
+	assert.EqualValues(t, 2, getCount(t, x.Where("project_board_id=0"), &ProjectIssue{ProjectID: 1}))
+
+	_, err = GetProjectBoard(3, 2)
+	assert.True(t, IsErrProjectBoardNotExist(err))
+}
+
+func TestToProjectBoardTrigger(t *testing.T) {
+	for _, name := range []string{"none", "closed", "merged"} {
+		trigger, ok := ToProjectBoardTrigger(name)
+		assert.True(t, ok)
+		assert.Equal(t, name, trigger.String())
+	}
+	trigger, ok := ToProjectBoardTrigger("")
+	assert.True(t, ok)
+	assert.Equal(t, ProjectBoardTriggerNone, trigger)
+	_, ok = ToProjectBoardTrigger("reopened")
+	assert.False(t, ok)
+}
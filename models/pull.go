@@ -40,6 +40,18 @@ const (
 	PullRequestGit
 )
 
+// PullRequestFlow defines how the head commits of a pull request are pushed
+type PullRequestFlow int
+
+// Enumerate all the pull request flows
+const (
+	// PullRequestFlowGithub the head commits are pushed to a branch of the head repository
+	PullRequestFlowGithub PullRequestFlow = iota
+	// PullRequestFlowAGit the head commits are pushed to refs/for/<base branch>/<topic> of the base
+	// repository, they are only kept in the hidden pull request ref
+	PullRequestFlowAGit
+)
+
 // PullRequestStatus defines pull request status
 type PullRequestStatus int
 
@@ -55,6 +67,7 @@ const (
 type PullRequest struct {
 	ID              int64 `xorm:"pk autoincr"`
 	Type            PullRequestType
+	Flow            PullRequestFlow `xorm:"NOT NULL DEFAULT 0"`
 	Status          PullRequestStatus
 	ConflictedFiles []string `xorm:"TEXT JSON"`
 
@@ -155,6 +168,59 @@ func (pr *PullRequest) GetGitRefName() string {
 	return fmt.Sprintf("refs/pull/%d/head", pr.Index)
 }
 
+// GetHeadRefName returns the ref of the head commit of the pull request in the head repository,
+// the pull requests created with AGit flow do not have a head branch.
+func (pr *PullRequest) GetHeadRefName() string {
+	if pr.Flow == PullRequestFlowAGit {
+		return pr.GetGitRefName()
+	}
+	return git.BranchPrefix + pr.HeadBranch
+}
+
+// IsAGitFlow returns true if the pull request was created with AGit flow
+func (pr *PullRequest) IsAGitFlow() bool {
+	return pr.Flow == PullRequestFlowAGit
+}
+
+// GetHeadCommitID returns the id of the head commit of the pull request as
+// pushed to the base repository
+func (pr *PullRequest) GetHeadCommitID() (string, error) {
+	if err := pr.GetBaseRepo(); err != nil {
+		return "", err
+	}
+	gitRepo, err := git.OpenRepository(pr.BaseRepo.RepoPath())
+	if err != nil {
+		return "", fmt.Errorf("OpenRepository: %v", err)
+	}
+	return gitRepo.GetRefCommitID(pr.GetGitRefName())
+}
+
+// HasCommit returns true if the commit is one of the commits of the pull
+// request, the commits reachable from its head but not from its merge base.
+func (pr *PullRequest) HasCommit(commitID string) (bool, error) {
+	headCommitID, err := pr.GetHeadCommitID()
+	if err != nil {
+		return false, err
+	}
+	if commitID == headCommitID {
+		return true, nil
+	}
+	base := pr.MergeBase
+	if base == "" {
+		base = git.BranchPrefix + pr.BaseBranch
+	}
+	stdout, err := git.NewCommand("rev-list", headCommitID, "^"+base).RunInDir(pr.BaseRepo.RepoPath())
+	if err != nil {
+		return false, fmt.Errorf("rev-list: %v", err)
+	}
+	for _, id := range strings.Fields(stdout) {
+		if id == commitID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 // APIFormat assumes following fields have been assigned with valid values:
 // Required - Issue
 // Optional - Merger
@@ -246,8 +312,18 @@ func (pr *PullRequest) apiFormat(e Engine) *api.PullRequest {
 		apiPullRequest.Base = apiBaseBranchInfo
 	}
 
-	headBranch, err = pr.HeadRepo.GetBranch(pr.HeadBranch)
-	if err != nil {
+	if pr.IsAGitFlow() {
+		// the head commit is only kept in the hidden pull request ref of the base repository
+		if sha, err := pr.GetHeadCommitID(); err == nil {
+			apiPullRequest.Head = &api.PRBranchInfo{
+				Name:       pr.HeadBranch,
+				Ref:        pr.GetGitRefName(),
+				Sha:        sha,
+				RepoID:     pr.HeadRepoID,
+				Repository: pr.HeadRepo.innerAPIFormat(e, AccessModeNone, false),
+			}
+		}
+	} else if headBranch, err = pr.HeadRepo.GetBranch(pr.HeadBranch); err != nil {
 		if git.IsErrBranchNotExist(err) {
 			apiPullRequest.Head = nil
 		} else {
@@ -340,7 +416,7 @@ func (pr *PullRequest) GetLastCommitStatus() (status *CommitStatus, err error) {
 	}
 
 	repo := pr.HeadRepo
-	lastCommitID, err := headGitRepo.GetBranchCommitID(pr.HeadBranch)
+	lastCommitID, err := headGitRepo.GetRefCommitID(pr.GetHeadRefName())
 	if err != nil {
 		return nil, err
 	}
@@ -423,6 +499,9 @@ func (pr *PullRequest) SetMerged() (err error) {
 	if err = pr.Issue.changeStatus(sess, pr.Merger, true); err != nil {
 		return fmt.Errorf("Issue.changeStatus: %v", err)
 	}
+	if err = moveIssueByProjectTrigger(sess, pr.Issue, ProjectBoardTriggerMerged); err != nil {
+		return fmt.Errorf("moveIssueByProjectTrigger: %v", err)
+	}
 	if _, err = sess.ID(pr.ID).Cols("has_merged, status, merged_commit_id, merger_id, merged_unix").Update(pr); err != nil {
 		return fmt.Errorf("update pull request: %v", err)
 	}
@@ -778,8 +857,8 @@ func PullRequests(baseRepoID int64, opts *PullRequestsOptions) ([]*PullRequest,
 func GetUnmergedPullRequest(headRepoID, baseRepoID int64, headBranch, baseBranch string) (*PullRequest, error) {
 	pr := new(PullRequest)
 	has, err := x.
-		Where("head_repo_id=? AND head_branch=? AND base_repo_id=? AND base_branch=? AND has_merged=? AND issue.is_closed=?",
-			headRepoID, headBranch, baseRepoID, baseBranch, false, false).
+		Where("head_repo_id=? AND head_branch=? AND base_repo_id=? AND base_branch=? AND has_merged=? AND issue.is_closed=? AND flow=?",
+			headRepoID, headBranch, baseRepoID, baseBranch, false, false, PullRequestFlowGithub).
 		Join("INNER", "issue", "issue.id=pull_request.issue_id").
 		Get(pr)
 	if err != nil {
@@ -791,13 +870,31 @@ func GetUnmergedPullRequest(headRepoID, baseRepoID int64, headBranch, baseBranch
 	return pr, nil
 }
 
+// GetUnmergedAGitPullRequest returns the open pull request created with AGit flow by given
+// base repository, poster, topic and base branch.
+func GetUnmergedAGitPullRequest(repoID, posterID int64, topic, baseBranch string) (*PullRequest, error) {
+	pr := new(PullRequest)
+	has, err := x.
+		Where("head_repo_id=? AND head_branch=? AND base_repo_id=? AND base_branch=? AND has_merged=? AND issue.is_closed=? AND flow=? AND issue.poster_id=?",
+			repoID, topic, repoID, baseBranch, false, false, PullRequestFlowAGit, posterID).
+		Join("INNER", "issue", "issue.id=pull_request.issue_id").
+		Get(pr)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrPullRequestNotExist{0, 0, repoID, repoID, topic, baseBranch}
+	}
+
+	return pr, nil
+}
+
 // GetUnmergedPullRequestsByHeadInfo returns all pull requests that are open and has not been merged
 // by given head information (repo and branch).
 func GetUnmergedPullRequestsByHeadInfo(repoID int64, branch string) ([]*PullRequest, error) {
 	prs := make([]*PullRequest, 0, 2)
 	return prs, x.
-		Where("head_repo_id = ? AND head_branch = ? AND has_merged = ? AND issue.is_closed = ?",
-			repoID, branch, false, false).
+		Where("head_repo_id = ? AND head_branch = ? AND has_merged = ? AND issue.is_closed = ? AND flow = ?",
+			repoID, branch, false, false, PullRequestFlowGithub).
 		Join("INNER", "issue", "issue.id = pull_request.issue_id").
 		Find(&prs)
 }
@@ -807,7 +904,7 @@ func GetUnmergedPullRequestsByHeadInfo(repoID int64, branch string) ([]*PullRequ
 func GetLatestPullRequestByHeadInfo(repoID int64, branch string) (*PullRequest, error) {
 	pr := new(PullRequest)
 	has, err := x.
-		Where("head_repo_id = ? AND head_branch = ?", repoID, branch).
+		Where("head_repo_id = ? AND head_branch = ? AND flow = ?", repoID, branch, PullRequestFlowGithub).
 		OrderBy("id DESC").
 		Get(pr)
 	if !has {
@@ -925,14 +1022,14 @@ func (pr *PullRequest) UpdatePatch() (err error) {
 			log.Error("UpdatePatch: RemoveRemote: %s", err)
 		}
 	}()
-	pr.MergeBase, _, err = headGitRepo.GetMergeBase(tmpRemote, pr.BaseBranch, pr.HeadBranch)
+	pr.MergeBase, _, err = headGitRepo.GetMergeBase(tmpRemote, pr.BaseBranch, pr.GetHeadRefName())
 	if err != nil {
 		return fmt.Errorf("GetMergeBase: %v", err)
 	} else if err = pr.Update(); err != nil {
 		return fmt.Errorf("Update: %v", err)
 	}
 
-	patch, err := headGitRepo.GetPatch(pr.MergeBase, pr.HeadBranch)
+	patch, err := headGitRepo.GetPatch(pr.MergeBase, pr.GetHeadRefName())
 	if err != nil {
 		return fmt.Errorf("GetPatch: %v", err)
 	}
@@ -948,6 +1045,11 @@ func (pr *PullRequest) UpdatePatch() (err error) {
 // corresponding branches of base repository.
 // FIXME: Only push branches that are actually updates?
 func (pr *PullRequest) PushToBaseRepo() (err error) {
+	// the commits of the pull requests created with AGit flow are pushed to the base repository
+	if pr.IsAGitFlow() {
+		return nil
+	}
+
 	log.Trace("PushToBaseRepo[%d]: pushing commits to base repo '%s'", pr.BaseRepoID, pr.GetGitRefName())
 
 	headRepoPath := pr.HeadRepo.RepoPath()
@@ -1086,9 +1188,32 @@ func AddTestPullRequestTask(doer *User, repoID int64, branch string, isSync bool
 		return
 	}
 
+	syncPullRequests(doer, prs, repoID, branch, isSync)
+
+	log.Trace("AddTestPullRequestTask [base_repo_id: %d, base_branch: %s]: finding pull requests", repoID, branch)
+	prs, err = GetUnmergedPullRequestsByBaseInfo(repoID, branch)
+	if err != nil {
+		log.Error("Find pull requests [base_repo_id: %d, base_branch: %s]: %v", repoID, branch, err)
+		return
+	}
+	for _, pr := range prs {
+		pr.AddToTaskQueue()
+	}
+}
+
+// AddTestAGitPullRequestTask generates the new patch of a pull request created with AGit flow
+// once its head ref has been updated by a push, and notifies the push.
+func AddTestAGitPullRequestTask(doer *User, pr *PullRequest) {
+	syncPullRequests(doer, []*PullRequest{pr}, pr.BaseRepoID, pr.GetGitRefName(), true)
+}
+
+// syncPullRequests generates the new patches of the pull requests after a push to their head
+// ref in the repository, the push is notified when isSync is true.
+func syncPullRequests(doer *User, prs []*PullRequest, repoID int64, branch string, isSync bool) {
 	if isSync {
 		requests := PullRequestList(prs)
-		if err = requests.LoadAttributes(); err != nil {
+		err := requests.LoadAttributes()
+		if err != nil {
 			log.Error("PullRequestList.LoadAttributes: %v", err)
 		}
 		if invalidationErr := checkForInvalidation(requests, repoID, doer, branch); invalidationErr != nil {
@@ -1101,7 +1226,7 @@ func AddTestPullRequestTask(doer *User, repoID int64, branch string, isSync bool
 					log.Error("LoadAttributes: %v", err)
 					continue
 				}
-				if err = PrepareWebhooks(pr.Issue.Repo, HookEventPullRequest, &api.PullRequestPayload{
+				if err = PrepareWebhooks(pr.Issue.Repo, HookEventPullRequestSync, &api.PullRequestPayload{
 					Action:      api.HookIssueSynchronized,
 					Index:       pr.Issue.Index,
 					PullRequest: pr.Issue.PullRequest.APIFormat(),
@@ -1119,14 +1244,15 @@ func AddTestPullRequestTask(doer *User, repoID int64, branch string, isSync bool
 
 	addHeadRepoTasks(prs)
 
-	log.Trace("AddTestPullRequestTask [base_repo_id: %d, base_branch: %s]: finding pull requests", repoID, branch)
-	prs, err = GetUnmergedPullRequestsByBaseInfo(repoID, branch)
-	if err != nil {
-		log.Error("Find pull requests [base_repo_id: %d, base_branch: %s]: %v", repoID, branch, err)
-		return
-	}
-	for _, pr := range prs {
-		pr.AddToTaskQueue()
+	if isSync {
+		for _, pr := range prs {
+			if err := DismissStaleApprovals(doer, pr); err != nil {
+				log.Error("DismissStaleApprovals [pull_id: %d]: %v", pr.ID, err)
+			}
+			if err := TriggerPullRequestActionWorkflows(doer, pr); err != nil {
+				log.Error("TriggerPullRequestActionWorkflows [pull_id: %d]: %v", pr.ID, err)
+			}
+		}
 	}
 }
 
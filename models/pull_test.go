@@ -107,6 +107,26 @@ func TestGetUnmergedPullRequest(t *testing.T) {
 	assert.True(t, IsErrPullRequestNotExist(err))
 }
 
+func TestGetUnmergedAGitPullRequest(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	pr := AssertExistsAndLoadBean(t, &PullRequest{ID: 2}).(*PullRequest)
+	pr.Flow = PullRequestFlowAGit
+	_, err := x.ID(pr.ID).Cols("flow").Update(pr)
+	assert.NoError(t, err)
+	issue := AssertExistsAndLoadBean(t, &Issue{ID: pr.IssueID}).(*Issue)
+
+	pr, err = GetUnmergedAGitPullRequest(1, issue.PosterID, "branch2", "master")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, pr.ID)
+
+	// the topic only tells the pull requests of a poster apart
+	_, err = GetUnmergedAGitPullRequest(1, issue.PosterID+1, "branch2", "master")
+	assert.True(t, IsErrPullRequestNotExist(err))
+
+	_, err = GetUnmergedPullRequest(1, 1, "branch2", "master")
+	assert.True(t, IsErrPullRequestNotExist(err))
+}
+
 func TestGetUnmergedPullRequestsByHeadInfo(t *testing.T) {
 	assert.NoError(t, PrepareTestDatabase())
 	prs, err := GetUnmergedPullRequestsByHeadInfo(1, "branch2")
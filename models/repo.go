@@ -14,7 +14,6 @@ import (
 
 	// Needed for jpeg support
 	_ "image/jpeg"
-	"image/png"
 	"io/ioutil"
 	"net/url"
 	"os"
@@ -32,6 +31,7 @@ import (
 	"code.gitea.io/gitea/modules/options"
 	"code.gitea.io/gitea/modules/process"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
 	api "code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/modules/sync"
 	"code.gitea.io/gitea/modules/util"
@@ -126,6 +126,15 @@ func NewRepoContext() {
 	RemoveAllWithNotice("Clean up repository temporary data", filepath.Join(setting.AppDataPath, "tmp"))
 }
 
+// RepositoryStatus defines the status of repository
+type RepositoryStatus int
+
+// all kinds of RepositoryStatus
+const (
+	RepositoryReady         RepositoryStatus = iota // a normal repository
+	RepositoryBeingMigrated                         // repository is migrating
+)
+
 // Repository represents a git repository.
 type Repository struct {
 	ID            int64  `xorm:"pk autoincr"`
@@ -159,6 +168,7 @@ type Repository struct {
 
 	IsMirror bool `xorm:"INDEX"`
 	*Mirror  `xorm:"-"`
+	Status   RepositoryStatus `xorm:"NOT NULL DEFAULT 0"`
 
 	ExternalMetas map[string]string `xorm:"-"`
 	Units         []*RepoUnit       `xorm:"-"`
@@ -166,6 +176,9 @@ type Repository struct {
 	IsFork                          bool               `xorm:"INDEX NOT NULL DEFAULT false"`
 	ForkID                          int64              `xorm:"INDEX"`
 	BaseRepo                        *Repository        `xorm:"-"`
+	IsTemplate                      bool               `xorm:"INDEX NOT NULL DEFAULT false"`
+	TemplateID                      int64              `xorm:"INDEX"`
+	TemplateRepo                    *Repository        `xorm:"-"`
 	Size                            int64              `xorm:"NOT NULL DEFAULT 0"`
 	IndexerStatus                   *RepoIndexerStatus `xorm:"-"`
 	IsFsckEnabled                   bool               `xorm:"NOT NULL DEFAULT true"`
@@ -209,6 +222,11 @@ func (repo *Repository) AfterLoad() {
 	repo.NumOpenMilestones = repo.NumMilestones - repo.NumClosedMilestones
 }
 
+// IsBeingMigrated indicates that repository is being migrated
+func (repo *Repository) IsBeingMigrated() bool {
+	return repo.Status == RepositoryBeingMigrated
+}
+
 // MustOwner always returns a valid *User object to avoid
 // conceptually impossible error handling.
 // It creates a fake object that contains error details
@@ -310,6 +328,7 @@ func (repo *Repository) innerAPIFormat(e Engine, mode AccessMode, isParent bool)
 		Size:                      int(repo.Size / 1024),
 		Fork:                      repo.IsFork,
 		Parent:                    parent,
+		Template:                  repo.IsTemplate,
 		Mirror:                    repo.IsMirror,
 		HTMLURL:                   repo.HTMLURL(),
 		SSHURL:                    cloneLink.SSH,
@@ -613,6 +632,27 @@ func (repo *Repository) getBaseRepo(e Engine) (err error) {
 	return err
 }
 
+// IsGenerated returns whether the repository was generated from a template
+func (repo *Repository) IsGenerated() bool {
+	return repo.TemplateID != 0
+}
+
+// GetTemplateRepo populates repo.TemplateRepo for a generated repository and
+// returns an error on failure (NOTE: no error is returned for
+// non-generated repositories, and TemplateRepo will be left untouched)
+func (repo *Repository) GetTemplateRepo() (err error) {
+	return repo.getTemplateRepo(x)
+}
+
+func (repo *Repository) getTemplateRepo(e Engine) (err error) {
+	if !repo.IsGenerated() {
+		return nil
+	}
+
+	repo.TemplateRepo, err = getRepositoryByID(e, repo.TemplateID)
+	return err
+}
+
 func (repo *Repository) repoPath(e Engine) string {
 	return RepoPath(repo.mustOwnerName(e), repo.Name)
 }
@@ -888,6 +928,14 @@ func MigrateRepository(doer, u *User, opts MigrateRepoOptions) (*Repository, err
 		return nil, err
 	}
 
+	return MigrateRepositoryGitData(doer, u, repo, opts)
+}
+
+// MigrateRepositoryGitData clones the git data of a migrated repository into an
+// already created repository. Any git data already present is replaced, so it
+// can be called again when a previous attempt failed.
+func MigrateRepositoryGitData(doer, u *User, repo *Repository, opts MigrateRepoOptions) (*Repository, error) {
+	var err error
 	repoPath := RepoPath(u.Name, opts.Name)
 
 	if u.IsOrganization() {
@@ -966,6 +1014,9 @@ func MigrateRepository(doer, u *User, opts MigrateRepoOptions) (*Repository, err
 	}
 
 	if opts.IsMirror {
+		if _, err = x.Delete(&Mirror{RepoID: repo.ID}); err != nil {
+			return repo, fmt.Errorf("Delete: %v", err)
+		}
 		if _, err = x.InsertOne(&Mirror{
 			RepoID:         repo.ID,
 			Interval:       setting.Mirror.DefaultInterval,
@@ -1068,7 +1119,7 @@ func CleanUpMigrateInfo(repo *Repository) (*Repository, error) {
 }
 
 // initRepoCommit temporarily changes with work directory.
-func initRepoCommit(tmpPath string, sig *git.Signature) (err error) {
+func initRepoCommit(tmpPath string, sig *git.Signature, branch string) (err error) {
 	var stderr string
 	if _, stderr, err = process.GetManager().ExecDir(-1,
 		tmpPath, fmt.Sprintf("initRepoCommit (git add): %s", tmpPath),
@@ -1085,7 +1136,7 @@ func initRepoCommit(tmpPath string, sig *git.Signature) (err error) {
 
 	if _, stderr, err = process.GetManager().ExecDir(-1,
 		tmpPath, fmt.Sprintf("initRepoCommit (git push): %s", tmpPath),
-		git.GitExecutable, "push", "origin", "master"); err != nil {
+		git.GitExecutable, "push", "origin", "HEAD:"+branch); err != nil {
 		return fmt.Errorf("git push: %s", stderr)
 	}
 	return nil
@@ -1102,6 +1153,7 @@ type CreateRepoOptions struct {
 	IsPrivate   bool
 	IsMirror    bool
 	AutoInit    bool
+	Status      RepositoryStatus
 }
 
 func getRepoInitFile(tp, name string) ([]byte, error) {
@@ -1222,7 +1274,7 @@ func initRepository(e Engine, repoPath string, u *User, repo *Repository, opts C
 		}
 
 		// Apply changes and commit.
-		if err = initRepoCommit(tmpDir, u.NewGitSig()); err != nil {
+		if err = initRepoCommit(tmpDir, u.NewGitSig(), "master"); err != nil {
 			return fmt.Errorf("initRepoCommit: %v", err)
 		}
 	}
@@ -1370,6 +1422,7 @@ func CreateRepository(doer, u *User, opts CreateRepoOptions) (_ *Repository, err
 		IsPrivate:                       opts.IsPrivate,
 		IsFsckEnabled:                   !opts.IsMirror,
 		CloseIssuesViaCommitInAnyBranch: setting.Repository.DefaultCloseIssuesViaCommitsInAnyBranch,
+		Status:                          opts.Status,
 	}
 
 	sess := x.NewSession()
@@ -1497,11 +1550,14 @@ func TransferOwnership(doer *User, newOwnerName string, repo *Repository) error
 		}
 	}
 
-	// Remove old team-repository relations.
+	// Remove old team-repository relations and the labels of the old organization.
 	if owner.IsOrganization() {
 		if err = owner.removeOrgRepo(sess, repo.ID); err != nil {
 			return fmt.Errorf("removeOrgRepo: %v", err)
 		}
+		if err = removeOrgLabelsFromRepo(sess, owner.ID, repo.ID); err != nil {
+			return fmt.Errorf("removeOrgLabelsFromRepo: %v", err)
+		}
 	}
 
 	if newOwner.IsOrganization() {
@@ -1776,13 +1832,21 @@ func DeleteRepository(doer *User, uid, repoID int64) error {
 		}
 	}
 
+	jobCond := builder.Select("id").From("action_run_job").Where(builder.Eq{"repo_id": repoID})
+	if _, err = sess.In("job_id", jobCond).
+		Delete(&ActionRunJobLog{}); err != nil {
+		return err
+	}
+
 	if err = deleteBeans(sess,
 		&Access{RepoID: repo.ID},
 		&Action{RepoID: repo.ID},
 		&Watch{RepoID: repoID},
 		&Star{RepoID: repoID},
 		&Mirror{RepoID: repoID},
+		&PushMirror{RepoID: repoID},
 		&Milestone{RepoID: repoID},
+		&Label{RepoID: repoID},
 		&Release{RepoID: repoID},
 		&Collaboration{RepoID: repoID},
 		&PullRequest{BaseRepoID: repoID},
@@ -1792,6 +1856,11 @@ func DeleteRepository(doer *User, uid, repoID int64) error {
 		&HookTask{RepoID: repoID},
 		&Notification{RepoID: repoID},
 		&CommitStatus{RepoID: repoID},
+		&ActionRun{RepoID: repoID},
+		&ActionRunJob{RepoID: repoID},
+		&ActionRunner{RepoID: repoID},
+		&ActionRunnerToken{RepoID: repoID},
+		&Task{RepoID: repoID},
 	); err != nil {
 		return fmt.Errorf("deleteBeans: %v", err)
 	}
@@ -1823,6 +1892,31 @@ func DeleteRepository(doer *User, uid, repoID int64) error {
 		return err
 	}
 
+	// Delete the labels of the issues, including the labels of the organization
+	if _, err = sess.In("issue_id", deleteCond).
+		Delete(&IssueLabel{}); err != nil {
+		return err
+	}
+
+	if err = updateOrgLabelsNumIssues(sess, repo.OwnerID); err != nil {
+		return err
+	}
+
+	// Delete the cards of the issues in the projects of the organization and the projects of the repository
+	if _, err = sess.In("issue_id", deleteCond).
+		Delete(&ProjectIssue{}); err != nil {
+		return err
+	}
+
+	if err = deleteProjects(sess, builder.Eq{"repo_id": repoID}); err != nil {
+		return err
+	}
+
+	// The packages linked to the repository are kept, they belong to the owner
+	if _, err = sess.Where("repo_id = ?", repoID).Cols("repo_id").Update(&Package{}); err != nil {
+		return err
+	}
+
 	attachmentPaths := make([]string, 0, 20)
 	attachments := make([]*Attachment, 0, len(attachmentPaths))
 	if err = sess.Join("INNER", "issue", "issue.id = attachment.issue_id").
@@ -1831,7 +1925,7 @@ func DeleteRepository(doer *User, uid, repoID int64) error {
 		return err
 	}
 	for j := range attachments {
-		attachmentPaths = append(attachmentPaths, attachments[j].LocalPath())
+		attachmentPaths = append(attachmentPaths, attachments[j].RelativePath())
 	}
 
 	if _, err = sess.In("issue_id", deleteCond).
@@ -1868,7 +1962,7 @@ func DeleteRepository(doer *User, uid, repoID int64) error {
 
 	// Remove attachment files.
 	for i := range attachmentPaths {
-		removeAllWithNotice(sess, "Delete attachment", attachmentPaths[i])
+		removeStorageWithNotice(sess, storage.Attachments, "Delete attachment", attachmentPaths[i])
 	}
 
 	// Remove LFS objects
@@ -1924,11 +2018,9 @@ func DeleteRepository(doer *User, uid, repoID int64) error {
 	}
 
 	if len(repo.Avatar) > 0 {
-		avatarPath := repo.CustomAvatarPath()
-		if com.IsExist(avatarPath) {
-			if err := os.Remove(avatarPath); err != nil {
-				return fmt.Errorf("Failed to remove %s: %v", avatarPath, err)
-			}
+		avatarPath := repo.CustomAvatarRelativePath()
+		if err := storage.RepoAvatars.Delete(avatarPath); err != nil {
+			return fmt.Errorf("Failed to remove %s: %v", avatarPath, err)
 		}
 	}
 
@@ -1989,6 +2081,26 @@ func GetRepositoriesMapByIDs(ids []int64) (map[int64]*Repository, error) {
 	return repos, x.In("id", ids).Find(&repos)
 }
 
+// GetRepositoryIDsByFullNames returns the IDs of the repositories by given
+// "owner/name" slice, the repositories must all exist.
+func GetRepositoryIDsByFullNames(fullNames []string) ([]int64, error) {
+	ids := make([]int64, 0, len(fullNames))
+	for _, fullName := range fullNames {
+		fields := strings.SplitN(strings.TrimSpace(fullName), "/", 2)
+		if len(fields) != 2 {
+			return nil, ErrRepoNotExist{0, 0, "", fullName}
+		}
+		repo, err := GetRepositoryByOwnerAndName(fields[0], fields[1])
+		if err != nil {
+			return nil, err
+		}
+		if !com.IsSliceContainsInt64(ids, repo.ID) {
+			ids = append(ids, repo.ID)
+		}
+	}
+	return ids, nil
+}
+
 // GetUserRepositories returns a list of repositories of given user.
 func GetUserRepositories(userID int64, private bool, page, pageSize int, orderBy string) ([]*Repository, error) {
 	if len(orderBy) == 0 {
@@ -2509,13 +2621,20 @@ func (repo *Repository) GetUserFork(userID int64) (*Repository, error) {
 	return &forkedRepo, nil
 }
 
-// CustomAvatarPath returns repository custom avatar file path.
-func (repo *Repository) CustomAvatarPath() string {
-	// Avatar empty by default
+// CustomAvatarRelativePath returns the path of the repository custom avatar
+// relative to the repository avatar storage.
+func (repo *Repository) CustomAvatarRelativePath() string {
+	return repo.Avatar
+}
+
+// IsCustomAvatarExist returns true if the repository custom avatar exists in
+// the repository avatar storage.
+func (repo *Repository) IsCustomAvatarExist() bool {
 	if len(repo.Avatar) == 0 {
-		return ""
+		return false
 	}
-	return filepath.Join(setting.RepositoryAvatarUploadPath, repo.Avatar)
+	_, err := storage.RepoAvatars.Stat(repo.CustomAvatarRelativePath())
+	return err == nil
 }
 
 // generateRandomAvatar generates a random avatar for repository.
@@ -2529,17 +2648,8 @@ func (repo *Repository) generateRandomAvatar(e Engine) error {
 	}
 
 	repo.Avatar = idToString
-	if err = os.MkdirAll(filepath.Dir(repo.CustomAvatarPath()), os.ModePerm); err != nil {
-		return fmt.Errorf("MkdirAll: %v", err)
-	}
-	fw, err := os.Create(repo.CustomAvatarPath())
-	if err != nil {
-		return fmt.Errorf("Create: %v", err)
-	}
-	defer fw.Close()
-
-	if err = png.Encode(fw, img); err != nil {
-		return fmt.Errorf("Encode: %v", err)
+	if err = savePNG(storage.RepoAvatars, repo.CustomAvatarRelativePath(), img); err != nil {
+		return err
 	}
 	log.Info("New random avatar created for repository: %d", repo.ID)
 
@@ -2571,9 +2681,7 @@ func (repo *Repository) RelAvatarLink() string {
 }
 
 func (repo *Repository) relAvatarLink(e Engine) string {
-	// If no avatar - path is empty
-	avatarPath := repo.CustomAvatarPath()
-	if len(avatarPath) == 0 || !com.IsFile(avatarPath) {
+	if !repo.IsCustomAvatarExist() {
 		switch mode := setting.RepositoryAvatarFallback; mode {
 		case "image":
 			return setting.RepositoryAvatarFallbackImage
@@ -2615,7 +2723,7 @@ func (repo *Repository) UploadAvatar(data []byte) error {
 		return err
 	}
 
-	oldAvatarPath := repo.CustomAvatarPath()
+	oldAvatarPath := repo.CustomAvatarRelativePath()
 
 	// Users can upload the same image to other repo - prefix it with ID
 	// Then repo will be removed - only it avatar file will be removed
@@ -2624,22 +2732,12 @@ func (repo *Repository) UploadAvatar(data []byte) error {
 		return fmt.Errorf("UploadAvatar: Update repository avatar: %v", err)
 	}
 
-	if err := os.MkdirAll(setting.RepositoryAvatarUploadPath, os.ModePerm); err != nil {
-		return fmt.Errorf("UploadAvatar: Failed to create dir %s: %v", setting.RepositoryAvatarUploadPath, err)
-	}
-
-	fw, err := os.Create(repo.CustomAvatarPath())
-	if err != nil {
-		return fmt.Errorf("UploadAvatar: Create file: %v", err)
-	}
-	defer fw.Close()
-
-	if err = png.Encode(fw, *m); err != nil {
-		return fmt.Errorf("UploadAvatar: Encode png: %v", err)
+	if err = savePNG(storage.RepoAvatars, repo.CustomAvatarRelativePath(), *m); err != nil {
+		return fmt.Errorf("UploadAvatar: %v", err)
 	}
 
-	if len(oldAvatarPath) > 0 && oldAvatarPath != repo.CustomAvatarPath() {
-		if err := os.Remove(oldAvatarPath); err != nil {
+	if len(oldAvatarPath) > 0 && oldAvatarPath != repo.CustomAvatarRelativePath() {
+		if err := storage.RepoAvatars.Delete(oldAvatarPath); err != nil {
 			return fmt.Errorf("UploadAvatar: Failed to remove old repo avatar %s: %v", oldAvatarPath, err)
 		}
 	}
@@ -2655,7 +2753,7 @@ func (repo *Repository) DeleteAvatar() error {
 		return nil
 	}
 
-	avatarPath := repo.CustomAvatarPath()
+	avatarPath := repo.CustomAvatarRelativePath()
 	log.Trace("DeleteAvatar[%d]: %s", repo.ID, avatarPath)
 
 	sess := x.NewSession()
@@ -2669,13 +2767,8 @@ func (repo *Repository) DeleteAvatar() error {
 		return fmt.Errorf("DeleteAvatar: Update repository avatar: %v", err)
 	}
 
-	if _, err := os.Stat(avatarPath); err == nil {
-		if err := os.Remove(avatarPath); err != nil {
-			return fmt.Errorf("DeleteAvatar: Failed to remove %s: %v", avatarPath, err)
-		}
-	} else {
-		// // Schrodinger: file may or may not exist. See err for details.
-		log.Trace("DeleteAvatar[%d]: %v", err)
+	if err := storage.RepoAvatars.Delete(avatarPath); err != nil {
+		return fmt.Errorf("DeleteAvatar: Failed to remove %s: %v", avatarPath, err)
 	}
 	return sess.Commit()
 }
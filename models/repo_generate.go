@@ -0,0 +1,425 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/process"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
+
+	"github.com/Unknwon/com"
+)
+
+// templateFilePath is the path of the file in a template repository which lists the
+// glob patterns of the files whose variables are expanded when a repository is generated.
+const templateFilePath = ".gitea/template"
+
+// GenerateRepoOptions contains the template units to generate
+type GenerateRepoOptions struct {
+	Name        string
+	Description string
+	Private     bool
+	GitContent  bool
+	Topics      bool
+	Webhooks    bool
+	Avatar      bool
+	IssueLabels bool
+}
+
+// IsValid checks whether at least one option is chosen for generation
+func (opts GenerateRepoOptions) IsValid() bool {
+	return opts.GitContent || opts.Topics || opts.Webhooks || opts.Avatar || opts.IssueLabels
+}
+
+// templateVariables returns the variables which can be used in the files of a template repository
+func templateVariables(e Engine, templateRepo, generateRepo *Repository) map[string]string {
+	templateLink := templateRepo.cloneLink(e, false)
+	generateLink := generateRepo.cloneLink(e, false)
+	return map[string]string{
+		"REPO_NAME":            generateRepo.Name,
+		"REPO_DESCRIPTION":     generateRepo.Description,
+		"REPO_OWNER":           generateRepo.mustOwnerName(e),
+		"REPO_LINK":            generateRepo.HTMLURL(),
+		"REPO_HTTPS_URL":       generateLink.HTTPS,
+		"REPO_SSH_URL":         generateLink.SSH,
+		"TEMPLATE_NAME":        templateRepo.Name,
+		"TEMPLATE_DESCRIPTION": templateRepo.Description,
+		"TEMPLATE_OWNER":       templateRepo.mustOwnerName(e),
+		"TEMPLATE_LINK":        templateRepo.HTMLURL(),
+		"TEMPLATE_HTTPS_URL":   templateLink.HTTPS,
+		"TEMPLATE_SSH_URL":     templateLink.SSH,
+	}
+}
+
+// expandTemplateVariables replaces the known $VAR and ${VAR} variables of the text,
+// the other variables are kept so scripts using environment variables still work.
+func expandTemplateVariables(text string, vars map[string]string) string {
+	return os.Expand(text, func(key string) string {
+		if value, ok := vars[key]; ok {
+			return value
+		}
+		if len(key) == 1 && !com.IsLetter(key[0]) && key != "_" {
+			// special shell variables, e.g. $$ or $1
+			return "$" + key
+		}
+		return "${" + key + "}"
+	})
+}
+
+// templateGlobToRegexp converts a glob pattern of the template file to a regular expression,
+// "*" matches within a path segment and "**" matches across path segments.
+func templateGlobToRegexp(pattern string) (*regexp.Regexp, error) {
+	var buf strings.Builder
+	buf.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					i++
+					buf.WriteString("(.*/)?")
+				} else {
+					buf.WriteString(".*")
+				}
+			} else {
+				buf.WriteString("[^/]*")
+			}
+		case '?':
+			buf.WriteString("[^/]")
+		default:
+			buf.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	buf.WriteString("$")
+	return regexp.Compile(buf.String())
+}
+
+// readTemplateGlobs reads the glob patterns of the template file, blank lines and
+// lines starting with "#" are ignored.
+func readTemplateGlobs(content []byte) ([]*regexp.Regexp, error) {
+	var globs []*regexp.Regexp
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		g, err := templateGlobToRegexp(strings.TrimPrefix(line, "/"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %v", line, err)
+		}
+		globs = append(globs, g)
+	}
+	return globs, scanner.Err()
+}
+
+// expandTemplateFiles expands the variables of the files in the working directory which
+// match the patterns of the template file, which is then removed.
+func expandTemplateFiles(e Engine, tmpDir string, templateRepo, generateRepo *Repository) error {
+	templatePath := filepath.Join(tmpDir, filepath.FromSlash(templateFilePath))
+	content, err := ioutil.ReadFile(templatePath)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if err = os.Remove(templatePath); err != nil {
+		return err
+	}
+
+	globs, err := readTemplateGlobs(content)
+	if err != nil {
+		return err
+	}
+	vars := templateVariables(e, templateRepo, generateRepo)
+
+	return filepath.Walk(tmpDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(tmpDir, path)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+		for _, g := range globs {
+			if !g.MatchString(relPath) {
+				continue
+			}
+			data, err := ioutil.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			return ioutil.WriteFile(path, []byte(expandTemplateVariables(string(data), vars)), info.Mode())
+		}
+		return nil
+	})
+}
+
+// generateRepoCommit prepares the working directory with the content of the default branch
+// of the template repository, whose history isn't kept.
+func generateRepoCommit(e Engine, repo, templateRepo *Repository, tmpDir string) error {
+	templateRepoPath := templateRepo.repoPath(e)
+	_, stderr, err := process.GetManager().ExecTimeout(10*time.Minute,
+		fmt.Sprintf("generateRepoCommit(git clone): %s", templateRepoPath),
+		git.GitExecutable, "clone", "--depth", "1", "--branch", templateRepo.DefaultBranch,
+		"file://"+filepath.ToSlash(templateRepoPath), tmpDir)
+	if err != nil {
+		return fmt.Errorf("git clone: %v - %s", err, stderr)
+	}
+
+	if err = os.RemoveAll(filepath.Join(tmpDir, ".git")); err != nil {
+		return fmt.Errorf("remove git dir: %v", err)
+	}
+
+	if err = expandTemplateFiles(e, tmpDir, templateRepo, repo); err != nil {
+		return fmt.Errorf("expandTemplateFiles: %v", err)
+	}
+
+	if _, stderr, err = process.GetManager().ExecDir(-1,
+		tmpDir, fmt.Sprintf("generateRepoCommit(git init): %s", tmpDir),
+		git.GitExecutable, "init"); err != nil {
+		return fmt.Errorf("git init: %v - %s", err, stderr)
+	}
+
+	repoPath := repo.repoPath(e)
+	if _, stderr, err = process.GetManager().ExecDir(-1,
+		tmpDir, fmt.Sprintf("generateRepoCommit(git remote add): %s", repoPath),
+		git.GitExecutable, "remote", "add", "origin", repoPath); err != nil {
+		return fmt.Errorf("git remote add: %v - %s", err, stderr)
+	}
+	return nil
+}
+
+// generateRepository initializes the git repository of a generated repository with the
+// content of the template repository if needed.
+func generateRepository(e Engine, doer *User, repo, templateRepo *Repository, opts GenerateRepoOptions) (err error) {
+	repoPath := repo.repoPath(e)
+	// Somehow the directory could exist.
+	if com.IsExist(repoPath) {
+		return fmt.Errorf("generateRepository: path already exists: %s", repoPath)
+	}
+
+	if err = git.InitRepository(repoPath, true); err != nil {
+		return fmt.Errorf("InitRepository: %v", err)
+	}
+
+	repo.DefaultBranch = "master"
+	if opts.GitContent && !templateRepo.IsEmpty {
+		tmpDir := filepath.Join(os.TempDir(), "gitea-"+repo.Name+"-"+com.ToStr(time.Now().Nanosecond()))
+		if err = os.MkdirAll(tmpDir, os.ModePerm); err != nil {
+			return fmt.Errorf("Failed to create dir %s: %v", tmpDir, err)
+		}
+		defer os.RemoveAll(tmpDir)
+
+		if err = generateRepoCommit(e, repo, templateRepo, tmpDir); err != nil {
+			return fmt.Errorf("generateRepoCommit: %v", err)
+		}
+
+		if err = initRepoCommit(tmpDir, doer.NewGitSig(), templateRepo.DefaultBranch); err != nil {
+			return fmt.Errorf("initRepoCommit: %v", err)
+		}
+		repo.DefaultBranch = templateRepo.DefaultBranch
+	} else {
+		repo.IsEmpty = true
+	}
+
+	if _, err = e.ID(repo.ID).Cols("default_branch", "is_empty").Update(repo); err != nil {
+		return fmt.Errorf("update repository: %v", err)
+	}
+
+	// The hooks are created after the content is pushed, like a fork the generated
+	// repository starts with the content instead of a push of it.
+	if err = createDelegateHooks(repoPath); err != nil {
+		return fmt.Errorf("createDelegateHooks: %v", err)
+	}
+
+	_, stderr, err := process.GetManager().ExecDir(-1,
+		repoPath, fmt.Sprintf("generateRepository(git update-server-info): %s", repoPath),
+		git.GitExecutable, "update-server-info")
+	if err != nil {
+		return errors.New("generateRepository(git update-server-info): " + stderr)
+	}
+	return nil
+}
+
+// generateIssueLabels copies the labels of the template repository
+func generateIssueLabels(e Engine, repo, templateRepo *Repository) error {
+	labels := make([]*Label, 0, 10)
+	if err := e.Where("repo_id = ?", templateRepo.ID).Asc("name").Find(&labels); err != nil {
+		return err
+	}
+
+	for _, label := range labels {
+		if err := newLabel(e, &Label{
+			RepoID:      repo.ID,
+			Name:        label.Name,
+			Description: label.Description,
+			Color:       label.Color,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// generateWebhooks copies the webhooks of the template repository. Their secrets
+// and the credentials kept in their meta are not copied, the hooks which had any
+// are copied inactive until the credentials are entered again.
+func generateWebhooks(e Engine, repo, templateRepo *Repository) error {
+	ws := make([]*Webhook, 0, 5)
+	if err := e.Where("repo_id = ?", templateRepo.ID).Find(&ws); err != nil {
+		return err
+	}
+
+	for _, w := range ws {
+		hasCredentials := len(w.Secret) > 0
+		w.Secret = ""
+		switch w.HookTaskType {
+		case MATRIX:
+			meta := w.GetMatrixHook()
+			hasCredentials = hasCredentials || len(meta.AccessToken) > 0
+			meta.AccessToken = ""
+			if err := w.setMeta(meta); err != nil {
+				return err
+			}
+		case TELEGRAM:
+			meta := w.GetTelegramHook()
+			hasCredentials = hasCredentials || len(meta.BotToken) > 0
+			meta.BotToken = ""
+			if err := w.setMeta(meta); err != nil {
+				return err
+			}
+			// the bot token is part of the URL
+			w.URL = TelegramSendMessageURL("", meta.ChatID)
+		}
+		if hasCredentials {
+			w.IsActive = false
+		}
+
+		w.ID = 0
+		w.RepoID = repo.ID
+		w.LastStatus = HookStatusNone
+		if err := createWebhook(e, w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// generateAvatar copies the custom avatar of the template repository
+func generateAvatar(repo, templateRepo *Repository) error {
+	if !templateRepo.IsCustomAvatarExist() {
+		return nil
+	}
+
+	obj, err := storage.RepoAvatars.Open(templateRepo.CustomAvatarRelativePath())
+	if err != nil {
+		return err
+	}
+	defer obj.Close()
+
+	data, err := ioutil.ReadAll(obj)
+	if err != nil {
+		return err
+	}
+	return repo.UploadAvatar(data)
+}
+
+// GenerateRepository generates a repository for the user/organization from a template repository.
+func GenerateRepository(doer, owner *User, templateRepo *Repository, opts GenerateRepoOptions) (_ *Repository, err error) {
+	if !doer.IsAdmin && !owner.CanCreateRepo() {
+		return nil, ErrReachLimitOfRepo{owner.MaxRepoCreation}
+	}
+
+	repo := &Repository{
+		OwnerID:                         owner.ID,
+		Owner:                           owner,
+		Name:                            opts.Name,
+		LowerName:                       strings.ToLower(opts.Name),
+		Description:                     opts.Description,
+		IsPrivate:                       opts.Private,
+		IsFsckEnabled:                   true,
+		CloseIssuesViaCommitInAnyBranch: setting.Repository.DefaultCloseIssuesViaCommitsInAnyBranch,
+		TemplateID:                      templateRepo.ID,
+	}
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return nil, err
+	}
+
+	if err = createRepository(sess, doer, owner, repo); err != nil {
+		return nil, err
+	}
+
+	if err = generateRepository(sess, doer, repo, templateRepo, opts); err != nil {
+		repoPath := RepoPath(owner.Name, repo.Name)
+		if err2 := os.RemoveAll(repoPath); err2 != nil {
+			log.Error("generateRepository: %v", err)
+			return nil, fmt.Errorf(
+				"delete repo directory %s/%s failed(2): %v", owner.Name, repo.Name, err2)
+		}
+		return nil, fmt.Errorf("generateRepository: %v", err)
+	}
+
+	if opts.IssueLabels {
+		if err = generateIssueLabels(sess, repo, templateRepo); err != nil {
+			return nil, fmt.Errorf("generateIssueLabels: %v", err)
+		}
+	}
+
+	if opts.Webhooks {
+		if err = generateWebhooks(sess, repo, templateRepo); err != nil {
+			return nil, fmt.Errorf("generateWebhooks: %v", err)
+		}
+	}
+
+	if err = sess.Commit(); err != nil {
+		return nil, err
+	}
+
+	if opts.Topics && len(templateRepo.Topics) > 0 {
+		if err = SaveTopics(repo.ID, templateRepo.Topics...); err != nil {
+			return repo, fmt.Errorf("SaveTopics: %v", err)
+		}
+	}
+
+	if opts.Avatar {
+		if err = generateAvatar(repo, templateRepo); err != nil {
+			return repo, fmt.Errorf("generateAvatar: %v", err)
+		}
+	}
+
+	if err = repo.UpdateSize(); err != nil {
+		log.Error("Failed to update size for repository: %v", err)
+	}
+	return repo, nil
+}
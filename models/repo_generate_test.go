@@ -0,0 +1,108 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"os"
+	"testing"
+
+	"code.gitea.io/gitea/modules/git"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandTemplateVariables(t *testing.T) {
+	vars := map[string]string{
+		"REPO_NAME":  "service",
+		"REPO_OWNER": "user2",
+	}
+
+	assert.Equal(t, "user2/service", expandTemplateVariables("$REPO_OWNER/${REPO_NAME}", vars))
+	assert.Equal(t, "cd ${HOME} && echo $1 $$", expandTemplateVariables("cd $HOME && echo $1 $$", vars))
+	assert.Equal(t, "no variables", expandTemplateVariables("no variables", vars))
+}
+
+func TestTemplateGlobToRegexp(t *testing.T) {
+	kases := []struct {
+		pattern string
+		matches []string
+		others  []string
+	}{
+		{"README.md", []string{"README.md"}, []string{"docs/README.md", "README.mdx"}},
+		{"*.go", []string{"main.go"}, []string{"cmd/main.go", "main.go.orig"}},
+		{"**.go", []string{"main.go", "cmd/main.go"}, []string{"main.gox"}},
+		{"cmd/**/*.go", []string{"cmd/main.go", "cmd/serv/serv.go"}, []string{"main.go"}},
+		{"docs/?.md", []string{"docs/a.md"}, []string{"docs/ab.md", "docs/a/b.md"}},
+	}
+	for _, kase := range kases {
+		g, err := templateGlobToRegexp(kase.pattern)
+		assert.NoError(t, err)
+		for _, path := range kase.matches {
+			assert.True(t, g.MatchString(path), "%s should match %s", kase.pattern, path)
+		}
+		for _, path := range kase.others {
+			assert.False(t, g.MatchString(path), "%s shouldn't match %s", kase.pattern, path)
+		}
+	}
+}
+
+func TestGenerateRepository(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	// the committer is configured globally by git.Init on start
+	for key, value := range map[string]string{"GIT_COMMITTER_NAME": "Gitea", "GIT_COMMITTER_EMAIL": "gitea@fake.local"} {
+		if _, ok := os.LookupEnv(key); !ok {
+			os.Setenv(key, value)
+			defer os.Unsetenv(key)
+		}
+	}
+
+	doer := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	templateRepo := AssertExistsAndLoadBean(t, &Repository{ID: 1}).(*Repository)
+	templateRepo.IsTemplate = true
+	templateRepo.DefaultBranch = "master"
+	_, err := x.ID(templateRepo.ID).Cols("is_template", "default_branch").Update(templateRepo)
+	assert.NoError(t, err)
+	_, err = x.ID(1).Cols("secret").Update(&Webhook{Secret: "template secret"})
+	assert.NoError(t, err)
+
+	repo, err := GenerateRepository(doer, doer, templateRepo, GenerateRepoOptions{
+		Name:        "generated",
+		Description: "generated from repo1",
+		GitContent:  true,
+		Webhooks:    true,
+		IssueLabels: true,
+	})
+	assert.NoError(t, err)
+	assert.NotNil(t, repo)
+
+	generated := AssertExistsAndLoadBean(t, &Repository{ID: repo.ID}).(*Repository)
+	assert.EqualValues(t, templateRepo.ID, generated.TemplateID)
+	assert.False(t, generated.IsTemplate)
+	assert.False(t, generated.IsEmpty)
+	assert.Equal(t, "master", generated.DefaultBranch)
+
+	assert.NoError(t, generated.GetTemplateRepo())
+	assert.EqualValues(t, templateRepo.ID, generated.TemplateRepo.ID)
+
+	assert.Equal(t, getCount(t, x, &Label{RepoID: templateRepo.ID}), getCount(t, x, &Label{RepoID: repo.ID}))
+	assert.Equal(t, getCount(t, x, &Webhook{RepoID: templateRepo.ID}), getCount(t, x, &Webhook{RepoID: repo.ID}))
+	hooks, err := GetWebhooksByRepoID(repo.ID)
+	assert.NoError(t, err)
+	for _, hook := range hooks {
+		assert.Empty(t, hook.Secret)
+		// the hook which had a secret must be activated again by the new owner
+		assert.False(t, hook.IsActive)
+	}
+
+	gitRepo, err := git.OpenRepository(repo.RepoPath())
+	assert.NoError(t, err)
+	commit, err := gitRepo.GetBranchCommit("master")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, commit.ParentCount())
+
+	_, err = GenerateRepository(doer, doer, templateRepo, GenerateRepoOptions{Name: "generated"})
+	assert.True(t, IsErrRepoAlreadyExist(err))
+}
@@ -11,13 +11,15 @@ import (
 
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/highlight"
 	"code.gitea.io/gitea/modules/indexer"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
-
-	"github.com/ethantkoenig/rupture"
 )
 
+// repoIndexerBatchSize the number of files sent to the repo indexer at once
+const repoIndexerBatchSize = 16
+
 // RepoIndexerStatus status of a repo's entry in the repo indexer
 // For now, implicitly refers to default branch
 type RepoIndexerStatus struct {
@@ -142,18 +144,39 @@ func updateRepoIndexer(repo *Repository) error {
 		return nil
 	}
 
-	batch := indexer.RepoIndexerBatch()
+	var updates = make([]*indexer.RepoIndexerUpdate, 0, repoIndexerBatchSize)
+	flush := func() error {
+		if len(updates) == 0 {
+			return nil
+		}
+		err := indexer.UpdateRepoIndexerFiles(updates)
+		updates = updates[:0]
+		return err
+	}
 	for _, update := range changes.Updates {
-		if err := addUpdate(update, repo, batch); err != nil {
+		indexerUpdate, err := getIndexerUpdate(update, repo)
+		if err != nil {
 			return err
+		} else if indexerUpdate == nil {
+			continue
+		}
+		updates = append(updates, indexerUpdate)
+		if len(updates) >= repoIndexerBatchSize {
+			if err = flush(); err != nil {
+				return err
+			}
 		}
 	}
 	for _, filename := range changes.RemovedFilenames {
-		if err := addDelete(filename, repo, batch); err != nil {
-			return err
-		}
+		updates = append(updates, &indexer.RepoIndexerUpdate{
+			Filepath: filename,
+			Op:       indexer.RepoIndexerOpDelete,
+			Data: &indexer.RepoIndexerData{
+				RepoID: repo.ID,
+			},
+		})
 	}
-	if err = batch.Flush(); err != nil {
+	if err = flush(); err != nil {
 		return err
 	}
 	return repo.updateIndexerStatus(sha)
@@ -190,45 +213,42 @@ func getRepoChanges(repo *Repository, revision string) (*repoChanges, error) {
 	return nonGenesisChanges(repo, revision)
 }
 
-func addUpdate(update fileUpdate, repo *Repository, batch rupture.FlushingBatch) error {
+// getIndexerUpdate returns the update to index the file, or nil if the file
+// should not be indexed
+func getIndexerUpdate(update fileUpdate, repo *Repository) (*indexer.RepoIndexerUpdate, error) {
 	stdout, err := git.NewCommand("cat-file", "-s", update.BlobSha).
 		RunInDir(repo.RepoPath())
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if size, err := strconv.Atoi(strings.TrimSpace(stdout)); err != nil {
-		return fmt.Errorf("Misformatted git cat-file output: %v", err)
+		return nil, fmt.Errorf("Misformatted git cat-file output: %v", err)
 	} else if int64(size) > setting.Indexer.MaxIndexerFileSize {
-		return nil
+		return nil, nil
 	}
 
 	fileContents, err := git.NewCommand("cat-file", "blob", update.BlobSha).
 		RunInDirBytes(repo.RepoPath())
 	if err != nil {
-		return err
+		return nil, err
 	} else if !base.IsTextFile(fileContents) {
-		return nil
+		return nil, nil
+	}
+
+	language := highlight.FileNameToHighlightClass(update.Filename)
+	if language == "nohighlight" {
+		language = ""
 	}
-	indexerUpdate := indexer.RepoIndexerUpdate{
+	return &indexer.RepoIndexerUpdate{
 		Filepath: update.Filename,
 		Op:       indexer.RepoIndexerOpUpdate,
 		Data: &indexer.RepoIndexerData{
-			RepoID:  repo.ID,
-			Content: string(fileContents),
+			RepoID:   repo.ID,
+			Filename: update.Filename,
+			Language: language,
+			Content:  string(fileContents),
 		},
-	}
-	return indexerUpdate.AddToFlushingBatch(batch)
-}
-
-func addDelete(filename string, repo *Repository, batch rupture.FlushingBatch) error {
-	indexerUpdate := indexer.RepoIndexerUpdate{
-		Filepath: filename,
-		Op:       indexer.RepoIndexerOpDelete,
-		Data: &indexer.RepoIndexerData{
-			RepoID: repo.ID,
-		},
-	}
-	return indexerUpdate.AddToFlushingBatch(batch)
+	}, nil
 }
 
 // parseGitLsTreeOutput parses the output of a `git ls-tree -r --full-name` command
@@ -136,6 +136,8 @@ type SearchRepoOptions struct {
 	Mirror util.OptionalBool
 	// only search topic name
 	TopicOnly bool
+	// Restrict to the repositories, if not empty
+	RepoIDs []int64
 }
 
 //SearchOrderBy is used to sort the result
@@ -209,6 +211,10 @@ func SearchRepositoryByName(opts *SearchRepoOptions) (RepositoryList, int64, err
 		cond = cond.And(accessCond)
 	}
 
+	if len(opts.RepoIDs) > 0 {
+		cond = cond.And(builder.In("id", opts.RepoIDs))
+	}
+
 	// Restrict to starred repositories
 	if opts.StarredByID > 0 {
 		cond = cond.And(builder.In("id", builder.Select("repo_id").From("star").Where(builder.Eq{"uid": opts.StarredByID})))
@@ -71,15 +71,19 @@ func (m *Mirror) ScheduleNextUpdate() {
 }
 
 func remoteAddress(repoPath string) (string, error) {
+	return getRemoteAddress(repoPath, "origin")
+}
+
+func getRemoteAddress(repoPath, remoteName string) (string, error) {
 	var cmd *git.Command
 	binVersion, err := git.BinVersion()
 	if err != nil {
 		return "", err
 	}
 	if version.Compare(binVersion, "2.7", ">=") {
-		cmd = git.NewCommand("remote", "get-url", "origin")
+		cmd = git.NewCommand("remote", "get-url", remoteName)
 	} else {
-		cmd = git.NewCommand("config", "--get", "remote.origin.url")
+		cmd = git.NewCommand("config", "--get", "remote."+remoteName+".url")
 	}
 
 	result, err := cmd.RunInDir(repoPath)
@@ -435,7 +439,8 @@ func SyncMirrors() {
 	}
 }
 
-// InitSyncMirrors initializes a go routine to sync the mirrors
+// InitSyncMirrors initializes go routines to sync the pull and push mirrors
 func InitSyncMirrors() {
 	go SyncMirrors()
+	go SyncPushMirrors()
 }
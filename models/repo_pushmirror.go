@@ -0,0 +1,231 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/modules/generate"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/process"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/sync"
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/Unknwon/com"
+	"github.com/go-xorm/xorm"
+)
+
+// PushMirrorQueue holds an UniqueQueue object of the push mirror IDs to sync
+var PushMirrorQueue = sync.NewUniqueQueue(setting.Repository.MirrorQueueLength)
+
+// pushMirrorRemotePrefix is the prefix of the git remotes created for push mirrors
+const pushMirrorRemotePrefix = "remote_mirror_"
+
+// PushMirror represents a remote repository that a repository is pushed to.
+type PushMirror struct {
+	ID         int64       `xorm:"pk autoincr"`
+	RepoID     int64       `xorm:"INDEX"`
+	Repo       *Repository `xorm:"-"`
+	RemoteName string
+
+	Interval       time.Duration
+	CreatedUnix    util.TimeStamp `xorm:"created"`
+	LastUpdateUnix util.TimeStamp `xorm:"INDEX"`
+	NextUpdateUnix util.TimeStamp `xorm:"INDEX"`
+	LastError      string         `xorm:"TEXT"`
+
+	address string `xorm:"-"`
+}
+
+// AfterLoad is invoked from XORM after setting the values of all fields of this object.
+func (m *PushMirror) AfterLoad(session *xorm.Session) {
+	if m == nil {
+		return
+	}
+
+	var err error
+	m.Repo, err = getRepositoryByID(session, m.RepoID)
+	if err != nil {
+		log.Error("getRepositoryByID[%d]: %v", m.ID, err)
+	}
+}
+
+// ScheduleNextUpdate calculates and sets next update time.
+func (m *PushMirror) ScheduleNextUpdate() {
+	if m.Interval != 0 {
+		m.NextUpdateUnix = util.TimeStampNow().AddDuration(m.Interval)
+	} else {
+		m.NextUpdateUnix = 0
+	}
+}
+
+func (m *PushMirror) readAddress() {
+	if len(m.address) > 0 {
+		return
+	}
+	var err error
+	m.address, err = getRemoteAddress(m.Repo.RepoPath(), m.RemoteName)
+	if err != nil {
+		log.Error("getRemoteAddress: %v", err)
+	}
+}
+
+// Address returns the push mirror address without credentials.
+func (m *PushMirror) Address() string {
+	m.readAddress()
+	return util.SanitizeURLCredentials(m.address, false)
+}
+
+// runSync pushes all references of the repository to the remote and returns
+// the sanitized error output if the push failed.
+func (m *PushMirror) runSync() error {
+	repoPath := m.Repo.RepoPath()
+	timeout := time.Duration(setting.Git.Timeout.Mirror) * time.Second
+
+	_, stderr, err := process.GetManager().ExecDir(
+		timeout, repoPath, fmt.Sprintf("PushMirror.runSync: %s", repoPath),
+		git.GitExecutable, "push", "--mirror", m.RemoteName)
+	if err != nil {
+		// sanitize the output, since it may contain the remote address, which may
+		// contain a password
+		m.readAddress()
+		message := util.SanitizeMessage(stderr, m.address)
+		return fmt.Errorf("Failed to push mirror repository '%s' to %s: %s", repoPath, m.Address(), message)
+	}
+	return nil
+}
+
+// InsertPushMirror creates the git remote for a push mirror and stores it.
+func InsertPushMirror(m *PushMirror, address string) error {
+	if m.Repo == nil {
+		repo, err := GetRepositoryByID(m.RepoID)
+		if err != nil {
+			return err
+		}
+		m.Repo = repo
+	}
+
+	remoteSuffix, err := generate.GetRandomString(10)
+	if err != nil {
+		return err
+	}
+	m.RemoteName = pushMirrorRemotePrefix + strings.ToLower(remoteSuffix)
+	m.ScheduleNextUpdate()
+
+	repoPath := m.Repo.RepoPath()
+	if _, err = git.NewCommand("remote", "add", "--mirror=push", m.RemoteName, address).RunInDir(repoPath); err != nil {
+		return fmt.Errorf("add remote: %v", err)
+	}
+
+	if _, err = x.Insert(m); err != nil {
+		if _, rmErr := git.NewCommand("remote", "remove", m.RemoteName).RunInDir(repoPath); rmErr != nil {
+			log.Error("Unable to remove remote %s from %s: %v", m.RemoteName, repoPath, rmErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// UpdatePushMirror updates the push mirror
+func UpdatePushMirror(m *PushMirror) error {
+	_, err := x.ID(m.ID).AllCols().Update(m)
+	return err
+}
+
+// DeletePushMirror removes the git remote of a push mirror and deletes it.
+func DeletePushMirror(m *PushMirror) error {
+	if m.Repo != nil {
+		_, err := git.NewCommand("remote", "remove", m.RemoteName).RunInDir(m.Repo.RepoPath())
+		if err != nil && !strings.HasPrefix(err.Error(), "exit status 128 - fatal: No such remote") {
+			return err
+		}
+	}
+	_, err := x.ID(m.ID).Delete(new(PushMirror))
+	return err
+}
+
+// GetPushMirrorByRepoIDAndID returns the push mirror with given ID of a repository.
+func GetPushMirrorByRepoIDAndID(repoID, id int64) (*PushMirror, error) {
+	m := &PushMirror{ID: id, RepoID: repoID}
+	has, err := x.Get(m)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrPushMirrorNotExist{ID: id, RepoID: repoID}
+	}
+	return m, nil
+}
+
+// GetPushMirrorsByRepoID returns all push mirrors of a repository.
+func GetPushMirrorsByRepoID(repoID int64) ([]*PushMirror, error) {
+	mirrors := make([]*PushMirror, 0, 5)
+	return mirrors, x.Where("repo_id=?", repoID).Asc("id").Find(&mirrors)
+}
+
+// AddPushMirrorsToQueue adds all push mirrors of a repository to the sync queue.
+func AddPushMirrorsToQueue(repoID int64) {
+	mirrors, err := GetPushMirrorsByRepoID(repoID)
+	if err != nil {
+		log.Error("GetPushMirrorsByRepoID [%d]: %v", repoID, err)
+		return
+	}
+	for _, m := range mirrors {
+		PushMirrorQueue.Add(m.ID)
+	}
+}
+
+// PushMirrorUpdate checks and queues push mirrors whose interval has elapsed.
+func PushMirrorUpdate() {
+	log.Trace("Doing: PushMirrorUpdate")
+
+	if err := x.
+		Where("next_update_unix<=?", time.Now().Unix()).
+		And("next_update_unix!=0").
+		Iterate(new(PushMirror), func(idx int, bean interface{}) error {
+			m := bean.(*PushMirror)
+			if m.Repo == nil {
+				log.Error("Disconnected push mirror repository found: %d", m.ID)
+				return nil
+			}
+
+			PushMirrorQueue.Add(m.ID)
+			return nil
+		}); err != nil {
+		log.Error("PushMirrorUpdate: %v", err)
+	}
+}
+
+// SyncPushMirrors pushes queued push mirrors to their remotes.
+func SyncPushMirrors() {
+	// Start listening on new sync requests.
+	for id := range PushMirrorQueue.Queue() {
+		log.Trace("SyncPushMirrors [push_mirror_id: %v]", id)
+		PushMirrorQueue.Remove(id)
+
+		m := new(PushMirror)
+		has, err := x.ID(com.StrTo(id).MustInt64()).Get(m)
+		if err != nil {
+			log.Error("GetPushMirrorByID [%s]: %v", id, err)
+			continue
+		} else if !has || m.Repo == nil {
+			continue
+		}
+
+		m.LastError = ""
+		if err = m.runSync(); err != nil {
+			log.Error("SyncPushMirrors [push_mirror_id: %d]: %v", m.ID, err)
+			m.LastError = err.Error()
+		}
+		m.LastUpdateUnix = util.TimeStampNow()
+		m.ScheduleNextUpdate()
+		if err = UpdatePushMirror(m); err != nil {
+			log.Error("UpdatePushMirror [%d]: %v", m.ID, err)
+		}
+	}
+}
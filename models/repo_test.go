@@ -142,6 +142,23 @@ func TestRepoAPIURL(t *testing.T) {
 	assert.Equal(t, "https://try.gitea.io/api/v1/repos/user12/repo10", repo.APIURL())
 }
 
+func TestGetRepositoryIDsByFullNames(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	ids, err := GetRepositoryIDsByFullNames([]string{"user2/repo1", " User3/Repo3", "user2/repo1"})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1, 3}, ids)
+
+	ids, err = GetRepositoryIDsByFullNames(nil)
+	assert.NoError(t, err)
+	assert.Empty(t, ids)
+
+	_, err = GetRepositoryIDsByFullNames([]string{"user2/repo1", "user2/repo100"})
+	assert.True(t, IsErrRepoNotExist(err))
+	_, err = GetRepositoryIDsByFullNames([]string{"repo1"})
+	assert.True(t, IsErrRepoNotExist(err))
+}
+
 func TestTransferOwnership(t *testing.T) {
 	assert.NoError(t, PrepareTestDatabase())
 
@@ -162,7 +179,10 @@ func TestTransferOwnership(t *testing.T) {
 		Content:   "user3/repo3",
 	})
 
-	CheckConsistencyFor(t, &Repository{}, &User{}, &Team{})
+	// the labels of the old organization are removed from the issues
+	AssertNotExistsBean(t, &IssueLabel{LabelID: 3})
+
+	CheckConsistencyFor(t, &Repository{}, &User{}, &Team{}, &Label{})
 }
 
 func TestUploadAvatar(t *testing.T) {
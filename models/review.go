@@ -56,6 +56,9 @@ type Review struct {
 	Issue      *Issue `xorm:"-"`
 	IssueID    int64  `xorm:"index"`
 	Content    string
+	// CommitID is the head commit of the pull request the review was submitted on
+	CommitID  string `xorm:"VARCHAR(40)"`
+	Dismissed bool   `xorm:"NOT NULL DEFAULT false"`
 
 	CreatedUnix util.TimeStamp `xorm:"INDEX created"`
 	UpdatedUnix util.TimeStamp `xorm:"INDEX updated"`
@@ -102,6 +105,34 @@ func (r *Review) LoadAttributes() error {
 	return r.loadAttributes(x)
 }
 
+// HTMLURL returns the absolute URL of the review in the conversation of the pull request
+func (r *Review) HTMLURL() string {
+	if r.Issue == nil {
+		if err := r.loadIssue(x); err != nil {
+			log.Error("loadIssue(%d): %v", r.IssueID, err)
+			return ""
+		}
+	}
+	if err := r.Issue.loadRepo(x); err != nil {
+		log.Error("loadRepo(%d): %v", r.Issue.RepoID, err)
+		return ""
+	}
+	comment := new(Comment)
+	has, err := x.Where("issue_id = ? AND review_id = ? AND type = ?", r.IssueID, r.ID, CommentTypeReview).Get(comment)
+	if err != nil {
+		log.Error("Get review comment [review_id: %d]: %v", r.ID, err)
+	}
+	if !has {
+		return r.Issue.HTMLURL()
+	}
+	return fmt.Sprintf("%s#%s", r.Issue.HTMLURL(), comment.HashTag())
+}
+
+// GetCodeCommentsCount returns the number of code comments of the review
+func (r *Review) GetCodeCommentsCount() (int64, error) {
+	return x.Where("review_id = ? AND type = ?", r.ID, CommentTypeCode).Count(new(Comment))
+}
+
 // Publish will send notifications / actions to participants for all code comments; parts are concurrent
 func (r *Review) Publish() error {
 	return r.publish(x)
@@ -165,7 +196,7 @@ func GetReviewByID(id int64) (*Review, error) {
 func getUniqueApprovalsByPullRequestID(e Engine, prID int64) (reviews []*Review, err error) {
 	reviews = make([]*Review, 0)
 	if err := e.
-		Where("issue_id = ? AND type = ?", prID, ReviewTypeApprove).
+		Where("issue_id = ? AND type = ? AND dismissed = ?", prID, ReviewTypeApprove, false).
 		OrderBy("updated_unix").
 		GroupBy("reviewer_id").
 		Find(&reviews); err != nil {
@@ -220,6 +251,7 @@ type CreateReviewOptions struct {
 	Type     ReviewType
 	Issue    *Issue
 	Reviewer *User
+	CommitID string
 }
 
 func createReview(e Engine, opts CreateReviewOptions) (*Review, error) {
@@ -230,6 +262,7 @@ func createReview(e Engine, opts CreateReviewOptions) (*Review, error) {
 		Reviewer:   opts.Reviewer,
 		ReviewerID: opts.Reviewer.ID,
 		Content:    opts.Content,
+		CommitID:   opts.CommitID,
 	}
 	if _, err := e.Insert(review); err != nil {
 		return nil, err
@@ -312,6 +345,151 @@ func UpdateReview(r *Review) error {
 	return nil
 }
 
+// SubmitReview publishes the pending review of the reviewer on the pull
+// request, a new review is created if there is none. commitID is the head
+// commit the review applies to, the current head of the pull request is used
+// when it is empty.
+func SubmitReview(reviewer *User, issue *Issue, reviewType ReviewType, content, commitID string) (*Review, *Comment, error) {
+	if err := issue.loadPullRequest(x); err != nil {
+		return nil, nil, err
+	}
+	if err := issue.loadRepo(x); err != nil {
+		return nil, nil, err
+	}
+	if commitID == "" {
+		var err error
+		if commitID, err = issue.PullRequest.GetHeadCommitID(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	review, err := getCurrentReview(x, reviewer, issue)
+	if err != nil {
+		if !IsErrReviewNotExist(err) {
+			return nil, nil, err
+		}
+		if review, err = CreateReview(CreateReviewOptions{
+			Type:     reviewType,
+			Issue:    issue,
+			Reviewer: reviewer,
+			Content:  content,
+			CommitID: commitID,
+		}); err != nil {
+			return nil, nil, err
+		}
+	} else {
+		review.Content = content
+		review.Type = reviewType
+		review.CommitID = commitID
+		if err = UpdateReview(review); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	comm, err := CreateComment(&CreateCommentOptions{
+		Type:     CommentTypeReview,
+		Doer:     reviewer,
+		Content:  review.Content,
+		Issue:    issue,
+		Repo:     issue.Repo,
+		ReviewID: review.ID,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = review.Publish(); err != nil {
+		return nil, nil, err
+	}
+	return review, comm, nil
+}
+
+// DismissReview dismisses a submitted review so that it does not count
+// towards the approvals of the pull request anymore. The dismissal is
+// recorded in the conversation of the pull request along with message.
+func DismissReview(doer *User, review *Review, message string) (*Comment, error) {
+	if review.Dismissed {
+		return nil, nil
+	}
+	if err := review.loadIssue(x); err != nil {
+		return nil, err
+	}
+	if err := review.Issue.loadRepo(x); err != nil {
+		return nil, err
+	}
+
+	review.Dismissed = true
+	if _, err := x.ID(review.ID).Cols("dismissed").Update(review); err != nil {
+		return nil, err
+	}
+	return CreateComment(&CreateCommentOptions{
+		Type:     CommentTypeDismissReview,
+		Doer:     doer,
+		Content:  message,
+		Issue:    review.Issue,
+		Repo:     review.Issue.Repo,
+		ReviewID: review.ID,
+	})
+}
+
+// DismissStaleApprovals dismisses the approvals of pr which were given on
+// another commit than its current head commit if the protected base branch
+// asks for it. Approvals without a known commit are kept.
+func DismissStaleApprovals(doer *User, pr *PullRequest) error {
+	if err := pr.LoadProtectedBranch(); err != nil {
+		return err
+	}
+	if pr.ProtectedBranch == nil || !pr.ProtectedBranch.DismissStaleApprovals {
+		return nil
+	}
+
+	headCommitID, err := pr.GetHeadCommitID()
+	if err != nil {
+		return err
+	}
+	reviews := make([]*Review, 0, 5)
+	if err = x.Where("issue_id = ? AND type = ? AND dismissed = ?", pr.IssueID, ReviewTypeApprove, false).
+		And("commit_id <> '' AND commit_id <> ?", headCommitID).
+		Find(&reviews); err != nil {
+		return err
+	}
+	for _, review := range reviews {
+		if _, err = DismissReview(doer, review, "New commits were pushed after this approval."); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getApproverIDsByCommit returns the ids of the users who approved the pull request of
+// the issue on the commit and whose approval is not dismissed.
+func getApproverIDsByCommit(e Engine, issueID int64, commitID string) ([]int64, error) {
+	userIDs := make([]int64, 0, 5)
+	return userIDs, e.Table("review").
+		Where("issue_id = ? AND type = ? AND dismissed = ? AND commit_id = ?", issueID, ReviewTypeApprove, false, commitID).
+		Distinct("reviewer_id").
+		Find(&userIDs)
+}
+
+// DeleteReview deletes a pending review along with its code comments
+func DeleteReview(r *Review) error {
+	if r.Type != ReviewTypePending {
+		return fmt.Errorf("only pending reviews can be deleted")
+	}
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+	if _, err := sess.Where("review_id = ? AND type = ?", r.ID, CommentTypeCode).Delete(new(Comment)); err != nil {
+		return err
+	}
+	if _, err := sess.ID(r.ID).Delete(new(Review)); err != nil {
+		return err
+	}
+	return sess.Commit()
+}
+
 // PullReviewersWithType represents the type used to display a review overview
 type PullReviewersWithType struct {
 	User              `xorm:"extends"`
@@ -325,17 +503,17 @@ func GetReviewersByPullID(pullID int64) (issueReviewers []*PullReviewersWithType
 	if x.Dialect().DBType() == core.MSSQL {
 		err = x.SQL(`SELECT [user].*, review.type, review.review_updated_unix FROM
 (SELECT review.id, review.type, review.reviewer_id, max(review.updated_unix) as review_updated_unix
-FROM review WHERE review.issue_id=? AND (review.type = ? OR review.type = ?)
+FROM review WHERE review.issue_id=? AND (review.type = ? OR review.type = ?) AND review.dismissed = ?
 GROUP BY review.id, review.type, review.reviewer_id) as review
 INNER JOIN [user] ON review.reviewer_id = [user].id ORDER BY review_updated_unix DESC`,
-			pullID, ReviewTypeApprove, ReviewTypeReject).
+			pullID, ReviewTypeApprove, ReviewTypeReject, false).
 			Find(&irs)
 	} else {
 		err = x.Select("`user`.*, review.type, max(review.updated_unix) as review_updated_unix").
 			Table("review").
 			Join("INNER", "`user`", "review.reviewer_id = `user`.id").
-			Where("review.issue_id = ? AND (review.type = ? OR review.type = ?)",
-				pullID, ReviewTypeApprove, ReviewTypeReject).
+			Where("review.issue_id = ? AND (review.type = ? OR review.type = ?) AND review.dismissed = ?",
+				pullID, ReviewTypeApprove, ReviewTypeReject, false).
 			GroupBy("`user`.id, review.type").
 			OrderBy("review_updated_unix DESC").
 			Find(&irs)
@@ -135,3 +135,46 @@ func TestGetReviewersByPullID(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, expectedReviews, allReviews)
 }
+
+func TestSubmitReview(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	issue := AssertExistsAndLoadBean(t, &Issue{ID: 2}).(*Issue)
+	user := AssertExistsAndLoadBean(t, &User{ID: 1}).(*User)
+
+	review, comment, err := SubmitReview(user, issue, ReviewTypeApprove, "LGTM", "4a357436d925b5c974181ff12a994538ddc5a269")
+	assert.NoError(t, err)
+	// the pending review of the user is submitted
+	assert.EqualValues(t, 4, review.ID)
+	assert.Equal(t, ReviewTypeApprove, review.Type)
+	assert.EqualValues(t, review.ID, comment.ReviewID)
+	assert.Equal(t, CommentTypeReview, comment.Type)
+	AssertExistsAndLoadBean(t, &Review{ID: 4, Type: ReviewTypeApprove, Content: "LGTM", CommitID: "4a357436d925b5c974181ff12a994538ddc5a269"})
+}
+
+func TestDismissReview(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	review := AssertExistsAndLoadBean(t, &Review{ID: 8}).(*Review)
+	doer := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+
+	comment, err := DismissReview(doer, review, "outdated")
+	assert.NoError(t, err)
+	assert.Equal(t, CommentTypeDismissReview, comment.Type)
+	assert.EqualValues(t, review.ID, comment.ReviewID)
+	AssertExistsAndLoadBean(t, &Review{ID: 8, Dismissed: true})
+
+	reviewers, err := GetReviewersByPullID(review.IssueID)
+	assert.NoError(t, err)
+	for _, reviewer := range reviewers {
+		assert.NotEqual(t, review.ReviewerID, reviewer.ID)
+	}
+}
+
+func TestDeleteReview(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	assert.Error(t, DeleteReview(AssertExistsAndLoadBean(t, &Review{ID: 1}).(*Review)))
+
+	review := AssertExistsAndLoadBean(t, &Review{ID: 4}).(*Review)
+	assert.NoError(t, DeleteReview(review))
+	AssertNotExistsBean(t, &Review{ID: 4})
+	AssertNotExistsBean(t, &Comment{ReviewID: 4, Type: CommentTypeCode})
+}
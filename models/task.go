@@ -0,0 +1,262 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/json"
+
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/util"
+
+	"xorm.io/builder"
+)
+
+// TaskType defines the kind of a background task
+type TaskType int
+
+// all kinds of TaskType
+const (
+	TaskTypeMigrateRepo TaskType = iota // migrate repository from external or local disk
+)
+
+// TaskStatus defines the status of a background task
+type TaskStatus int
+
+// enumerates all the statuses of tasks
+const (
+	TaskStatusQueue    TaskStatus = iota // 0 task is queued
+	TaskStatusRunning                    // 1 task is running
+	TaskStatusFailed                     // 2 task failed and can be retried
+	TaskStatusFinished                   // 3 task finished
+)
+
+var taskStatusNames = map[TaskStatus]string{
+	TaskStatusQueue:    "queue",
+	TaskStatusRunning:  "running",
+	TaskStatusFailed:   "failed",
+	TaskStatusFinished: "finished",
+}
+
+// String returns the name of the status
+func (s TaskStatus) String() string {
+	return taskStatusNames[s]
+}
+
+// Task represents a task which is processed in the background, e.g. a repository migration.
+// A task keeps the stage it is working on so that it can be resumed after a failure.
+type Task struct {
+	ID        int64
+	DoerID    int64       `xorm:"index"` // operator
+	Doer      *User       `xorm:"-"`
+	OwnerID   int64       `xorm:"index"` // repo owner id, when creating, the repoID maybe zero
+	Owner     *User       `xorm:"-"`
+	RepoID    int64       `xorm:"index"`
+	Repo      *Repository `xorm:"-"`
+	Type      TaskType
+	Status    TaskStatus `xorm:"index"`
+	Stage     string     // the stage the task is working on
+	StagePage int        // the number of pages of the stage which have been completed
+	// StageComments is the number of comments of the page after StagePage which have been migrated
+	StageComments int
+	// Message is the error of the last failed run
+	Message string `xorm:"TEXT"`
+	// PayloadContent is encrypted with the secret key, see SetPayload
+	PayloadContent string `xorm:"TEXT"`
+
+	StartedUnix util.TimeStamp
+	StoppedUnix util.TimeStamp
+	CreatedUnix util.TimeStamp `xorm:"created"`
+	UpdatedUnix util.TimeStamp `xorm:"updated"`
+}
+
+// LoadRepo loads repository of the task
+func (task *Task) LoadRepo() error {
+	return task.loadRepo(x)
+}
+
+func (task *Task) loadRepo(e Engine) error {
+	if task.Repo != nil {
+		return nil
+	}
+	var repo Repository
+	has, err := e.ID(task.RepoID).Get(&repo)
+	if err != nil {
+		return err
+	} else if !has {
+		return ErrRepoNotExist{
+			ID: task.RepoID,
+		}
+	}
+	task.Repo = &repo
+	return nil
+}
+
+// LoadDoer loads the user who started the task
+func (task *Task) LoadDoer() (err error) {
+	if task.Doer != nil {
+		return nil
+	}
+	if task.Doer, err = GetUserByID(task.DoerID); err != nil {
+		if !IsErrUserNotExist(err) {
+			return err
+		}
+		task.Doer = NewGhostUser()
+	}
+	return nil
+}
+
+// LoadOwner loads the owner of the repository of the task
+func (task *Task) LoadOwner() (err error) {
+	if task.Owner != nil {
+		return nil
+	}
+	task.Owner, err = GetUserByID(task.OwnerID)
+	return err
+}
+
+// IsDone returns true if the task will not run anymore without being retried
+func (task *Task) IsDone() bool {
+	return task.Status == TaskStatusFailed || task.Status == TaskStatusFinished
+}
+
+func (task *Task) getEncryptionKey() []byte {
+	k := md5.Sum([]byte(setting.SecretKey))
+	return k[:]
+}
+
+// SetPayload stores v as the payload of the task. The payload may contain
+// credentials, so it is encrypted with the secret key.
+func (task *Task) SetPayload(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	encrypted, err := aesEncrypt(task.getEncryptionKey(), data)
+	if err != nil {
+		return err
+	}
+	task.PayloadContent = base64.StdEncoding.EncodeToString(encrypted)
+	return nil
+}
+
+// GetPayload decrypts the payload of the task into v
+func (task *Task) GetPayload(v interface{}) error {
+	encrypted, err := base64.StdEncoding.DecodeString(task.PayloadContent)
+	if err != nil {
+		return err
+	}
+	data, err := aesDecrypt(task.getEncryptionKey(), encrypted)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// UpdateCols updates some columns
+func (task *Task) UpdateCols(cols ...string) error {
+	_, err := x.ID(task.ID).Cols(cols...).Update(task)
+	return err
+}
+
+// APIFormat converts the task to the api format
+func (task *Task) APIFormat() *structs.RepoTask {
+	apiTask := &structs.RepoTask{
+		ID:      task.ID,
+		Status:  task.Status.String(),
+		Stage:   task.Stage,
+		Message: task.Message,
+		Created: task.CreatedUnix.AsTime(),
+		Updated: task.UpdatedUnix.AsTime(),
+	}
+	if task.StartedUnix > 0 {
+		apiTask.Started = task.StartedUnix.AsTimePtr()
+	}
+	if task.StoppedUnix > 0 {
+		apiTask.Stopped = task.StoppedUnix.AsTimePtr()
+	}
+	return apiTask
+}
+
+// CreateTask creates a task on database
+func CreateTask(task *Task) error {
+	_, err := x.Insert(task)
+	return err
+}
+
+// GetTaskByID returns the task with the given id
+func GetTaskByID(id int64) (*Task, error) {
+	task := new(Task)
+	has, err := x.ID(id).Get(task)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrTaskNotExist{ID: id}
+	}
+	return task, nil
+}
+
+// GetMigratingTask returns the latest migrating task of the repository
+func GetMigratingTask(repoID int64) (*Task, error) {
+	task := new(Task)
+	has, err := x.
+		Where("repo_id = ? AND type = ?", repoID, TaskTypeMigrateRepo).
+		Desc("id").
+		Get(task)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrTaskNotExist{RepoID: repoID}
+	}
+	return task, nil
+}
+
+// FindTaskOptions find all tasks
+type FindTaskOptions struct {
+	Status []TaskStatus
+}
+
+// ToConds generates conditions for database operation.
+func (opts FindTaskOptions) ToConds() builder.Cond {
+	var cond = builder.NewCond()
+	if len(opts.Status) > 0 {
+		cond = cond.And(builder.In("status", opts.Status))
+	}
+	return cond
+}
+
+// FindTasks find all tasks
+func FindTasks(opts FindTaskOptions) ([]*Task, error) {
+	var tasks = make([]*Task, 0, 10)
+	err := x.Where(opts.ToConds()).Asc("id").Find(&tasks)
+	return tasks, err
+}
+
+// FinishMigrateTask marks the migration task as finished and the repository
+// as ready. The payload is cleared since it may contain credentials.
+func FinishMigrateTask(task *Task) error {
+	task.Status = TaskStatusFinished
+	task.StoppedUnix = util.TimeStampNow()
+	task.Message = ""
+	task.PayloadContent = ""
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+	if _, err := sess.ID(task.ID).Cols("status", "stopped_unix", "message", "payload_content").Update(task); err != nil {
+		return err
+	}
+	if _, err := sess.ID(task.RepoID).Cols("status").Update(&Repository{Status: RepositoryReady}); err != nil {
+		return err
+	}
+	if task.Repo != nil {
+		task.Repo.Status = RepositoryReady
+	}
+	return sess.Commit()
+}
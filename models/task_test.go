@@ -0,0 +1,64 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFinishMigrateTask(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	repo := AssertExistsAndLoadBean(t, &Repository{ID: 1}).(*Repository)
+	repo.Status = RepositoryBeingMigrated
+	assert.NoError(t, UpdateRepository(repo, false))
+
+	task := &Task{
+		DoerID:  2,
+		OwnerID: repo.OwnerID,
+		RepoID:  repo.ID,
+		Type:    TaskTypeMigrateRepo,
+		Status:  TaskStatusRunning,
+		Stage:   "issues",
+	}
+	assert.NoError(t, task.SetPayload(map[string]string{"auth_password": "secret"}))
+	assert.NoError(t, CreateTask(task))
+
+	migrating, err := GetMigratingTask(repo.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, task.ID, migrating.ID)
+
+	tasks, err := FindTasks(FindTaskOptions{Status: []TaskStatus{TaskStatusQueue, TaskStatusRunning}})
+	assert.NoError(t, err)
+	if assert.Len(t, tasks, 1) {
+		assert.Equal(t, task.ID, tasks[0].ID)
+	}
+
+	assert.NoError(t, FinishMigrateTask(migrating))
+	task = AssertExistsAndLoadBean(t, &Task{ID: task.ID}).(*Task)
+	assert.Equal(t, TaskStatusFinished, task.Status)
+	assert.Empty(t, task.PayloadContent)
+	assert.True(t, task.StoppedUnix > 0)
+	repo = AssertExistsAndLoadBean(t, &Repository{ID: 1}).(*Repository)
+	assert.False(t, repo.IsBeingMigrated())
+
+	_, err = GetMigratingTask(2)
+	assert.True(t, IsErrTaskNotExist(err))
+}
+
+func TestTaskPayload(t *testing.T) {
+	task := new(Task)
+	assert.NoError(t, task.SetPayload(map[string]string{"auth_password": "secret"}))
+	assert.NotContains(t, task.PayloadContent, "secret")
+
+	var payload map[string]string
+	assert.NoError(t, task.GetPayload(&payload))
+	assert.Equal(t, "secret", payload["auth_password"])
+
+	task.PayloadContent = `{"auth_password":"secret"}`
+	assert.Error(t, task.GetPayload(&payload))
+}
@@ -0,0 +1,110 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"encoding/json"
+	"strings"
+
+	"code.gitea.io/gitea/modules/log"
+)
+
+// GroupTeamMapping maps the names of the groups of an external identity provider
+// to the teams of organizations, e.g. {"developers": {"myorg": ["Owners", "Developers"]}}
+type GroupTeamMapping map[string]map[string][]string
+
+// ParseGroupTeamMapping parses a group to team mapping in JSON format,
+// an empty string is an empty mapping.
+func ParseGroupTeamMapping(s string) (GroupTeamMapping, error) {
+	mapping := make(GroupTeamMapping)
+	if len(strings.TrimSpace(s)) == 0 {
+		return mapping, nil
+	}
+	if err := json.Unmarshal([]byte(s), &mapping); err != nil {
+		return nil, err
+	}
+	return mapping, nil
+}
+
+// normalizeGroupName returns the group name in lower case, without the spaces around
+// the components of the LDAP DNs, so that the names of a group can be compared.
+func normalizeGroupName(group string) string {
+	parts := strings.Split(strings.ToLower(group), ",")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	return strings.Join(parts, ",")
+}
+
+// SyncGroupsToTeams adds the user to the teams mapped from the groups they belong to.
+// If removeOther is true, the user is also removed from the mapped teams of the other groups.
+// The groups are compared case-insensitively, as LDAP DNs.
+func SyncGroupsToTeams(user *User, groups []string, mapping GroupTeamMapping, removeOther bool) error {
+	inGroup := make(map[string]bool, len(groups))
+	for _, group := range groups {
+		inGroup[normalizeGroupName(group)] = true
+	}
+
+	// a team is kept as soon as one of the groups mapped to it contains the user
+	membership := make(map[string]map[string]bool)
+	for group, orgTeams := range mapping {
+		for orgName, teamNames := range orgTeams {
+			orgName = strings.ToLower(orgName)
+			if membership[orgName] == nil {
+				membership[orgName] = make(map[string]bool)
+			}
+			for _, teamName := range teamNames {
+				teamName = strings.ToLower(teamName)
+				membership[orgName][teamName] = membership[orgName][teamName] || inGroup[normalizeGroupName(group)]
+			}
+		}
+	}
+
+	for orgName, teams := range membership {
+		org, err := GetOrgByName(orgName)
+		if err != nil {
+			if IsErrOrgNotExist(err) {
+				log.Warn("SyncGroupsToTeams: organization %s of the group mapping does not exist", orgName)
+				continue
+			}
+			return err
+		}
+
+		for teamName, isMember := range teams {
+			team, err := GetTeam(org.ID, teamName)
+			if err != nil {
+				if err == ErrTeamNotExist {
+					log.Warn("SyncGroupsToTeams: team %s/%s of the group mapping does not exist", orgName, teamName)
+					continue
+				}
+				return err
+			}
+
+			if isMember {
+				if err := AddTeamMember(team, user.ID); err != nil {
+					return err
+				}
+				continue
+			} else if !removeOther {
+				continue
+			}
+
+			wasMember, err := IsTeamMember(org.ID, team.ID, user.ID)
+			if err != nil {
+				return err
+			} else if !wasMember {
+				continue
+			}
+			if err := RemoveTeamMember(team, user.ID); err != nil {
+				if IsErrLastOrgOwner(err) {
+					log.Warn("SyncGroupsToTeams: %s is the last owner of %s and cannot be removed", user.Name, orgName)
+					continue
+				}
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/modules/auth/ldap"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseGroupTeamMapping(t *testing.T) {
+	mapping, err := ParseGroupTeamMapping("")
+	assert.NoError(t, err)
+	assert.Empty(t, mapping)
+
+	mapping, err = ParseGroupTeamMapping(`{"dev": {"user3": ["team1", "test_team"]}}`)
+	assert.NoError(t, err)
+	assert.Equal(t, GroupTeamMapping{"dev": {"user3": {"team1", "test_team"}}}, mapping)
+
+	_, err = ParseGroupTeamMapping(`{"dev": ["team1"]}`)
+	assert.Error(t, err)
+}
+
+func TestSyncGroupsToTeams(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	user := AssertExistsAndLoadBean(t, &User{ID: 4}).(*User)
+	mapping := GroupTeamMapping{
+		"dev":     {"user3": {"Test_Team"}},
+		"ops":     {"user3": {"team1"}},
+		"unknown": {"user3": {"no-such-team"}, "no-such-org": {"Owners"}},
+	}
+
+	assert.NoError(t, SyncGroupsToTeams(user, []string{"dev"}, mapping, false))
+	AssertExistsAndLoadBean(t, &TeamUser{TeamID: 7, UID: 4})
+	AssertExistsAndLoadBean(t, &TeamUser{TeamID: 2, UID: 4})
+
+	assert.NoError(t, SyncGroupsToTeams(user, []string{"dev"}, mapping, true))
+	AssertExistsAndLoadBean(t, &TeamUser{TeamID: 7, UID: 4})
+	AssertNotExistsBean(t, &TeamUser{TeamID: 2, UID: 4})
+
+	// the last owner of an organization is kept
+	owner := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	assert.NoError(t, SyncGroupsToTeams(owner, nil, GroupTeamMapping{"admins": {"user3": {"Owners"}}}, true))
+	AssertExistsAndLoadBean(t, &TeamUser{TeamID: 1, UID: 2})
+}
+
+func TestSyncGroupsToTeams_DN(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	user := AssertExistsAndLoadBean(t, &User{ID: 4}).(*User)
+	mapping := GroupTeamMapping{"cn=Developers,ou=Groups,dc=example,dc=org": {"user3": {"test_team"}}}
+
+	assert.NoError(t, SyncGroupsToTeams(user, []string{"CN=developers, OU=groups, DC=example, DC=org"}, mapping, true))
+	AssertExistsAndLoadBean(t, &TeamUser{TeamID: 7, UID: 4})
+}
+
+func TestSyncLdapGroupsToTeams(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	user := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	source := &LoginSource{
+		Type: LoginLDAP,
+		Cfg: &LDAPConfig{Source: &ldap.Source{
+			GroupsEnabled:       true,
+			GroupTeamMap:        `{"cn=ops,dc=example,dc=org": {"user3": ["team1"]}}`,
+			GroupTeamMapRemoval: true,
+		}},
+	}
+
+	// the teams are kept when the groups are unavailable
+	assert.NoError(t, syncLdapGroupsToTeams(user, source, &ldap.SearchResult{GroupsUnavailable: true}))
+	AssertExistsAndLoadBean(t, &TeamUser{TeamID: 2, UID: 2})
+
+	assert.NoError(t, syncLdapGroupsToTeams(user, source, &ldap.SearchResult{}))
+	AssertNotExistsBean(t, &TeamUser{TeamID: 2, UID: 2})
+}
+
+func TestSyncOAuth2User(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	user := AssertExistsAndLoadBean(t, &User{ID: 4}).(*User)
+	source := &LoginSource{
+		Type: LoginOAuth2,
+		Cfg: &OAuth2Config{
+			Provider:        "openidConnect",
+			AdminClaim:      "roles",
+			AdminClaimValue: "gitea-admin",
+			GroupClaimName:  "roles",
+			GroupTeamMap:    `{"gitea-dev": {"user3": ["test_team"]}}`,
+		},
+	}
+
+	claims := map[string]interface{}{"roles": []interface{}{"gitea-admin", "gitea-dev"}}
+	assert.NoError(t, SyncOAuth2User(user, source, claims))
+	AssertExistsAndLoadBean(t, &User{ID: 4, IsAdmin: true})
+	AssertExistsAndLoadBean(t, &TeamUser{TeamID: 7, UID: 4})
+
+	claims = map[string]interface{}{"roles": "gitea-dev"}
+	assert.NoError(t, SyncOAuth2User(user, source, claims))
+	assert.False(t, user.IsAdmin)
+	AssertExistsAndLoadBean(t, &User{ID: 4}, "is_admin=0")
+
+	// the last administrator, like the first user who registered, is kept
+	admin := AssertExistsAndLoadBean(t, &User{ID: 1}).(*User)
+	assert.NoError(t, SyncOAuth2User(admin, source, claims))
+	assert.True(t, admin.IsAdmin)
+	AssertExistsAndLoadBean(t, &User{ID: 1}, "is_admin=1")
+
+	cfg := &OAuth2Config{AdminClaim: "is_admin"}
+	assert.True(t, cfg.IsAdminByClaims(map[string]interface{}{"is_admin": true}))
+	assert.False(t, cfg.IsAdminByClaims(map[string]interface{}{"is_admin": false}))
+	assert.False(t, cfg.IsAdminByClaims(map[string]interface{}{}))
+}
@@ -7,12 +7,15 @@ package models
 
 import (
 	"crypto/subtle"
+	"strings"
 	"time"
 
+	"github.com/Unknwon/com"
 	gouuid "github.com/satori/go.uuid"
 
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/generate"
+	api "code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/modules/util"
 )
 
@@ -25,6 +28,12 @@ type AccessToken struct {
 	TokenHash      string `xorm:"UNIQUE"` // sha256 of token
 	TokenSalt      string
 	TokenLastEight string `xorm:"token_last_eight"`
+	// Scope is the list of the scopes granted to the token separated by commas
+	Scope string `xorm:"NOT NULL DEFAULT 'all'"`
+	// RepoIDs restricts the token to some repositories, it can access all the repositories
+	// of the user when it is empty.
+	RepoIDs []int64       `xorm:"JSON TEXT"`
+	Repos   []*Repository `xorm:"-"`
 
 	CreatedUnix       util.TimeStamp `xorm:"INDEX created"`
 	UpdatedUnix       util.TimeStamp `xorm:"INDEX updated"`
@@ -38,8 +47,75 @@ func (t *AccessToken) AfterLoad() {
 	t.HasRecentActivity = t.UpdatedUnix.AddDuration(7*24*time.Hour) > util.TimeStampNow()
 }
 
+// Scopes returns the scopes granted to the token
+func (t *AccessToken) Scopes() []AccessTokenScope {
+	if t.Scope == "" {
+		return []AccessTokenScope{AccessTokenScopeAll}
+	}
+	names := strings.Split(t.Scope, ",")
+	scopes := make([]AccessTokenScope, len(names))
+	for i := range names {
+		scopes[i] = AccessTokenScope(names[i])
+	}
+	return scopes
+}
+
+// HasScope returns true if the token is granted the scope
+func (t *AccessToken) HasScope(scope AccessTokenScope) bool {
+	for _, s := range t.Scopes() {
+		if s.Implies(scope) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsRestricted returns true if the token can only access some repositories
+func (t *AccessToken) IsRestricted() bool {
+	return len(t.RepoIDs) > 0
+}
+
+// CanAccessRepo returns true if the token is not restricted to other repositories
+func (t *AccessToken) CanAccessRepo(repoID int64) bool {
+	return !t.IsRestricted() || com.IsSliceContainsInt64(t.RepoIDs, repoID)
+}
+
+// LoadRepos loads the repositories the token is restricted to
+func (t *AccessToken) LoadRepos() error {
+	if !t.IsRestricted() || t.Repos != nil {
+		return nil
+	}
+	t.Repos = make([]*Repository, 0, len(t.RepoIDs))
+	return x.In("id", t.RepoIDs).Asc("lower_name").Find(&t.Repos)
+}
+
+// APIFormat converts an AccessToken to the api.AccessToken format, without the token itself
+func (t *AccessToken) APIFormat() (*api.AccessToken, error) {
+	if err := t.LoadRepos(); err != nil {
+		return nil, err
+	}
+	scopes := t.Scopes()
+	apiToken := &api.AccessToken{
+		ID:             t.ID,
+		Name:           t.Name,
+		TokenLastEight: t.TokenLastEight,
+		Scopes:         make([]string, len(scopes)),
+		Repositories:   make([]string, len(t.Repos)),
+	}
+	for i := range scopes {
+		apiToken.Scopes[i] = string(scopes[i])
+	}
+	for i := range t.Repos {
+		apiToken.Repositories[i] = t.Repos[i].FullName()
+	}
+	return apiToken, nil
+}
+
 // NewAccessToken creates new access token.
 func NewAccessToken(t *AccessToken) error {
+	if t.Scope == "" {
+		t.Scope = string(AccessTokenScopeAll)
+	}
 	salt, err := generate.GetRandomString(10)
 	if err != nil {
 		return err
@@ -0,0 +1,98 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"strings"
+)
+
+// AccessTokenScope is a permission granted to an access token
+type AccessTokenScope string
+
+// Enumerate all the access token scopes
+const (
+	AccessTokenScopeAll       AccessTokenScope = "all"        // everything the user can do, the scope of the tokens without scope
+	AccessTokenScopeRepoRead  AccessTokenScope = "repo:read"  // read repositories, clone and fetch
+	AccessTokenScopeRepoWrite AccessTokenScope = "repo:write" // write repositories and push, implies repo:read
+	AccessTokenScopeAdmin     AccessTokenScope = "admin"      // site administration
+	AccessTokenScopeOrg       AccessTokenScope = "org"        // organizations and teams
+	AccessTokenScopeUser      AccessTokenScope = "user"       // settings of the user
+	AccessTokenScopePackage   AccessTokenScope = "package"    // packages
+
+	// AccessTokenScopeNone grants no access to the API, it is the scope of the OAuth2
+	// access tokens requested with the OpenID Connect scopes only.
+	AccessTokenScopeNone AccessTokenScope = "none"
+)
+
+// AccessTokenScopes lists all the access token scopes in their display order
+var AccessTokenScopes = []AccessTokenScope{
+	AccessTokenScopeAll,
+	AccessTokenScopeRepoRead,
+	AccessTokenScopeRepoWrite,
+	AccessTokenScopeAdmin,
+	AccessTokenScopeOrg,
+	AccessTokenScopeUser,
+	AccessTokenScopePackage,
+}
+
+// impliedAccessTokenScopes lists the scopes granted along with a scope
+var impliedAccessTokenScopes = map[AccessTokenScope][]AccessTokenScope{
+	AccessTokenScopeRepoWrite: {AccessTokenScopeRepoRead},
+}
+
+// IsValid returns true if the scope exists
+func (s AccessTokenScope) IsValid() bool {
+	for _, scope := range AccessTokenScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// DescKey returns the locale key of the description of the scope
+func (s AccessTokenScope) DescKey() string {
+	return "settings.token_scope." + strings.Replace(string(s), ":", "_", -1)
+}
+
+// Implies returns true if a token granted the scope s is granted the scope other
+func (s AccessTokenScope) Implies(other AccessTokenScope) bool {
+	if s == AccessTokenScopeAll || s == other {
+		return true
+	}
+	for _, implied := range impliedAccessTokenScopes[s] {
+		if implied == other {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeAccessTokenScope validates a list of scope names and returns them separated
+// by commas in their display order, an empty list grants all the scopes.
+func NormalizeAccessTokenScope(names []string) (string, error) {
+	granted := make(map[AccessTokenScope]bool, len(names))
+	for _, name := range names {
+		scope := AccessTokenScope(strings.TrimSpace(name))
+		if scope == "" {
+			continue
+		}
+		if !scope.IsValid() {
+			return "", ErrAccessTokenScopeInvalid{Scope: name}
+		}
+		granted[scope] = true
+	}
+	if len(granted) == 0 || granted[AccessTokenScopeAll] {
+		return string(AccessTokenScopeAll), nil
+	}
+
+	scopes := make([]string, 0, len(granted))
+	for _, scope := range AccessTokenScopes {
+		if granted[scope] {
+			scopes = append(scopes, string(scope))
+		}
+	}
+	return strings.Join(scopes, ","), nil
+}
@@ -0,0 +1,42 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeAccessTokenScope(t *testing.T) {
+	for _, test := range []struct {
+		Names    []string
+		Expected string
+	}{
+		{nil, "all"},
+		{[]string{"", " "}, "all"},
+		{[]string{"user", "all"}, "all"},
+		{[]string{"user", " repo:read", "user"}, "repo:read,user"},
+		{[]string{"package", "admin", "repo:write"}, "repo:write,admin,package"},
+	} {
+		scope, err := NormalizeAccessTokenScope(test.Names)
+		assert.NoError(t, err)
+		assert.Equal(t, test.Expected, scope)
+	}
+
+	_, err := NormalizeAccessTokenScope([]string{"repo", "user"})
+	assert.True(t, IsErrAccessTokenScopeInvalid(err))
+}
+
+func TestAccessTokenScope_Implies(t *testing.T) {
+	assert.True(t, AccessTokenScopeAll.Implies(AccessTokenScopeAdmin))
+	assert.True(t, AccessTokenScopeRepoWrite.Implies(AccessTokenScopeRepoRead))
+	assert.True(t, AccessTokenScopeOrg.Implies(AccessTokenScopeOrg))
+	assert.False(t, AccessTokenScopeRepoRead.Implies(AccessTokenScopeRepoWrite))
+	assert.False(t, AccessTokenScopeAdmin.Implies(AccessTokenScopeAll))
+	assert.False(t, AccessTokenScopeNone.Implies(AccessTokenScopeRepoRead))
+	assert.False(t, AccessTokenScopeNone.IsValid())
+	assert.Equal(t, "settings.token_scope.repo_write", AccessTokenScopeRepoWrite.DescKey())
+}
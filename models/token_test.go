@@ -18,6 +18,18 @@ func TestNewAccessToken(t *testing.T) {
 	}
 	assert.NoError(t, NewAccessToken(token))
 	AssertExistsAndLoadBean(t, token)
+	assert.Equal(t, string(AccessTokenScopeAll), token.Scope)
+
+	restrictedToken := &AccessToken{
+		UID:     2,
+		Name:    "Token D",
+		Scope:   "repo:read,org",
+		RepoIDs: []int64{1, 3},
+	}
+	assert.NoError(t, NewAccessToken(restrictedToken))
+	restrictedToken = AssertExistsAndLoadBean(t, &AccessToken{ID: restrictedToken.ID}).(*AccessToken)
+	assert.Equal(t, "repo:read,org", restrictedToken.Scope)
+	assert.Equal(t, []int64{1, 3}, restrictedToken.RepoIDs)
 
 	invalidToken := &AccessToken{
 		ID:   token.ID, // duplicate
@@ -92,3 +104,39 @@ func TestDeleteAccessTokenByID(t *testing.T) {
 	assert.Error(t, err)
 	assert.True(t, IsErrAccessTokenNotExist(err))
 }
+
+func TestAccessToken_HasScope(t *testing.T) {
+	token := &AccessToken{}
+	assert.True(t, token.HasScope(AccessTokenScopeAdmin))
+	assert.True(t, token.HasScope(AccessTokenScopeRepoWrite))
+
+	token.Scope = "repo:write,user"
+	assert.Equal(t, []AccessTokenScope{AccessTokenScopeRepoWrite, AccessTokenScopeUser}, token.Scopes())
+	assert.True(t, token.HasScope(AccessTokenScopeRepoRead))
+	assert.True(t, token.HasScope(AccessTokenScopeRepoWrite))
+	assert.True(t, token.HasScope(AccessTokenScopeUser))
+	assert.False(t, token.HasScope(AccessTokenScopeAdmin))
+	assert.False(t, token.HasScope(AccessTokenScopeAll))
+
+	token.Scope = "repo:read"
+	assert.True(t, token.HasScope(AccessTokenScopeRepoRead))
+	assert.False(t, token.HasScope(AccessTokenScopeRepoWrite))
+}
+
+func TestAccessToken_CanAccessRepo(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	token := &AccessToken{}
+	assert.False(t, token.IsRestricted())
+	assert.True(t, token.CanAccessRepo(1))
+
+	token.RepoIDs = []int64{1, 3}
+	assert.True(t, token.IsRestricted())
+	assert.True(t, token.CanAccessRepo(1))
+	assert.False(t, token.CanAccessRepo(2))
+
+	apiToken, err := token.APIFormat()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"all"}, apiToken.Scopes)
+	assert.Equal(t, []string{"user2/repo1", "user3/repo3"}, apiToken.Repositories)
+}
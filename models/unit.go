@@ -23,6 +23,8 @@ const (
 	UnitTypeWiki                                // 5 Wiki
 	UnitTypeExternalWiki                        // 6 ExternalWiki
 	UnitTypeExternalTracker                     // 7 ExternalTracker
+	UnitTypeActions                             // 8 Actions
+	UnitTypeProjects                            // 9 Projects
 )
 
 // Value returns integer value for unit type
@@ -46,6 +48,10 @@ func (u UnitType) String() string {
 		return "UnitTypeExternalWiki"
 	case UnitTypeExternalTracker:
 		return "UnitTypeExternalTracker"
+	case UnitTypeActions:
+		return "UnitTypeActions"
+	case UnitTypeProjects:
+		return "UnitTypeProjects"
 	}
 	return fmt.Sprintf("Unknown UnitType %d", u)
 }
@@ -67,6 +73,8 @@ var (
 		UnitTypeWiki,
 		UnitTypeExternalWiki,
 		UnitTypeExternalTracker,
+		UnitTypeActions,
+		UnitTypeProjects,
 	}
 
 	// DefaultRepoUnits contains the default unit types
@@ -76,6 +84,7 @@ var (
 		UnitTypePullRequests,
 		UnitTypeReleases,
 		UnitTypeWiki,
+		UnitTypeProjects,
 	}
 
 	// MustRepoUnits contains the units could not be disabled currently
@@ -165,6 +174,22 @@ var (
 		4,
 	}
 
+	UnitActions = Unit{
+		UnitTypeActions,
+		"repo.actions",
+		"/actions",
+		"repo.actions.desc",
+		5,
+	}
+
+	UnitProjects = Unit{
+		UnitTypeProjects,
+		"repo.projects",
+		"/projects",
+		"repo.projects.desc",
+		6,
+	}
+
 	// Units contains all the units
 	Units = map[UnitType]Unit{
 		UnitTypeCode:            UnitCode,
@@ -174,6 +199,8 @@ var (
 		UnitTypeReleases:        UnitReleases,
 		UnitTypeWiki:            UnitWiki,
 		UnitTypeExternalWiki:    UnitExternalWiki,
+		UnitTypeActions:         UnitActions,
+		UnitTypeProjects:        UnitProjects,
 	}
 )
 
@@ -16,6 +16,7 @@ import (
 
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
 
 	"github.com/Unknwon/com"
 	"github.com/go-xorm/xorm"
@@ -60,6 +61,14 @@ func MainTest(m *testing.M, pathToGiteaRoot string) {
 	}
 	setting.AppWorkPath = pathToGiteaRoot
 	setting.StaticRootPath = pathToGiteaRoot
+	setting.LFSStorage = setting.Storage{Type: setting.LocalStorageType, Path: filepath.Join(setting.AppDataPath, "lfs")}
+	setting.AttachmentStorage = setting.Storage{Type: setting.LocalStorageType, Path: filepath.Join(setting.AppDataPath, "attachments")}
+	setting.AvatarStorage = setting.Storage{Type: setting.LocalStorageType, Path: filepath.Join(setting.AppDataPath, "avatars")}
+	setting.RepoAvatarStorage = setting.Storage{Type: setting.LocalStorageType, Path: filepath.Join(setting.AppDataPath, "repo-avatars")}
+	setting.PackageStorage = setting.Storage{Type: setting.LocalStorageType, Path: filepath.Join(setting.AppDataPath, "packages")}
+	if err = storage.Init(); err != nil {
+		fatalTestError("storage.Init: %v\n", err)
+	}
 	setting.GravatarSourceURL, err = url.Parse("https://secure.gravatar.com/avatar/")
 	if err != nil {
 		fatalTestError("url.Parse: %v\n", err)
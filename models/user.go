@@ -6,6 +6,7 @@
 package models
 
 import (
+	"bytes"
 	"container/list"
 	"crypto/md5"
 	"crypto/sha256"
@@ -13,6 +14,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"image"
 	_ "image/jpeg" // Needed for jpeg support
 	"image/png"
 	"os"
@@ -27,6 +29,7 @@ import (
 	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
 	"code.gitea.io/gitea/modules/structs"
 	api "code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/modules/util"
@@ -318,9 +321,31 @@ func (u *User) GenerateActivateCode() string {
 	return u.GenerateEmailActivateCode(u.Email)
 }
 
-// CustomAvatarPath returns user custom avatar file path.
-func (u *User) CustomAvatarPath() string {
-	return filepath.Join(setting.AvatarUploadPath, u.Avatar)
+// CustomAvatarRelativePath returns the path of the user custom avatar relative
+// to the avatar storage.
+func (u *User) CustomAvatarRelativePath() string {
+	return u.Avatar
+}
+
+// IsCustomAvatarExist returns true if the user custom avatar exists in the avatar storage.
+func (u *User) IsCustomAvatarExist() bool {
+	if len(u.Avatar) == 0 {
+		return false
+	}
+	_, err := storage.Avatars.Stat(u.CustomAvatarRelativePath())
+	return err == nil
+}
+
+// savePNG encodes img as PNG and saves it at path in the given storage.
+func savePNG(s storage.ObjectStorage, path string, img image.Image) error {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return fmt.Errorf("Encode: %v", err)
+	}
+	if _, err := s.Save(path, &buf); err != nil {
+		return fmt.Errorf("Save: %v", err)
+	}
+	return nil
 }
 
 // GenerateRandomAvatar generates a random avatar for user.
@@ -343,23 +368,14 @@ func (u *User) generateRandomAvatar(e Engine) error {
 	if u.Avatar == "" {
 		u.Avatar = fmt.Sprintf("%d", u.ID)
 	}
-	if err = os.MkdirAll(filepath.Dir(u.CustomAvatarPath()), os.ModePerm); err != nil {
-		return fmt.Errorf("MkdirAll: %v", err)
-	}
-	fw, err := os.Create(u.CustomAvatarPath())
-	if err != nil {
-		return fmt.Errorf("Create: %v", err)
+	if err = savePNG(storage.Avatars, u.CustomAvatarRelativePath(), img); err != nil {
+		return err
 	}
-	defer fw.Close()
 
 	if _, err := e.ID(u.ID).Cols("avatar").Update(u); err != nil {
 		return err
 	}
 
-	if err = png.Encode(fw, img); err != nil {
-		return fmt.Errorf("Encode: %v", err)
-	}
-
 	log.Info("New random avatar created: %d", u.ID)
 	return nil
 }
@@ -373,12 +389,12 @@ func (u *User) SizedRelAvatarLink(size int) string {
 
 	switch {
 	case u.UseCustomAvatar:
-		if !com.IsFile(u.CustomAvatarPath()) {
+		if !u.IsCustomAvatarExist() {
 			return base.DefaultAvatarLink()
 		}
 		return setting.AppSubURL + "/avatars/" + u.Avatar
 	case setting.DisableGravatar, setting.OfflineMode:
-		if !com.IsFile(u.CustomAvatarPath()) {
+		if !u.IsCustomAvatarExist() {
 			if err := u.GenerateRandomAvatar(); err != nil {
 				log.Error("GenerateRandomAvatar: %v", err)
 			}
@@ -503,18 +519,8 @@ func (u *User) UploadAvatar(data []byte) error {
 		return fmt.Errorf("updateUser: %v", err)
 	}
 
-	if err := os.MkdirAll(setting.AvatarUploadPath, os.ModePerm); err != nil {
-		return fmt.Errorf("Failed to create dir %s: %v", setting.AvatarUploadPath, err)
-	}
-
-	fw, err := os.Create(u.CustomAvatarPath())
-	if err != nil {
-		return fmt.Errorf("Create: %v", err)
-	}
-	defer fw.Close()
-
-	if err = png.Encode(fw, *m); err != nil {
-		return fmt.Errorf("Encode: %v", err)
+	if err = savePNG(storage.Avatars, u.CustomAvatarRelativePath(), *m); err != nil {
+		return err
 	}
 
 	return sess.Commit()
@@ -522,10 +528,10 @@ func (u *User) UploadAvatar(data []byte) error {
 
 // DeleteAvatar deletes the user's custom avatar.
 func (u *User) DeleteAvatar() error {
-	log.Trace("DeleteAvatar[%d]: %s", u.ID, u.CustomAvatarPath())
+	log.Trace("DeleteAvatar[%d]: %s", u.ID, u.CustomAvatarRelativePath())
 	if len(u.Avatar) > 0 {
-		if err := os.Remove(u.CustomAvatarPath()); err != nil {
-			return fmt.Errorf("Failed to remove %s: %v", u.CustomAvatarPath(), err)
+		if err := storage.Avatars.Delete(u.CustomAvatarRelativePath()); err != nil {
+			return fmt.Errorf("Failed to remove %s: %v", u.CustomAvatarRelativePath(), err)
 		}
 	}
 
@@ -874,7 +880,15 @@ func CreateUser(u *User) (err error) {
 		return err
 	}
 
-	return sess.Commit()
+	if err = prepareUserWebhooks(sess, u, nil, api.HookUserCreated); err != nil {
+		return err
+	}
+
+	if err = sess.Commit(); err != nil {
+		return err
+	}
+	go HookQueue.Add(0)
+	return nil
 }
 
 func countUsers(e Engine) int64 {
@@ -1144,11 +1158,9 @@ func deleteUser(e *xorm.Session, u *User) error {
 	}
 
 	if len(u.Avatar) > 0 {
-		avatarPath := u.CustomAvatarPath()
-		if com.IsExist(avatarPath) {
-			if err := os.Remove(avatarPath); err != nil {
-				return fmt.Errorf("Failed to remove %s: %v", avatarPath, err)
-			}
+		avatarPath := u.CustomAvatarRelativePath()
+		if err := storage.Avatars.Delete(avatarPath); err != nil {
+			return fmt.Errorf("Failed to remove %s: %v", avatarPath, err)
 		}
 	}
 
@@ -1169,7 +1181,21 @@ func DeleteUser(u *User) (err error) {
 		return err
 	}
 
-	return sess.Commit()
+	blobs, err := deleteOwnerPackages(sess, u)
+	if err != nil {
+		return fmt.Errorf("deleteOwnerPackages: %v", err)
+	}
+
+	if err = prepareUserWebhooks(sess, u, nil, api.HookUserDeleted); err != nil {
+		return err
+	}
+
+	if err = sess.Commit(); err != nil {
+		return err
+	}
+	RemovePackageBlobs(blobs)
+	go HookQueue.Add(0)
+	return nil
 }
 
 // DeleteInactivateUsers deletes all inactivate users and email addresses.
@@ -1674,7 +1700,12 @@ func SyncExternalUsers() {
 				return
 			}
 
-			sr := s.LDAP().SearchEntries()
+			sr, err := s.LDAP().SearchEntries()
+			if err != nil {
+				// the users missing from an incomplete result would be deactivated
+				log.Error("SyncExternalUsers[%s]: Error searching the LDAP users, the source is skipped: %v", s.Name, err)
+				continue
+			}
 			for _, su := range sr {
 				if len(su.Username) == 0 {
 					continue
@@ -1714,7 +1745,14 @@ func SyncExternalUsers() {
 
 					if err != nil {
 						log.Error("SyncExternalUsers[%s]: Error creating user %s: %v", s.Name, su.Username, err)
-					} else if isAttributeSSHPublicKeySet {
+						continue
+					}
+
+					if err = syncLdapGroupsToTeams(usr, s, su); err != nil {
+						log.Error("SyncExternalUsers[%s]: Error syncing teams of user %s: %v", s.Name, usr.Name, err)
+					}
+
+					if isAttributeSSHPublicKeySet {
 						log.Trace("SyncExternalUsers[%s]: Adding LDAP Public SSH Keys for user %s", s.Name, usr.Name)
 						if addLdapSSHPublicKeys(usr, s, su.SSHPublicKey) {
 							sshKeysNeedUpdate = true
@@ -1723,6 +1761,10 @@ func SyncExternalUsers() {
 				} else if updateExisting {
 					existingUsers = append(existingUsers, usr.ID)
 
+					if err = syncLdapGroupsToTeams(usr, s, su); err != nil {
+						log.Error("SyncExternalUsers[%s]: Error syncing teams of user %s: %v", s.Name, usr.Name, err)
+					}
+
 					// Synchronize SSH Public Key if that attribute is set
 					if isAttributeSSHPublicKeySet && synchronizeLdapSSHPublicKeys(usr, s, su.SSHPublicKey) {
 						sshKeysNeedUpdate = true
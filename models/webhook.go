@@ -24,7 +24,6 @@ import (
 	api "code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/modules/sync"
 	"code.gitea.io/gitea/modules/util"
-	"github.com/Unknwon/com"
 	gouuid "github.com/satori/go.uuid"
 )
 
@@ -70,15 +69,25 @@ func IsValidHookContentType(name string) bool {
 
 // HookEvents is a set of web hook events
 type HookEvents struct {
-	Create       bool `json:"create"`
-	Delete       bool `json:"delete"`
-	Fork         bool `json:"fork"`
-	Issues       bool `json:"issues"`
-	IssueComment bool `json:"issue_comment"`
-	Push         bool `json:"push"`
-	PullRequest  bool `json:"pull_request"`
-	Repository   bool `json:"repository"`
-	Release      bool `json:"release"`
+	Create               bool `json:"create"`
+	Delete               bool `json:"delete"`
+	Fork                 bool `json:"fork"`
+	Issues               bool `json:"issues"`
+	IssueAssign          bool `json:"issue_assign"`
+	IssueLabel           bool `json:"issue_label"`
+	IssueMilestone       bool `json:"issue_milestone"`
+	IssueComment         bool `json:"issue_comment"`
+	Push                 bool `json:"push"`
+	PullRequest          bool `json:"pull_request"`
+	PullRequestAssign    bool `json:"pull_request_assign"`
+	PullRequestLabel     bool `json:"pull_request_label"`
+	PullRequestMilestone bool `json:"pull_request_milestone"`
+	PullRequestSync      bool `json:"pull_request_sync"`
+	PullRequestComment   bool `json:"pull_request_comment"`
+	Repository           bool `json:"repository"`
+	Release              bool `json:"release"`
+	User                 bool `json:"user"`
+	Organization         bool `json:"organization"`
 }
 
 // HookEvent represents events that will delivery hook.
@@ -117,6 +126,9 @@ type Webhook struct {
 	HookTaskType HookTaskType
 	Meta         string     `xorm:"TEXT"` // store hook-specific attributes
 	LastStatus   HookStatus // Last delivery status
+	// IsSystemWebhook is true for the webhooks of the instance which are triggered
+	// for all repositories, users and organizations
+	IsSystemWebhook bool
 
 	CreatedUnix util.TimeStamp `xorm:"INDEX created"`
 	UpdatedUnix util.TimeStamp `xorm:"INDEX updated"`
@@ -157,6 +169,34 @@ func (w *Webhook) GetTelegramHook() *TelegramMeta {
 	return s
 }
 
+// setMeta stores meta as the metadata of the webhook
+func (w *Webhook) setMeta(meta interface{}) error {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	w.Meta = string(data)
+	return nil
+}
+
+// GetMatrixHook returns Matrix metadata
+func (w *Webhook) GetMatrixHook() *MatrixMeta {
+	s := &MatrixMeta{}
+	if err := json.Unmarshal([]byte(w.Meta), s); err != nil {
+		log.Error("webhook.GetMatrixHook(%d): %v", w.ID, err)
+	}
+	return s
+}
+
+// GetCustomHook returns the metadata of the custom hook
+func (w *Webhook) GetCustomHook() *CustomMeta {
+	s := &CustomMeta{}
+	if err := json.Unmarshal([]byte(w.Meta), s); err != nil {
+		log.Error("webhook.GetCustomHook(%d): %v", w.ID, err)
+	}
+	return s
+}
+
 // History returns history of webhook by given conditions.
 func (w *Webhook) History(page int) ([]*HookTask, error) {
 	return HookTasks(w.ID, page)
@@ -193,6 +233,24 @@ func (w *Webhook) HasIssuesEvent() bool {
 		(w.ChooseEvents && w.HookEvents.Issues)
 }
 
+// HasIssueAssignEvent returns true if hook enabled issue_assign event.
+func (w *Webhook) HasIssueAssignEvent() bool {
+	return w.SendEverything ||
+		(w.ChooseEvents && w.HookEvents.IssueAssign)
+}
+
+// HasIssueLabelEvent returns true if hook enabled issue_label event.
+func (w *Webhook) HasIssueLabelEvent() bool {
+	return w.SendEverything ||
+		(w.ChooseEvents && w.HookEvents.IssueLabel)
+}
+
+// HasIssueMilestoneEvent returns true if hook enabled issue_milestone event.
+func (w *Webhook) HasIssueMilestoneEvent() bool {
+	return w.SendEverything ||
+		(w.ChooseEvents && w.HookEvents.IssueMilestone)
+}
+
 // HasIssueCommentEvent returns true if hook enabled issue_comment event.
 func (w *Webhook) HasIssueCommentEvent() bool {
 	return w.SendEverything ||
@@ -211,6 +269,36 @@ func (w *Webhook) HasPullRequestEvent() bool {
 		(w.ChooseEvents && w.HookEvents.PullRequest)
 }
 
+// HasPullRequestAssignEvent returns true if hook enabled pull_request_assign event.
+func (w *Webhook) HasPullRequestAssignEvent() bool {
+	return w.SendEverything ||
+		(w.ChooseEvents && w.HookEvents.PullRequestAssign)
+}
+
+// HasPullRequestLabelEvent returns true if hook enabled pull_request_label event.
+func (w *Webhook) HasPullRequestLabelEvent() bool {
+	return w.SendEverything ||
+		(w.ChooseEvents && w.HookEvents.PullRequestLabel)
+}
+
+// HasPullRequestMilestoneEvent returns true if hook enabled pull_request_milestone event.
+func (w *Webhook) HasPullRequestMilestoneEvent() bool {
+	return w.SendEverything ||
+		(w.ChooseEvents && w.HookEvents.PullRequestMilestone)
+}
+
+// HasPullRequestSyncEvent returns true if hook enabled pull_request_sync event.
+func (w *Webhook) HasPullRequestSyncEvent() bool {
+	return w.SendEverything ||
+		(w.ChooseEvents && w.HookEvents.PullRequestSync)
+}
+
+// HasPullRequestCommentEvent returns true if hook enabled pull_request_comment event.
+func (w *Webhook) HasPullRequestCommentEvent() bool {
+	return w.SendEverything ||
+		(w.ChooseEvents && w.HookEvents.PullRequestComment)
+}
+
 // HasReleaseEvent returns if hook enabled release event.
 func (w *Webhook) HasReleaseEvent() bool {
 	return w.SendEverything ||
@@ -223,6 +311,18 @@ func (w *Webhook) HasRepositoryEvent() bool {
 		(w.ChooseEvents && w.HookEvents.Repository)
 }
 
+// HasUserEvent returns if hook enabled user event, which is only sent by system webhooks.
+func (w *Webhook) HasUserEvent() bool {
+	return w.IsSystemWebhook && (w.SendEverything ||
+		(w.ChooseEvents && w.HookEvents.User))
+}
+
+// HasOrganizationEvent returns if hook enabled organization event, which is only sent by system webhooks.
+func (w *Webhook) HasOrganizationEvent() bool {
+	return w.IsSystemWebhook && (w.SendEverything ||
+		(w.ChooseEvents && w.HookEvents.Organization))
+}
+
 func (w *Webhook) eventCheckers() []struct {
 	has func() bool
 	typ HookEventType
@@ -236,10 +336,20 @@ func (w *Webhook) eventCheckers() []struct {
 		{w.HasForkEvent, HookEventFork},
 		{w.HasPushEvent, HookEventPush},
 		{w.HasIssuesEvent, HookEventIssues},
+		{w.HasIssueAssignEvent, HookEventIssueAssign},
+		{w.HasIssueLabelEvent, HookEventIssueLabel},
+		{w.HasIssueMilestoneEvent, HookEventIssueMilestone},
 		{w.HasIssueCommentEvent, HookEventIssueComment},
 		{w.HasPullRequestEvent, HookEventPullRequest},
+		{w.HasPullRequestAssignEvent, HookEventPullRequestAssign},
+		{w.HasPullRequestLabelEvent, HookEventPullRequestLabel},
+		{w.HasPullRequestMilestoneEvent, HookEventPullRequestMilestone},
+		{w.HasPullRequestSyncEvent, HookEventPullRequestSync},
+		{w.HasPullRequestCommentEvent, HookEventPullRequestComment},
 		{w.HasRepositoryEvent, HookEventRepository},
 		{w.HasReleaseEvent, HookEventRelease},
+		{w.HasUserEvent, HookEventUser},
+		{w.HasOrganizationEvent, HookEventOrganization},
 	}
 }
 
@@ -340,7 +450,7 @@ func GetWebhooksByOrgID(orgID int64) (ws []*Webhook, err error) {
 func GetDefaultWebhook(id int64) (*Webhook, error) {
 	webhook := &Webhook{ID: id}
 	has, err := x.
-		Where("repo_id=? AND org_id=?", 0, 0).
+		Where("repo_id=? AND org_id=? AND is_system_webhook=?", 0, 0, false).
 		Get(webhook)
 	if err != nil {
 		return nil, err
@@ -358,7 +468,7 @@ func GetDefaultWebhooks() ([]*Webhook, error) {
 func getDefaultWebhooks(e Engine) ([]*Webhook, error) {
 	webhooks := make([]*Webhook, 0, 5)
 	return webhooks, e.
-		Where("repo_id=? AND org_id=?", 0, 0).
+		Where("repo_id=? AND org_id=? AND is_system_webhook=?", 0, 0, false).
 		Find(&webhooks)
 }
 
@@ -412,6 +522,11 @@ func DeleteWebhookByOrgID(orgID, id int64) error {
 
 // DeleteDefaultWebhook deletes an admin-default webhook by given ID.
 func DeleteDefaultWebhook(id int64) error {
+	return deleteAdminWebhook(id, false)
+}
+
+// deleteAdminWebhook deletes an admin-default or a system webhook by given ID.
+func deleteAdminWebhook(id int64, isSystemWebhook bool) error {
 	sess := x.NewSession()
 	defer sess.Close()
 	if err := sess.Begin(); err != nil {
@@ -419,7 +534,7 @@ func DeleteDefaultWebhook(id int64) error {
 	}
 
 	count, err := sess.
-		Where("repo_id=? AND org_id=?", 0, 0).
+		Where("repo_id=? AND org_id=? AND is_system_webhook=?", 0, 0, isSystemWebhook).
 		Delete(&Webhook{ID: id})
 	if err != nil {
 		return err
@@ -470,6 +585,8 @@ const (
 	DINGTALK
 	TELEGRAM
 	MSTEAMS
+	MATRIX
+	CUSTOM
 )
 
 var hookTaskTypes = map[string]HookTaskType{
@@ -480,6 +597,8 @@ var hookTaskTypes = map[string]HookTaskType{
 	"dingtalk": DINGTALK,
 	"telegram": TELEGRAM,
 	"msteams":  MSTEAMS,
+	"matrix":   MATRIX,
+	"custom":   CUSTOM,
 }
 
 // ToHookTaskType returns HookTaskType by given name.
@@ -504,6 +623,10 @@ func (t HookTaskType) Name() string {
 		return "telegram"
 	case MSTEAMS:
 		return "msteams"
+	case MATRIX:
+		return "matrix"
+	case CUSTOM:
+		return "custom"
 	}
 	return ""
 }
@@ -519,20 +642,41 @@ type HookEventType string
 
 // Types of hook events
 const (
-	HookEventCreate              HookEventType = "create"
-	HookEventDelete              HookEventType = "delete"
-	HookEventFork                HookEventType = "fork"
-	HookEventPush                HookEventType = "push"
-	HookEventIssues              HookEventType = "issues"
-	HookEventIssueComment        HookEventType = "issue_comment"
-	HookEventPullRequest         HookEventType = "pull_request"
-	HookEventRepository          HookEventType = "repository"
-	HookEventRelease             HookEventType = "release"
-	HookEventPullRequestApproved HookEventType = "pull_request_approved"
-	HookEventPullRequestRejected HookEventType = "pull_request_rejected"
-	HookEventPullRequestComment  HookEventType = "pull_request_comment"
+	HookEventCreate               HookEventType = "create"
+	HookEventDelete               HookEventType = "delete"
+	HookEventFork                 HookEventType = "fork"
+	HookEventPush                 HookEventType = "push"
+	HookEventIssues               HookEventType = "issues"
+	HookEventIssueAssign          HookEventType = "issue_assign"
+	HookEventIssueLabel           HookEventType = "issue_label"
+	HookEventIssueMilestone       HookEventType = "issue_milestone"
+	HookEventIssueComment         HookEventType = "issue_comment"
+	HookEventPullRequest          HookEventType = "pull_request"
+	HookEventPullRequestAssign    HookEventType = "pull_request_assign"
+	HookEventPullRequestLabel     HookEventType = "pull_request_label"
+	HookEventPullRequestMilestone HookEventType = "pull_request_milestone"
+	HookEventPullRequestSync      HookEventType = "pull_request_sync"
+	HookEventRepository           HookEventType = "repository"
+	HookEventRelease              HookEventType = "release"
+	HookEventUser                 HookEventType = "user"
+	HookEventOrganization         HookEventType = "organization"
+	HookEventPullRequestApproved  HookEventType = "pull_request_approved"
+	HookEventPullRequestRejected  HookEventType = "pull_request_rejected"
+	HookEventPullRequestComment   HookEventType = "pull_request_comment"
 )
 
+// Event returns the event of the payload, the sub-events of issues and pull requests
+// are sent as their parent events with the action of the payload telling them apart.
+func (h HookEventType) Event() string {
+	switch h {
+	case HookEventIssueAssign, HookEventIssueLabel, HookEventIssueMilestone:
+		return string(HookEventIssues)
+	case HookEventPullRequestAssign, HookEventPullRequestLabel, HookEventPullRequestMilestone, HookEventPullRequestSync:
+		return string(HookEventPullRequest)
+	}
+	return string(h)
+}
+
 // HookRequest represents hook task request information.
 type HookRequest struct {
 	Headers map[string]string `json:"headers"`
@@ -563,6 +707,10 @@ type HookTask struct {
 	IsDelivered     bool
 	Delivered       int64
 	DeliveredString string `xorm:"-"`
+	// Attempts is the number of times the delivery has been attempted
+	Attempts int
+	// NextDeliveryUnix is the time of the next attempt when a failed delivery will be retried
+	NextDeliveryUnix util.TimeStamp `xorm:"INDEX"`
 
 	// History info.
 	IsSucceed       bool
@@ -645,12 +793,115 @@ func UpdateHookTask(t *HookTask) error {
 	return err
 }
 
+// IsRetrying returns true if the delivery failed and will be retried
+func (t *HookTask) IsRetrying() bool {
+	return !t.IsDelivered && t.Attempts > 0
+}
+
+// GetHookTaskByID returns hook task by given ID.
+func GetHookTaskByID(id int64) (*HookTask, error) {
+	t := new(HookTask)
+	has, err := x.ID(id).Get(t)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrHookTaskNotExist{ID: id}
+	}
+	return t, nil
+}
+
+// GetHookTaskByUUID returns the hook task of the webhook by given delivery UUID.
+func GetHookTaskByUUID(hookID int64, uuid string) (*HookTask, error) {
+	t := &HookTask{HookID: hookID, UUID: uuid}
+	has, err := x.Get(t)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrHookTaskNotExist{HookID: hookID, UUID: uuid}
+	}
+	return t, nil
+}
+
+// FindDueHookTaskIDs returns the IDs of the hook tasks which are due to be delivered,
+// the tasks of all repositories are returned if repoID is zero.
+func FindDueHookTaskIDs(repoID int64) ([]int64, error) {
+	sess := x.Table("hook_task").
+		Where("is_delivered=?", false).
+		And("next_delivery_unix<=?", util.TimeStampNow())
+	if repoID > 0 {
+		sess.And("repo_id=?", repoID)
+	}
+	ids := make([]int64, 0, 10)
+	return ids, sess.Asc("id").Cols("id").Find(&ids)
+}
+
+// hookTaskRetryDelay returns the delay before the next attempt of a delivery which
+// failed the given times, the delay doubles with each attempt up to the maximum.
+func hookTaskRetryDelay(attempts int) time.Duration {
+	delay := time.Duration(setting.Webhook.RetryBackoff) * time.Second
+	maxDelay := time.Duration(setting.Webhook.MaxRetryBackoff) * time.Second
+	for i := 1; i < attempts && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay
+}
+
+// DeliverHookTask delivers the hook task if it is due. Failed deliveries are
+// retried with an exponential backoff until the maximum of retries is reached.
+func DeliverHookTask(id int64) error {
+	t, err := GetHookTaskByID(id)
+	if err != nil {
+		return err
+	}
+
+	// claim the attempt, so the task is not delivered twice when it is queued more than once
+	t.Attempts++
+	affected, err := x.ID(t.ID).
+		Where("is_delivered=?", false).
+		And("attempts=?", t.Attempts-1).
+		And("next_delivery_unix<=?", util.TimeStampNow()).
+		Cols("attempts").
+		Update(&HookTask{Attempts: t.Attempts})
+	if err != nil {
+		return err
+	} else if affected == 0 {
+		return nil
+	}
+	return t.deliver()
+}
+
+// RedeliverHookTask delivers the payload of the hook task again as a new delivery.
+func RedeliverHookTask(t *HookTask) (*HookTask, error) {
+	redelivery := &HookTask{
+		RepoID:         t.RepoID,
+		HookID:         t.HookID,
+		UUID:           gouuid.NewV4().String(),
+		Type:           t.Type,
+		URL:            t.URL,
+		Signature:      t.Signature,
+		PayloadContent: t.PayloadContent,
+		HTTPMethod:     t.HTTPMethod,
+		ContentType:    t.ContentType,
+		EventType:      t.EventType,
+		IsSSL:          t.IsSSL,
+	}
+	if _, err := x.Insert(redelivery); err != nil {
+		return nil, err
+	}
+	return redelivery, nil
+}
+
 // PrepareWebhook adds special webhook to task queue for given payload.
 func PrepareWebhook(w *Webhook, repo *Repository, event HookEventType, p api.Payloader) error {
-	return prepareWebhook(x, w, repo, event, p)
+	return prepareWebhook(x, w, repo.ID, event, p)
 }
 
-func prepareWebhook(e Engine, w *Webhook, repo *Repository, event HookEventType, p api.Payloader) error {
+// prepareWebhook adds the task of the webhook for the payload, the repository is
+// zero for the events of the instance which are only sent by system webhooks.
+func prepareWebhook(e Engine, w *Webhook, repoID int64, event HookEventType, p api.Payloader) error {
 	for _, e := range w.eventCheckers() {
 		if event == e.typ {
 			if !e.has() {
@@ -661,33 +912,49 @@ func prepareWebhook(e Engine, w *Webhook, repo *Repository, event HookEventType,
 
 	var payloader api.Payloader
 	var err error
+	// The payloads of the sub-events are converted like the ones of their parent events.
+	payloadEvent := HookEventType(event.Event())
 	// Use separate objects so modifications won't be made on payload on non-Gogs/Gitea type hooks.
 	switch w.HookTaskType {
 	case SLACK:
-		payloader, err = GetSlackPayload(p, event, w.Meta)
+		payloader, err = GetSlackPayload(p, payloadEvent, w.Meta)
 		if err != nil {
 			return fmt.Errorf("GetSlackPayload: %v", err)
 		}
 	case DISCORD:
-		payloader, err = GetDiscordPayload(p, event, w.Meta)
+		payloader, err = GetDiscordPayload(p, payloadEvent, w.Meta)
 		if err != nil {
 			return fmt.Errorf("GetDiscordPayload: %v", err)
 		}
 	case DINGTALK:
-		payloader, err = GetDingtalkPayload(p, event, w.Meta)
+		payloader, err = GetDingtalkPayload(p, payloadEvent, w.Meta)
 		if err != nil {
 			return fmt.Errorf("GetDingtalkPayload: %v", err)
 		}
 	case TELEGRAM:
-		payloader, err = GetTelegramPayload(p, event, w.Meta)
+		payloader, err = GetTelegramPayload(p, payloadEvent, w.Meta)
 		if err != nil {
 			return fmt.Errorf("GetTelegramPayload: %v", err)
 		}
 	case MSTEAMS:
-		payloader, err = GetMSTeamsPayload(p, event, w.Meta)
+		payloader, err = GetMSTeamsPayload(p, payloadEvent, w.Meta)
 		if err != nil {
 			return fmt.Errorf("GetMSTeamsPayload: %v", err)
 		}
+	case MATRIX:
+		payloader, err = GetMatrixPayload(p, payloadEvent, w.Meta)
+		if err != nil {
+			return fmt.Errorf("GetMatrixPayload: %v", err)
+		}
+	case CUSTOM:
+		p.SetSecret(w.Secret)
+		payloader, err = GetCustomPayload(p, event, w.Meta)
+		if err != nil {
+			// the template may not fit the payload of every event, which mustn't
+			// stop the other webhooks from being triggered
+			log.Error("GetCustomPayload [hook_id: %d]: %v", w.ID, err)
+			return nil
+		}
 	default:
 		p.SetSecret(w.Secret)
 		payloader = p
@@ -708,7 +975,7 @@ func prepareWebhook(e Engine, w *Webhook, repo *Repository, event HookEventType,
 	}
 
 	if err = createHookTask(e, &HookTask{
-		RepoID:      repo.ID,
+		RepoID:      repoID,
 		HookID:      w.ID,
 		Type:        w.HookTaskType,
 		URL:         w.URL,
@@ -745,21 +1012,28 @@ func prepareWebhooks(e Engine, repo *Repository, event HookEventType, p api.Payl
 		ws = append(ws, orgHooks...)
 	}
 
+	systemHooks, err := getActiveSystemWebhooks(e)
+	if err != nil {
+		return fmt.Errorf("GetActiveSystemWebhooks: %v", err)
+	}
+	ws = append(ws, systemHooks...)
+
 	if len(ws) == 0 {
 		return nil
 	}
 
 	for _, w := range ws {
-		if err = prepareWebhook(e, w, repo, event, p); err != nil {
-			return err
+		// a hook which fails, e.g. because of invalid meta, mustn't stop the others
+		if err = prepareWebhook(e, w, repo.ID, event, p); err != nil {
+			log.Error("prepareWebhook [hook_id: %d]: %v", w.ID, err)
 		}
 	}
 	return nil
 }
 
-func (t *HookTask) deliver() error {
-	t.IsDelivered = true
-
+// getHookRequest creates the request to deliver the payload of the hook task
+// with its HTTP method and content type
+func getHookRequest(t *HookTask) (*http.Request, error) {
 	var req *http.Request
 	var err error
 
@@ -772,7 +1046,7 @@ func (t *HookTask) deliver() error {
 		case ContentTypeJSON:
 			req, err = http.NewRequest("POST", t.URL, strings.NewReader(t.PayloadContent))
 			if err != nil {
-				return err
+				return nil, err
 			}
 
 			req.Header.Set("Content-Type", "application/json")
@@ -784,42 +1058,28 @@ func (t *HookTask) deliver() error {
 			req, err = http.NewRequest("POST", t.URL, strings.NewReader(forms.Encode()))
 			if err != nil {
 
-				return err
+				return nil, err
 			}
 		}
 	case http.MethodGet:
 		u, err := url.Parse(t.URL)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		vals := u.Query()
 		vals["payload"] = []string{t.PayloadContent}
 		u.RawQuery = vals.Encode()
 		req, err = http.NewRequest("GET", u.String(), nil)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	default:
-		return fmt.Errorf("Invalid http method for webhook: [%d] %v", t.ID, t.HTTPMethod)
-	}
-
-	req.Header.Add("X-Gitea-Delivery", t.UUID)
-	req.Header.Add("X-Gitea-Event", string(t.EventType))
-	req.Header.Add("X-Gitea-Signature", t.Signature)
-	req.Header.Add("X-Gogs-Delivery", t.UUID)
-	req.Header.Add("X-Gogs-Event", string(t.EventType))
-	req.Header.Add("X-Gogs-Signature", t.Signature)
-	req.Header["X-GitHub-Delivery"] = []string{t.UUID}
-	req.Header["X-GitHub-Event"] = []string{string(t.EventType)}
-
-	// Record delivery information.
-	t.RequestInfo = &HookRequest{
-		Headers: map[string]string{},
-	}
-	for k, vals := range req.Header {
-		t.RequestInfo.Headers[k] = strings.Join(vals, ",")
+		return nil, fmt.Errorf("Invalid http method for webhook: [%d] %v", t.ID, t.HTTPMethod)
 	}
+	return req, nil
+}
 
+func (t *HookTask) deliver() error {
 	t.ResponseInfo = &HookResponse{
 		Headers: map[string]string{},
 	}
@@ -827,9 +1087,14 @@ func (t *HookTask) deliver() error {
 	defer func() {
 		t.Delivered = time.Now().UnixNano()
 		if t.IsSucceed {
+			t.IsDelivered = true
 			log.Trace("Hook delivered: %s", t.UUID)
-		} else {
+		} else if t.Attempts > setting.Webhook.MaxRetries {
+			t.IsDelivered = true
 			log.Trace("Hook delivery failed: %s", t.UUID)
+		} else {
+			t.NextDeliveryUnix = util.TimeStampNow().AddDuration(hookTaskRetryDelay(t.Attempts))
+			log.Trace("Hook delivery failed, retrying at %s: %s", t.NextDeliveryUnix.FormatLong(), t.UUID)
 		}
 
 		if err := UpdateHookTask(t); err != nil {
@@ -853,6 +1118,43 @@ func (t *HookTask) deliver() error {
 		}
 	}()
 
+	var req *http.Request
+	var err error
+	switch t.Type {
+	case MATRIX:
+		req, err = getMatrixHookRequest(t)
+	case CUSTOM:
+		req, err = getCustomHookRequest(t)
+	default:
+		req, err = getHookRequest(t)
+	}
+	if err != nil {
+		return err
+	}
+
+	event := t.EventType.Event()
+	req.Header.Add("X-Gitea-Delivery", t.UUID)
+	req.Header.Add("X-Gitea-Event", event)
+	req.Header.Add("X-Gitea-Event-Type", string(t.EventType))
+	req.Header.Add("X-Gitea-Signature", t.Signature)
+	req.Header.Add("X-Gogs-Delivery", t.UUID)
+	req.Header.Add("X-Gogs-Event", event)
+	req.Header.Add("X-Gogs-Signature", t.Signature)
+	req.Header["X-GitHub-Delivery"] = []string{t.UUID}
+	req.Header["X-GitHub-Event"] = []string{event}
+
+	// Record delivery information.
+	t.RequestInfo = &HookRequest{
+		Headers: map[string]string{},
+	}
+	for k, vals := range req.Header {
+		// the credentials of the endpoint mustn't be shown in the delivery history
+		if k == "Authorization" {
+			continue
+		}
+		t.RequestInfo.Headers[k] = strings.Join(vals, ",")
+	}
+
 	resp, err := webhookHTTPClient.Do(req)
 	if err != nil {
 		t.ResponseInfo.Body = fmt.Sprintf("Delivery: %v", err)
@@ -876,51 +1178,9 @@ func (t *HookTask) deliver() error {
 	return nil
 }
 
-// DeliverHooks checks and delivers undelivered hooks.
-// TODO: shoot more hooks at same time.
-func DeliverHooks() {
-	tasks := make([]*HookTask, 0, 10)
-	err := x.Where("is_delivered=?", false).Find(&tasks)
-	if err != nil {
-		log.Error("DeliverHooks: %v", err)
-		return
-	}
-
-	// Update hook task status.
-	for _, t := range tasks {
-		if err = t.deliver(); err != nil {
-			log.Error("deliver: %v", err)
-			continue
-		}
-	}
-
-	// Start listening on new hook requests.
-	for repoIDStr := range HookQueue.Queue() {
-		log.Trace("DeliverHooks [repo_id: %v]", repoIDStr)
-		HookQueue.Remove(repoIDStr)
-
-		repoID, err := com.StrTo(repoIDStr).Int64()
-		if err != nil {
-			log.Error("Invalid repo ID: %s", repoIDStr)
-			continue
-		}
-
-		tasks = make([]*HookTask, 0, 5)
-		if err := x.Where("repo_id=? AND is_delivered=?", repoID, false).Find(&tasks); err != nil {
-			log.Error("Get repository [%d] hook tasks: %v", repoID, err)
-			continue
-		}
-		for _, t := range tasks {
-			if err = t.deliver(); err != nil {
-				log.Error("deliver: %v", err)
-			}
-		}
-	}
-}
-
 var webhookHTTPClient *http.Client
 
-// InitDeliverHooks starts the hooks delivery thread
+// InitDeliverHooks initializes the http client to deliver hooks
 func InitDeliverHooks() {
 	timeout := time.Duration(setting.Webhook.DeliverTimeout) * time.Second
 
@@ -938,6 +1198,4 @@ func InitDeliverHooks() {
 			},
 		},
 	}
-
-	go DeliverHooks()
 }
@@ -0,0 +1,105 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"text/template"
+
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+type (
+	// CustomPayload contains the body rendered from the template of a custom hook
+	CustomPayload struct {
+		Body string
+	}
+
+	// CustomMeta contains the metadata of a custom hook
+	CustomMeta struct {
+		Template    string `json:"template"`
+		ContentType string `json:"content_type"`
+	}
+)
+
+// SetSecret sets the custom hook secret
+func (p *CustomPayload) SetSecret(_ string) {}
+
+// JSONPayload returns the rendered body, which is only JSON if the template produces it
+func (p *CustomPayload) JSONPayload() ([]byte, error) {
+	return []byte(p.Body), nil
+}
+
+// customTemplateFuncs are the functions available to the templates of custom hooks,
+// the event function is replaced by the event type of the payload on rendering.
+func customTemplateFuncs(event HookEventType) template.FuncMap {
+	return template.FuncMap{
+		"event": func() string {
+			return string(event)
+		},
+		"json": func(v interface{}) (string, error) {
+			data, err := json.Marshal(v)
+			return string(data), err
+		},
+		"join":  strings.Join,
+		"lower": strings.ToLower,
+		"upper": strings.ToUpper,
+	}
+}
+
+// ParseCustomTemplate parses the template of a custom hook
+func ParseCustomTemplate(text string) (*template.Template, error) {
+	return template.New("webhook").Funcs(customTemplateFuncs("")).Parse(text)
+}
+
+// GetCustomPayload renders the template of a custom hook over the payload
+func GetCustomPayload(p api.Payloader, event HookEventType, meta string) (*CustomPayload, error) {
+	customMeta := &CustomMeta{}
+	if err := json.Unmarshal([]byte(meta), customMeta); err != nil {
+		return nil, errors.New("GetCustomPayload meta json:" + err.Error())
+	}
+
+	tmpl, err := ParseCustomTemplate(customMeta.Template)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err = tmpl.Funcs(customTemplateFuncs(event)).Execute(&buf, p); err != nil {
+		return nil, err
+	}
+	return &CustomPayload{
+		Body: buf.String(),
+	}, nil
+}
+
+// getCustomHookRequest creates the request to send the rendered body of the hook task
+// with the content type of the custom hook
+func getCustomHookRequest(t *HookTask) (*http.Request, error) {
+	w, err := GetWebhookByID(t.HookID)
+	if err != nil {
+		return nil, err
+	}
+
+	method := t.HTTPMethod
+	if method == "" {
+		method = http.MethodPost
+	}
+	req, err := http.NewRequest(method, t.URL, strings.NewReader(t.PayloadContent))
+	if err != nil {
+		return nil, err
+	}
+
+	contentType := w.GetCustomHook().ContentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	req.Header.Set("Content-Type", contentType)
+	return req, nil
+}
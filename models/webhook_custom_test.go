@@ -0,0 +1,89 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCustomTemplate(t *testing.T) {
+	_, err := ParseCustomTemplate(`{"event": {{json event}}}`)
+	assert.NoError(t, err)
+
+	_, err = ParseCustomTemplate(`{{.Repo.FullName`)
+	assert.Error(t, err)
+	_, err = ParseCustomTemplate(`{{unknown .}}`)
+	assert.Error(t, err)
+}
+
+func TestGetCustomPayload(t *testing.T) {
+	p := &api.PushPayload{
+		Ref:     "refs/heads/master",
+		Commits: []*api.PayloadCommit{{ID: "abc"}, {ID: "def"}},
+		Repo:    &api.Repository{FullName: "user2/\"repo1\""},
+	}
+	meta := `{"template":"{\"text\": {{json (printf \"%s: %d commits to %s\" event (len .Commits) .Repo.FullName)}}}"}`
+
+	payload, err := GetCustomPayload(p, HookEventPush, meta)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"text": "push: 2 commits to user2/\"repo1\""}`, payload.Body)
+
+	// the template doesn't fit the payload of the event
+	_, err = GetCustomPayload(&api.IssuePayload{}, HookEventIssues, meta)
+	assert.Error(t, err)
+}
+
+func TestDeliverHookTask_Custom(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	InitDeliverHooks()
+
+	var contentType, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		data, _ := ioutil.ReadAll(r.Body)
+		body = string(data)
+	}))
+	defer server.Close()
+
+	meta, err := json.Marshal(&CustomMeta{
+		Template:    "pushed to {{.Repo.FullName}}",
+		ContentType: "text/plain",
+	})
+	assert.NoError(t, err)
+	hook := &Webhook{
+		RepoID:       1,
+		URL:          server.URL,
+		HTTPMethod:   http.MethodPost,
+		ContentType:  ContentTypeJSON,
+		HookEvent:    &HookEvent{PushOnly: true},
+		IsActive:     true,
+		HookTaskType: CUSTOM,
+		Meta:         string(meta),
+	}
+	assert.NoError(t, hook.UpdateEvent())
+	assert.NoError(t, CreateWebhook(hook))
+
+	repo := AssertExistsAndLoadBean(t, &Repository{ID: 1}).(*Repository)
+	assert.NoError(t, PrepareWebhook(hook, repo, HookEventPush, &api.PushPayload{
+		Repo: &api.Repository{FullName: "user2/repo1"},
+	}))
+	task := AssertExistsAndLoadBean(t, &HookTask{HookID: hook.ID}).(*HookTask)
+	assert.NoError(t, DeliverHookTask(task.ID))
+
+	assert.Equal(t, "text/plain", contentType)
+	assert.Equal(t, "pushed to user2/repo1", body)
+
+	// events which the template fails to render are skipped
+	assert.NoError(t, PrepareWebhook(hook, repo, HookEventPush, &api.PushPayload{}))
+	assert.EqualValues(t, 1, GetCount(t, &HookTask{HookID: hook.ID}))
+}
@@ -0,0 +1,275 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"code.gitea.io/gitea/modules/git"
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+// Matrix message types
+const (
+	MatrixMessageTypeNotice = "m.notice"
+	MatrixMessageTypeText   = "m.text"
+)
+
+const matrixMessageFormat = "org.matrix.custom.html"
+
+type (
+	// MatrixPayload contains the m.room.message event sent to a Matrix room
+	MatrixPayload struct {
+		Body          string `json:"body"`
+		MsgType       string `json:"msgtype"`
+		Format        string `json:"format"`
+		FormattedBody string `json:"formatted_body"`
+	}
+
+	// MatrixMeta contains the Matrix metadata
+	MatrixMeta struct {
+		HomeserverURL string `json:"homeserver_url"`
+		RoomID        string `json:"room_id"`
+		AccessToken   string `json:"access_token"`
+		MessageType   string `json:"message_type"`
+	}
+)
+
+// MatrixRoomMessageURL returns the URL of the client-server API to send messages to the room,
+// the transaction ID of each message is appended on delivery.
+func MatrixRoomMessageURL(homeserverURL, roomID string) string {
+	return fmt.Sprintf("%s/_matrix/client/r0/rooms/%s/send/m.room.message",
+		strings.TrimSuffix(homeserverURL, "/"), url.PathEscape(roomID))
+}
+
+// SetSecret sets the Matrix secret
+func (p *MatrixPayload) SetSecret(_ string) {}
+
+// JSONPayload Marshals the MatrixPayload to json
+func (p *MatrixPayload) JSONPayload() ([]byte, error) {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return []byte{}, err
+	}
+	return data, nil
+}
+
+var matrixLinkPattern = regexp.MustCompile(`<a href="([^"]*)">([^<]*)</a>`)
+
+// newMatrixPayload creates the payload of a message from its HTML, the plain text body
+// is used by the clients which don't support formatted messages.
+func newMatrixPayload(formatted string, meta *MatrixMeta) *MatrixPayload {
+	body := matrixLinkPattern.ReplaceAllString(formatted, "[$2]($1)")
+	body = strings.NewReplacer("<br>", "\n", "<code>", "", "</code>", "").Replace(body)
+
+	msgType := meta.MessageType
+	if msgType != MatrixMessageTypeText {
+		msgType = MatrixMessageTypeNotice
+	}
+	return &MatrixPayload{
+		Body:          html.UnescapeString(body),
+		MsgType:       msgType,
+		Format:        matrixMessageFormat,
+		FormattedBody: formatted,
+	}
+}
+
+func matrixLink(url, text string) string {
+	return fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(url), html.EscapeString(text))
+}
+
+func matrixRepoLink(repo *api.Repository) string {
+	return "[" + matrixLink(repo.HTMLURL, repo.FullName) + "]"
+}
+
+func getMatrixCreatePayload(p *api.CreatePayload, meta *MatrixMeta) (*MatrixPayload, error) {
+	refName := git.RefEndName(p.Ref)
+	text := fmt.Sprintf("%s %s %s created by %s", matrixRepoLink(p.Repo), p.RefType,
+		matrixLink(p.Repo.HTMLURL+"/src/"+refName, refName), html.EscapeString(p.Sender.UserName))
+	return newMatrixPayload(text, meta), nil
+}
+
+func getMatrixDeletePayload(p *api.DeletePayload, meta *MatrixMeta) (*MatrixPayload, error) {
+	refName := git.RefEndName(p.Ref)
+	text := fmt.Sprintf("%s %s <code>%s</code> deleted by %s", matrixRepoLink(p.Repo), p.RefType,
+		html.EscapeString(refName), html.EscapeString(p.Sender.UserName))
+	return newMatrixPayload(text, meta), nil
+}
+
+func getMatrixForkPayload(p *api.ForkPayload, meta *MatrixMeta) (*MatrixPayload, error) {
+	text := fmt.Sprintf("%s is forked to %s", html.EscapeString(p.Forkee.FullName),
+		matrixLink(p.Repo.HTMLURL, p.Repo.FullName))
+	return newMatrixPayload(text, meta), nil
+}
+
+func getMatrixPushPayload(p *api.PushPayload, meta *MatrixMeta) (*MatrixPayload, error) {
+	branchName := git.RefEndName(p.Ref)
+
+	commitDesc := "1 new commit"
+	titleLink := p.CompareURL
+	if len(p.Commits) == 1 {
+		titleLink = p.Commits[0].URL
+	} else {
+		commitDesc = fmt.Sprintf("%d new commits", len(p.Commits))
+	}
+	if titleLink == "" {
+		titleLink = p.Repo.HTMLURL + "/src/" + branchName
+	}
+
+	text := fmt.Sprintf("%s %s pushed %s to %s", matrixRepoLink(p.Repo), html.EscapeString(p.Pusher.UserName),
+		matrixLink(titleLink, commitDesc), html.EscapeString(branchName))
+	for _, commit := range p.Commits {
+		text += fmt.Sprintf("<br>%s: %s", matrixLink(commit.URL, commit.ID[:7]),
+			html.EscapeString(strings.TrimRight(commit.Message, "\r\n")))
+		if commit.Author != nil {
+			text += " - " + html.EscapeString(commit.Author.Name)
+		}
+	}
+	return newMatrixPayload(text, meta), nil
+}
+
+var matrixIssueActions = map[api.HookIssueAction]string{
+	api.HookIssueOpened:       "opened",
+	api.HookIssueClosed:       "closed",
+	api.HookIssueReOpened:     "re-opened",
+	api.HookIssueEdited:       "edited",
+	api.HookIssueAssigned:     "assigned",
+	api.HookIssueUnassigned:   "unassigned",
+	api.HookIssueLabelUpdated: "labels updated",
+	api.HookIssueLabelCleared: "labels cleared",
+	api.HookIssueSynchronized: "synchronized",
+	api.HookIssueMilestoned:   "milestoned",
+	api.HookIssueDemilestoned: "milestone cleared",
+}
+
+func getMatrixIssuesPayload(p *api.IssuePayload, meta *MatrixMeta) (*MatrixPayload, error) {
+	issueURL := fmt.Sprintf("%s/issues/%d", p.Repository.HTMLURL, p.Index)
+	text := fmt.Sprintf("%s Issue %s: %s by %s", matrixRepoLink(p.Repository), matrixIssueActions[p.Action],
+		matrixLink(issueURL, fmt.Sprintf("#%d %s", p.Index, p.Issue.Title)), html.EscapeString(p.Sender.UserName))
+	return newMatrixPayload(text, meta), nil
+}
+
+func getMatrixIssueCommentPayload(p *api.IssueCommentPayload, meta *MatrixMeta) (*MatrixPayload, error) {
+	var action string
+	switch p.Action {
+	case api.HookIssueCommentCreated:
+		action = "New comment"
+	case api.HookIssueCommentEdited:
+		action = "Comment edited"
+	case api.HookIssueCommentDeleted:
+		action = "Comment deleted"
+	}
+
+	commentURL := fmt.Sprintf("%s/issues/%d#%s", p.Repository.HTMLURL, p.Issue.Index, CommentHashTag(p.Comment.ID))
+	text := fmt.Sprintf("%s %s on %s by %s", matrixRepoLink(p.Repository), action,
+		matrixLink(commentURL, fmt.Sprintf("#%d %s", p.Issue.Index, p.Issue.Title)), html.EscapeString(p.Sender.UserName))
+	return newMatrixPayload(text, meta), nil
+}
+
+func getMatrixPullRequestPayload(p *api.PullRequestPayload, meta *MatrixMeta) (*MatrixPayload, error) {
+	action := matrixIssueActions[p.Action]
+	if p.Action == api.HookIssueClosed && p.PullRequest.HasMerged {
+		action = "merged"
+	}
+
+	text := fmt.Sprintf("%s Pull request %s: %s by %s", matrixRepoLink(p.Repository), action,
+		matrixLink(p.PullRequest.HTMLURL, fmt.Sprintf("#%d %s", p.Index, p.PullRequest.Title)), html.EscapeString(p.Sender.UserName))
+	return newMatrixPayload(text, meta), nil
+}
+
+func getMatrixPullRequestApprovalPayload(p *api.PullRequestPayload, meta *MatrixMeta, event HookEventType) (*MatrixPayload, error) {
+	action, err := parseHookPullRequestEventType(event)
+	if err != nil {
+		return nil, err
+	}
+
+	text := fmt.Sprintf("%s Pull request review %s: %s by %s", matrixRepoLink(p.Repository), action,
+		matrixLink(p.PullRequest.HTMLURL, fmt.Sprintf("#%d %s", p.Index, p.PullRequest.Title)), html.EscapeString(p.Sender.UserName))
+	return newMatrixPayload(text, meta), nil
+}
+
+func getMatrixRepositoryPayload(p *api.RepositoryPayload, meta *MatrixMeta) (*MatrixPayload, error) {
+	var text string
+	switch p.Action {
+	case api.HookRepoCreated:
+		text = fmt.Sprintf("%s Repository created by %s", matrixRepoLink(p.Repository), html.EscapeString(p.Sender.UserName))
+	case api.HookRepoDeleted:
+		text = fmt.Sprintf("[%s] Repository deleted by %s", html.EscapeString(p.Repository.FullName), html.EscapeString(p.Sender.UserName))
+	}
+	return newMatrixPayload(text, meta), nil
+}
+
+func getMatrixReleasePayload(p *api.ReleasePayload, meta *MatrixMeta) (*MatrixPayload, error) {
+	var action string
+	switch p.Action {
+	case api.HookReleasePublished:
+		action = "created"
+	case api.HookReleaseUpdated:
+		action = "updated"
+	case api.HookReleaseDeleted:
+		action = "deleted"
+	}
+
+	text := fmt.Sprintf("%s Release %s %s by %s", matrixRepoLink(p.Repository), matrixLink(p.Release.URL, p.Release.TagName),
+		action, html.EscapeString(p.Sender.UserName))
+	return newMatrixPayload(text, meta), nil
+}
+
+// GetMatrixPayload converts a Matrix webhook into a MatrixPayload
+func GetMatrixPayload(p api.Payloader, event HookEventType, meta string) (*MatrixPayload, error) {
+	matrixMeta := &MatrixMeta{}
+	if err := json.Unmarshal([]byte(meta), matrixMeta); err != nil {
+		return nil, errors.New("GetMatrixPayload meta json:" + err.Error())
+	}
+
+	switch event {
+	case HookEventCreate:
+		return getMatrixCreatePayload(p.(*api.CreatePayload), matrixMeta)
+	case HookEventDelete:
+		return getMatrixDeletePayload(p.(*api.DeletePayload), matrixMeta)
+	case HookEventFork:
+		return getMatrixForkPayload(p.(*api.ForkPayload), matrixMeta)
+	case HookEventIssues:
+		return getMatrixIssuesPayload(p.(*api.IssuePayload), matrixMeta)
+	case HookEventIssueComment:
+		return getMatrixIssueCommentPayload(p.(*api.IssueCommentPayload), matrixMeta)
+	case HookEventPush:
+		return getMatrixPushPayload(p.(*api.PushPayload), matrixMeta)
+	case HookEventPullRequest:
+		return getMatrixPullRequestPayload(p.(*api.PullRequestPayload), matrixMeta)
+	case HookEventPullRequestRejected, HookEventPullRequestApproved, HookEventPullRequestComment:
+		return getMatrixPullRequestApprovalPayload(p.(*api.PullRequestPayload), matrixMeta, event)
+	case HookEventRepository:
+		return getMatrixRepositoryPayload(p.(*api.RepositoryPayload), matrixMeta)
+	case HookEventRelease:
+		return getMatrixReleasePayload(p.(*api.ReleasePayload), matrixMeta)
+	}
+
+	return newMatrixPayload("", matrixMeta), nil
+}
+
+// getMatrixHookRequest creates the request to send the message of the hook task to the room,
+// the UUID of the task is used as the transaction ID so a retried delivery isn't posted twice.
+func getMatrixHookRequest(t *HookTask) (*http.Request, error) {
+	w, err := GetWebhookByID(t.HookID)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPut, t.URL+"/"+url.PathEscape(t.UUID), strings.NewReader(t.PayloadContent))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+w.GetMatrixHook().AccessToken)
+	return req, nil
+}
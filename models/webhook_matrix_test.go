@@ -0,0 +1,112 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatrixRoomMessageURL(t *testing.T) {
+	assert.Equal(t, "https://matrix.example.com/_matrix/client/r0/rooms/%21room:example.com/send/m.room.message",
+		MatrixRoomMessageURL("https://matrix.example.com/", "!room:example.com"))
+}
+
+func TestGetMatrixPayload(t *testing.T) {
+	p := &api.IssuePayload{
+		Action: api.HookIssueOpened,
+		Index:  2,
+		Issue:  &api.Issue{Title: "<b>crash</b>"},
+		Repository: &api.Repository{
+			FullName: "user2/repo1",
+			HTMLURL:  "http://localhost:3000/user2/repo1",
+		},
+		Sender: &api.User{UserName: "user2"},
+	}
+
+	payload, err := GetMatrixPayload(p, HookEventIssues, `{"message_type":"m.text"}`)
+	assert.NoError(t, err)
+	assert.Equal(t, MatrixMessageTypeText, payload.MsgType)
+	assert.Equal(t, "org.matrix.custom.html", payload.Format)
+	assert.Equal(t, `[<a href="http://localhost:3000/user2/repo1">user2/repo1</a>] Issue opened: `+
+		`<a href="http://localhost:3000/user2/repo1/issues/2">#2 &lt;b&gt;crash&lt;/b&gt;</a> by user2`, payload.FormattedBody)
+	assert.Equal(t, "[[user2/repo1](http://localhost:3000/user2/repo1)] Issue opened: "+
+		"[#2 <b>crash</b>](http://localhost:3000/user2/repo1/issues/2) by user2", payload.Body)
+
+	payload, err = GetMatrixPayload(p, HookEventIssues, `{}`)
+	assert.NoError(t, err)
+	assert.Equal(t, MatrixMessageTypeNotice, payload.MsgType)
+
+	pr := &api.PullRequestPayload{
+		Action:      api.HookIssueSynchronized,
+		Index:       3,
+		PullRequest: &api.PullRequest{Title: "fix", HTMLURL: "http://localhost:3000/user2/repo1/pulls/3"},
+		Repository:  p.Repository,
+		Sender:      p.Sender,
+	}
+	payload, err = GetMatrixPayload(pr, HookEventPullRequestApproved, `{}`)
+	assert.NoError(t, err)
+	assert.Equal(t, `[<a href="http://localhost:3000/user2/repo1">user2/repo1</a>] Pull request review approved: `+
+		`<a href="http://localhost:3000/user2/repo1/pulls/3">#3 fix</a> by user2`, payload.FormattedBody)
+}
+
+func TestDeliverHookTask_Matrix(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	InitDeliverHooks()
+
+	var method, path, authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		path = r.URL.Path
+		authorization = r.Header.Get("Authorization")
+	}))
+	defer server.Close()
+
+	meta, err := json.Marshal(&MatrixMeta{
+		HomeserverURL: server.URL,
+		RoomID:        "!room:example.com",
+		AccessToken:   "secret_token",
+	})
+	assert.NoError(t, err)
+	hook := &Webhook{
+		RepoID:       1,
+		URL:          MatrixRoomMessageURL(server.URL, "!room:example.com"),
+		HTTPMethod:   http.MethodPut,
+		ContentType:  ContentTypeJSON,
+		HookEvent:    &HookEvent{PushOnly: true},
+		IsActive:     true,
+		HookTaskType: MATRIX,
+		Meta:         string(meta),
+	}
+	assert.NoError(t, hook.UpdateEvent())
+	assert.NoError(t, CreateWebhook(hook))
+
+	task := &HookTask{
+		RepoID:      1,
+		HookID:      hook.ID,
+		Type:        MATRIX,
+		URL:         hook.URL,
+		Payloader:   &MatrixPayload{Body: "pushed"},
+		HTTPMethod:  hook.HTTPMethod,
+		ContentType: ContentTypeJSON,
+		EventType:   HookEventPush,
+	}
+	assert.NoError(t, CreateHookTask(task))
+	assert.NoError(t, DeliverHookTask(task.ID))
+
+	assert.Equal(t, http.MethodPut, method)
+	assert.Equal(t, "/_matrix/client/r0/rooms/!room:example.com/send/m.room.message/"+task.UUID, path)
+	assert.Equal(t, "Bearer secret_token", authorization)
+
+	task = AssertExistsAndLoadBean(t, &HookTask{ID: task.ID}).(*HookTask)
+	assert.True(t, task.IsSucceed)
+	assert.NotContains(t, task.RequestContent, "secret_token")
+}
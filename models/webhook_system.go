@@ -0,0 +1,109 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/modules/log"
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+// GetSystemWebhook returns system webhook by given ID.
+func GetSystemWebhook(id int64) (*Webhook, error) {
+	webhook := &Webhook{ID: id}
+	has, err := x.
+		Where("repo_id=? AND org_id=? AND is_system_webhook=?", 0, 0, true).
+		Get(webhook)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrWebhookNotExist{id}
+	}
+	return webhook, nil
+}
+
+// GetSystemWebhooks returns all system webhooks.
+func GetSystemWebhooks() ([]*Webhook, error) {
+	webhooks := make([]*Webhook, 0, 5)
+	return webhooks, x.
+		Where("repo_id=? AND org_id=? AND is_system_webhook=?", 0, 0, true).
+		Find(&webhooks)
+}
+
+func getActiveSystemWebhooks(e Engine) ([]*Webhook, error) {
+	webhooks := make([]*Webhook, 0, 5)
+	return webhooks, e.
+		Where("repo_id=? AND org_id=? AND is_system_webhook=?", 0, 0, true).
+		And("is_active=?", true).
+		Find(&webhooks)
+}
+
+// DeleteSystemWebhook deletes a system webhook by given ID.
+func DeleteSystemWebhook(id int64) error {
+	return deleteAdminWebhook(id, true)
+}
+
+// isInstanceEventSupported returns true if the webhook can send the user and
+// organization events, which aren't converted by the chat integrations.
+func (w *Webhook) isInstanceEventSupported() bool {
+	switch w.HookTaskType {
+	case GITEA, GOGS, CUSTOM:
+		return true
+	}
+	return false
+}
+
+// prepareSystemWebhooks adds the tasks of the system webhooks for an event of the
+// instance, which doesn't belong to a repository.
+func prepareSystemWebhooks(e Engine, event HookEventType, p api.Payloader) error {
+	ws, err := getActiveSystemWebhooks(e)
+	if err != nil {
+		return fmt.Errorf("GetActiveSystemWebhooks: %v", err)
+	}
+
+	for _, w := range ws {
+		if !w.isInstanceEventSupported() {
+			continue
+		}
+		// a hook which fails mustn't stop the others
+		if err = prepareWebhook(e, w, 0, event, p); err != nil {
+			log.Error("prepareWebhook [hook_id: %d]: %v", w.ID, err)
+		}
+	}
+	return nil
+}
+
+// prepareUserWebhooks adds the tasks of the system webhooks for the creation or deletion
+// of a user or an organization, the doer is optional.
+func prepareUserWebhooks(e Engine, u, doer *User, action api.HookUserAction) error {
+	if u.IsOrganization() {
+		payload := &api.OrganizationPayload{
+			Action:       action,
+			Organization: u.apiOrganization(),
+		}
+		if doer != nil {
+			payload.Sender = doer.APIFormat()
+		}
+		return prepareSystemWebhooks(e, HookEventOrganization, payload)
+	}
+	return prepareSystemWebhooks(e, HookEventUser, &api.UserPayload{
+		Action: action,
+		User:   u.APIFormat(),
+	})
+}
+
+func (u *User) apiOrganization() *api.Organization {
+	return &api.Organization{
+		ID:          u.ID,
+		AvatarURL:   u.AvatarLink(),
+		UserName:    u.Name,
+		FullName:    u.FullName,
+		Description: u.Description,
+		Website:     u.Website,
+		Location:    u.Location,
+		Visibility:  u.Visibility.String(),
+	}
+}
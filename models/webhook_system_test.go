@@ -0,0 +1,91 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createTestSystemWebhook(t *testing.T, hookType HookTaskType, events HookEvents) *Webhook {
+	hook := &Webhook{
+		URL:             "www.example.com/system",
+		ContentType:     ContentTypeJSON,
+		IsActive:        true,
+		HookTaskType:    hookType,
+		IsSystemWebhook: true,
+		HookEvent: &HookEvent{
+			ChooseEvents: true,
+			HookEvents:   events,
+		},
+	}
+	assert.NoError(t, hook.UpdateEvent())
+	assert.NoError(t, CreateWebhook(hook))
+	return hook
+}
+
+func TestGetSystemWebhooks(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	hook := createTestSystemWebhook(t, GITEA, HookEvents{Push: true})
+
+	hooks, err := GetSystemWebhooks()
+	assert.NoError(t, err)
+	if assert.Len(t, hooks, 1) {
+		assert.Equal(t, hook.ID, hooks[0].ID)
+	}
+
+	hooks, err = GetDefaultWebhooks()
+	assert.NoError(t, err)
+	assert.Empty(t, hooks)
+
+	_, err = GetDefaultWebhook(hook.ID)
+	assert.True(t, IsErrWebhookNotExist(err))
+
+	assert.True(t, IsErrWebhookNotExist(DeleteDefaultWebhook(hook.ID)))
+	assert.NoError(t, DeleteSystemWebhook(hook.ID))
+	AssertNotExistsBean(t, &Webhook{ID: hook.ID})
+}
+
+func TestPrepareWebhooks_SystemWebhook(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	hook := createTestSystemWebhook(t, GITEA, HookEvents{Push: true})
+
+	for _, repoID := range []int64{1, 2} {
+		repo := AssertExistsAndLoadBean(t, &Repository{ID: repoID}).(*Repository)
+		assert.NoError(t, PrepareWebhooks(repo, HookEventPush, &api.PushPayload{}))
+		AssertExistsAndLoadBean(t, &HookTask{RepoID: repo.ID, HookID: hook.ID, EventType: HookEventPush})
+	}
+}
+
+func TestPrepareUserWebhooks(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	hook := createTestSystemWebhook(t, GITEA, HookEvents{User: true, Organization: true})
+	slackHook := createTestSystemWebhook(t, SLACK, HookEvents{User: true})
+
+	user := &User{
+		Name:   "systemhookuser",
+		Email:  "systemhookuser@example.com",
+		Passwd: "password",
+	}
+	assert.NoError(t, CreateUser(user))
+	AssertExistsAndLoadBean(t, &HookTask{HookID: hook.ID, EventType: HookEventUser})
+	AssertNotExistsBean(t, &HookTask{HookID: slackHook.ID})
+
+	assert.NoError(t, DeleteUser(user))
+	assert.EqualValues(t, 2, getCount(t, x, &HookTask{HookID: hook.ID, EventType: HookEventUser}))
+
+	owner := AssertExistsAndLoadBean(t, &User{ID: 1}).(*User)
+	org := &User{Name: "systemhookorg"}
+	assert.NoError(t, CreateOrganization(org, owner))
+	task := AssertExistsAndLoadBean(t, &HookTask{HookID: hook.ID, EventType: HookEventOrganization}).(*HookTask)
+	assert.Contains(t, task.PayloadContent, `"action": "created"`)
+	assert.Contains(t, task.PayloadContent, owner.Name)
+}
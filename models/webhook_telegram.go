@@ -30,6 +30,11 @@ type (
 	}
 )
 
+// TelegramSendMessageURL returns the URL of the bot API to send messages to the chat
+func TelegramSendMessageURL(botToken, chatID string) string {
+	return fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage?chat_id=%s", botToken, chatID)
+}
+
 // SetSecret sets the telegram secret
 func (p *TelegramPayload) SetSecret(_ string) {}
 
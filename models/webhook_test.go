@@ -6,9 +6,14 @@ package models
 
 import (
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
+	"code.gitea.io/gitea/modules/setting"
 	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/util"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -73,7 +78,9 @@ func TestWebhook_UpdateEvent(t *testing.T) {
 }
 
 func TestWebhook_EventsArray(t *testing.T) {
-	assert.Equal(t, []string{"create", "delete", "fork", "push", "issues", "issue_comment", "pull_request", "repository", "release"},
+	assert.Equal(t, []string{"create", "delete", "fork", "push", "issues", "issue_assign", "issue_label", "issue_milestone", "issue_comment",
+		"pull_request", "pull_request_assign", "pull_request_label", "pull_request_milestone", "pull_request_sync", "pull_request_comment",
+		"repository", "release"},
 		(&Webhook{
 			HookEvent: &HookEvent{SendEverything: true},
 		}).EventsArray(),
@@ -270,6 +277,189 @@ func TestPrepareWebhooks(t *testing.T) {
 	}
 }
 
-// TODO TestHookTask_deliver
+func TestPrepareWebhooks_InvalidHook(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	repo := AssertExistsAndLoadBean(t, &Repository{ID: 1}).(*Repository)
+	var hooks []*Webhook
+	for _, hook := range []*Webhook{
+		{RepoID: repo.ID, URL: "www.example.com/invalid", HookTaskType: MATRIX, Meta: "invalid"},
+		{RepoID: repo.ID, URL: "www.example.com/valid", HookTaskType: GITEA},
+	} {
+		hook.ContentType = ContentTypeJSON
+		hook.IsActive = true
+		hook.HookEvent = &HookEvent{PushOnly: true}
+		assert.NoError(t, hook.UpdateEvent())
+		assert.NoError(t, CreateWebhook(hook))
+		hooks = append(hooks, hook)
+	}
+
+	// the invalid hook doesn't keep the other hooks from being triggered
+	assert.NoError(t, PrepareWebhooks(repo, HookEventPush, &api.PushPayload{}))
+	AssertNotExistsBean(t, &HookTask{HookID: hooks[0].ID})
+	AssertExistsAndLoadBean(t, &HookTask{HookID: hooks[1].ID, EventType: HookEventPush})
+}
+
+func TestPrepareWebhooks_SubEvents(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	repo := AssertExistsAndLoadBean(t, &Repository{ID: 1}).(*Repository)
+	hook := &Webhook{
+		RepoID:      repo.ID,
+		URL:         "www.example.com/sub_events",
+		ContentType: ContentTypeJSON,
+		IsActive:    true,
+		HookEvent: &HookEvent{
+			ChooseEvents: true,
+			HookEvents: HookEvents{
+				IssueLabel: true,
+			},
+		},
+	}
+	assert.NoError(t, hook.UpdateEvent())
+	assert.NoError(t, CreateWebhook(hook))
+
+	payload := &api.IssuePayload{Action: api.HookIssueLabelUpdated}
+	assert.NoError(t, PrepareWebhook(hook, repo, HookEventIssues, payload))
+	assert.NoError(t, PrepareWebhook(hook, repo, HookEventIssueAssign, payload))
+	AssertNotExistsBean(t, &HookTask{HookID: hook.ID})
+
+	assert.NoError(t, PrepareWebhook(hook, repo, HookEventIssueLabel, payload))
+	AssertExistsAndLoadBean(t, &HookTask{HookID: hook.ID, EventType: HookEventIssueLabel})
+}
+
+func TestHookEventType_Event(t *testing.T) {
+	assert.Equal(t, "issues", HookEventIssueMilestone.Event())
+	assert.Equal(t, "pull_request", HookEventPullRequestSync.Event())
+	assert.Equal(t, "pull_request_comment", HookEventPullRequestComment.Event())
+	assert.Equal(t, "push", HookEventPush.Event())
+}
+
+func TestHookTaskRetryDelay(t *testing.T) {
+	defer func(backoff, maxBackoff int) {
+		setting.Webhook.RetryBackoff = backoff
+		setting.Webhook.MaxRetryBackoff = maxBackoff
+	}(setting.Webhook.RetryBackoff, setting.Webhook.MaxRetryBackoff)
+	setting.Webhook.RetryBackoff = 10
+	setting.Webhook.MaxRetryBackoff = 60
+
+	assert.Equal(t, 10*time.Second, hookTaskRetryDelay(1))
+	assert.Equal(t, 20*time.Second, hookTaskRetryDelay(2))
+	assert.Equal(t, 40*time.Second, hookTaskRetryDelay(3))
+	assert.Equal(t, 60*time.Second, hookTaskRetryDelay(4))
+	assert.Equal(t, 60*time.Second, hookTaskRetryDelay(100))
+}
+
+func TestDeliverHookTask(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	InitDeliverHooks()
+
+	var status = http.StatusInternalServerError
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	task := &HookTask{
+		RepoID:      1,
+		HookID:      1,
+		URL:         server.URL,
+		Payloader:   &api.PushPayload{},
+		HTTPMethod:  http.MethodPost,
+		ContentType: ContentTypeJSON,
+		EventType:   HookEventPush,
+	}
+	assert.NoError(t, CreateHookTask(task))
+
+	ids, err := FindDueHookTaskIDs(1)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{task.ID}, ids)
+
+	// the failed delivery is retried later
+	assert.NoError(t, DeliverHookTask(task.ID))
+	task = AssertExistsAndLoadBean(t, &HookTask{ID: task.ID}).(*HookTask)
+	assert.False(t, task.IsSucceed)
+	assert.True(t, task.IsRetrying())
+	assert.Equal(t, 1, task.Attempts)
+	assert.True(t, task.NextDeliveryUnix > util.TimeStampNow())
+	assert.EqualValues(t, http.StatusInternalServerError, task.ResponseInfo.Status)
+	assert.EqualValues(t, HookStatusFail, AssertExistsAndLoadBean(t, &Webhook{ID: 1}).(*Webhook).LastStatus)
+
+	ids, err = FindDueHookTaskIDs(1)
+	assert.NoError(t, err)
+	assert.Empty(t, ids)
+
+	// the delivery is not attempted again before it is due
+	assert.NoError(t, DeliverHookTask(task.ID))
+	AssertExistsAndLoadBean(t, &HookTask{ID: task.ID, Attempts: 1})
+
+	_, err = x.ID(task.ID).Cols("next_delivery_unix").Update(&HookTask{NextDeliveryUnix: util.TimeStampNow()})
+	assert.NoError(t, err)
+	status = http.StatusOK
+	assert.NoError(t, DeliverHookTask(task.ID))
+	task = AssertExistsAndLoadBean(t, &HookTask{ID: task.ID}).(*HookTask)
+	assert.True(t, task.IsDelivered)
+	assert.True(t, task.IsSucceed)
+	assert.Equal(t, 2, task.Attempts)
+	assert.EqualValues(t, HookStatusSucceed, AssertExistsAndLoadBean(t, &Webhook{ID: 1}).(*Webhook).LastStatus)
+
+	// delivered tasks are not delivered again
+	assert.NoError(t, DeliverHookTask(task.ID))
+	AssertExistsAndLoadBean(t, &HookTask{ID: task.ID, Attempts: 2})
+}
+
+func TestDeliverHookTask_MaxRetries(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	InitDeliverHooks()
+
+	defer func(maxRetries int) {
+		setting.Webhook.MaxRetries = maxRetries
+	}(setting.Webhook.MaxRetries)
+	setting.Webhook.MaxRetries = 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	task := &HookTask{
+		RepoID:      1,
+		HookID:      1,
+		URL:         server.URL,
+		Payloader:   &api.PushPayload{},
+		HTTPMethod:  http.MethodPost,
+		ContentType: ContentTypeJSON,
+		EventType:   HookEventPush,
+	}
+	assert.NoError(t, CreateHookTask(task))
+	assert.NoError(t, DeliverHookTask(task.ID))
+
+	task = AssertExistsAndLoadBean(t, &HookTask{ID: task.ID}).(*HookTask)
+	assert.True(t, task.IsDelivered)
+	assert.False(t, task.IsSucceed)
+	assert.False(t, task.IsRetrying())
+}
+
+func TestRedeliverHookTask(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
 
-// TODO TestDeliverHooks
+	task, err := GetHookTaskByUUID(1, "uuid1")
+	assert.NoError(t, err)
+
+	redelivery, err := RedeliverHookTask(task)
+	assert.NoError(t, err)
+	assert.NotEqual(t, task.UUID, redelivery.UUID)
+	AssertExistsAndLoadBean(t, &HookTask{
+		ID:          redelivery.ID,
+		RepoID:      task.RepoID,
+		HookID:      task.HookID,
+		IsDelivered: false,
+	}, "attempts = 0")
+
+	ids, err := FindDueHookTaskIDs(0)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{redelivery.ID}, ids)
+
+	_, err = GetHookTaskByUUID(2, "uuid1")
+	assert.True(t, IsErrHookTaskNotExist(err))
+}
@@ -0,0 +1,232 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package actions
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// WorkflowsDir is the directory of the repository containing the workflow files
+const WorkflowsDir = ".gitea/workflows"
+
+// Event represents an event triggering workflows
+type Event string
+
+// enumerates all the events triggering workflows
+const (
+	EventPush        Event = "push"
+	EventPullRequest Event = "pull_request"
+)
+
+// IsWorkflowFile returns true if the name is the name of a workflow file
+func IsWorkflowFile(name string) bool {
+	ext := path.Ext(name)
+	return ext == ".yml" || ext == ".yaml"
+}
+
+// Step represents a shell command executed by a job
+type Step struct {
+	Name string            `yaml:"name" json:"name"`
+	Run  string            `yaml:"run" json:"run"`
+	Env  map[string]string `yaml:"env" json:"env,omitempty"`
+}
+
+// Job represents a job of a workflow, a list of steps run on one runner
+type Job struct {
+	ID     string
+	Name   string
+	RunsOn []string
+	Env    map[string]string
+	Steps  []*Step
+}
+
+// EventFilter represents the branches and tags a workflow is triggered for
+type EventFilter struct {
+	Branches []string `yaml:"branches"`
+	Tags     []string `yaml:"tags"`
+}
+
+// Workflow represents a parsed workflow file
+type Workflow struct {
+	Name string
+	On   map[Event]*EventFilter
+	Env  map[string]string
+	Jobs []*Job
+}
+
+type rawJob struct {
+	Name   string            `yaml:"name"`
+	RunsOn interface{}       `yaml:"runs-on"`
+	Env    map[string]string `yaml:"env"`
+	Steps  []*Step           `yaml:"steps"`
+}
+
+type rawWorkflow struct {
+	Name string            `yaml:"name"`
+	On   interface{}       `yaml:"on"`
+	Env  map[string]string `yaml:"env"`
+	Jobs yaml.MapSlice     `yaml:"jobs"`
+}
+
+// ParseWorkflow parses the content of a workflow file, name is used as the
+// name of the workflow when the file does not define one.
+func ParseWorkflow(name string, content []byte) (*Workflow, error) {
+	var raw rawWorkflow
+	if err := yaml.Unmarshal(content, &raw); err != nil {
+		return nil, err
+	}
+
+	w := &Workflow{
+		Name: raw.Name,
+		Env:  raw.Env,
+	}
+	if w.Name == "" {
+		w.Name = strings.TrimSuffix(name, path.Ext(name))
+	}
+
+	var err error
+	if w.On, err = parseEvents(raw.On); err != nil {
+		return nil, err
+	}
+
+	if len(raw.Jobs) == 0 {
+		return nil, errors.New("no jobs defined")
+	}
+	for _, item := range raw.Jobs {
+		id, ok := item.Key.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid job id: %v", item.Key)
+		}
+		// Re-marshal the job so that it can be decoded into its structure
+		bs, err := yaml.Marshal(item.Value)
+		if err != nil {
+			return nil, err
+		}
+		var rj rawJob
+		if err = yaml.Unmarshal(bs, &rj); err != nil {
+			return nil, fmt.Errorf("job %s: %v", id, err)
+		}
+
+		job := &Job{
+			ID:    id,
+			Name:  rj.Name,
+			Env:   rj.Env,
+			Steps: rj.Steps,
+		}
+		if job.Name == "" {
+			job.Name = id
+		}
+		if job.RunsOn, err = toStrings(rj.RunsOn); err != nil {
+			return nil, fmt.Errorf("job %s: runs-on: %v", id, err)
+		}
+		if len(job.Steps) == 0 {
+			return nil, fmt.Errorf("job %s: no steps defined", id)
+		}
+		for i, step := range job.Steps {
+			if strings.TrimSpace(step.Run) == "" {
+				return nil, fmt.Errorf("job %s: step %d has nothing to run", id, i+1)
+			}
+		}
+		w.Jobs = append(w.Jobs, job)
+	}
+	return w, nil
+}
+
+func parseEvents(on interface{}) (map[Event]*EventFilter, error) {
+	events := make(map[Event]*EventFilter)
+	switch v := on.(type) {
+	case nil:
+		return nil, errors.New("no events defined")
+	case string, []interface{}:
+		names, err := toStrings(v)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			events[Event(name)] = &EventFilter{}
+		}
+	case map[interface{}]interface{}:
+		for key, value := range v {
+			name, ok := key.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid event: %v", key)
+			}
+			filter := &EventFilter{}
+			if value != nil {
+				bs, err := yaml.Marshal(value)
+				if err != nil {
+					return nil, err
+				}
+				if err = yaml.Unmarshal(bs, filter); err != nil {
+					return nil, fmt.Errorf("event %s: %v", name, err)
+				}
+			}
+			events[Event(name)] = filter
+		}
+	default:
+		return nil, fmt.Errorf("invalid events: %v", on)
+	}
+	return events, nil
+}
+
+func toStrings(v interface{}) ([]string, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{v}, nil
+	case []interface{}:
+		res := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("expected a string, got %v", item)
+			}
+			res = append(res, s)
+		}
+		return res, nil
+	}
+	return nil, fmt.Errorf("expected a string or a list of strings, got %v", v)
+}
+
+// Match returns true if the workflow is triggered by the event on the given
+// ref. For pull requests ref is the base branch of the pull request.
+func (w *Workflow) Match(event Event, ref string) bool {
+	filter, ok := w.On[event]
+	if !ok {
+		return false
+	}
+
+	const (
+		branchPrefix = "refs/heads/"
+		tagPrefix    = "refs/tags/"
+	)
+	switch {
+	case strings.HasPrefix(ref, tagPrefix):
+		// Tags only trigger workflows which explicitly ask for them
+		return matchAny(filter.Tags, strings.TrimPrefix(ref, tagPrefix))
+	case len(filter.Branches) == 0:
+		return event != EventPush || len(filter.Tags) == 0
+	default:
+		return matchAny(filter.Branches, strings.TrimPrefix(ref, branchPrefix))
+	}
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if pattern == "**" || pattern == name {
+			return true
+		}
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
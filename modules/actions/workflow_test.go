@@ -0,0 +1,96 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package actions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testWorkflow = `
+name: CI
+on:
+  push:
+    branches: [master, "release/*"]
+    tags: ["v*"]
+  pull_request:
+env:
+  GOPROXY: off
+jobs:
+  test:
+    runs-on: linux
+    steps:
+      - name: Test
+        run: make test
+  lint:
+    name: Lint code
+    runs-on: [linux, docker]
+    env:
+      GOFLAGS: -mod=vendor
+    steps:
+      - run: make lint
+`
+
+func TestParseWorkflow(t *testing.T) {
+	w, err := ParseWorkflow("ci.yml", []byte(testWorkflow))
+	assert.NoError(t, err)
+	assert.Equal(t, "CI", w.Name)
+	assert.Equal(t, map[string]string{"GOPROXY": "off"}, w.Env)
+	assert.Len(t, w.On, 2)
+	assert.Equal(t, []string{"master", "release/*"}, w.On[EventPush].Branches)
+
+	if assert.Len(t, w.Jobs, 2) {
+		assert.Equal(t, "test", w.Jobs[0].ID)
+		assert.Equal(t, "test", w.Jobs[0].Name)
+		assert.Equal(t, []string{"linux"}, w.Jobs[0].RunsOn)
+		assert.Equal(t, "make test", w.Jobs[0].Steps[0].Run)
+
+		assert.Equal(t, "lint", w.Jobs[1].ID)
+		assert.Equal(t, "Lint code", w.Jobs[1].Name)
+		assert.Equal(t, []string{"linux", "docker"}, w.Jobs[1].RunsOn)
+		assert.Equal(t, map[string]string{"GOFLAGS": "-mod=vendor"}, w.Jobs[1].Env)
+	}
+
+	w, err = ParseWorkflow("build.yaml", []byte("on: [push, pull_request]\njobs:\n  build:\n    steps:\n      - run: make\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "build", w.Name)
+	assert.Len(t, w.On, 2)
+
+	for _, content := range []string{
+		"jobs:\n  build:\n    steps:\n      - run: make\n",
+		"on: push\n",
+		"on: push\njobs:\n  build:\n    runs-on: linux\n",
+		"on: push\njobs:\n  build:\n    steps:\n      - name: empty\n",
+		"on: push\njobs: [",
+	} {
+		_, err = ParseWorkflow("invalid.yml", []byte(content))
+		assert.Error(t, err, content)
+	}
+}
+
+func TestWorkflow_Match(t *testing.T) {
+	w, err := ParseWorkflow("ci.yml", []byte(testWorkflow))
+	assert.NoError(t, err)
+
+	assert.True(t, w.Match(EventPush, "refs/heads/master"))
+	assert.True(t, w.Match(EventPush, "refs/heads/release/1.9"))
+	assert.False(t, w.Match(EventPush, "refs/heads/feature"))
+	assert.True(t, w.Match(EventPush, "refs/tags/v1.9.0"))
+	assert.False(t, w.Match(EventPush, "refs/tags/latest"))
+	assert.True(t, w.Match(EventPullRequest, "refs/heads/feature"))
+
+	w, err = ParseWorkflow("build.yml", []byte("on: push\njobs:\n  build:\n    steps:\n      - run: make\n"))
+	assert.NoError(t, err)
+	assert.True(t, w.Match(EventPush, "refs/heads/feature"))
+	assert.False(t, w.Match(EventPush, "refs/tags/v1.0"))
+	assert.False(t, w.Match(EventPullRequest, "refs/heads/master"))
+}
+
+func TestIsWorkflowFile(t *testing.T) {
+	assert.True(t, IsWorkflowFile("ci.yml"))
+	assert.True(t, IsWorkflowFile("ci.yaml"))
+	assert.False(t, IsWorkflowFile("README.md"))
+}
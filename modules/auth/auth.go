@@ -7,6 +7,7 @@ package auth
 
 import (
 	"reflect"
+	"regexp"
 	"strings"
 	"time"
 
@@ -29,6 +30,14 @@ func IsAPIPath(url string) bool {
 	return strings.HasPrefix(url, "/api/")
 }
 
+var gitPathRegexp = regexp.MustCompile(`^/[^/]+/[^/]+(\.git)?/(git-upload-pack|git-receive-pack|info/refs|HEAD|objects/.+|info/lfs/.+)$`)
+
+// isScopedTokenPath returns true if URL is a path which checks the scopes of the
+// access tokens: the API, the container registry, git over HTTP and LFS.
+func isScopedTokenPath(url string) bool {
+	return IsAPIPath(url) || url == "/v2" || strings.HasPrefix(url, "/v2/") || gitPathRegexp.MatchString(url)
+}
+
 // SignedInID returns the id of signed in user.
 func SignedInID(ctx *macaron.Context, sess session.Store) int64 {
 	if !models.HasEngine {
@@ -55,11 +64,13 @@ func SignedInID(ctx *macaron.Context, sess session.Store) int64 {
 		// Let's see if token is valid.
 		if len(tokenSHA) > 0 {
 			if strings.Contains(tokenSHA, ".") {
-				uid := CheckOAuthAccessToken(tokenSHA)
-				if uid != 0 {
-					ctx.Data["IsApiToken"] = true
+				grant, scope := ParseOAuthAccessToken(tokenSHA)
+				if grant == nil {
+					return 0
 				}
-				return uid
+				ctx.Data["IsApiToken"] = true
+				ctx.Data["AccessToken"] = OAuthAccessTokenScope(grant, scope)
+				return grant.UserID
 			}
 			t, err := models.GetAccessTokenBySHA(tokenSHA)
 			if err != nil {
@@ -73,6 +84,7 @@ func SignedInID(ctx *macaron.Context, sess session.Store) int64 {
 				log.Error("UpdateAccessToken: %v", err)
 			}
 			ctx.Data["IsApiToken"] = true
+			ctx.Data["AccessToken"] = t
 			return t.UID
 		}
 	}
@@ -86,28 +98,37 @@ func SignedInID(ctx *macaron.Context, sess session.Store) int64 {
 	return 0
 }
 
-// CheckOAuthAccessToken returns uid of user from oauth token token
-func CheckOAuthAccessToken(accessToken string) int64 {
+// ParseOAuthAccessToken returns the grant of a valid oauth access token and the scope it was requested with
+func ParseOAuthAccessToken(accessToken string) (*models.OAuth2Grant, models.OAuth2Scope) {
 	// JWT tokens require a "."
 	if !strings.Contains(accessToken, ".") {
-		return 0
+		return nil, ""
 	}
 	token, err := models.ParseOAuth2Token(accessToken)
 	if err != nil {
 		log.Trace("ParseOAuth2Token: %v", err)
-		return 0
+		return nil, ""
 	}
 	var grant *models.OAuth2Grant
 	if grant, err = models.GetOAuth2GrantByID(token.GrantID); err != nil || grant == nil {
-		return 0
+		return nil, ""
 	}
 	if token.Type != models.TypeAccessToken {
-		return 0
+		return nil, ""
 	}
 	if token.ExpiresAt < time.Now().Unix() || token.IssuedAt > time.Now().Unix() {
-		return 0
+		return nil, ""
+	}
+	return grant, models.OAuth2Scope(token.Scope)
+}
+
+// OAuthAccessTokenScope returns an unsaved personal access token holding the API scopes of
+// an oauth access token, so that they are enforced like the ones of personal access tokens.
+func OAuthAccessTokenScope(grant *models.OAuth2Grant, scope models.OAuth2Scope) *models.AccessToken {
+	return &models.AccessToken{
+		UID:   grant.UserID,
+		Scope: scope.AccessTokenScope(),
 	}
-	return grant.UserID
 }
 
 // SignedInUser returns the user object of signed user.
@@ -181,12 +202,12 @@ func SignedInUser(ctx *macaron.Context, sess session.Store) (*models.User, bool)
 				authToken = passwd
 			}
 
-			uid := CheckOAuthAccessToken(authToken)
-			if uid != 0 {
+			if grant, scope := ParseOAuthAccessToken(authToken); grant != nil {
 				var err error
 				ctx.Data["IsApiToken"] = true
+				ctx.Data["AccessToken"] = OAuthAccessTokenScope(grant, scope)
 
-				u, err = models.GetUserByID(uid)
+				u, err = models.GetUserByID(grant.UserID)
 				if err != nil {
 					log.Error("GetUserByID:  %v", err)
 					return nil, false
@@ -214,10 +235,19 @@ func SignedInUser(ctx *macaron.Context, sess session.Store) (*models.User, bool)
 				if err = models.UpdateAccessToken(token); err != nil {
 					log.Error("UpdateAccessToken:  %v", err)
 				}
+				ctx.Data["AccessToken"] = token
 			} else if !models.IsErrAccessTokenNotExist(err) && !models.IsErrAccessTokenEmpty(err) {
 				log.Error("GetAccessTokenBySha: %v", err)
 			}
 
+			// the other routes don't check the scopes, so they only accept the tokens granted all of them
+			if token, ok := ctx.Data["AccessToken"].(*models.AccessToken); ok && !token.HasScope(models.AccessTokenScopeAll) &&
+				!isScopedTokenPath(ctx.Req.URL.Path) {
+				delete(ctx.Data, "AccessToken")
+				delete(ctx.Data, "IsApiToken")
+				return nil, false
+			}
+
 			if u == nil {
 				u, err = models.UserSignIn(uname, passwd)
 				if err != nil {
@@ -49,6 +49,22 @@ type AuthenticationForm struct {
 	Oauth2AuthURL                 string
 	Oauth2ProfileURL              string
 	Oauth2EmailURL                string
+	Oauth2Scopes                  string
+	Oauth2UsernameClaim           string
+	Oauth2EmailClaim              string
+	Oauth2FullNameClaim           string
+	Oauth2AdminClaim              string
+	Oauth2AdminClaimValue         string
+	Oauth2GroupClaimName          string
+	Oauth2GroupTeamMap            string
+	Oauth2GroupTeamMapRemoval     bool
+	GroupsEnabled                 bool
+	GroupDN                       string
+	GroupFilter                   string
+	GroupMemberUID                string
+	UserUID                       string
+	GroupTeamMap                  string
+	GroupTeamMapRemoval           bool
 }
 
 // Validate validates fields
@@ -47,6 +47,13 @@ type Source struct {
 	Filter                string // Query filter to validate entry
 	AdminFilter           string // Query filter to check if user is admin
 	Enabled               bool   // if this source is disabled
+	GroupsEnabled         bool   // if the groups of the user are searched
+	GroupDN               string // Base search path for groups
+	GroupFilter           string // Query filter to validate groups, the user must belong to one of them
+	GroupMemberUID        string // Group attribute listing the UserUID of its members
+	UserUID               string // User attribute listed in the groups, the DN of the user when empty
+	GroupTeamMap          string // Map the group DNs to organization teams, in JSON format
+	GroupTeamMapRemoval   bool   // Remove the users from the mapped teams of the groups they left
 }
 
 // SearchResult : user data
@@ -57,6 +64,10 @@ type SearchResult struct {
 	Mail         string   // E-mail address
 	SSHPublicKey []string // SSH Public Key
 	IsAdmin      bool     // if user is administrator
+	Groups       []string // DNs of the groups of the user
+	// GroupsUnavailable is true if the search of the groups failed, the team
+	// memberships of the user must not be synced with Groups then.
+	GroupsUnavailable bool
 }
 
 func (ls *Source) sanitizedUserQuery(username string) (string, bool) {
@@ -172,6 +183,53 @@ func checkAdmin(l *ldap.Conn, ls *Source, userDN string) bool {
 	return false
 }
 
+// userUID returns the value identifying the entry in the member attribute of the groups
+func (ls *Source) userUID(entry *ldap.Entry) string {
+	if len(ls.UserUID) == 0 || strings.EqualFold(ls.UserUID, "dn") {
+		return entry.DN
+	}
+	return entry.GetAttributeValue(ls.UserUID)
+}
+
+// userAttributes returns the attributes fetched for the users
+func (ls *Source) userAttributes() []string {
+	attribs := []string{ls.AttributeUsername, ls.AttributeName, ls.AttributeSurname, ls.AttributeMail}
+	if len(strings.TrimSpace(ls.AttributeSSHPublicKey)) > 0 {
+		attribs = append(attribs, ls.AttributeSSHPublicKey)
+	}
+	if ls.GroupsEnabled && len(ls.UserUID) > 0 && !strings.EqualFold(ls.UserUID, "dn") {
+		attribs = append(attribs, ls.UserUID)
+	}
+	return attribs
+}
+
+// listGroupMemberships returns the DNs of the groups listing uid as member
+func (ls *Source) listGroupMemberships(l *ldap.Conn, uid string) ([]string, error) {
+	var groups []string
+	if len(uid) == 0 {
+		return groups, nil
+	}
+
+	groupFilter := fmt.Sprintf("(%s=%s)", ls.GroupMemberUID, ldap.EscapeFilter(uid))
+	if len(ls.GroupFilter) > 0 {
+		groupFilter = fmt.Sprintf("(&%s%s)", ls.GroupFilter, groupFilter)
+	}
+
+	log.Trace("Searching for groups using filter %s and base %s", groupFilter, ls.GroupDN)
+	search := ldap.NewSearchRequest(
+		ls.GroupDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false, groupFilter,
+		[]string{}, nil)
+
+	sr, err := l.Search(search)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range sr.Entries {
+		groups = append(groups, entry.DN)
+	}
+	return groups, nil
+}
+
 // SearchEntry : search an LDAP source if an entry (name, passwd) is valid and in the specific filter
 func (ls *Source) SearchEntry(name, passwd string, directBind bool) *SearchResult {
 	// See https://tools.ietf.org/search/rfc4513#section-5.1.2
@@ -249,10 +307,7 @@ func (ls *Source) SearchEntry(name, passwd string, directBind bool) *SearchResul
 
 	var isAttributeSSHPublicKeySet = len(strings.TrimSpace(ls.AttributeSSHPublicKey)) > 0
 
-	attribs := []string{ls.AttributeUsername, ls.AttributeName, ls.AttributeSurname, ls.AttributeMail}
-	if isAttributeSSHPublicKeySet {
-		attribs = append(attribs, ls.AttributeSSHPublicKey)
-	}
+	attribs := ls.userAttributes()
 
 	log.Trace("Fetching attributes '%v', '%v', '%v', '%v', '%v' with filter %s and base %s", ls.AttributeUsername, ls.AttributeName, ls.AttributeSurname, ls.AttributeMail, ls.AttributeSSHPublicKey, userFilter, userDN)
 	search := ldap.NewSearchRequest(
@@ -284,6 +339,23 @@ func (ls *Source) SearchEntry(name, passwd string, directBind bool) *SearchResul
 	}
 	isAdmin := checkAdmin(l, ls, userDN)
 
+	var groups []string
+	var groupsUnavailable bool
+	if ls.GroupsEnabled {
+		groups, err = ls.listGroupMemberships(l, ls.userUID(sr.Entries[0]))
+		if err != nil {
+			log.Error("LDAP Group Search failed unexpectedly! (%v)", err)
+			if len(ls.GroupFilter) > 0 {
+				// the user cannot be checked against the group filter
+				return nil
+			}
+			groupsUnavailable = true
+		} else if len(ls.GroupFilter) > 0 && len(groups) == 0 {
+			log.Trace("LDAP user %s is not a member of any group matching the group filter.", name)
+			return nil
+		}
+	}
+
 	if !directBind && ls.AttributesInBind {
 		// binds user (checking password) after looking-up attributes in BindDN context
 		err = bindUser(l, userDN, passwd)
@@ -299,6 +371,9 @@ func (ls *Source) SearchEntry(name, passwd string, directBind bool) *SearchResul
 		Mail:         mail,
 		SSHPublicKey: sshPublicKey,
 		IsAdmin:      isAdmin,
+		Groups:       groups,
+
+		GroupsUnavailable: groupsUnavailable,
 	}
 }
 
@@ -308,12 +383,12 @@ func (ls *Source) UsePagedSearch() bool {
 }
 
 // SearchEntries : search an LDAP source for all users matching userFilter
-func (ls *Source) SearchEntries() []*SearchResult {
+func (ls *Source) SearchEntries() ([]*SearchResult, error) {
 	l, err := dial(ls)
 	if err != nil {
 		log.Error("LDAP Connect error, %s:%v", ls.Host, err)
 		ls.Enabled = false
-		return nil
+		return nil, err
 	}
 	defer l.Close()
 
@@ -321,7 +396,7 @@ func (ls *Source) SearchEntries() []*SearchResult {
 		err := l.Bind(ls.BindDN, ls.BindPassword)
 		if err != nil {
 			log.Debug("Failed to bind as BindDN[%s]: %v", ls.BindDN, err)
-			return nil
+			return nil, err
 		}
 		log.Trace("Bound as BindDN %s", ls.BindDN)
 	} else {
@@ -332,10 +407,7 @@ func (ls *Source) SearchEntries() []*SearchResult {
 
 	var isAttributeSSHPublicKeySet = len(strings.TrimSpace(ls.AttributeSSHPublicKey)) > 0
 
-	attribs := []string{ls.AttributeUsername, ls.AttributeName, ls.AttributeSurname, ls.AttributeMail}
-	if isAttributeSSHPublicKeySet {
-		attribs = append(attribs, ls.AttributeSSHPublicKey)
-	}
+	attribs := ls.userAttributes()
 
 	log.Trace("Fetching attributes '%v', '%v', '%v', '%v', '%v' with filter %s and base %s", ls.AttributeUsername, ls.AttributeName, ls.AttributeSurname, ls.AttributeMail, ls.AttributeSSHPublicKey, userFilter, ls.UserBase)
 	search := ldap.NewSearchRequest(
@@ -350,13 +422,13 @@ func (ls *Source) SearchEntries() []*SearchResult {
 	}
 	if err != nil {
 		log.Error("LDAP Search failed unexpectedly! (%v)", err)
-		return nil
+		return nil, err
 	}
 
-	result := make([]*SearchResult, len(sr.Entries))
+	result := make([]*SearchResult, 0, len(sr.Entries))
 
-	for i, v := range sr.Entries {
-		result[i] = &SearchResult{
+	for _, v := range sr.Entries {
+		su := &SearchResult{
 			Username: v.GetAttributeValue(ls.AttributeUsername),
 			Name:     v.GetAttributeValue(ls.AttributeName),
 			Surname:  v.GetAttributeValue(ls.AttributeSurname),
@@ -364,9 +436,22 @@ func (ls *Source) SearchEntries() []*SearchResult {
 			IsAdmin:  checkAdmin(l, ls, v.DN),
 		}
 		if isAttributeSSHPublicKeySet {
-			result[i].SSHPublicKey = v.GetAttributeValues(ls.AttributeSSHPublicKey)
+			su.SSHPublicKey = v.GetAttributeValues(ls.AttributeSSHPublicKey)
+		}
+		if ls.GroupsEnabled {
+			// a user missing from the result is deactivated, so the sync cannot go on
+			// without knowing the groups of every user
+			su.Groups, err = ls.listGroupMemberships(l, ls.userUID(v))
+			if err != nil {
+				log.Error("LDAP Group Search failed unexpectedly! (%v)", err)
+				return nil, err
+			}
+			if len(ls.GroupFilter) > 0 && len(su.Groups) == 0 {
+				continue
+			}
 		}
+		result = append(result, su)
 	}
 
-	return result
+	return result, nil
 }
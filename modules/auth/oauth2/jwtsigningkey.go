@@ -0,0 +1,120 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package oauth2
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/Unknwon/com"
+	"github.com/dgrijalva/jwt-go"
+)
+
+// JWTSigningKey is the RSA key signing the ID tokens issued by the OpenID Connect provider
+type JWTSigningKey struct {
+	privateKey *rsa.PrivateKey
+	id         string
+}
+
+// DefaultSigningKey is the signing key loaded by InitSigningKey
+var DefaultSigningKey *JWTSigningKey
+
+// NewJWTSigningKey creates a signing key from a RSA private key
+func NewJWTSigningKey(privateKey *rsa.PrivateKey) (*JWTSigningKey, error) {
+	der, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.Sum256(der)
+	return &JWTSigningKey{
+		privateKey: privateKey,
+		id:         base64.RawURLEncoding.EncodeToString(h[:16]),
+	}, nil
+}
+
+// KeyID returns the identifier of the key, set as the kid header of the signed tokens
+func (key *JWTSigningKey) KeyID() string {
+	return key.id
+}
+
+// PublicKey returns the public key verifying the signed tokens
+func (key *JWTSigningKey) PublicKey() *rsa.PublicKey {
+	return &key.privateKey.PublicKey
+}
+
+// SignToken signs the claims with the RS256 algorithm
+func (key *JWTSigningKey) SignToken(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = key.id
+	return token.SignedString(key.privateKey)
+}
+
+// ToJWK returns the public key in the JSON Web Key format of RFC 7517
+func (key *JWTSigningKey) ToJWK() map[string]string {
+	pub := key.PublicKey()
+	return map[string]string{
+		"kty": "RSA",
+		"alg": jwt.SigningMethodRS256.Alg(),
+		"use": "sig",
+		"kid": key.id,
+		"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+	}
+}
+
+// InitSigningKey loads the signing key from setting.OAuth2.JWTSigningPrivateKeyFile,
+// the key is generated the first time.
+func InitSigningKey() error {
+	keyPath := setting.OAuth2.JWTSigningPrivateKeyFile
+	if !com.IsFile(keyPath) {
+		log.Info("Generating the OAuth2 JWT signing key %s", keyPath)
+		if err := generateSigningKeyFile(keyPath); err != nil {
+			return fmt.Errorf("generateSigningKeyFile: %v", err)
+		}
+	}
+
+	data, err := ioutil.ReadFile(keyPath)
+	if err != nil {
+		return err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "RSA PRIVATE KEY" {
+		return fmt.Errorf("%s is not a PEM encoded RSA private key", keyPath)
+	}
+	privateKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		return err
+	}
+	DefaultSigningKey, err = NewJWTSigningKey(privateKey)
+	return err
+}
+
+func generateSigningKeyFile(keyPath string) error {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(keyPath), os.ModePerm); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(keyPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return pem.Encode(f, &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)})
+}
@@ -40,6 +40,28 @@ type CustomURLMapping struct {
 	EmailURL   string
 }
 
+// OpenIDConnectOptions describes the scopes requested from an OpenID Connect provider
+// and the claims mapped to the username, email and full name of the users
+type OpenIDConnectOptions struct {
+	Scopes        []string
+	UsernameClaim string
+	EmailClaim    string
+	FullNameClaim string
+}
+
+// apply overrides the default claims of the provider with the configured ones
+func (opts *OpenIDConnectOptions) apply(provider *openidConnect.Provider) {
+	if len(opts.UsernameClaim) > 0 {
+		provider.NickNameClaims = []string{opts.UsernameClaim}
+	}
+	if len(opts.EmailClaim) > 0 {
+		provider.EmailClaims = []string{opts.EmailClaim}
+	}
+	if len(opts.FullNameClaim) > 0 {
+		provider.NameClaims = []string{opts.FullNameClaim}
+	}
+}
+
 // Init initialize the setup of the OAuth2 library
 func Init(x *xorm.Engine) error {
 	store, err := xormstore.NewOptions(x, xormstore.Options{
@@ -100,8 +122,8 @@ func ProviderCallback(provider string, request *http.Request, response http.Resp
 }
 
 // RegisterProvider register a OAuth2 provider in goth lib
-func RegisterProvider(providerName, providerType, clientID, clientSecret, openIDConnectAutoDiscoveryURL string, customURLMapping *CustomURLMapping) error {
-	provider, err := createProvider(providerName, providerType, clientID, clientSecret, openIDConnectAutoDiscoveryURL, customURLMapping)
+func RegisterProvider(providerName, providerType, clientID, clientSecret, openIDConnectAutoDiscoveryURL string, customURLMapping *CustomURLMapping, openIDConnectOptions *OpenIDConnectOptions) error {
+	provider, err := createProvider(providerName, providerType, clientID, clientSecret, openIDConnectAutoDiscoveryURL, customURLMapping, openIDConnectOptions)
 
 	if err == nil && provider != nil {
 		goth.UseProviders(provider)
@@ -116,7 +138,7 @@ func RemoveProvider(providerName string) {
 }
 
 // used to create different types of goth providers
-func createProvider(providerName, providerType, clientID, clientSecret, openIDConnectAutoDiscoveryURL string, customURLMapping *CustomURLMapping) (goth.Provider, error) {
+func createProvider(providerName, providerType, clientID, clientSecret, openIDConnectAutoDiscoveryURL string, customURLMapping *CustomURLMapping, openIDConnectOptions *OpenIDConnectOptions) (goth.Provider, error) {
 	callbackURL := setting.AppURL + "user/oauth2/" + providerName + "/callback"
 
 	var provider goth.Provider
@@ -168,8 +190,18 @@ func createProvider(providerName, providerType, clientID, clientSecret, openIDCo
 	case "gplus":
 		provider = gplus.New(clientID, clientSecret, callbackURL, "email")
 	case "openidConnect":
-		if provider, err = openidConnect.New(clientID, clientSecret, callbackURL, openIDConnectAutoDiscoveryURL); err != nil {
+		var scopes []string
+		if openIDConnectOptions != nil {
+			scopes = openIDConnectOptions.Scopes
+		}
+		var oidcProvider *openidConnect.Provider
+		if oidcProvider, err = openidConnect.New(clientID, clientSecret, callbackURL, openIDConnectAutoDiscoveryURL, scopes...); err != nil {
 			log.Warn("Failed to create OpenID Connect Provider with name '%s' with url '%s': %v", providerName, openIDConnectAutoDiscoveryURL, err)
+		} else {
+			if openIDConnectOptions != nil {
+				openIDConnectOptions.apply(oidcProvider)
+			}
+			provider = oidcProvider
 		}
 	case "twitter":
 		provider = twitter.NewAuthenticate(clientID, clientSecret, callbackURL)
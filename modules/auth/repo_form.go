@@ -11,6 +11,7 @@ import (
 
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/routers/utils"
 
 	"github.com/Unknwon/com"
@@ -35,6 +36,13 @@ type CreateRepoForm struct {
 	Gitignores  string
 	License     string
 	Readme      string
+
+	RepoTemplate int64
+	GitContent   bool
+	Topics       bool
+	Webhooks     bool
+	Avatar       bool
+	Labels       bool
 }
 
 // Validate validates the fields
@@ -61,6 +69,9 @@ type MigrateRepoForm struct {
 	Issues       bool   `json:"issues"`
 	PullRequests bool   `json:"pull_requests"`
 	Releases     bool   `json:"releases"`
+	// git service to migrate from, 1 for plain git, 2 for GitHub and 3 for GitLab,
+	// it is detected from the clone address when empty
+	Service structs.GitServiceType `json:"service"`
 }
 
 // Validate validates the fields
@@ -96,6 +107,17 @@ func (f MigrateRepoForm) ParseRemoteAddr(user *models.User) (string, error) {
 	return remoteAddr, nil
 }
 
+// MigrateRetryForm form for retrying a failed migration
+type MigrateRetryForm struct {
+	AuthUsername string
+	AuthPassword string
+}
+
+// Validate validates the fields
+func (f *MigrateRetryForm) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
+	return validate(errs, ctx.Data, f, ctx.Locale)
+}
+
 // RepoSettingForm form for changing repository settings
 type RepoSettingForm struct {
 	RepoName      string `binding:"Required;AlphaDashDot;MaxSize(100)"`
@@ -104,8 +126,16 @@ type RepoSettingForm struct {
 	Interval      string
 	MirrorAddress string
 	Private       bool
+	Template      bool
 	EnablePrune   bool
 
+	// Push mirror settings
+	PushMirrorID       int64
+	PushMirrorAddress  string
+	PushMirrorUsername string
+	PushMirrorPassword string
+	PushMirrorInterval string
+
 	// Advanced settings
 	EnableWiki                       bool
 	EnableExternalWiki               bool
@@ -124,6 +154,8 @@ type RepoSettingForm struct {
 	EnableTimetracker                bool
 	AllowOnlyContributorsToTrackTime bool
 	EnableIssueDependencies          bool
+	EnableActions                    bool
+	EnableProjects                   bool
 	IsArchived                       bool
 
 	// Admin settings
@@ -155,6 +187,9 @@ type ProtectBranchForm struct {
 	RequiredApprovals       int64
 	ApprovalsWhitelistUsers string
 	ApprovalsWhitelistTeams string
+	DismissStaleApprovals   bool
+	EnableStatusCheck       bool
+	StatusCheckContexts     []string
 }
 
 // Validate validates the fields
@@ -171,17 +206,27 @@ func (f *ProtectBranchForm) Validate(ctx *macaron.Context, errs binding.Errors)
 
 // WebhookForm form for changing web hook
 type WebhookForm struct {
-	Events       string
-	Create       bool
-	Delete       bool
-	Fork         bool
-	Issues       bool
-	IssueComment bool
-	Release      bool
-	Push         bool
-	PullRequest  bool
-	Repository   bool
-	Active       bool
+	Events               string
+	Create               bool
+	Delete               bool
+	Fork                 bool
+	Issues               bool
+	IssueAssign          bool
+	IssueLabel           bool
+	IssueMilestone       bool
+	IssueComment         bool
+	Release              bool
+	Push                 bool
+	PullRequest          bool
+	PullRequestAssign    bool
+	PullRequestLabel     bool
+	PullRequestMilestone bool
+	PullRequestSync      bool
+	PullRequestComment   bool
+	Repository           bool
+	User                 bool
+	Organization         bool
+	Active               bool
 }
 
 // PushOnly if the hook will be triggered when push
@@ -293,6 +338,35 @@ func (f *NewMSTeamsHookForm) Validate(ctx *macaron.Context, errs binding.Errors)
 	return validate(errs, ctx.Data, f, ctx.Locale)
 }
 
+// NewMatrixHookForm form for creating Matrix hook
+type NewMatrixHookForm struct {
+	HomeserverURL string `binding:"Required;ValidUrl"`
+	RoomID        string `binding:"Required"`
+	AccessToken   string `binding:"Required"`
+	MessageType   string `binding:"In(m.notice,m.text)"`
+	WebhookForm
+}
+
+// Validate validates the fields
+func (f *NewMatrixHookForm) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
+	return validate(errs, ctx.Data, f, ctx.Locale)
+}
+
+// NewCustomHookForm form for creating custom hook
+type NewCustomHookForm struct {
+	PayloadURL  string `binding:"Required;ValidUrl"`
+	HTTPMethod  string `binding:"Required;In(POST,PUT)"`
+	ContentType string `binding:"Required;MaxSize(255)"`
+	Template    string `binding:"Required"`
+	Secret      string
+	WebhookForm
+}
+
+// Validate validates the fields
+func (f *NewCustomHookForm) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
+	return validate(errs, ctx.Data, f, ctx.Locale)
+}
+
 // .___
 // |   | ______ ________ __   ____
 // |   |/  ___//  ___/  |  \_/ __ \
@@ -384,6 +458,35 @@ func (f *CreateMilestoneForm) Validate(ctx *macaron.Context, errs binding.Errors
 	return validate(errs, ctx.Data, f, ctx.Locale)
 }
 
+// __________                   __               __
+// \______   \_______  ____    |__| ____   _____/  |_
+//  |     ___/\_  __ \/  _ \   |  |/ __ \_/ ___\   __\
+//  |    |     |  | \(  <_> )  |  \  ___/\  \___|  |
+//  |____|     |__|   \____/\__|  |\___  >\___  >__|
+//                       \______|    \/     \/
+
+// CreateProjectForm form for creating a project
+type CreateProjectForm struct {
+	Title   string `binding:"Required;MaxSize(100)"`
+	Content string
+}
+
+// Validate validates the fields
+func (f *CreateProjectForm) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
+	return validate(errs, ctx.Data, f, ctx.Locale)
+}
+
+// ProjectBoardForm form for creating or editing a project board
+type ProjectBoardForm struct {
+	Title   string `binding:"Required;MaxSize(100)"`
+	Trigger string `binding:"In(none,closed,merged)"`
+}
+
+// Validate validates the fields
+func (f *ProjectBoardForm) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
+	return validate(errs, ctx.Data, f, ctx.Locale)
+}
+
 // .____          ___.          .__
 // |    |   _____ \_ |__   ____ |  |
 // |    |   \__  \ | __ \_/ __ \|  |
@@ -145,6 +145,8 @@ type AuthorizationForm struct {
 	ClientID     string `binding:"Required"`
 	RedirectURI  string
 	State        string
+	Scope        string
+	Nonce        string
 
 	// PKCE support
 	CodeChallengeMethod string // S256, plain
@@ -300,7 +302,9 @@ func (f *AddKeyForm) Validate(ctx *macaron.Context, errs binding.Errors) binding
 
 // NewAccessTokenForm form for creating access token
 type NewAccessTokenForm struct {
-	Name string `binding:"Required;MaxSize(255)"`
+	Name         string   `binding:"Required;MaxSize(255)"`
+	Scopes       []string `form:"scope"`
+	Repositories string
 }
 
 // Validate valideates the fields
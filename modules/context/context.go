@@ -42,8 +42,9 @@ type Context struct {
 	IsSigned    bool
 	IsBasicAuth bool
 
-	Repo *Repository
-	Org  *Organization
+	Repo    *Repository
+	Org     *Organization
+	Package *Package
 }
 
 // IsUserSiteAdmin returns true if current user is a site admin
@@ -82,6 +83,12 @@ func (ctx *Context) IsUserRepoReaderAny() bool {
 	return ctx.Repo.HasAccess()
 }
 
+// AccessToken returns the personal access token the current user is signed in with, if any.
+func (ctx *Context) AccessToken() *models.AccessToken {
+	t, _ := ctx.Data["AccessToken"].(*models.AccessToken)
+	return t
+}
+
 // HasAPIError returns true if error occurs in form validation.
 func (ctx *Context) HasAPIError() bool {
 	hasErr, ok := ctx.Data["HasError"]
@@ -236,7 +243,8 @@ func Contexter() macaron.Handler {
 			Repo: &Repository{
 				PullRequest: &PullRequest{},
 			},
-			Org: &Organization{},
+			Org:     &Organization{},
+			Package: &Package{},
 		}
 		ctx.Data["Language"] = ctx.Locale.Language()
 		c.Data["Link"] = ctx.Link
@@ -324,6 +332,7 @@ func Contexter() macaron.Handler {
 		ctx.Data["ShowFooterVersion"] = setting.ShowFooterVersion
 
 		ctx.Data["EnableSwagger"] = setting.API.EnableSwagger
+		ctx.Data["EnableActions"] = setting.Actions.Enabled
 		ctx.Data["EnableOpenIDSignIn"] = setting.Service.EnableOpenIDSignIn
 
 		c.Map(ctx)
@@ -0,0 +1,89 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"code.gitea.io/gitea/models"
+	macaron "gopkg.in/macaron.v1"
+)
+
+// Package contains the owner of the packages of the request and the permission of the user on them
+type Package struct {
+	Owner    *models.User
+	CanWrite bool
+}
+
+func packageAssignment(ctx *Context, errCb func(int, string, error)) {
+	owner, err := models.GetUserByName(ctx.Params(":username"))
+	if err != nil {
+		if models.IsErrUserNotExist(err) {
+			errCb(404, "GetUserByName", err)
+		} else {
+			errCb(500, "GetUserByName", err)
+		}
+		return
+	}
+	if !models.CanReadPackages(owner, ctx.User) {
+		errCb(404, "CanReadPackages", nil)
+		return
+	}
+
+	ctx.Package.Owner = owner
+	ctx.Package.CanWrite, err = models.CanWritePackages(owner, ctx.User)
+	if err != nil {
+		errCb(500, "CanWritePackages", err)
+		return
+	}
+	ctx.Data["PackageOwner"] = owner
+	ctx.Data["CanWritePackages"] = ctx.Package.CanWrite
+}
+
+// PackageAssignment loads the owner of the packages from the :username parameter,
+// its packages must be visible to the signed in user.
+func PackageAssignment() macaron.Handler {
+	return func(ctx *Context) {
+		packageAssignment(ctx, func(status int, title string, err error) {
+			if status == 404 {
+				ctx.NotFound(title, err)
+			} else {
+				ctx.ServerError(title, err)
+			}
+		})
+	}
+}
+
+// PackageAssignmentAPI loads the owner of the packages for the API requests,
+// anonymous users are asked to authenticate instead of getting a 404.
+func PackageAssignmentAPI() macaron.Handler {
+	return PackageAssignmentAPIWithErrorHandler(func(ctx *APIContext, status int, title string, err error) {
+		if status == 404 && !ctx.IsSigned {
+			ctx.Resp.Header().Set("WWW-Authenticate", `Basic realm="Gitea Package API"`)
+			ctx.Error(401, title, "Authentication required")
+		} else if status == 404 {
+			ctx.NotFound()
+		} else {
+			ctx.Error(status, title, err)
+		}
+	})
+}
+
+// PackageAssignmentAPIWithErrorHandler loads the owner of the packages for the package
+// APIs answering the errors in their own format, the status is either 404 or 500.
+func PackageAssignmentAPIWithErrorHandler(errCb func(ctx *APIContext, status int, title string, err error)) macaron.Handler {
+	return func(ctx *APIContext) {
+		packageAssignment(ctx.Context, func(status int, title string, err error) {
+			errCb(ctx, status, title, err)
+		})
+	}
+}
+
+// RequirePackageWriter returns a macaron middleware for requiring the user to be able to write the packages of the owner
+func RequirePackageWriter() macaron.Handler {
+	return func(ctx *Context) {
+		if !ctx.Package.CanWrite {
+			ctx.NotFound("RequirePackageWriter", nil)
+		}
+	}
+}
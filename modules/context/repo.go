@@ -186,6 +186,33 @@ func RetrieveBaseRepo(ctx *Context, repo *models.Repository) {
 	}
 }
 
+// RetrieveTemplateRepo retrieves template repository used to generate this repository
+func RetrieveTemplateRepo(ctx *Context, repo *models.Repository) {
+	// Non-generated repository will not return error in this method.
+	if err := repo.GetTemplateRepo(); err != nil {
+		if models.IsErrRepoNotExist(err) {
+			repo.TemplateID = 0
+			return
+		}
+		ctx.ServerError("GetTemplateRepo", err)
+		return
+	} else if err = repo.TemplateRepo.GetOwner(); err != nil {
+		ctx.ServerError("TemplateRepo.GetOwner", err)
+		return
+	}
+
+	perm, err := models.GetUserRepoPermission(repo.TemplateRepo, ctx.User)
+	if err != nil {
+		ctx.ServerError("GetUserRepoPermission", err)
+		return
+	}
+	// The template isn't shown to the users who can't read it
+	if !perm.CanRead(models.UnitTypeCode) {
+		repo.TemplateID = 0
+		repo.TemplateRepo = nil
+	}
+}
+
 // ComposeGoGetImport returns go-get-import meta content.
 func ComposeGoGetImport(owner, repo string) string {
 	/// setting.AppUrl is guaranteed to be parse as url
@@ -354,14 +381,29 @@ func RepoAssignment() macaron.Handler {
 			return
 		}
 
+		ctx.Repo.RepoLink = repo.Link()
+		ctx.Data["RepoLink"] = ctx.Repo.RepoLink
+
+		// the git data of the repository is not available until the migration has finished,
+		// so only the pages showing the progress of the migration can be visited
+		if repo.IsBeingMigrated() {
+			ctx.Data["Title"] = owner.Name + "/" + repo.Name
+			ctx.Data["Repository"] = repo
+			ctx.Data["Owner"] = repo.Owner
+			ctx.Data["IsRepositoryAdmin"] = ctx.Repo.IsAdmin()
+			migratingPath := "/" + strings.ToLower(owner.Name) + "/" + repo.LowerName + "/migrating"
+			if p := strings.ToLower(ctx.Req.URL.Path); p != migratingPath && !strings.HasPrefix(p, migratingPath+"/") {
+				ctx.Redirect(ctx.Repo.RepoLink + "/migrating")
+			}
+			return
+		}
+
 		gitRepo, err := git.OpenRepository(models.RepoPath(userName, repoName))
 		if err != nil {
 			ctx.ServerError("RepoAssignment Invalid repo "+models.RepoPath(userName, repoName), err)
 			return
 		}
 		ctx.Repo.GitRepo = gitRepo
-		ctx.Repo.RepoLink = repo.Link()
-		ctx.Data["RepoLink"] = ctx.Repo.RepoLink
 		ctx.Data["RepoRelPath"] = ctx.Repo.Owner.Name + "/" + ctx.Repo.Repository.Name
 
 		unit, err := ctx.Repo.Repository.GetUnit(models.UnitTypeExternalTracker)
@@ -419,6 +461,13 @@ func RepoAssignment() macaron.Handler {
 			}
 		}
 
+		if repo.IsGenerated() {
+			RetrieveTemplateRepo(ctx, repo)
+			if ctx.Written() {
+				return
+			}
+		}
+
 		// repo is empty and display enable
 		if ctx.Repo.Repository.IsEmpty {
 			ctx.Data["BranchName"] = ctx.Repo.Repository.DefaultBranch
@@ -688,5 +737,7 @@ func UnitTypes() macaron.Handler {
 		ctx.Data["UnitTypeWiki"] = models.UnitTypeWiki
 		ctx.Data["UnitTypeExternalWiki"] = models.UnitTypeExternalWiki
 		ctx.Data["UnitTypeExternalTracker"] = models.UnitTypeExternalTracker
+		ctx.Data["UnitTypeActions"] = models.UnitTypeActions
+		ctx.Data["UnitTypeProjects"] = models.UnitTypeProjects
 	}
 }
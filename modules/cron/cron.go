@@ -10,6 +10,7 @@ import (
 
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/packages"
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/sync"
 
@@ -18,11 +19,14 @@ import (
 
 const (
 	mirrorUpdate           = "mirror_update"
+	pushMirrorUpdate       = "push_mirror_update"
+	stopZombieActions      = "stop_zombie_actions"
 	gitFsck                = "git_fsck"
 	checkRepos             = "check_repos"
 	archiveCleanup         = "archive_cleanup"
 	syncExternalUsers      = "sync_external_users"
 	deletedBranchesCleanup = "deleted_branches_cleanup"
+	packageBlobsCleanup    = "package_blobs_cleanup"
 )
 
 var c = cron.New()
@@ -61,6 +65,28 @@ func NewContext() {
 			go WithUnique(mirrorUpdate, models.MirrorUpdate)()
 		}
 	}
+	if setting.Cron.UpdatePushMirror.Enabled {
+		entry, err = c.AddFunc("Update push mirrors", setting.Cron.UpdatePushMirror.Schedule, WithUnique(pushMirrorUpdate, models.PushMirrorUpdate))
+		if err != nil {
+			log.Fatal("Cron[Update push mirrors]: %v", err)
+		}
+		if setting.Cron.UpdatePushMirror.RunAtStart {
+			entry.Prev = time.Now()
+			entry.ExecTimes++
+			go WithUnique(pushMirrorUpdate, models.PushMirrorUpdate)()
+		}
+	}
+	if setting.Cron.StopZombieActions.Enabled {
+		entry, err = c.AddFunc("Stop zombie actions", setting.Cron.StopZombieActions.Schedule, WithUnique(stopZombieActions, models.StopZombieActionRunJobs))
+		if err != nil {
+			log.Fatal("Cron[Stop zombie actions]: %v", err)
+		}
+		if setting.Cron.StopZombieActions.RunAtStart {
+			entry.Prev = time.Now()
+			entry.ExecTimes++
+			go WithUnique(stopZombieActions, models.StopZombieActionRunJobs)()
+		}
+	}
 	if setting.Cron.RepoHealthCheck.Enabled {
 		entry, err = c.AddFunc("Repository health check", setting.Cron.RepoHealthCheck.Schedule, WithUnique(gitFsck, models.GitFsck))
 		if err != nil {
@@ -116,6 +142,17 @@ func NewContext() {
 			go WithUnique(deletedBranchesCleanup, models.RemoveOldDeletedBranches)()
 		}
 	}
+	if setting.Packages.Enabled && setting.Cron.PackageBlobsCleanup.Enabled {
+		entry, err = c.AddFunc("Remove unreferenced package blobs", setting.Cron.PackageBlobsCleanup.Schedule, WithUnique(packageBlobsCleanup, packages.CleanupUnreferencedBlobs))
+		if err != nil {
+			log.Fatal("Cron[Remove unreferenced package blobs]: %v", err)
+		}
+		if setting.Cron.PackageBlobsCleanup.RunAtStart {
+			entry.Prev = time.Now()
+			entry.ExecTimes++
+			go WithUnique(packageBlobsCleanup, packages.CleanupUnreferencedBlobs)()
+		}
+	}
 	c.Start()
 }
 
@@ -8,6 +8,7 @@ package git
 import (
 	"fmt"
 	"os/exec"
+	"regexp"
 	"strings"
 	"time"
 
@@ -16,6 +17,9 @@ import (
 	"github.com/mcuadros/go-version"
 )
 
+// EnvProtocol is the environment variable passing the wire protocol requested by the client to git
+const EnvProtocol = "GIT_PROTOCOL"
+
 // Version return this package's current version
 func Version() string {
 	return "0.4.2"
@@ -35,8 +39,16 @@ var (
 	GitExecutable = "git"
 
 	gitVersion string
+
+	protocolRegex = regexp.MustCompile(`^[0-9a-zA-Z-]+=[0-9a-zA-Z-]+(:[0-9a-zA-Z-]+=[0-9a-zA-Z-]+)*$`)
 )
 
+// IsValidProtocol returns true if protocol is a well-formed value of GIT_PROTOCOL like version=2,
+// the value is received from the clients and is not forwarded to git otherwise.
+func IsValidProtocol(protocol string) bool {
+	return protocolRegex.MatchString(protocol)
+}
+
 func log(format string, args ...interface{}) {
 	if !Debug {
 		return
@@ -133,6 +145,35 @@ func Init() error {
 			return fmt.Errorf("Failed to execute 'git config --global gc.writeCommitGraph true': %s", stderr)
 		}
 	}
+
+	// Pass the push options to the hooks, they describe the pull requests pushed with AGit flow.
+	if version.Compare(gitVersion, "2.10", ">=") {
+		if _, stderr, err := process.GetManager().Exec("git.Init(git config --global receive.advertisePushOptions true)",
+			GitExecutable, "config", "--global", "receive.advertisePushOptions", "true"); err != nil {
+			return fmt.Errorf("Failed to execute 'git config --global receive.advertisePushOptions true': %s", stderr)
+		}
+	}
+
+	// Allow the partial clones, the objects left out are fetched afterwards by their id.
+	if version.Compare(gitVersion, "2.22", ">=") {
+		if _, stderr, err := process.GetManager().Exec("git.Init(git config --global uploadpack.allowfilter true)",
+			GitExecutable, "config", "--global", "uploadpack.allowfilter", "true"); err != nil {
+			return fmt.Errorf("Failed to execute 'git config --global uploadpack.allowfilter true': %s", stderr)
+		}
+
+		// with the wire protocol version 0 and 1, only the objects reachable from the refs can be
+		// fetched, not the ones of the deleted branches and the unreferenced objects which are still
+		// in the repository. The version 2 does not enforce it, any object id can be fetched then.
+		if _, stderr, err := process.GetManager().Exec("git.Init(git config --global uploadpack.allowReachableSHA1InWant true)",
+			GitExecutable, "config", "--global", "uploadpack.allowReachableSHA1InWant", "true"); err != nil {
+			return fmt.Errorf("Failed to execute 'git config --global uploadpack.allowReachableSHA1InWant true': %s", stderr)
+		}
+
+		if _, stderr, err := process.GetManager().Exec("git.Init(git config --global uploadpack.allowAnySHA1InWant false)",
+			GitExecutable, "config", "--global", "uploadpack.allowAnySHA1InWant", "false"); err != nil {
+			return fmt.Errorf("Failed to execute 'git config --global uploadpack.allowAnySHA1InWant false': %s", stderr)
+		}
+	}
 	return nil
 }
 
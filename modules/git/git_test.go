@@ -8,6 +8,8 @@ import (
 	"fmt"
 	"os"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func fatalTestError(fmtStr string, args ...interface{}) {
@@ -23,3 +25,12 @@ func TestMain(m *testing.M) {
 	exitStatus := m.Run()
 	os.Exit(exitStatus)
 }
+
+func TestIsValidProtocol(t *testing.T) {
+	for _, protocol := range []string{"version=2", "version=1", "version=2:object-format=sha1"} {
+		assert.True(t, IsValidProtocol(protocol), protocol)
+	}
+	for _, protocol := range []string{"", "version", "version=", "version=2:", "version=2 -c", "version=2\nversion=1"} {
+		assert.False(t, IsValidProtocol(protocol), protocol)
+	}
+}
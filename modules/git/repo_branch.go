@@ -15,6 +15,9 @@ import (
 // BranchPrefix base dir of the branch information file store on git
 const BranchPrefix = "refs/heads/"
 
+// ForPrefix is the prefix of the refs pushed to create or update a pull request with AGit flow
+const ForPrefix = "refs/for/"
+
 // IsReferenceExist returns true if given reference exists in the repository.
 func IsReferenceExist(repoPath, name string) bool {
 	_, err := NewCommand("show-ref", "--verify", "--", name).RunInDir(repoPath)
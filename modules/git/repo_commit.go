@@ -9,6 +9,7 @@ import (
 	"bytes"
 	"container/list"
 	"fmt"
+	"os/exec"
 	"strconv"
 	"strings"
 
@@ -312,6 +313,19 @@ func (repo *Repository) CommitsCountBetween(start, end string) (int64, error) {
 	return commitsCount(repo.Path, start+"..."+end, "")
 }
 
+// IsAncestor returns true if the commit ancestor is reachable from the commit descendant
+func (repo *Repository) IsAncestor(ancestor, descendant string) (bool, error) {
+	stderr := new(bytes.Buffer)
+	err := NewCommand("merge-base", "--is-ancestor", ancestor, descendant).RunInDirPipeline(repo.Path, nil, stderr)
+	if err == nil {
+		return true, nil
+	}
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+		return false, nil
+	}
+	return false, concatenateError(err, stderr.String())
+}
+
 // commitsBefore the limit is depth, not total number of returned commits.
 func (repo *Repository) commitsBefore(id SHA1, limit int) (*list.List, error) {
 	cmd := NewCommand("log")
@@ -60,3 +60,18 @@ func TestGetCommitWithBadCommitID(t *testing.T) {
 	assert.Error(t, err)
 	assert.EqualError(t, err, "object does not exist [id: bad_branch, rel_path: ]")
 }
+
+func TestRepository_IsAncestor(t *testing.T) {
+	bareRepo1Path := filepath.Join(testReposDir, "repo1_bare")
+	bareRepo1, err := OpenRepository(bareRepo1Path)
+	assert.NoError(t, err)
+
+	isAncestor, err := bareRepo1.IsAncestor("8d92fc957a4d7cfd98bc375f0b7bb189a0d6c9f2", "master")
+	assert.NoError(t, err)
+	assert.True(t, isAncestor)
+	isAncestor, err = bareRepo1.IsAncestor("2839944139e0de9737a044f78b0e4b40d989a9e3", "master")
+	assert.NoError(t, err)
+	assert.False(t, isAncestor)
+	_, err = bareRepo1.IsAncestor("unknown", "master")
+	assert.Error(t, err)
+}
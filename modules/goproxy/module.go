@@ -0,0 +1,334 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package goproxy resolves the versions of the Go modules hosted in the repositories
+// and builds their module zip archives, as served by the Go module proxies.
+package goproxy
+
+import (
+	"errors"
+	"io/ioutil"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/modules/git"
+)
+
+const (
+	// MaxZipSize is the maximum total size of the files of a module zip archive
+	MaxZipSize = 500 << 20
+	// MaxGoModSize is the maximum size of a go.mod file
+	MaxGoModSize = 16 << 20
+)
+
+var (
+	// ErrInvalidModulePath is returned when the path is not a valid module path of the repository
+	ErrInvalidModulePath = errors.New("invalid module path")
+	// ErrVersionNotExist is returned when the version or the revision does not exist
+	ErrVersionNotExist = errors.New("unknown revision")
+	// ErrModuleTooLarge is returned when the module zip archive or the go.mod file is too large
+	ErrModuleTooLarge = errors.New("module too large")
+
+	majorSuffixRegex = regexp.MustCompile(`^v[1-9][0-9]*$`)
+	commitIDRegex    = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+)
+
+// Info is the metadata of a version of a module
+type Info struct {
+	Version string
+	Time    time.Time
+}
+
+// Module is a Go module hosted in a repository, its path is made of the import path of the
+// repository, of the directory of the module in the repository and of the major version suffix.
+type Module struct {
+	// Path is the module path
+	Path string
+	// Dir is the directory of the module in the repository, it is empty at the root
+	Dir string
+	// Major is the major version suffix of the module path like v2, it is empty for the v0 and v1 versions
+	Major string
+
+	repo *git.Repository
+}
+
+// NewModule returns the module of the repository, subpath is the part of the module path
+// following the import path of the repository.
+func NewModule(repo *git.Repository, modulePath, subpath string) (*Module, error) {
+	m := &Module{
+		Path: modulePath,
+		repo: repo,
+	}
+	if len(subpath) == 0 {
+		return m, nil
+	}
+
+	elems := strings.Split(subpath, "/")
+	for _, elem := range elems {
+		if len(elem) == 0 || elem == "." || elem == ".." || strings.HasPrefix(elem, "-") {
+			return nil, ErrInvalidModulePath
+		}
+	}
+	if last := elems[len(elems)-1]; majorSuffixRegex.MatchString(last) {
+		// the v0 and v1 versions do not have a major version suffix
+		if last == "v1" {
+			return nil, ErrInvalidModulePath
+		}
+		m.Major = last
+		elems = elems[:len(elems)-1]
+	}
+	m.Dir = strings.Join(elems, "/")
+	return m, nil
+}
+
+// tagPrefix returns the prefix of the tags of the versions, the modules in a subdirectory
+// are tagged like dir/v1.2.3
+func (m *Module) tagPrefix() string {
+	if len(m.Dir) == 0 {
+		return git.TagPrefix
+	}
+	return git.TagPrefix + m.Dir + "/"
+}
+
+// isModuleVersion returns true if v is a valid version with the major version of the module
+func (m *Module) isModuleVersion(v string) bool {
+	if !IsValidVersion(v) {
+		return false
+	}
+	major := majorVersion(v)
+	if len(m.Major) == 0 {
+		return major == "v0" || major == "v1"
+	}
+	return major == m.Major
+}
+
+// Versions returns the versions of the module tagged in the repository in increasing order,
+// the pseudo-versions are not listed.
+func (m *Module) Versions() ([]string, error) {
+	tags, err := m.repo.GetTags()
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := strings.TrimPrefix(m.tagPrefix(), git.TagPrefix)
+	versions := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if !strings.HasPrefix(tag, prefix) {
+			continue
+		}
+		v := tag[len(prefix):]
+		if m.isModuleVersion(v) && !IsPseudoVersion(v) {
+			versions = append(versions, v)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return CompareVersions(versions[i], versions[j]) < 0
+	})
+	return versions, nil
+}
+
+// Latest returns the latest release of the module, or the latest prerelease if there is no
+// release. The pseudo-version of the head of the default branch is returned without versions.
+func (m *Module) Latest(defaultBranch string) (*Info, error) {
+	versions, err := m.Versions()
+	if err != nil {
+		return nil, err
+	}
+	for i := len(versions) - 1; i >= 0; i-- {
+		if !IsPrerelease(versions[i]) {
+			return m.Stat(versions[i])
+		}
+	}
+	if len(versions) > 0 {
+		return m.Stat(versions[len(versions)-1])
+	}
+
+	commit, err := m.repo.GetBranchCommit(defaultBranch)
+	if err != nil {
+		if git.IsErrNotExist(err) {
+			return nil, ErrVersionNotExist
+		}
+		return nil, err
+	}
+	return m.commitInfo(commit, versions)
+}
+
+// Stat returns the metadata of a version of the module, query can also be a branch,
+// a tag or a commit id which are resolved to the version of the commit.
+func (m *Module) Stat(query string) (*Info, error) {
+	if m.isModuleVersion(query) {
+		commit, err := m.versionCommit(query)
+		if err != nil {
+			return nil, err
+		}
+		return &Info{Version: query, Time: commit.Committer.When.UTC()}, nil
+	} else if IsValidVersion(query) {
+		// a version of another major version
+		return nil, ErrVersionNotExist
+	}
+
+	var (
+		commit *git.Commit
+		err    error
+	)
+	switch {
+	case m.repo.IsBranchExist(query):
+		commit, err = m.repo.GetBranchCommit(query)
+	case m.repo.IsTagExist(query):
+		commit, err = m.repo.GetTagCommit(git.TagPrefix + query)
+	case commitIDRegex.MatchString(query):
+		commit, err = m.repo.GetCommit(query)
+	default:
+		return nil, ErrVersionNotExist
+	}
+	if err != nil {
+		if git.IsErrNotExist(err) {
+			return nil, ErrVersionNotExist
+		}
+		return nil, err
+	}
+
+	versions, err := m.Versions()
+	if err != nil {
+		return nil, err
+	}
+	return m.commitInfo(commit, versions)
+}
+
+// commitInfo returns the metadata of the version of a commit, it is the highest version tagged
+// on the commit if any, or a pseudo-version based on the highest version tagged on an ancestor.
+func (m *Module) commitInfo(commit *git.Commit, versions []string) (*Info, error) {
+	commitID := commit.ID.String()
+	info := &Info{Time: commit.Committer.When.UTC()}
+
+	tagged := make([]string, len(versions))
+	for i := len(versions) - 1; i >= 0; i-- {
+		id, err := m.repo.GetTagCommitID(m.tagPrefix() + versions[i])
+		if err != nil {
+			return nil, err
+		}
+		if id == commitID {
+			info.Version = versions[i]
+			return info, nil
+		}
+		tagged[i] = id
+	}
+
+	var base string
+	for i := len(versions) - 1; i >= 0; i-- {
+		isAncestor, err := m.repo.IsAncestor(tagged[i], commitID)
+		if err != nil {
+			return nil, err
+		}
+		if isAncestor {
+			base = versions[i]
+			break
+		}
+	}
+	info.Version = pseudoVersion(m.Major, base, commit.Committer.When, commitID)
+	return info, nil
+}
+
+// versionCommit returns the commit of a version, either tagged or a pseudo-version
+func (m *Module) versionCommit(v string) (*git.Commit, error) {
+	var (
+		commit *git.Commit
+		err    error
+	)
+	t, commitID, isPseudo := parsePseudoVersion(v)
+	if isPseudo {
+		commit, err = m.repo.GetCommit(commitID)
+	} else {
+		commit, err = m.repo.GetTagCommit(m.tagPrefix() + v)
+	}
+	if err != nil {
+		if git.IsErrNotExist(err) {
+			return nil, ErrVersionNotExist
+		}
+		return nil, err
+	}
+	// the time of a pseudo-version must be the one of its commit
+	if isPseudo && commit.Committer.When.UTC().Format(pseudoVersionTimeFormat) != t.Format(pseudoVersionTimeFormat) {
+		return nil, ErrVersionNotExist
+	}
+	return commit, nil
+}
+
+// codeDir returns the directory of the files of the module in the commit, the modules with a
+// major version suffix may be kept in a subdirectory named after it.
+func (m *Module) codeDir(commit *git.Commit) string {
+	if len(m.Major) > 0 {
+		dir := path.Join(m.Dir, m.Major)
+		if entry, err := commit.GetTreeEntryByPath(path.Join(dir, "go.mod")); err == nil && isRegularFile(entry) {
+			return dir
+		}
+	}
+	return m.Dir
+}
+
+// GoMod returns the go.mod file of a version of the module, one is synthesized for the
+// modules at the root of the repository which do not have any.
+func (m *Module) GoMod(v string) ([]byte, error) {
+	commit, err := m.versionCommit(v)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := commit.GetTreeEntryByPath(path.Join(m.codeDir(commit), "go.mod"))
+	if err != nil || !isRegularFile(entry) {
+		if err != nil && !git.IsErrNotExist(err) {
+			return nil, err
+		}
+		if len(m.Dir) > 0 {
+			return nil, ErrVersionNotExist
+		}
+		return []byte("module " + m.Path + "\n"), nil
+	}
+	if entry.Size() > MaxGoModSize {
+		return nil, ErrModuleTooLarge
+	}
+	blob := entry.Blob()
+	if blob == nil {
+		return nil, ErrVersionNotExist
+	}
+	r, err := blob.DataAsync()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+func isRegularFile(entry *git.TreeEntry) bool {
+	return entry.Mode() == git.EntryModeBlob || entry.Mode() == git.EntryModeExec
+}
+
+// UnescapePath decodes a module path or a version escaped for the proxy protocol, the upper
+// case letters are escaped as an exclamation mark followed by the lower case letter.
+func UnescapePath(escaped string) (string, bool) {
+	var (
+		buf  strings.Builder
+		bang bool
+	)
+	for _, c := range escaped {
+		switch {
+		case bang:
+			if c < 'a' || c > 'z' {
+				return "", false
+			}
+			buf.WriteRune(c - 'a' + 'A')
+			bang = false
+		case c == '!':
+			bang = true
+		case c >= 'A' && c <= 'Z':
+			return "", false
+		default:
+			buf.WriteRune(c)
+		}
+	}
+	return buf.String(), !bang
+}
@@ -0,0 +1,201 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package goproxy
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"code.gitea.io/gitea/modules/git"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func runGit(t *testing.T, dir, date string, args ...string) string {
+	env := append(os.Environ(),
+		"GIT_AUTHOR_NAME=Gitea", "GIT_AUTHOR_EMAIL=gitea@example.com", "GIT_AUTHOR_DATE="+date,
+		"GIT_COMMITTER_NAME=Gitea", "GIT_COMMITTER_EMAIL=gitea@example.com", "GIT_COMMITTER_DATE="+date)
+	stdout, err := git.NewCommand(args...).RunInDirWithEnv(dir, env)
+	assert.NoError(t, err)
+	return stdout
+}
+
+func commitFiles(t *testing.T, dir, date string, files map[string]string) {
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(p), os.ModePerm))
+		assert.NoError(t, ioutil.WriteFile(p, []byte(content), 0644))
+	}
+	runGit(t, dir, date, "add", "--all")
+	runGit(t, dir, date, "commit", "-m", "commit")
+}
+
+// prepareTestRepo creates a repository with a module at the root tagged v1.0.0 and v1.1.0-rc.1,
+// a nested module tagged sub/v0.1.0 and a v2 module in a major version subdirectory tagged v2.0.0.
+func prepareTestRepo(t *testing.T) (*git.Repository, func()) {
+	dir, err := ioutil.TempDir("", "goproxy")
+	assert.NoError(t, err)
+	runGit(t, dir, "", "init")
+	runGit(t, dir, "", "symbolic-ref", "HEAD", "refs/heads/master")
+
+	commitFiles(t, dir, "2019-10-17T03:48:14Z", map[string]string{
+		"go.mod":             "module example.com/user/repo\n",
+		"main.go":            "package main\n",
+		"LICENSE":            "license\n",
+		"vendor/modules.txt": "# vendored\n",
+		"vendor/pkg/pkg.go":  "package pkg\n",
+		"sub/go.mod":         "module example.com/user/repo/sub\n",
+		"sub/sub.go":         "package sub\n",
+	})
+	runGit(t, dir, "", "tag", "v1.0.0")
+	runGit(t, dir, "", "tag", "sub/v0.1.0")
+	commitFiles(t, dir, "2019-10-18T03:48:14Z", map[string]string{
+		"main.go":   "package main // v1.1.0\n",
+		"v2/go.mod": "module example.com/user/repo/v2\n",
+		"v2/v2.go":  "package v2\n",
+	})
+	runGit(t, dir, "", "tag", "-a", "-m", "release candidate", "v1.1.0-rc.1")
+	runGit(t, dir, "", "tag", "v2.0.0")
+	runGit(t, dir, "", "tag", "not-a-version")
+	commitFiles(t, dir, "2019-10-19T03:48:14Z", map[string]string{
+		"main.go": "package main // master\n",
+	})
+
+	repo, err := git.OpenRepository(dir)
+	assert.NoError(t, err)
+	return repo, func() {
+		os.RemoveAll(dir)
+	}
+}
+
+func TestModuleVersions(t *testing.T) {
+	repo, cleanup := prepareTestRepo(t)
+	defer cleanup()
+
+	m, err := NewModule(repo, "example.com/user/repo", "")
+	assert.NoError(t, err)
+	versions, err := m.Versions()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"v1.0.0", "v1.1.0-rc.1"}, versions)
+
+	info, err := m.Latest("master")
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.0.0", info.Version)
+	assert.Equal(t, "2019-10-17T03:48:14Z", info.Time.Format("2006-01-02T15:04:05Z07:00"))
+
+	info, err = m.Stat("master")
+	assert.NoError(t, err)
+	assert.Regexp(t, `^v1\.1\.0-rc\.1\.0\.20191019034814-[0-9a-f]{12}$`, info.Version)
+	pseudo := info.Version
+	info, err = m.Stat(pseudo)
+	assert.NoError(t, err)
+	assert.Equal(t, pseudo, info.Version)
+
+	info, err = m.Stat("not-a-version")
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.1.0-rc.1", info.Version)
+
+	for _, query := range []string{"v1.0.1", "v2.0.0", "unknown", "v0.0.0-20191019034814-000000000000"} {
+		_, err = m.Stat(query)
+		assert.Equal(t, ErrVersionNotExist, err, query)
+	}
+
+	m, err = NewModule(repo, "example.com/user/repo/v2", "v2")
+	assert.NoError(t, err)
+	versions, err = m.Versions()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"v2.0.0"}, versions)
+
+	m, err = NewModule(repo, "example.com/user/repo/sub", "sub")
+	assert.NoError(t, err)
+	info, err = m.Latest("master")
+	assert.NoError(t, err)
+	assert.Equal(t, "v0.1.0", info.Version)
+
+	m, err = NewModule(repo, "example.com/user/repo/none", "none")
+	assert.NoError(t, err)
+	info, err = m.Latest("master")
+	assert.NoError(t, err)
+	assert.Regexp(t, `^v0\.0\.0-20191019034814-[0-9a-f]{12}$`, info.Version)
+
+	for _, subpath := range []string{"v1", "a//b", "../a", "-a"} {
+		_, err = NewModule(repo, "example.com/user/repo/"+subpath, subpath)
+		assert.Equal(t, ErrInvalidModulePath, err, subpath)
+	}
+}
+
+func TestModuleGoMod(t *testing.T) {
+	repo, cleanup := prepareTestRepo(t)
+	defer cleanup()
+
+	m, err := NewModule(repo, "example.com/user/repo/v2", "v2")
+	assert.NoError(t, err)
+	content, err := m.GoMod("v2.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, "module example.com/user/repo/v2\n", string(content))
+
+	// a subdirectory without go.mod is not a module
+	m, err = NewModule(repo, "example.com/user/repo/vendor", "vendor")
+	assert.NoError(t, err)
+	info, err := m.Latest("master")
+	assert.NoError(t, err)
+	_, err = m.GoMod(info.Version)
+	assert.Equal(t, ErrVersionNotExist, err)
+}
+
+func TestModuleZip(t *testing.T) {
+	repo, cleanup := prepareTestRepo(t)
+	defer cleanup()
+
+	listZip := func(m *Module, v string) []string {
+		p, err := m.ZipPath(v)
+		assert.NoError(t, err)
+		r, err := zip.OpenReader(p)
+		assert.NoError(t, err)
+		defer r.Close()
+		names := make([]string, 0, len(r.File))
+		for _, f := range r.File {
+			names = append(names, f.Name)
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	m, err := NewModule(repo, "example.com/user/repo", "")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"example.com/user/repo@v1.0.0/LICENSE",
+		"example.com/user/repo@v1.0.0/go.mod",
+		"example.com/user/repo@v1.0.0/main.go",
+		"example.com/user/repo@v1.0.0/vendor/modules.txt",
+	}, listZip(m, "v1.0.0"))
+	// the archive is cached
+	p, err := m.ZipPath("v1.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(repo.Path, "archives", "goproxy"), filepath.Dir(p))
+
+	m, err = NewModule(repo, "example.com/user/repo/sub", "sub")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"example.com/user/repo/sub@v0.1.0/LICENSE",
+		"example.com/user/repo/sub@v0.1.0/go.mod",
+		"example.com/user/repo/sub@v0.1.0/sub.go",
+	}, listZip(m, "v0.1.0"))
+
+	m, err = NewModule(repo, "example.com/user/repo/v2", "v2")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"example.com/user/repo/v2@v2.0.0/LICENSE",
+		"example.com/user/repo/v2@v2.0.0/go.mod",
+		"example.com/user/repo/v2@v2.0.0/v2.go",
+	}, listZip(m, "v2.0.0"))
+
+	_, err = m.ZipPath("v2.0.1")
+	assert.Equal(t, ErrVersionNotExist, err)
+}
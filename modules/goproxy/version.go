@@ -0,0 +1,230 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package goproxy
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// pseudoVersionTimeFormat is the format of the commit time in the pseudo-versions
+const pseudoVersionTimeFormat = "20060102150405"
+
+// pseudoVersionRegex matches the pseudo-versions, the versions computed from a commit
+var pseudoVersionRegex = regexp.MustCompile(`^v[0-9]+\.(0\.0-|\d+\.\d+-([^+]*\.)?0\.)(\d{14})-([0-9a-f]{12})$`)
+
+// version is a parsed semantic version, the build metadata is not supported
+type version struct {
+	major, minor, patch string
+	prerelease          []string
+}
+
+// parseVersion parses a canonical semantic version as used by the Go modules,
+// like v1.2.3 or v1.2.3-rc.1, it returns false if the version is not canonical.
+func parseVersion(v string) (*version, bool) {
+	if !strings.HasPrefix(v, "v") {
+		return nil, false
+	}
+	v = v[1:]
+	var prerelease string
+	if i := strings.IndexByte(v, '-'); i >= 0 {
+		v, prerelease = v[:i], v[i+1:]
+		if len(prerelease) == 0 {
+			return nil, false
+		}
+	}
+
+	numbers := strings.Split(v, ".")
+	if len(numbers) != 3 {
+		return nil, false
+	}
+	for _, n := range numbers {
+		if !isNumber(n) {
+			return nil, false
+		}
+	}
+	parsed := &version{major: numbers[0], minor: numbers[1], patch: numbers[2]}
+	if len(prerelease) > 0 {
+		parsed.prerelease = strings.Split(prerelease, ".")
+		for _, id := range parsed.prerelease {
+			if !isPrereleaseIdentifier(id) {
+				return nil, false
+			}
+		}
+	}
+	return parsed, true
+}
+
+// isNumber returns true if s is a decimal number without leading zero
+func isNumber(s string) bool {
+	if len(s) == 0 || (len(s) > 1 && s[0] == '0') {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func isPrereleaseIdentifier(s string) bool {
+	if len(s) == 0 {
+		return false
+	}
+	numeric := true
+	for _, c := range s {
+		switch {
+		case c >= '0' && c <= '9':
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '-':
+			numeric = false
+		default:
+			return false
+		}
+	}
+	return !numeric || isNumber(s)
+}
+
+// compareNumbers compares two decimal numbers without leading zero
+func compareNumbers(a, b string) int {
+	if len(a) != len(b) {
+		if len(a) < len(b) {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func comparePrereleases(a, b []string) int {
+	// a release has a higher precedence than its prereleases
+	switch {
+	case len(a) == 0 && len(b) == 0:
+		return 0
+	case len(a) == 0:
+		return 1
+	case len(b) == 0:
+		return -1
+	}
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] == b[i] {
+			continue
+		}
+		aNumeric, bNumeric := isNumber(a[i]), isNumber(b[i])
+		switch {
+		case aNumeric && bNumeric:
+			return compareNumbers(a[i], b[i])
+		case aNumeric:
+			return -1
+		case bNumeric:
+			return 1
+		}
+		return strings.Compare(a[i], b[i])
+	}
+	// a larger set of identifiers has a higher precedence when the others are equal
+	switch {
+	case len(a) < len(b):
+		return -1
+	case len(a) > len(b):
+		return 1
+	}
+	return 0
+}
+
+func (v *version) compare(other *version) int {
+	if c := compareNumbers(v.major, other.major); c != 0 {
+		return c
+	}
+	if c := compareNumbers(v.minor, other.minor); c != 0 {
+		return c
+	}
+	if c := compareNumbers(v.patch, other.patch); c != 0 {
+		return c
+	}
+	return comparePrereleases(v.prerelease, other.prerelease)
+}
+
+// IsValidVersion returns true if v is a canonical semantic version, like v1.2.3 or v1.2.3-rc.1
+func IsValidVersion(v string) bool {
+	_, ok := parseVersion(v)
+	return ok
+}
+
+// IsPseudoVersion returns true if v is a pseudo-version, like v0.0.0-20191017034814-abcdefabcdef
+func IsPseudoVersion(v string) bool {
+	return IsValidVersion(v) && pseudoVersionRegex.MatchString(v)
+}
+
+// IsPrerelease returns true if v is a prerelease version, the pseudo-versions are prereleases
+func IsPrerelease(v string) bool {
+	parsed, ok := parseVersion(v)
+	return ok && len(parsed.prerelease) > 0
+}
+
+// CompareVersions returns -1, 0 or 1 if a is lower, equal or higher than b,
+// the invalid versions are lower than all the valid ones.
+func CompareVersions(a, b string) int {
+	va, okA := parseVersion(a)
+	vb, okB := parseVersion(b)
+	switch {
+	case !okA && !okB:
+		return strings.Compare(a, b)
+	case !okA:
+		return -1
+	case !okB:
+		return 1
+	}
+	return va.compare(vb)
+}
+
+// majorVersion returns the major version of a valid version, like v2
+func majorVersion(v string) string {
+	parsed, _ := parseVersion(v)
+	return "v" + parsed.major
+}
+
+// pseudoVersion returns the pseudo-version of a commit, base is the highest version tagged
+// on an ancestor of the commit or empty if there is none, major is used without base.
+func pseudoVersion(major, base string, t time.Time, commitID string) string {
+	suffix := t.UTC().Format(pseudoVersionTimeFormat) + "-" + commitID[:12]
+	if len(base) == 0 {
+		if len(major) == 0 {
+			major = "v0"
+		}
+		return major + ".0.0-" + suffix
+	}
+	if IsPrerelease(base) {
+		return base + ".0." + suffix
+	}
+	parsed, _ := parseVersion(base)
+	return "v" + parsed.major + "." + parsed.minor + "." + incrementNumber(parsed.patch) + "-0." + suffix
+}
+
+// incrementNumber returns n+1 of a decimal number of any length
+func incrementNumber(n string) string {
+	digits := []byte(n)
+	for i := len(digits) - 1; i >= 0; i-- {
+		if digits[i] < '9' {
+			digits[i]++
+			return string(digits)
+		}
+		digits[i] = '0'
+	}
+	return "1" + string(digits)
+}
+
+// parsePseudoVersion returns the commit time and the commit id prefix of a pseudo-version
+func parsePseudoVersion(v string) (time.Time, string, bool) {
+	if !IsPseudoVersion(v) {
+		return time.Time{}, "", false
+	}
+	matches := pseudoVersionRegex.FindStringSubmatch(v)
+	t, err := time.Parse(pseudoVersionTimeFormat, matches[3])
+	if err != nil {
+		return time.Time{}, "", false
+	}
+	return t, matches[4], true
+}
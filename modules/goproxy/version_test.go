@@ -0,0 +1,76 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package goproxy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsValidVersion(t *testing.T) {
+	for _, v := range []string{"v0.0.0", "v1.2.3", "v10.20.30", "v1.2.3-rc.1", "v1.2.3-0.20191017034814-abcdefabcdef", "v1.0.0-alpha-1"} {
+		assert.True(t, IsValidVersion(v), v)
+	}
+	for _, v := range []string{"", "1.2.3", "v1.2", "v1.2.3.4", "v01.2.3", "v1.2.3-", "v1.2.3-01", "v1.2.3+build", "v1.2.3-rc..1", "va.b.c"} {
+		assert.False(t, IsValidVersion(v), v)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	ordered := []string{
+		"v0.1.0",
+		"v1.0.0-alpha",
+		"v1.0.0-alpha.1",
+		"v1.0.0-alpha.beta",
+		"v1.0.0-beta.2",
+		"v1.0.0-beta.11",
+		"v1.0.0-rc.1",
+		"v1.0.0",
+		"v1.2.0",
+		"v1.10.0",
+	}
+	for i := range ordered {
+		assert.Equal(t, 0, CompareVersions(ordered[i], ordered[i]))
+		for j := i + 1; j < len(ordered); j++ {
+			assert.Equal(t, -1, CompareVersions(ordered[i], ordered[j]), ordered[i]+" < "+ordered[j])
+			assert.Equal(t, 1, CompareVersions(ordered[j], ordered[i]), ordered[j]+" > "+ordered[i])
+		}
+	}
+	assert.Equal(t, -1, CompareVersions("invalid", "v0.0.0"))
+}
+
+func TestPseudoVersion(t *testing.T) {
+	commitTime := time.Date(2019, 10, 17, 3, 48, 14, 0, time.UTC)
+	commitID := "abcdefabcdef0123456789abcdefabcdef012345"
+
+	assert.Equal(t, "v0.0.0-20191017034814-abcdefabcdef", pseudoVersion("", "", commitTime, commitID))
+	assert.Equal(t, "v2.0.0-20191017034814-abcdefabcdef", pseudoVersion("v2", "", commitTime, commitID))
+	assert.Equal(t, "v1.2.4-0.20191017034814-abcdefabcdef", pseudoVersion("", "v1.2.3", commitTime, commitID))
+	assert.Equal(t, "v1.2.10-0.20191017034814-abcdefabcdef", pseudoVersion("", "v1.2.9", commitTime, commitID))
+	assert.Equal(t, "v1.2.3-rc.1.0.20191017034814-abcdefabcdef", pseudoVersion("", "v1.2.3-rc.1", commitTime, commitID))
+
+	for _, v := range []string{"v0.0.0-20191017034814-abcdefabcdef", "v1.2.4-0.20191017034814-abcdefabcdef", "v1.2.3-rc.1.0.20191017034814-abcdefabcdef"} {
+		assert.True(t, IsPseudoVersion(v), v)
+		parsedTime, id, ok := parsePseudoVersion(v)
+		assert.True(t, ok)
+		assert.True(t, commitTime.Equal(parsedTime))
+		assert.Equal(t, "abcdefabcdef", id)
+	}
+	assert.False(t, IsPseudoVersion("v1.2.3"))
+	assert.False(t, IsPseudoVersion("v1.2.3-rc.1"))
+}
+
+func TestUnescapePath(t *testing.T) {
+	p, ok := UnescapePath("example.com/!user/!my!repo")
+	assert.True(t, ok)
+	assert.Equal(t, "example.com/User/MyRepo", p)
+
+	for _, escaped := range []string{"example.com/User", "example.com/!", "example.com/!1"} {
+		_, ok = UnescapePath(escaped)
+		assert.False(t, ok, escaped)
+	}
+}
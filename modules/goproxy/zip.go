@@ -0,0 +1,183 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package goproxy
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"code.gitea.io/gitea/modules/git"
+
+	"github.com/Unknwon/com"
+)
+
+type moduleFile struct {
+	name  string
+	entry *git.TreeEntry
+}
+
+// listFiles returns the regular files of the tree recursively, the symbolic links
+// and the submodules are ignored like the Go command does.
+func listFiles(tree *git.Tree, dir string, files []moduleFile) ([]moduleFile, error) {
+	entries, err := tree.ListEntries()
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		name := path.Join(dir, entry.Name())
+		if entry.IsDir() {
+			subtree, err := tree.SubTree(entry.Name())
+			if err != nil {
+				return nil, err
+			}
+			if files, err = listFiles(subtree, name, files); err != nil {
+				return nil, err
+			}
+		} else if isRegularFile(entry) {
+			files = append(files, moduleFile{name: name, entry: entry})
+		}
+	}
+	return files, nil
+}
+
+// isVendoredPackage returns true if the file belongs to a vendored package, it matches the
+// implementation of the Go command, including its known inaccuracy, for the checksums of
+// the archives to match the ones of the modules downloaded directly from the repository.
+func isVendoredPackage(name string) bool {
+	var i int
+	if strings.HasPrefix(name, "vendor/") {
+		i += len("vendor/")
+	} else if j := strings.Index(name, "/vendor/"); j >= 0 {
+		i += len("/vendor/")
+	} else {
+		return false
+	}
+	return strings.Contains(name[i:], "/")
+}
+
+// isInNestedModule returns true if the file belongs to one of the nested modules
+func isInNestedModule(name string, nested map[string]bool) bool {
+	for dir := path.Dir(name); dir != "."; dir = path.Dir(dir) {
+		if nested[dir] {
+			return true
+		}
+	}
+	return false
+}
+
+// writeZip writes the module zip archive of the version of the commit, the files are
+// prefixed with module@version/. The vendored packages and the nested modules are excluded,
+// the license at the root of the repository is included in the modules of the subdirectories.
+func (m *Module) writeZip(commit *git.Commit, v string, w io.Writer) error {
+	dir := m.codeDir(commit)
+	tree, err := commit.SubTree(dir)
+	if err != nil {
+		if git.IsErrNotExist(err) {
+			return ErrVersionNotExist
+		}
+		return err
+	}
+	files, err := listFiles(tree, "", nil)
+	if err != nil {
+		return err
+	}
+
+	nested := make(map[string]bool)
+	for _, f := range files {
+		if path.Base(f.name) == "go.mod" && path.Dir(f.name) != "." {
+			nested[path.Dir(f.name)] = true
+		}
+	}
+	included := make([]moduleFile, 0, len(files))
+	var hasLicense bool
+	for _, f := range files {
+		if isVendoredPackage(f.name) || isInNestedModule(f.name, nested) {
+			continue
+		}
+		hasLicense = hasLicense || f.name == "LICENSE"
+		included = append(included, f)
+	}
+	if len(dir) > 0 && !hasLicense {
+		if entry, err := commit.GetTreeEntryByPath("LICENSE"); err == nil && isRegularFile(entry) {
+			included = append(included, moduleFile{name: "LICENSE", entry: entry})
+		}
+	}
+
+	var size int64
+	for _, f := range included {
+		if size += f.entry.Size(); size > MaxZipSize {
+			return ErrModuleTooLarge
+		}
+	}
+
+	zw := zip.NewWriter(w)
+	prefix := m.Path + "@" + v + "/"
+	for _, f := range included {
+		if err = writeZipFile(zw, prefix+f.name, f.entry); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func writeZipFile(zw *zip.Writer, name string, entry *git.TreeEntry) error {
+	blob := entry.Blob()
+	if blob == nil {
+		return git.ErrNotExist{ID: entry.ID.String()}
+	}
+	r, err := blob.DataAsync()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	fw, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(fw, r)
+	return err
+}
+
+// ZipPath returns the path of the module zip archive of a version of the module. The archives
+// are built on demand and cached with the other archives of the repository, they are rebuilt
+// when the tag of the version is moved to another commit.
+func (m *Module) ZipPath(v string) (string, error) {
+	commit, err := m.versionCommit(v)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256([]byte(m.Path + "@" + v + "\x00" + commit.ID.String()))
+	dir := filepath.Join(m.repo.Path, "archives", "goproxy")
+	archivePath := filepath.Join(dir, hex.EncodeToString(hash[:])+".zip")
+	if com.IsFile(archivePath) {
+		return archivePath, nil
+	}
+
+	if err = os.MkdirAll(dir, os.ModePerm); err != nil {
+		return "", err
+	}
+	tmp, err := ioutil.TempFile(dir, "tmp-")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	if err = m.writeZip(commit, v, tmp); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err = tmp.Close(); err != nil {
+		return "", err
+	}
+	return archivePath, os.Rename(tmp.Name(), archivePath)
+}
@@ -0,0 +1,307 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package issues
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	esIssueIndexerLatestVersion = 1
+	esRequestTimeout            = 30 * time.Second
+)
+
+var (
+	_ Indexer = &ElasticSearchIndexer{}
+)
+
+// ElasticSearchIndexer implements Indexer interface using an elasticsearch server.
+// The issues are stored in a versioned index which is accessed through an alias
+// named indexName, so the index can be recreated when its mapping changes.
+type ElasticSearchIndexer struct {
+	url       string
+	indexName string
+	client    *http.Client
+}
+
+// NewElasticSearchIndexer creates a new elasticsearch indexer, url is the address of
+// the elasticsearch server and may contain the credentials to access it.
+func NewElasticSearchIndexer(url, indexName string) *ElasticSearchIndexer {
+	return &ElasticSearchIndexer{
+		url:       strings.TrimSuffix(url, "/"),
+		indexName: indexName,
+		client:    &http.Client{Timeout: esRequestTimeout},
+	}
+}
+
+// versionedIndexName returns the name of the index storing the issues of the given version
+func (b *ElasticSearchIndexer) versionedIndexName(version int) string {
+	return fmt.Sprintf("%s.v%d", b.indexName, version)
+}
+
+const esIssueIndexerMapping = `{
+	"mappings": {
+		"properties": {
+			"id": {
+				"type": "long",
+				"index": true
+			},
+			"repo_id": {
+				"type": "long",
+				"index": true
+			},
+			"title": {
+				"type": "text",
+				"index": true
+			},
+			"content": {
+				"type": "text",
+				"index": true
+			},
+			"comments": {
+				"type": "text",
+				"index": true
+			}
+		}
+	}
+}`
+
+// esError represents an error returned by elasticsearch
+type esError struct {
+	Type   string `json:"type"`
+	Reason string `json:"reason"`
+}
+
+func (err *esError) Error() string {
+	return fmt.Sprintf("%s: %s", err.Type, err.Reason)
+}
+
+// do sends a request to elasticsearch and decodes the response into result if it's not nil.
+// It returns the status code of the response, errors are only returned for status codes
+// which are not expected.
+func (b *ElasticSearchIndexer) do(method, path, contentType string, body io.Reader, result interface{}, expected ...int) (int, error) {
+	req, err := http.NewRequest(method, b.url+path, body)
+	if err != nil {
+		return 0, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		if result != nil && method != http.MethodHead {
+			if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+				return resp.StatusCode, fmt.Errorf("Decode: %v", err)
+			}
+		}
+		return resp.StatusCode, nil
+	}
+
+	for _, code := range expected {
+		if resp.StatusCode == code {
+			return resp.StatusCode, nil
+		}
+	}
+
+	var errResp struct {
+		Error *esError `json:"error"`
+	}
+	data, _ := ioutil.ReadAll(resp.Body)
+	if err := json.Unmarshal(data, &errResp); err == nil && errResp.Error != nil {
+		return resp.StatusCode, fmt.Errorf("%s %s: %v", method, path, errResp.Error)
+	}
+	return resp.StatusCode, fmt.Errorf("%s %s: unexpected status %d: %s", method, path, resp.StatusCode, data)
+}
+
+func (b *ElasticSearchIndexer) doJSON(method, path string, body interface{}, result interface{}, expected ...int) (int, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	return b.do(method, path, "application/json", reader, result, expected...)
+}
+
+// Init will initial the indexer, it returns true if the index of the latest version exists
+func (b *ElasticSearchIndexer) Init() (bool, error) {
+	indexName := b.versionedIndexName(esIssueIndexerLatestVersion)
+	status, err := b.do(http.MethodHead, "/"+indexName, "", nil, nil, http.StatusNotFound)
+	if err != nil {
+		return false, err
+	}
+	if status == http.StatusOK {
+		return true, b.setAlias(indexName)
+	}
+
+	// the indexes of older versions are outdated since all the issues are indexed again,
+	// deleting them removes the alias from them
+	for version := 1; version < esIssueIndexerLatestVersion; version++ {
+		if _, err := b.do(http.MethodDelete, "/"+b.versionedIndexName(version), "", nil, nil, http.StatusNotFound); err != nil {
+			return false, err
+		}
+	}
+
+	if _, err := b.do(http.MethodPut, "/"+indexName, "application/json", strings.NewReader(esIssueIndexerMapping), nil); err != nil {
+		return false, err
+	}
+	return false, b.setAlias(indexName)
+}
+
+// setAlias makes the index accessible by the name of the indexer
+func (b *ElasticSearchIndexer) setAlias(indexName string) error {
+	_, err := b.do(http.MethodPut, "/"+indexName+"/_alias/"+b.indexName, "", nil, nil)
+	return err
+}
+
+// bulk sends the actions to the bulk api and waits for them to be searchable
+func (b *ElasticSearchIndexer) bulk(actions []interface{}) error {
+	if len(actions) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, action := range actions {
+		if err := enc.Encode(action); err != nil {
+			return err
+		}
+	}
+
+	var result struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			ID     string   `json:"_id"`
+			Status int      `json:"status"`
+			Error  *esError `json:"error"`
+		} `json:"items"`
+	}
+	if _, err := b.do(http.MethodPost, "/"+b.indexName+"/_bulk?refresh=wait_for", "application/x-ndjson", &buf, &result); err != nil {
+		return err
+	}
+	if !result.Errors {
+		return nil
+	}
+	for _, item := range result.Items {
+		for op, res := range item {
+			// deleting a missing document is not an error
+			if res.Error != nil && !(op == "delete" && res.Status == http.StatusNotFound) {
+				return fmt.Errorf("%s %s: %v", op, res.ID, res.Error)
+			}
+		}
+	}
+	return nil
+}
+
+// Index will save the index data
+func (b *ElasticSearchIndexer) Index(issues []*IndexerData) error {
+	var actions = make([]interface{}, 0, len(issues)*2)
+	for _, issue := range issues {
+		actions = append(actions,
+			map[string]interface{}{
+				"index": map[string]string{"_id": strconv.FormatInt(issue.ID, 10)},
+			},
+			map[string]interface{}{
+				"id":       issue.ID,
+				"repo_id":  issue.RepoID,
+				"title":    issue.Title,
+				"content":  issue.Content,
+				"comments": issue.Comments,
+			})
+	}
+	return b.bulk(actions)
+}
+
+// Delete deletes indexes by ids
+func (b *ElasticSearchIndexer) Delete(ids ...int64) error {
+	var actions = make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		actions = append(actions, map[string]interface{}{
+			"delete": map[string]string{"_id": strconv.FormatInt(id, 10)},
+		})
+	}
+	return b.bulk(actions)
+}
+
+// Search searches for issues by given conditions.
+// Returns the matching issue IDs
+func (b *ElasticSearchIndexer) Search(keyword string, repoID int64, limit, start int) (*SearchResult, error) {
+	query := map[string]interface{}{
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"must": map[string]interface{}{
+					"multi_match": map[string]interface{}{
+						"query":  keyword,
+						"fields": []string{"title", "content", "comments"},
+						"type":   "phrase",
+					},
+				},
+				"filter": map[string]interface{}{
+					"term": map[string]interface{}{"repo_id": repoID},
+				},
+			},
+		},
+		"sort": []interface{}{"_score", map[string]string{"id": "asc"}},
+		"from": start,
+		"size": limit,
+	}
+
+	var result struct {
+		Hits struct {
+			// Total is an object since elasticsearch 7 and a number before
+			Total json.RawMessage `json:"total"`
+			Hits  []struct {
+				ID     string `json:"_id"`
+				Source struct {
+					RepoID int64 `json:"repo_id"`
+				} `json:"_source"`
+				Score float64 `json:"_score"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if _, err := b.doJSON(http.MethodPost, "/"+b.indexName+"/_search", query, &result); err != nil {
+		return nil, err
+	}
+
+	var ret = SearchResult{
+		Hits: make([]Match, 0, len(result.Hits.Hits)),
+	}
+	var total struct {
+		Value int64 `json:"value"`
+	}
+	if err := json.Unmarshal(result.Hits.Total, &total); err == nil {
+		ret.Total = total.Value
+	} else if err := json.Unmarshal(result.Hits.Total, &ret.Total); err != nil {
+		return nil, fmt.Errorf("Unexpected total of hits %s: %v", result.Hits.Total, err)
+	}
+	for _, hit := range result.Hits.Hits {
+		id, err := strconv.ParseInt(hit.ID, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Unexpected indexer ID %s: %v", hit.ID, err)
+		}
+		ret.Hits = append(ret.Hits, Match{
+			ID:     id,
+			RepoID: hit.Source.RepoID,
+			Score:  hit.Score,
+		})
+	}
+	return &ret, nil
+}
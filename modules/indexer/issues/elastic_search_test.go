@@ -0,0 +1,276 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package issues
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// esStandIn is a minimal in-memory stand-in for the elasticsearch api used by the indexer
+type esStandIn struct {
+	mutex   sync.Mutex
+	indexes map[string]map[string]map[string]interface{}
+	aliases map[string]string
+}
+
+func newESStandIn() *httptest.Server {
+	es := &esStandIn{
+		indexes: make(map[string]map[string]map[string]interface{}),
+		aliases: make(map[string]string),
+	}
+	return httptest.NewServer(es)
+}
+
+func (es *esStandIn) resolve(name string) (map[string]map[string]interface{}, bool) {
+	if index, ok := es.aliases[name]; ok {
+		name = index
+	}
+	docs, ok := es.indexes[name]
+	return docs, ok
+}
+
+func (es *esStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	es.mutex.Lock()
+	defer es.mutex.Unlock()
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodHead:
+		if _, ok := es.indexes[parts[0]]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	case len(parts) == 1 && r.Method == http.MethodPut:
+		es.indexes[parts[0]] = make(map[string]map[string]interface{})
+		fmt.Fprint(w, `{"acknowledged":true}`)
+	case len(parts) == 1 && r.Method == http.MethodDelete:
+		if _, ok := es.indexes[parts[0]]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		delete(es.indexes, parts[0])
+		fmt.Fprint(w, `{"acknowledged":true}`)
+	case len(parts) == 3 && parts[1] == "_alias" && r.Method == http.MethodPut:
+		es.aliases[parts[2]] = parts[0]
+		fmt.Fprint(w, `{"acknowledged":true}`)
+	case len(parts) == 2 && parts[1] == "_bulk":
+		es.bulk(w, r, parts[0])
+	case len(parts) == 2 && parts[1] == "_search":
+		es.search(w, r, parts[0])
+	default:
+		http.Error(w, `{"error":{"type":"unsupported","reason":"unsupported request"}}`, http.StatusBadRequest)
+	}
+}
+
+func (es *esStandIn) bulk(w http.ResponseWriter, r *http.Request, name string) {
+	docs, ok := es.resolve(name)
+	if !ok {
+		http.Error(w, `{"error":{"type":"index_not_found_exception","reason":"no such index"}}`, http.StatusNotFound)
+		return
+	}
+
+	scanner := bufio.NewScanner(r.Body)
+	for scanner.Scan() {
+		var action map[string]struct {
+			ID string `json:"_id"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &action); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if meta, ok := action["index"]; ok {
+			var doc map[string]interface{}
+			if !scanner.Scan() || json.Unmarshal(scanner.Bytes(), &doc) != nil {
+				http.Error(w, "missing document", http.StatusBadRequest)
+				return
+			}
+			docs[meta.ID] = doc
+		} else if meta, ok := action["delete"]; ok {
+			delete(docs, meta.ID)
+		}
+	}
+	fmt.Fprint(w, `{"errors":false,"items":[]}`)
+}
+
+func (es *esStandIn) search(w http.ResponseWriter, r *http.Request, name string) {
+	docs, ok := es.resolve(name)
+	if !ok {
+		http.Error(w, `{"error":{"type":"index_not_found_exception","reason":"no such index"}}`, http.StatusNotFound)
+		return
+	}
+
+	var req struct {
+		Query struct {
+			Bool struct {
+				Must struct {
+					MultiMatch struct {
+						Query  string   `json:"query"`
+						Fields []string `json:"fields"`
+					} `json:"multi_match"`
+				} `json:"must"`
+				Filter struct {
+					Term struct {
+						RepoID float64 `json:"repo_id"`
+					} `json:"term"`
+				} `json:"filter"`
+			} `json:"bool"`
+		} `json:"query"`
+		From int `json:"from"`
+		Size int `json:"size"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	keyword := strings.ToLower(req.Query.Bool.Must.MultiMatch.Query)
+	var ids []string
+	for id, doc := range docs {
+		if doc["repo_id"] != req.Query.Bool.Filter.Term.RepoID {
+			continue
+		}
+		for _, field := range req.Query.Bool.Must.MultiMatch.Fields {
+			if strings.Contains(strings.ToLower(fmt.Sprint(doc[field])), keyword) {
+				ids = append(ids, id)
+				break
+			}
+		}
+	}
+	sort.Strings(ids)
+
+	type hit struct {
+		ID     string                 `json:"_id"`
+		Score  float64                `json:"_score"`
+		Source map[string]interface{} `json:"_source"`
+	}
+	var hits = make([]hit, 0, len(ids))
+	for i, id := range ids {
+		if i >= req.From && len(hits) < req.Size {
+			hits = append(hits, hit{ID: id, Score: 1, Source: docs[id]})
+		}
+	}
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"hits": map[string]interface{}{
+			"total": map[string]interface{}{"value": len(ids), "relation": "eq"},
+			"hits":  hits,
+		},
+	})
+}
+
+// TestElasticSearchIndexer runs against the elasticsearch server given by the environment
+// variable TEST_INDEXER_ELASTICSEARCH_URL, e.g. a local container, or against a stand-in.
+func TestElasticSearchIndexer(t *testing.T) {
+	url := os.Getenv("TEST_INDEXER_ELASTICSEARCH_URL")
+	if url == "" {
+		server := newESStandIn()
+		defer server.Close()
+		url = server.URL
+	}
+
+	indexerName := fmt.Sprintf("gitea_issues_test_%d", time.Now().UnixNano())
+	indexer := NewElasticSearchIndexer(url, indexerName)
+	defer indexer.do(http.MethodDelete, "/"+indexer.versionedIndexName(esIssueIndexerLatestVersion), "", nil, nil)
+
+	exist, err := indexer.Init()
+	assert.NoError(t, err)
+	assert.False(t, exist)
+
+	// the index is kept when gitea is restarted
+	exist, err = NewElasticSearchIndexer(url, indexerName).Init()
+	assert.NoError(t, err)
+	assert.True(t, exist)
+
+	err = indexer.Index([]*IndexerData{
+		{
+			ID:      1,
+			RepoID:  2,
+			Title:   "Issue search should support Chinese",
+			Content: "As title",
+			Comments: []string{
+				"test1",
+				"test2",
+			},
+		},
+		{
+			ID:      2,
+			RepoID:  2,
+			Title:   "CJK support could be optional",
+			Content: "Chinese Korean and Japanese should be supported but I would like it's not enabled by default",
+			Comments: []string{
+				"LGTM",
+				"Good idea",
+			},
+		},
+		{
+			ID:      3,
+			RepoID:  3,
+			Title:   "Support for another repository",
+			Content: "Not visible in repository 2",
+		},
+	})
+	assert.NoError(t, err)
+
+	var keywords = []struct {
+		Keyword string
+		IDs     []int64
+	}{
+		{
+			Keyword: "search",
+			IDs:     []int64{1},
+		},
+		{
+			Keyword: "test1",
+			IDs:     []int64{1},
+		},
+		{
+			Keyword: "test2",
+			IDs:     []int64{1},
+		},
+		{
+			Keyword: "support",
+			IDs:     []int64{1, 2},
+		},
+		{
+			Keyword: "chinese",
+			IDs:     []int64{1, 2},
+		},
+		{
+			Keyword: "help",
+			IDs:     []int64{},
+		},
+	}
+
+	search := func(keyword string) []int64 {
+		res, err := indexer.Search(keyword, 2, 10, 0)
+		assert.NoError(t, err)
+
+		var ids = make([]int64, 0, len(res.Hits))
+		for _, hit := range res.Hits {
+			assert.EqualValues(t, 2, hit.RepoID)
+			ids = append(ids, hit.ID)
+		}
+		assert.EqualValues(t, len(ids), res.Total)
+		return ids
+	}
+
+	for _, kw := range keywords {
+		assert.ElementsMatch(t, kw.IDs, search(kw.Keyword), "keyword: %s", kw.Keyword)
+	}
+
+	assert.NoError(t, indexer.Delete(1, 4))
+	assert.ElementsMatch(t, []int64{2}, search("chinese"))
+	assert.Empty(t, search("test1"))
+}
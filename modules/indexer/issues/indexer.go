@@ -64,6 +64,13 @@ func InitIssueIndexer(syncReindex bool) error {
 			return err
 		}
 		populate = !exist
+	case "elasticsearch":
+		issueIndexer = NewElasticSearchIndexer(setting.Indexer.IssueConnStr, setting.Indexer.IssueIndexerName)
+		exist, err := issueIndexer.Init()
+		if err != nil {
+			return err
+		}
+		populate = !exist
 	case "db":
 		issueIndexer = &DBIndexer{}
 		dummyQueue = true
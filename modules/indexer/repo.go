@@ -5,31 +5,13 @@
 package indexer
 
 import (
+	"sort"
 	"strings"
 
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
-
-	"github.com/blevesearch/bleve"
-	"github.com/blevesearch/bleve/analysis/analyzer/custom"
-	"github.com/blevesearch/bleve/analysis/token/camelcase"
-	"github.com/blevesearch/bleve/analysis/token/lowercase"
-	"github.com/blevesearch/bleve/analysis/token/unique"
-	"github.com/blevesearch/bleve/analysis/tokenizer/unicode"
-	"github.com/blevesearch/bleve/search/query"
-	"github.com/ethantkoenig/rupture"
-)
-
-const (
-	repoIndexerAnalyzer = "repoIndexerAnalyzer"
-	repoIndexerDocType  = "repoIndexerDocType"
-
-	repoIndexerLatestVersion = 1
 )
 
-// repoIndexer (thread-safe) index for repository contents
-var repoIndexer bleve.Index
-
 // RepoIndexerOp type of operation to perform on repo indexer
 type RepoIndexerOp int
 
@@ -43,13 +25,10 @@ const (
 
 // RepoIndexerData data stored in the repo indexer
 type RepoIndexerData struct {
-	RepoID  int64
-	Content string
-}
-
-// Type returns the document type, for bleve's mapping.Classifier interface.
-func (d *RepoIndexerData) Type() string {
-	return repoIndexerDocType
+	RepoID   int64
+	Filename string
+	Language string
+	Content  string
 }
 
 // RepoIndexerUpdate an update to the repo indexer
@@ -59,171 +38,95 @@ type RepoIndexerUpdate struct {
 	Data     *RepoIndexerData
 }
 
-// AddToFlushingBatch adds the update to the given flushing batch.
-func (update RepoIndexerUpdate) AddToFlushingBatch(batch rupture.FlushingBatch) error {
-	id := filenameIndexerID(update.Data.RepoID, update.Filepath)
-	switch update.Op {
-	case RepoIndexerOpUpdate:
-		return batch.Index(id, update.Data)
-	case RepoIndexerOpDelete:
-		return batch.Delete(id)
-	default:
-		log.Error("Unrecognized repo indexer op: %d", update.Op)
-	}
-	return nil
+// RepoSearchOptions options to search for files in the repo indexer
+type RepoSearchOptions struct {
+	// RepoIDs restricts the search to the given repositories, all repositories are searched if empty
+	RepoIDs []int64
+	Keyword string
+	// Language restricts the search to files of the given language, see highlight.FileNameToHighlightClass
+	Language string
+	// Filename restricts the search to files whose path contains the given words, e.g. "modules/indexer"
+	Filename string
+	Page     int
+	PageSize int
+}
+
+// RepoSearchResult result of performing a search in a repo
+type RepoSearchResult struct {
+	RepoID     int64
+	StartIndex int
+	EndIndex   int
+	Filename   string
+	Language   string
+	Content    string
+	// LineNumbers the 1-based line numbers of the lines containing a match
+	LineNumbers []int
+}
+
+// RepoIndexer defines an interface to index repository contents
+type RepoIndexer interface {
+	Init() (bool, error)
+	Index(updates []*RepoIndexerUpdate) error
+	Delete(repoID int64) error
+	Search(opts *RepoSearchOptions) (int64, []*RepoSearchResult, error)
 }
 
+// repoIndexer (thread-safe) index for repository contents
+var repoIndexer RepoIndexer
+
 // InitRepoIndexer initialize repo indexer
 func InitRepoIndexer(populateIndexer func() error) {
-	var err error
-	repoIndexer, err = openIndexer(setting.Indexer.RepoPath, repoIndexerLatestVersion)
+	switch setting.Indexer.RepoType {
+	case "bleve":
+		repoIndexer = NewBleveRepoIndexer(setting.Indexer.RepoPath)
+	default:
+		log.Fatal("InitRepoIndexer: unknown repo indexer type: %s", setting.Indexer.RepoType)
+	}
+
+	exist, err := repoIndexer.Init()
 	if err != nil {
 		log.Fatal("InitRepoIndexer: %v", err)
 	}
-	if repoIndexer != nil {
+	if exist {
 		return
 	}
 
-	if err = createRepoIndexer(setting.Indexer.RepoPath, repoIndexerLatestVersion); err != nil {
-		log.Fatal("CreateRepoIndexer: %v", err)
-	}
 	if err = populateIndexer(); err != nil {
 		log.Fatal("PopulateRepoIndex: %v", err)
 	}
 }
 
-// createRepoIndexer create a repo indexer if one does not already exist
-func createRepoIndexer(path string, latestVersion int) error {
-	var err error
-	docMapping := bleve.NewDocumentMapping()
-	numericFieldMapping := bleve.NewNumericFieldMapping()
-	numericFieldMapping.IncludeInAll = false
-	docMapping.AddFieldMappingsAt("RepoID", numericFieldMapping)
-
-	textFieldMapping := bleve.NewTextFieldMapping()
-	textFieldMapping.IncludeInAll = false
-	docMapping.AddFieldMappingsAt("Content", textFieldMapping)
-
-	mapping := bleve.NewIndexMapping()
-	if err = addUnicodeNormalizeTokenFilter(mapping); err != nil {
-		return err
-	} else if err = mapping.AddCustomAnalyzer(repoIndexerAnalyzer, map[string]interface{}{
-		"type":          custom.Name,
-		"char_filters":  []string{},
-		"tokenizer":     unicode.Name,
-		"token_filters": []string{unicodeNormalizeName, camelcase.Name, lowercase.Name, unique.Name},
-	}); err != nil {
-		return err
-	}
-	mapping.DefaultAnalyzer = repoIndexerAnalyzer
-	mapping.AddDocumentMapping(repoIndexerDocType, docMapping)
-	mapping.AddDocumentMapping("_all", bleve.NewDocumentDisabledMapping())
-
-	repoIndexer, err = bleve.New(path, mapping)
-	if err != nil {
-		return err
-	}
-	return rupture.WriteIndexMetadata(path, &rupture.IndexMetadata{
-		Version: latestVersion,
-	})
-}
-
-func filenameIndexerID(repoID int64, filename string) string {
-	return indexerID(repoID) + "_" + filename
-}
-
-func filenameOfIndexerID(indexerID string) string {
-	index := strings.IndexByte(indexerID, '_')
-	if index == -1 {
-		log.Error("Unexpected ID in repo indexer: %s", indexerID)
-	}
-	return indexerID[index+1:]
-}
-
-// RepoIndexerBatch batch to add updates to
-func RepoIndexerBatch() rupture.FlushingBatch {
-	return rupture.NewFlushingBatch(repoIndexer, maxBatchSize)
+// UpdateRepoIndexerFiles adds, updates or deletes files in the repo indexer
+func UpdateRepoIndexerFiles(updates []*RepoIndexerUpdate) error {
+	return repoIndexer.Index(updates)
 }
 
 // DeleteRepoFromIndexer delete all of a repo's files from indexer
 func DeleteRepoFromIndexer(repoID int64) error {
-	query := numericEqualityQuery(repoID, "RepoID")
-	searchRequest := bleve.NewSearchRequestOptions(query, 2147483647, 0, false)
-	result, err := repoIndexer.Search(searchRequest)
-	if err != nil {
-		return err
-	}
-	batch := RepoIndexerBatch()
-	for _, hit := range result.Hits {
-		if err = batch.Delete(hit.ID); err != nil {
-			return err
-		}
-	}
-	return batch.Flush()
-}
-
-// RepoSearchResult result of performing a search in a repo
-type RepoSearchResult struct {
-	RepoID     int64
-	StartIndex int
-	EndIndex   int
-	Filename   string
-	Content    string
+	return repoIndexer.Delete(repoID)
 }
 
-// SearchRepoByKeyword searches for files in the specified repo.
+// SearchRepoByKeyword searches for files in the specified repos.
 // Returns the matching file-paths
-func SearchRepoByKeyword(repoIDs []int64, keyword string, page, pageSize int) (int64, []*RepoSearchResult, error) {
-	phraseQuery := bleve.NewMatchPhraseQuery(keyword)
-	phraseQuery.FieldVal = "Content"
-	phraseQuery.Analyzer = repoIndexerAnalyzer
-
-	var indexerQuery query.Query
-	if len(repoIDs) > 0 {
-		var repoQueries = make([]query.Query, 0, len(repoIDs))
-		for _, repoID := range repoIDs {
-			repoQueries = append(repoQueries, numericEqualityQuery(repoID, "RepoID"))
-		}
-
-		indexerQuery = bleve.NewConjunctionQuery(
-			bleve.NewDisjunctionQuery(repoQueries...),
-			phraseQuery,
-		)
-	} else {
-		indexerQuery = phraseQuery
-	}
-
-	from := (page - 1) * pageSize
-	searchRequest := bleve.NewSearchRequestOptions(indexerQuery, pageSize, from, false)
-	searchRequest.Fields = []string{"Content", "RepoID"}
-	searchRequest.IncludeLocations = true
-
-	result, err := repoIndexer.Search(searchRequest)
-	if err != nil {
-		return 0, nil, err
-	}
+func SearchRepoByKeyword(opts *RepoSearchOptions) (int64, []*RepoSearchResult, error) {
+	return repoIndexer.Search(opts)
+}
 
-	searchResults := make([]*RepoSearchResult, len(result.Hits))
-	for i, hit := range result.Hits {
-		var startIndex, endIndex int = -1, -1
-		for _, locations := range hit.Locations["Content"] {
-			location := locations[0]
-			locationStart := int(location.Start)
-			locationEnd := int(location.End)
-			if startIndex < 0 || locationStart < startIndex {
-				startIndex = locationStart
-			}
-			if endIndex < 0 || locationEnd > endIndex {
-				endIndex = locationEnd
-			}
+// matchedLineNumbers returns the sorted 1-based numbers of the lines containing
+// the given byte offsets of content
+func matchedLineNumbers(content string, offsets []int) []int {
+	var lineNumbers = make([]int, 0, len(offsets))
+	var seen = make(map[int]bool, len(offsets))
+	for _, offset := range offsets {
+		if offset < 0 || offset > len(content) {
+			continue
 		}
-		searchResults[i] = &RepoSearchResult{
-			RepoID:     int64(hit.Fields["RepoID"].(float64)),
-			StartIndex: startIndex,
-			EndIndex:   endIndex,
-			Filename:   filenameOfIndexerID(hit.ID),
-			Content:    hit.Fields["Content"].(string),
+		line := 1 + strings.Count(content[:offset], "\n")
+		if !seen[line] {
+			seen[line] = true
+			lineNumbers = append(lineNumbers, line)
 		}
 	}
-	return int64(result.Total), searchResults, nil
+	sort.Ints(lineNumbers)
+	return lineNumbers
 }
@@ -0,0 +1,265 @@
+// Copyright 2017 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package indexer
+
+import (
+	"strings"
+	"unicode"
+
+	"code.gitea.io/gitea/modules/log"
+
+	"github.com/blevesearch/bleve"
+	"github.com/blevesearch/bleve/analysis/analyzer/custom"
+	"github.com/blevesearch/bleve/analysis/token/camelcase"
+	"github.com/blevesearch/bleve/analysis/token/lowercase"
+	"github.com/blevesearch/bleve/analysis/token/unique"
+	unicodeTokenizer "github.com/blevesearch/bleve/analysis/tokenizer/unicode"
+	"github.com/blevesearch/bleve/search/query"
+	"github.com/ethantkoenig/rupture"
+)
+
+const (
+	repoIndexerAnalyzer         = "repoIndexerAnalyzer"
+	repoIndexerFilenameAnalyzer = "repoIndexerFilenameAnalyzer"
+	repoIndexerDocType          = "repoIndexerDocType"
+
+	repoIndexerLatestVersion = 2
+)
+
+var (
+	_ RepoIndexer = &BleveRepoIndexer{}
+)
+
+// bleveRepoIndexerData data stored in the bleve repo indexer
+type bleveRepoIndexerData struct {
+	RepoID   int64
+	Filename string
+	Language string
+	Content  string
+}
+
+// Type returns the document type, for bleve's mapping.Classifier interface.
+func (d *bleveRepoIndexerData) Type() string {
+	return repoIndexerDocType
+}
+
+// BleveRepoIndexer implements RepoIndexer interface using a local bleve index
+type BleveRepoIndexer struct {
+	indexDir string
+	indexer  bleve.Index
+}
+
+// NewBleveRepoIndexer creates a new bleve local repo indexer
+func NewBleveRepoIndexer(indexDir string) *BleveRepoIndexer {
+	return &BleveRepoIndexer{
+		indexDir: indexDir,
+	}
+}
+
+// Init will initial the indexer, it returns true if the index already exists
+func (b *BleveRepoIndexer) Init() (bool, error) {
+	var err error
+	b.indexer, err = openIndexer(b.indexDir, repoIndexerLatestVersion)
+	if err != nil {
+		return false, err
+	}
+	if b.indexer != nil {
+		return true, nil
+	}
+
+	b.indexer, err = createRepoIndexer(b.indexDir, repoIndexerLatestVersion)
+	return false, err
+}
+
+// createRepoIndexer create a repo indexer if one does not already exist
+func createRepoIndexer(path string, latestVersion int) (bleve.Index, error) {
+	docMapping := bleve.NewDocumentMapping()
+	numericFieldMapping := bleve.NewNumericFieldMapping()
+	numericFieldMapping.IncludeInAll = false
+	docMapping.AddFieldMappingsAt("RepoID", numericFieldMapping)
+
+	textFieldMapping := bleve.NewTextFieldMapping()
+	textFieldMapping.IncludeInAll = false
+	docMapping.AddFieldMappingsAt("Content", textFieldMapping)
+	docMapping.AddFieldMappingsAt("Language", textFieldMapping)
+
+	filenameFieldMapping := bleve.NewTextFieldMapping()
+	filenameFieldMapping.IncludeInAll = false
+	filenameFieldMapping.Analyzer = repoIndexerFilenameAnalyzer
+	docMapping.AddFieldMappingsAt("Filename", filenameFieldMapping)
+
+	mapping := bleve.NewIndexMapping()
+	if err := addUnicodeNormalizeTokenFilter(mapping); err != nil {
+		return nil, err
+	} else if err = mapping.AddCustomAnalyzer(repoIndexerAnalyzer, map[string]interface{}{
+		"type":          custom.Name,
+		"char_filters":  []string{},
+		"tokenizer":     unicodeTokenizer.Name,
+		"token_filters": []string{unicodeNormalizeName, camelcase.Name, lowercase.Name, unique.Name},
+	}); err != nil {
+		return nil, err
+	} else if err = mapping.AddCustomAnalyzer(repoIndexerFilenameAnalyzer, map[string]interface{}{
+		"type":          custom.Name,
+		"char_filters":  []string{},
+		"tokenizer":     unicodeTokenizer.Name,
+		"token_filters": []string{unicodeNormalizeName, lowercase.Name},
+	}); err != nil {
+		return nil, err
+	}
+	mapping.DefaultAnalyzer = repoIndexerAnalyzer
+	mapping.AddDocumentMapping(repoIndexerDocType, docMapping)
+	mapping.AddDocumentMapping("_all", bleve.NewDocumentDisabledMapping())
+
+	index, err := bleve.New(path, mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = rupture.WriteIndexMetadata(path, &rupture.IndexMetadata{
+		Version: latestVersion,
+	}); err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+func filenameIndexerID(repoID int64, filename string) string {
+	return indexerID(repoID) + "_" + filename
+}
+
+func filenameOfIndexerID(indexerID string) string {
+	index := strings.IndexByte(indexerID, '_')
+	if index == -1 {
+		log.Error("Unexpected ID in repo indexer: %s", indexerID)
+	}
+	return indexerID[index+1:]
+}
+
+// filenameWords splits the path of a file into words, so they can be matched
+// separately, e.g. "README" in "docs/README.md"
+func filenameWords(filename string) string {
+	return strings.Join(strings.FieldsFunc(filename, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// Index adds, updates or deletes the files of the updates
+func (b *BleveRepoIndexer) Index(updates []*RepoIndexerUpdate) error {
+	batch := rupture.NewFlushingBatch(b.indexer, maxBatchSize)
+	for _, update := range updates {
+		id := filenameIndexerID(update.Data.RepoID, update.Filepath)
+		var err error
+		switch update.Op {
+		case RepoIndexerOpUpdate:
+			err = batch.Index(id, &bleveRepoIndexerData{
+				RepoID:   update.Data.RepoID,
+				Filename: filenameWords(update.Data.Filename),
+				Language: update.Data.Language,
+				Content:  update.Data.Content,
+			})
+		case RepoIndexerOpDelete:
+			err = batch.Delete(id)
+		default:
+			log.Error("Unrecognized repo indexer op: %d", update.Op)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return batch.Flush()
+}
+
+// Delete deletes all of a repo's files
+func (b *BleveRepoIndexer) Delete(repoID int64) error {
+	query := numericEqualityQuery(repoID, "RepoID")
+	searchRequest := bleve.NewSearchRequestOptions(query, 2147483647, 0, false)
+	result, err := b.indexer.Search(searchRequest)
+	if err != nil {
+		return err
+	}
+	batch := rupture.NewFlushingBatch(b.indexer, maxBatchSize)
+	for _, hit := range result.Hits {
+		if err = batch.Delete(hit.ID); err != nil {
+			return err
+		}
+	}
+	return batch.Flush()
+}
+
+// Search searches for files in the specified repos.
+// Returns the matching file-paths
+func (b *BleveRepoIndexer) Search(opts *RepoSearchOptions) (int64, []*RepoSearchResult, error) {
+	phraseQuery := bleve.NewMatchPhraseQuery(opts.Keyword)
+	phraseQuery.FieldVal = "Content"
+	phraseQuery.Analyzer = repoIndexerAnalyzer
+
+	var queries = []query.Query{phraseQuery}
+	if len(opts.RepoIDs) > 0 {
+		var repoQueries = make([]query.Query, 0, len(opts.RepoIDs))
+		for _, repoID := range opts.RepoIDs {
+			repoQueries = append(repoQueries, numericEqualityQuery(repoID, "RepoID"))
+		}
+		queries = append(queries, bleve.NewDisjunctionQuery(repoQueries...))
+	}
+	if len(opts.Language) > 0 {
+		languageQuery := bleve.NewMatchQuery(opts.Language)
+		languageQuery.FieldVal = "Language"
+		languageQuery.Analyzer = repoIndexerAnalyzer
+		queries = append(queries, languageQuery)
+	}
+	if len(opts.Filename) > 0 {
+		filenameQuery := bleve.NewMatchPhraseQuery(filenameWords(opts.Filename))
+		filenameQuery.FieldVal = "Filename"
+		filenameQuery.Analyzer = repoIndexerFilenameAnalyzer
+		queries = append(queries, filenameQuery)
+	}
+
+	var indexerQuery query.Query = phraseQuery
+	if len(queries) > 1 {
+		indexerQuery = bleve.NewConjunctionQuery(queries...)
+	}
+
+	from := (opts.Page - 1) * opts.PageSize
+	searchRequest := bleve.NewSearchRequestOptions(indexerQuery, opts.PageSize, from, false)
+	searchRequest.Fields = []string{"Content", "RepoID", "Language"}
+	searchRequest.IncludeLocations = true
+
+	result, err := b.indexer.Search(searchRequest)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	searchResults := make([]*RepoSearchResult, len(result.Hits))
+	for i, hit := range result.Hits {
+		var startIndex, endIndex int = -1, -1
+		var offsets []int
+		for _, locations := range hit.Locations["Content"] {
+			location := locations[0]
+			locationStart := int(location.Start)
+			locationEnd := int(location.End)
+			if startIndex < 0 || locationStart < startIndex {
+				startIndex = locationStart
+			}
+			if endIndex < 0 || locationEnd > endIndex {
+				endIndex = locationEnd
+			}
+			for _, location := range locations {
+				offsets = append(offsets, int(location.Start))
+			}
+		}
+		content := hit.Fields["Content"].(string)
+		language, _ := hit.Fields["Language"].(string)
+		searchResults[i] = &RepoSearchResult{
+			RepoID:      int64(hit.Fields["RepoID"].(float64)),
+			StartIndex:  startIndex,
+			EndIndex:    endIndex,
+			Filename:    filenameOfIndexerID(hit.ID),
+			Language:    language,
+			Content:     content,
+			LineNumbers: matchedLineNumbers(content, offsets),
+		}
+	}
+	return int64(result.Total), searchResults, nil
+}
@@ -0,0 +1,114 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package indexer
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBleveRepoIndexAndSearch(t *testing.T) {
+	dir := "./repos.bleve"
+	indexer := NewBleveRepoIndexer(dir)
+	defer os.RemoveAll(dir)
+
+	exist, err := indexer.Init()
+	assert.NoError(t, err)
+	assert.False(t, exist)
+
+	newUpdate := func(repoID int64, filename, language, content string) *RepoIndexerUpdate {
+		return &RepoIndexerUpdate{
+			Filepath: filename,
+			Op:       RepoIndexerOpUpdate,
+			Data: &RepoIndexerData{
+				RepoID:   repoID,
+				Filename: filename,
+				Language: language,
+				Content:  content,
+			},
+		}
+	}
+	err = indexer.Index([]*RepoIndexerUpdate{
+		newUpdate(1, "README.md", "", "# repo1\n\nDescription for repo1\n"),
+		newUpdate(1, "main.go", "go", "package main\n\n// Description of main\nfunc main() {\n}\n"),
+		newUpdate(1, "modules/indexer/repo.go", "go", "package indexer\n\n// Description of the indexer\n"),
+		newUpdate(2, "README.md", "", "Description for repo2\n"),
+	})
+	assert.NoError(t, err)
+
+	var searches = []struct {
+		Opts      RepoSearchOptions
+		Filenames []string
+	}{
+		{
+			Opts:      RepoSearchOptions{RepoIDs: []int64{1}, Keyword: "Description"},
+			Filenames: []string{"README.md", "main.go", "modules/indexer/repo.go"},
+		},
+		{
+			Opts:      RepoSearchOptions{Keyword: "Description"},
+			Filenames: []string{"README.md", "README.md", "main.go", "modules/indexer/repo.go"},
+		},
+		{
+			Opts:      RepoSearchOptions{RepoIDs: []int64{1}, Keyword: "Description", Language: "go"},
+			Filenames: []string{"main.go", "modules/indexer/repo.go"},
+		},
+		{
+			Opts:      RepoSearchOptions{RepoIDs: []int64{1}, Keyword: "Description", Filename: "modules/indexer"},
+			Filenames: []string{"modules/indexer/repo.go"},
+		},
+		{
+			Opts:      RepoSearchOptions{RepoIDs: []int64{1}, Keyword: "Description", Filename: "readme"},
+			Filenames: []string{"README.md"},
+		},
+		{
+			Opts:      RepoSearchOptions{RepoIDs: []int64{2}, Keyword: "Description", Language: "go"},
+			Filenames: []string{},
+		},
+	}
+
+	for _, search := range searches {
+		search.Opts.Page = 1
+		search.Opts.PageSize = 10
+		total, results, err := indexer.Search(&search.Opts)
+		assert.NoError(t, err)
+		assert.EqualValues(t, len(search.Filenames), total)
+
+		var filenames = make([]string, 0, len(results))
+		for _, result := range results {
+			filenames = append(filenames, result.Filename)
+		}
+		assert.ElementsMatch(t, search.Filenames, filenames, "options: %+v", search.Opts)
+	}
+
+	_, results, err := indexer.Search(&RepoSearchOptions{
+		RepoIDs:  []int64{1},
+		Keyword:  "Description",
+		Language: "go",
+		Filename: "main",
+		Page:     1,
+		PageSize: 10,
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, results, 1) {
+		assert.EqualValues(t, "go", results[0].Language)
+		assert.EqualValues(t, []int{3}, results[0].LineNumbers)
+	}
+
+	assert.NoError(t, indexer.Index([]*RepoIndexerUpdate{{
+		Filepath: "main.go",
+		Op:       RepoIndexerOpDelete,
+		Data:     &RepoIndexerData{RepoID: 1},
+	}}))
+	total, _, err := indexer.Search(&RepoSearchOptions{RepoIDs: []int64{1}, Keyword: "Description", Page: 1, PageSize: 10})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, total)
+
+	assert.NoError(t, indexer.Delete(1))
+	total, _, err = indexer.Search(&RepoSearchOptions{Keyword: "Description", Page: 1, PageSize: 10})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, total)
+}
@@ -4,11 +4,13 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"errors"
+	"hash"
 	"io"
 	"os"
-	"path/filepath"
+	"path"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/storage"
 )
 
 var (
@@ -16,68 +18,43 @@ var (
 	errSizeMismatch = errors.New("Content size does not match")
 )
 
-// ContentStore provides a simple file system based storage.
+// ContentStore provides a simple storage of LFS objects on top of an object storage.
 type ContentStore struct {
-	BasePath string
+	storage.ObjectStorage
 }
 
 // Get takes a Meta object and retrieves the content from the store, returning
 // it as an io.Reader. If fromByte > 0, the reader starts from that byte
 func (s *ContentStore) Get(meta *models.LFSMetaObject, fromByte int64) (io.ReadCloser, error) {
-	path := filepath.Join(s.BasePath, transformKey(meta.Oid))
-
-	f, err := os.Open(path)
+	f, err := s.Open(transformKey(meta.Oid))
 	if err != nil {
 		return nil, err
 	}
 	if fromByte > 0 {
-		_, err = f.Seek(fromByte, os.SEEK_CUR)
+		if _, err = f.Seek(fromByte, io.SeekCurrent); err != nil {
+			f.Close()
+			return nil, err
+		}
 	}
-	return f, err
+	return f, nil
 }
 
 // Put takes a Meta object and an io.Reader and writes the content to the store.
+// The content is verified while it is read, the storage only moves it into
+// place once it matches the Meta object so an existing object is never lost.
 func (s *ContentStore) Put(meta *models.LFSMetaObject, r io.Reader) error {
-	path := filepath.Join(s.BasePath, transformKey(meta.Oid))
-	tmpPath := path + ".tmp"
-
-	dir := filepath.Dir(path)
-	if err := os.MkdirAll(dir, 0750); err != nil {
-		return err
-	}
-
-	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0640)
-	if err != nil {
-		return err
-	}
-	defer os.Remove(tmpPath)
-
-	hash := sha256.New()
-	hw := io.MultiWriter(hash, file)
-
-	written, err := io.Copy(hw, r)
-	if err != nil {
-		file.Close()
-		return err
-	}
-	file.Close()
-
-	if written != meta.Size {
-		return errSizeMismatch
-	}
-
-	shaStr := hex.EncodeToString(hash.Sum(nil))
-	if shaStr != meta.Oid {
-		return errHashMismatch
-	}
-
-	return os.Rename(tmpPath, path)
+	_, err := s.Save(transformKey(meta.Oid), &hashingReader{
+		internal:     r,
+		expectedSize: meta.Size,
+		expectedHash: meta.Oid,
+		hash:         sha256.New(),
+	})
+	return err
 }
 
 // Exists returns true if the object exists in the content store.
 func (s *ContentStore) Exists(meta *models.LFSMetaObject) bool {
-	path := filepath.Join(s.BasePath, transformKey(meta.Oid))
-	if _, err := os.Stat(path); os.IsNotExist(err) {
+	if _, err := s.Stat(transformKey(meta.Oid)); os.IsNotExist(err) {
 		return false
 	}
 	return true
@@ -85,9 +62,7 @@ func (s *ContentStore) Exists(meta *models.LFSMetaObject) bool {
 
 // Verify returns true if the object exists in the content store and size is correct.
 func (s *ContentStore) Verify(meta *models.LFSMetaObject) (bool, error) {
-	path := filepath.Join(s.BasePath, transformKey(meta.Oid))
-
-	fi, err := os.Stat(path)
+	fi, err := s.Stat(transformKey(meta.Oid))
 	if os.IsNotExist(err) || err == nil && fi.Size() != meta.Size {
 		return false, nil
 	} else if err != nil {
@@ -97,10 +72,40 @@ func (s *ContentStore) Verify(meta *models.LFSMetaObject) (bool, error) {
 	return true, nil
 }
 
+// hashingReader fails the read at the end of its content when the content
+// does not match the expected size and hash.
+type hashingReader struct {
+	internal     io.Reader
+	currentSize  int64
+	expectedSize int64
+	hash         hash.Hash
+	expectedHash string
+}
+
+func (r *hashingReader) Read(b []byte) (int, error) {
+	n, err := r.internal.Read(b)
+	if n > 0 {
+		r.currentSize += int64(n)
+		if r.currentSize > r.expectedSize {
+			return n, errSizeMismatch
+		}
+		r.hash.Write(b[:n])
+	}
+	if err == io.EOF {
+		if r.currentSize != r.expectedSize {
+			return n, errSizeMismatch
+		}
+		if hex.EncodeToString(r.hash.Sum(nil)) != r.expectedHash {
+			return n, errHashMismatch
+		}
+	}
+	return n, err
+}
+
 func transformKey(key string) string {
 	if len(key) < 5 {
 		return key
 	}
 
-	return filepath.Join(key[0:2], key[2:4], key[4:])
+	return path.Join(key[0:2], key[2:4], key[4:])
 }
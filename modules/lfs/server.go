@@ -16,6 +16,7 @@ import (
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
 
 	"github.com/dgrijalva/jwt-go"
 	"gopkg.in/macaron.v1"
@@ -159,7 +160,7 @@ func getContentHandler(ctx *context.Context) {
 		}
 	}
 
-	contentStore := &ContentStore{BasePath: setting.LFS.ContentPath}
+	contentStore := &ContentStore{ObjectStorage: storage.LFS}
 	content, err := contentStore.Get(meta, fromByte)
 	if err != nil {
 		writeStatus(ctx, 404)
@@ -242,7 +243,7 @@ func PostHandler(ctx *context.Context) {
 	ctx.Resp.Header().Set("Content-Type", metaMediaType)
 
 	sentStatus := 202
-	contentStore := &ContentStore{BasePath: setting.LFS.ContentPath}
+	contentStore := &ContentStore{ObjectStorage: storage.LFS}
 	if meta.Existing && contentStore.Exists(meta) {
 		sentStatus = 200
 	}
@@ -293,7 +294,7 @@ func BatchHandler(ctx *context.Context) {
 			return
 		}
 
-		contentStore := &ContentStore{BasePath: setting.LFS.ContentPath}
+		contentStore := &ContentStore{ObjectStorage: storage.LFS}
 
 		meta, err := repository.GetLFSMetaObjectByOid(object.Oid)
 		if err == nil && contentStore.Exists(meta) { // Object is found and exists
@@ -326,7 +327,7 @@ func PutHandler(ctx *context.Context) {
 		return
 	}
 
-	contentStore := &ContentStore{BasePath: setting.LFS.ContentPath}
+	contentStore := &ContentStore{ObjectStorage: storage.LFS}
 	if err := contentStore.Put(meta, ctx.Req.Body().ReadCloser()); err != nil {
 		ctx.Resp.WriteHeader(500)
 		fmt.Fprintf(ctx.Resp, `{"message":"%s"}`, err)
@@ -358,7 +359,7 @@ func VerifyHandler(ctx *context.Context) {
 		return
 	}
 
-	contentStore := &ContentStore{BasePath: setting.LFS.ContentPath}
+	contentStore := &ContentStore{ObjectStorage: storage.LFS}
 	ok, err := contentStore.Verify(meta)
 	if err != nil {
 		ctx.Resp.WriteHeader(500)
@@ -502,7 +503,7 @@ func authenticate(ctx *context.Context, repository *models.Repository, authoriza
 
 	canRead := perm.CanAccess(accessMode, models.UnitTypeCode)
 	if canRead {
-		return true
+		return tokenCanAccess(ctx.AccessToken(), repository, requireWrite)
 	}
 
 	user, repo, opStr, err := parseToken(authorization)
@@ -526,6 +527,19 @@ func authenticate(ctx *context.Context, repository *models.Repository, authoriza
 	return false
 }
 
+// tokenCanAccess checks the scopes and the repository restrictions of the
+// personal access token the user is signed in with, if any
+func tokenCanAccess(token *models.AccessToken, repository *models.Repository, requireWrite bool) bool {
+	if token == nil {
+		return true
+	}
+	scope := models.AccessTokenScopeRepoRead
+	if requireWrite {
+		scope = models.AccessTokenScopeRepoWrite
+	}
+	return token.HasScope(scope) && token.CanAccessRepo(repository.ID)
+}
+
 func parseToken(authorization string) (*models.User, *models.Repository, string, error) {
 	if authorization == "" {
 		return nil, nil, "unknown", fmt.Errorf("No token")
@@ -5,14 +5,17 @@
 
 package base
 
+import "code.gitea.io/gitea/modules/structs"
+
 // MigrateOptions defines the way a repository gets migrated
 type MigrateOptions struct {
-	RemoteURL    string
-	AuthUsername string
-	AuthPassword string
-	Name         string
-	Description  string
-	OriginalURL  string
+	RemoteURL      string
+	AuthUsername   string
+	AuthPassword   string
+	Name           string
+	Description    string
+	OriginalURL    string
+	GitServiceType structs.GitServiceType
 
 	Wiki         bool
 	Issues       bool
@@ -15,5 +15,6 @@ type Uploader interface {
 	CreateIssues(issues ...*Issue) error
 	CreateComments(comments ...*Comment) error
 	CreatePullRequests(prs ...*PullRequest) error
+	Resume() error
 	Rollback() error
 }
@@ -10,7 +10,6 @@ import (
 	"io"
 	"net/http"
 	"os"
-	"path"
 	"path/filepath"
 	"strings"
 	"sync"
@@ -21,6 +20,8 @@ import (
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/migrations/base"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
+	api "code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/modules/util"
 
 	gouuid "github.com/satori/go.uuid"
@@ -79,7 +80,7 @@ func (g *GiteaLocalUploader) CreateRepo(repo *base.Repository, opts base.Migrate
 		return err
 	}
 
-	r, err := models.MigrateRepository(g.doer, owner, models.MigrateRepoOptions{
+	var migrateOpts = models.MigrateRepoOptions{
 		Name:                 g.repoName,
 		Description:          repo.Description,
 		OriginalURL:          repo.OriginalURL,
@@ -88,7 +89,17 @@ func (g *GiteaLocalUploader) CreateRepo(repo *base.Repository, opts base.Migrate
 		IsPrivate:            repo.IsPrivate,
 		Wiki:                 opts.Wiki,
 		SyncReleasesWithTags: !opts.Releases, // if didn't get releases, then sync them from tags
-	})
+	}
+
+	var r *models.Repository
+	if g.repo != nil {
+		// the repository has been created when the migration was queued
+		g.repo.Description = repo.Description
+		g.repo.OriginalURL = repo.OriginalURL
+		r, err = models.MigrateRepositoryGitData(g.doer, owner, g.repo, migrateOpts)
+	} else {
+		r, err = models.MigrateRepository(g.doer, owner, migrateOpts)
+	}
 	g.repo = r
 	if err != nil {
 		return err
@@ -97,10 +108,49 @@ func (g *GiteaLocalUploader) CreateRepo(repo *base.Repository, opts base.Migrate
 	return err
 }
 
+// Resume loads the milestones, labels and issues which have already been migrated
+// into the repository, so that an interrupted migration can be continued without
+// creating them twice.
+func (g *GiteaLocalUploader) Resume() error {
+	var err error
+	if g.gitRepo, err = git.OpenRepository(g.repo.RepoPath()); err != nil {
+		return err
+	}
+
+	milestones, err := models.GetMilestonesByRepoID(g.repo.ID, api.StateAll)
+	if err != nil {
+		return err
+	}
+	for _, ms := range milestones {
+		g.milestones.Store(ms.Name, ms.ID)
+	}
+
+	labels, err := models.GetLabelsByRepoID(g.repo.ID, "")
+	if err != nil {
+		return err
+	}
+	for _, lb := range labels {
+		g.labels.Store(lb.Name, lb)
+	}
+
+	issues, err := models.GetIssueIndexIDs(g.repo.ID)
+	if err != nil {
+		return err
+	}
+	for index, id := range issues {
+		g.issues.Store(index, id)
+	}
+	return nil
+}
+
 // CreateMilestones creates milestones
 func (g *GiteaLocalUploader) CreateMilestones(milestones ...*base.Milestone) error {
 	var mss = make([]*models.Milestone, 0, len(milestones))
 	for _, milestone := range milestones {
+		if _, ok := g.milestones.Load(milestone.Title); ok {
+			continue
+		}
+
 		var deadline util.TimeStamp
 		if milestone.Deadline != nil {
 			deadline = util.TimeStamp(milestone.Deadline.Unix())
@@ -136,6 +186,10 @@ func (g *GiteaLocalUploader) CreateMilestones(milestones ...*base.Milestone) err
 func (g *GiteaLocalUploader) CreateLabels(labels ...*base.Label) error {
 	var lbs = make([]*models.Label, 0, len(labels))
 	for _, label := range labels {
+		if _, ok := g.labels.Load(label.Name); ok {
+			continue
+		}
+
 		lbs = append(lbs, &models.Label{
 			RepoID:      g.repo.ID,
 			Name:        label.Name,
@@ -158,6 +212,14 @@ func (g *GiteaLocalUploader) CreateLabels(labels ...*base.Label) error {
 func (g *GiteaLocalUploader) CreateReleases(releases ...*base.Release) error {
 	var rels = make([]*models.Release, 0, len(releases))
 	for _, release := range releases {
+		// the release may have been migrated before the migration was resumed
+		isExist, err := models.IsReleaseExist(g.repo.ID, release.TagName)
+		if err != nil {
+			return err
+		} else if isExist {
+			continue
+		}
+
 		var rel = models.Release{
 			RepoID:       g.repo.ID,
 			PublisherID:  g.doer.ID,
@@ -199,19 +261,8 @@ func (g *GiteaLocalUploader) CreateReleases(releases ...*base.Release) error {
 			}
 			defer resp.Body.Close()
 
-			localPath := attach.LocalPath()
-			if err = os.MkdirAll(path.Dir(localPath), os.ModePerm); err != nil {
-				return fmt.Errorf("MkdirAll: %v", err)
-			}
-
-			fw, err := os.Create(localPath)
-			if err != nil {
-				return fmt.Errorf("Create: %v", err)
-			}
-			defer fw.Close()
-
-			if _, err := io.Copy(fw, resp.Body); err != nil {
-				return err
+			if _, err = storage.Attachments.Save(attach.RelativePath(), resp.Body); err != nil {
+				return fmt.Errorf("Save: %v", err)
 			}
 
 			rel.Attachments = append(rel.Attachments, &attach)
@@ -231,6 +282,10 @@ func (g *GiteaLocalUploader) CreateReleases(releases ...*base.Release) error {
 func (g *GiteaLocalUploader) CreateIssues(issues ...*base.Issue) error {
 	var iss = make([]*models.Issue, 0, len(issues))
 	for _, issue := range issues {
+		if _, ok := g.issues.Load(issue.Number); ok {
+			continue
+		}
+
 		var labels []*models.Label
 		for _, label := range issue.Labels {
 			lb, ok := g.labels.Load(label.Name)
@@ -315,6 +370,10 @@ func (g *GiteaLocalUploader) CreateComments(comments ...*base.Comment) error {
 func (g *GiteaLocalUploader) CreatePullRequests(prs ...*base.PullRequest) error {
 	var gprs = make([]*models.PullRequest, 0, len(prs))
 	for _, pr := range prs {
+		if _, ok := g.issues.Load(pr.Number); ok {
+			continue
+		}
+
 		gpr, err := g.newPullRequest(pr)
 		if err != nil {
 			return err
@@ -14,6 +14,7 @@ import (
 
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/migrations/base"
+	"code.gitea.io/gitea/modules/structs"
 
 	"github.com/google/go-github/v24/github"
 	"golang.org/x/oauth2"
@@ -39,6 +40,9 @@ func (f *GithubDownloaderV3Factory) Match(opts base.MigrateOptions) (bool, error
 		return false, err
 	}
 
+	if opts.GitServiceType != structs.NotMigrated {
+		return opts.GitServiceType == structs.GithubService && opts.AuthUsername != "", nil
+	}
 	return u.Host == "github.com" && opts.AuthUsername != "", nil
 }
 
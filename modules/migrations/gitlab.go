@@ -0,0 +1,616 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/migrations/base"
+	"code.gitea.io/gitea/modules/structs"
+)
+
+var (
+	_ base.Downloader        = &GitlabDownloader{}
+	_ base.DownloaderFactory = &GitlabDownloaderFactory{}
+)
+
+func init() {
+	RegisterDownloaderFactory(&GitlabDownloaderFactory{})
+}
+
+// GitlabDownloaderFactory defines a gitlab downloader factory
+type GitlabDownloaderFactory struct {
+}
+
+// Match returns ture if the migration remote URL matched this downloader factory
+func (f *GitlabDownloaderFactory) Match(opts base.MigrateOptions) (bool, error) {
+	u, err := url.Parse(opts.RemoteURL)
+	if err != nil {
+		return false, err
+	}
+
+	if opts.GitServiceType != structs.NotMigrated {
+		return opts.GitServiceType == structs.GitlabService, nil
+	}
+	return u.Host == "gitlab.com", nil
+}
+
+// New returns a Downloader related to this factory according MigrateOptions
+func (f *GitlabDownloaderFactory) New(opts base.MigrateOptions) (base.Downloader, error) {
+	u, err := url.Parse(opts.RemoteURL)
+	if err != nil {
+		return nil, err
+	}
+
+	projectPath := strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
+	baseURL := u.Scheme + "://" + u.Host
+
+	// A personal access token can be given either as the password or as the username
+	token := opts.AuthPassword
+	if token == "" {
+		token = opts.AuthUsername
+	}
+
+	log.Trace("Create gitlab downloader: %s/%s", baseURL, projectPath)
+
+	downloader := NewGitlabDownloader(baseURL, projectPath, token)
+	downloader.cloneURL = opts.RemoteURL
+	return downloader, nil
+}
+
+// GitlabDownloader implements a Downloader interface to get repository informations
+// from gitlab via its REST API v4
+type GitlabDownloader struct {
+	client      *http.Client
+	baseURL     string
+	projectPath string
+	token       string
+	cloneURL    string
+
+	project       *gitlabProject
+	maxIssueIndex int64
+	issueIndexSet bool
+}
+
+// NewGitlabDownloader creates a gitlab Downloader via gitlab API v4, baseURL is the
+// root URL of the gitlab instance and projectPath the full path of the project
+func NewGitlabDownloader(baseURL, projectPath, token string) *GitlabDownloader {
+	return &GitlabDownloader{
+		client:      &http.Client{Timeout: time.Minute},
+		baseURL:     strings.TrimSuffix(baseURL, "/"),
+		projectPath: projectPath,
+		token:       token,
+	}
+}
+
+type gitlabUser struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	Email    string `json:"public_email"`
+}
+
+type gitlabNamespace struct {
+	FullPath string `json:"full_path"`
+}
+
+type gitlabProject struct {
+	ID                int64           `json:"id"`
+	Path              string          `json:"path"`
+	PathWithNamespace string          `json:"path_with_namespace"`
+	Description       string          `json:"description"`
+	Visibility        string          `json:"visibility"`
+	WebURL            string          `json:"web_url"`
+	HTTPURLToRepo     string          `json:"http_url_to_repo"`
+	Namespace         gitlabNamespace `json:"namespace"`
+}
+
+type gitlabMilestone struct {
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	State       string     `json:"state"`
+	DueDate     string     `json:"due_date"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   *time.Time `json:"updated_at"`
+}
+
+type gitlabLabel struct {
+	Name        string `json:"name"`
+	Color       string `json:"color"`
+	Description string `json:"description"`
+}
+
+type gitlabReleaseLink struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+type gitlabRelease struct {
+	TagName     string     `json:"tag_name"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ReleasedAt  *time.Time `json:"released_at"`
+	Commit      struct {
+		ID string `json:"id"`
+	} `json:"commit"`
+	Assets struct {
+		Links []gitlabReleaseLink `json:"links"`
+	} `json:"assets"`
+}
+
+type gitlabIssue struct {
+	IID              int64            `json:"iid"`
+	Title            string           `json:"title"`
+	Description      string           `json:"description"`
+	State            string           `json:"state"`
+	Author           *gitlabUser      `json:"author"`
+	Labels           []string         `json:"labels"`
+	Milestone        *gitlabMilestone `json:"milestone"`
+	DiscussionLocked *bool            `json:"discussion_locked"`
+	CreatedAt        time.Time        `json:"created_at"`
+	ClosedAt         *time.Time       `json:"closed_at"`
+	Upvotes          int              `json:"upvotes"`
+	Downvotes        int              `json:"downvotes"`
+	WebURL           string           `json:"web_url"`
+	MergedAt         *time.Time       `json:"merged_at"`
+	MergeCommitSHA   string           `json:"merge_commit_sha"`
+	SHA              string           `json:"sha"`
+	SourceBranch     string           `json:"source_branch"`
+	TargetBranch     string           `json:"target_branch"`
+	SourceProjectID  int64            `json:"source_project_id"`
+	TargetProjectID  int64            `json:"target_project_id"`
+	DiffRefs         *gitlabDiffRefs  `json:"diff_refs"`
+}
+
+type gitlabDiffRefs struct {
+	BaseSHA string `json:"base_sha"`
+	HeadSHA string `json:"head_sha"`
+}
+
+type gitlabNote struct {
+	Body      string      `json:"body"`
+	Author    *gitlabUser `json:"author"`
+	CreatedAt time.Time   `json:"created_at"`
+	System    bool        `json:"system"`
+}
+
+// get requests an API path of the gitlab instance and decodes the JSON response into v,
+// it returns the next page as announced by gitlab or 0 if this is the last page
+func (g *GitlabDownloader) get(path string, query url.Values, v interface{}) (int, error) {
+	u := g.baseURL + "/api/v4/" + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return 0, err
+	}
+	if g.token != "" {
+		req.Header.Set("PRIVATE-TOKEN", g.token)
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return 0, fmt.Errorf("GET %s: %s %s", path, resp.Status, strings.TrimSpace(string(body)))
+	}
+	if err = json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return 0, fmt.Errorf("Decode %s: %v", path, err)
+	}
+
+	nextPage, _ := strconv.Atoi(resp.Header.Get("X-Next-Page"))
+	return nextPage, nil
+}
+
+func (g *GitlabDownloader) projectAPIPath(projectID string) string {
+	return "projects/" + url.PathEscape(projectID)
+}
+
+func (g *GitlabDownloader) getProject() (*gitlabProject, error) {
+	if g.project != nil {
+		return g.project, nil
+	}
+	var project gitlabProject
+	if _, err := g.get(g.projectAPIPath(g.projectPath), nil, &project); err != nil {
+		return nil, err
+	}
+	g.project = &project
+	return g.project, nil
+}
+
+// GetRepoInfo returns a repository information
+func (g *GitlabDownloader) GetRepoInfo() (*base.Repository, error) {
+	project, err := g.getProject()
+	if err != nil {
+		return nil, err
+	}
+
+	cloneURL := g.cloneURL
+	if cloneURL == "" {
+		cloneURL = project.HTTPURLToRepo
+	}
+	return &base.Repository{
+		Owner:       project.Namespace.FullPath,
+		Name:        project.Path,
+		IsPrivate:   project.Visibility != "public",
+		Description: project.Description,
+		OriginalURL: project.WebURL,
+		CloneURL:    cloneURL,
+	}, nil
+}
+
+// GetMilestones returns milestones
+func (g *GitlabDownloader) GetMilestones() ([]*base.Milestone, error) {
+	var perPage = 100
+	var milestones = make([]*base.Milestone, 0, perPage)
+	for page := 1; page > 0; {
+		var ms []*gitlabMilestone
+		next, err := g.get(g.projectAPIPath(g.projectPath)+"/milestones", url.Values{
+			"page":     {strconv.Itoa(page)},
+			"per_page": {strconv.Itoa(perPage)},
+		}, &ms)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, m := range ms {
+			var deadline *time.Time
+			if m.DueDate != "" {
+				if due, err := time.Parse("2006-01-02", m.DueDate); err == nil {
+					deadline = &due
+				}
+			}
+			var state = "open"
+			var closed *time.Time
+			if m.State == "closed" {
+				state = "closed"
+				closed = m.UpdatedAt
+			}
+			milestones = append(milestones, &base.Milestone{
+				Title:       m.Title,
+				Description: m.Description,
+				Deadline:    deadline,
+				State:       state,
+				Created:     m.CreatedAt,
+				Updated:     m.UpdatedAt,
+				Closed:      closed,
+			})
+		}
+		page = next
+	}
+	return milestones, nil
+}
+
+// GetLabels returns labels
+func (g *GitlabDownloader) GetLabels() ([]*base.Label, error) {
+	var perPage = 100
+	var labels = make([]*base.Label, 0, perPage)
+	for page := 1; page > 0; {
+		var ls []*gitlabLabel
+		next, err := g.get(g.projectAPIPath(g.projectPath)+"/labels", url.Values{
+			"page":     {strconv.Itoa(page)},
+			"per_page": {strconv.Itoa(perPage)},
+		}, &ls)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, label := range ls {
+			labels = append(labels, &base.Label{
+				Name:        label.Name,
+				Color:       strings.TrimPrefix(label.Color, "#"),
+				Description: label.Description,
+			})
+		}
+		page = next
+	}
+	return labels, nil
+}
+
+// GetReleases returns releases
+func (g *GitlabDownloader) GetReleases() ([]*base.Release, error) {
+	var perPage = 100
+	var releases = make([]*base.Release, 0, perPage)
+	for page := 1; page > 0; {
+		var rels []*gitlabRelease
+		next, err := g.get(g.projectAPIPath(g.projectPath)+"/releases", url.Values{
+			"page":     {strconv.Itoa(page)},
+			"per_page": {strconv.Itoa(perPage)},
+		}, &rels)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, rel := range rels {
+			published := rel.CreatedAt
+			if rel.ReleasedAt != nil {
+				published = *rel.ReleasedAt
+			}
+			r := &base.Release{
+				TagName:         rel.TagName,
+				TargetCommitish: rel.Commit.ID,
+				Name:            rel.Name,
+				Body:            rel.Description,
+				Created:         rel.CreatedAt,
+				Published:       published,
+			}
+			for _, link := range rel.Assets.Links {
+				var size, downloadCount int
+				r.Assets = append(r.Assets, base.ReleaseAsset{
+					URL:           link.URL,
+					Name:          link.Name,
+					Size:          &size,
+					DownloadCount: &downloadCount,
+					Created:       rel.CreatedAt,
+					Updated:       rel.CreatedAt,
+				})
+			}
+			releases = append(releases, r)
+		}
+		page = next
+	}
+	return releases, nil
+}
+
+func convertGitlabReactions(upvotes, downvotes int) *base.Reactions {
+	if upvotes == 0 && downvotes == 0 {
+		return nil
+	}
+	return &base.Reactions{
+		TotalCount: upvotes + downvotes,
+		PlusOne:    upvotes,
+		MinusOne:   downvotes,
+	}
+}
+
+func convertGitlabLabels(names []string) []*base.Label {
+	var labels = make([]*base.Label, 0, len(names))
+	for _, name := range names {
+		labels = append(labels, &base.Label{Name: name})
+	}
+	return labels
+}
+
+func convertGitlabState(state string) string {
+	if state == "opened" {
+		return "open"
+	}
+	return "closed"
+}
+
+// getMaxIssueIndex returns the highest index of the issues of the project. Issues
+// and merge requests are numbered separately on gitlab, so the indexes of merge
+// requests are shifted by it to not collide with the indexes of the issues.
+func (g *GitlabDownloader) getMaxIssueIndex() (int64, error) {
+	if g.issueIndexSet {
+		return g.maxIssueIndex, nil
+	}
+	var issues []*gitlabIssue
+	if _, err := g.get(g.projectAPIPath(g.projectPath)+"/issues", url.Values{
+		"scope":    {"all"},
+		"order_by": {"created_at"},
+		"sort":     {"desc"},
+		"per_page": {"1"},
+	}, &issues); err != nil {
+		return 0, err
+	}
+	if len(issues) > 0 {
+		g.maxIssueIndex = issues[0].IID
+	}
+	g.issueIndexSet = true
+	return g.maxIssueIndex, nil
+}
+
+// GetIssues returns issues according start and limit
+func (g *GitlabDownloader) GetIssues(page, perPage int) ([]*base.Issue, bool, error) {
+	var issues []*gitlabIssue
+	if _, err := g.get(g.projectAPIPath(g.projectPath)+"/issues", url.Values{
+		"scope":    {"all"},
+		"state":    {"all"},
+		"order_by": {"created_at"},
+		"sort":     {"asc"},
+		"page":     {strconv.Itoa(page)},
+		"per_page": {strconv.Itoa(perPage)},
+	}, &issues); err != nil {
+		return nil, false, fmt.Errorf("error while listing issues: %v", err)
+	}
+
+	var allIssues = make([]*base.Issue, 0, perPage)
+	for _, issue := range issues {
+		var milestone string
+		if issue.Milestone != nil {
+			milestone = issue.Milestone.Title
+		}
+		var poster gitlabUser
+		if issue.Author != nil {
+			poster = *issue.Author
+		}
+		allIssues = append(allIssues, &base.Issue{
+			Title:       issue.Title,
+			Number:      issue.IID,
+			PosterID:    poster.ID,
+			PosterName:  poster.Username,
+			PosterEmail: poster.Email,
+			Content:     issue.Description,
+			Milestone:   milestone,
+			State:       convertGitlabState(issue.State),
+			Created:     issue.CreatedAt,
+			Labels:      convertGitlabLabels(issue.Labels),
+			Reactions:   convertGitlabReactions(issue.Upvotes, issue.Downvotes),
+			Closed:      issue.ClosedAt,
+			IsLocked:    issue.DiscussionLocked != nil && *issue.DiscussionLocked,
+		})
+	}
+
+	return allIssues, len(issues) < perPage, nil
+}
+
+// GetComments returns comments according issueNumber
+func (g *GitlabDownloader) GetComments(issueNumber int64) ([]*base.Comment, error) {
+	maxIssueIndex, err := g.getMaxIssueIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	notesPath := fmt.Sprintf("%s/issues/%d/notes", g.projectAPIPath(g.projectPath), issueNumber)
+	if issueNumber > maxIssueIndex {
+		notesPath = fmt.Sprintf("%s/merge_requests/%d/notes", g.projectAPIPath(g.projectPath), issueNumber-maxIssueIndex)
+	}
+
+	var perPage = 100
+	var allComments = make([]*base.Comment, 0, perPage)
+	for page := 1; page > 0; {
+		var notes []*gitlabNote
+		next, err := g.get(notesPath, url.Values{
+			"order_by": {"created_at"},
+			"sort":     {"asc"},
+			"page":     {strconv.Itoa(page)},
+			"per_page": {strconv.Itoa(perPage)},
+		}, &notes)
+		if err != nil {
+			return nil, fmt.Errorf("error while listing notes: %v", err)
+		}
+
+		for _, note := range notes {
+			// system notes record events like label changes rather than comments
+			if note.System {
+				continue
+			}
+			var poster gitlabUser
+			if note.Author != nil {
+				poster = *note.Author
+			}
+			allComments = append(allComments, &base.Comment{
+				IssueIndex:  issueNumber,
+				PosterID:    poster.ID,
+				PosterName:  poster.Username,
+				PosterEmail: poster.Email,
+				Content:     note.Body,
+				Created:     note.CreatedAt,
+			})
+		}
+		page = next
+	}
+	return allComments, nil
+}
+
+func (g *GitlabDownloader) getProjectByID(id int64, cache map[int64]*gitlabProject) *gitlabProject {
+	if project, ok := cache[id]; ok {
+		return project
+	}
+	var project gitlabProject
+	if _, err := g.get(g.projectAPIPath(strconv.FormatInt(id, 10)), nil, &project); err != nil {
+		log.Warn("Unable to get gitlab project %d: %v", id, err)
+		cache[id] = nil
+		return nil
+	}
+	cache[id] = &project
+	return &project
+}
+
+// GetPullRequests returns pull requests according page and perPage
+func (g *GitlabDownloader) GetPullRequests(page, perPage int) ([]*base.PullRequest, error) {
+	project, err := g.getProject()
+	if err != nil {
+		return nil, err
+	}
+	maxIssueIndex, err := g.getMaxIssueIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	var mrs []*gitlabIssue
+	if _, err := g.get(g.projectAPIPath(g.projectPath)+"/merge_requests", url.Values{
+		"scope":    {"all"},
+		"state":    {"all"},
+		"order_by": {"created_at"},
+		"sort":     {"asc"},
+		"page":     {strconv.Itoa(page)},
+		"per_page": {strconv.Itoa(perPage)},
+	}, &mrs); err != nil {
+		return nil, fmt.Errorf("error while listing merge requests: %v", err)
+	}
+
+	var projects = map[int64]*gitlabProject{project.ID: project}
+	var allPRs = make([]*base.PullRequest, 0, perPage)
+	for _, mr := range mrs {
+		// the list does not contain the base commit of the merge request
+		var detail gitlabIssue
+		if _, err := g.get(fmt.Sprintf("%s/merge_requests/%d", g.projectAPIPath(g.projectPath), mr.IID), nil, &detail); err != nil {
+			return nil, fmt.Errorf("error while getting merge request %d: %v", mr.IID, err)
+		}
+		var baseSHA, headSHA = "", mr.SHA
+		if detail.DiffRefs != nil {
+			baseSHA = detail.DiffRefs.BaseSHA
+			if detail.DiffRefs.HeadSHA != "" {
+				headSHA = detail.DiffRefs.HeadSHA
+			}
+		}
+
+		var milestone string
+		if mr.Milestone != nil {
+			milestone = mr.Milestone.Title
+		}
+		var poster gitlabUser
+		if mr.Author != nil {
+			poster = *mr.Author
+		}
+		closed := mr.ClosedAt
+		if closed == nil && mr.State == "merged" {
+			closed = mr.MergedAt
+		}
+
+		head := base.PullRequestBranch{
+			Ref: mr.SourceBranch,
+			SHA: headSHA,
+		}
+		if source := g.getProjectByID(mr.SourceProjectID, projects); source != nil {
+			head.OwnerName = source.Namespace.FullPath
+			head.RepoName = source.Path
+			head.CloneURL = source.HTTPURLToRepo
+		}
+
+		allPRs = append(allPRs, &base.PullRequest{
+			Title:          mr.Title,
+			Number:         maxIssueIndex + mr.IID,
+			PosterName:     poster.Username,
+			PosterID:       poster.ID,
+			PosterEmail:    poster.Email,
+			Content:        mr.Description,
+			Milestone:      milestone,
+			State:          convertGitlabState(mr.State),
+			Created:        mr.CreatedAt,
+			Closed:         closed,
+			Labels:         convertGitlabLabels(mr.Labels),
+			Merged:         mr.State == "merged",
+			MergeCommitSHA: mr.MergeCommitSHA,
+			MergedTime:     mr.MergedAt,
+			IsLocked:       mr.DiscussionLocked != nil && *mr.DiscussionLocked,
+			Head:           head,
+			Base: base.PullRequestBranch{
+				Ref:       mr.TargetBranch,
+				SHA:       baseSHA,
+				RepoName:  project.Path,
+				OwnerName: project.Namespace.FullPath,
+			},
+			PatchURL: mr.WebURL + ".patch",
+		})
+	}
+
+	return allPRs, nil
+}
@@ -7,6 +7,7 @@ package migrations
 
 import (
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/migrations/base"
 )
@@ -23,51 +24,164 @@ func RegisterDownloaderFactory(factory base.DownloaderFactory) {
 	factories = append(factories, factory)
 }
 
-// MigrateRepository migrate repository according MigrateOptions
-func MigrateRepository(doer *models.User, ownerName string, opts base.MigrateOptions) (*models.Repository, error) {
-	var (
-		downloader base.Downloader
-		uploader   = NewGiteaLocalUploader(doer, ownerName, opts.Name)
-	)
+// the stages of a migration, in the order they are processed
+const (
+	StageRepository   = "repository"
+	StageMilestones   = "milestones"
+	StageLabels       = "labels"
+	StageReleases     = "releases"
+	StageIssues       = "issues"
+	StagePullRequests = "pull_requests"
+)
+
+var stages = []string{
+	StageRepository,
+	StageMilestones,
+	StageLabels,
+	StageReleases,
+	StageIssues,
+	StagePullRequests,
+}
+
+// Checkpoint records the progress of a migration, a migration started from a
+// checkpoint skips all the stages before Stage, the first Page pages of Stage and
+// the first Comments comments of the page after them
+type Checkpoint struct {
+	Stage    string
+	Page     int
+	Comments int
+}
+
+// CheckpointFunc is called with the new checkpoint whenever a migration makes progress
+type CheckpointFunc func(Checkpoint) error
+
+// MigrateRepository migrates the repository according MigrateOptions into repo, which
+// must have been created before. The migration starts from checkpoint, an empty
+// checkpoint starts it from the beginning. When the migration fails, all the data
+// migrated so far is kept, so that it can be continued from the last checkpoint.
+func MigrateRepository(doer *models.User, repo *models.Repository, opts base.MigrateOptions, checkpoint Checkpoint, save CheckpointFunc) error {
+	if err := repo.GetOwner(); err != nil {
+		return err
+	}
+
+	uploader := NewGiteaLocalUploader(doer, repo.Owner.Name, opts.Name)
+	uploader.repo = repo
 
+	downloader, err := newDownloader(repo.Owner.Name, &opts)
+	if err != nil {
+		return err
+	}
+
+	return migrateRepository(downloader, uploader, opts, checkpoint, save)
+}
+
+// newDownloader returns the downloader of the first factory matching opts, when
+// none matches the repository is migrated from plain git and opts is changed to
+// migrate only the git data and the wiki.
+func newDownloader(ownerName string, opts *base.MigrateOptions) (base.Downloader, error) {
 	for _, factory := range factories {
-		if match, err := factory.Match(opts); err != nil {
+		if match, err := factory.Match(*opts); err != nil {
 			return nil, err
 		} else if match {
-			downloader, err = factory.New(opts)
-			if err != nil {
-				return nil, err
-			}
-			break
+			return factory.New(*opts)
 		}
 	}
 
-	if downloader == nil {
-		opts.Wiki = true
-		opts.Milestones = false
-		opts.Labels = false
-		opts.Releases = false
-		opts.Comments = false
-		opts.Issues = false
-		opts.PullRequests = false
-		downloader = NewPlainGitDownloader(ownerName, opts.Name, opts.RemoteURL)
-		log.Trace("Will migrate from git: %s", opts.RemoteURL)
+	opts.Wiki = true
+	opts.Milestones = false
+	opts.Labels = false
+	opts.Releases = false
+	opts.Comments = false
+	opts.Issues = false
+	opts.PullRequests = false
+	log.Trace("Will migrate from git: %s", opts.RemoteURL)
+	return NewPlainGitDownloader(ownerName, opts.Name, opts.RemoteURL), nil
+}
+
+// CheckRemote checks that the repository to migrate can be accessed with the
+// credentials of opts, so that such errors are reported before the migration
+// is queued.
+func CheckRemote(ownerName string, opts base.MigrateOptions) error {
+	downloader, err := newDownloader(ownerName, &opts)
+	if err != nil {
+		return err
+	}
+	if _, err = downloader.GetRepoInfo(); err != nil {
+		return err
 	}
+	_, err = git.NewCommand("ls-remote", "-q", "-h", opts.RemoteURL, "HEAD").Run()
+	return err
+}
 
-	if err := migrateRepository(downloader, uploader, opts); err != nil {
-		if err1 := uploader.Rollback(); err1 != nil {
-			log.Error("rollback failed: %v", err1)
+func stageIndex(stage string) int {
+	for i, s := range stages {
+		if s == stage {
+			return i
 		}
-		return nil, err
 	}
-
-	return uploader.repo, nil
+	return 0
 }
 
 // migrateRepository will download informations and upload to Uploader, this is a simple
 // process for small repository. For a big repository, save all the data to disk
 // before upload is better
-func migrateRepository(downloader base.Downloader, uploader base.Uploader, opts base.MigrateOptions) error {
+func migrateRepository(downloader base.Downloader, uploader base.Uploader, opts base.MigrateOptions, checkpoint Checkpoint, save CheckpointFunc) error {
+	if save == nil {
+		save = func(Checkpoint) error { return nil }
+	}
+
+	start := stageIndex(checkpoint.Stage)
+	if start > 0 {
+		log.Trace("resuming migration from stage %s", stages[start])
+		if err := uploader.Resume(); err != nil {
+			return err
+		}
+	}
+
+	for i := start; i < len(stages); i++ {
+		var from Checkpoint
+		if i == start {
+			from = checkpoint
+		}
+		from.Stage = stages[i]
+		if err := save(from); err != nil {
+			return err
+		}
+
+		var err error
+		switch stages[i] {
+		case StageRepository:
+			err = migrateRepositoryData(downloader, uploader, opts)
+		case StageMilestones:
+			if opts.Milestones {
+				err = migrateMilestones(downloader, uploader)
+			}
+		case StageLabels:
+			if opts.Labels {
+				err = migrateLabels(downloader, uploader)
+			}
+		case StageReleases:
+			if opts.Releases {
+				err = migrateReleases(downloader, uploader)
+			}
+		case StageIssues:
+			if opts.Issues {
+				err = migrateIssues(downloader, uploader, opts, from, save)
+			}
+		case StagePullRequests:
+			if opts.PullRequests {
+				err = migratePullRequests(downloader, uploader, opts, from, save)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func migrateRepositoryData(downloader base.Downloader, uploader base.Uploader, opts base.MigrateOptions) error {
 	repo, err := downloader.GetRepoInfo()
 	if err != nil {
 		return err
@@ -78,164 +192,195 @@ func migrateRepository(downloader base.Downloader, uploader base.Uploader, opts
 		repo.Description = opts.Description
 	}
 	log.Trace("migrating git data")
-	if err := uploader.CreateRepo(repo, opts); err != nil {
+	return uploader.CreateRepo(repo, opts)
+}
+
+func migrateMilestones(downloader base.Downloader, uploader base.Uploader) error {
+	log.Trace("migrating milestones")
+	milestones, err := downloader.GetMilestones()
+	if err != nil {
 		return err
 	}
 
-	if opts.Milestones {
-		log.Trace("migrating milestones")
-		milestones, err := downloader.GetMilestones()
-		if err != nil {
+	msBatchSize := uploader.MaxBatchInsertSize("milestone")
+	for len(milestones) > 0 {
+		if len(milestones) < msBatchSize {
+			msBatchSize = len(milestones)
+		}
+
+		if err := uploader.CreateMilestones(milestones[:msBatchSize]...); err != nil {
 			return err
 		}
+		milestones = milestones[msBatchSize:]
+	}
+	return nil
+}
 
-		msBatchSize := uploader.MaxBatchInsertSize("milestone")
-		for len(milestones) > 0 {
-			if len(milestones) < msBatchSize {
-				msBatchSize = len(milestones)
-			}
+func migrateLabels(downloader base.Downloader, uploader base.Uploader) error {
+	log.Trace("migrating labels")
+	labels, err := downloader.GetLabels()
+	if err != nil {
+		return err
+	}
 
-			if err := uploader.CreateMilestones(milestones...); err != nil {
-				return err
-			}
-			milestones = milestones[msBatchSize:]
+	lbBatchSize := uploader.MaxBatchInsertSize("label")
+	for len(labels) > 0 {
+		if len(labels) < lbBatchSize {
+			lbBatchSize = len(labels)
 		}
-	}
 
-	if opts.Labels {
-		log.Trace("migrating labels")
-		labels, err := downloader.GetLabels()
-		if err != nil {
+		if err := uploader.CreateLabels(labels[:lbBatchSize]...); err != nil {
 			return err
 		}
+		labels = labels[lbBatchSize:]
+	}
+	return nil
+}
 
-		lbBatchSize := uploader.MaxBatchInsertSize("label")
-		for len(labels) > 0 {
-			if len(labels) < lbBatchSize {
-				lbBatchSize = len(labels)
-			}
-
-			if err := uploader.CreateLabels(labels...); err != nil {
-				return err
-			}
-			labels = labels[lbBatchSize:]
-		}
+func migrateReleases(downloader base.Downloader, uploader base.Uploader) error {
+	log.Trace("migrating releases")
+	releases, err := downloader.GetReleases()
+	if err != nil {
+		return err
 	}
 
-	if opts.Releases {
-		log.Trace("migrating releases")
-		releases, err := downloader.GetReleases()
-		if err != nil {
-			return err
+	relBatchSize := uploader.MaxBatchInsertSize("release")
+	for len(releases) > 0 {
+		if len(releases) < relBatchSize {
+			relBatchSize = len(releases)
 		}
 
-		relBatchSize := uploader.MaxBatchInsertSize("release")
-		for len(releases) > 0 {
-			if len(releases) < relBatchSize {
-				relBatchSize = len(releases)
-			}
-
-			if err := uploader.CreateReleases(releases[:relBatchSize]...); err != nil {
-				return err
-			}
-			releases = releases[relBatchSize:]
+		if err := uploader.CreateReleases(releases[:relBatchSize]...); err != nil {
+			return err
 		}
+		releases = releases[relBatchSize:]
 	}
+	return nil
+}
 
-	var commentBatchSize = uploader.MaxBatchInsertSize("comment")
-
-	if opts.Issues {
-		log.Trace("migrating issues and comments")
-		var issueBatchSize = uploader.MaxBatchInsertSize("issue")
-
-		for i := 1; ; i++ {
-			issues, isEnd, err := downloader.GetIssues(i, issueBatchSize)
-			if err != nil {
-				return err
-			}
-
-			if err := uploader.CreateIssues(issues...); err != nil {
-				return err
-			}
+// migrateIssues migrates the issues and their comments page by page, starting after
+// the pages and comments recorded by from. The comments of a page are downloaded
+// before its issues are uploaded, so that a page can be migrated again if it fails.
+func migrateIssues(downloader base.Downloader, uploader base.Uploader, opts base.MigrateOptions, from Checkpoint, save CheckpointFunc) error {
+	log.Trace("migrating issues and comments")
+	var issueBatchSize = uploader.MaxBatchInsertSize("issue")
 
-			if !opts.Comments {
-				continue
-			}
+	for i := from.Page + 1; ; i++ {
+		issues, isEnd, err := downloader.GetIssues(i, issueBatchSize)
+		if err != nil {
+			return err
+		}
 
-			var allComments = make([]*base.Comment, 0, commentBatchSize)
+		var allComments []*base.Comment
+		if opts.Comments {
 			for _, issue := range issues {
 				comments, err := downloader.GetComments(issue.Number)
 				if err != nil {
 					return err
 				}
-
 				allComments = append(allComments, comments...)
+			}
+		}
 
-				if len(allComments) >= commentBatchSize {
-					if err := uploader.CreateComments(allComments[:commentBatchSize]...); err != nil {
-						return err
-					}
+		if err := uploader.CreateIssues(issues...); err != nil {
+			return err
+		}
 
-					allComments = allComments[commentBatchSize:]
-				}
-			}
+		if err := migrateComments(uploader, allComments, skipComments(from, i), func(n int) error {
+			return save(Checkpoint{Stage: StageIssues, Page: i - 1, Comments: n})
+		}); err != nil {
+			return err
+		}
 
-			if len(allComments) > 0 {
-				if err := uploader.CreateComments(allComments...); err != nil {
-					return err
-				}
-			}
+		if err := save(Checkpoint{Stage: StageIssues, Page: i}); err != nil {
+			return err
+		}
 
-			if isEnd {
-				break
-			}
+		if isEnd {
+			break
 		}
 	}
+	return nil
+}
 
-	if opts.PullRequests {
-		log.Trace("migrating pull requests and comments")
-		var prBatchSize = uploader.MaxBatchInsertSize("pullrequest")
-		for i := 1; ; i++ {
-			prs, err := downloader.GetPullRequests(i, prBatchSize)
-			if err != nil {
-				return err
-			}
-
-			if err := uploader.CreatePullRequests(prs...); err != nil {
-				return err
-			}
+// migratePullRequests migrates the pull requests and their comments page by page,
+// starting after the pages and comments recorded by from.
+func migratePullRequests(downloader base.Downloader, uploader base.Uploader, opts base.MigrateOptions, from Checkpoint, save CheckpointFunc) error {
+	log.Trace("migrating pull requests and comments")
+	var prBatchSize = uploader.MaxBatchInsertSize("pullrequest")
 
-			if !opts.Comments {
-				continue
-			}
+	for i := from.Page + 1; ; i++ {
+		prs, err := downloader.GetPullRequests(i, prBatchSize)
+		if err != nil {
+			return err
+		}
 
-			var allComments = make([]*base.Comment, 0, commentBatchSize)
+		var allComments []*base.Comment
+		if opts.Comments {
 			for _, pr := range prs {
 				comments, err := downloader.GetComments(pr.Number)
 				if err != nil {
 					return err
 				}
-
 				allComments = append(allComments, comments...)
-
-				if len(allComments) >= commentBatchSize {
-					if err := uploader.CreateComments(allComments[:commentBatchSize]...); err != nil {
-						return err
-					}
-					allComments = allComments[commentBatchSize:]
-				}
-			}
-			if len(allComments) > 0 {
-				if err := uploader.CreateComments(allComments...); err != nil {
-					return err
-				}
 			}
+		}
 
-			if len(prs) < prBatchSize {
-				break
-			}
+		if err := uploader.CreatePullRequests(prs...); err != nil {
+			return err
+		}
+
+		if err := migrateComments(uploader, allComments, skipComments(from, i), func(n int) error {
+			return save(Checkpoint{Stage: StagePullRequests, Page: i - 1, Comments: n})
+		}); err != nil {
+			return err
+		}
+
+		if err := save(Checkpoint{Stage: StagePullRequests, Page: i}); err != nil {
+			return err
 		}
+
+		if len(prs) < prBatchSize {
+			break
+		}
+	}
+	return nil
+}
+
+// skipComments returns the number of comments of the page which were migrated
+// before the migration was resumed from checkpoint
+func skipComments(checkpoint Checkpoint, page int) int {
+	if page == checkpoint.Page+1 {
+		return checkpoint.Comments
+	}
+	return 0
+}
+
+// migrateComments migrates the comments of a page in batches, skipping the first
+// skip comments. progress is called with the number of comments migrated so far
+// after every batch.
+func migrateComments(uploader base.Uploader, comments []*base.Comment, skip int, progress func(int) error) error {
+	if skip > len(comments) {
+		skip = len(comments)
 	}
+	migrated := skip
+	comments = comments[skip:]
 
+	var commentBatchSize = uploader.MaxBatchInsertSize("comment")
+	for len(comments) > 0 {
+		if len(comments) < commentBatchSize {
+			commentBatchSize = len(comments)
+		}
+
+		if err := uploader.CreateComments(comments[:commentBatchSize]...); err != nil {
+			return err
+		}
+		comments = comments[commentBatchSize:]
+
+		migrated += commentBatchSize
+		if err := progress(migrated); err != nil {
+			return err
+		}
+	}
 	return nil
 }
@@ -0,0 +1,148 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/migrations/base"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// pagedDownloader serves the issues in pages and fails to download failPage
+type pagedDownloader struct {
+	issues   [][]*base.Issue
+	failPage int
+}
+
+func (d *pagedDownloader) GetRepoInfo() (*base.Repository, error) {
+	return nil, errors.New("not supported")
+}
+
+func (d *pagedDownloader) GetMilestones() ([]*base.Milestone, error) {
+	return nil, nil
+}
+
+func (d *pagedDownloader) GetReleases() ([]*base.Release, error) {
+	return nil, nil
+}
+
+func (d *pagedDownloader) GetLabels() ([]*base.Label, error) {
+	return []*base.Label{{Name: "migrated", Color: "ee0701"}}, nil
+}
+
+func (d *pagedDownloader) GetIssues(page, perPage int) ([]*base.Issue, bool, error) {
+	if page == d.failPage {
+		return nil, false, errors.New("connection reset")
+	}
+	return d.issues[page-1], page == len(d.issues), nil
+}
+
+func (d *pagedDownloader) GetComments(issueNumber int64) ([]*base.Comment, error) {
+	return []*base.Comment{{
+		IssueIndex: issueNumber,
+		PosterName: "commenter",
+		Content:    "a comment",
+		Created:    time.Now(),
+	}}, nil
+}
+
+func (d *pagedDownloader) GetPullRequests(page, perPage int) ([]*base.PullRequest, error) {
+	return nil, nil
+}
+
+func TestMigrateRepositoryResume(t *testing.T) {
+	models.PrepareTestEnv(t)
+
+	doer := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+	repo := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+
+	newIssue := func(number int64) *base.Issue {
+		return &base.Issue{
+			Number:     number,
+			Title:      "migrated issue",
+			PosterName: "poster",
+			State:      "open",
+			Created:    time.Now(),
+			Labels:     []*base.Label{{Name: "migrated"}},
+		}
+	}
+	downloader := &pagedDownloader{
+		issues: [][]*base.Issue{
+			{newIssue(101), newIssue(102)},
+			{newIssue(103), newIssue(104)},
+		},
+		failPage: 2,
+	}
+	opts := base.MigrateOptions{Labels: true, Issues: true, Comments: true}
+
+	var checkpoint Checkpoint
+	save := func(cp Checkpoint) error {
+		checkpoint = cp
+		return nil
+	}
+	migrate := func(from Checkpoint) error {
+		uploader := NewGiteaLocalUploader(doer, "user2", repo.Name)
+		uploader.repo = repo
+		return migrateRepository(downloader, uploader, opts, from, save)
+	}
+
+	// the repository itself has been migrated, the download of the second page of issues fails
+	assert.Error(t, migrate(Checkpoint{Stage: StageMilestones}))
+	assert.Equal(t, Checkpoint{Stage: StageIssues, Page: 1}, checkpoint)
+	models.AssertCount(t, &models.Issue{RepoID: repo.ID, Index: 102}, 1)
+	models.AssertCount(t, &models.Issue{RepoID: repo.ID, Index: 103}, 0)
+
+	// the second page was interrupted after its issues and the first comment were migrated
+	uploader := NewGiteaLocalUploader(doer, "user2", repo.Name)
+	uploader.repo = repo
+	assert.NoError(t, uploader.CreateIssues(newIssue(103), newIssue(104)))
+	comments, err := downloader.GetComments(103)
+	assert.NoError(t, err)
+	assert.NoError(t, uploader.CreateComments(comments...))
+	checkpoint.Comments = 1
+
+	// retrying continues with the remaining comments of the second page
+	downloader.failPage = 0
+	assert.NoError(t, migrate(checkpoint))
+	assert.Equal(t, Checkpoint{Stage: StagePullRequests}, checkpoint)
+
+	// migrating the same labels and issues again does not duplicate them
+	opts.Comments = false
+	assert.NoError(t, migrate(Checkpoint{Stage: StageLabels}))
+
+	models.AssertCount(t, &models.Label{RepoID: repo.ID, Name: "migrated"}, 1)
+	for _, index := range []int64{101, 102, 103, 104} {
+		models.AssertCount(t, &models.Issue{RepoID: repo.ID, Index: index}, 1)
+		issue := models.AssertExistsAndLoadBean(t, &models.Issue{RepoID: repo.ID, Index: index}).(*models.Issue)
+		models.AssertCount(t, &models.Comment{IssueID: issue.ID}, 1)
+	}
+}
+
+func TestMigrateComments(t *testing.T) {
+	models.PrepareTestEnv(t)
+
+	doer := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+	repo := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+	issue := models.AssertExistsAndLoadBean(t, &models.Issue{RepoID: repo.ID, Index: 1}).(*models.Issue)
+	uploader := NewGiteaLocalUploader(doer, "user2", repo.Name)
+	uploader.repo = repo
+
+	var comments []*base.Comment
+	for i := 0; i < 3; i++ {
+		comments = append(comments, &base.Comment{IssueIndex: 1, PosterName: "commenter", Content: "migrated comment", Created: time.Now()})
+	}
+	var progress []int
+	assert.NoError(t, migrateComments(uploader, comments, 1, func(n int) error {
+		progress = append(progress, n)
+		return nil
+	}))
+	assert.Equal(t, []int{3}, progress)
+	models.AssertCount(t, &models.Comment{IssueID: issue.ID, Content: "migrated comment"}, 2)
+}
@@ -0,0 +1,40 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package actions
+
+import (
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/notification/base"
+)
+
+type actionsNotifier struct {
+	base.NullNotifier
+}
+
+var (
+	_ base.Notifier = &actionsNotifier{}
+)
+
+// NewNotifier create a new actionsNotifier notifier
+func NewNotifier() base.Notifier {
+	return &actionsNotifier{}
+}
+
+func (a *actionsNotifier) NotifyNewPullRequest(pr *models.PullRequest) {
+	if err := pr.LoadIssue(); err != nil {
+		log.Error("LoadIssue: %v", err)
+		return
+	}
+	if err := pr.Issue.LoadPoster(); err != nil {
+		log.Error("LoadPoster: %v", err)
+		return
+	}
+	go func() {
+		if err := models.TriggerPullRequestActionWorkflows(pr.Issue.Poster, pr); err != nil {
+			log.Error("TriggerPullRequestActionWorkflows [pull_id: %d]: %v", pr.ID, err)
+		}
+	}()
+}
@@ -7,6 +7,7 @@ package notification
 import (
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/notification/actions"
 	"code.gitea.io/gitea/modules/notification/base"
 	"code.gitea.io/gitea/modules/notification/indexer"
 	"code.gitea.io/gitea/modules/notification/mail"
@@ -27,6 +28,7 @@ func init() {
 	RegisterNotifier(ui.NewNotifier())
 	RegisterNotifier(mail.NewNotifier())
 	RegisterNotifier(indexer.NewNotifier())
+	RegisterNotifier(actions.NewNotifier())
 }
 
 // NotifyCreateIssueComment notifies issue comment related message to notifiers
@@ -0,0 +1,237 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package packages stores the content of the package files in the package
+// storage, addressed by its SHA256 hash so that identical files are stored once.
+package packages
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
+	"code.gitea.io/gitea/modules/sync"
+)
+
+// ErrFileTooLarge is returned when an uploaded file exceeds setting.Packages.MaxFileSize
+var ErrFileTooLarge = errors.New("package file is too large")
+
+// blobPool serializes the changes of the content of a blob, by its hash, between its
+// storage with its blob and the removal of the content no blob references anymore.
+var blobPool = sync.NewExclusivePool()
+
+func init() {
+	models.RemovePackageBlobs = removeBlobs
+}
+
+// HashedBuffer holds content read from a reader in a temporary file along
+// with its size and SHA256 hash.
+type HashedBuffer struct {
+	*os.File
+	size int64
+	hash string
+}
+
+// NewHashedBuffer reads r into a temporary file, at most maxSize bytes are
+// accepted when maxSize is greater than 0. The buffer must be closed.
+func NewHashedBuffer(r io.Reader, maxSize int64) (*HashedBuffer, error) {
+	f, err := ioutil.TempFile("", "gitea-package-")
+	if err != nil {
+		return nil, err
+	}
+	buf := &HashedBuffer{File: f}
+
+	if maxSize > 0 {
+		r = io.LimitReader(r, maxSize+1)
+	}
+	h := sha256.New()
+	if buf.size, err = io.Copy(io.MultiWriter(f, h), r); err != nil {
+		buf.Close()
+		return nil, err
+	}
+	if maxSize > 0 && buf.size > maxSize {
+		buf.Close()
+		return nil, ErrFileTooLarge
+	}
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		buf.Close()
+		return nil, err
+	}
+	buf.hash = hex.EncodeToString(h.Sum(nil))
+	return buf, nil
+}
+
+// Size returns the size of the content
+func (b *HashedBuffer) Size() int64 {
+	return b.size
+}
+
+// HashSHA256 returns the hex encoded SHA256 hash of the content
+func (b *HashedBuffer) HashSHA256() string {
+	return b.hash
+}
+
+// Close closes and removes the temporary file
+func (b *HashedBuffer) Close() error {
+	err := b.File.Close()
+	if rmErr := os.Remove(b.Name()); rmErr != nil && !os.IsNotExist(rmErr) {
+		log.Error("Unable to remove temporary package file %s: %v", b.Name(), rmErr)
+	}
+	return err
+}
+
+// saveBlob stores the content of r unless content with the same hash is already stored,
+// the caller must hold the lock of the hash in blobPool until the blob is added.
+func saveBlob(hash string, r io.Reader) (bool, error) {
+	p := models.PackageBlobRelativePath(hash)
+	if _, err := storage.Packages.Stat(p); err == nil {
+		return false, nil
+	} else if !os.IsNotExist(err) {
+		return false, err
+	}
+	if _, err := storage.Packages.Save(p, r); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// AddFile stores the content of r and adds it as a file to the package version
+// described by opts, the package and the version are created if needed.
+func AddFile(opts *models.AddPackageFileOptions, r io.Reader) (*models.PackageFile, error) {
+	buf, err := NewHashedBuffer(r, setting.Packages.MaxFileSize)
+	if err != nil {
+		return nil, err
+	}
+	defer buf.Close()
+
+	blobPool.CheckIn(buf.HashSHA256())
+	defer blobPool.CheckOut(buf.HashSHA256())
+
+	saved, err := saveBlob(buf.HashSHA256(), buf)
+	if err != nil {
+		return nil, err
+	}
+
+	opts.Blob = &models.PackageBlob{
+		Size:       buf.Size(),
+		HashSHA256: buf.HashSHA256(),
+	}
+	f, err := models.AddPackageFile(opts)
+	if err != nil {
+		if saved {
+			removeUnreferencedContent(opts.Blob.HashSHA256)
+		}
+		return nil, err
+	}
+	return f, nil
+}
+
+// AddBlob stores the content of r as a blob which is not referenced by any file yet,
+// the blob must be referenced before the cleanup of the unreferenced blobs deletes it.
+func AddBlob(r io.Reader) (*models.PackageBlob, error) {
+	buf, err := NewHashedBuffer(r, setting.Packages.MaxFileSize)
+	if err != nil {
+		return nil, err
+	}
+	defer buf.Close()
+	return addBlob(buf.HashSHA256(), buf.Size(), buf)
+}
+
+func addBlob(hash string, size int64, r io.Reader) (*models.PackageBlob, error) {
+	blobPool.CheckIn(hash)
+	defer blobPool.CheckOut(hash)
+
+	if _, err := saveBlob(hash, r); err != nil {
+		return nil, err
+	}
+	return models.AddPackageBlob(&models.PackageBlob{
+		Size:       size,
+		HashSHA256: hash,
+	})
+}
+
+// removeUnreferencedContent removes the content with the hash unless a blob references it,
+// the caller must hold the lock of the hash in blobPool.
+func removeUnreferencedContent(hash string) {
+	existing, err := models.GetPackageBlobByHash(hash)
+	if err != nil {
+		log.Error("GetPackageBlobByHash [hash: %s]: %v", hash, err)
+		return
+	} else if existing != nil {
+		// the content was added again since its blob was deleted
+		return
+	}
+	if err = storage.Packages.Delete(models.PackageBlobRelativePath(hash)); err != nil {
+		log.Error("Unable to remove the content of package blob %s: %v", hash, err)
+	}
+}
+
+// OpenFile opens the content of a package file, its blob must be loaded
+func OpenFile(f *models.PackageFile) (storage.Object, error) {
+	return OpenBlob(f.Blob)
+}
+
+// OpenBlob opens the content of a blob
+func OpenBlob(blob *models.PackageBlob) (storage.Object, error) {
+	return storage.Packages.Open(blob.RelativePath())
+}
+
+// DeleteFile deletes a file of a package version and its content when no other file references it
+func DeleteFile(p *models.Package, v *models.PackageVersion, f *models.PackageFile) error {
+	blobs, err := models.DeletePackageFile(p, v, f)
+	if err != nil {
+		return err
+	}
+	removeBlobs(blobs)
+	return nil
+}
+
+// DeleteVersion deletes a package version with its files and the content no other file references
+func DeleteVersion(p *models.Package, v *models.PackageVersion) error {
+	blobs, err := models.DeletePackageVersion(p, v)
+	if err != nil {
+		return err
+	}
+	removeBlobs(blobs)
+	return nil
+}
+
+// removeBlobs removes the content of deleted blobs
+func removeBlobs(blobs []*models.PackageBlob) {
+	for _, blob := range blobs {
+		blobPool.CheckIn(blob.HashSHA256)
+		removeUnreferencedContent(blob.HashSHA256)
+		blobPool.CheckOut(blob.HashSHA256)
+	}
+}
+
+// CleanupUnreferencedBlobs removes the blobs no file references which are older than
+// setting.Cron.PackageBlobsCleanup.OlderThan, including the blobs uploaded to container
+// images which no manifest referenced since, and the abandoned chunked uploads.
+func CleanupUnreferencedBlobs() {
+	olderThan := setting.Cron.PackageBlobsCleanup.OlderThan
+	if err := models.DeleteExpiredContainerUploads(olderThan); err != nil {
+		log.Error("DeleteExpiredContainerUploads: %v", err)
+		return
+	}
+
+	blobs, err := models.DeleteUnreferencedPackageBlobs(olderThan)
+	if err != nil {
+		log.Error("DeleteUnreferencedPackageBlobs: %v", err)
+		return
+	}
+	removeBlobs(blobs)
+	log.Trace("Removed %d unreferenced package blobs", len(blobs))
+
+	if err = removeUploadsOlderThan(olderThan); err != nil {
+		log.Error("Unable to remove the abandoned package uploads: %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package packages
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	models.MainTest(m, filepath.Join("..", ".."))
+}
+
+func TestNewHashedBuffer(t *testing.T) {
+	buf, err := NewHashedBuffer(strings.NewReader("package content"), 0)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 15, buf.Size())
+	assert.Equal(t, "830df696604d16c1966d36f166b8635aa0788f09af6df4cc8ba9976d1a1c5dd9", buf.HashSHA256())
+
+	content, err := ioutil.ReadAll(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "package content", string(content))
+
+	name := buf.Name()
+	assert.NoError(t, buf.Close())
+	_, err = os.Stat(name)
+	assert.True(t, os.IsNotExist(err))
+
+	buf, err = NewHashedBuffer(strings.NewReader("package content"), 15)
+	assert.NoError(t, err)
+	assert.NoError(t, buf.Close())
+
+	_, err = NewHashedBuffer(strings.NewReader("package content"), 14)
+	assert.Equal(t, ErrFileTooLarge, err)
+}
+
+func TestAddFileAfterDeleteFile(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+	owner := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+	opts := &models.AddPackageFileOptions{
+		Owner:    owner,
+		Creator:  owner,
+		Type:     models.PackageGeneric,
+		Name:     "package",
+		Version:  "1.0.0",
+		Filename: "file.bin",
+	}
+	f, err := AddFile(opts, strings.NewReader("foo"))
+	assert.NoError(t, err)
+	blob := f.Blob
+
+	// the content of a deleted blob is kept when a file added it again meanwhile
+	p := models.AssertExistsAndLoadBean(t, &models.Package{OwnerID: owner.ID, LowerName: "package"}).(*models.Package)
+	v := models.AssertExistsAndLoadBean(t, &models.PackageVersion{ID: f.VersionID}).(*models.PackageVersion)
+	blobs, err := models.DeletePackageFile(p, v, f)
+	assert.NoError(t, err)
+	_, err = AddFile(opts, strings.NewReader("foo"))
+	assert.NoError(t, err)
+	removeBlobs(blobs)
+	_, err = storage.Packages.Stat(blob.RelativePath())
+	assert.NoError(t, err)
+
+	p = models.AssertExistsAndLoadBean(t, &models.Package{OwnerID: owner.ID, LowerName: "package"}).(*models.Package)
+	v, err = models.GetPackageVersionByName(p.ID, "1.0.0")
+	assert.NoError(t, err)
+	f, err = models.GetPackageFileByName(v.ID, "file.bin")
+	assert.NoError(t, err)
+	assert.NoError(t, DeleteFile(p, v, f))
+	_, err = storage.Packages.Stat(blob.RelativePath())
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestDeleteOwnerWithPackages(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+	owner := &models.User{
+		Name:   "package-owner",
+		Email:  "package-owner@example.com",
+		Passwd: "password",
+	}
+	assert.NoError(t, models.CreateUser(owner))
+	f, err := AddFile(&models.AddPackageFileOptions{
+		Owner:    owner,
+		Creator:  owner,
+		Type:     models.PackageGeneric,
+		Name:     "package",
+		Version:  "1.0.0",
+		Filename: "file.bin",
+	}, strings.NewReader("owner content"))
+	assert.NoError(t, err)
+
+	// the content is removed once the deletion of the owner is committed
+	assert.NoError(t, models.DeleteUser(owner))
+	models.AssertNotExistsBean(t, &models.Package{OwnerID: owner.ID})
+	models.AssertNotExistsBean(t, &models.PackageBlob{ID: f.BlobID})
+	_, err = storage.Packages.Stat(f.Blob.RelativePath())
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,215 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package packages
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+
+	gouuid "github.com/satori/go.uuid"
+)
+
+var (
+	// ErrUploadNotExist is returned for an unknown or finished chunked upload
+	ErrUploadNotExist = errors.New("package upload does not exist")
+	// ErrHashMismatch is returned when the content of a chunked upload does not match the expected hash
+	ErrHashMismatch = errors.New("package upload content does not match the hash")
+)
+
+// uploadPath returns the path of the temporary file of a chunked upload,
+// the id must be a UUID so that it can not escape the upload directory.
+func uploadPath(id string) (string, error) {
+	if _, err := gouuid.FromString(id); err != nil {
+		return "", ErrUploadNotExist
+	}
+	return filepath.Join(setting.Packages.ChunkedUploadPath, id), nil
+}
+
+// CreateUpload starts a chunked upload by the creator for the package of the owner with the name,
+// its content is received in one or more chunks before it is stored as a blob.
+func CreateUpload(owner, creator *models.User, name string) (*models.PackageUpload, error) {
+	if err := os.MkdirAll(setting.Packages.ChunkedUploadPath, os.ModePerm); err != nil {
+		return nil, err
+	}
+	id := gouuid.NewV4().String()
+	p, err := uploadPath(id)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err = f.Close(); err != nil {
+		return nil, err
+	}
+	if err = models.CreatePackageUpload(id, owner, creator, name); err != nil {
+		if rmErr := os.Remove(p); rmErr != nil {
+			log.Error("Unable to remove package upload %s: %v", id, rmErr)
+		}
+		return nil, err
+	}
+	return GetUpload(id, owner, creator, name)
+}
+
+// GetUpload returns the chunked upload with the id, it can only be continued by
+// its creator for the package of the owner with the name it was started for.
+func GetUpload(id string, owner, creator *models.User, name string) (*models.PackageUpload, error) {
+	if _, err := uploadPath(id); err != nil {
+		return nil, err
+	}
+	upload, err := models.GetPackageUpload(id, owner, creator, name)
+	if err != nil {
+		if models.IsErrPackageUploadNotExist(err) {
+			return nil, ErrUploadNotExist
+		}
+		return nil, err
+	}
+	return upload, nil
+}
+
+// UploadSize returns the size of the content received by a chunked upload
+func UploadSize(upload *models.PackageUpload) (int64, error) {
+	p, err := uploadPath(upload.UUID)
+	if err != nil {
+		return 0, err
+	}
+	fi, err := os.Stat(p)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, ErrUploadNotExist
+		}
+		return 0, err
+	}
+	return fi.Size(), nil
+}
+
+// AppendUpload appends the content of r to a chunked upload and returns its size,
+// the upload is canceled when it exceeds setting.Packages.MaxFileSize.
+func AppendUpload(upload *models.PackageUpload, r io.Reader) (int64, error) {
+	p, err := uploadPath(upload.UUID)
+	if err != nil {
+		return 0, err
+	}
+	f, err := os.OpenFile(p, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, ErrUploadNotExist
+		}
+		return 0, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	size := fi.Size()
+	maxSize := setting.Packages.MaxFileSize
+	if maxSize > 0 {
+		r = io.LimitReader(r, maxSize-size+1)
+	}
+	n, err := io.Copy(f, r)
+	size += n
+	if err != nil {
+		return size, err
+	}
+	if maxSize > 0 && size > maxSize {
+		f.Close()
+		if err = removeUpload(upload); err != nil {
+			return size, err
+		}
+		return size, ErrFileTooLarge
+	}
+	return size, models.UpdatePackageUpload(upload)
+}
+
+// FinishUpload stores the content of a chunked upload as a blob which is not referenced by
+// any file yet. The content must match the hex encoded SHA256 hash, the upload is kept otherwise.
+func FinishUpload(upload *models.PackageUpload, hash string) (*models.PackageBlob, error) {
+	p, err := uploadPath(upload.UUID)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrUploadNotExist
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return nil, err
+	}
+	if hex.EncodeToString(h.Sum(nil)) != hash {
+		return nil, ErrHashMismatch
+	}
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	blob, err := addBlob(hash, size, f)
+	if err != nil {
+		return nil, err
+	}
+	f.Close()
+	return blob, removeUpload(upload)
+}
+
+// CancelUpload cancels a chunked upload and removes its content
+func CancelUpload(upload *models.PackageUpload) error {
+	return removeUpload(upload)
+}
+
+func removeUpload(upload *models.PackageUpload) error {
+	p, err := uploadPath(upload.UUID)
+	if err != nil {
+		return err
+	}
+	if err = models.DeletePackageUpload(upload.UUID); err != nil {
+		return err
+	}
+	if err = os.Remove(p); os.IsNotExist(err) {
+		return ErrUploadNotExist
+	}
+	return err
+}
+
+// removeUploadsOlderThan removes the chunked uploads which did not receive content for longer than olderThan
+func removeUploadsOlderThan(olderThan time.Duration) error {
+	if err := models.DeleteExpiredPackageUploads(olderThan); err != nil {
+		return err
+	}
+	fis, err := ioutil.ReadDir(setting.Packages.ChunkedUploadPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	deadline := time.Now().Add(-olderThan)
+	for _, fi := range fis {
+		if fi.ModTime().Before(deadline) {
+			if err = os.Remove(filepath.Join(setting.Packages.ChunkedUploadPath, fi.Name())); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
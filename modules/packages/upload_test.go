@@ -0,0 +1,82 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package packages
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChunkedUpload(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+	owner := models.AssertExistsAndLoadBean(t, &models.User{ID: 3}).(*models.User)
+	creator := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+	dir, err := ioutil.TempDir("", "package-upload")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	oldPath, oldMaxSize := setting.Packages.ChunkedUploadPath, setting.Packages.MaxFileSize
+	defer func() {
+		setting.Packages.ChunkedUploadPath, setting.Packages.MaxFileSize = oldPath, oldMaxSize
+	}()
+	setting.Packages.ChunkedUploadPath = dir
+	setting.Packages.MaxFileSize = 20
+
+	upload, err := CreateUpload(owner, creator, "Image")
+	assert.NoError(t, err)
+	size, err := AppendUpload(upload, strings.NewReader("package "))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 8, size)
+	size, err = AppendUpload(upload, strings.NewReader("content"))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 15, size)
+	size, err = UploadSize(upload)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 15, size)
+
+	// the upload can only be continued by its creator for its image
+	_, err = GetUpload(upload.UUID, owner, creator, "image")
+	assert.NoError(t, err)
+	_, err = GetUpload(upload.UUID, owner, owner, "image")
+	assert.Equal(t, ErrUploadNotExist, err)
+	_, err = GetUpload(upload.UUID, creator, creator, "image")
+	assert.Equal(t, ErrUploadNotExist, err)
+	_, err = GetUpload(upload.UUID, owner, creator, "other")
+	assert.Equal(t, ErrUploadNotExist, err)
+	_, err = GetUpload("../../etc/passwd", owner, creator, "image")
+	assert.Equal(t, ErrUploadNotExist, err)
+
+	_, err = FinishUpload(upload, "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae")
+	assert.Equal(t, ErrHashMismatch, err)
+
+	// the upload is canceled when it is too large
+	_, err = AppendUpload(upload, strings.NewReader(" too large"))
+	assert.Equal(t, ErrFileTooLarge, err)
+	_, err = UploadSize(upload)
+	assert.Equal(t, ErrUploadNotExist, err)
+	_, err = GetUpload(upload.UUID, owner, creator, "image")
+	assert.Equal(t, ErrUploadNotExist, err)
+
+	upload, err = CreateUpload(owner, creator, "image")
+	assert.NoError(t, err)
+	assert.NoError(t, removeUploadsOlderThan(time.Hour))
+	assert.NoError(t, CancelUpload(upload))
+	assert.Equal(t, ErrUploadNotExist, CancelUpload(upload))
+	models.AssertNotExistsBean(t, &models.PackageUpload{UUID: upload.UUID})
+
+	upload, err = CreateUpload(owner, creator, "image")
+	assert.NoError(t, err)
+	assert.NoError(t, removeUploadsOlderThan(-time.Hour))
+	_, err = UploadSize(upload)
+	assert.Equal(t, ErrUploadNotExist, err)
+	models.AssertNotExistsBean(t, &models.PackageUpload{UUID: upload.UUID})
+}
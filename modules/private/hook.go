@@ -18,6 +18,7 @@ const (
 	GitAlternativeObjectDirectories = "GIT_ALTERNATE_OBJECT_DIRECTORIES"
 	GitObjectDirectory              = "GIT_OBJECT_DIRECTORY"
 	GitQuarantinePath               = "GIT_QUARANTINE_PATH"
+	GitPushOptionCount              = "GIT_PUSH_OPTION_COUNT"
 )
 
 // HookOptions represents the options for the Hook calls
@@ -30,6 +31,16 @@ type HookOptions struct {
 	GitObjectDirectory              string
 	GitAlternativeObjectDirectories string
 	ProtectedBranchID               int64
+	GitPushOptions                  []string
+}
+
+// encodePushOptions returns the push options as query parameters
+func encodePushOptions(options []string) string {
+	var query string
+	for _, option := range options {
+		query += "&pushOption=" + url.QueryEscape(option)
+	}
+	return query
 }
 
 // HookPreReceive check whether the provided commits are allowed
@@ -44,7 +55,7 @@ func HookPreReceive(ownerName, repoName string, opts HookOptions) (int, string)
 		url.QueryEscape(opts.GitObjectDirectory),
 		url.QueryEscape(opts.GitAlternativeObjectDirectories),
 		opts.ProtectedBranchID,
-	)
+	) + encodePushOptions(opts.GitPushOptions)
 
 	resp, err := newInternalRequest(reqURL, "GET").Response()
 	if err != nil {
@@ -68,7 +79,7 @@ func HookPostReceive(ownerName, repoName string, opts HookOptions) (map[string]i
 		url.QueryEscape(opts.NewCommitID),
 		url.QueryEscape(opts.RefFullName),
 		opts.UserID,
-		url.QueryEscape(opts.UserName))
+		url.QueryEscape(opts.UserName)) + encodePushOptions(opts.GitPushOptions)
 
 	resp, err := newInternalRequest(reqURL, "GET").Response()
 	if err != nil {
@@ -0,0 +1,159 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package pull
+
+import (
+	"fmt"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/notification"
+)
+
+// ErrInvalidAGitRef represents an error when a ref pushed with AGit flow does not describe a pull request
+type ErrInvalidAGitRef struct {
+	RefFullName string
+	Reason      string
+}
+
+// IsErrInvalidAGitRef checks if an error is a ErrInvalidAGitRef.
+func IsErrInvalidAGitRef(err error) bool {
+	_, ok := err.(ErrInvalidAGitRef)
+	return ok
+}
+
+func (err ErrInvalidAGitRef) Error() string {
+	return fmt.Sprintf("%s: %s", err.RefFullName, err.Reason)
+}
+
+// ParseAGitRef returns the base branch and the topic of the pull request pushed to
+// refs/for/<base branch>/<topic>, the topic may also be given with the topic push option.
+// The topic is the head branch of the pull request, it is never created in the repository
+// and only tells the pull requests of a poster apart.
+func ParseAGitRef(gitRepo *git.Repository, refFullName string, pushOptions map[string]string) (baseBranch, topic string, err error) {
+	rest := strings.TrimPrefix(refFullName, git.ForPrefix)
+	topic = pushOptions["topic"]
+	if len(topic) > 0 {
+		baseBranch = rest
+	} else {
+		// the base branch is the longest existing branch followed by a topic
+		for i := strings.LastIndex(rest, "/"); i > 0; i = strings.LastIndex(rest[:i], "/") {
+			if gitRepo.IsBranchExist(rest[:i]) {
+				baseBranch, topic = rest[:i], rest[i+1:]
+				break
+			}
+		}
+		if len(baseBranch) == 0 && gitRepo.IsBranchExist(rest) {
+			return "", "", ErrInvalidAGitRef{refFullName, fmt.Sprintf("the topic is missing, push to %s%s/<topic>", git.ForPrefix, rest)}
+		}
+	}
+	if len(baseBranch) == 0 || !gitRepo.IsBranchExist(baseBranch) {
+		return "", "", ErrInvalidAGitRef{refFullName, "the base branch does not exist"}
+	}
+
+	if _, err := git.NewCommand("check-ref-format", "--branch", topic).Run(); err != nil {
+		return "", "", ErrInvalidAGitRef{refFullName, fmt.Sprintf("%q is not a valid topic", topic)}
+	}
+	return baseBranch, topic, nil
+}
+
+// PushAGitPullRequest creates or updates the pull request of the pusher for a push to refs/for/<base branch>/<topic>,
+// the title and the description of a new pull request are read from the push options. The pushed ref
+// is removed afterwards, the head commit of the pull request is only kept in its hidden ref.
+func PushAGitPullRequest(repo *models.Repository, pusher *models.User, refFullName, newCommitID string, pushOptions map[string]string) (_ *models.PullRequest, created bool, err error) {
+	gitRepo, err := git.OpenRepository(repo.RepoPath())
+	if err != nil {
+		return nil, false, fmt.Errorf("OpenRepository: %v", err)
+	}
+	defer func() {
+		if _, err := git.NewCommand("update-ref", "-d", refFullName, newCommitID).RunInDir(repo.RepoPath()); err != nil {
+			log.Error("Unable to delete %s in %-v: %v", refFullName, repo, err)
+		}
+	}()
+
+	baseBranch, topic, err := ParseAGitRef(gitRepo, refFullName, pushOptions)
+	if err != nil {
+		return nil, false, err
+	}
+
+	pr, err := models.GetUnmergedAGitPullRequest(repo.ID, pusher.ID, topic, baseBranch)
+	if err == nil {
+		if err = updateGitRef(repo, pr.GetGitRefName(), newCommitID); err != nil {
+			return nil, false, err
+		}
+		go models.AddTestAGitPullRequestTask(pusher, pr)
+		return pr, false, nil
+	} else if !models.IsErrPullRequestNotExist(err) {
+		return nil, false, fmt.Errorf("GetUnmergedAGitPullRequest: %v", err)
+	}
+
+	mergeBase, _, err := gitRepo.GetMergeBase("", baseBranch, newCommitID)
+	if err != nil {
+		return nil, false, ErrInvalidAGitRef{refFullName, "the commits have no common history with the base branch"}
+	}
+	patch, err := gitRepo.GetPatch(mergeBase, newCommitID)
+	if err != nil {
+		return nil, false, fmt.Errorf("GetPatch: %v", err)
+	}
+
+	title := strings.TrimSpace(pushOptions["title"])
+	if len(title) == 0 {
+		commit, err := gitRepo.GetCommit(newCommitID)
+		if err != nil {
+			return nil, false, fmt.Errorf("GetCommit: %v", err)
+		}
+		title = commit.Summary()
+	}
+
+	maxIndex, err := models.GetMaxIndexOfIssue(repo.ID)
+	if err != nil {
+		return nil, false, fmt.Errorf("GetMaxIndexOfIssue: %v", err)
+	}
+	pullIssue := &models.Issue{
+		RepoID:   repo.ID,
+		Index:    maxIndex + 1,
+		Title:    title,
+		PosterID: pusher.ID,
+		Poster:   pusher,
+		IsPull:   true,
+		Content:  pushOptions["description"],
+	}
+	pr = &models.PullRequest{
+		HeadRepoID:   repo.ID,
+		BaseRepoID:   repo.ID,
+		HeadUserName: repo.MustOwner().Name,
+		HeadBranch:   topic,
+		BaseBranch:   baseBranch,
+		HeadRepo:     repo,
+		BaseRepo:     repo,
+		MergeBase:    mergeBase,
+		Type:         models.PullRequestGitea,
+		Flow:         models.PullRequestFlowAGit,
+	}
+	// The head commit must be known before the webhooks of the new pull request are prepared. The ref
+	// of another pull request which took the index meanwhile is not overwritten, the insert fails then.
+	refName := fmt.Sprintf("refs/pull/%d/head", pullIssue.Index)
+	if _, err = git.NewCommand("update-ref", refName, newCommitID, "").RunInDir(repo.RepoPath()); err != nil {
+		return nil, false, fmt.Errorf("update-ref %s: %v", refName, err)
+	}
+	if err = models.NewPullRequest(repo, pullIssue, nil, nil, pr, patch, nil); err != nil {
+		if _, delErr := git.NewCommand("update-ref", "-d", refName, newCommitID).RunInDir(repo.RepoPath()); delErr != nil {
+			log.Error("Unable to delete %s in %-v: %v", refName, repo, delErr)
+		}
+		return nil, false, fmt.Errorf("NewPullRequest: %v", err)
+	}
+
+	notification.NotifyNewPullRequest(pr)
+	return pr, true, nil
+}
+
+func updateGitRef(repo *models.Repository, refName, commitID string) error {
+	if _, err := git.NewCommand("update-ref", refName, commitID).RunInDir(repo.RepoPath()); err != nil {
+		return fmt.Errorf("update-ref %s: %v", refName, err)
+	}
+	return nil
+}
@@ -15,12 +15,14 @@ import (
 	"golang.org/x/text/transform"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/actions"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/cache"
 	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/lfs"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
 	"code.gitea.io/gitea/modules/structs"
 )
 
@@ -373,7 +375,7 @@ func CreateOrUpdateRepoFile(repo *models.Repository, doer *models.User, opts *Up
 		if err != nil {
 			return nil, err
 		}
-		contentStore := &lfs.ContentStore{BasePath: setting.LFS.ContentPath}
+		contentStore := &lfs.ContentStore{ObjectStorage: storage.LFS}
 		if !contentStore.Exists(lfsMetaObject) {
 			if err := contentStore.Put(lfsMetaObject, strings.NewReader(opts.Content)); err != nil {
 				if err2 := repo.RemoveLFSMetaObjectByOid(lfsMetaObject.Oid); err2 != nil {
@@ -518,8 +520,24 @@ func PushUpdate(repo *models.Repository, branch string, opts models.PushUpdateOp
 
 	go models.AddTestPullRequestTask(pusher, repo.ID, branch, true)
 
+	if !isDelRef {
+		go triggerPushActions(repo, pusher, opts)
+	}
+
 	if opts.RefFullName == git.BranchPrefix+repo.DefaultBranch {
 		models.UpdateRepoIndexer(repo)
 	}
 	return nil
 }
+
+func triggerPushActions(repo *models.Repository, pusher *models.User, opts models.PushUpdateOptions) {
+	if err := models.TriggerActionWorkflows(models.ActionTriggerOptions{
+		Repo:      repo,
+		Doer:      pusher,
+		Event:     actions.EventPush,
+		Ref:       opts.RefFullName,
+		CommitSHA: opts.NewCommitID,
+	}); err != nil {
+		log.Error("TriggerActionWorkflows [repo_id: %d, ref: %s]: %v", repo.ID, opts.RefFullName, err)
+	}
+}
@@ -21,6 +21,7 @@ type Result struct {
 	Filename       string
 	HighlightClass string
 	LineNumbers    []int
+	MatchedLines   []int
 	FormattedLines gotemplate.HTML
 }
 
@@ -103,17 +104,18 @@ func searchResult(result *indexer.RepoSearchResult, startIndex, endIndex int) (*
 		Filename:       result.Filename,
 		HighlightClass: highlight.FileNameToHighlightClass(result.Filename),
 		LineNumbers:    lineNumbers,
+		MatchedLines:   result.LineNumbers,
 		FormattedLines: gotemplate.HTML(formattedLinesBuffer.String()),
 	}, nil
 }
 
-// PerformSearch perform a search on a repository
-func PerformSearch(repoIDs []int64, keyword string, page, pageSize int) (int, []*Result, error) {
-	if len(keyword) == 0 {
+// PerformSearch perform a search on repositories
+func PerformSearch(opts *indexer.RepoSearchOptions) (int, []*Result, error) {
+	if len(opts.Keyword) == 0 {
 		return 0, nil, nil
 	}
 
-	total, results, err := indexer.SearchRepoByKeyword(repoIDs, keyword, page, pageSize)
+	total, results, err := indexer.SearchRepoByKeyword(opts)
 	if err != nil {
 		return 0, nil, err
 	}
@@ -0,0 +1,29 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package setting
+
+import (
+	"time"
+
+	"code.gitea.io/gitea/modules/log"
+)
+
+var (
+	// Actions settings
+	Actions = struct {
+		Enabled bool
+		// ZombieTimeout is the time after which a running job not updated by its runner is marked as failed
+		ZombieTimeout time.Duration
+	}{
+		Enabled:       false,
+		ZombieTimeout: 10 * time.Minute,
+	}
+)
+
+func newActions() {
+	if err := Cfg.Section("actions").MapTo(&Actions); err != nil {
+		log.Fatal("Failed to map Actions settings: %v", err)
+	}
+}
@@ -19,6 +19,16 @@ var (
 			RunAtStart bool
 			Schedule   string
 		} `ini:"cron.update_mirrors"`
+		UpdatePushMirror struct {
+			Enabled    bool
+			RunAtStart bool
+			Schedule   string
+		} `ini:"cron.update_push_mirrors"`
+		StopZombieActions struct {
+			Enabled    bool
+			RunAtStart bool
+			Schedule   string
+		} `ini:"cron.stop_zombie_actions"`
 		RepoHealthCheck struct {
 			Enabled    bool
 			RunAtStart bool
@@ -49,6 +59,12 @@ var (
 			Schedule   string
 			OlderThan  time.Duration
 		} `ini:"cron.deleted_branches_cleanup"`
+		PackageBlobsCleanup struct {
+			Enabled    bool
+			RunAtStart bool
+			Schedule   string
+			OlderThan  time.Duration
+		} `ini:"cron.package_blobs_cleanup"`
 	}{
 		UpdateMirror: struct {
 			Enabled    bool
@@ -59,6 +75,24 @@ var (
 			RunAtStart: false,
 			Schedule:   "@every 10m",
 		},
+		UpdatePushMirror: struct {
+			Enabled    bool
+			RunAtStart bool
+			Schedule   string
+		}{
+			Enabled:    true,
+			RunAtStart: false,
+			Schedule:   "@every 10m",
+		},
+		StopZombieActions: struct {
+			Enabled    bool
+			RunAtStart bool
+			Schedule   string
+		}{
+			Enabled:    true,
+			RunAtStart: true,
+			Schedule:   "@every 5m",
+		},
 		RepoHealthCheck: struct {
 			Enabled    bool
 			RunAtStart bool
@@ -114,6 +148,17 @@ var (
 			Schedule:   "@every 24h",
 			OlderThan:  24 * time.Hour,
 		},
+		PackageBlobsCleanup: struct {
+			Enabled    bool
+			RunAtStart bool
+			Schedule   string
+			OlderThan  time.Duration
+		}{
+			Enabled:    true,
+			RunAtStart: false,
+			Schedule:   "@every 24h",
+			OlderThan:  24 * time.Hour,
+		},
 	}
 )
 
@@ -21,7 +21,10 @@ var (
 	Indexer = struct {
 		IssueType             string
 		IssuePath             string
+		IssueConnStr          string
+		IssueIndexerName      string
 		RepoIndexerEnabled    bool
+		RepoType              string
 		RepoPath              string
 		UpdateQueueLength     int
 		MaxIndexerFileSize    int64
@@ -32,6 +35,9 @@ var (
 	}{
 		IssueType:             "bleve",
 		IssuePath:             "indexers/issues.bleve",
+		IssueConnStr:          "",
+		IssueIndexerName:      "gitea_issues",
+		RepoType:              "bleve",
 		IssueQueueType:        LevelQueueType,
 		IssueQueueDir:         "indexers/issues.queue",
 		IssueQueueConnStr:     "",
@@ -46,7 +52,10 @@ func newIndexerService() {
 	if !filepath.IsAbs(Indexer.IssuePath) {
 		Indexer.IssuePath = path.Join(AppWorkPath, Indexer.IssuePath)
 	}
+	Indexer.IssueConnStr = sec.Key("ISSUE_INDEXER_CONN_STR").MustString(Indexer.IssueConnStr)
+	Indexer.IssueIndexerName = sec.Key("ISSUE_INDEXER_NAME").MustString(Indexer.IssueIndexerName)
 	Indexer.RepoIndexerEnabled = sec.Key("REPO_INDEXER_ENABLED").MustBool(false)
+	Indexer.RepoType = sec.Key("REPO_INDEXER_TYPE").MustString(Indexer.RepoType)
 	Indexer.RepoPath = sec.Key("REPO_INDEXER_PATH").MustString(path.Join(AppDataPath, "indexers/repos.bleve"))
 	if !filepath.IsAbs(Indexer.RepoPath) {
 		Indexer.RepoPath = path.Join(AppWorkPath, Indexer.RepoPath)
@@ -0,0 +1,35 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package setting
+
+import (
+	"path/filepath"
+
+	"code.gitea.io/gitea/modules/log"
+)
+
+var (
+	// Packages settings
+	Packages = struct {
+		Enabled bool
+		// MaxFileSize is the maximum size in bytes of an uploaded package file, 0 means no limit
+		MaxFileSize int64
+		// ChunkedUploadPath is where the uploads in progress of the container registry are kept
+		ChunkedUploadPath string
+	}{
+		Enabled:           true,
+		MaxFileSize:       0,
+		ChunkedUploadPath: "tmp/package-upload",
+	}
+)
+
+func newPackages() {
+	if err := Cfg.Section("packages").MapTo(&Packages); err != nil {
+		log.Fatal("Failed to map Packages settings: %v", err)
+	}
+	if !filepath.IsAbs(Packages.ChunkedUploadPath) {
+		Packages.ChunkedUploadPath = filepath.Join(AppDataPath, Packages.ChunkedUploadPath)
+	}
+}
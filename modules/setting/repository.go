@@ -64,6 +64,11 @@ var (
 		Issue struct {
 			LockReasons []string
 		} `ini:"repository.issue"`
+
+		// Go module proxy settings
+		GoProxy struct {
+			Enabled bool
+		} `ini:"repository.go-proxy"`
 	}{
 		AnsiCharset:                             "",
 		ForcePrivate:                            false,
@@ -121,6 +126,13 @@ var (
 		}{
 			LockReasons: strings.Split("Too heated,Off-topic,Spam,Resolved", ","),
 		},
+
+		// Go module proxy settings
+		GoProxy: struct {
+			Enabled bool
+		}{
+			Enabled: true,
+		},
 	}
 	RepoRootPath string
 	ScriptType   = "bash"
@@ -312,11 +312,13 @@ var (
 		InvalidateRefreshTokens    bool
 		JWTSecretBytes             []byte `ini:"-"`
 		JWTSecretBase64            string `ini:"JWT_SECRET"`
+		JWTSigningPrivateKeyFile   string `ini:"JWT_SIGNING_PRIVATE_KEY_FILE"`
 	}{
 		Enable:                     true,
 		AccessTokenExpirationTime:  3600,
 		RefreshTokenExpirationTime: 730,
-		InvalidateRefreshTokens:    false,
+		InvalidateRefreshTokens:    true,
+		JWTSigningPrivateKeyFile:   "jwt/private.pem",
 	}
 
 	U2F = struct {
@@ -732,6 +734,10 @@ func NewContext() {
 		return
 	}
 
+	if !filepath.IsAbs(OAuth2.JWTSigningPrivateKeyFile) {
+		OAuth2.JWTSigningPrivateKeyFile = filepath.Join(AppDataPath, OAuth2.JWTSigningPrivateKeyFile)
+	}
+
 	if OAuth2.Enable {
 		OAuth2.JWTSecretBytes = make([]byte, 32)
 		n, err := base64.RawURLEncoding.Decode(OAuth2.JWTSecretBytes, []byte(OAuth2.JWTSecretBase64))
@@ -905,6 +911,9 @@ func NewContext() {
 
 	newCron()
 	newGit()
+	newStorage()
+	newActions()
+	newPackages()
 
 	sec = Cfg.Section("mirror")
 	Mirror.MinInterval = sec.Key("MIN_INTERVAL").MustDuration(10 * time.Minute)
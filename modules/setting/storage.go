@@ -0,0 +1,81 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package setting
+
+import (
+	"path/filepath"
+	"strings"
+
+	ini "gopkg.in/ini.v1"
+)
+
+// enumerates all the storage types
+const (
+	LocalStorageType = "local"
+	S3StorageType    = "s3"
+)
+
+// Storage represents the configuration of the storage backend of one kind of data
+type Storage struct {
+	Type string
+	// Path is the directory used by the local storage
+	Path string
+
+	// S3 compatible storage settings
+	S3Endpoint        string
+	S3AccessKeyID     string
+	S3SecretAccessKey string
+	S3Bucket          string
+	S3Region          string
+	S3BasePath        string
+	S3UseSSL          bool
+}
+
+// Storage settings of every kind of data kept out of the git repositories
+var (
+	LFSStorage        Storage
+	AttachmentStorage Storage
+	AvatarStorage     Storage
+	RepoAvatarStorage Storage
+	PackageStorage    Storage
+)
+
+// getStorage reads the storage settings of a kind from its [storage.<name>]
+// section. Keys missing there fall back to the [storage] section.
+func getStorage(name, defaultPath string) Storage {
+	base := Cfg.Section("storage")
+	sec := Cfg.Section("storage." + name)
+
+	key := func(k string) *ini.Key {
+		if sec.HasKey(k) {
+			return sec.Key(k)
+		}
+		return base.Key(k)
+	}
+
+	storage := Storage{
+		Type:              key("STORAGE_TYPE").In(LocalStorageType, []string{LocalStorageType, S3StorageType}),
+		Path:              sec.Key("PATH").MustString(defaultPath),
+		S3Endpoint:        key("S3_ENDPOINT").MustString("localhost:9000"),
+		S3AccessKeyID:     key("S3_ACCESS_KEY_ID").String(),
+		S3SecretAccessKey: key("S3_SECRET_ACCESS_KEY").String(),
+		S3Bucket:          key("S3_BUCKET").MustString("gitea"),
+		S3Region:          key("S3_REGION").MustString("us-east-1"),
+		S3BasePath:        sec.Key("S3_BASE_PATH").MustString(name + "/"),
+		S3UseSSL:          key("S3_USE_SSL").MustBool(false),
+	}
+	if storage.S3BasePath != "" && !strings.HasSuffix(storage.S3BasePath, "/") {
+		storage.S3BasePath += "/"
+	}
+	return storage
+}
+
+func newStorage() {
+	LFSStorage = getStorage("lfs", LFS.ContentPath)
+	AttachmentStorage = getStorage("attachments", AttachmentPath)
+	AvatarStorage = getStorage("avatars", AvatarUploadPath)
+	RepoAvatarStorage = getStorage("repo-avatars", RepositoryAvatarUploadPath)
+	PackageStorage = getStorage("packages", filepath.Join(AppDataPath, "packages"))
+}
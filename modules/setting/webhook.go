@@ -4,27 +4,56 @@
 
 package setting
 
+import (
+	"path"
+	"path/filepath"
+)
+
 var (
 	// Webhook settings
 	Webhook = struct {
-		QueueLength    int
-		DeliverTimeout int
-		SkipTLSVerify  bool
-		Types          []string
-		PagingNum      int
+		QueueLength        int
+		QueueType          string
+		QueueDir           string
+		QueueConnStr       string
+		DeliverTimeout     int
+		DeliverConcurrency int
+		MaxRetries         int
+		RetryBackoff       int
+		MaxRetryBackoff    int
+		SkipTLSVerify      bool
+		Types              []string
+		PagingNum          int
 	}{
-		QueueLength:    1000,
-		DeliverTimeout: 5,
-		SkipTLSVerify:  false,
-		PagingNum:      10,
+		QueueLength:        1000,
+		QueueType:          LevelQueueType,
+		QueueDir:           "queues/webhook",
+		QueueConnStr:       "",
+		DeliverTimeout:     5,
+		DeliverConcurrency: 1,
+		MaxRetries:         5,
+		RetryBackoff:       10,
+		MaxRetryBackoff:    3600,
+		SkipTLSVerify:      false,
+		PagingNum:          10,
 	}
 )
 
 func newWebhookService() {
 	sec := Cfg.Section("webhook")
 	Webhook.QueueLength = sec.Key("QUEUE_LENGTH").MustInt(1000)
+	Webhook.QueueType = sec.Key("QUEUE_TYPE").MustString(LevelQueueType)
+	Webhook.QueueDir = sec.Key("QUEUE_DIR").MustString(path.Join(AppDataPath, "queues/webhook"))
+	if !filepath.IsAbs(Webhook.QueueDir) {
+		Webhook.QueueDir = path.Join(AppWorkPath, Webhook.QueueDir)
+	}
+	Webhook.QueueConnStr = sec.Key("QUEUE_CONN_STR").MustString("addrs=127.0.0.1:6379 db=0")
 	Webhook.DeliverTimeout = sec.Key("DELIVER_TIMEOUT").MustInt(5)
+	Webhook.DeliverConcurrency = sec.Key("DELIVER_CONCURRENCY").MustInt(1)
+	Webhook.MaxRetries = sec.Key("MAX_RETRIES").MustInt(5)
+	Webhook.RetryBackoff = sec.Key("RETRY_BACKOFF").MustInt(10)
+	Webhook.MaxRetryBackoff = sec.Key("MAX_RETRY_BACKOFF").MustInt(3600)
 	Webhook.SkipTLSVerify = sec.Key("SKIP_TLS_VERIFY").MustBool()
-	Webhook.Types = []string{"gitea", "gogs", "slack", "discord", "dingtalk", "telegram", "msteams"}
+	Webhook.Types = []string{"gitea", "gogs", "slack", "discord", "dingtalk", "telegram", "msteams", "matrix", "custom"}
 	Webhook.PagingNum = sec.Key("PAGING_NUM").MustInt(10)
 }
@@ -19,6 +19,7 @@ import (
 	"syscall"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
 
@@ -70,6 +71,12 @@ func sessionHandler(session ssh.Session) {
 		"SSH_ORIGINAL_COMMAND="+command,
 		"SKIP_MINWINSVC=1",
 	)
+	// the wire protocol requested by the client, it is checked by the serv command
+	for _, env := range session.Environ() {
+		if strings.HasPrefix(env, git.EnvProtocol+"=") {
+			cmd.Env = append(cmd.Env, env)
+		}
+	}
 
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
@@ -0,0 +1,50 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"net/http"
+	"os"
+	"strings"
+)
+
+// FileSystem returns a http.FileSystem serving the objects of the given storage
+func FileSystem(s ObjectStorage) http.FileSystem {
+	return fileSystem{s}
+}
+
+type fileSystem struct {
+	storage ObjectStorage
+}
+
+func (fs fileSystem) Open(name string) (http.File, error) {
+	name = strings.TrimPrefix(name, "/")
+	if name == "" {
+		return nil, os.ErrNotExist
+	}
+	fi, err := fs.storage.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := fs.storage.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &file{Object: obj, info: fi}, nil
+}
+
+// file implements http.File for an object, objects are never directories
+type file struct {
+	Object
+	info os.FileInfo
+}
+
+func (f *file) Readdir(count int) ([]os.FileInfo, error) {
+	return nil, os.ErrInvalid
+}
+
+func (f *file) Stat() (os.FileInfo, error) {
+	return f.info, nil
+}
@@ -0,0 +1,99 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStorage represents a storage backed by a directory on the local disk
+type LocalStorage struct {
+	dir string
+}
+
+// NewLocalStorage returns a local storage rooted at dir
+func NewLocalStorage(dir string) (*LocalStorage, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	return &LocalStorage{dir: dir}, nil
+}
+
+func (l *LocalStorage) localPath(path string) string {
+	return filepath.Join(l.dir, filepath.FromSlash(path))
+}
+
+// Open returns the file stored at path
+func (l *LocalStorage) Open(path string) (Object, error) {
+	return os.Open(l.localPath(path))
+}
+
+// Save writes the content of r to path. The content is written to a temporary
+// file first so that readers never see partially written objects.
+func (l *LocalStorage) Save(path string, r io.Reader) (int64, error) {
+	p := l.localPath(path)
+	if err := os.MkdirAll(filepath.Dir(p), os.ModePerm); err != nil {
+		return 0, err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(p), filepath.Base(p)+".*.tmp")
+	if err != nil {
+		return 0, err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	n, err := io.Copy(tmp, r)
+	if err != nil {
+		tmp.Close()
+		return 0, err
+	}
+	if err = tmp.Close(); err != nil {
+		return 0, err
+	}
+	if err = os.Chmod(tmpPath, 0644); err != nil {
+		return 0, err
+	}
+	return n, os.Rename(tmpPath, p)
+}
+
+// Stat returns the information of the file stored at path
+func (l *LocalStorage) Stat(path string) (os.FileInfo, error) {
+	return os.Stat(l.localPath(path))
+}
+
+// Delete removes the file stored at path
+func (l *LocalStorage) Delete(path string) error {
+	if err := os.Remove(l.localPath(path)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// IterateObjects calls fn for every file below the storage directory
+func (l *LocalStorage) IterateObjects(fn func(path string, obj Object) error) error {
+	return filepath.Walk(l.dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || strings.HasSuffix(p, ".tmp") {
+			return nil
+		}
+		relPath, err := filepath.Rel(l.dir, p)
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return fn(filepath.ToSlash(relPath), f)
+	})
+}
@@ -0,0 +1,80 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("read failed")
+}
+
+func testObjectStorage(t *testing.T, s ObjectStorage) {
+	n, err := s.Save("a/b/c", strings.NewReader("hello world"))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 11, n)
+	_, err = s.Save("d", strings.NewReader("gitea"))
+	assert.NoError(t, err)
+
+	// a failed save keeps the stored object
+	_, err = s.Save("d", io.MultiReader(strings.NewReader("broken"), failingReader{}))
+	assert.Error(t, err)
+	obj, err := s.Open("d")
+	assert.NoError(t, err)
+	data, err := ioutil.ReadAll(obj)
+	assert.NoError(t, err)
+	assert.Equal(t, "gitea", string(data))
+	assert.NoError(t, obj.Close())
+
+	fi, err := s.Stat("a/b/c")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 11, fi.Size())
+
+	_, err = s.Stat("missing")
+	assert.True(t, os.IsNotExist(err))
+
+	obj, err = s.Open("a/b/c")
+	assert.NoError(t, err)
+	_, err = obj.Seek(6, os.SEEK_SET)
+	assert.NoError(t, err)
+	data, err = ioutil.ReadAll(obj)
+	assert.NoError(t, err)
+	assert.Equal(t, "world", string(data))
+	assert.NoError(t, obj.Close())
+
+	var paths []string
+	assert.NoError(t, s.IterateObjects(func(p string, obj Object) error {
+		paths = append(paths, p)
+		return nil
+	}))
+	sort.Strings(paths)
+	assert.Equal(t, []string{"a/b/c", "d"}, paths)
+
+	assert.NoError(t, s.Delete("a/b/c"))
+	assert.NoError(t, s.Delete("a/b/c"))
+	_, err = s.Stat("a/b/c")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestLocalStorage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "storage")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := NewLocalStorage(dir)
+	assert.NoError(t, err)
+	testObjectStorage(t, s)
+}
@@ -0,0 +1,474 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	s3Algorithm       = "AWS4-HMAC-SHA256"
+	s3DateFormat      = "20060102T150405Z"
+	s3ShortDateFormat = "20060102"
+	emptyPayloadHash  = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+// S3Options represents the options of a S3 compatible storage
+type S3Options struct {
+	Endpoint        string
+	AccessKeyID     string
+	SecretAccessKey string
+	Bucket          string
+	Region          string
+	BasePath        string
+	UseSSL          bool
+}
+
+// S3Storage represents a storage backed by a bucket of an S3 compatible
+// object storage such as Amazon S3 or MinIO. Requests are authenticated with
+// AWS signature version 4 and buckets are addressed in path style.
+type S3Storage struct {
+	opts    S3Options
+	baseURL string
+	client  *http.Client
+}
+
+// NewS3Storage returns a S3 storage and creates its bucket if it does not exist
+func NewS3Storage(opts S3Options) (*S3Storage, error) {
+	scheme := "http"
+	if opts.UseSSL {
+		scheme = "https"
+	}
+	s := &S3Storage{
+		opts:    opts,
+		baseURL: scheme + "://" + opts.Endpoint + "/" + opts.Bucket,
+		client:  &http.Client{},
+	}
+
+	if err := s.makeBucket(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *S3Storage) makeBucket() error {
+	resp, err := s.do(http.MethodHead, "", nil, nil, emptyPayloadHash, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+	default:
+		return fmt.Errorf("unable to check bucket %s: %s", s.opts.Bucket, resp.Status)
+	}
+
+	var body []byte
+	if s.opts.Region != "" && s.opts.Region != "us-east-1" {
+		body = []byte(`<CreateBucketConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/"><LocationConstraint>` +
+			s.opts.Region + `</LocationConstraint></CreateBucketConfiguration>`)
+	}
+	resp, err = s.do(http.MethodPut, "", nil, nil, hashBytes(body), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return s.responseError("create bucket", s.opts.Bucket, resp)
+	}
+	return nil
+}
+
+// keyPrefix returns the prefix of the keys of the objects below the base path
+func (s *S3Storage) keyPrefix() string {
+	base := strings.Trim(path.Clean("/"+s.opts.BasePath), "/")
+	if base == "" {
+		return ""
+	}
+	return base + "/"
+}
+
+func (s *S3Storage) objectKey(p string) string {
+	return s.keyPrefix() + path.Clean("/" + p)[1:]
+}
+
+// Open returns the object stored at path. Content is fetched lazily so that
+// seeking before the first read results in a ranged request.
+func (s *S3Storage) Open(p string) (Object, error) {
+	fi, err := s.Stat(p)
+	if err != nil {
+		return nil, err
+	}
+	return &s3Object{storage: s, key: s.objectKey(p), size: fi.Size()}, nil
+}
+
+// Save uploads the content of r to path. The content is spooled to a temporary
+// file first since S3 requires the length and the hash of the payload upfront.
+func (s *S3Storage) Save(p string, r io.Reader) (int64, error) {
+	tmp, err := ioutil.TempFile("", "gitea-s3-upload")
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}()
+
+	hash := sha256.New()
+	n, err := io.Copy(io.MultiWriter(tmp, hash), r)
+	if err != nil {
+		return 0, err
+	}
+	if _, err = tmp.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	header := http.Header{}
+	header.Set("Content-Type", "application/octet-stream")
+	resp, err := s.doObject(http.MethodPut, s.objectKey(p), header, hex.EncodeToString(hash.Sum(nil)), &sizedReader{tmp, n})
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, s.responseError("save", p, resp)
+	}
+	return n, nil
+}
+
+// Stat returns the information of the object stored at path
+func (s *S3Storage) Stat(p string) (os.FileInfo, error) {
+	resp, err := s.doObject(http.MethodHead, s.objectKey(p), nil, emptyPayloadHash, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, &os.PathError{Op: "stat", Path: p, Err: os.ErrNotExist}
+	default:
+		return nil, s.responseError("stat", p, resp)
+	}
+
+	modTime, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	return &s3FileInfo{name: path.Base(p), size: resp.ContentLength, modTime: modTime}, nil
+}
+
+// Delete removes the object stored at path
+func (s *S3Storage) Delete(p string) error {
+	resp, err := s.doObject(http.MethodDelete, s.objectKey(p), nil, emptyPayloadHash, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return s.responseError("delete", p, resp)
+	}
+	return nil
+}
+
+type s3ListBucketResult struct {
+	IsTruncated           bool
+	NextContinuationToken string
+	Contents              []struct {
+		Key  string
+		Size int64
+	}
+}
+
+// IterateObjects calls fn for every object below the base path of the storage
+func (s *S3Storage) IterateObjects(fn func(path string, obj Object) error) error {
+	prefix := s.keyPrefix()
+	var token string
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", prefix)
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		resp, err := s.do(http.MethodGet, "", query, nil, emptyPayloadHash, nil)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			err = s.responseError("list", prefix, resp)
+			resp.Body.Close()
+			return err
+		}
+		var result s3ListBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+
+		for _, content := range result.Contents {
+			obj := &s3Object{storage: s, key: content.Key, size: content.Size}
+			err := fn(strings.TrimPrefix(content.Key, prefix), obj)
+			obj.Close()
+			if err != nil {
+				return err
+			}
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+func (s *S3Storage) responseError(op, p string, resp *http.Response) error {
+	var s3Err struct {
+		Code    string
+		Message string
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&s3Err); err == nil && s3Err.Code != "" {
+		return fmt.Errorf("s3 %s %s: %s: %s", op, p, s3Err.Code, s3Err.Message)
+	}
+	return fmt.Errorf("s3 %s %s: %s", op, p, resp.Status)
+}
+
+func (s *S3Storage) doObject(method, key string, header http.Header, payloadHash string, body io.Reader) (*http.Response, error) {
+	return s.do(method, key, nil, header, payloadHash, body)
+}
+
+func (s *S3Storage) do(method, key string, query url.Values, header http.Header, payloadHash string, body io.Reader) (*http.Response, error) {
+	u := s.baseURL
+	if key != "" {
+		u += "/" + s3EscapePath(key)
+	}
+	if len(query) > 0 {
+		u += "?" + s3EncodeQuery(query)
+	}
+
+	req, err := http.NewRequest(method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	if sr, ok := body.(*sizedReader); ok {
+		req.ContentLength = sr.size
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	signS3Request(req, s.opts.AccessKeyID, s.opts.SecretAccessKey, s.opts.Region, payloadHash, time.Now())
+	return s.client.Do(req)
+}
+
+// sizedReader is a reader of which the length is known upfront
+type sizedReader struct {
+	io.Reader
+	size int64
+}
+
+// s3Object is a seekable reader of an object, a ranged GET request is issued
+// at the first read after opening or seeking.
+type s3Object struct {
+	storage *S3Storage
+	key     string
+	size    int64
+	offset  int64
+	body    io.ReadCloser
+}
+
+func (o *s3Object) Read(p []byte) (int, error) {
+	if o.body == nil {
+		if o.offset >= o.size {
+			return 0, io.EOF
+		}
+		header := http.Header{}
+		if o.offset > 0 {
+			header.Set("Range", fmt.Sprintf("bytes=%d-", o.offset))
+		}
+		resp, err := o.storage.doObject(http.MethodGet, o.key, header, emptyPayloadHash, nil)
+		if err != nil {
+			return 0, err
+		}
+		if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+			defer resp.Body.Close()
+			return 0, o.storage.responseError("get", o.key, resp)
+		}
+		o.body = resp.Body
+	}
+	n, err := o.body.Read(p)
+	o.offset += int64(n)
+	return n, err
+}
+
+func (o *s3Object) Seek(offset int64, whence int) (int64, error) {
+	var abs int64
+	switch whence {
+	case io.SeekStart:
+		abs = offset
+	case io.SeekCurrent:
+		abs = o.offset + offset
+	case io.SeekEnd:
+		abs = o.size + offset
+	default:
+		return 0, errors.New("s3Object.Seek: invalid whence")
+	}
+	if abs < 0 {
+		return 0, errors.New("s3Object.Seek: negative position")
+	}
+	if abs != o.offset && o.body != nil {
+		o.body.Close()
+		o.body = nil
+	}
+	o.offset = abs
+	return abs, nil
+}
+
+func (o *s3Object) Close() error {
+	if o.body == nil {
+		return nil
+	}
+	err := o.body.Close()
+	o.body = nil
+	return err
+}
+
+type s3FileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+}
+
+func (fi *s3FileInfo) Name() string       { return fi.name }
+func (fi *s3FileInfo) Size() int64        { return fi.size }
+func (fi *s3FileInfo) Mode() os.FileMode  { return 0644 }
+func (fi *s3FileInfo) ModTime() time.Time { return fi.modTime }
+func (fi *s3FileInfo) IsDir() bool        { return false }
+func (fi *s3FileInfo) Sys() interface{}   { return nil }
+
+// signS3Request adds an AWS signature version 4 to the request. All the
+// headers already present on the request are signed.
+func signS3Request(req *http.Request, accessKeyID, secretAccessKey, region, payloadHash string, t time.Time) {
+	t = t.UTC()
+	amzDate := t.Format(s3DateFormat)
+	shortDate := t.Format(s3ShortDateFormat)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		headers[strings.ToLower(k)] = strings.TrimSpace(strings.Join(v, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalURI := req.URL.EscapedPath()
+	if canonicalURI == "" {
+		canonicalURI = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI,
+		s3EncodeQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := shortDate + "/" + region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		s3Algorithm,
+		amzDate,
+		scope,
+		hashBytes([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+secretAccessKey), shortDate)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, accessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func hashBytes(data []byte) string {
+	h := sha256.Sum256(data)
+	return hex.EncodeToString(h[:])
+}
+
+// s3Escape escapes a string as required by the signature: everything but the
+// unreserved characters of RFC 3986 is percent encoded.
+func s3Escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func s3EscapePath(p string) string {
+	segments := strings.Split(p, "/")
+	for i := range segments {
+		segments[i] = s3Escape(segments[i])
+	}
+	return strings.Join(segments, "/")
+}
+
+func s3EncodeQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		values := query[k]
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, s3Escape(k)+"="+s3Escape(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
@@ -0,0 +1,100 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"code.gitea.io/gitea/modules/setting"
+)
+
+// Object represents an object read from an ObjectStorage
+type Object interface {
+	io.ReadCloser
+	io.Seeker
+}
+
+// ObjectStorage represents a storage of objects addressed by slash separated paths
+type ObjectStorage interface {
+	// Open returns the object stored at path
+	Open(path string) (Object, error)
+	// Save stores the content of r at path and returns the number of bytes written.
+	// If reading r fails, the object previously stored at path is left untouched.
+	Save(path string, r io.Reader) (int64, error)
+	// Stat returns the information of the object at path. If the object does
+	// not exist the returned error satisfies os.IsNotExist.
+	Stat(path string) (os.FileInfo, error)
+	// Delete removes the object at path, deleting an object which does not exist is not an error
+	Delete(path string) error
+	// IterateObjects calls fn for every object in the storage
+	IterateObjects(fn func(path string, obj Object) error) error
+}
+
+// Copy copies the object at srcPath in srcStorage to dstPath in dstStorage
+func Copy(dstStorage ObjectStorage, dstPath string, srcStorage ObjectStorage, srcPath string) (int64, error) {
+	f, err := srcStorage.Open(srcPath)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	return dstStorage.Save(dstPath, f)
+}
+
+var (
+	// LFS represents the storage of LFS objects
+	LFS ObjectStorage
+	// Attachments represents the storage of issue and release attachments
+	Attachments ObjectStorage
+	// Avatars represents the storage of user avatars
+	Avatars ObjectStorage
+	// RepoAvatars represents the storage of repository avatars
+	RepoAvatars ObjectStorage
+	// Packages represents the storage of the content of package files
+	Packages ObjectStorage
+)
+
+// NewStorage creates the storage described by cfg
+func NewStorage(cfg setting.Storage) (ObjectStorage, error) {
+	switch cfg.Type {
+	case setting.LocalStorageType:
+		return NewLocalStorage(cfg.Path)
+	case setting.S3StorageType:
+		return NewS3Storage(S3Options{
+			Endpoint:        cfg.S3Endpoint,
+			AccessKeyID:     cfg.S3AccessKeyID,
+			SecretAccessKey: cfg.S3SecretAccessKey,
+			Bucket:          cfg.S3Bucket,
+			Region:          cfg.S3Region,
+			BasePath:        cfg.S3BasePath,
+			UseSSL:          cfg.S3UseSSL,
+		})
+	default:
+		return nil, fmt.Errorf("unsupported storage type: %s", cfg.Type)
+	}
+}
+
+// Init initializes the storages of all kinds of data from the settings
+func Init() error {
+	var err error
+	if LFS, err = NewStorage(setting.LFSStorage); err != nil {
+		return fmt.Errorf("LFS storage: %v", err)
+	}
+	if Attachments, err = NewStorage(setting.AttachmentStorage); err != nil {
+		return fmt.Errorf("attachment storage: %v", err)
+	}
+	if Avatars, err = NewStorage(setting.AvatarStorage); err != nil {
+		return fmt.Errorf("avatar storage: %v", err)
+	}
+	if RepoAvatars, err = NewStorage(setting.RepoAvatarStorage); err != nil {
+		return fmt.Errorf("repository avatar storage: %v", err)
+	}
+	if Packages, err = NewStorage(setting.PackageStorage); err != nil {
+		return fmt.Errorf("package storage: %v", err)
+	}
+	return nil
+}
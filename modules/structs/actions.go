@@ -0,0 +1,60 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package structs
+
+// ActionRunnerRegisterRequest is sent by a runner to register itself with a registration token
+type ActionRunnerRegisterRequest struct {
+	Token  string   `json:"token" binding:"Required"`
+	Name   string   `json:"name" binding:"Required"`
+	Labels []string `json:"labels"`
+}
+
+// ActionRunnerRegisterResponse holds the credentials of a newly registered runner
+type ActionRunnerRegisterResponse struct {
+	ID   int64  `json:"id"`
+	UUID string `json:"uuid"`
+	// Token authenticates the runner in all the following requests
+	Token string `json:"token"`
+}
+
+// ActionStep represents a shell command executed by a job
+type ActionStep struct {
+	Name string            `json:"name"`
+	Run  string            `json:"run"`
+	Env  map[string]string `json:"env,omitempty"`
+}
+
+// ActionJob represents a job assigned to a runner
+type ActionJob struct {
+	ID       int64             `json:"id"`
+	RunID    int64             `json:"run_id"`
+	Name     string            `json:"name"`
+	Repo     string            `json:"repo"`
+	CloneURL string            `json:"clone_url"`
+	Event    string            `json:"event"`
+	Ref      string            `json:"ref"`
+	SHA      string            `json:"sha"`
+	RunsOn   []string          `json:"runs_on"`
+	Env      map[string]string `json:"env"`
+	Steps    []*ActionStep     `json:"steps"`
+	// Token is the password of the clone URL, it is valid while the job is running
+	Token string `json:"token"`
+}
+
+// ActionJobLogRequest appends lines to the log of a job, Offset is the index of the first line
+type ActionJobLogRequest struct {
+	Offset int64    `json:"offset"`
+	Lines  []string `json:"lines"`
+}
+
+// ActionJobLogResponse holds the number of lines of the log received so far
+type ActionJobLogResponse struct {
+	Ack int64 `json:"ack"`
+}
+
+// ActionJobResultRequest reports the result of a job, one of "success", "failure" or "cancelled"
+type ActionJobResultRequest struct {
+	Result string `json:"result" binding:"Required"`
+}
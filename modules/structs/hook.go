@@ -105,6 +105,8 @@ var (
 	_ Payloader = &PullRequestPayload{}
 	_ Payloader = &RepositoryPayload{}
 	_ Payloader = &ReleasePayload{}
+	_ Payloader = &UserPayload{}
+	_ Payloader = &OrganizationPayload{}
 )
 
 // _________                        __
@@ -463,3 +465,55 @@ func (p *RepositoryPayload) SetSecret(secret string) {
 func (p *RepositoryPayload) JSONPayload() ([]byte, error) {
 	return json.MarshalIndent(p, "", " ")
 }
+
+// ____ ___
+// |    |   \______ ___________
+// |    |   /  ___// __ \_  __ \
+// |    |  /\___ \\  ___/|  | \/
+// |______//____  >\___  >__|
+//              \/     \/
+
+// HookUserAction an action that happens to a user or an organization
+type HookUserAction string
+
+const (
+	// HookUserCreated created
+	HookUserCreated HookUserAction = "created"
+	// HookUserDeleted deleted
+	HookUserDeleted HookUserAction = "deleted"
+)
+
+// UserPayload payload for user webhooks
+type UserPayload struct {
+	Secret string         `json:"secret"`
+	Action HookUserAction `json:"action"`
+	User   *User          `json:"user"`
+}
+
+// SetSecret modifies the secret of the UserPayload
+func (p *UserPayload) SetSecret(secret string) {
+	p.Secret = secret
+}
+
+// JSONPayload JSON representation of the payload
+func (p *UserPayload) JSONPayload() ([]byte, error) {
+	return json.MarshalIndent(p, "", " ")
+}
+
+// OrganizationPayload payload for organization webhooks
+type OrganizationPayload struct {
+	Secret       string         `json:"secret"`
+	Action       HookUserAction `json:"action"`
+	Organization *Organization  `json:"organization"`
+	Sender       *User          `json:"sender,omitempty"`
+}
+
+// SetSecret modifies the secret of the OrganizationPayload
+func (p *OrganizationPayload) SetSecret(secret string) {
+	p.Secret = secret
+}
+
+// JSONPayload JSON representation of the payload
+func (p *OrganizationPayload) JSONPayload() ([]byte, error) {
+	return json.MarshalIndent(p, "", " ")
+}
@@ -0,0 +1,90 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package structs
+
+import (
+	"time"
+)
+
+// Project represents a board of issues and pull requests of a repository or an organization
+type Project struct {
+	ID          int64     `json:"id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	State       StateType `json:"state"`
+	// RepoID is 0 for the projects of an organization
+	RepoID       int64  `json:"repo_id"`
+	OwnerID      int64  `json:"owner_id"`
+	Creator      *User  `json:"creator"`
+	OpenIssues   int    `json:"open_issues"`
+	ClosedIssues int    `json:"closed_issues"`
+	HTMLURL      string `json:"html_url"`
+	// swagger:strfmt date-time
+	Created time.Time `json:"created_at"`
+	// swagger:strfmt date-time
+	Updated time.Time `json:"updated_at"`
+	// swagger:strfmt date-time
+	Closed *time.Time `json:"closed_at"`
+}
+
+// CreateProjectOption options for creating a project
+type CreateProjectOption struct {
+	// required:true
+	Title       string `json:"title" binding:"Required"`
+	Description string `json:"description"`
+}
+
+// EditProjectOption options for editing a project
+type EditProjectOption struct {
+	Title       *string `json:"title"`
+	Description *string `json:"description"`
+	// enum: open,closed
+	State *string `json:"state"`
+}
+
+// ProjectBoard represents a column of a project
+type ProjectBoard struct {
+	ID      int64  `json:"id"`
+	Title   string `json:"title"`
+	Sorting int    `json:"sorting"`
+	// the event moving the issues to the board automatically
+	// enum: none,closed,merged
+	Trigger string `json:"trigger"`
+}
+
+// CreateProjectBoardOption options for creating a project board
+type CreateProjectBoardOption struct {
+	// required:true
+	Title string `json:"title" binding:"Required"`
+	// enum: none,closed,merged
+	Trigger string `json:"trigger"`
+}
+
+// EditProjectBoardOption options for editing a project board
+type EditProjectBoardOption struct {
+	Title   *string `json:"title"`
+	Sorting *int    `json:"sorting"`
+	// enum: none,closed,merged
+	Trigger *string `json:"trigger"`
+}
+
+// ProjectCard represents an issue or a pull request in a project
+type ProjectCard struct {
+	// the board of the card, 0 for the uncategorized column
+	BoardID int64  `json:"board_id"`
+	Sorting int    `json:"sorting"`
+	Issue   *Issue `json:"issue"`
+}
+
+// MoveProjectCardOption options for adding an issue to a project or moving its card
+type MoveProjectCardOption struct {
+	// the ID of the issue or pull request
+	// required:true
+	IssueID int64 `json:"issue_id" binding:"Required"`
+	// the target board, 0 for the uncategorized column
+	BoardID int64 `json:"board_id"`
+	// the position in the board, the card is added at the end if it is negative
+	Position int `json:"position"`
+}
@@ -27,6 +27,7 @@ type Repository struct {
 	Fork          bool        `json:"fork"`
 	Parent        *Repository `json:"parent"`
 	Mirror        bool        `json:"mirror"`
+	Template      bool        `json:"template"`
 	Size          int         `json:"size"`
 	HTMLURL       string      `json:"html_url"`
 	SSHURL        string      `json:"ssh_url"`
@@ -111,6 +112,61 @@ type EditRepoOption struct {
 	AllowSquash *bool `json:"allow_squash_merge,omitempty"`
 	// set to `true` to archive this repository.
 	Archived *bool `json:"archived,omitempty"`
+	// either `true` to make this repository a template or `false` to make it a normal repository
+	Template *bool `json:"template,omitempty"`
+}
+
+// GenerateRepoOption options when creating repository using a template
+// swagger:model
+type GenerateRepoOption struct {
+	// The organization or person who will own the new repository
+	//
+	// required: true
+	Owner string `json:"owner"`
+	// Name of the repository to create
+	//
+	// required: true
+	// unique: true
+	Name string `json:"name" binding:"Required;AlphaDashDot;MaxSize(100)"`
+	// Description of the repository to create
+	Description string `json:"description" binding:"MaxSize(255)"`
+	// Whether the repository is private
+	Private bool `json:"private"`
+	// include git content of default branch in template repo
+	GitContent bool `json:"git_content"`
+	// include topics in template repo
+	Topics bool `json:"topics"`
+	// include webhooks in template repo
+	Webhooks bool `json:"webhooks"`
+	// include avatar of the template repo
+	Avatar bool `json:"avatar"`
+	// include labels in template repo
+	Labels bool `json:"labels"`
+}
+
+// GitServiceType represents the type of the git service a repository is migrated from
+type GitServiceType int
+
+// enumerate all the supported git services
+const (
+	// NotMigrated means the type of the git service is not specified, it is detected from the clone address
+	NotMigrated GitServiceType = iota
+	PlainGitService
+	GithubService
+	GitlabService
+)
+
+// Name returns the name of the git service
+func (gt GitServiceType) Name() string {
+	switch gt {
+	case PlainGitService:
+		return "git"
+	case GithubService:
+		return "github"
+	case GitlabService:
+		return "gitlab"
+	}
+	return ""
 }
 
 // MigrateRepoOption options for migrating a repository from an external service
@@ -127,3 +183,29 @@ type MigrateRepoOption struct {
 	Private     bool   `json:"private"`
 	Description string `json:"description"`
 }
+
+// RetryMigrationOption options for retrying a failed migration, the credentials
+// of a migration are not kept when it fails
+type RetryMigrationOption struct {
+	AuthUsername string `json:"auth_username"`
+	AuthPassword string `json:"auth_password"`
+}
+
+// RepoTask represents a background task of a repository, e.g. its migration
+type RepoTask struct {
+	ID int64 `json:"id"`
+	// status of the task, one of `queue`, `running`, `failed` or `finished`
+	Status string `json:"status"`
+	// the stage the task is working on or failed in
+	Stage string `json:"stage"`
+	// the error of the task if it failed
+	Message string `json:"message"`
+	// swagger:strfmt date-time
+	Created time.Time `json:"created_at"`
+	// swagger:strfmt date-time
+	Updated time.Time `json:"updated_at"`
+	// swagger:strfmt date-time
+	Started *time.Time `json:"started_at"`
+	// swagger:strfmt date-time
+	Stopped *time.Time `json:"stopped_at"`
+}
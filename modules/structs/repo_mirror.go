@@ -0,0 +1,35 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package structs
+
+import (
+	"time"
+)
+
+// PushMirror represents a remote repository a repository is pushed to
+type PushMirror struct {
+	ID            int64  `json:"id"`
+	RemoteName    string `json:"remote_name"`
+	RemoteAddress string `json:"remote_address"`
+	// Interval between automatic pushes, 0 if only pushed after changes
+	Interval string `json:"interval"`
+	// swagger:strfmt date-time
+	Created time.Time `json:"created_at"`
+	// swagger:strfmt date-time
+	LastUpdate *time.Time `json:"last_update,omitempty"`
+	LastError  string     `json:"last_error"`
+}
+
+// CreatePushMirrorOption options when creating a push mirror
+type CreatePushMirrorOption struct {
+	// URL of the remote repository, http(s) or git
+	//
+	// required: true
+	RemoteAddress  string `json:"remote_address" binding:"Required"`
+	RemoteUsername string `json:"remote_username"`
+	RemotePassword string `json:"remote_password"`
+	// Interval between automatic pushes (valid time units are 'h', 'm', 's'), 0 to only push after changes
+	Interval string `json:"interval"`
+}
@@ -17,10 +17,14 @@ func BasicAuthEncode(user, pass string) string {
 // AccessToken represents an API access token.
 // swagger:response AccessToken
 type AccessToken struct {
-	ID             int64  `json:"id"`
-	Name           string `json:"name"`
-	Token          string `json:"sha1"`
-	TokenLastEight string `json:"token_last_eight"`
+	ID             int64    `json:"id"`
+	Name           string   `json:"name"`
+	Token          string   `json:"sha1"`
+	TokenLastEight string   `json:"token_last_eight"`
+	Scopes         []string `json:"scopes"`
+	// the full names of the repositories the token is restricted to, it can access
+	// all the repositories when it is empty
+	Repositories []string `json:"repositories"`
 }
 
 // AccessTokenList represents a list of API access token.
@@ -31,4 +35,8 @@ type AccessTokenList []*AccessToken
 // swagger:parameters userCreateToken
 type CreateAccessTokenOption struct {
 	Name string `json:"name" binding:"Required"`
+	// the scopes granted to the token, all the scopes are granted when it is empty
+	Scopes []string `json:"scopes"`
+	// the full names of the repositories the token is restricted to
+	Repositories []string `json:"repositories"`
 }
@@ -0,0 +1,163 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package task
+
+import (
+	"fmt"
+	"net/url"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/migrations"
+	"code.gitea.io/gitea/modules/notification"
+	"code.gitea.io/gitea/modules/util"
+)
+
+// MigrateRepository creates the repository and queues a task which migrates
+// its data in the background. The repository keeps the status
+// models.RepositoryBeingMigrated until the task has finished.
+// The remote repository is checked before, so that errors like failed
+// authentication are returned directly.
+func MigrateRepository(doer, u *models.User, opts migrations.MigrateOptions) (*models.Repository, error) {
+	if err := migrations.CheckRemote(u.Name, opts); err != nil {
+		return nil, err
+	}
+
+	task := &models.Task{
+		DoerID:  doer.ID,
+		OwnerID: u.ID,
+		Type:    models.TaskTypeMigrateRepo,
+		Status:  models.TaskStatusQueue,
+	}
+	if err := task.SetPayload(opts); err != nil {
+		return nil, err
+	}
+
+	repo, err := models.CreateRepository(doer, u, models.CreateRepoOptions{
+		Name:        opts.Name,
+		Description: opts.Description,
+		IsPrivate:   opts.Private,
+		IsMirror:    opts.Mirror,
+		Status:      models.RepositoryBeingMigrated,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	task.RepoID = repo.ID
+	if err := models.CreateTask(task); err != nil {
+		if errDelete := models.DeleteRepository(doer, u.ID, repo.ID); errDelete != nil {
+			log.Error("DeleteRepository: %v", errDelete)
+		}
+		return nil, err
+	}
+
+	taskQueue.Add(task.ID)
+	return repo, nil
+}
+
+// RetryMigrateTask queues a failed migration task again. The credentials are
+// removed from a task when it fails, so they have to be given again.
+func RetryMigrateTask(task *models.Task, authUsername, authPassword string) error {
+	var opts migrations.MigrateOptions
+	if err := task.GetPayload(&opts); err != nil {
+		return fmt.Errorf("GetPayload: %v", err)
+	}
+	opts.AuthUsername = authUsername
+	opts.AuthPassword = authPassword
+	if len(authUsername)+len(authPassword) > 0 {
+		if u, err := url.Parse(opts.RemoteURL); err == nil && u.Scheme != "" {
+			u.User = url.UserPassword(authUsername, authPassword)
+			opts.RemoteURL = u.String()
+		}
+	}
+	if err := task.SetPayload(opts); err != nil {
+		return err
+	}
+	if err := task.UpdateCols("payload_content"); err != nil {
+		return err
+	}
+	return RetryTask(task)
+}
+
+func runMigrateTask(task *models.Task) {
+	var opts migrations.MigrateOptions
+	defer func() {
+		if r := recover(); r != nil {
+			failMigrateTask(task, opts, fmt.Errorf("PANIC: %v", r))
+		}
+	}()
+
+	if err := task.LoadRepo(); err != nil {
+		log.Error("LoadRepo [task_id: %d]: %v", task.ID, err)
+		return
+	}
+	if err := task.LoadDoer(); err != nil {
+		log.Error("LoadDoer [task_id: %d]: %v", task.ID, err)
+		return
+	}
+	if err := task.GetPayload(&opts); err != nil {
+		failMigrateTask(task, opts, fmt.Errorf("GetPayload: %v", err))
+		return
+	}
+
+	task.Status = models.TaskStatusRunning
+	task.StartedUnix = util.TimeStampNow()
+	task.StoppedUnix = 0
+	if err := task.UpdateCols("status", "started_unix", "stopped_unix"); err != nil {
+		log.Error("UpdateCols [task_id: %d]: %v", task.ID, err)
+		return
+	}
+
+	checkpoint := migrations.Checkpoint{Stage: task.Stage, Page: task.StagePage, Comments: task.StageComments}
+	if err := migrations.MigrateRepository(task.Doer, task.Repo, opts, checkpoint, func(checkpoint migrations.Checkpoint) error {
+		task.Stage = checkpoint.Stage
+		task.StagePage = checkpoint.Page
+		task.StageComments = checkpoint.Comments
+		return task.UpdateCols("stage", "stage_page", "stage_comments")
+	}); err != nil {
+		failMigrateTask(task, opts, err)
+		return
+	}
+
+	if err := models.FinishMigrateTask(task); err != nil {
+		log.Error("FinishMigrateTask [task_id: %d]: %v", task.ID, err)
+		return
+	}
+
+	log.Trace("Repository migrated [%d]: %s", task.Repo.ID, task.Repo.FullName())
+	notification.NotifyCreateRepository(task.Doer, task.Repo.Owner, task.Repo)
+}
+
+// stripCredentials removes the credentials from the options of a migration
+func stripCredentials(opts migrations.MigrateOptions) migrations.MigrateOptions {
+	opts.AuthUsername = ""
+	opts.AuthPassword = ""
+	if u, err := url.Parse(opts.RemoteURL); err == nil {
+		u.User = nil
+		opts.RemoteURL = u.String()
+	}
+	return opts
+}
+
+func failMigrateTask(task *models.Task, opts migrations.MigrateOptions, err error) {
+	// the remote address may contain credentials, so we sanitize it
+	err = util.URLSanitizedError(err, opts.RemoteURL)
+	log.Error("Migration of repository %d failed: %v", task.RepoID, err)
+
+	task.Status = models.TaskStatusFailed
+	task.StoppedUnix = util.TimeStampNow()
+	task.Message = err.Error()
+	// the credentials are not kept, they have to be given again to retry the task
+	if opts.RemoteURL == "" {
+		task.PayloadContent = ""
+	} else if err := task.SetPayload(stripCredentials(opts)); err != nil {
+		log.Error("SetPayload [task_id: %d]: %v", task.ID, err)
+		task.PayloadContent = ""
+	}
+	if err := task.UpdateCols("status", "stopped_unix", "message", "payload_content"); err != nil {
+		log.Error("UpdateCols [task_id: %d]: %v", task.ID, err)
+	}
+}
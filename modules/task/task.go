@@ -0,0 +1,73 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package task
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/sync"
+
+	"github.com/Unknwon/com"
+)
+
+// taskQueue holds the ids of the tasks waiting to be run
+var taskQueue = sync.NewUniqueQueue(1000)
+
+// Init starts running the queued tasks, the tasks which were queued or running
+// when Gitea stopped are queued again.
+func Init() error {
+	tasks, err := models.FindTasks(models.FindTaskOptions{
+		Status: []models.TaskStatus{models.TaskStatusQueue, models.TaskStatusRunning},
+	})
+	if err != nil {
+		return fmt.Errorf("FindTasks: %v", err)
+	}
+
+	go run()
+
+	for _, task := range tasks {
+		taskQueue.Add(task.ID)
+	}
+	return nil
+}
+
+func run() {
+	for taskID := range taskQueue.Queue() {
+		log.Trace("Processing task: %s", taskID)
+		taskQueue.Remove(taskID)
+
+		id := com.StrTo(taskID).MustInt64()
+		task, err := models.GetTaskByID(id)
+		if err != nil {
+			log.Error("GetTaskByID [%d]: %v", id, err)
+			continue
+		}
+
+		switch task.Type {
+		case models.TaskTypeMigrateRepo:
+			runMigrateTask(task)
+		default:
+			log.Error("Unknown type of task [%d]: %d", task.ID, task.Type)
+		}
+	}
+}
+
+// RetryTask queues a failed task again, it continues from the stage it failed in
+func RetryTask(task *models.Task) error {
+	if task.Status != models.TaskStatusFailed {
+		return fmt.Errorf("task %d has not failed", task.ID)
+	}
+
+	task.Status = models.TaskStatusQueue
+	task.Message = ""
+	if err := task.UpdateCols("status", "message"); err != nil {
+		return err
+	}
+
+	taskQueue.Add(task.ID)
+	return nil
+}
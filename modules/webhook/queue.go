@@ -0,0 +1,14 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package webhook
+
+// DeliverFunc delivers the hook task of the given ID
+type DeliverFunc func(taskID int64)
+
+// Queue defines an interface to queue hook tasks for delivery
+type Queue interface {
+	Run() error
+	Push(taskID int64) error
+}
@@ -0,0 +1,37 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package webhook
+
+var (
+	_ Queue = &ChannelQueue{}
+)
+
+// ChannelQueue implements a memory channel queue
+type ChannelQueue struct {
+	queue   chan int64
+	deliver DeliverFunc
+}
+
+// NewChannelQueue create a memory channel queue
+func NewChannelQueue(deliver DeliverFunc, queueLength int) *ChannelQueue {
+	return &ChannelQueue{
+		queue:   make(chan int64, queueLength),
+		deliver: deliver,
+	}
+}
+
+// Run starts to run the queue
+func (c *ChannelQueue) Run() error {
+	for taskID := range c.queue {
+		c.deliver(taskID)
+	}
+	return nil
+}
+
+// Push will push the hook task to queue
+func (c *ChannelQueue) Push(taskID int64) error {
+	c.queue <- taskID
+	return nil
+}
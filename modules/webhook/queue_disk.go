@@ -0,0 +1,64 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package webhook
+
+import (
+	"strconv"
+	"time"
+
+	"code.gitea.io/gitea/modules/log"
+	"github.com/lunny/levelqueue"
+)
+
+var (
+	_ Queue = &LevelQueue{}
+)
+
+// LevelQueue implements a disk library queue
+type LevelQueue struct {
+	queue   *levelqueue.Queue
+	deliver DeliverFunc
+}
+
+// NewLevelQueue creates a ledis local queue
+func NewLevelQueue(deliver DeliverFunc, dataDir string) (*LevelQueue, error) {
+	queue, err := levelqueue.Open(dataDir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LevelQueue{
+		queue:   queue,
+		deliver: deliver,
+	}, nil
+}
+
+// Run starts to run the queue
+func (l *LevelQueue) Run() error {
+	for {
+		bs, err := l.queue.RPop()
+		if err != nil {
+			if err != levelqueue.ErrNotFound {
+				log.Error("RPop: %v", err)
+			}
+			time.Sleep(time.Millisecond * 100)
+			continue
+		}
+
+		taskID, err := strconv.ParseInt(string(bs), 10, 64)
+		if err != nil {
+			log.Error("Invalid hook task ID: %s", bs)
+			continue
+		}
+
+		log.Trace("LevelQueue: hook task found: %d", taskID)
+		l.deliver(taskID)
+	}
+}
+
+// Push will push the hook task to queue
+func (l *LevelQueue) Push(taskID int64) error {
+	return l.queue.LPush([]byte(strconv.FormatInt(taskID, 10)))
+}
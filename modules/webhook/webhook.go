@@ -0,0 +1,152 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package webhook
+
+import (
+	"fmt"
+	gosync "sync"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/sync"
+
+	"github.com/Unknwon/com"
+)
+
+// retryCheckInterval is the interval to look for failed deliveries which are due to be retried
+const retryCheckInterval = 10 * time.Second
+
+var (
+	hookTaskQueue Queue
+
+	// queuedTasks contains the hook tasks which are queued or being delivered,
+	// so they are not queued again when looking for due deliveries
+	queuedTasks = sync.NewStatusTable()
+
+	// hookSemaphores limit the number of concurrent deliveries to each webhook
+	hookSemaphores     = make(map[int64]chan struct{})
+	hookSemaphoresLock gosync.Mutex
+)
+
+// Init starts the delivery of hook tasks
+func Init() error {
+	models.InitDeliverHooks()
+
+	var err error
+	switch setting.Webhook.QueueType {
+	case setting.LevelQueueType:
+		hookTaskQueue, err = NewLevelQueue(deliver, setting.Webhook.QueueDir)
+		if err != nil {
+			return err
+		}
+	case setting.ChannelQueueType:
+		hookTaskQueue = NewChannelQueue(deliver, setting.Webhook.QueueLength)
+	case setting.RedisQueueType:
+		addrs, pass, idx, err := parseConnStr(setting.Webhook.QueueConnStr)
+		if err != nil {
+			return err
+		}
+		hookTaskQueue, err = NewRedisQueue(addrs, pass, idx, deliver)
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("Unsupported webhook queue type: %v", setting.Webhook.QueueType)
+	}
+
+	go func() {
+		if err := hookTaskQueue.Run(); err != nil {
+			log.Error("hookTaskQueue.Run: %v", err)
+		}
+	}()
+	go queueRepoHookTasks()
+	go queueRetries()
+	return nil
+}
+
+// queueDueHookTasks pushes the hook tasks of the repository which are due to be delivered
+// to the queue, the tasks of all repositories are pushed if repoID is zero.
+func queueDueHookTasks(repoID int64) {
+	ids, err := models.FindDueHookTaskIDs(repoID)
+	if err != nil {
+		log.Error("FindDueHookTaskIDs [repo_id: %d]: %v", repoID, err)
+		return
+	}
+	for _, id := range ids {
+		if !queuedTasks.StartIfNotRunning(com.ToStr(id)) {
+			continue
+		}
+		if err = hookTaskQueue.Push(id); err != nil {
+			log.Error("Push hook task [%d]: %v", id, err)
+			queuedTasks.Stop(com.ToStr(id))
+		}
+	}
+}
+
+// queueRepoHookTasks queues the new hook tasks of the repositories added to models.HookQueue
+func queueRepoHookTasks() {
+	for repoIDStr := range models.HookQueue.Queue() {
+		log.Trace("DeliverHooks [repo_id: %v]", repoIDStr)
+		models.HookQueue.Remove(repoIDStr)
+
+		repoID, err := com.StrTo(repoIDStr).Int64()
+		if err != nil {
+			log.Error("Invalid repo ID: %s", repoIDStr)
+			continue
+		}
+		queueDueHookTasks(repoID)
+	}
+}
+
+// queueRetries queues the pending deliveries on start and then the failed deliveries
+// when they are due to be retried
+func queueRetries() {
+	for {
+		queueDueHookTasks(0)
+		time.Sleep(retryCheckInterval)
+	}
+}
+
+func getHookSemaphore(hookID int64) chan struct{} {
+	hookSemaphoresLock.Lock()
+	defer hookSemaphoresLock.Unlock()
+
+	semaphore, ok := hookSemaphores[hookID]
+	if !ok {
+		concurrency := setting.Webhook.DeliverConcurrency
+		if concurrency < 1 {
+			concurrency = 1
+		}
+		semaphore = make(chan struct{}, concurrency)
+		hookSemaphores[hookID] = semaphore
+	}
+	return semaphore
+}
+
+// deliver delivers the hook task in the background, the deliveries to the same
+// webhook are limited so a slow endpoint doesn't stall the other webhooks.
+func deliver(taskID int64) {
+	t, err := models.GetHookTaskByID(taskID)
+	if err != nil {
+		log.Error("GetHookTaskByID [%d]: %v", taskID, err)
+		queuedTasks.Stop(com.ToStr(taskID))
+		return
+	}
+
+	semaphore := getHookSemaphore(t.HookID)
+	go func() {
+		semaphore <- struct{}{}
+		defer func() {
+			<-semaphore
+			queuedTasks.Stop(com.ToStr(taskID))
+		}()
+
+		if err := models.DeliverHookTask(taskID); err != nil {
+			log.Error("DeliverHookTask [%d]: %v", taskID, err)
+		}
+	}()
+}
@@ -0,0 +1,67 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package webhook
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	models.MainTest(m, filepath.Join("..", ".."))
+}
+
+func TestDeliverConcurrency(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+	models.InitDeliverHooks()
+
+	// the endpoint of the first webhook hangs until it is released
+	release := make(chan struct{})
+	slowServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer slowServer.Close()
+	defer close(release)
+
+	delivered := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delivered <- struct{}{}
+	}))
+	defer server.Close()
+
+	createTask := func(hookID int64, url string) int64 {
+		task := &models.HookTask{
+			RepoID:      1,
+			HookID:      hookID,
+			URL:         url,
+			Payloader:   &api.PushPayload{},
+			HTTPMethod:  http.MethodPost,
+			ContentType: models.ContentTypeJSON,
+			EventType:   models.HookEventPush,
+		}
+		assert.NoError(t, models.CreateHookTask(task))
+		return task.ID
+	}
+
+	queue := NewChannelQueue(deliver, 10)
+	go queue.Run()
+	assert.NoError(t, queue.Push(createTask(1, slowServer.URL)))
+	assert.NoError(t, queue.Push(createTask(1, slowServer.URL)))
+	assert.NoError(t, queue.Push(createTask(2, server.URL)))
+
+	select {
+	case <-delivered:
+	case <-time.After(10 * time.Second):
+		assert.Fail(t, "the delivery to the second webhook was stalled by the first webhook")
+	}
+}
@@ -516,6 +516,7 @@ function initCommentForm() {
     initListSubmits('select-label', 'labels');
     initListSubmits('select-assignees', 'assignees');
     initListSubmits('select-assignees-modify', 'assignees');
+    initListSubmits('select-projects', 'projects');
 
     function selectItem(select_id, input_id) {
         const $menu = $(select_id + ' .menu');
@@ -729,7 +730,7 @@ function initRepository() {
     }
 
     // Labels
-    if ($('.repository.labels').length > 0) {
+    if ($('.repository.labels').length > 0 || $('.organization.settings.labels').length > 0) {
         // Create label
         const $newLabelPanel = $('.new-label.segment');
         $('.new-label.button').click(function () {
@@ -991,8 +992,11 @@ function initMigration() {
     const toggleMigrations = function() {
         const authUserName = $('#auth_username').val();
         const cloneAddr = $('#clone_addr').val();
-        if (!$('#mirror').is(":checked") && (authUserName!=undefined && authUserName.length > 0)
-        && (cloneAddr!=undefined && (cloneAddr.startsWith("https://github.com") || cloneAddr.startsWith("http://github.com")))) {
+        const service = $('#service').val();
+        const hasAuthUserName = authUserName!=undefined && authUserName.length > 0;
+        const isGithub = service == "2" || (service == "" && cloneAddr!=undefined && (cloneAddr.startsWith("https://github.com") || cloneAddr.startsWith("http://github.com")));
+        const isGitlab = service == "3" || (service == "" && cloneAddr!=undefined && (cloneAddr.startsWith("https://gitlab.com") || cloneAddr.startsWith("http://gitlab.com")));
+        if (!$('#mirror').is(":checked") && ((isGithub && hasAuthUserName) || isGitlab)) {
             $('#migrate_items').show();
         } else {
             $('#migrate_items').hide();
@@ -1003,9 +1007,103 @@ function initMigration() {
 
     $('#clone_addr').on('input', toggleMigrations)
     $('#auth_username').on('input', toggleMigrations)
+    $('#service').on('change', toggleMigrations)
     $('#mirror').on('change', toggleMigrations)
 }
 
+function initMigrationStatus() {
+    const $status = $('#repo-migrating');
+    if ($status.length === 0) {
+        return;
+    }
+
+    const status = $status.data('status');
+    if (status === 'failed') {
+        return;
+    }
+    const stage = $status.data('stage');
+
+    const checkStatus = function () {
+        $.getJSON($status.data('status-link'), function (task) {
+            if (task.status === 'finished') {
+                window.location.href = $status.data('repo-link');
+            } else if (task.status !== status || task.stage !== stage) {
+                window.location.reload();
+            } else {
+                setTimeout(checkStatus, 2000);
+            }
+        }).fail(function () {
+            setTimeout(checkStatus, 10000);
+        });
+    };
+    setTimeout(checkStatus, 2000);
+}
+
+function initProjectBoard() {
+    const $board = $('.project.board');
+    if ($board.length === 0) {
+        return;
+    }
+
+    $('.new-board.button').click(function () {
+        $('.new-board.segment').toggleClass('hide');
+    });
+    $board.find('.edit-board').click(function (e) {
+        e.preventDefault();
+        $('#edit-board-' + $(this).data('id')).toggleClass('hide');
+    });
+
+    let $dragged = null;
+    // the card which the dragged card is dropped before, none to drop it at the end
+    const cardAfter = function (cards, y) {
+        let after = null;
+        $(cards).children('.card:not(.dragging)').each(function () {
+            const box = this.getBoundingClientRect();
+            if (y < box.top + box.height / 2) {
+                after = this;
+                return false;
+            }
+        });
+        return after;
+    };
+
+    $board.find('.card[draggable="true"]').on('dragstart', function (e) {
+        $dragged = $(this);
+        $dragged.addClass('dragging');
+        e.originalEvent.dataTransfer.setData('text/plain', $dragged.data('issue-id'));
+    }).on('dragend', function () {
+        $(this).removeClass('dragging');
+        $dragged = null;
+    });
+
+    $board.find('.cards').on('dragover', function (e) {
+        if ($dragged === null) {
+            return;
+        }
+        e.preventDefault();
+        const after = cardAfter(this, e.originalEvent.clientY);
+        if (after === null) {
+            $(this).append($dragged);
+        } else {
+            $(after).before($dragged);
+        }
+    }).on('drop', function (e) {
+        if ($dragged === null) {
+            return;
+        }
+        e.preventDefault();
+        const $cards = $(this);
+        $.post($board.data('move-url'), {
+            '_csrf': csrf,
+            'issue_id': $dragged.data('issue-id'),
+            'board_id': $cards.data('board-id'),
+            'position': $cards.children('.card').index($dragged),
+        }).fail(function () {
+            window.location.reload();
+        });
+    });
+}
+
 function initPullRequestReview() {
     $('.show-outdated').on('click', function (e) {
         e.preventDefault();
@@ -1569,7 +1667,7 @@ function initAdmin() {
     }
 
     function onOAuth2Change() {
-        $('.open_id_connect_auto_discovery_url, .oauth2_use_custom_url').hide();
+        $('.open_id_connect_auto_discovery_url, .open_id_connect_field, .oauth2_use_custom_url').hide();
         $('.open_id_connect_auto_discovery_url input[required]').removeAttr('required');
 
         const provider = $('#oauth2_provider').val();
@@ -1580,7 +1678,7 @@ function initAdmin() {
                 break;
             case 'openidConnect':
                 $('.open_id_connect_auto_discovery_url input').attr('required', 'required');
-                $('.open_id_connect_auto_discovery_url').show();
+                $('.open_id_connect_auto_discovery_url, .open_id_connect_field').show();
                 break;
         }
         onOAuth2UseCustomURLChange();
@@ -2179,6 +2277,8 @@ $(document).ready(function () {
     initInstall();
     initRepository();
     initMigration();
+    initMigrationStatus();
+    initProjectBoard();
     initWikiForm();
     initEditForm();
     initEditor();
@@ -6,6 +6,7 @@ package admin
 
 import (
 	"fmt"
+	"strings"
 
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/auth"
@@ -116,6 +117,13 @@ func parseLDAPConfig(form auth.AuthenticationForm) *models.LDAPConfig {
 			Filter:                form.Filter,
 			AdminFilter:           form.AdminFilter,
 			Enabled:               true,
+			GroupsEnabled:         form.GroupsEnabled,
+			GroupDN:               form.GroupDN,
+			GroupFilter:           form.GroupFilter,
+			GroupMemberUID:        form.GroupMemberUID,
+			UserUID:               form.UserUID,
+			GroupTeamMap:          strings.TrimSpace(form.GroupTeamMap),
+			GroupTeamMapRemoval:   form.GroupTeamMapRemoval,
 		},
 	}
 }
@@ -143,13 +151,35 @@ func parseOAuth2Config(form auth.AuthenticationForm) *models.OAuth2Config {
 	} else {
 		customURLMapping = nil
 	}
-	return &models.OAuth2Config{
+	cfg := &models.OAuth2Config{
 		Provider:                      form.Oauth2Provider,
 		ClientID:                      form.Oauth2Key,
 		ClientSecret:                  form.Oauth2Secret,
 		OpenIDConnectAutoDiscoveryURL: form.OpenIDConnectAutoDiscoveryURL,
 		CustomURLMapping:              customURLMapping,
 	}
+	if form.Oauth2Provider == "openidConnect" {
+		cfg.OpenIDConnectOptions = &oauth2.OpenIDConnectOptions{
+			Scopes:        strings.Fields(strings.Replace(form.Oauth2Scopes, ",", " ", -1)),
+			UsernameClaim: strings.TrimSpace(form.Oauth2UsernameClaim),
+			EmailClaim:    strings.TrimSpace(form.Oauth2EmailClaim),
+			FullNameClaim: strings.TrimSpace(form.Oauth2FullNameClaim),
+		}
+		cfg.AdminClaim = strings.TrimSpace(form.Oauth2AdminClaim)
+		cfg.AdminClaimValue = strings.TrimSpace(form.Oauth2AdminClaimValue)
+		cfg.GroupClaimName = strings.TrimSpace(form.Oauth2GroupClaimName)
+		cfg.GroupTeamMap = strings.TrimSpace(form.Oauth2GroupTeamMap)
+		cfg.GroupTeamMapRemoval = form.Oauth2GroupTeamMapRemoval
+	}
+	return cfg
+}
+
+// groupTeamMap returns the group to team mapping submitted for the type of the source
+func groupTeamMap(form auth.AuthenticationForm) string {
+	if models.LoginType(form.Type) == models.LoginOAuth2 {
+		return form.Oauth2GroupTeamMap
+	}
+	return form.GroupTeamMap
 }
 
 // NewAuthSourcePost response for adding an auth source
@@ -187,6 +217,12 @@ func NewAuthSourcePost(ctx *context.Context, form auth.AuthenticationForm) {
 	}
 	ctx.Data["HasTLS"] = hasTLS
 
+	if _, err := models.ParseGroupTeamMapping(groupTeamMap(form)); err != nil {
+		ctx.Data["Err_GroupTeamMap"] = true
+		ctx.RenderWithErr(ctx.Tr("admin.auths.invalid_group_team_map", err), tplAuthNew, form)
+		return
+	}
+
 	if ctx.HasError() {
 		ctx.HTML(200, tplAuthNew)
 		return
@@ -279,6 +315,12 @@ func EditAuthSourcePost(ctx *context.Context, form auth.AuthenticationForm) {
 		return
 	}
 
+	if _, err := models.ParseGroupTeamMapping(groupTeamMap(form)); err != nil {
+		ctx.Data["Err_GroupTeamMap"] = true
+		ctx.RenderWithErr(ctx.Tr("admin.auths.invalid_group_team_map", err), tplAuthEdit, form)
+		return
+	}
+
 	source.Name = form.Name
 	source.IsActived = form.IsActive
 	source.IsSyncEnabled = form.IsSyncEnabled
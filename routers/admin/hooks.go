@@ -45,3 +45,33 @@ func DeleteDefaultWebhook(ctx *context.Context) {
 		"redirect": setting.AppSubURL + "/admin/hooks",
 	})
 }
+
+// SystemWebhooks render admin system webhook list page
+func SystemWebhooks(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("admin.systemhooks")
+	ctx.Data["PageIsAdminSystemHooks"] = true
+	ctx.Data["BaseLink"] = setting.AppSubURL + "/admin/system-hooks"
+	ctx.Data["Description"] = ctx.Tr("admin.systemhooks.desc")
+
+	ws, err := models.GetSystemWebhooks()
+	if err != nil {
+		ctx.ServerError("GetSystemWebhooks", err)
+		return
+	}
+
+	ctx.Data["Webhooks"] = ws
+	ctx.HTML(200, tplAdminHooks)
+}
+
+// DeleteSystemWebhook response for delete admin system webhook
+func DeleteSystemWebhook(ctx *context.Context) {
+	if err := models.DeleteSystemWebhook(ctx.QueryInt64("id")); err != nil {
+		ctx.Flash.Error("DeleteSystemWebhook: " + err.Error())
+	} else {
+		ctx.Flash.Success(ctx.Tr("repo.settings.webhook_deletion_success"))
+	}
+
+	ctx.JSON(200, map[string]interface{}{
+		"redirect": setting.AppSubURL + "/admin/system-hooks",
+	})
+}
@@ -0,0 +1,177 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package actions implements the protocol used by runners to register
+// themselves, fetch jobs and report their logs and results.
+package actions
+
+import (
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/log"
+	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/go-macaron/binding"
+	macaron "gopkg.in/macaron.v1"
+)
+
+func jsonError(ctx *macaron.Context, status int, err error) {
+	ctx.JSON(status, map[string]interface{}{
+		"err": err.Error(),
+	})
+}
+
+// reqRunner authenticates the runner sending the request with its bearer token
+func reqRunner(ctx *macaron.Context) {
+	fields := strings.Fields(ctx.Req.Header.Get("Authorization"))
+	if len(fields) != 2 || fields[0] != "Bearer" {
+		ctx.Error(401)
+		return
+	}
+	runner, err := models.GetActionRunnerByToken(fields[1])
+	if err != nil {
+		if models.IsErrActionRunnerNotExist(err) {
+			ctx.Error(401)
+		} else {
+			jsonError(ctx, 500, err)
+		}
+		return
+	}
+	if err = models.UpdateActionRunnerOnline(runner); err != nil {
+		log.Error("UpdateActionRunnerOnline [runner_id: %d]: %v", runner.ID, err)
+	}
+	ctx.Map(runner)
+}
+
+// reqRunnerJob loads the job of the request, it must be running on the runner
+func reqRunnerJob(ctx *macaron.Context, runner *models.ActionRunner) {
+	job, err := models.GetActionRunJobByID(ctx.ParamsInt64(":id"))
+	if err != nil {
+		if models.IsErrActionRunJobNotExist(err) {
+			ctx.Error(404)
+		} else {
+			jsonError(ctx, 500, err)
+		}
+		return
+	}
+	if job.RunnerID != runner.ID {
+		ctx.Error(404)
+		return
+	}
+	if job.Status.IsDone() {
+		jsonError(ctx, 409, models.ErrActionRunJobDone{ID: job.ID})
+		return
+	}
+	if err = models.TouchActionRunJob(job); err != nil {
+		log.Error("TouchActionRunJob [job_id: %d]: %v", job.ID, err)
+	}
+	ctx.Map(job)
+}
+
+// Register registers a new runner
+func Register(ctx *macaron.Context, form api.ActionRunnerRegisterRequest) {
+	runner, err := models.RegisterActionRunner(form.Token, form.Name, form.Labels)
+	if err != nil {
+		if models.IsErrActionRunnerTokenNotExist(err) {
+			ctx.Error(401)
+		} else {
+			jsonError(ctx, 500, err)
+		}
+		return
+	}
+	ctx.JSON(201, &api.ActionRunnerRegisterResponse{
+		ID:    runner.ID,
+		UUID:  runner.UUID,
+		Token: runner.Token,
+	})
+}
+
+// Fetch assigns a waiting job to the runner, it responds with 204 if there is none
+func Fetch(ctx *macaron.Context, runner *models.ActionRunner) {
+	job, err := models.ClaimActionRunJob(runner)
+	if err != nil {
+		jsonError(ctx, 500, err)
+		return
+	} else if job == nil {
+		ctx.Status(204)
+		return
+	}
+	if err = job.LoadRun(); err != nil {
+		jsonError(ctx, 500, err)
+		return
+	}
+
+	steps := make([]*api.ActionStep, 0, len(job.Steps))
+	for _, step := range job.Steps {
+		steps = append(steps, &api.ActionStep{
+			Name: step.Name,
+			Run:  step.Run,
+			Env:  step.Env,
+		})
+	}
+	run := job.Run
+	ctx.JSON(200, &api.ActionJob{
+		ID:       job.ID,
+		RunID:    run.ID,
+		Name:     job.Name,
+		Repo:     run.Repo.FullName(),
+		CloneURL: run.Repo.CloneLink().HTTPS,
+		Event:    string(run.Event),
+		Ref:      run.Ref,
+		SHA:      run.CommitSHA,
+		RunsOn:   job.RunsOn,
+		Env:      job.Env,
+		Steps:    steps,
+		Token:    job.Token,
+	})
+}
+
+// AppendLog appends lines to the log of the job
+func AppendLog(ctx *macaron.Context, job *models.ActionRunJob, form api.ActionJobLogRequest) {
+	ack, err := models.AppendActionRunJobLog(job, form.Offset, form.Lines)
+	if err != nil {
+		if models.IsErrActionRunJobLogOffset(err) {
+			jsonError(ctx, 422, err)
+		} else {
+			jsonError(ctx, 500, err)
+		}
+		return
+	}
+	ctx.JSON(200, &api.ActionJobLogResponse{Ack: ack})
+}
+
+// UpdateResult reports the result of the job
+func UpdateResult(ctx *macaron.Context, job *models.ActionRunJob, form api.ActionJobResultRequest) {
+	status, ok := models.ActionStatusFromString(form.Result)
+	if !ok || !status.IsDone() {
+		ctx.JSON(422, map[string]interface{}{
+			"err": "invalid result: " + form.Result,
+		})
+		return
+	}
+	if err := models.UpdateActionRunJobStatus(job, status); err != nil {
+		if models.IsErrActionRunJobDone(err) {
+			jsonError(ctx, 409, err)
+		} else {
+			jsonError(ctx, 500, err)
+		}
+		return
+	}
+	ctx.Status(204)
+}
+
+// RegisterRoutes registers the routes used by runners
+func RegisterRoutes(m *macaron.Macaron) {
+	bind := binding.Bind
+
+	m.Post("/runners/register", bind(api.ActionRunnerRegisterRequest{}), Register)
+	m.Group("", func() {
+		m.Post("/runners/fetch", Fetch)
+		m.Group("/jobs/:id", func() {
+			m.Post("/logs", bind(api.ActionJobLogRequest{}), AppendLog)
+			m.Post("/result", bind(api.ActionJobResultRequest{}), UpdateResult)
+		}, reqRunnerJob)
+	}, reqRunner)
+}
@@ -0,0 +1,186 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package goproxy implements the Go module proxy protocol for the modules hosted in
+// the repositories, the Go command uses it when GOPROXY is set to <ROOT_URL>api/go.
+package goproxy
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/goproxy"
+	"code.gitea.io/gitea/modules/setting"
+
+	macaron "gopkg.in/macaron.v1"
+)
+
+// RegisterRoutes registers the routes of the Go module proxy
+func RegisterRoutes(m *macaron.Macaron) {
+	m.Get("/*", Serve)
+}
+
+// loadModule returns the module of the path and the repository hosting it, the user must be
+// able to read the code of the repository. It returns nil if an error has been rendered.
+func loadModule(ctx *context.Context, modulePath string) (*goproxy.Module, *models.Repository) {
+	appURL, _ := url.Parse(setting.AppURL)
+	prefix := path.Join(appURL.Host, setting.AppSubURL) + "/"
+	parts := strings.SplitN(strings.TrimPrefix(modulePath, prefix), "/", 3)
+	if !strings.HasPrefix(modulePath, prefix) || len(parts) < 2 {
+		ctx.HandleText(http.StatusNotFound, fmt.Sprintf("module %s is not hosted on %s", modulePath, prefix))
+		return nil, nil
+	}
+
+	repo, err := models.GetRepositoryByOwnerAndName(parts[0], parts[1])
+	if err != nil && !models.IsErrRepoNotExist(err) {
+		ctx.ServerError("GetRepositoryByOwnerAndName", err)
+		return nil, nil
+	}
+	canRead := false
+	if repo != nil {
+		perm, err := models.GetUserRepoPermission(repo, ctx.User)
+		if err != nil {
+			ctx.ServerError("GetUserRepoPermission", err)
+			return nil, nil
+		}
+		canRead = perm.CanRead(models.UnitTypeCode)
+		if token := ctx.AccessToken(); token != nil {
+			canRead = canRead && token.HasScope(models.AccessTokenScopeRepoRead) && token.CanAccessRepo(repo.ID)
+		}
+	}
+	// the existence of the private repositories is not disclosed
+	if !ctx.IsSigned && (!canRead || setting.Service.RequireSignInView) {
+		ctx.Header().Set("WWW-Authenticate", `Basic realm="Gitea Go Module Proxy"`)
+		ctx.HandleText(http.StatusUnauthorized, "authentication required, the credentials of the host are read from the .netrc file")
+		return nil, nil
+	}
+	if !canRead || repo.IsEmpty {
+		ctx.HandleText(http.StatusNotFound, fmt.Sprintf("repository %s/%s does not exist", parts[0], parts[1]))
+		return nil, nil
+	}
+
+	gitRepo, err := git.OpenRepository(repo.RepoPath())
+	if err != nil {
+		ctx.ServerError("OpenRepository", err)
+		return nil, nil
+	}
+	var subpath string
+	if len(parts) == 3 {
+		subpath = parts[2]
+	}
+	m, err := goproxy.NewModule(gitRepo, modulePath, subpath)
+	if err != nil {
+		ctx.HandleText(http.StatusNotFound, fmt.Sprintf("%s: %v", modulePath, err))
+		return nil, nil
+	}
+	return m, repo
+}
+
+// handleError renders the errors of the module, the Go command reports the
+// text of the responses and looks for the modules not found in the next proxy.
+func handleError(ctx *context.Context, title string, err error) {
+	switch err {
+	case goproxy.ErrVersionNotExist, goproxy.ErrModuleTooLarge:
+		ctx.HandleText(http.StatusNotFound, err.Error())
+	default:
+		ctx.ServerError(title, err)
+	}
+}
+
+// Serve answers the requests of the Go module proxy protocol, the paths are
+// <module>/@v/list, <module>/@v/<version>.info, .mod and .zip and <module>/@latest
+func Serve(ctx *context.Context) {
+	p := ctx.Params("*")
+	var escapedPath, file string
+	if strings.HasSuffix(p, "/@latest") {
+		escapedPath, file = strings.TrimSuffix(p, "/@latest"), "@latest"
+	} else if i := strings.LastIndex(p, "/@v/"); i >= 0 {
+		escapedPath, file = p[:i], p[i+len("/@v/"):]
+	} else {
+		ctx.HandleText(http.StatusNotFound, "not found")
+		return
+	}
+	modulePath, ok := goproxy.UnescapePath(escapedPath)
+	if !ok {
+		ctx.HandleText(http.StatusNotFound, "invalid escaped module path")
+		return
+	}
+
+	var ext, version string
+	if file != "list" && file != "@latest" {
+		ext = path.Ext(file)
+		if version, ok = goproxy.UnescapePath(strings.TrimSuffix(file, ext)); !ok {
+			ctx.HandleText(http.StatusNotFound, "invalid escaped version")
+			return
+		}
+	}
+
+	m, repo := loadModule(ctx, modulePath)
+	if m == nil {
+		return
+	}
+
+	switch {
+	case file == "list":
+		versions, err := m.Versions()
+		if err != nil {
+			handleError(ctx, "Versions", err)
+			return
+		}
+		var list string
+		if len(versions) > 0 {
+			list = strings.Join(versions, "\n") + "\n"
+		}
+		ctx.PlainText(http.StatusOK, []byte(list))
+	case file == "@latest":
+		info, err := m.Latest(repo.DefaultBranch)
+		if err != nil {
+			handleError(ctx, "Latest", err)
+			return
+		}
+		ctx.JSON(http.StatusOK, info)
+	case ext == ".info":
+		info, err := m.Stat(version)
+		if err != nil {
+			handleError(ctx, "Stat", err)
+			return
+		}
+		ctx.JSON(http.StatusOK, info)
+	case ext == ".mod" && goproxy.IsValidVersion(version):
+		content, err := m.GoMod(version)
+		if err != nil {
+			handleError(ctx, "GoMod", err)
+			return
+		}
+		ctx.PlainText(http.StatusOK, content)
+	case ext == ".zip" && goproxy.IsValidVersion(version):
+		archivePath, err := m.ZipPath(version)
+		if err != nil {
+			handleError(ctx, "ZipPath", err)
+			return
+		}
+		f, err := os.Open(archivePath)
+		if err != nil {
+			ctx.ServerError("Open", err)
+			return
+		}
+		defer f.Close()
+		fi, err := f.Stat()
+		if err != nil {
+			ctx.ServerError("Stat", err)
+			return
+		}
+		ctx.Header().Set("Content-Type", "application/zip")
+		http.ServeContent(ctx.Resp, ctx.Req.Request, version+".zip", fi.ModTime(), f)
+	default:
+		ctx.HandleText(http.StatusNotFound, "not found")
+	}
+}
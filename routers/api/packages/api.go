@@ -0,0 +1,83 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package packages implements the APIs used by the package managers to
+// publish and download the packages of the users and organizations.
+package packages
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/routers/api/packages/container"
+	"code.gitea.io/gitea/routers/api/packages/generic"
+
+	macaron "gopkg.in/macaron.v1"
+)
+
+// reqPackageAccess checks that the user can write the packages when accessMode is write,
+// the personal access token the user is signed in with, if any, must be granted the package scope.
+// The packages are not linked to repositories, a token restricted to some repositories can only read them.
+func reqPackageAccess(accessMode models.AccessMode) macaron.Handler {
+	return func(ctx *context.APIContext) {
+		token := ctx.AccessToken()
+		if token != nil && !token.HasScope(models.AccessTokenScopePackage) {
+			ctx.Error(403, "", fmt.Sprintf("The access token is not granted the %s scope", models.AccessTokenScopePackage))
+			return
+		}
+		if accessMode >= models.AccessModeWrite && token != nil && token.IsRestricted() {
+			ctx.Error(403, "", "The access token is restricted to some repositories and can not write the packages")
+			return
+		}
+		if accessMode >= models.AccessModeWrite && !ctx.Package.CanWrite {
+			if !ctx.IsSigned {
+				ctx.Resp.Header().Set("WWW-Authenticate", `Basic realm="Gitea Package API"`)
+				ctx.Error(401, "", "Authentication required")
+				return
+			}
+			ctx.Error(403, "", "Must be able to write the packages of the owner")
+			return
+		}
+	}
+}
+
+// RegisterRoutes registers the routes of the package APIs
+func RegisterRoutes(m *macaron.Macaron) {
+	m.Group("/:username", func() {
+		m.Group("/generic/:packagename/:packageversion", func() {
+			m.Delete("", reqPackageAccess(models.AccessModeWrite), generic.DeletePackage)
+			m.Group("/:filename", func() {
+				m.Get("", reqPackageAccess(models.AccessModeRead), generic.DownloadPackageFile)
+				m.Put("", reqPackageAccess(models.AccessModeWrite), generic.UploadPackage)
+				m.Delete("", reqPackageAccess(models.AccessModeWrite), generic.DeletePackageFile)
+			})
+		})
+	}, context.APIContexter(), context.PackageAssignmentAPI())
+}
+
+// RegisterContainerRoutes registers the routes of the container registry, the clients
+// expect them under /v2 at the root of the host.
+func RegisterContainerRoutes(m *macaron.Macaron) {
+	m.Get("/token", context.APIContexter(), container.Token)
+	m.Group("", func() {
+		m.Get("/", container.CheckAPIVersion)
+		m.Group("/:username/:image", func() {
+			m.Group("/blobs", func() {
+				m.Post("/uploads", container.ReqContainerAccess(models.AccessModeWrite), container.InitiateUpload)
+				m.Combo("/uploads/:uuid", container.ReqContainerAccess(models.AccessModeWrite)).
+					Get(container.GetUploadStatus).
+					Patch(container.UploadChunk).
+					Put(container.CompleteUpload).
+					Delete(container.CancelUpload)
+				m.Get("/:digest", container.ReqContainerAccess(models.AccessModeRead), container.GetBlob)
+			})
+			m.Combo("/manifests/:reference").
+				Get(container.ReqContainerAccess(models.AccessModeRead), container.GetManifest).
+				Put(container.ReqContainerAccess(models.AccessModeWrite), container.PutManifest).
+				Delete(container.ReqContainerAccess(models.AccessModeWrite), container.DeleteManifest)
+			m.Get("/tags/list", container.ReqContainerAccess(models.AccessModeRead), container.GetTagsList)
+		}, container.PackageAssignment())
+	}, context.APIContexter(), container.Authenticate())
+}
@@ -0,0 +1,209 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package container
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/dgrijalva/jwt-go"
+	macaron "gopkg.in/macaron.v1"
+)
+
+// tokenExpiration is the lifetime of the bearer tokens, the clients request a new one when it expires
+const tokenExpiration = time.Hour
+
+// tokenClaims are the claims of the bearer tokens, the user is 0 for the anonymous tokens.
+// The tokens issued for an access token restricted to some repositories are read only.
+type tokenClaims struct {
+	UserID   int64 `json:"user"`
+	ReadOnly bool  `json:"read_only,omitempty"`
+	jwt.StandardClaims
+}
+
+func signingKey() []byte {
+	return []byte(setting.SecretKey)
+}
+
+// issueToken returns a signed bearer token of the user
+func issueToken(userID int64, readOnly bool, issuedAt time.Time) (string, error) {
+	claims := &tokenClaims{
+		UserID:   userID,
+		ReadOnly: readOnly,
+		StandardClaims: jwt.StandardClaims{
+			IssuedAt:  issuedAt.Unix(),
+			ExpiresAt: issuedAt.Add(tokenExpiration).Unix(),
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(signingKey())
+}
+
+// parseToken returns the claims of a bearer token issued by the token endpoint
+func parseToken(token string) (*tokenClaims, error) {
+	parsed, err := jwt.ParseWithClaims(token, &tokenClaims{}, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+		}
+		return signingKey(), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	claims, ok := parsed.Claims.(*tokenClaims)
+	if !ok || !parsed.Valid {
+		return nil, fmt.Errorf("invalid token")
+	}
+	return claims, nil
+}
+
+// setChallenge asks the client to authenticate with a bearer token requested from the token endpoint
+func setChallenge(ctx *context.APIContext) {
+	ctx.Resp.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%sv2/token",service="container_registry",scope="*"`, setting.AppURL))
+}
+
+// unauthorized asks the client to authenticate
+func unauthorized(ctx *context.APIContext, message string) {
+	setChallenge(ctx)
+	apiError(ctx, 401, errCodeUnauthorized, message)
+}
+
+// isTokenScopeDenied returns true if the user is signed in with a personal access token
+// which is not granted the package scope
+func isTokenScopeDenied(ctx *context.APIContext) bool {
+	token := ctx.AccessToken()
+	return token != nil && !token.HasScope(models.AccessTokenScopePackage)
+}
+
+// isTokenRestricted returns true if the user is signed in with a personal access token
+// restricted to some repositories, the images are not linked to repositories so it can only read them
+func isTokenRestricted(ctx *context.APIContext) bool {
+	token := ctx.AccessToken()
+	return token != nil && token.IsRestricted()
+}
+
+// Authenticate signs in the user of the bearer token of the request. The requests authenticated
+// with the basic authentication are accepted too, the session of the web interface is not used.
+func Authenticate() macaron.Handler {
+	return func(ctx *context.APIContext) {
+		ctx.Resp.Header().Set("Docker-Distribution-Api-Version", "registry/2.0")
+
+		if ctx.IsBasicAuth {
+			if isTokenScopeDenied(ctx) {
+				apiError(ctx, 403, errCodeDenied, fmt.Sprintf("The access token is not granted the %s scope", models.AccessTokenScopePackage))
+				return
+			}
+			ctx.Data["ContainerAuthenticated"] = true
+			ctx.Data["ContainerReadOnly"] = isTokenRestricted(ctx)
+			return
+		}
+		ctx.User = nil
+		ctx.IsSigned = false
+
+		fields := strings.Fields(ctx.Req.Header.Get("Authorization"))
+		if len(fields) != 2 || !strings.EqualFold(fields[0], "bearer") {
+			return
+		}
+		claims, err := parseToken(fields[1])
+		if err != nil {
+			unauthorized(ctx, "Invalid or expired token")
+			return
+		}
+		if claims.UserID > 0 {
+			u, err := models.GetUserByID(claims.UserID)
+			if err != nil {
+				if models.IsErrUserNotExist(err) {
+					unauthorized(ctx, "Invalid or expired token")
+				} else {
+					apiServerError(ctx, "GetUserByID", err)
+				}
+				return
+			}
+			if !u.IsActive || u.ProhibitLogin {
+				unauthorized(ctx, "The user is not allowed to sign in")
+				return
+			}
+			ctx.User = u
+			ctx.IsSigned = true
+		}
+		ctx.Data["ContainerAuthenticated"] = true
+		ctx.Data["ContainerReadOnly"] = claims.ReadOnly
+	}
+}
+
+// ReqContainerAccess checks that the user can write the images of the owner when accessMode is
+// write, anonymous users can only read them when signing in is not required to view the site.
+func ReqContainerAccess(accessMode models.AccessMode) macaron.Handler {
+	return func(ctx *context.APIContext) {
+		if !ctx.IsSigned && setting.Service.RequireSignInView {
+			unauthorized(ctx, "Authentication required")
+			return
+		}
+		if readOnly, _ := ctx.Data["ContainerReadOnly"].(bool); readOnly && accessMode >= models.AccessModeWrite {
+			apiError(ctx, 403, errCodeDenied, "The access token is restricted to some repositories and can not write the images")
+			return
+		}
+		if accessMode >= models.AccessModeWrite && !ctx.Package.CanWrite {
+			if !ctx.IsSigned {
+				unauthorized(ctx, "Authentication required")
+				return
+			}
+			apiError(ctx, 403, errCodeDenied, "Must be able to write the packages of the owner")
+		}
+	}
+}
+
+// CheckAPIVersion answers the clients checking that the registry implements the
+// version 2 of the API, they are asked to authenticate first.
+func CheckAPIVersion(ctx *context.APIContext) {
+	if authenticated, _ := ctx.Data["ContainerAuthenticated"].(bool); !authenticated {
+		unauthorized(ctx, "Authentication required")
+		return
+	}
+	ctx.JSON(200, map[string]interface{}{})
+}
+
+// Token issues a bearer token of the user signed in with the basic authentication, a token
+// of the anonymous user is issued to the clients without credentials. The token is read only
+// when the user signed in with an access token restricted to some repositories.
+func Token(ctx *context.APIContext) {
+	var userID int64
+	var readOnly bool
+	if ctx.IsBasicAuth && ctx.User != nil {
+		if isTokenScopeDenied(ctx) {
+			unauthorized(ctx, fmt.Sprintf("The access token is not granted the %s scope", models.AccessTokenScopePackage))
+			return
+		}
+		if !ctx.User.IsActive || ctx.User.ProhibitLogin {
+			unauthorized(ctx, "The user is not allowed to sign in")
+			return
+		}
+		userID = ctx.User.ID
+		readOnly = isTokenRestricted(ctx)
+	} else if len(ctx.Req.Header.Get("Authorization")) > 0 {
+		unauthorized(ctx, "Invalid username or password")
+		return
+	} else if setting.Service.RequireSignInView {
+		unauthorized(ctx, "Authentication required")
+		return
+	}
+
+	issuedAt := time.Now()
+	token, err := issueToken(userID, readOnly, issuedAt)
+	if err != nil {
+		apiServerError(ctx, "issueToken", err)
+		return
+	}
+	ctx.JSON(200, map[string]interface{}{
+		"token":        token,
+		"access_token": token,
+		"expires_in":   int64(tokenExpiration / time.Second),
+		"issued_at":    issuedAt.UTC().Format(time.RFC3339),
+	})
+}
@@ -0,0 +1,616 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package container implements the OCI distribution specification, the container images
+// of the users and organizations are pushed and pulled by docker and the other OCI clients.
+package container
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/packages"
+	"code.gitea.io/gitea/modules/setting"
+
+	macaron "gopkg.in/macaron.v1"
+)
+
+// maxManifestSize is the maximum size in bytes of a manifest
+const maxManifestSize = 4 * 1024 * 1024
+
+var (
+	imageNameRegex = regexp.MustCompile(`\A[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*\z`)
+	tagRegex       = regexp.MustCompile(`\A[a-zA-Z0-9_][a-zA-Z0-9._-]{0,127}\z`)
+	digestRegex    = regexp.MustCompile(`\Asha256:[a-f0-9]{64}\z`)
+)
+
+// The error codes of the distribution specification
+const (
+	errCodeBlobUnknown         = "BLOB_UNKNOWN"
+	errCodeBlobUploadInvalid   = "BLOB_UPLOAD_INVALID"
+	errCodeBlobUploadUnknown   = "BLOB_UPLOAD_UNKNOWN"
+	errCodeDenied              = "DENIED"
+	errCodeDigestInvalid       = "DIGEST_INVALID"
+	errCodeManifestBlobUnknown = "MANIFEST_BLOB_UNKNOWN"
+	errCodeManifestInvalid     = "MANIFEST_INVALID"
+	errCodeManifestUnknown     = "MANIFEST_UNKNOWN"
+	errCodeNameInvalid         = "NAME_INVALID"
+	errCodeNameUnknown         = "NAME_UNKNOWN"
+	errCodeSizeInvalid         = "SIZE_INVALID"
+	errCodeTagInvalid          = "TAG_INVALID"
+	errCodeUnauthorized        = "UNAUTHORIZED"
+	errCodeUnknown             = "UNKNOWN"
+)
+
+type apiErrorItem struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// apiError answers an error in the format of the distribution specification
+func apiError(ctx *context.APIContext, status int, code, message string) {
+	ctx.JSON(status, map[string]interface{}{
+		"errors": []apiErrorItem{{Code: code, Message: message}},
+	})
+}
+
+// apiServerError logs an internal error and answers it without its details
+func apiServerError(ctx *context.APIContext, title string, err error) {
+	log.Error("%s: %v", title, err)
+	apiError(ctx, 500, errCodeUnknown, "Internal Server Error")
+}
+
+// PackageAssignment loads the owner of the images, anonymous users are asked to
+// authenticate before the owners they can not see are reported as unknown.
+func PackageAssignment() macaron.Handler {
+	return context.PackageAssignmentAPIWithErrorHandler(func(ctx *context.APIContext, status int, title string, err error) {
+		if status != 404 {
+			apiServerError(ctx, title, err)
+		} else if !ctx.IsSigned {
+			unauthorized(ctx, "Authentication required")
+		} else {
+			apiError(ctx, 404, errCodeNameUnknown, "Unknown repository name")
+		}
+	})
+}
+
+// The media types of the supported manifests
+const (
+	mediaTypeOCIManifest        = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeOCIIndex           = "application/vnd.oci.image.index.v1+json"
+	mediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	mediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+)
+
+type descriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+	Size      int64  `json:"size"`
+}
+
+// manifest holds the fields of the image manifests and the image indexes
+type manifest struct {
+	SchemaVersion int          `json:"schemaVersion"`
+	MediaType     string       `json:"mediaType"`
+	Config        *descriptor  `json:"config"`
+	Layers        []descriptor `json:"layers"`
+	Manifests     []descriptor `json:"manifests"`
+}
+
+// contentType returns the media type of the manifest, the OCI manifests may omit it
+func (m *manifest) contentType() string {
+	if len(m.MediaType) > 0 {
+		return m.MediaType
+	}
+	if m.Manifests != nil {
+		return mediaTypeOCIIndex
+	}
+	return mediaTypeOCIManifest
+}
+
+// references returns the blobs and the manifests referenced by the manifest
+func (m *manifest) references() []descriptor {
+	refs := make([]descriptor, 0, len(m.Layers)+len(m.Manifests)+1)
+	if m.Config != nil {
+		refs = append(refs, *m.Config)
+	}
+	refs = append(refs, m.Layers...)
+	return append(refs, m.Manifests...)
+}
+
+// parseManifest parses and validates an image manifest or an image index
+func parseManifest(content []byte) (*manifest, error) {
+	m := new(manifest)
+	if err := json.Unmarshal(content, m); err != nil {
+		return nil, err
+	}
+	if m.SchemaVersion != 2 {
+		return nil, fmt.Errorf("unsupported schema version %d", m.SchemaVersion)
+	}
+
+	var isIndex bool
+	switch m.contentType() {
+	case mediaTypeOCIManifest, mediaTypeDockerManifest:
+	case mediaTypeOCIIndex, mediaTypeDockerManifestList:
+		isIndex = true
+	default:
+		return nil, fmt.Errorf("unsupported media type %s", m.contentType())
+	}
+	if isIndex && (m.Config != nil || len(m.Layers) > 0) {
+		return nil, fmt.Errorf("an image index can only reference manifests")
+	} else if !isIndex && (m.Config == nil || len(m.Manifests) > 0) {
+		return nil, fmt.Errorf("an image manifest must reference a configuration and layers")
+	}
+	for _, ref := range m.references() {
+		if !digestRegex.MatchString(ref.Digest) {
+			return nil, fmt.Errorf("invalid digest %s", ref.Digest)
+		}
+	}
+	return m, nil
+}
+
+// imageLink returns the link of the API of the image of the request
+func imageLink(ctx *context.APIContext) string {
+	return fmt.Sprintf("%s/v2/%s/%s", setting.AppSubURL, url.PathEscape(ctx.Params(":username")), url.PathEscape(ctx.Params(":image")))
+}
+
+// loadImage loads the container image of the request, it returns nil if the image does not exist
+func loadImage(ctx *context.APIContext) *models.Package {
+	p, err := models.GetPackageByName(ctx.Package.Owner.ID, models.PackageContainer, ctx.Params(":image"))
+	if err != nil {
+		if !models.IsErrPackageNotExist(err) {
+			apiServerError(ctx, "GetPackageByName", err)
+		}
+		return nil
+	}
+	return p
+}
+
+// checkImageName checks that the name of the image of the request can be pushed
+func checkImageName(ctx *context.APIContext) bool {
+	if !imageNameRegex.MatchString(ctx.Params(":image")) {
+		apiError(ctx, 400, errCodeNameInvalid, "Invalid image name")
+		return false
+	}
+	return true
+}
+
+// GetBlob serves a blob of an image, the HEAD requests check that it exists
+func GetBlob(ctx *context.APIContext) {
+	digest := ctx.Params(":digest")
+	if !digestRegex.MatchString(digest) {
+		apiError(ctx, 400, errCodeDigestInvalid, "Invalid digest")
+		return
+	}
+	p := loadImage(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	var blob *models.PackageBlob
+	if p != nil {
+		var err error
+		if blob, err = models.GetContainerBlob(p.ID, strings.TrimPrefix(digest, models.ContainerDigestPrefix)); err != nil {
+			apiServerError(ctx, "GetContainerBlob", err)
+			return
+		}
+	}
+	if blob == nil {
+		apiError(ctx, 404, errCodeBlobUnknown, "Unknown blob")
+		return
+	}
+
+	r, err := packages.OpenBlob(blob)
+	if err != nil {
+		apiServerError(ctx, "OpenBlob", err)
+		return
+	}
+	defer r.Close()
+
+	ctx.Resp.Header().Set("Content-Type", "application/octet-stream")
+	ctx.Resp.Header().Set("Docker-Content-Digest", digest)
+	http.ServeContent(ctx.Resp, ctx.Req.Request, "", blob.CreatedUnix.AsTime(), r)
+}
+
+// setBlobCreated answers the creation of a blob
+func setBlobCreated(ctx *context.APIContext, digest string) {
+	ctx.Resp.Header().Set("Location", imageLink(ctx)+"/blobs/"+digest)
+	ctx.Resp.Header().Set("Docker-Content-Digest", digest)
+	ctx.Status(201)
+}
+
+// setUploadHeaders sets the headers describing a chunked upload which received size bytes
+func setUploadHeaders(ctx *context.APIContext, id string, size int64) {
+	end := size - 1
+	if end < 0 {
+		end = 0
+	}
+	ctx.Resp.Header().Set("Location", imageLink(ctx)+"/blobs/uploads/"+id)
+	ctx.Resp.Header().Set("Range", fmt.Sprintf("0-%d", end))
+	ctx.Resp.Header().Set("Docker-Upload-UUID", id)
+}
+
+// addUploadedBlob adds a blob uploaded to the image of the request, the content of the blob must match the digest
+func addUploadedBlob(ctx *context.APIContext, blob *models.PackageBlob, digest string) {
+	if models.ContainerDigestPrefix+blob.HashSHA256 != digest {
+		apiError(ctx, 400, errCodeDigestInvalid, "The content does not match the digest")
+		return
+	}
+	if err := models.AddContainerUploadedBlob(ctx.Package.Owner, ctx.User, ctx.Params(":image"), blob); err != nil {
+		if models.IsErrPackageBlobNotExist(err) {
+			apiError(ctx, 404, errCodeBlobUnknown, "The blob was removed, it must be uploaded again")
+			return
+		}
+		apiServerError(ctx, "AddContainerUploadedBlob", err)
+		return
+	}
+	setBlobCreated(ctx, digest)
+}
+
+// uploadError answers the errors of the chunked uploads
+func uploadError(ctx *context.APIContext, title string, err error) {
+	switch err {
+	case packages.ErrUploadNotExist:
+		apiError(ctx, 404, errCodeBlobUploadUnknown, "Unknown upload")
+	case packages.ErrFileTooLarge:
+		apiError(ctx, 413, errCodeSizeInvalid, "The blob is too large")
+	case packages.ErrHashMismatch:
+		apiError(ctx, 400, errCodeDigestInvalid, "The content does not match the digest")
+	default:
+		apiServerError(ctx, title, err)
+	}
+}
+
+// InitiateUpload starts a chunked upload, the blob is uploaded at once when the request has its digest
+func InitiateUpload(ctx *context.APIContext) {
+	if !checkImageName(ctx) {
+		return
+	}
+
+	if digest := ctx.Query("digest"); len(digest) > 0 {
+		if !digestRegex.MatchString(digest) {
+			apiError(ctx, 400, errCodeDigestInvalid, "Invalid digest")
+			return
+		}
+		body := ctx.Req.Request.Body
+		defer body.Close()
+		blob, err := packages.AddBlob(body)
+		if err != nil {
+			uploadError(ctx, "AddBlob", err)
+			return
+		}
+		addUploadedBlob(ctx, blob, digest)
+		return
+	}
+
+	upload, err := packages.CreateUpload(ctx.Package.Owner, ctx.User, ctx.Params(":image"))
+	if err != nil {
+		apiServerError(ctx, "CreateUpload", err)
+		return
+	}
+	setUploadHeaders(ctx, upload.UUID, 0)
+	ctx.Status(202)
+}
+
+// loadUpload returns the chunked upload of the request, which must have been
+// started by the user of the request for the image of the request.
+func loadUpload(ctx *context.APIContext) *models.PackageUpload {
+	upload, err := packages.GetUpload(ctx.Params(":uuid"), ctx.Package.Owner, ctx.User, ctx.Params(":image"))
+	if err != nil {
+		uploadError(ctx, "GetUpload", err)
+		return nil
+	}
+	return upload
+}
+
+// GetUploadStatus answers the size of the content received by a chunked upload
+func GetUploadStatus(ctx *context.APIContext) {
+	upload := loadUpload(ctx)
+	if ctx.Written() {
+		return
+	}
+	size, err := packages.UploadSize(upload)
+	if err != nil {
+		uploadError(ctx, "UploadSize", err)
+		return
+	}
+	setUploadHeaders(ctx, upload.UUID, size)
+	ctx.Status(204)
+}
+
+// UploadChunk appends a chunk to a chunked upload, the chunks must be sent in order
+func UploadChunk(ctx *context.APIContext) {
+	upload := loadUpload(ctx)
+	if ctx.Written() {
+		return
+	}
+	size, err := packages.UploadSize(upload)
+	if err != nil {
+		uploadError(ctx, "UploadSize", err)
+		return
+	}
+	if contentRange := ctx.Req.Header.Get("Content-Range"); len(contentRange) > 0 {
+		var start, end int64
+		if _, err = fmt.Sscanf(contentRange, "%d-%d", &start, &end); err != nil || start != size || end < start {
+			setUploadHeaders(ctx, upload.UUID, size)
+			apiError(ctx, 416, errCodeBlobUploadInvalid, "The chunk does not start at the end of the upload")
+			return
+		}
+	}
+
+	body := ctx.Req.Request.Body
+	defer body.Close()
+	if size, err = packages.AppendUpload(upload, body); err != nil {
+		uploadError(ctx, "AppendUpload", err)
+		return
+	}
+	setUploadHeaders(ctx, upload.UUID, size)
+	ctx.Status(202)
+}
+
+// CompleteUpload appends the last chunk to a chunked upload, if any, and adds the uploaded blob to the image
+func CompleteUpload(ctx *context.APIContext) {
+	if !checkImageName(ctx) {
+		return
+	}
+	digest := ctx.Query("digest")
+	if !digestRegex.MatchString(digest) {
+		apiError(ctx, 400, errCodeDigestInvalid, "Invalid digest")
+		return
+	}
+
+	upload := loadUpload(ctx)
+	if ctx.Written() {
+		return
+	}
+	// the last chunk is optional
+	if body := ctx.Req.Request.Body; body != nil {
+		defer body.Close()
+		if _, err := packages.AppendUpload(upload, body); err != nil {
+			uploadError(ctx, "AppendUpload", err)
+			return
+		}
+	}
+	blob, err := packages.FinishUpload(upload, strings.TrimPrefix(digest, models.ContainerDigestPrefix))
+	if err != nil {
+		uploadError(ctx, "FinishUpload", err)
+		return
+	}
+	addUploadedBlob(ctx, blob, digest)
+}
+
+// CancelUpload cancels a chunked upload
+func CancelUpload(ctx *context.APIContext) {
+	upload := loadUpload(ctx)
+	if ctx.Written() {
+		return
+	}
+	if err := packages.CancelUpload(upload); err != nil {
+		uploadError(ctx, "CancelUpload", err)
+		return
+	}
+	ctx.Status(204)
+}
+
+// GetManifest serves a manifest of an image by tag or by digest, the HEAD requests check that it exists
+func GetManifest(ctx *context.APIContext) {
+	p := loadImage(ctx)
+	if ctx.Written() {
+		return
+	} else if p == nil {
+		apiError(ctx, 404, errCodeNameUnknown, "Unknown repository name")
+		return
+	}
+
+	v, f, err := models.GetContainerManifest(p.ID, ctx.Params(":reference"))
+	if err != nil {
+		if models.IsErrPackageVersionNotExist(err) || models.IsErrPackageFileNotExist(err) {
+			apiError(ctx, 404, errCodeManifestUnknown, "Unknown manifest")
+		} else {
+			apiServerError(ctx, "GetContainerManifest", err)
+		}
+		return
+	}
+
+	r, err := packages.OpenFile(f)
+	if err != nil {
+		apiServerError(ctx, "OpenFile", err)
+		return
+	}
+	defer r.Close()
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		apiServerError(ctx, "ReadAll", err)
+		return
+	}
+	m, err := parseManifest(content)
+	if err != nil {
+		apiServerError(ctx, "parseManifest", err)
+		return
+	}
+
+	if ctx.Req.Method == "GET" {
+		if err = v.IncreaseDownloadCount(); err != nil {
+			log.Error("IncreaseDownloadCount: %v", err)
+		}
+	}
+
+	ctx.Resp.Header().Set("Content-Type", m.contentType())
+	ctx.Resp.Header().Set("Content-Length", strconv.Itoa(len(content)))
+	ctx.Resp.Header().Set("Docker-Content-Digest", models.ContainerDigestPrefix+f.Blob.HashSHA256)
+	ctx.Resp.WriteHeader(200)
+	if _, err = ctx.Resp.Write(content); err != nil {
+		log.Error("Write: %v", err)
+	}
+}
+
+// PutManifest pushes a manifest of an image by tag or by digest, the blobs and the manifests it
+// references must have been pushed to the image before. The image is created with its first manifest.
+func PutManifest(ctx *context.APIContext) {
+	if !checkImageName(ctx) {
+		return
+	}
+	reference := ctx.Params(":reference")
+	isDigest := models.IsContainerDigest(reference)
+	if isDigest && !digestRegex.MatchString(reference) {
+		apiError(ctx, 400, errCodeDigestInvalid, "Invalid digest")
+		return
+	} else if !isDigest && !tagRegex.MatchString(reference) {
+		apiError(ctx, 400, errCodeTagInvalid, "Invalid tag")
+		return
+	}
+
+	body := ctx.Req.Request.Body
+	defer body.Close()
+	content, err := ioutil.ReadAll(http.MaxBytesReader(ctx.Resp, body, maxManifestSize))
+	if err != nil {
+		apiError(ctx, 413, errCodeSizeInvalid, "The manifest is too large")
+		return
+	}
+	m, err := parseManifest(content)
+	if err != nil {
+		apiError(ctx, 400, errCodeManifestInvalid, err.Error())
+		return
+	}
+	if contentType, _, _ := mime.ParseMediaType(ctx.Req.Header.Get("Content-Type")); len(contentType) > 0 && contentType != m.contentType() {
+		apiError(ctx, 400, errCodeManifestInvalid, "The content type does not match the media type of the manifest")
+		return
+	}
+	sum := sha256.Sum256(content)
+	digest := models.ContainerDigestPrefix + hex.EncodeToString(sum[:])
+	if isDigest && reference != digest {
+		apiError(ctx, 400, errCodeDigestInvalid, "The manifest does not match the digest")
+		return
+	}
+
+	p := loadImage(ctx)
+	if ctx.Written() {
+		return
+	}
+	refs := m.references()
+	blobs := make([]*models.PackageBlob, 0, len(refs))
+	for _, ref := range refs {
+		var blob *models.PackageBlob
+		if p != nil {
+			if blob, err = models.GetContainerBlob(p.ID, strings.TrimPrefix(ref.Digest, models.ContainerDigestPrefix)); err != nil {
+				apiServerError(ctx, "GetContainerBlob", err)
+				return
+			}
+		}
+		if blob == nil {
+			apiError(ctx, 400, errCodeManifestBlobUnknown, "Unknown blob "+ref.Digest)
+			return
+		}
+		blobs = append(blobs, blob)
+	}
+
+	manifestBlob, err := packages.AddBlob(bytes.NewReader(content))
+	if err != nil {
+		apiServerError(ctx, "AddBlob", err)
+		return
+	}
+	if _, err = models.SetContainerManifest(&models.SetContainerManifestOptions{
+		Owner:     ctx.Package.Owner,
+		Creator:   ctx.User,
+		Image:     ctx.Params(":image"),
+		Reference: reference,
+		Manifest:  manifestBlob,
+		Blobs:     blobs,
+	}); err != nil {
+		if blobErr, ok := err.(models.ErrPackageBlobNotExist); ok {
+			apiError(ctx, 400, errCodeManifestBlobUnknown, "Unknown blob "+models.ContainerDigestPrefix+blobErr.Hash)
+			return
+		}
+		apiServerError(ctx, "SetContainerManifest", err)
+		return
+	}
+
+	ctx.Resp.Header().Set("Location", imageLink(ctx)+"/manifests/"+digest)
+	ctx.Resp.Header().Set("Docker-Content-Digest", digest)
+	ctx.Status(201)
+}
+
+// DeleteManifest deletes a tag of an image, or all the tags of a manifest when the reference is its digest
+func DeleteManifest(ctx *context.APIContext) {
+	p := loadImage(ctx)
+	if ctx.Written() {
+		return
+	} else if p == nil {
+		apiError(ctx, 404, errCodeNameUnknown, "Unknown repository name")
+		return
+	}
+
+	reference := ctx.Params(":reference")
+	deleted := false
+	for {
+		v, _, err := models.GetContainerManifest(p.ID, reference)
+		if models.IsErrPackageVersionNotExist(err) || models.IsErrPackageFileNotExist(err) {
+			break
+		} else if err != nil {
+			apiServerError(ctx, "GetContainerManifest", err)
+			return
+		}
+		if err = packages.DeleteVersion(p, v); err != nil {
+			apiServerError(ctx, "DeleteVersion", err)
+			return
+		}
+		deleted = true
+		if !models.IsContainerDigest(reference) {
+			break
+		}
+	}
+	if !deleted {
+		apiError(ctx, 404, errCodeManifestUnknown, "Unknown manifest")
+		return
+	}
+	ctx.Status(202)
+}
+
+// GetTagsList lists the tags of an image in lexical order, the list is paginated by
+// the n parameter and continues after the tag of the last parameter.
+func GetTagsList(ctx *context.APIContext) {
+	p := loadImage(ctx)
+	if ctx.Written() {
+		return
+	} else if p == nil {
+		apiError(ctx, 404, errCodeNameUnknown, "Unknown repository name")
+		return
+	}
+
+	tags, err := models.GetContainerTags(p.ID)
+	if err != nil {
+		apiServerError(ctx, "GetContainerTags", err)
+		return
+	}
+	sort.Strings(tags)
+	if last := ctx.Query("last"); len(last) > 0 {
+		tags = tags[sort.SearchStrings(tags, last):]
+		if len(tags) > 0 && tags[0] == last {
+			tags = tags[1:]
+		}
+	}
+	if n := ctx.QueryInt("n"); n > 0 && len(tags) > n {
+		tags = tags[:n]
+		ctx.Resp.Header().Set("Link", fmt.Sprintf(`<%s/tags/list?n=%d&last=%s>; rel="next"`, imageLink(ctx), n, url.QueryEscape(tags[n-1])))
+	}
+
+	ctx.JSON(200, map[string]interface{}{
+		"name": ctx.Package.Owner.LowerName + "/" + ctx.Params(":image"),
+		"tags": tags,
+	})
+}
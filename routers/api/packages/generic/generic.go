@@ -0,0 +1,146 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package generic implements the API of the generic packages, a package
+// version holds arbitrary files uploaded and downloaded by name.
+package generic
+
+import (
+	"regexp"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/packages"
+)
+
+// nameRegex matches the valid package names, versions and filenames
+var nameRegex = regexp.MustCompile(`\A[A-Za-z0-9][A-Za-z0-9._+-]*\z`)
+
+// loadPackageVersion loads the package and the version of the request
+func loadPackageVersion(ctx *context.APIContext) (*models.Package, *models.PackageVersion) {
+	p, err := models.GetPackageByName(ctx.Package.Owner.ID, models.PackageGeneric, ctx.Params(":packagename"))
+	if err != nil {
+		if models.IsErrPackageNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(500, "GetPackageByName", err)
+		}
+		return nil, nil
+	}
+	v, err := models.GetPackageVersionByName(p.ID, ctx.Params(":packageversion"))
+	if err != nil {
+		if models.IsErrPackageVersionNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(500, "GetPackageVersionByName", err)
+		}
+		return nil, nil
+	}
+	return p, v
+}
+
+// loadPackageFile loads the package, the version and the file of the request
+func loadPackageFile(ctx *context.APIContext) (*models.Package, *models.PackageVersion, *models.PackageFile) {
+	p, v := loadPackageVersion(ctx)
+	if ctx.Written() {
+		return nil, nil, nil
+	}
+	f, err := models.GetPackageFileByName(v.ID, ctx.Params(":filename"))
+	if err != nil {
+		if models.IsErrPackageFileNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(500, "GetPackageFileByName", err)
+		}
+		return nil, nil, nil
+	}
+	return p, v, f
+}
+
+// DownloadPackageFile serves a file of a package version
+func DownloadPackageFile(ctx *context.APIContext) {
+	_, v, f := loadPackageFile(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	r, err := packages.OpenFile(f)
+	if err != nil {
+		ctx.Error(500, "OpenFile", err)
+		return
+	}
+	defer r.Close()
+
+	if err = v.IncreaseDownloadCount(); err != nil {
+		log.Error("IncreaseDownloadCount: %v", err)
+	}
+
+	ctx.ServeContent(f.Name, r, f.CreatedUnix.AsTime())
+}
+
+// UploadPackage adds a file to a package version, the package and the version are
+// created with their first file. A file can not be replaced, it must be deleted first.
+func UploadPackage(ctx *context.APIContext) {
+	name := ctx.Params(":packagename")
+	version := ctx.Params(":packageversion")
+	filename := ctx.Params(":filename")
+	if !nameRegex.MatchString(name) || !nameRegex.MatchString(version) || !nameRegex.MatchString(filename) {
+		ctx.Error(400, "", "Invalid package name, version or filename")
+		return
+	}
+
+	body := ctx.Req.Request.Body
+	defer body.Close()
+
+	_, err := packages.AddFile(&models.AddPackageFileOptions{
+		Owner:    ctx.Package.Owner,
+		Creator:  ctx.User,
+		Type:     models.PackageGeneric,
+		Name:     name,
+		Version:  version,
+		Filename: filename,
+	}, body)
+	if err != nil {
+		switch {
+		case models.IsErrPackageFileAlreadyExist(err):
+			ctx.Error(409, "", err)
+		case err == packages.ErrFileTooLarge:
+			ctx.Error(413, "", err)
+		default:
+			ctx.Error(500, "AddFile", err)
+		}
+		return
+	}
+
+	ctx.Status(201)
+}
+
+// DeletePackage deletes a package version with all its files
+func DeletePackage(ctx *context.APIContext) {
+	p, v := loadPackageVersion(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if err := packages.DeleteVersion(p, v); err != nil {
+		ctx.Error(500, "DeleteVersion", err)
+		return
+	}
+	ctx.Status(204)
+}
+
+// DeletePackageFile deletes a file of a package version, the version is deleted with its last file
+func DeletePackageFile(ctx *context.APIContext) {
+	p, v, f := loadPackageFile(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if err := packages.DeleteFile(p, v, f); err != nil {
+		ctx.Error(500, "DeleteFile", err)
+		return
+	}
+	ctx.Status(204)
+}
@@ -59,6 +59,7 @@
 package v1
 
 import (
+	"fmt"
 	"strings"
 
 	"code.gitea.io/gitea/models"
@@ -166,6 +167,11 @@ func repoAssignment() macaron.Handler {
 			ctx.NotFound()
 			return
 		}
+
+		if token := ctx.AccessToken(); token != nil && !token.CanAccessRepo(repo.ID) {
+			ctx.Error(403, "", "The access token is restricted to other repositories")
+			return
+		}
 	}
 }
 
@@ -187,6 +193,40 @@ func reqToken() macaron.Handler {
 	}
 }
 
+// reqTokenScope the personal access token, if any, should be granted the read scope
+// for the read-only requests and the write scope for the other ones
+func reqTokenScope(read, write models.AccessTokenScope) macaron.Handler {
+	return func(ctx *context.APIContext) {
+		token := ctx.AccessToken()
+		if token == nil {
+			return
+		}
+		scope := write
+		if ctx.Req.Method == "GET" || ctx.Req.Method == "HEAD" {
+			scope = read
+		}
+		if !token.HasScope(scope) {
+			ctx.Error(403, "", fmt.Sprintf("The access token is not granted the %s scope", scope))
+			return
+		}
+	}
+}
+
+// reqRepoTokenScope the personal access token, if any, should be granted the repository scopes
+func reqRepoTokenScope() macaron.Handler {
+	return reqTokenScope(models.AccessTokenScopeRepoRead, models.AccessTokenScopeRepoWrite)
+}
+
+// reqOrgTokenScope the personal access token, if any, should be granted the organization scope
+func reqOrgTokenScope() macaron.Handler {
+	return reqTokenScope(models.AccessTokenScopeOrg, models.AccessTokenScopeOrg)
+}
+
+// reqUserTokenScope the personal access token, if any, should be granted the user scope
+func reqUserTokenScope() macaron.Handler {
+	return reqTokenScope(models.AccessTokenScopeUser, models.AccessTokenScopeUser)
+}
+
 func reqBasicAuth() macaron.Handler {
 	return func(ctx *context.APIContext) {
 		if !ctx.Context.IsBasicAuth {
@@ -357,6 +397,65 @@ func reqOrgMembership() macaron.Handler {
 	}
 }
 
+// projectAssignment loads the project of the :id parameter, which the user should be able to read
+func projectAssignment() macaron.Handler {
+	return func(ctx *context.APIContext) {
+		p, err := models.GetProjectByID(ctx.ParamsInt64(":id"))
+		if err != nil {
+			if models.IsErrProjectNotExist(err) {
+				ctx.NotFound()
+			} else {
+				ctx.Error(500, "GetProjectByID", err)
+			}
+			return
+		}
+		if err = p.LoadAttributes(); err != nil {
+			ctx.Error(500, "LoadAttributes", err)
+			return
+		}
+
+		var canRead, canWrite bool
+		if p.IsOrgProject() {
+			canRead = models.HasOrgVisible(p.Owner, ctx.User)
+			if canRead {
+				if canWrite, err = models.CanWriteOrgProjects(p.Owner, ctx.User); err != nil {
+					ctx.Error(500, "CanWriteOrgProjects", err)
+					return
+				}
+			}
+		} else {
+			perm, err := models.GetUserRepoPermission(p.Repo, ctx.User)
+			if err != nil {
+				ctx.Error(500, "GetUserRepoPermission", err)
+				return
+			}
+			canRead = perm.CanRead(models.UnitTypeProjects)
+			canWrite = perm.CanWrite(models.UnitTypeProjects) && !p.Repo.IsArchived
+		}
+		if !canRead {
+			ctx.NotFound()
+			return
+		}
+		// a restricted token can only access the projects of its repositories, not the organization projects
+		if token := ctx.AccessToken(); token != nil && !token.CanAccessRepo(p.RepoID) {
+			ctx.Error(403, "", "The access token is restricted to other repositories")
+			return
+		}
+		ctx.Data["Project"] = p
+		ctx.Data["CanWriteProject"] = canWrite
+	}
+}
+
+// reqProjectWriter user should be able to manage the project
+func reqProjectWriter() macaron.Handler {
+	return func(ctx *context.APIContext) {
+		if canWrite, _ := ctx.Data["CanWriteProject"].(bool); !canWrite {
+			ctx.Error(403, "", "Must be able to write the project")
+			return
+		}
+	}
+}
+
 func reqGitHook() macaron.Handler {
 	return func(ctx *context.APIContext) {
 		if !ctx.User.CanEditGitHook() {
@@ -522,15 +621,15 @@ func RegisterRoutes(m *macaron.Macaron) {
 			m.Get("/search", user.Search)
 
 			m.Group("/:username", func() {
-				m.Get("", user.GetInfo)
-				m.Get("/heatmap", mustEnableUserHeatmap, user.GetUserHeatmapData)
+				m.Get("", reqUserTokenScope(), user.GetInfo)
+				m.Get("/heatmap", mustEnableUserHeatmap, reqUserTokenScope(), user.GetUserHeatmapData)
 
-				m.Get("/repos", user.ListUserRepos)
+				m.Get("/repos", reqRepoTokenScope(), user.ListUserRepos)
 				m.Group("/tokens", func() {
 					m.Combo("").Get(user.ListAccessTokens).
 						Post(bind(api.CreateAccessTokenOption{}), user.CreateAccessToken)
 					m.Combo("/:id").Delete(user.DeleteAccessToken)
-				}, reqBasicAuth())
+				}, reqBasicAuth(), reqUserTokenScope())
 			})
 		})
 
@@ -549,7 +648,7 @@ func RegisterRoutes(m *macaron.Macaron) {
 
 				m.Get("/subscriptions", user.GetWatchedRepos)
 			})
-		}, reqToken())
+		}, reqToken(), reqUserTokenScope())
 
 		m.Group("/user", func() {
 			m.Get("", user.GetAuthenticatedUser)
@@ -577,9 +676,6 @@ func RegisterRoutes(m *macaron.Macaron) {
 					Delete(user.DeleteGPGKey)
 			})
 
-			m.Combo("/repos").Get(user.ListMyRepos).
-				Post(bind(api.CreateRepoOption{}), repo.Create)
-
 			m.Group("/starred", func() {
 				m.Get("", user.GetMyStarredRepos)
 				m.Group("/:username/:reponame", func() {
@@ -593,16 +689,19 @@ func RegisterRoutes(m *macaron.Macaron) {
 			m.Get("/subscriptions", user.GetMyWatchedRepos)
 
 			m.Get("/teams", org.ListUserTeams)
-		}, reqToken())
+		}, reqToken(), reqUserTokenScope())
 
 		// Repositories
-		m.Post("/org/:org/repos", reqToken(), bind(api.CreateRepoOption{}), repo.CreateOrgRepo)
+		m.Combo("/user/repos", reqToken(), reqRepoTokenScope()).Get(user.ListMyRepos).
+			Post(bind(api.CreateRepoOption{}), repo.Create)
+		m.Post("/org/:org/repos", reqToken(), reqTokenScope(models.AccessTokenScopeOrg, models.AccessTokenScopeOrg),
+			bind(api.CreateRepoOption{}), repo.CreateOrgRepo)
 
 		m.Group("/repos", func() {
-			m.Get("/search", repo.Search)
+			m.Get("/search", reqRepoTokenScope(), repo.Search)
 		})
 
-		m.Combo("/repositories/:id", reqToken()).Get(repo.GetByID)
+		m.Combo("/repositories/:id", reqToken(), reqRepoTokenScope()).Get(repo.GetByID)
 
 		m.Group("/repos", func() {
 			m.Post("/migrate", reqToken(), bind(auth.MigrateRepoForm{}), repo.Migrate)
@@ -611,6 +710,10 @@ func RegisterRoutes(m *macaron.Macaron) {
 				m.Combo("").Get(reqAnyRepoReader(), repo.Get).
 					Delete(reqToken(), reqOwner(), repo.Delete).
 					Patch(reqToken(), reqAdmin(), bind(api.EditRepoOption{}), repo.Edit)
+				m.Group("/migration", func() {
+					m.Get("", repo.GetMigration)
+					m.Post("/retry", reqToken(), reqAdmin(), bind(api.RetryMigrationOption{}), repo.RetryMigration)
+				}, reqAnyRepoReader())
 				m.Group("/hooks", func() {
 					m.Combo("").Get(repo.ListHooks).
 						Post(bind(api.CreateHookOption{}), repo.CreateHook)
@@ -619,6 +722,7 @@ func RegisterRoutes(m *macaron.Macaron) {
 							Patch(bind(api.EditHookOption{}), repo.EditHook).
 							Delete(repo.DeleteHook)
 						m.Post("/tests", context.RepoRef(), repo.TestHook)
+						m.Post("/deliveries/:uuid/redeliver", repo.RedeliverHook)
 					})
 					m.Group("/git", func() {
 						m.Combo("").Get(repo.ListGitHooks)
@@ -639,6 +743,7 @@ func RegisterRoutes(m *macaron.Macaron) {
 				m.Get("/archive/*", reqRepoReader(models.UnitTypeCode), repo.GetArchive)
 				m.Combo("/forks").Get(repo.ListForks).
 					Post(reqToken(), reqRepoReader(models.UnitTypeCode), bind(api.CreateForkOption{}), repo.CreateFork)
+				m.Post("/generate", reqToken(), reqRepoReader(models.UnitTypeCode), bind(api.GenerateRepoOption{}), repo.Generate)
 				m.Group("/branches", func() {
 					m.Get("", repo.ListBranches)
 					m.Get("/*", context.RepoRefByType(context.RepoRefBranch), repo.GetBranch)
@@ -652,6 +757,15 @@ func RegisterRoutes(m *macaron.Macaron) {
 					m.Combo("/:id").Get(repo.GetDeployKey).
 						Delete(repo.DeleteDeploykey)
 				}, reqToken(), reqAdmin())
+				m.Group("/push_mirrors", func() {
+					m.Combo("").Get(repo.ListPushMirrors).
+						Post(bind(api.CreatePushMirrorOption{}), repo.AddPushMirror)
+					m.Group("/:id", func() {
+						m.Combo("").Get(repo.GetPushMirror).
+							Delete(repo.DeletePushMirror)
+						m.Post("/sync", repo.SyncPushMirror)
+					})
+				}, reqToken(), reqAdmin())
 				m.Group("/times", func() {
 					m.Combo("").Get(repo.ListTrackedTimesByRepository)
 					m.Combo("/:timetrackingusername").Get(repo.ListTrackedTimesByUser)
@@ -712,6 +826,8 @@ func RegisterRoutes(m *macaron.Macaron) {
 						Patch(reqToken(), reqRepoWriter(models.UnitTypeIssues, models.UnitTypePullRequests), bind(api.EditMilestoneOption{}), repo.EditMilestone).
 						Delete(reqToken(), reqRepoWriter(models.UnitTypeIssues, models.UnitTypePullRequests), repo.DeleteMilestone)
 				})
+				m.Combo("/projects", reqRepoReader(models.UnitTypeProjects)).Get(repo.ListProjects).
+					Post(reqToken(), reqRepoWriter(models.UnitTypeProjects), mustNotBeArchived, bind(api.CreateProjectOption{}), repo.CreateProject)
 				m.Get("/stargazers", repo.ListStargazers)
 				m.Get("/subscribers", repo.ListSubscribers)
 				m.Group("/subscription", func() {
@@ -745,6 +861,17 @@ func RegisterRoutes(m *macaron.Macaron) {
 							Patch(reqToken(), reqRepoWriter(models.UnitTypePullRequests), bind(api.EditPullRequestOption{}), repo.EditPullRequest)
 						m.Combo("/merge").Get(repo.IsPullRequestMerged).
 							Post(reqToken(), mustNotBeArchived, reqRepoWriter(models.UnitTypePullRequests), bind(auth.MergePullRequestForm{}), repo.MergePullRequest)
+						m.Group("/reviews", func() {
+							m.Combo("").Get(repo.ListPullReviews).
+								Post(reqToken(), mustNotBeArchived, bind(api.CreatePullReviewOptions{}), repo.CreatePullReview)
+							m.Group("/:id", func() {
+								m.Combo("").Get(repo.GetPullReview).
+									Post(reqToken(), mustNotBeArchived, bind(api.SubmitPullReviewOptions{}), repo.SubmitPullReview).
+									Delete(reqToken(), repo.DeletePullReview)
+								m.Get("/comments", repo.GetPullReviewComments)
+								m.Post("/dismissals", reqToken(), mustNotBeArchived, reqRepoWriter(models.UnitTypePullRequests), bind(api.DismissPullReviewOptions{}), repo.DismissPullReview)
+							})
+						})
 					})
 				}, mustAllowPulls, reqRepoReader(models.UnitTypeCode), context.ReferencesGitRepo(false))
 				m.Group("/statuses", func() {
@@ -776,12 +903,12 @@ func RegisterRoutes(m *macaron.Macaron) {
 					}, reqRepoWriter(models.UnitTypeCode), reqToken())
 				}, reqRepoReader(models.UnitTypeCode))
 			}, repoAssignment())
-		})
+		}, reqRepoTokenScope())
 
 		// Organizations
-		m.Get("/user/orgs", reqToken(), org.ListMyOrgs)
-		m.Get("/users/:username/orgs", org.ListUserOrgs)
-		m.Post("/orgs", reqToken(), bind(api.CreateOrgOption{}), org.Create)
+		m.Get("/user/orgs", reqToken(), reqOrgTokenScope(), org.ListMyOrgs)
+		m.Get("/users/:username/orgs", reqOrgTokenScope(), org.ListUserOrgs)
+		m.Post("/orgs", reqToken(), reqOrgTokenScope(), bind(api.CreateOrgOption{}), org.Create)
 		m.Group("/orgs/:orgname", func() {
 			m.Get("/repos", user.ListOrgRepos)
 			m.Combo("").Get(org.Get).
@@ -807,7 +934,33 @@ func RegisterRoutes(m *macaron.Macaron) {
 					Patch(bind(api.EditHookOption{}), org.EditHook).
 					Delete(org.DeleteHook)
 			}, reqToken(), reqOrgOwnership())
-		}, orgAssignment(true))
+			m.Combo("/projects").Get(org.ListProjects).
+				Post(reqToken(), bind(api.CreateProjectOption{}), org.CreateProject)
+			m.Group("/labels", func() {
+				m.Combo("").Get(org.ListLabels).
+					Post(reqToken(), reqOrgOwnership(), bind(api.CreateLabelOption{}), org.CreateLabel)
+				m.Combo("/:id").Get(org.GetLabel).
+					Patch(reqToken(), reqOrgOwnership(), bind(api.EditLabelOption{}), org.EditLabel).
+					Delete(reqToken(), reqOrgOwnership(), org.DeleteLabel)
+			})
+		}, orgAssignment(true), reqOrgTokenScope())
+		m.Group("/projects/:id", func() {
+			m.Combo("").Get(repo.GetProject).
+				Patch(reqToken(), reqProjectWriter(), bind(api.EditProjectOption{}), repo.EditProject).
+				Delete(reqToken(), reqProjectWriter(), repo.DeleteProject)
+			m.Group("/boards", func() {
+				m.Combo("").Get(repo.ListProjectBoards).
+					Post(reqToken(), reqProjectWriter(), bind(api.CreateProjectBoardOption{}), repo.CreateProjectBoard)
+				m.Combo("/:board_id", reqToken(), reqProjectWriter()).
+					Patch(bind(api.EditProjectBoardOption{}), repo.EditProjectBoard).
+					Delete(repo.DeleteProjectBoard)
+			})
+			m.Group("/cards", func() {
+				m.Combo("").Get(repo.ListProjectCards).
+					Post(reqToken(), reqProjectWriter(), bind(api.MoveProjectCardOption{}), repo.MoveProjectCard)
+				m.Delete("/:issue_id", reqToken(), reqProjectWriter(), repo.RemoveProjectCard)
+			})
+		}, projectAssignment(), reqRepoTokenScope())
 		m.Group("/teams/:teamid", func() {
 			m.Combo("").Get(org.GetTeam).
 				Patch(reqOrgOwnership(), bind(api.EditTeamOption{}), org.EditTeam).
@@ -825,7 +978,7 @@ func RegisterRoutes(m *macaron.Macaron) {
 					Put(org.AddTeamRepository).
 					Delete(org.RemoveTeamRepository)
 			})
-		}, orgAssignment(false, true), reqToken(), reqTeamMembership())
+		}, orgAssignment(false, true), reqToken(), reqOrgTokenScope(), reqTeamMembership())
 
 		m.Any("/*", func(ctx *context.APIContext) {
 			ctx.NotFound()
@@ -833,6 +986,13 @@ func RegisterRoutes(m *macaron.Macaron) {
 
 		m.Group("/admin", func() {
 			m.Get("/orgs", admin.GetAllOrgs)
+			m.Group("/hooks", func() {
+				m.Combo("").Get(admin.ListHooks).
+					Post(bind(api.CreateHookOption{}), admin.CreateHook)
+				m.Combo("/:id").Get(admin.GetHook).
+					Patch(bind(api.EditHookOption{}), admin.EditHook).
+					Delete(admin.DeleteHook)
+			})
 			m.Group("/users", func() {
 				m.Get("", admin.GetAllUsers)
 				m.Post("", bind(api.CreateUserOption{}), admin.CreateUser)
@@ -848,7 +1008,7 @@ func RegisterRoutes(m *macaron.Macaron) {
 					m.Post("/repos", bind(api.CreateRepoOption{}), admin.CreateRepo)
 				})
 			})
-		}, reqToken(), reqSiteAdmin())
+		}, reqToken(), reqTokenScope(models.AccessTokenScopeAdmin, models.AccessTokenScopeAdmin), reqSiteAdmin())
 
 		m.Group("/topics", func() {
 			m.Get("/search", repo.TopicSearch)
@@ -12,6 +12,7 @@ import (
 	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/markup"
+	"code.gitea.io/gitea/modules/setting"
 	api "code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/modules/util"
 
@@ -160,12 +161,24 @@ func ToHook(repoLink string, w *models.Webhook) *api.Hook {
 		"url":          w.URL,
 		"content_type": w.ContentType.Name(),
 	}
-	if w.HookTaskType == models.SLACK {
+	switch w.HookTaskType {
+	case models.SLACK:
 		s := w.GetSlackHook()
 		config["channel"] = s.Channel
 		config["username"] = s.Username
 		config["icon_url"] = s.IconURL
 		config["color"] = s.Color
+	case models.MATRIX:
+		// the access token is not returned
+		s := w.GetMatrixHook()
+		config["homeserver_url"] = s.HomeserverURL
+		config["room_id"] = s.RoomID
+		config["message_type"] = s.MessageType
+	case models.CUSTOM:
+		s := w.GetCustomHook()
+		config["content_type"] = s.ContentType
+		config["http_method"] = w.HTTPMethod
+		config["template"] = s.Template
 	}
 
 	return &api.Hook{
@@ -180,6 +193,13 @@ func ToHook(repoLink string, w *models.Webhook) *api.Hook {
 	}
 }
 
+// ToSystemHook convert models.Webhook of the instance to api.Hook
+func ToSystemHook(w *models.Webhook) *api.Hook {
+	hook := ToHook("", w)
+	hook.URL = fmt.Sprintf("%sadmin/system-hooks/%d", setting.AppURL, w.ID)
+	return hook
+}
+
 // ToGitHook convert git.Hook to api.GitHook
 func ToGitHook(h *git.Hook) *api.GitHook {
 	return &api.GitHook{
@@ -203,6 +223,23 @@ func ToDeployKey(apiLink string, key *models.DeployKey) *api.DeployKey {
 	}
 }
 
+// ToPushMirror convert models.PushMirror to api.PushMirror
+func ToPushMirror(m *models.PushMirror) *api.PushMirror {
+	apiMirror := &api.PushMirror{
+		ID:            m.ID,
+		RemoteName:    m.RemoteName,
+		RemoteAddress: m.Address(),
+		Interval:      m.Interval.String(),
+		Created:       m.CreatedUnix.AsTime(),
+		LastError:     m.LastError,
+	}
+	if m.LastUpdateUnix > 0 {
+		lastUpdate := m.LastUpdateUnix.AsTime()
+		apiMirror.LastUpdate = &lastUpdate
+	}
+	return apiMirror
+}
+
 // ToOrganization convert models.User to api.Organization
 func ToOrganization(org *models.User) *api.Organization {
 	return &api.Organization{
@@ -0,0 +1,90 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package convert
+
+import (
+	"code.gitea.io/gitea/models"
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+// ToPullReviewState convert models.ReviewType to api.ReviewStateType
+func ToPullReviewState(t models.ReviewType) api.ReviewStateType {
+	switch t {
+	case models.ReviewTypePending:
+		return api.ReviewStatePending
+	case models.ReviewTypeApprove:
+		return api.ReviewStateApproved
+	case models.ReviewTypeComment:
+		return api.ReviewStateComment
+	case models.ReviewTypeReject:
+		return api.ReviewStateRequestChanges
+	}
+	return api.ReviewStateUnknown
+}
+
+// ToPullReview convert a review to api format, the issue and the reviewer of the review must be loaded
+func ToPullReview(r *models.Review) (*api.PullReview, error) {
+	count, err := r.GetCodeCommentsCount()
+	if err != nil {
+		return nil, err
+	}
+	reviewer := r.Reviewer
+	if reviewer == nil {
+		reviewer = models.NewGhostUser()
+	}
+	return &api.PullReview{
+		ID:                r.ID,
+		Reviewer:          reviewer.APIFormat(),
+		State:             ToPullReviewState(r.Type),
+		Body:              r.Content,
+		CommitID:          r.CommitID,
+		Dismissed:         r.Dismissed,
+		CodeCommentsCount: int(count),
+		Submitted:         r.UpdatedUnix.AsTime(),
+		HTMLURL:           r.HTMLURL(),
+		HTMLPullURL:       r.Issue.HTMLURL(),
+	}, nil
+}
+
+// ToPullReviewCommentList convert the code comments of a review to api format
+func ToPullReviewCommentList(r *models.Review) ([]*api.PullReviewComment, error) {
+	comments, err := models.FindComments(models.FindCommentsOptions{
+		Type:     models.CommentTypeCode,
+		IssueID:  r.IssueID,
+		ReviewID: r.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err = models.CommentList(comments).LoadPosters(); err != nil {
+		return nil, err
+	}
+
+	apiComments := make([]*api.PullReviewComment, 0, len(comments))
+	for _, comment := range comments {
+		comment.Issue = r.Issue
+		apiComment := &api.PullReviewComment{
+			ID:          comment.ID,
+			Body:        comment.Content,
+			Reviewer:    comment.Poster.APIFormat(),
+			ReviewID:    r.ID,
+			Created:     comment.CreatedUnix.AsTime(),
+			Updated:     comment.UpdatedUnix.AsTime(),
+			Path:        comment.TreePath,
+			CommitID:    comment.CommitSHA,
+			DiffHunk:    comment.Patch,
+			Outdated:    comment.Invalidated,
+			HTMLURL:     comment.HTMLURL(),
+			HTMLPullURL: r.Issue.HTMLURL(),
+		}
+		if comment.Line < 0 {
+			apiComment.OldLineNum = comment.UnsignedLine()
+		} else {
+			apiComment.LineNum = comment.UnsignedLine()
+		}
+		apiComments = append(apiComments, apiComment)
+	}
+	return apiComments, nil
+}
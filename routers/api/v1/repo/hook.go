@@ -140,6 +140,63 @@ func TestHook(ctx *context.APIContext) {
 	ctx.Status(204)
 }
 
+// RedeliverHook delivers the payload of a previous delivery of a hook again
+func RedeliverHook(ctx *context.APIContext) {
+	// swagger:operation POST /repos/{owner}/{repo}/hooks/{id}/deliveries/{uuid}/redeliver repository repoRedeliverHook
+	// ---
+	// summary: Redeliver the payload of a previous delivery of a hook
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the hook
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: uuid
+	//   in: path
+	//   description: uuid of the delivery to redeliver, as sent in the X-Gitea-Delivery header
+	//   type: string
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	hook, err := utils.GetRepoHook(ctx, ctx.Repo.Repository.ID, ctx.ParamsInt64(":id"))
+	if err != nil {
+		return
+	}
+
+	t, err := models.GetHookTaskByUUID(hook.ID, ctx.Params(":uuid"))
+	if err != nil {
+		if models.IsErrHookTaskNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(500, "GetHookTaskByUUID", err)
+		}
+		return
+	}
+
+	if _, err = models.RedeliverHookTask(t); err != nil {
+		ctx.Error(500, "RedeliverHookTask", err)
+		return
+	}
+	go models.HookQueue.Add(ctx.Repo.Repository.ID)
+	ctx.Status(204)
+}
+
 // CreateHook create a hook for a repository
 func CreateHook(ctx *context.APIContext, form api.CreateHookOption) {
 	// swagger:operation POST /repos/{owner}/{repo}/hooks repository repoCreateHook
@@ -183,12 +183,15 @@ func DeleteIssueLabel(ctx *context.APIContext) {
 		return
 	}
 
-	label, err := models.GetLabelInRepoByID(ctx.Repo.Repository.ID, ctx.ParamsInt64(":id"))
+	label, err := models.GetLabelByID(ctx.ParamsInt64(":id"))
+	if err == nil && !label.IsUsableIn(ctx.Repo.Repository) {
+		err = models.ErrLabelNotExist{LabelID: label.ID, RepoID: ctx.Repo.Repository.ID}
+	}
 	if err != nil {
 		if models.IsErrLabelNotExist(err) {
 			ctx.Error(422, "", err)
 		} else {
-			ctx.Error(500, "GetLabelInRepoByID", err)
+			ctx.Error(500, "GetLabelByID", err)
 		}
 		return
 	}
@@ -0,0 +1,578 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/setting"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/util"
+)
+
+// ProjectStateOption converts the state query of a projects list to a search option
+func ProjectStateOption(state string) util.OptionalBool {
+	switch state {
+	case "closed":
+		return util.OptionalBoolTrue
+	case "all":
+		return util.OptionalBoolNone
+	default:
+		return util.OptionalBoolFalse
+	}
+}
+
+// ListProjectsByOptions writes the projects matching the options
+func ListProjectsByOptions(ctx *context.APIContext, opts models.ProjectSearchOptions) {
+	projects, err := models.FindProjects(opts)
+	if err != nil {
+		ctx.Error(500, "FindProjects", err)
+		return
+	}
+	if err = projects.LoadAttributes(); err != nil {
+		ctx.Error(500, "LoadAttributes", err)
+		return
+	}
+
+	apiProjects := make([]*api.Project, len(projects))
+	for i := range projects {
+		apiProjects[i] = projects[i].APIFormat()
+	}
+	ctx.JSON(200, &apiProjects)
+}
+
+// CreateProjectByOptions creates the project and writes it
+func CreateProjectByOptions(ctx *context.APIContext, p *models.Project) {
+	p.CreatorID = ctx.User.ID
+	if err := models.NewProject(p); err != nil {
+		ctx.Error(500, "NewProject", err)
+		return
+	}
+	if err := p.LoadAttributes(); err != nil {
+		ctx.Error(500, "LoadAttributes", err)
+		return
+	}
+	ctx.JSON(201, p.APIFormat())
+}
+
+// ListProjects list the projects of a repository
+func ListProjects(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/projects project projectListRepoProjects
+	// ---
+	// summary: List a repository's projects
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: state
+	//   in: query
+	//   description: Project state, Recognised values are open, closed and all. Defaults to "open"
+	//   type: string
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ProjectList"
+	ListProjectsByOptions(ctx, models.ProjectSearchOptions{
+		RepoID:   ctx.Repo.Repository.ID,
+		IsClosed: ProjectStateOption(ctx.Query("state")),
+		Page:     ctx.QueryInt("page"),
+		PageSize: setting.UI.IssuePagingNum,
+	})
+}
+
+// CreateProject create a project for a repository
+func CreateProject(ctx *context.APIContext, form api.CreateProjectOption) {
+	// swagger:operation POST /repos/{owner}/{repo}/projects project projectCreateRepoProject
+	// ---
+	// summary: Create a project in a repository
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/CreateProjectOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/Project"
+	CreateProjectByOptions(ctx, &models.Project{
+		RepoID:      ctx.Repo.Repository.ID,
+		Title:       form.Title,
+		Description: form.Description,
+	})
+}
+
+// apiProject returns the project loaded by the project assignment
+func apiProject(ctx *context.APIContext) *models.Project {
+	return ctx.Data["Project"].(*models.Project)
+}
+
+// GetProject get a project
+func GetProject(ctx *context.APIContext) {
+	// swagger:operation GET /projects/{id} project projectGetProject
+	// ---
+	// summary: Get a project
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/Project"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	p := apiProject(ctx)
+	if err := models.ProjectList([]*models.Project{p}).LoadAttributes(); err != nil {
+		ctx.Error(500, "LoadAttributes", err)
+		return
+	}
+	ctx.JSON(200, p.APIFormat())
+}
+
+// EditProject edit a project
+func EditProject(ctx *context.APIContext, form api.EditProjectOption) {
+	// swagger:operation PATCH /projects/{id} project projectEditProject
+	// ---
+	// summary: Update a project, the state can be "open" or "closed"
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/EditProjectOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/Project"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	p := apiProject(ctx)
+	if form.Title != nil && len(*form.Title) > 0 {
+		p.Title = *form.Title
+	}
+	if form.Description != nil {
+		p.Description = *form.Description
+	}
+	if err := models.UpdateProject(p); err != nil {
+		ctx.Error(500, "UpdateProject", err)
+		return
+	}
+	if form.State != nil && (*form.State == string(api.StateClosed)) != p.IsClosed {
+		if err := models.ChangeProjectStatus(p, *form.State == string(api.StateClosed)); err != nil {
+			ctx.Error(500, "ChangeProjectStatus", err)
+			return
+		}
+	}
+
+	if err := models.ProjectList([]*models.Project{p}).LoadAttributes(); err != nil {
+		ctx.Error(500, "LoadAttributes", err)
+		return
+	}
+	ctx.JSON(200, p.APIFormat())
+}
+
+// DeleteProject delete a project
+func DeleteProject(ctx *context.APIContext) {
+	// swagger:operation DELETE /projects/{id} project projectDeleteProject
+	// ---
+	// summary: Delete a project with its boards
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	if err := models.DeleteProjectByID(apiProject(ctx).ID); err != nil {
+		ctx.Error(500, "DeleteProjectByID", err)
+		return
+	}
+	ctx.Status(204)
+}
+
+// ListProjectBoards list the boards of a project
+func ListProjectBoards(ctx *context.APIContext) {
+	// swagger:operation GET /projects/{id}/boards project projectListBoards
+	// ---
+	// summary: List the boards of a project in their order
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ProjectBoardList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	boards, err := apiProject(ctx).GetBoards()
+	if err != nil {
+		ctx.Error(500, "GetBoards", err)
+		return
+	}
+
+	apiBoards := make([]*api.ProjectBoard, len(boards))
+	for i := range boards {
+		apiBoards[i] = boards[i].APIFormat()
+	}
+	ctx.JSON(200, &apiBoards)
+}
+
+// CreateProjectBoard add a board to a project
+func CreateProjectBoard(ctx *context.APIContext, form api.CreateProjectBoardOption) {
+	// swagger:operation POST /projects/{id}/boards project projectCreateBoard
+	// ---
+	// summary: Add a board at the end of a project
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/CreateProjectBoardOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/ProjectBoard"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	trigger, ok := models.ToProjectBoardTrigger(form.Trigger)
+	if !ok {
+		ctx.Error(422, "", "invalid trigger")
+		return
+	}
+
+	b := &models.ProjectBoard{
+		ProjectID: apiProject(ctx).ID,
+		Title:     form.Title,
+		Trigger:   trigger,
+		CreatorID: ctx.User.ID,
+	}
+	if err := models.NewProjectBoard(b); err != nil {
+		ctx.Error(500, "NewProjectBoard", err)
+		return
+	}
+	ctx.JSON(201, b.APIFormat())
+}
+
+// getAPIProjectBoard returns the board of the :board_id parameter in the project
+func getAPIProjectBoard(ctx *context.APIContext) *models.ProjectBoard {
+	b, err := models.GetProjectBoard(apiProject(ctx).ID, ctx.ParamsInt64(":board_id"))
+	if err != nil {
+		if models.IsErrProjectBoardNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(500, "GetProjectBoard", err)
+		}
+		return nil
+	}
+	return b
+}
+
+// EditProjectBoard edit a board of a project
+func EditProjectBoard(ctx *context.APIContext, form api.EditProjectBoardOption) {
+	// swagger:operation PATCH /projects/{id}/boards/{board_id} project projectEditBoard
+	// ---
+	// summary: Update a board of a project
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: board_id
+	//   in: path
+	//   description: id of the board
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/EditProjectBoardOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ProjectBoard"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	b := getAPIProjectBoard(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if form.Title != nil && len(*form.Title) > 0 {
+		b.Title = *form.Title
+	}
+	if form.Sorting != nil {
+		b.Sorting = *form.Sorting
+	}
+	if form.Trigger != nil {
+		trigger, ok := models.ToProjectBoardTrigger(*form.Trigger)
+		if !ok {
+			ctx.Error(422, "", "invalid trigger")
+			return
+		}
+		b.Trigger = trigger
+	}
+	if err := models.UpdateProjectBoard(b); err != nil {
+		ctx.Error(500, "UpdateProjectBoard", err)
+		return
+	}
+	ctx.JSON(200, b.APIFormat())
+}
+
+// DeleteProjectBoard delete a board of a project
+func DeleteProjectBoard(ctx *context.APIContext) {
+	// swagger:operation DELETE /projects/{id}/boards/{board_id} project projectDeleteBoard
+	// ---
+	// summary: Delete a board, its cards are moved to the uncategorized column
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: board_id
+	//   in: path
+	//   description: id of the board
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	b := getAPIProjectBoard(ctx)
+	if ctx.Written() {
+		return
+	}
+	if err := models.DeleteProjectBoard(b); err != nil {
+		ctx.Error(500, "DeleteProjectBoard", err)
+		return
+	}
+	ctx.Status(204)
+}
+
+// ListProjectCards list the cards of a project
+func ListProjectCards(ctx *context.APIContext) {
+	// swagger:operation GET /projects/{id}/cards project projectListCards
+	// ---
+	// summary: List the issues and pull requests of a project with their board
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ProjectCardList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	pis, err := models.GetProjectIssues(apiProject(ctx), ctx.User)
+	if err != nil {
+		ctx.Error(500, "GetProjectIssues", err)
+		return
+	}
+
+	apiCards := make([]*api.ProjectCard, len(pis))
+	for i := range pis {
+		apiCards[i] = pis[i].APIFormat()
+	}
+	ctx.JSON(200, &apiCards)
+}
+
+// MoveProjectCard add an issue to a project or move its card
+func MoveProjectCard(ctx *context.APIContext, form api.MoveProjectCardOption) {
+	// swagger:operation POST /projects/{id}/cards project projectMoveCard
+	// ---
+	// summary: Add an issue or a pull request to a project, or move its card to a position in a board
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/MoveProjectCardOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ProjectCard"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	p := apiProject(ctx)
+	issue, err := models.GetIssueByID(form.IssueID)
+	if err != nil {
+		if models.IsErrIssueNotExist(err) {
+			ctx.Error(422, "GetIssueByID", err)
+		} else {
+			ctx.Error(500, "GetIssueByID", err)
+		}
+		return
+	}
+	if err = issue.LoadRepo(); err != nil {
+		ctx.Error(500, "LoadRepo", err)
+		return
+	}
+	perm, err := models.GetUserRepoPermission(issue.Repo, ctx.User)
+	if err != nil {
+		ctx.Error(500, "GetUserRepoPermission", err)
+		return
+	}
+	if !perm.CanReadIssuesOrPulls(issue.IsPull) {
+		ctx.Error(422, "GetIssueByID", models.ErrIssueNotExist{ID: issue.ID})
+		return
+	}
+	if token := ctx.AccessToken(); token != nil && !token.CanAccessRepo(issue.RepoID) {
+		ctx.Error(403, "", "The access token is restricted to other repositories")
+		return
+	}
+
+	if err = models.MoveIssueToProjectBoard(p, issue, form.BoardID, form.Position); err != nil {
+		if models.IsErrProjectBoardNotExist(err) || models.IsErrProjectIssueNotAllowed(err) {
+			ctx.Error(422, "MoveIssueToProjectBoard", err)
+		} else {
+			ctx.Error(500, "MoveIssueToProjectBoard", err)
+		}
+		return
+	}
+
+	pi, err := models.GetProjectIssue(p.ID, issue.ID)
+	if err != nil {
+		ctx.Error(500, "GetProjectIssue", err)
+		return
+	}
+	pi.Issue = issue
+	ctx.JSON(200, pi.APIFormat())
+}
+
+// RemoveProjectCard remove an issue from a project
+func RemoveProjectCard(ctx *context.APIContext) {
+	// swagger:operation DELETE /projects/{id}/cards/{issue_id} project projectRemoveCard
+	// ---
+	// summary: Remove an issue or a pull request from a project
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: issue_id
+	//   in: path
+	//   description: id of the issue or the pull request
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	p := apiProject(ctx)
+	if _, err := models.GetProjectIssue(p.ID, ctx.ParamsInt64(":issue_id")); err != nil {
+		if models.IsErrProjectIssueNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(500, "GetProjectIssue", err)
+		}
+		return
+	}
+	if err := models.RemoveIssueFromProject(p, ctx.ParamsInt64(":issue_id")); err != nil {
+		ctx.Error(500, "RemoveIssueFromProject", err)
+		return
+	}
+	ctx.Status(204)
+}
@@ -0,0 +1,578 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"fmt"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/notification"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/routers/api/v1/convert"
+)
+
+// ListPullReviews lists all reviews of a pull request
+func ListPullReviews(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/pulls/{index}/reviews repository repoListPullReviews
+	// ---
+	// summary: List all reviews for a pull request.
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the pull request
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/PullReviewList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	pr := getPullRequestByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	reviews, err := models.FindReviews(models.FindReviewOptions{
+		Type:    models.ReviewTypeUnknown,
+		IssueID: pr.IssueID,
+	})
+	if err != nil {
+		ctx.Error(500, "FindReviews", err)
+		return
+	}
+
+	apiReviews := make([]*api.PullReview, 0, len(reviews))
+	for _, review := range reviews {
+		// Pending reviews are only visible to their reviewer
+		if review.Type == models.ReviewTypePending && (ctx.User == nil || review.ReviewerID != ctx.User.ID) {
+			continue
+		}
+		apiReview, err := toPullReview(review, pr)
+		if err != nil {
+			ctx.Error(500, "toPullReview", err)
+			return
+		}
+		apiReviews = append(apiReviews, apiReview)
+	}
+	ctx.JSON(200, &apiReviews)
+}
+
+// GetPullReview gets a specific review of a pull request
+func GetPullReview(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/pulls/{index}/reviews/{id} repository repoGetPullReview
+	// ---
+	// summary: Get a specific review for a pull request.
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the pull request
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the review
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/PullReview"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	review, pr := getPullReviewByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+	apiReview, err := toPullReview(review, pr)
+	if err != nil {
+		ctx.Error(500, "toPullReview", err)
+		return
+	}
+	ctx.JSON(200, apiReview)
+}
+
+// GetPullReviewComments lists all the code comments of a pull request review
+func GetPullReviewComments(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/pulls/{index}/reviews/{id}/comments repository repoGetPullReviewComments
+	// ---
+	// summary: Get the code comments of a pull request review.
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the pull request
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the review
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/PullReviewCommentList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	review, _ := getPullReviewByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+	apiComments, err := convert.ToPullReviewCommentList(review)
+	if err != nil {
+		ctx.Error(500, "ToPullReviewCommentList", err)
+		return
+	}
+	ctx.JSON(200, &apiComments)
+}
+
+// CreatePullReview creates a review of a pull request
+func CreatePullReview(ctx *context.APIContext, form api.CreatePullReviewOptions) {
+	// swagger:operation POST /repos/{owner}/{repo}/pulls/{index}/reviews repository repoCreatePullReview
+	// ---
+	// summary: Create a review for a pull request, the review is submitted unless the event is empty or PENDING.
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the pull request
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/CreatePullReviewOptions"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/PullReview"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	pr := getPullRequestByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+	issue := pr.Issue
+
+	reviewType, ok := preparePullReviewType(ctx, issue, form.Event, form.Body, len(form.Comments) > 0)
+	if !ok {
+		return
+	}
+
+	// The review is of the head commit unless another commit of the pull request is given
+	commitID := form.CommitID
+	if commitID == "" {
+		var err error
+		if commitID, err = pr.GetHeadCommitID(); err != nil {
+			ctx.Error(500, "GetHeadCommitID", err)
+			return
+		}
+	} else if has, err := pr.HasCommit(commitID); err != nil {
+		ctx.Error(500, "HasCommit", err)
+		return
+	} else if !has {
+		ctx.Error(422, "", "commit_id is not a commit of the pull request")
+		return
+	}
+
+	// Code comments are always added to the pending review of the user
+	review, err := models.GetCurrentReview(ctx.User, issue)
+	if err != nil {
+		if !models.IsErrReviewNotExist(err) {
+			ctx.Error(500, "GetCurrentReview", err)
+			return
+		}
+		if review, err = models.CreateReview(models.CreateReviewOptions{
+			Type:     models.ReviewTypePending,
+			Issue:    issue,
+			Reviewer: ctx.User,
+			Content:  form.Body,
+			CommitID: commitID,
+		}); err != nil {
+			ctx.Error(500, "CreateReview", err)
+			return
+		}
+	}
+
+	for _, c := range form.Comments {
+		line := c.NewLineNum
+		if line == 0 {
+			line = -c.OldLineNum
+		}
+		if _, err = models.CreateCodeComment(
+			ctx.User,
+			issue.Repo,
+			issue,
+			c.Body,
+			c.Path,
+			line,
+			review.ID,
+		); err != nil {
+			ctx.Error(500, "CreateCodeComment", err)
+			return
+		}
+	}
+
+	if reviewType != models.ReviewTypePending {
+		if review = submitPullReview(ctx, pr, reviewType, form.Body, commitID); ctx.Written() {
+			return
+		}
+	}
+
+	apiReview, err := toPullReview(review, pr)
+	if err != nil {
+		ctx.Error(500, "toPullReview", err)
+		return
+	}
+	ctx.JSON(200, apiReview)
+}
+
+// SubmitPullReview submits a pending review of a pull request
+func SubmitPullReview(ctx *context.APIContext, form api.SubmitPullReviewOptions) {
+	// swagger:operation POST /repos/{owner}/{repo}/pulls/{index}/reviews/{id} repository repoSubmitPullReview
+	// ---
+	// summary: Submit a pending review of a pull request.
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the pull request
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the review
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/SubmitPullReviewOptions"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/PullReview"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	review, pr := getPullReviewByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+	if review.Type != models.ReviewTypePending || review.ReviewerID != ctx.User.ID {
+		ctx.Error(422, "", "only your own pending reviews can be submitted")
+		return
+	}
+
+	count, err := review.GetCodeCommentsCount()
+	if err != nil {
+		ctx.Error(500, "GetCodeCommentsCount", err)
+		return
+	}
+	if form.Event == api.ReviewStatePending {
+		ctx.Error(422, "", "a review cannot be submitted as pending")
+		return
+	}
+	reviewType, ok := preparePullReviewType(ctx, pr.Issue, form.Event, form.Body, count > 0)
+	if !ok {
+		return
+	}
+
+	if review = submitPullReview(ctx, pr, reviewType, form.Body, ""); ctx.Written() {
+		return
+	}
+	apiReview, err := toPullReview(review, pr)
+	if err != nil {
+		ctx.Error(500, "toPullReview", err)
+		return
+	}
+	ctx.JSON(200, apiReview)
+}
+
+// DeletePullReview deletes a pending review of a pull request
+func DeletePullReview(ctx *context.APIContext) {
+	// swagger:operation DELETE /repos/{owner}/{repo}/pulls/{index}/reviews/{id} repository repoDeletePullReview
+	// ---
+	// summary: Delete a pending review of a pull request along with its code comments.
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the pull request
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the review
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	review, _ := getPullReviewByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+	if review.Type != models.ReviewTypePending || review.ReviewerID != ctx.User.ID {
+		ctx.Error(422, "", "only your own pending reviews can be deleted")
+		return
+	}
+	if err := models.DeleteReview(review); err != nil {
+		ctx.Error(500, "DeleteReview", err)
+		return
+	}
+	ctx.Status(204)
+}
+
+// DismissPullReview dismisses a review of a pull request
+func DismissPullReview(ctx *context.APIContext, form api.DismissPullReviewOptions) {
+	// swagger:operation POST /repos/{owner}/{repo}/pulls/{index}/reviews/{id}/dismissals repository repoDismissPullReview
+	// ---
+	// summary: Dismiss a review of a pull request so that it does not count towards its approvals.
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the pull request
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the review
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/DismissPullReviewOptions"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/PullReview"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	review, pr := getPullReviewByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+	if review.Type != models.ReviewTypeApprove && review.Type != models.ReviewTypeReject {
+		ctx.Error(422, "", "only approvals and requests for changes can be dismissed")
+		return
+	}
+	if _, err := models.DismissReview(ctx.User, review, form.Message); err != nil {
+		ctx.Error(500, "DismissReview", err)
+		return
+	}
+	apiReview, err := toPullReview(review, pr)
+	if err != nil {
+		ctx.Error(500, "toPullReview", err)
+		return
+	}
+	ctx.JSON(200, apiReview)
+}
+
+// getPullRequestByParams returns the pull request of the :index parameter along with its issue
+func getPullRequestByParams(ctx *context.APIContext) *models.PullRequest {
+	pr, err := models.GetPullRequestByIndex(ctx.Repo.Repository.ID, ctx.ParamsInt64(":index"))
+	if err != nil {
+		if models.IsErrPullRequestNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(500, "GetPullRequestByIndex", err)
+		}
+		return nil
+	}
+	if err = pr.LoadIssue(); err != nil {
+		ctx.Error(500, "LoadIssue", err)
+		return nil
+	}
+	if err = pr.Issue.LoadAttributes(); err != nil {
+		ctx.Error(500, "LoadAttributes", err)
+		return nil
+	}
+	pr.Issue.PullRequest = pr
+	return pr
+}
+
+// getPullReviewByParams returns the review of the :id parameter and its pull request,
+// pending reviews are only returned to their reviewer.
+func getPullReviewByParams(ctx *context.APIContext) (*models.Review, *models.PullRequest) {
+	pr := getPullRequestByParams(ctx)
+	if ctx.Written() {
+		return nil, nil
+	}
+	review, err := models.GetReviewByID(ctx.ParamsInt64(":id"))
+	if err != nil {
+		if models.IsErrReviewNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(500, "GetReviewByID", err)
+		}
+		return nil, nil
+	}
+	if review.IssueID != pr.IssueID ||
+		(review.Type == models.ReviewTypePending && (ctx.User == nil || review.ReviewerID != ctx.User.ID)) {
+		ctx.NotFound()
+		return nil, nil
+	}
+	return review, pr
+}
+
+// preparePullReviewType validates the event of a review and returns the type of the review
+func preparePullReviewType(ctx *context.APIContext, issue *models.Issue, event api.ReviewStateType, body string, hasComments bool) (models.ReviewType, bool) {
+	var reviewType models.ReviewType
+	switch event {
+	case api.ReviewStateUnknown, api.ReviewStatePending:
+		return models.ReviewTypePending, true
+	case api.ReviewStateApproved:
+		reviewType = models.ReviewTypeApprove
+	case api.ReviewStateRequestChanges:
+		reviewType = models.ReviewTypeReject
+	case api.ReviewStateComment:
+		reviewType = models.ReviewTypeComment
+	default:
+		ctx.Error(422, "", fmt.Sprintf("invalid review event: %s", event))
+		return models.ReviewTypeUnknown, false
+	}
+
+	// can not approve/reject your own PR
+	if reviewType != models.ReviewTypeComment && issue.PosterID == ctx.User.ID {
+		ctx.Error(422, "", "you cannot approve or request changes on your own pull request")
+		return models.ReviewTypeUnknown, false
+	}
+	if reviewType != models.ReviewTypeApprove && !hasComments && len(strings.TrimSpace(body)) == 0 {
+		ctx.Error(422, "", "review event needs a body or code comments")
+		return models.ReviewTypeUnknown, false
+	}
+	return reviewType, true
+}
+
+// submitPullReview publishes the pending review of the user and notifies about it
+func submitPullReview(ctx *context.APIContext, pr *models.PullRequest, reviewType models.ReviewType, body, commitID string) *models.Review {
+	review, comm, err := models.SubmitReview(ctx.User, pr.Issue, reviewType, body, commitID)
+	if err != nil {
+		ctx.Error(500, "SubmitReview", err)
+		return nil
+	}
+	notification.NotifyPullRequestReview(pr, review, comm)
+	return review
+}
+
+func toPullReview(review *models.Review, pr *models.PullRequest) (*api.PullReview, error) {
+	review.Issue = pr.Issue
+	if err := review.LoadAttributes(); err != nil && !models.IsErrUserNotExist(err) {
+		return nil, err
+	}
+	review.Issue = pr.Issue
+	return convert.ToPullReview(review)
+}
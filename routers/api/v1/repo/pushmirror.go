@@ -0,0 +1,236 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"net/url"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/routers/api/v1/convert"
+
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+// ListPushMirrors list all the push mirrors of a repository
+func ListPushMirrors(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/push_mirrors repository repoListPushMirrors
+	// ---
+	// summary: List a repository's push mirrors
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/PushMirrorList"
+	mirrors, err := models.GetPushMirrorsByRepoID(ctx.Repo.Repository.ID)
+	if err != nil {
+		ctx.Error(500, "GetPushMirrorsByRepoID", err)
+		return
+	}
+
+	apiMirrors := make([]*api.PushMirror, len(mirrors))
+	for i := range mirrors {
+		apiMirrors[i] = convert.ToPushMirror(mirrors[i])
+	}
+	ctx.JSON(200, &apiMirrors)
+}
+
+// GetPushMirror get a push mirror by id
+func GetPushMirror(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/push_mirrors/{id} repository repoGetPushMirror
+	// ---
+	// summary: Get a repository's push mirror by id
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the push mirror to get
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/PushMirror"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	m := getPushMirrorByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+	ctx.JSON(200, convert.ToPushMirror(m))
+}
+
+// AddPushMirror adds a push mirror to a repository
+func AddPushMirror(ctx *context.APIContext, form api.CreatePushMirrorOption) {
+	// swagger:operation POST /repos/{owner}/{repo}/push_mirrors repository repoAddPushMirror
+	// ---
+	// summary: Add a push mirror to a repository
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/CreatePushMirrorOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/PushMirror"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	interval := setting.Mirror.DefaultInterval
+	if form.Interval != "" {
+		var err error
+		interval, err = time.ParseDuration(form.Interval)
+		if err != nil || (interval != 0 && interval < setting.Mirror.MinInterval) {
+			ctx.Error(422, "", "invalid interval")
+			return
+		}
+	}
+
+	u, err := url.Parse(form.RemoteAddress)
+	if err != nil || u.Opaque != "" || u.Host == "" || !u.IsAbs() ||
+		!(u.Scheme == "http" || u.Scheme == "https" || u.Scheme == "git") {
+		ctx.Error(422, "", "invalid remote address")
+		return
+	}
+	if len(form.RemoteUsername)+len(form.RemotePassword) > 0 {
+		u.User = url.UserPassword(form.RemoteUsername, form.RemotePassword)
+	}
+
+	m := &models.PushMirror{
+		RepoID:   ctx.Repo.Repository.ID,
+		Repo:     ctx.Repo.Repository,
+		Interval: interval,
+	}
+	if err = models.InsertPushMirror(m, u.String()); err != nil {
+		ctx.Error(500, "InsertPushMirror", err)
+		return
+	}
+	ctx.JSON(201, convert.ToPushMirror(m))
+}
+
+// DeletePushMirror deletes a push mirror from a repository
+func DeletePushMirror(ctx *context.APIContext) {
+	// swagger:operation DELETE /repos/{owner}/{repo}/push_mirrors/{id} repository repoDeletePushMirror
+	// ---
+	// summary: Delete a push mirror from a repository
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the push mirror to delete
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	m := getPushMirrorByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+	if err := models.DeletePushMirror(m); err != nil {
+		ctx.Error(500, "DeletePushMirror", err)
+		return
+	}
+	ctx.Status(204)
+}
+
+// SyncPushMirror adds a push mirror to the sync queue
+func SyncPushMirror(ctx *context.APIContext) {
+	// swagger:operation POST /repos/{owner}/{repo}/push_mirrors/{id}/sync repository repoSyncPushMirror
+	// ---
+	// summary: Push a repository to one of its push mirrors
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the push mirror to sync
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/empty"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	m := getPushMirrorByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+	go models.PushMirrorQueue.Add(m.ID)
+	ctx.Status(200)
+}
+
+// getPushMirrorByParams returns the push mirror identified by the :id parameter
+// and writes a response if it could not be loaded.
+func getPushMirrorByParams(ctx *context.APIContext) *models.PushMirror {
+	m, err := models.GetPushMirrorByRepoIDAndID(ctx.Repo.Repository.ID, ctx.ParamsInt64(":id"))
+	if err != nil {
+		if models.IsErrPushMirrorNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(500, "GetPushMirrorByRepoIDAndID", err)
+		}
+		return nil
+	}
+	return m
+}
@@ -17,6 +17,7 @@ import (
 	"code.gitea.io/gitea/modules/migrations"
 	"code.gitea.io/gitea/modules/notification"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/task"
 	"code.gitea.io/gitea/modules/util"
 	"code.gitea.io/gitea/routers/api/v1/convert"
 
@@ -111,6 +112,9 @@ func Search(ctx *context.APIContext) {
 		UserID:      ctx.Data["SignedUserID"].(int64),
 		StarredByID: ctx.QueryInt64("starredBy"),
 	}
+	if token := ctx.AccessToken(); token != nil {
+		opts.RepoIDs = token.RepoIDs
+	}
 
 	if ctx.QueryBool("exclusive") {
 		opts.Collaborate = util.OptionalBoolFalse
@@ -255,6 +259,121 @@ func Create(ctx *context.APIContext, opt api.CreateRepoOption) {
 	CreateUserRepo(ctx, ctx.User, opt)
 }
 
+// Generate Create a repository using a template
+func Generate(ctx *context.APIContext, form api.GenerateRepoOption) {
+	// swagger:operation POST /repos/{template_owner}/{template_repo}/generate repository generateRepo
+	// ---
+	// summary: Create a repository using a template
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: template_owner
+	//   in: path
+	//   description: name of the template repository owner
+	//   type: string
+	//   required: true
+	// - name: template_repo
+	//   in: path
+	//   description: name of the template repository
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/GenerateRepoOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/Repository"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "409":
+	//     description: The repository with the same name already exists.
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	templateRepo := ctx.Repo.Repository
+	if !templateRepo.IsTemplate {
+		ctx.Error(422, "", "this is not a template repo")
+		return
+	}
+
+	opts := models.GenerateRepoOptions{
+		Name:        form.Name,
+		Description: form.Description,
+		Private:     form.Private || setting.Repository.ForcePrivate,
+		GitContent:  form.GitContent,
+		Topics:      form.Topics,
+		Webhooks:    form.Webhooks,
+		Avatar:      form.Avatar,
+		IssueLabels: form.Labels,
+	}
+	if !opts.IsValid() {
+		ctx.Error(422, "", "must select at least one template item")
+		return
+	}
+	// the webhooks may deliver to the services of the template owner
+	if opts.Webhooks && !ctx.Repo.IsAdmin() {
+		ctx.Error(403, "", "only administrators of the template can copy its webhooks")
+		return
+	}
+
+	ctxUser := ctx.User
+	var err error
+	if form.Owner != ctxUser.Name {
+		ctxUser, err = models.GetUserByName(form.Owner)
+		if err != nil {
+			if models.IsErrUserNotExist(err) {
+				ctx.NotFound()
+			} else {
+				ctx.Error(500, "GetUserByName", err)
+			}
+			return
+		}
+
+		if !ctx.User.IsAdmin && !ctxUser.IsOrganization() {
+			ctx.Error(403, "", "Only admin can generate repository for other user.")
+			return
+		}
+
+		if !ctx.User.IsAdmin {
+			isOwner, err := ctxUser.IsOwnedBy(ctx.User.ID)
+			if err != nil {
+				ctx.ServerError("IsOwnedBy", err)
+				return
+			} else if !isOwner {
+				ctx.Error(403, "", "Given user is not owner of organization.")
+				return
+			}
+		}
+	}
+
+	repo, err := models.GenerateRepository(ctx.User, ctxUser, templateRepo, opts)
+	if err != nil {
+		if models.IsErrRepoAlreadyExist(err) {
+			ctx.Error(409, "", "The repository with the same name already exists.")
+		} else if models.IsErrNameReserved(err) ||
+			models.IsErrNamePatternNotAllowed(err) {
+			ctx.Error(422, "", err)
+		} else {
+			if repo != nil {
+				if err := models.DeleteRepository(ctx.User, ctxUser.ID, repo.ID); err != nil {
+					log.Error("DeleteRepository: %v", err)
+				}
+			}
+			ctx.Error(500, "GenerateRepository", err)
+		}
+		return
+	}
+	log.Trace("Repository generated [%d]: %s/%s", repo.ID, ctxUser.Name, repo.Name)
+
+	notification.NotifyCreateRepository(ctx.User, ctxUser, repo)
+
+	ctx.JSON(201, repo.APIFormat(models.AccessModeOwner))
+}
+
 // CreateOrgRepo create one repository of the organization
 func CreateOrgRepo(ctx *context.APIContext, opt api.CreateRepoOption) {
 	// swagger:operation POST /org/{org}/repos organization createOrgRepo
@@ -387,20 +506,21 @@ func Migrate(ctx *context.APIContext, form auth.MigrateRepoForm) {
 	}
 
 	var opts = migrations.MigrateOptions{
-		RemoteURL:    remoteAddr,
-		Name:         form.RepoName,
-		Description:  form.Description,
-		Private:      form.Private || setting.Repository.ForcePrivate,
-		Mirror:       form.Mirror,
-		AuthUsername: form.AuthUsername,
-		AuthPassword: form.AuthPassword,
-		Wiki:         form.Wiki,
-		Issues:       form.Issues,
-		Milestones:   form.Milestones,
-		Labels:       form.Labels,
-		Comments:     true,
-		PullRequests: form.PullRequests,
-		Releases:     form.Releases,
+		RemoteURL:      remoteAddr,
+		Name:           form.RepoName,
+		Description:    form.Description,
+		Private:        form.Private || setting.Repository.ForcePrivate,
+		Mirror:         form.Mirror,
+		AuthUsername:   form.AuthUsername,
+		AuthPassword:   form.AuthPassword,
+		GitServiceType: form.Service,
+		Wiki:           form.Wiki,
+		Issues:         form.Issues,
+		Milestones:     form.Milestones,
+		Labels:         form.Labels,
+		Comments:       true,
+		PullRequests:   form.PullRequests,
+		Releases:       form.Releases,
 	}
 	if opts.Mirror {
 		opts.Issues = false
@@ -411,11 +531,9 @@ func Migrate(ctx *context.APIContext, form auth.MigrateRepoForm) {
 		opts.Releases = false
 	}
 
-	repo, err := migrations.MigrateRepository(ctx.User, ctxUser.Name, opts)
+	repo, err := task.MigrateRepository(ctx.User, ctxUser, opts)
 	if err == nil {
-		notification.NotifyCreateRepository(ctx.User, ctxUser, repo)
-
-		log.Trace("Repository migrated: %s/%s", ctxUser.Name, form.RepoName)
+		log.Trace("Repository migration queued: %s/%s", ctxUser.Name, form.RepoName)
 		ctx.JSON(201, repo.APIFormat(models.AccessModeAdmin))
 		return
 	}
@@ -423,16 +541,16 @@ func Migrate(ctx *context.APIContext, form auth.MigrateRepoForm) {
 	switch {
 	case models.IsErrRepoAlreadyExist(err):
 		ctx.Error(409, "", "The repository with the same name already exists.")
-	case migrations.IsRateLimitError(err):
-		ctx.Error(422, "", "Remote visit addressed rate limitation.")
-	case migrations.IsTwoFactorAuthError(err):
-		ctx.Error(422, "", "Remote visit required two factors authentication.")
 	case models.IsErrReachLimitOfRepo(err):
 		ctx.Error(422, "", fmt.Sprintf("You have already reached your limit of %d repositories.", ctxUser.MaxCreationLimit()))
 	case models.IsErrNameReserved(err):
 		ctx.Error(422, "", fmt.Sprintf("The username '%s' is reserved.", err.(models.ErrNameReserved).Name))
 	case models.IsErrNamePatternNotAllowed(err):
 		ctx.Error(422, "", fmt.Sprintf("The pattern '%s' is not allowed in a username.", err.(models.ErrNamePatternNotAllowed).Pattern))
+	case migrations.IsRateLimitError(err):
+		ctx.Error(422, "", "Remote visit addressed rate limitation.")
+	case migrations.IsTwoFactorAuthError(err):
+		ctx.Error(422, "", "Remote visit required two factors authentication.")
 	default:
 		err = util.URLSanitizedError(err, remoteAddr)
 		if strings.Contains(err.Error(), "Authentication failed") ||
@@ -447,6 +565,93 @@ func Migrate(ctx *context.APIContext, form auth.MigrateRepoForm) {
 	}
 }
 
+// GetMigration gets the status of the migration of a repository
+func GetMigration(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/migration repository repoGetMigration
+	// ---
+	// summary: Get the status of the migration of a repository
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/RepoTask"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	t, err := models.GetMigratingTask(ctx.Repo.Repository.ID)
+	if err != nil {
+		if models.IsErrTaskNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(500, "GetMigratingTask", err)
+		}
+		return
+	}
+	ctx.JSON(200, t.APIFormat())
+}
+
+// RetryMigration continues a failed migration of a repository from the stage it failed in
+func RetryMigration(ctx *context.APIContext, form api.RetryMigrationOption) {
+	// swagger:operation POST /repos/{owner}/{repo}/migration/retry repository repoRetryMigration
+	// ---
+	// summary: Retry the failed migration of a repository
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   description: the credentials of the remote repository, they are not kept when a migration fails
+	//   schema:
+	//     "$ref": "#/definitions/RetryMigrationOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/RepoTask"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	t, err := models.GetMigratingTask(ctx.Repo.Repository.ID)
+	if err != nil {
+		if models.IsErrTaskNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(500, "GetMigratingTask", err)
+		}
+		return
+	}
+	if t.Status != models.TaskStatusFailed {
+		ctx.Error(422, "", "The migration has not failed.")
+		return
+	}
+	if err := task.RetryMigrateTask(t, form.AuthUsername, form.AuthPassword); err != nil {
+		ctx.Error(500, "RetryMigrateTask", err)
+		return
+	}
+	ctx.JSON(200, t.APIFormat())
+}
+
 // Get one repository
 func Get(ctx *context.APIContext) {
 	// swagger:operation GET /repos/{owner}/{repo} repository repoGet
@@ -488,6 +693,8 @@ func GetByID(ctx *context.APIContext) {
 	// responses:
 	//   "200":
 	//     "$ref": "#/responses/Repository"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
 	repo, err := models.GetRepositoryByID(ctx.ParamsInt64(":id"))
 	if err != nil {
 		if models.IsErrRepoNotExist(err) {
@@ -506,6 +713,10 @@ func GetByID(ctx *context.APIContext) {
 		ctx.NotFound()
 		return
 	}
+	if token := ctx.AccessToken(); token != nil && !token.CanAccessRepo(repo.ID) {
+		ctx.Error(403, "", "The access token is restricted to other repositories")
+		return
+	}
 	ctx.JSON(200, repo.APIFormat(perm.AccessMode))
 }
 
@@ -609,6 +820,10 @@ func updateBasicProperties(ctx *context.APIContext, opts api.EditRepoOption) err
 		repo.Website = *opts.Website
 	}
 
+	if opts.Template != nil {
+		repo.IsTemplate = *opts.Template
+	}
+
 	visibilityChanged := false
 	if opts.Private != nil {
 		// Visibility of forked repository is forced sync with base repository.
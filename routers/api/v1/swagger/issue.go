@@ -84,3 +84,45 @@ type swaggerIssueDeadline struct {
 	// in:body
 	Body api.IssueDeadline `json:"body"`
 }
+
+// Project
+// swagger:response Project
+type swaggerResponseProject struct {
+	// in:body
+	Body api.Project `json:"body"`
+}
+
+// ProjectList
+// swagger:response ProjectList
+type swaggerResponseProjectList struct {
+	// in:body
+	Body []api.Project `json:"body"`
+}
+
+// ProjectBoard
+// swagger:response ProjectBoard
+type swaggerResponseProjectBoard struct {
+	// in:body
+	Body api.ProjectBoard `json:"body"`
+}
+
+// ProjectBoardList
+// swagger:response ProjectBoardList
+type swaggerResponseProjectBoardList struct {
+	// in:body
+	Body []api.ProjectBoard `json:"body"`
+}
+
+// ProjectCard
+// swagger:response ProjectCard
+type swaggerResponseProjectCard struct {
+	// in:body
+	Body api.ProjectCard `json:"body"`
+}
+
+// ProjectCardList
+// swagger:response ProjectCardList
+type swaggerResponseProjectCardList struct {
+	// in:body
+	Body []api.ProjectCard `json:"body"`
+}
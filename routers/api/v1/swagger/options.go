@@ -49,6 +49,9 @@ type swaggerParameterBodies struct {
 	// in:body
 	CreateKeyOption api.CreateKeyOption
 
+	// in:body
+	CreatePushMirrorOption api.CreatePushMirrorOption
+
 	// in:body
 	CreateLabelOption api.CreateLabelOption
 	// in:body
@@ -62,6 +65,17 @@ type swaggerParameterBodies struct {
 	// in:body
 	EditMilestoneOption api.EditMilestoneOption
 
+	// in:body
+	CreateProjectOption api.CreateProjectOption
+	// in:body
+	EditProjectOption api.EditProjectOption
+	// in:body
+	CreateProjectBoardOption api.CreateProjectBoardOption
+	// in:body
+	EditProjectBoardOption api.EditProjectBoardOption
+	// in:body
+	MoveProjectCardOption api.MoveProjectCardOption
+
 	// in:body
 	CreateOrgOption api.CreateOrgOption
 	// in:body
@@ -74,6 +88,13 @@ type swaggerParameterBodies struct {
 	// in:body
 	MergePullRequestOption auth.MergePullRequestForm
 
+	// in:body
+	CreatePullReviewOptions api.CreatePullReviewOptions
+	// in:body
+	SubmitPullReviewOptions api.SubmitPullReviewOptions
+	// in:body
+	DismissPullReviewOptions api.DismissPullReviewOptions
+
 	// in:body
 	CreateReleaseOption api.CreateReleaseOption
 	// in:body
@@ -84,6 +105,8 @@ type swaggerParameterBodies struct {
 	// in:body
 	EditRepoOption api.EditRepoOption
 	// in:body
+	GenerateRepoOption api.GenerateRepoOption
+	// in:body
 	CreateForkOption api.CreateForkOption
 
 	// in:body
@@ -106,6 +129,9 @@ type swaggerParameterBodies struct {
 	// in:body
 	MigrateRepoForm auth.MigrateRepoForm
 
+	// in:body
+	RetryMigrationOption api.RetryMigrationOption
+
 	// in:body
 	EditAttachmentOptions api.EditAttachmentOptions
 
@@ -15,6 +15,13 @@ type swaggerResponseRepository struct {
 	Body api.Repository `json:"body"`
 }
 
+// RepoTask
+// swagger:response RepoTask
+type swaggerResponseRepoTask struct {
+	// in:body
+	Body api.RepoTask `json:"body"`
+}
+
 // RepositoryList
 // swagger:response RepositoryList
 type swaggerResponseRepositoryList struct {
@@ -217,3 +224,38 @@ type swaggerFileDeleteResponse struct {
 	//in: body
 	Body api.FileDeleteResponse `json:"body"`
 }
+
+// PushMirror
+// swagger:response PushMirror
+type swaggerPushMirror struct {
+	// in:body
+	Body api.PushMirror `json:"body"`
+}
+
+// PushMirrorList
+// swagger:response PushMirrorList
+type swaggerPushMirrorList struct {
+	// in:body
+	Body []api.PushMirror `json:"body"`
+}
+
+// PullReview
+// swagger:response PullReview
+type swaggerPullReview struct {
+	// in:body
+	Body api.PullReview `json:"body"`
+}
+
+// PullReviewList
+// swagger:response PullReviewList
+type swaggerPullReviewList struct {
+	// in:body
+	Body []api.PullReview `json:"body"`
+}
+
+// PullReviewCommentList
+// swagger:response PullReviewCommentList
+type swaggerPullReviewCommentList struct {
+	// in:body
+	Body []api.PullReviewComment `json:"body"`
+}
@@ -6,6 +6,8 @@
 package user
 
 import (
+	"fmt"
+
 	api "code.gitea.io/gitea/modules/structs"
 
 	"code.gitea.io/gitea/models"
@@ -39,10 +41,10 @@ func ListAccessTokens(ctx *context.APIContext) {
 		if tokens[i].Name == "drone" {
 			tokens[i].Name = "drone-legacy-use-oauth2-instead"
 		}
-		apiTokens[i] = &api.AccessToken{
-			ID:             tokens[i].ID,
-			Name:           tokens[i].Name,
-			TokenLastEight: tokens[i].TokenLastEight,
+		apiTokens[i], err = tokens[i].APIFormat()
+		if err != nil {
+			ctx.Error(500, "APIFormat", err)
+			return
 		}
 	}
 	ctx.JSON(200, &apiTokens)
@@ -72,26 +74,73 @@ func CreateAccessToken(ctx *context.APIContext, form api.CreateAccessTokenOption
 	//     properties:
 	//       name:
 	//         type: string
+	//       scopes:
+	//         description: the scopes granted to the token, all the scopes are granted when it is empty
+	//         type: array
+	//         items:
+	//           type: string
+	//           enum: [all,repo:read,repo:write,admin,org,user,package]
+	//       repositories:
+	//         description: the full names of the repositories the token is restricted to
+	//         type: array
+	//         items:
+	//           type: string
 	// responses:
 	//   "200":
 	//     "$ref": "#/responses/AccessToken"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	scope, err := models.NormalizeAccessTokenScope(form.Scopes)
+	if err != nil {
+		ctx.Error(422, "", err)
+		return
+	}
+	repoIDs, err := models.GetRepositoryIDsByFullNames(form.Repositories)
+	if err != nil {
+		if models.IsErrRepoNotExist(err) {
+			ctx.Error(422, "", err)
+		} else {
+			ctx.Error(500, "GetRepositoryIDsByFullNames", err)
+		}
+		return
+	}
 	t := &models.AccessToken{
-		UID:  ctx.User.ID,
-		Name: form.Name,
+		UID:     ctx.User.ID,
+		Name:    form.Name,
+		Scope:   scope,
+		RepoIDs: repoIDs,
 	}
 	if t.Name == "drone" {
 		t.Name = "drone-legacy-use-oauth2-instead"
 	}
+
+	// A token cannot be used to create a token more powerful than itself
+	if current := ctx.AccessToken(); current != nil {
+		if current.IsRestricted() {
+			ctx.Error(403, "", "A token restricted to some repositories cannot create tokens")
+			return
+		}
+		for _, s := range t.Scopes() {
+			if !current.HasScope(s) {
+				ctx.Error(403, "", fmt.Sprintf("The access token is not granted the %s scope", s))
+				return
+			}
+		}
+	}
+
 	if err := models.NewAccessToken(t); err != nil {
 		ctx.Error(500, "NewAccessToken", err)
 		return
 	}
-	ctx.JSON(201, &api.AccessToken{
-		Name:           t.Name,
-		Token:          t.Token,
-		ID:             t.ID,
-		TokenLastEight: t.TokenLastEight,
-	})
+	apiToken, err := t.APIFormat()
+	if err != nil {
+		ctx.Error(500, "APIFormat", err)
+		return
+	}
+	apiToken.Token = t.Token
+	ctx.JSON(201, apiToken)
 }
 
 // DeleteAccessToken delete access tokens
@@ -7,6 +7,8 @@ package utils
 import (
 	"encoding/json"
 	"net/http"
+	"net/url"
+	"sort"
 	"strings"
 
 	"code.gitea.io/gitea/models"
@@ -48,6 +50,21 @@ func GetRepoHook(ctx *context.APIContext, repoID, hookID int64) (*models.Webhook
 	return w, nil
 }
 
+// GetSystemHook get a system webhook. If there is an error, write to `ctx`
+// accordingly and return the error
+func GetSystemHook(ctx *context.APIContext, hookID int64) (*models.Webhook, error) {
+	w, err := models.GetSystemWebhook(hookID)
+	if err != nil {
+		if models.IsErrWebhookNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(500, "GetSystemWebhook", err)
+		}
+		return nil, err
+	}
+	return w, nil
+}
+
 // CheckCreateHookOption check if a CreateHookOption form is valid. If invalid,
 // write the appropriate error to `ctx`. Return whether the form is valid
 func CheckCreateHookOption(ctx *context.APIContext, form *api.CreateHookOption) bool {
@@ -55,6 +72,11 @@ func CheckCreateHookOption(ctx *context.APIContext, form *api.CreateHookOption)
 		ctx.Error(422, "", "Invalid hook type")
 		return false
 	}
+	switch models.ToHookTaskType(form.Type) {
+	case models.MATRIX, models.CUSTOM:
+		// their config is checked when their meta is built
+		return true
+	}
 	for _, name := range []string{"url", "content_type"} {
 		if _, ok := form.Config[name]; !ok {
 			ctx.Error(422, "", "Missing config option: "+name)
@@ -71,7 +93,7 @@ func CheckCreateHookOption(ctx *context.APIContext, form *api.CreateHookOption)
 // AddOrgHook add a hook to an organization. Writes to `ctx` accordingly
 func AddOrgHook(ctx *context.APIContext, form *api.CreateHookOption) {
 	org := ctx.Org.Organization
-	hook, ok := addHook(ctx, form, org.ID, 0)
+	hook, ok := addHook(ctx, form, org.ID, 0, false)
 	if ok {
 		ctx.JSON(http.StatusCreated, convert.ToHook(org.HomeLink(), hook))
 	}
@@ -80,15 +102,23 @@ func AddOrgHook(ctx *context.APIContext, form *api.CreateHookOption) {
 // AddRepoHook add a hook to a repo. Writes to `ctx` accordingly
 func AddRepoHook(ctx *context.APIContext, form *api.CreateHookOption) {
 	repo := ctx.Repo
-	hook, ok := addHook(ctx, form, 0, repo.Repository.ID)
+	hook, ok := addHook(ctx, form, 0, repo.Repository.ID, false)
 	if ok {
 		ctx.JSON(http.StatusCreated, convert.ToHook(repo.RepoLink, hook))
 	}
 }
 
-// addHook add the hook specified by `form`, `orgID` and `repoID`. If there is
-// an error, write to `ctx` accordingly. Return (webhook, ok)
-func addHook(ctx *context.APIContext, form *api.CreateHookOption, orgID, repoID int64) (*models.Webhook, bool) {
+// AddSystemHook add a system hook. Writes to `ctx` accordingly
+func AddSystemHook(ctx *context.APIContext, form *api.CreateHookOption) {
+	hook, ok := addHook(ctx, form, 0, 0, true)
+	if ok {
+		ctx.JSON(http.StatusCreated, convert.ToSystemHook(hook))
+	}
+}
+
+// addHook add the hook specified by `form`, `orgID`, `repoID` and `isSystemWebhook`.
+// If there is an error, write to `ctx` accordingly. Return (webhook, ok)
+func addHook(ctx *context.APIContext, form *api.CreateHookOption, orgID, repoID int64, isSystemWebhook bool) (*models.Webhook, bool) {
 	if len(form.Events) == 0 {
 		form.Events = []string{"push"}
 	}
@@ -102,19 +132,30 @@ func addHook(ctx *context.APIContext, form *api.CreateHookOption, orgID, repoID
 		HookEvent: &models.HookEvent{
 			ChooseEvents: true,
 			HookEvents: models.HookEvents{
-				Create:       com.IsSliceContainsStr(form.Events, string(models.HookEventCreate)),
-				Delete:       com.IsSliceContainsStr(form.Events, string(models.HookEventDelete)),
-				Fork:         com.IsSliceContainsStr(form.Events, string(models.HookEventFork)),
-				Issues:       com.IsSliceContainsStr(form.Events, string(models.HookEventIssues)),
-				IssueComment: com.IsSliceContainsStr(form.Events, string(models.HookEventIssueComment)),
-				Push:         com.IsSliceContainsStr(form.Events, string(models.HookEventPush)),
-				PullRequest:  com.IsSliceContainsStr(form.Events, string(models.HookEventPullRequest)),
-				Repository:   com.IsSliceContainsStr(form.Events, string(models.HookEventRepository)),
-				Release:      com.IsSliceContainsStr(form.Events, string(models.HookEventRelease)),
+				Create:               com.IsSliceContainsStr(form.Events, string(models.HookEventCreate)),
+				Delete:               com.IsSliceContainsStr(form.Events, string(models.HookEventDelete)),
+				Fork:                 com.IsSliceContainsStr(form.Events, string(models.HookEventFork)),
+				Issues:               com.IsSliceContainsStr(form.Events, string(models.HookEventIssues)),
+				IssueAssign:          com.IsSliceContainsStr(form.Events, string(models.HookEventIssueAssign)),
+				IssueLabel:           com.IsSliceContainsStr(form.Events, string(models.HookEventIssueLabel)),
+				IssueMilestone:       com.IsSliceContainsStr(form.Events, string(models.HookEventIssueMilestone)),
+				IssueComment:         com.IsSliceContainsStr(form.Events, string(models.HookEventIssueComment)),
+				Push:                 com.IsSliceContainsStr(form.Events, string(models.HookEventPush)),
+				PullRequest:          com.IsSliceContainsStr(form.Events, string(models.HookEventPullRequest)),
+				PullRequestAssign:    com.IsSliceContainsStr(form.Events, string(models.HookEventPullRequestAssign)),
+				PullRequestLabel:     com.IsSliceContainsStr(form.Events, string(models.HookEventPullRequestLabel)),
+				PullRequestMilestone: com.IsSliceContainsStr(form.Events, string(models.HookEventPullRequestMilestone)),
+				PullRequestSync:      com.IsSliceContainsStr(form.Events, string(models.HookEventPullRequestSync)),
+				PullRequestComment:   com.IsSliceContainsStr(form.Events, string(models.HookEventPullRequestComment)),
+				Repository:           com.IsSliceContainsStr(form.Events, string(models.HookEventRepository)),
+				Release:              com.IsSliceContainsStr(form.Events, string(models.HookEventRelease)),
+				User:                 com.IsSliceContainsStr(form.Events, string(models.HookEventUser)),
+				Organization:         com.IsSliceContainsStr(form.Events, string(models.HookEventOrganization)),
 			},
 		},
-		IsActive:     form.Active,
-		HookTaskType: models.ToHookTaskType(form.Type),
+		IsActive:        form.Active,
+		HookTaskType:    models.ToHookTaskType(form.Type),
+		IsSystemWebhook: isSystemWebhook,
 	}
 	if w.HookTaskType == models.SLACK {
 		channel, ok := form.Config["channel"]
@@ -140,6 +181,9 @@ func addHook(ctx *context.APIContext, form *api.CreateHookOption, orgID, repoID
 		}
 		w.Meta = string(meta)
 	}
+	if !setHookMeta(ctx, w, form.Config) {
+		return nil, false
+	}
 
 	if err := w.UpdateEvent(); err != nil {
 		ctx.Error(500, "UpdateEvent", err)
@@ -151,6 +195,115 @@ func addHook(ctx *context.APIContext, form *api.CreateHookOption, orgID, repoID
 	return w, true
 }
 
+// setHookMeta validates the config of Matrix and custom hooks and builds their
+// meta from it, the config of an existing hook is merged into its meta. If the
+// config is invalid, write the appropriate error to `ctx`. Return whether it is valid
+func setHookMeta(ctx *context.APIContext, w *models.Webhook, config map[string]string) bool {
+	var meta interface{}
+	switch w.HookTaskType {
+	case models.MATRIX:
+		matrix := &models.MatrixMeta{}
+		if w.Meta != "" {
+			matrix = w.GetMatrixHook()
+		}
+		setConfigOption(config, "homeserver_url", &matrix.HomeserverURL)
+		setConfigOption(config, "room_id", &matrix.RoomID)
+		setConfigOption(config, "access_token", &matrix.AccessToken)
+		setConfigOption(config, "message_type", &matrix.MessageType)
+		if !checkConfigOptions(ctx, map[string]string{
+			"homeserver_url": matrix.HomeserverURL,
+			"room_id":        matrix.RoomID,
+			"access_token":   matrix.AccessToken,
+		}) {
+			return false
+		}
+		if !isValidHookURL(matrix.HomeserverURL) {
+			ctx.Error(422, "", "Invalid homeserver URL")
+			return false
+		}
+		if matrix.MessageType != "" && matrix.MessageType != models.MatrixMessageTypeNotice &&
+			matrix.MessageType != models.MatrixMessageTypeText {
+			ctx.Error(422, "", "Invalid message type")
+			return false
+		}
+		w.URL = models.MatrixRoomMessageURL(matrix.HomeserverURL, matrix.RoomID)
+		w.HTTPMethod = http.MethodPut
+		w.ContentType = models.ContentTypeJSON
+		meta = matrix
+	case models.CUSTOM:
+		custom := &models.CustomMeta{}
+		if w.Meta != "" {
+			custom = w.GetCustomHook()
+		}
+		setConfigOption(config, "template", &custom.Template)
+		setConfigOption(config, "content_type", &custom.ContentType)
+		if method, ok := config["http_method"]; ok {
+			w.HTTPMethod = strings.ToUpper(method)
+		}
+		if !checkConfigOptions(ctx, map[string]string{
+			"url":          w.URL,
+			"template":     custom.Template,
+			"content_type": custom.ContentType,
+		}) {
+			return false
+		}
+		if !isValidHookURL(w.URL) {
+			ctx.Error(422, "", "Invalid URL")
+			return false
+		}
+		if w.HTTPMethod != http.MethodPost && w.HTTPMethod != http.MethodPut {
+			ctx.Error(422, "", "Invalid HTTP method")
+			return false
+		}
+		if _, err := models.ParseCustomTemplate(custom.Template); err != nil {
+			ctx.Error(422, "", "Invalid template: "+err.Error())
+			return false
+		}
+		w.ContentType = models.ContentTypeJSON
+		meta = custom
+	default:
+		return true
+	}
+
+	data, err := json.Marshal(meta)
+	if err != nil {
+		ctx.Error(500, "JSON marshal failed", err)
+		return false
+	}
+	w.Meta = string(data)
+	return true
+}
+
+// setConfigOption sets value to the config option name if it is given
+func setConfigOption(config map[string]string, name string, value *string) {
+	if v, ok := config[name]; ok {
+		*value = strings.TrimSpace(v)
+	}
+}
+
+// checkConfigOptions writes an error to `ctx` if one of the required options is
+// empty. Return whether all of them are set
+func checkConfigOptions(ctx *context.APIContext, options map[string]string) bool {
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if options[name] == "" {
+			ctx.Error(422, "", "Missing config option: "+name)
+			return false
+		}
+	}
+	return true
+}
+
+// isValidHookURL returns true if rawurl is an absolute HTTP(S) URL
+func isValidHookURL(rawurl string) bool {
+	u, err := url.ParseRequestURI(rawurl)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
 // EditOrgHook edit webhook `w` according to `form`. Writes to `ctx` accordingly
 func EditOrgHook(ctx *context.APIContext, form *api.EditHookOption, hookID int64) {
 	org := ctx.Org.Organization
@@ -185,6 +338,22 @@ func EditRepoHook(ctx *context.APIContext, form *api.EditHookOption, hookID int6
 	ctx.JSON(200, convert.ToHook(repo.RepoLink, updated))
 }
 
+// EditSystemHook edit system webhook `w` according to `form`. Writes to `ctx` accordingly
+func EditSystemHook(ctx *context.APIContext, form *api.EditHookOption, hookID int64) {
+	hook, err := GetSystemHook(ctx, hookID)
+	if err != nil {
+		return
+	}
+	if !editHook(ctx, form, hook) {
+		return
+	}
+	updated, err := GetSystemHook(ctx, hookID)
+	if err != nil {
+		return
+	}
+	ctx.JSON(200, convert.ToSystemHook(updated))
+}
+
 // editHook edit the webhook `w` according to `form`. If an error occurs, write
 // to `ctx` accordingly and return the error. Return whether successful
 func editHook(ctx *context.APIContext, form *api.EditHookOption, w *models.Webhook) bool {
@@ -192,7 +361,7 @@ func editHook(ctx *context.APIContext, form *api.EditHookOption, w *models.Webho
 		if url, ok := form.Config["url"]; ok {
 			w.URL = url
 		}
-		if ct, ok := form.Config["content_type"]; ok {
+		if ct, ok := form.Config["content_type"]; ok && w.HookTaskType != models.CUSTOM {
 			if !models.IsValidHookContentType(ct) {
 				ctx.Error(422, "", "Invalid content type")
 				return false
@@ -215,6 +384,9 @@ func editHook(ctx *context.APIContext, form *api.EditHookOption, w *models.Webho
 				w.Meta = string(meta)
 			}
 		}
+		if !setHookMeta(ctx, w, form.Config) {
+			return false
+		}
 	}
 
 	// Update events
@@ -231,11 +403,21 @@ func editHook(ctx *context.APIContext, form *api.EditHookOption, w *models.Webho
 	w.Delete = com.IsSliceContainsStr(form.Events, string(models.HookEventDelete))
 	w.Fork = com.IsSliceContainsStr(form.Events, string(models.HookEventFork))
 	w.Issues = com.IsSliceContainsStr(form.Events, string(models.HookEventIssues))
+	w.IssueAssign = com.IsSliceContainsStr(form.Events, string(models.HookEventIssueAssign))
+	w.IssueLabel = com.IsSliceContainsStr(form.Events, string(models.HookEventIssueLabel))
+	w.IssueMilestone = com.IsSliceContainsStr(form.Events, string(models.HookEventIssueMilestone))
 	w.IssueComment = com.IsSliceContainsStr(form.Events, string(models.HookEventIssueComment))
 	w.Push = com.IsSliceContainsStr(form.Events, string(models.HookEventPush))
 	w.PullRequest = com.IsSliceContainsStr(form.Events, string(models.HookEventPullRequest))
+	w.PullRequestAssign = com.IsSliceContainsStr(form.Events, string(models.HookEventPullRequestAssign))
+	w.PullRequestLabel = com.IsSliceContainsStr(form.Events, string(models.HookEventPullRequestLabel))
+	w.PullRequestMilestone = com.IsSliceContainsStr(form.Events, string(models.HookEventPullRequestMilestone))
+	w.PullRequestSync = com.IsSliceContainsStr(form.Events, string(models.HookEventPullRequestSync))
+	w.PullRequestComment = com.IsSliceContainsStr(form.Events, string(models.HookEventPullRequestComment))
 	w.Repository = com.IsSliceContainsStr(form.Events, string(models.HookEventRepository))
 	w.Release = com.IsSliceContainsStr(form.Events, string(models.HookEventRelease))
+	w.User = com.IsSliceContainsStr(form.Events, string(models.HookEventUser))
+	w.Organization = com.IsSliceContainsStr(form.Events, string(models.HookEventOrganization))
 
 	if err := w.UpdateEvent(); err != nil {
 		ctx.Error(500, "UpdateEvent", err)
@@ -12,6 +12,7 @@ import (
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/indexer"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/search"
 	"code.gitea.io/gitea/modules/setting"
@@ -282,10 +283,19 @@ func ExploreCode(ctx *context.Context) {
 	ctx.Data["PageIsExploreCode"] = true
 
 	keyword := strings.TrimSpace(ctx.Query("q"))
+	language := strings.TrimSpace(ctx.Query("l"))
+	filename := strings.TrimSpace(ctx.Query("path"))
 	page := ctx.QueryInt("page")
 	if page <= 0 {
 		page = 1
 	}
+	searchOpts := &indexer.RepoSearchOptions{
+		Keyword:  keyword,
+		Language: language,
+		Filename: filename,
+		Page:     page,
+		PageSize: setting.UI.RepoSearchPagingNum,
+	}
 
 	var (
 		repoIDs []int64
@@ -331,14 +341,16 @@ func ExploreCode(ctx *context.Context) {
 
 		ctx.Data["RepoMaps"] = rightRepoMap
 
-		total, searchResults, err = search.PerformSearch(repoIDs, keyword, page, setting.UI.RepoSearchPagingNum)
+		searchOpts.RepoIDs = repoIDs
+		total, searchResults, err = search.PerformSearch(searchOpts)
 		if err != nil {
 			ctx.ServerError("SearchResults", err)
 			return
 		}
 		// if non-login user or isAdmin, no need to check UnitTypeCode
 	} else if (ctx.User == nil && len(repoIDs) > 0) || isAdmin {
-		total, searchResults, err = search.PerformSearch(repoIDs, keyword, page, setting.UI.RepoSearchPagingNum)
+		searchOpts.RepoIDs = repoIDs
+		total, searchResults, err = search.PerformSearch(searchOpts)
 		if err != nil {
 			ctx.ServerError("SearchResults", err)
 			return
@@ -368,12 +380,16 @@ func ExploreCode(ctx *context.Context) {
 	}
 
 	ctx.Data["Keyword"] = keyword
+	ctx.Data["Language"] = language
+	ctx.Data["Filename"] = filename
 	ctx.Data["SearchResults"] = searchResults
 	ctx.Data["RequireHighlightJS"] = true
 	ctx.Data["PageIsViewCode"] = true
 
 	pager := context.NewPagination(total, setting.UI.RepoSearchPagingNum, page, 5)
 	pager.SetDefaultParams(ctx)
+	pager.AddParam(ctx, "l", "Language")
+	pager.AddParam(ctx, "path", "Filename")
 	ctx.Data["Page"] = pager
 
 	ctx.HTML(200, tplExploreCode)
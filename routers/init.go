@@ -21,6 +21,9 @@ import (
 	"code.gitea.io/gitea/modules/markup/external"
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/ssh"
+	"code.gitea.io/gitea/modules/storage"
+	"code.gitea.io/gitea/modules/task"
+	"code.gitea.io/gitea/modules/webhook"
 
 	macaron "gopkg.in/macaron.v1"
 )
@@ -90,6 +93,10 @@ func GlobalInit() {
 			log.Fatal("Failed to initialize OAuth2 support: %v", err)
 		}
 
+		if err := storage.Init(); err != nil {
+			log.Fatal("Failed to initialize storage: %v", err)
+		}
+
 		models.NewRepoContext()
 
 		// Booting long running goroutines.
@@ -99,8 +106,13 @@ func GlobalInit() {
 		}
 		models.InitRepoIndexer()
 		models.InitSyncMirrors()
-		models.InitDeliverHooks()
+		if err := webhook.Init(); err != nil {
+			log.Fatal("Failed to initialize webhook delivery: %v", err)
+		}
 		models.InitTestPullRequests()
+		if err := task.Init(); err != nil {
+			log.Fatal("Failed to initialize task scheduler: %v", err)
+		}
 	}
 	if models.EnableSQLite3 {
 		log.Info("SQLite3 Supported")
@@ -15,6 +15,7 @@ import (
 	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/private"
+	"code.gitea.io/gitea/modules/pull"
 	"code.gitea.io/gitea/modules/repofiles"
 	"code.gitea.io/gitea/modules/util"
 
@@ -43,6 +44,10 @@ func HookPreReceive(ctx *macaron.Context) {
 		return
 	}
 	repo.OwnerName = ownerName
+	if strings.HasPrefix(refFullName, git.ForPrefix) {
+		hookPreReceiveAGit(ctx, repo, refFullName, newCommitID, parsePushOptions(ctx.QueryStrings("pushOption")))
+		return
+	}
 	protectBranch, err := models.GetProtectedBranchBy(repo.ID, branchName)
 	if err != nil {
 		log.Error("Unable to get protected branch: %s in %-v Error: %v", branchName, repo, err)
@@ -103,6 +108,13 @@ func HookPreReceive(ctx *macaron.Context) {
 				})
 				return
 			}
+			if !protectBranch.IsStatusCheckSuccess(pr) {
+				log.Warn("Forbidden: User %d cannot push to protected branch: %s in %-v and pr #%d does not pass the required status checks", userID, branchName, repo, pr.Index)
+				ctx.JSON(http.StatusForbidden, map[string]interface{}{
+					"err": fmt.Sprintf("protected branch %s can not be pushed to and pr #%d does not pass the required status checks", branchName, prID),
+				})
+				return
+			}
 		} else if !canPush {
 			log.Warn("Forbidden: User %d cannot push to protected branch: %s in %-v", userID, branchName, repo)
 			ctx.JSON(http.StatusForbidden, map[string]interface{}{
@@ -114,6 +126,61 @@ func HookPreReceive(ctx *macaron.Context) {
 	ctx.PlainText(http.StatusOK, []byte("ok"))
 }
 
+// hookPreReceiveAGit checks a push to refs/for/<base branch>/<topic> creating or updating a pull request
+func hookPreReceiveAGit(ctx *macaron.Context, repo *models.Repository, refFullName, newCommitID string, pushOptions map[string]string) {
+	if newCommitID == git.EmptySHA {
+		ctx.JSON(http.StatusForbidden, map[string]interface{}{
+			"err": fmt.Sprintf("%s can not be deleted", refFullName),
+		})
+		return
+	}
+	if !repo.AllowsPulls() {
+		ctx.JSON(http.StatusForbidden, map[string]interface{}{
+			"err": fmt.Sprintf("pull requests are disabled in %s/%s", repo.OwnerName, repo.Name),
+		})
+		return
+	}
+
+	gitRepo, err := git.OpenRepository(repo.RepoPath())
+	if err != nil {
+		log.Error("Unable to open repository %-v Error: %v", repo, err)
+		ctx.JSON(http.StatusInternalServerError, map[string]interface{}{
+			"err": fmt.Sprintf("Unable to open repository Error: %v", err),
+		})
+		return
+	}
+	if _, _, err := pull.ParseAGitRef(gitRepo, refFullName, pushOptions); err != nil {
+		if pull.IsErrInvalidAGitRef(err) {
+			log.Warn("Forbidden: %v in %-v", err, repo)
+			ctx.JSON(http.StatusForbidden, map[string]interface{}{
+				"err": err.Error(),
+			})
+			return
+		}
+		log.Error("Unable to parse %s in %-v Error: %v", refFullName, repo, err)
+		ctx.JSON(http.StatusInternalServerError, map[string]interface{}{
+			"err": fmt.Sprintf("Unable to parse %s Error: %v", refFullName, err),
+		})
+		return
+	}
+	ctx.PlainText(http.StatusOK, []byte("ok"))
+}
+
+// parsePushOptions returns the key=value options given with git push -o,
+// the options without a value are set to true.
+func parsePushOptions(options []string) map[string]string {
+	opts := make(map[string]string, len(options))
+	for _, option := range options {
+		kv := strings.SplitN(option, "=", 2)
+		if len(kv) == 2 {
+			opts[kv[0]] = kv[1]
+		} else {
+			opts[kv[0]] = "true"
+		}
+	}
+	return opts
+}
+
 // HookPostReceive updates services and users
 func HookPostReceive(ctx *macaron.Context) {
 	ownerName := ctx.Params(":owner")
@@ -124,6 +191,11 @@ func HookPostReceive(ctx *macaron.Context) {
 	userID := ctx.QueryInt64("userID")
 	userName := ctx.Query("username")
 
+	if strings.HasPrefix(refFullName, git.ForPrefix) {
+		hookPostReceiveAGit(ctx, ownerName, repoName, refFullName, newCommitID, userID, parsePushOptions(ctx.QueryStrings("pushOption")))
+		return
+	}
+
 	branch := refFullName
 	if strings.HasPrefix(refFullName, git.BranchPrefix) {
 		branch = strings.TrimPrefix(refFullName, git.BranchPrefix)
@@ -159,6 +231,8 @@ func HookPostReceive(ctx *macaron.Context) {
 			})
 			return
 		}
+
+		go models.AddPushMirrorsToQueue(repo.ID)
 	}
 
 	if newCommitID != git.EmptySHA && strings.HasPrefix(refFullName, git.BranchPrefix) {
@@ -233,3 +307,42 @@ func HookPostReceive(ctx *macaron.Context) {
 		"message": false,
 	})
 }
+
+// hookPostReceiveAGit creates or updates the pull request pushed to refs/for/<base branch>/<topic>
+func hookPostReceiveAGit(ctx *macaron.Context, ownerName, repoName, refFullName, newCommitID string, userID int64, pushOptions map[string]string) {
+	repo, err := models.GetRepositoryByOwnerAndName(ownerName, repoName)
+	if err != nil {
+		log.Error("Failed to get repository: %s/%s Error: %v", ownerName, repoName, err)
+		ctx.JSON(http.StatusInternalServerError, map[string]interface{}{
+			"err": fmt.Sprintf("Failed to get repository: %s/%s Error: %v", ownerName, repoName, err),
+		})
+		return
+	}
+	repo.OwnerName = ownerName
+
+	pusher, err := models.GetUserByID(userID)
+	if err != nil {
+		log.Error("Failed to get user %d Error: %v", userID, err)
+		ctx.JSON(http.StatusInternalServerError, map[string]interface{}{
+			"err": fmt.Sprintf("Failed to get user %d Error: %v", userID, err),
+		})
+		return
+	}
+
+	pr, created, err := pull.PushAGitPullRequest(repo, pusher, refFullName, newCommitID, pushOptions)
+	if err != nil {
+		log.Error("Failed to push pull request %s in %-v Error: %v", refFullName, repo, err)
+		ctx.JSON(http.StatusInternalServerError, map[string]interface{}{
+			"err": fmt.Sprintf("Failed to push pull request %s Error: %v", refFullName, err),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, map[string]interface{}{
+		"message": true,
+		"agit":    true,
+		"create":  created,
+		"branch":  pr.HeadBranch,
+		"url":     fmt.Sprintf("%s/pulls/%d", repo.HTMLURL(), pr.Index),
+	})
+}
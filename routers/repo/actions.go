@@ -0,0 +1,197 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+const (
+	tplActions         base.TplName = "repo/actions/list"
+	tplActionRun       base.TplName = "repo/actions/view"
+	tplSettingsRunners base.TplName = "repo/settings/runners"
+)
+
+// MustEnableActions check if actions are enabled, if not 404
+func MustEnableActions(ctx *context.Context) {
+	if !setting.Actions.Enabled {
+		ctx.NotFound("MustEnableActions", nil)
+	}
+}
+
+// Actions render the list of the workflow runs of a repository
+func Actions(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("repo.actions")
+	ctx.Data["PageIsActions"] = true
+
+	page := ctx.QueryInt("page")
+	if page <= 1 {
+		page = 1
+	}
+	opts := models.FindActionRunOptions{
+		Page:     page,
+		PageSize: setting.UI.IssuePagingNum,
+		RepoID:   ctx.Repo.Repository.ID,
+	}
+	statusName := ctx.Query("status")
+	if statusName != "" {
+		opts.Status, opts.FilterStatus = models.ActionStatusFromString(statusName)
+	}
+	ctx.Data["Status"] = statusName
+
+	runs, count, err := models.FindActionRuns(opts)
+	if err != nil {
+		ctx.ServerError("FindActionRuns", err)
+		return
+	}
+	ctx.Data["Runs"] = runs
+
+	pager := context.NewPagination(int(count), opts.PageSize, page, 5)
+	pager.SetDefaultParams(ctx)
+	pager.AddParam(ctx, "status", "Status")
+	ctx.Data["Page"] = pager
+
+	ctx.HTML(200, tplActions)
+}
+
+func getActionRun(ctx *context.Context) *models.ActionRun {
+	run, err := models.GetActionRunByRepoAndID(ctx.Repo.Repository.ID, ctx.ParamsInt64(":id"))
+	if err != nil {
+		if models.IsErrActionRunNotExist(err) {
+			ctx.NotFound("GetActionRunByRepoAndID", err)
+		} else {
+			ctx.ServerError("GetActionRunByRepoAndID", err)
+		}
+		return nil
+	}
+	run.Repo = ctx.Repo.Repository
+	if err = run.LoadAttributes(); err != nil {
+		ctx.ServerError("LoadAttributes", err)
+		return nil
+	}
+	return run
+}
+
+// actionRunJobView is a job of a run along with its log
+type actionRunJobView struct {
+	*models.ActionRunJob
+	Log string
+}
+
+// ActionRunView render a workflow run with the logs of its jobs
+func ActionRunView(ctx *context.Context) {
+	run := getActionRun(ctx)
+	if ctx.Written() {
+		return
+	}
+	ctx.Data["Title"] = run.Title
+	ctx.Data["PageIsActions"] = true
+	ctx.Data["Run"] = run
+
+	jobs, err := models.GetActionRunJobs(run.ID)
+	if err != nil {
+		ctx.ServerError("GetActionRunJobs", err)
+		return
+	}
+	views := make([]*actionRunJobView, 0, len(jobs))
+	for _, job := range jobs {
+		content, err := models.GetActionRunJobLog(job.ID)
+		if err != nil {
+			ctx.ServerError("GetActionRunJobLog", err)
+			return
+		}
+		views = append(views, &actionRunJobView{
+			ActionRunJob: job,
+			Log:          content,
+		})
+	}
+	ctx.Data["Jobs"] = views
+
+	ctx.HTML(200, tplActionRun)
+}
+
+// ActionRunCancel cancels the jobs of a workflow run which are not done yet
+func ActionRunCancel(ctx *context.Context) {
+	run := getActionRun(ctx)
+	if ctx.Written() {
+		return
+	}
+	if err := models.CancelActionRun(run); err != nil {
+		ctx.ServerError("CancelActionRun", err)
+		return
+	}
+	log.Trace("Workflow run %d of %s cancelled by %s", run.ID, ctx.Repo.Repository.FullName(), ctx.User.Name)
+	ctx.Redirect(run.Link())
+}
+
+// ActionRunApprove lets the runners run the jobs of a workflow run waiting for approval
+func ActionRunApprove(ctx *context.Context) {
+	run := getActionRun(ctx)
+	if ctx.Written() {
+		return
+	}
+	if err := models.ApproveActionRun(run, ctx.User); err != nil {
+		ctx.ServerError("ApproveActionRun", err)
+		return
+	}
+	log.Trace("Workflow run %d of %s approved by %s", run.ID, ctx.Repo.Repository.FullName(), ctx.User.Name)
+	ctx.Redirect(run.Link())
+}
+
+// SettingsRunners render the registration token and the runners of a repository
+func SettingsRunners(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("repo.settings.runners")
+	ctx.Data["PageIsSettingsRunners"] = true
+
+	token, err := models.GetActionRunnerToken(ctx.Repo.Repository.ID)
+	if err != nil {
+		ctx.ServerError("GetActionRunnerToken", err)
+		return
+	}
+	ctx.Data["RegistrationToken"] = token.Token
+
+	runners, err := models.GetActionRunnersByRepoID(ctx.Repo.Repository.ID)
+	if err != nil {
+		ctx.ServerError("GetActionRunnersByRepoID", err)
+		return
+	}
+	ctx.Data["Runners"] = runners
+
+	ctx.HTML(200, tplSettingsRunners)
+}
+
+// ResetRunnerTokenPost replaces the registration token of a repository
+func ResetRunnerTokenPost(ctx *context.Context) {
+	if _, err := models.ResetActionRunnerToken(ctx.Repo.Repository.ID); err != nil {
+		ctx.ServerError("ResetActionRunnerToken", err)
+		return
+	}
+	ctx.Flash.Success(ctx.Tr("repo.settings.runners.reset_token_success"))
+	ctx.Redirect(ctx.Repo.RepoLink + "/settings/runners")
+}
+
+// DeleteRunner deletes a runner of a repository
+func DeleteRunner(ctx *context.Context) {
+	runner, err := models.GetActionRunnerByID(ctx.QueryInt64("id"))
+	if err != nil && !models.IsErrActionRunnerNotExist(err) {
+		ctx.ServerError("GetActionRunnerByID", err)
+		return
+	}
+	if err == nil && runner.RepoID == ctx.Repo.Repository.ID {
+		if err = models.DeleteActionRunner(runner); err != nil {
+			ctx.Flash.Error("DeleteActionRunner: " + err.Error())
+		} else {
+			ctx.Flash.Success(ctx.Tr("repo.settings.runners.deletion_success"))
+		}
+	}
+
+	ctx.JSON(200, map[string]interface{}{
+		"redirect": ctx.Repo.RepoLink + "/settings/runners",
+	})
+}
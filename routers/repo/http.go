@@ -169,11 +169,26 @@ func HTTP(ctx *context.Context) {
 				// Assume password is token
 				authToken = authPasswd
 			}
-			uid := auth.CheckOAuthAccessToken(authToken)
-			if uid != 0 {
+			// The token of a running job only grants cloning the repository of the job
+			if job, err := models.GetRunningActionRunJobByToken(authToken); err == nil {
+				if !isPull || isWiki || job.RepoID != repo.ID {
+					ctx.HandleText(http.StatusForbidden, "Job token permission denied")
+					return
+				}
+				HTTPBackend(ctx, &serviceConfig{
+					UploadPack: true,
+				})(ctx.Resp, ctx.Req.Request)
+				return
+			} else if !models.IsErrActionRunJobNotExist(err) {
+				ctx.ServerError("GetRunningActionRunJobByToken", err)
+				return
+			}
+
+			if grant, scope := auth.ParseOAuthAccessToken(authToken); grant != nil {
 				ctx.Data["IsApiToken"] = true
+				ctx.Data["AccessToken"] = auth.OAuthAccessTokenScope(grant, scope)
 
-				authUser, err = models.GetUserByID(uid)
+				authUser, err = models.GetUserByID(grant.UserID)
 				if err != nil {
 					ctx.ServerError("GetUserByID", err)
 					return
@@ -207,6 +222,7 @@ func HTTP(ctx *context.Context) {
 				if err = models.UpdateAccessToken(token); err != nil {
 					ctx.ServerError("UpdateAccessToken", err)
 				}
+				ctx.Data["AccessToken"] = token
 			} else if !models.IsErrAccessTokenNotExist(err) && !models.IsErrAccessTokenEmpty(err) {
 				log.Error("GetAccessTokenBySha: %v", err)
 			}
@@ -252,6 +268,17 @@ func HTTP(ctx *context.Context) {
 			return
 		}
 
+		if token := ctx.AccessToken(); token != nil {
+			scope := models.AccessTokenScopeRepoWrite
+			if isPull {
+				scope = models.AccessTokenScopeRepoRead
+			}
+			if !token.HasScope(scope) || !token.CanAccessRepo(repo.ID) {
+				ctx.HandleText(http.StatusForbidden, "Access token permission denied")
+				return
+			}
+		}
+
 		if !isPull && repo.IsMirror {
 			ctx.HandleText(http.StatusForbidden, "mirror repository is read-only")
 			return
@@ -312,6 +339,16 @@ func (h *serviceHandler) setHeaderCacheForever() {
 	h.w.Header().Set("Cache-Control", "public, max-age=31536000")
 }
 
+// gitEnviron returns the environment of the git commands serving the request, the wire
+// protocol requested by the client in the Git-Protocol header is passed on to git.
+func (h *serviceHandler) gitEnviron() []string {
+	environ := append(os.Environ(), h.environ...)
+	if protocol := h.r.Header.Get("Git-Protocol"); setting.Git.EnableAutoGitWireProtocol && git.IsValidProtocol(protocol) {
+		environ = append(environ, git.EnvProtocol+"="+protocol)
+	}
+	return environ
+}
+
 func (h *serviceHandler) sendFile(contentType string) {
 	reqFile := path.Join(h.dir, h.file)
 
@@ -420,9 +457,7 @@ func serviceRPC(h serviceHandler, service string) {
 	var stderr bytes.Buffer
 	cmd := exec.Command(git.GitExecutable, service, "--stateless-rpc", h.dir)
 	cmd.Dir = h.dir
-	if service == "receive-pack" {
-		cmd.Env = append(os.Environ(), h.environ...)
-	}
+	cmd.Env = h.gitEnviron()
 	cmd.Stdout = h.w
 	cmd.Stdin = reqBody
 	cmd.Stderr = &stderr
@@ -468,7 +503,7 @@ func getInfoRefs(h serviceHandler) {
 	h.setHeaderNoCache()
 	if hasAccess(getServiceType(h.r), h, false) {
 		service := getServiceType(h.r)
-		refs, err := git.NewCommand(service, "--stateless-rpc", "--advertise-refs", ".").RunInDirBytes(h.dir)
+		refs, err := git.NewCommand(service, "--stateless-rpc", "--advertise-refs", ".").RunInDirTimeoutEnv(h.gitEnviron(), -1, h.dir)
 		if err != nil {
 			log.Error(fmt.Sprintf("%v - %s", err, string(refs)))
 		}
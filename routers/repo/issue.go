@@ -242,9 +242,9 @@ func issues(ctx *context.Context, milestoneID int64, isPullOption util.OptionalB
 		return
 	}
 
-	labels, err := models.GetLabelsByRepoID(repo.ID, "")
+	labels, err := models.GetAvailableLabelsByRepoID(repo.ID, "")
 	if err != nil {
-		ctx.ServerError("GetLabelsByRepoID", err)
+		ctx.ServerError("GetAvailableLabelsByRepoID", err)
 		return
 	}
 	for _, l := range labels {
@@ -347,9 +347,9 @@ func RetrieveRepoMetas(ctx *context.Context, repo *models.Repository) []*models.
 		return nil
 	}
 
-	labels, err := models.GetLabelsByRepoID(repo.ID, "")
+	labels, err := models.GetAvailableLabelsByRepoID(repo.ID, "")
 	if err != nil {
-		ctx.ServerError("GetLabelsByRepoID", err)
+		ctx.ServerError("GetAvailableLabelsByRepoID", err)
 		return nil
 	}
 	ctx.Data["Labels"] = labels
@@ -693,9 +693,9 @@ func ViewIssue(ctx *context.Context) {
 	for i := range issue.Labels {
 		labelIDMark[issue.Labels[i].ID] = true
 	}
-	labels, err := models.GetLabelsByRepoID(repo.ID, "")
+	labels, err := models.GetAvailableLabelsByRepoID(repo.ID, "")
 	if err != nil {
-		ctx.ServerError("GetLabelsByRepoID", err)
+		ctx.ServerError("GetAvailableLabelsByRepoID", err)
 		return
 	}
 	hasSelected := false
@@ -716,6 +716,11 @@ func ViewIssue(ctx *context.Context) {
 		}
 	}
 
+	retrieveIssueProjects(ctx, repo, issue)
+	if ctx.Written() {
+		return
+	}
+
 	if ctx.IsSigned {
 		// Update issue-user.
 		if err = issue.ReadBy(ctx.User.ID); err != nil {
@@ -863,6 +868,21 @@ func ViewIssue(ctx *context.Context) {
 				ctx.ServerError("Review.LoadCodeComments", err)
 				return
 			}
+		} else if comment.Type == models.CommentTypeDismissReview {
+			if err = comment.LoadReview(); err != nil && !models.IsErrReviewNotExist(err) {
+				ctx.ServerError("LoadReview", err)
+				return
+			}
+			if comment.Review == nil {
+				continue
+			}
+			if err = comment.Review.LoadAttributes(); err != nil {
+				if !models.IsErrUserNotExist(err) {
+					ctx.ServerError("Review.LoadAttributes", err)
+					return
+				}
+				comment.Review.Reviewer = models.NewGhostUser()
+			}
 		}
 	}
 
@@ -874,7 +894,7 @@ func ViewIssue(ctx *context.Context) {
 		if ctx.IsSigned {
 			if err := pull.GetHeadRepo(); err != nil {
 				log.Error("GetHeadRepo: %v", err)
-			} else if pull.HeadRepo != nil && pull.HeadBranch != pull.HeadRepo.DefaultBranch {
+			} else if pull.HeadRepo != nil && !pull.IsAGitFlow() && pull.HeadBranch != pull.HeadRepo.DefaultBranch {
 				perm, err := models.GetUserRepoPermission(pull.HeadRepo, ctx.User)
 				if err != nil {
 					ctx.ServerError("GetUserRepoPermission", err)
@@ -931,6 +951,7 @@ func ViewIssue(ctx *context.Context) {
 			cnt := pull.ProtectedBranch.GetGrantedApprovalsCount(pull)
 			ctx.Data["IsBlockedByApprovals"] = pull.ProtectedBranch.RequiredApprovals > 0 && cnt < pull.ProtectedBranch.RequiredApprovals
 			ctx.Data["GrantedApprovals"] = cnt
+			ctx.Data["IsBlockedByStatusCheck"] = !pull.ProtectedBranch.IsStatusCheckSuccess(pull)
 		}
 		ctx.Data["IsPullBranchDeletable"] = canDelete && pull.HeadRepo != nil && git.IsBranchExist(pull.HeadRepo.RepoPath(), pull.HeadBranch)
 
@@ -1233,7 +1254,11 @@ func NewComment(ctx *context.Context, form auth.CreateCommentForm) {
 			if form.Status == "reopen" && issue.IsPull {
 				pull := issue.PullRequest
 				var err error
-				pr, err = models.GetUnmergedPullRequest(pull.HeadRepoID, pull.BaseRepoID, pull.HeadBranch, pull.BaseBranch)
+				if pull.IsAGitFlow() {
+					pr, err = models.GetUnmergedAGitPullRequest(pull.BaseRepoID, issue.PosterID, pull.HeadBranch, pull.BaseBranch)
+				} else {
+					pr, err = models.GetUnmergedPullRequest(pull.HeadRepoID, pull.BaseRepoID, pull.HeadBranch, pull.BaseBranch)
+				}
 				if err != nil {
 					if !models.IsErrPullRequestNotExist(err) {
 						ctx.ServerError("GetUnmergedPullRequest", err)
@@ -69,6 +69,16 @@ func RetrieveLabels(ctx *context.Context) {
 	ctx.Data["Labels"] = labels
 	ctx.Data["NumLabels"] = len(labels)
 	ctx.Data["SortType"] = ctx.Query("sort")
+
+	orgLabels, err := models.GetLabelsByOrgID(ctx.Repo.Repository.OwnerID, ctx.Query("sort"))
+	if err != nil {
+		ctx.ServerError("RetrieveLabels.GetLabelsByOrgID", err)
+		return
+	}
+	for _, l := range orgLabels {
+		l.CalOpenIssues()
+	}
+	ctx.Data["OrgLabels"] = orgLabels
 }
 
 // NewLabel create new label for repository
@@ -97,7 +107,7 @@ func NewLabel(ctx *context.Context, form auth.CreateLabelForm) {
 
 // UpdateLabel update a label's name and color
 func UpdateLabel(ctx *context.Context, form auth.CreateLabelForm) {
-	l, err := models.GetLabelByID(form.ID)
+	l, err := models.GetLabelInRepoByID(ctx.Repo.Repository.ID, form.ID)
 	if err != nil {
 		switch {
 		case models.IsErrLabelNotExist(err):
@@ -157,6 +167,11 @@ func UpdateIssueLabel(ctx *context.Context) {
 			return
 		}
 
+		if !label.IsUsableIn(ctx.Repo.Repository) {
+			ctx.Error(404, "IsUsableIn")
+			return
+		}
+
 		if action == "toggle" {
 			// detach if any issues already have label, otherwise attach
 			action = "attach"
@@ -0,0 +1,105 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/auth"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/task"
+)
+
+const (
+	tplMigrating base.TplName = "repo/migrating"
+)
+
+func getMigratingTask(ctx *context.Context) *models.Task {
+	t, err := models.GetMigratingTask(ctx.Repo.Repository.ID)
+	if err != nil {
+		if models.IsErrTaskNotExist(err) {
+			ctx.NotFound("GetMigratingTask", err)
+		} else {
+			ctx.ServerError("GetMigratingTask", err)
+		}
+		return nil
+	}
+	return t
+}
+
+// Migrating renders the progress of the migration of a repository
+func Migrating(ctx *context.Context) {
+	if !ctx.Repo.Repository.IsBeingMigrated() {
+		ctx.Redirect(ctx.Repo.RepoLink)
+		return
+	}
+
+	t := getMigratingTask(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	ctx.Data["Task"] = t
+	ctx.Data["IsRepositoryOwner"] = ctx.Repo.IsOwner()
+	ctx.HTML(200, tplMigrating)
+}
+
+// MigratingStatus responds with the status of the migration of a repository
+func MigratingStatus(ctx *context.Context) {
+	t := getMigratingTask(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	ctx.JSON(200, t.APIFormat())
+}
+
+// MigratingRetry continues a failed migration from the stage it failed in
+func MigratingRetry(ctx *context.Context, form auth.MigrateRetryForm) {
+	t := getMigratingTask(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if t.Status != models.TaskStatusFailed {
+		ctx.Flash.Error(ctx.Tr("repo.migrate.task_not_failed"))
+	} else if err := task.RetryMigrateTask(t, form.AuthUsername, form.AuthPassword); err != nil {
+		ctx.ServerError("RetryMigrateTask", err)
+		return
+	} else {
+		log.Trace("Repository migration retried [%d]: %s", ctx.Repo.Repository.ID, ctx.Repo.Repository.FullName())
+	}
+
+	ctx.Redirect(ctx.Repo.RepoLink + "/migrating")
+}
+
+// MigratingDelete deletes a repository whose migration has failed
+func MigratingDelete(ctx *context.Context) {
+	if !ctx.Repo.IsOwner() {
+		ctx.Error(404)
+		return
+	}
+
+	t := getMigratingTask(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if t.Status != models.TaskStatusFailed {
+		ctx.Flash.Error(ctx.Tr("repo.migrate.task_not_failed"))
+		ctx.Redirect(ctx.Repo.RepoLink + "/migrating")
+		return
+	}
+
+	if err := models.DeleteRepository(ctx.User, ctx.Repo.Owner.ID, ctx.Repo.Repository.ID); err != nil {
+		ctx.ServerError("DeleteRepository", err)
+		return
+	}
+	log.Trace("Repository deleted: %s/%s", ctx.Repo.Owner.Name, ctx.Repo.Repository.Name)
+
+	ctx.Flash.Success(ctx.Tr("repo.settings.deletion_success"))
+	ctx.Redirect(ctx.Repo.Owner.DashboardLink())
+}
@@ -0,0 +1,617 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/auth"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/markup/markdown"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
+)
+
+const (
+	tplProjects    base.TplName = "repo/projects/list"
+	tplProjectNew  base.TplName = "repo/projects/new"
+	tplProjectView base.TplName = "repo/projects/view"
+)
+
+// projectsCtx is the repository or the organization owning the projects of the page
+type projectsCtx struct {
+	RepoID   int64
+	OwnerID  int64
+	Link     string
+	CanWrite bool
+}
+
+// getProjectsCtx determines whether these are the projects of a repository or an organization.
+func getProjectsCtx(ctx *context.Context) *projectsCtx {
+	canWrite, _ := ctx.Data["CanWriteProjects"].(bool)
+	if len(ctx.Repo.RepoLink) > 0 {
+		return &projectsCtx{
+			RepoID:   ctx.Repo.Repository.ID,
+			Link:     ctx.Repo.RepoLink + "/projects",
+			CanWrite: canWrite,
+		}
+	}
+	return &projectsCtx{
+		OwnerID:  ctx.Org.Organization.ID,
+		Link:     ctx.Org.OrgLink + "/projects",
+		CanWrite: canWrite,
+	}
+}
+
+// ProjectsAssignment checks the access to the projects of the repository or the organization
+// and sets the permission to manage them.
+func ProjectsAssignment(ctx *context.Context) {
+	var canWrite bool
+	if len(ctx.Repo.RepoLink) > 0 {
+		canWrite = ctx.Repo.CanWrite(models.UnitTypeProjects) && !ctx.Repo.Repository.IsArchived
+	} else {
+		org := ctx.Org.Organization
+		if !models.HasOrgVisible(org, ctx.User) {
+			ctx.NotFound("HasOrgVisible", nil)
+			return
+		}
+		var err error
+		if canWrite, err = models.CanWriteOrgProjects(org, ctx.User); err != nil {
+			ctx.ServerError("CanWriteOrgProjects", err)
+			return
+		}
+		ctx.Data["PageIsOrgProjects"] = true
+	}
+	ctx.Data["PageIsProjects"] = true
+	ctx.Data["CanWriteProjects"] = canWrite
+	ctx.Data["ProjectsLink"] = getProjectsCtx(ctx).Link
+}
+
+// MustWriteProjects checks if the user can manage the projects, if not 404
+func MustWriteProjects(ctx *context.Context) {
+	if !getProjectsCtx(ctx).CanWrite {
+		ctx.NotFound("MustWriteProjects", nil)
+	}
+}
+
+func renderProjectDescription(ctx *context.Context, p *models.Project) {
+	if p.IsOrgProject() {
+		p.RenderedDescription = string(markdown.Render([]byte(p.Description), ctx.Org.OrgLink, nil))
+	} else {
+		p.RenderedDescription = string(markdown.Render([]byte(p.Description), ctx.Repo.RepoLink, ctx.Repo.Repository.ComposeMetas()))
+	}
+}
+
+// getProject returns the project of the :id parameter in the repository or the organization
+func getProject(ctx *context.Context, id int64) *models.Project {
+	pCtx := getProjectsCtx(ctx)
+
+	var p *models.Project
+	var err error
+	if pCtx.RepoID > 0 {
+		p, err = models.GetProjectByRepoID(pCtx.RepoID, id)
+	} else {
+		p, err = models.GetProjectByOwnerID(pCtx.OwnerID, id)
+	}
+	if err != nil {
+		if models.IsErrProjectNotExist(err) {
+			ctx.NotFound("", err)
+		} else {
+			ctx.ServerError("GetProject", err)
+		}
+		return nil
+	}
+	if err = p.LoadAttributes(); err != nil {
+		ctx.ServerError("LoadAttributes", err)
+		return nil
+	}
+	return p
+}
+
+// Projects renders the projects page
+func Projects(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("repo.projects")
+	pCtx := getProjectsCtx(ctx)
+
+	isShowClosed := ctx.Query("state") == "closed"
+	opts := models.ProjectSearchOptions{
+		RepoID:  pCtx.RepoID,
+		OwnerID: pCtx.OwnerID,
+	}
+
+	opts.IsClosed = util.OptionalBoolFalse
+	openCount, err := models.CountProjects(opts)
+	if err != nil {
+		ctx.ServerError("CountProjects", err)
+		return
+	}
+	opts.IsClosed = util.OptionalBoolTrue
+	closedCount, err := models.CountProjects(opts)
+	if err != nil {
+		ctx.ServerError("CountProjects", err)
+		return
+	}
+	ctx.Data["OpenCount"] = openCount
+	ctx.Data["ClosedCount"] = closedCount
+
+	page := ctx.QueryInt("page")
+	if page <= 1 {
+		page = 1
+	}
+
+	var total int
+	if !isShowClosed {
+		total = int(openCount)
+	} else {
+		total = int(closedCount)
+	}
+
+	opts.IsClosed = util.OptionalBoolOf(isShowClosed)
+	opts.Page = page
+	opts.PageSize = setting.UI.IssuePagingNum
+	projects, err := models.FindProjects(opts)
+	if err != nil {
+		ctx.ServerError("FindProjects", err)
+		return
+	}
+	if err = projects.LoadAttributes(); err != nil {
+		ctx.ServerError("LoadAttributes", err)
+		return
+	}
+	for _, p := range projects {
+		renderProjectDescription(ctx, p)
+	}
+	ctx.Data["Projects"] = projects
+
+	if isShowClosed {
+		ctx.Data["State"] = "closed"
+	} else {
+		ctx.Data["State"] = "open"
+	}
+	ctx.Data["IsShowClosed"] = isShowClosed
+
+	pager := context.NewPagination(total, setting.UI.IssuePagingNum, page, 5)
+	pager.AddParam(ctx, "state", "State")
+	ctx.Data["Page"] = pager
+
+	ctx.HTML(200, tplProjects)
+}
+
+// NewProject renders the page to create a project
+func NewProject(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("repo.projects.new")
+	ctx.HTML(200, tplProjectNew)
+}
+
+// NewProjectPost creates a project
+func NewProjectPost(ctx *context.Context, form auth.CreateProjectForm) {
+	ctx.Data["Title"] = ctx.Tr("repo.projects.new")
+	pCtx := getProjectsCtx(ctx)
+
+	if ctx.HasError() {
+		ctx.HTML(200, tplProjectNew)
+		return
+	}
+
+	if err := models.NewProject(&models.Project{
+		RepoID:      pCtx.RepoID,
+		OwnerID:     pCtx.OwnerID,
+		CreatorID:   ctx.User.ID,
+		Title:       form.Title,
+		Description: form.Content,
+	}); err != nil {
+		ctx.ServerError("NewProject", err)
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("repo.projects.create_success", form.Title))
+	ctx.Redirect(pCtx.Link)
+}
+
+// EditProject renders the page to edit a project
+func EditProject(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("repo.projects.edit")
+	ctx.Data["PageIsEditProject"] = true
+
+	p := getProject(ctx, ctx.ParamsInt64(":id"))
+	if ctx.Written() {
+		return
+	}
+	ctx.Data["title"] = p.Title
+	ctx.Data["content"] = p.Description
+	ctx.HTML(200, tplProjectNew)
+}
+
+// EditProjectPost updates a project
+func EditProjectPost(ctx *context.Context, form auth.CreateProjectForm) {
+	ctx.Data["Title"] = ctx.Tr("repo.projects.edit")
+	ctx.Data["PageIsEditProject"] = true
+
+	if ctx.HasError() {
+		ctx.HTML(200, tplProjectNew)
+		return
+	}
+
+	p := getProject(ctx, ctx.ParamsInt64(":id"))
+	if ctx.Written() {
+		return
+	}
+	p.Title = form.Title
+	p.Description = form.Content
+	if err := models.UpdateProject(p); err != nil {
+		ctx.ServerError("UpdateProject", err)
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("repo.projects.edit_success", p.Title))
+	ctx.Redirect(getProjectsCtx(ctx).Link)
+}
+
+// ChangeProjectStatus opens or closes a project
+func ChangeProjectStatus(ctx *context.Context) {
+	p := getProject(ctx, ctx.ParamsInt64(":id"))
+	if ctx.Written() {
+		return
+	}
+
+	link := getProjectsCtx(ctx).Link
+	switch ctx.Params(":action") {
+	case "open":
+		if p.IsClosed {
+			if err := models.ChangeProjectStatus(p, false); err != nil {
+				ctx.ServerError("ChangeProjectStatus", err)
+				return
+			}
+		}
+		ctx.Redirect(link + "?state=open")
+	case "close":
+		if !p.IsClosed {
+			if err := models.ChangeProjectStatus(p, true); err != nil {
+				ctx.ServerError("ChangeProjectStatus", err)
+				return
+			}
+		}
+		ctx.Redirect(link + "?state=closed")
+	default:
+		ctx.Redirect(link)
+	}
+}
+
+// DeleteProject deletes a project
+func DeleteProject(ctx *context.Context) {
+	pCtx := getProjectsCtx(ctx)
+
+	p := getProject(ctx, ctx.QueryInt64("id"))
+	if ctx.Written() {
+		return
+	}
+	if err := models.DeleteProjectByID(p.ID); err != nil {
+		ctx.Flash.Error("DeleteProjectByID: " + err.Error())
+	} else {
+		ctx.Flash.Success(ctx.Tr("repo.projects.deletion_success"))
+	}
+
+	ctx.JSON(200, map[string]interface{}{
+		"redirect": pCtx.Link,
+	})
+}
+
+// ViewProject renders the boards of a project with their cards
+func ViewProject(ctx *context.Context) {
+	p := getProject(ctx, ctx.ParamsInt64(":id"))
+	if ctx.Written() {
+		return
+	}
+	renderProjectDescription(ctx, p)
+
+	boards, err := p.GetBoards()
+	if err != nil {
+		ctx.ServerError("GetBoards", err)
+		return
+	}
+	pis, err := models.GetProjectIssues(p, ctx.User)
+	if err != nil {
+		ctx.ServerError("GetProjectIssues", err)
+		return
+	}
+
+	boardsByID := make(map[int64]*models.ProjectBoard, len(boards))
+	for _, b := range boards {
+		boardsByID[b.ID] = b
+	}
+	uncategorized := make([]*models.ProjectIssue, 0, len(pis))
+	for _, pi := range pis {
+		if b, ok := boardsByID[pi.ProjectBoardID]; ok {
+			b.Issues = append(b.Issues, pi)
+		} else {
+			uncategorized = append(uncategorized, pi)
+		}
+	}
+
+	ctx.Data["Title"] = p.Title
+	ctx.Data["Project"] = p
+	ctx.Data["Boards"] = boards
+	ctx.Data["UncategorizedIssues"] = uncategorized
+	ctx.HTML(200, tplProjectView)
+}
+
+// NewProjectBoardPost adds a board to a project
+func NewProjectBoardPost(ctx *context.Context, form auth.ProjectBoardForm) {
+	p := getProject(ctx, ctx.ParamsInt64(":id"))
+	if ctx.Written() {
+		return
+	}
+
+	if ctx.HasError() {
+		ctx.Flash.Error(ctx.Data["ErrorMsg"].(string))
+		ctx.Redirect(p.Link())
+		return
+	}
+
+	trigger, _ := models.ToProjectBoardTrigger(form.Trigger)
+	if err := models.NewProjectBoard(&models.ProjectBoard{
+		ProjectID: p.ID,
+		Title:     form.Title,
+		Trigger:   trigger,
+		CreatorID: ctx.User.ID,
+	}); err != nil {
+		ctx.ServerError("NewProjectBoard", err)
+		return
+	}
+	ctx.Redirect(p.Link())
+}
+
+// EditProjectBoardPost updates the title and the trigger of a board
+func EditProjectBoardPost(ctx *context.Context, form auth.ProjectBoardForm) {
+	p := getProject(ctx, ctx.ParamsInt64(":id"))
+	if ctx.Written() {
+		return
+	}
+
+	if ctx.HasError() {
+		ctx.Flash.Error(ctx.Data["ErrorMsg"].(string))
+		ctx.Redirect(p.Link())
+		return
+	}
+
+	b, err := models.GetProjectBoard(p.ID, ctx.ParamsInt64(":boardID"))
+	if err != nil {
+		ctx.NotFoundOrServerError("GetProjectBoard", models.IsErrProjectBoardNotExist, err)
+		return
+	}
+	b.Title = form.Title
+	b.Trigger, _ = models.ToProjectBoardTrigger(form.Trigger)
+	if err = models.UpdateProjectBoard(b); err != nil {
+		ctx.ServerError("UpdateProjectBoard", err)
+		return
+	}
+	ctx.Redirect(p.Link())
+}
+
+// DeleteProjectBoard deletes a board, its cards are moved to the uncategorized column
+func DeleteProjectBoard(ctx *context.Context) {
+	p := getProject(ctx, ctx.ParamsInt64(":id"))
+	if ctx.Written() {
+		return
+	}
+
+	b, err := models.GetProjectBoard(p.ID, ctx.QueryInt64("id"))
+	if err != nil {
+		ctx.NotFoundOrServerError("GetProjectBoard", models.IsErrProjectBoardNotExist, err)
+		return
+	}
+	if err = models.DeleteProjectBoard(b); err != nil {
+		ctx.Flash.Error("DeleteProjectBoard: " + err.Error())
+	} else {
+		ctx.Flash.Success(ctx.Tr("repo.projects.board.deletion_success"))
+	}
+
+	ctx.JSON(200, map[string]interface{}{
+		"redirect": p.Link(),
+	})
+}
+
+// getProjectCardIssue returns the issue of the issue_id query, which the user must be able to read
+func getProjectCardIssue(ctx *context.Context) *models.Issue {
+	issue, err := models.GetIssueByID(ctx.QueryInt64("issue_id"))
+	if err != nil {
+		if models.IsErrIssueNotExist(err) {
+			ctx.Error(404, "GetIssueByID")
+		} else {
+			ctx.ServerError("GetIssueByID", err)
+		}
+		return nil
+	}
+	if err = issue.LoadRepo(); err != nil {
+		ctx.ServerError("LoadRepo", err)
+		return nil
+	}
+	perm, err := models.GetUserRepoPermission(issue.Repo, ctx.User)
+	if err != nil {
+		ctx.ServerError("GetUserRepoPermission", err)
+		return nil
+	}
+	if !perm.CanReadIssuesOrPulls(issue.IsPull) {
+		ctx.Error(404, "CanReadIssuesOrPulls")
+		return nil
+	}
+	return issue
+}
+
+// MoveProjectCard adds an issue to a project or moves its card to the position in a board
+func MoveProjectCard(ctx *context.Context) {
+	p := getProject(ctx, ctx.ParamsInt64(":id"))
+	if ctx.Written() {
+		return
+	}
+	issue := getProjectCardIssue(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if err := models.MoveIssueToProjectBoard(p, issue, ctx.QueryInt64("board_id"), ctx.QueryInt("position")); err != nil {
+		if models.IsErrProjectBoardNotExist(err) || models.IsErrProjectIssueNotAllowed(err) {
+			ctx.Error(422, err.Error())
+		} else {
+			ctx.ServerError("MoveIssueToProjectBoard", err)
+		}
+		return
+	}
+	ctx.JSON(200, map[string]interface{}{
+		"ok": true,
+	})
+}
+
+// RemoveProjectCard removes an issue from a project
+func RemoveProjectCard(ctx *context.Context) {
+	p := getProject(ctx, ctx.ParamsInt64(":id"))
+	if ctx.Written() {
+		return
+	}
+	if err := models.RemoveIssueFromProject(p, ctx.QueryInt64("issue_id")); err != nil {
+		ctx.ServerError("RemoveIssueFromProject", err)
+		return
+	}
+	ctx.Redirect(p.Link())
+}
+
+// canWriteIssueProject returns true if the user can manage the project of the repository or of its organization
+func canWriteIssueProject(ctx *context.Context, p *models.Project) (bool, error) {
+	repo := ctx.Repo.Repository
+	if p.RepoID == repo.ID {
+		return ctx.Repo.CanWrite(models.UnitTypeProjects), nil
+	}
+	if p.IsOrgProject() && p.OwnerID == repo.OwnerID {
+		return models.CanWriteOrgProjects(repo.Owner, ctx.User)
+	}
+	return false, nil
+}
+
+// retrieveIssueProjects finds the projects of the issue and the projects it can be added to
+func retrieveIssueProjects(ctx *context.Context, repo *models.Repository, issue *models.Issue) {
+	issueProjects, err := models.GetIssueProjects(issue.ID)
+	if err != nil {
+		ctx.ServerError("GetIssueProjects", err)
+		return
+	}
+	visible := issueProjects[:0]
+	issueProjectIDs := make(map[int64]bool, len(issueProjects))
+	for _, p := range issueProjects {
+		if p.RepoID != repo.ID && (!p.IsOrgProject() || p.OwnerID != repo.OwnerID) {
+			continue
+		}
+		if err = p.LoadAttributes(); err != nil {
+			ctx.ServerError("LoadAttributes", err)
+			return
+		}
+		visible = append(visible, p)
+		issueProjectIDs[p.ID] = true
+	}
+	ctx.Data["IssueProjects"] = visible
+	ctx.Data["IssueProjectIDs"] = issueProjectIDs
+
+	var projects models.ProjectList
+	if repo.UnitEnabled(models.UnitTypeProjects) && ctx.Repo.CanWrite(models.UnitTypeProjects) {
+		if projects, err = models.FindProjects(models.ProjectSearchOptions{
+			RepoID:   repo.ID,
+			IsClosed: util.OptionalBoolFalse,
+		}); err != nil {
+			ctx.ServerError("FindProjects", err)
+			return
+		}
+	}
+	if repo.Owner.IsOrganization() {
+		canWrite, err := models.CanWriteOrgProjects(repo.Owner, ctx.User)
+		if err != nil {
+			ctx.ServerError("CanWriteOrgProjects", err)
+			return
+		}
+		if canWrite {
+			orgProjects, err := models.FindProjects(models.ProjectSearchOptions{
+				OwnerID:  repo.OwnerID,
+				IsClosed: util.OptionalBoolFalse,
+			})
+			if err != nil {
+				ctx.ServerError("FindProjects", err)
+				return
+			}
+			projects = append(projects, orgProjects...)
+		}
+	}
+	ctx.Data["Projects"] = projects
+}
+
+// UpdateIssueProjects adds or removes issues to or from the projects
+func UpdateIssueProjects(ctx *context.Context) {
+	issues := getActionIssues(ctx)
+	if ctx.Written() {
+		return
+	}
+	for _, issue := range issues {
+		if issue.RepoID != ctx.Repo.Repository.ID {
+			ctx.NotFound("UpdateIssueProjects", nil)
+			return
+		}
+	}
+
+	var projects models.ProjectList
+	action := ctx.Query("action")
+	if action == "clear" {
+		for _, issue := range issues {
+			issueProjects, err := models.GetIssueProjects(issue.ID)
+			if err != nil {
+				ctx.ServerError("GetIssueProjects", err)
+				return
+			}
+			projects = append(projects, issueProjects...)
+		}
+	} else {
+		p, err := models.GetProjectByID(ctx.QueryInt64("id"))
+		if err != nil {
+			ctx.NotFoundOrServerError("GetProjectByID", models.IsErrProjectNotExist, err)
+			return
+		}
+		projects = append(projects, p)
+	}
+
+	for _, p := range projects {
+		canWrite, err := canWriteIssueProject(ctx, p)
+		if err != nil {
+			ctx.ServerError("canWriteIssueProject", err)
+			return
+		} else if !canWrite {
+			if action == "clear" {
+				continue
+			}
+			ctx.Error(403)
+			return
+		}
+
+		for _, issue := range issues {
+			switch action {
+			case "attach":
+				_, err = models.GetProjectIssue(p.ID, issue.ID)
+				if models.IsErrProjectIssueNotExist(err) {
+					err = models.MoveIssueToProjectBoard(p, issue, 0, -1)
+				}
+			case "detach", "clear":
+				err = models.RemoveIssueFromProject(p, issue.ID)
+			default:
+				log.Warn("Unrecognized action: %s", action)
+			}
+			if err != nil {
+				ctx.ServerError("UpdateIssueProjects", fmt.Errorf("project %d, issue %d: %v", p.ID, issue.ID, err))
+				return
+			}
+		}
+	}
+
+	ctx.JSON(200, map[string]interface{}{
+		"ok": true,
+	})
+}
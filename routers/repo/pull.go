@@ -331,12 +331,16 @@ func PrepareViewPullInfo(ctx *context.Context, issue *models.Issue) *git.Compare
 			return nil
 		}
 
-		headBranchExist = headGitRepo.IsBranchExist(pull.HeadBranch)
+		if pull.IsAGitFlow() {
+			headBranchExist = git.IsReferenceExist(headGitRepo.Path, pull.GetGitRefName())
+		} else {
+			headBranchExist = headGitRepo.IsBranchExist(pull.HeadBranch)
+		}
 
 		if headBranchExist {
-			sha, err := headGitRepo.GetBranchCommitID(pull.HeadBranch)
+			sha, err := headGitRepo.GetRefCommitID(pull.GetHeadRefName())
 			if err != nil {
-				ctx.ServerError("GetBranchCommitID", err)
+				ctx.ServerError("GetRefCommitID", err)
 				return nil
 			}
 
@@ -361,7 +365,7 @@ func PrepareViewPullInfo(ctx *context.Context, issue *models.Issue) *git.Compare
 	}
 
 	compareInfo, err := headGitRepo.GetCompareInfo(models.RepoPath(repo.Owner.Name, repo.Name),
-		pull.BaseBranch, pull.HeadBranch)
+		pull.BaseBranch, pull.GetHeadRefName())
 	if err != nil {
 		if strings.Contains(err.Error(), "fatal: Not a valid object name") {
 			ctx.Data["IsPullRequestBroken"] = true
@@ -501,9 +505,9 @@ func ViewPullFiles(ctx *context.Context) {
 			return
 		}
 
-		headCommitID, err := headGitRepo.GetBranchCommitID(pull.HeadBranch)
+		headCommitID, err := headGitRepo.GetRefCommitID(pull.GetHeadRefName())
 		if err != nil {
-			ctx.ServerError("GetBranchCommitID", err)
+			ctx.ServerError("GetRefCommitID", err)
 			return
 		}
 
@@ -806,8 +810,8 @@ func CleanUpPullRequest(ctx *context.Context) {
 
 	pr := issue.PullRequest
 
-	// Don't cleanup unmerged and unclosed PRs
-	if !pr.HasMerged && !issue.IsClosed {
+	// Don't cleanup unmerged and unclosed PRs, nor the AGit flow ones which have no head branch
+	if (!pr.HasMerged && !issue.IsClosed) || pr.IsAGitFlow() {
 		ctx.NotFound("CleanUpPullRequest", nil)
 		return
 	}
@@ -979,7 +983,7 @@ func DownloadPullPatch(ctx *context.Context) {
 		return
 	}
 
-	patch, err := headGitRepo.GetFormatPatch(pr.MergeBase, pr.HeadBranch)
+	patch, err := headGitRepo.GetFormatPatch(pr.MergeBase, pr.GetHeadRefName())
 	if err != nil {
 		ctx.ServerError("GetFormatPatch", err)
 		return
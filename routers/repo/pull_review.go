@@ -148,43 +148,14 @@ func SubmitReview(ctx *context.Context, form auth.SubmitReviewForm) {
 		return
 	}
 
-	if err != nil {
-		if !models.IsErrReviewNotExist(err) {
-			ctx.ServerError("GetCurrentReview", err)
-			return
-		}
-		// No current review. Create a new one!
-		if review, err = models.CreateReview(models.CreateReviewOptions{
-			Type:     reviewType,
-			Issue:    issue,
-			Reviewer: ctx.User,
-			Content:  form.Content,
-		}); err != nil {
-			ctx.ServerError("CreateReview", err)
-			return
-		}
-	} else {
-		review.Content = form.Content
-		review.Type = reviewType
-		if err = models.UpdateReview(review); err != nil {
-			ctx.ServerError("UpdateReview", err)
-			return
-		}
-	}
-	comm, err := models.CreateComment(&models.CreateCommentOptions{
-		Type:     models.CommentTypeReview,
-		Doer:     ctx.User,
-		Content:  review.Content,
-		Issue:    issue,
-		Repo:     issue.Repo,
-		ReviewID: review.ID,
-	})
-	if err != nil || comm == nil {
-		ctx.ServerError("CreateComment", err)
+	if err != nil && !models.IsErrReviewNotExist(err) {
+		ctx.ServerError("GetCurrentReview", err)
 		return
 	}
-	if err = review.Publish(); err != nil {
-		ctx.ServerError("Publish", err)
+
+	review, comm, err := models.SubmitReview(ctx.User, issue, reviewType, form.Content, "")
+	if err != nil {
+		ctx.ServerError("SubmitReview", err)
 		return
 	}
 
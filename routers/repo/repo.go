@@ -19,6 +19,7 @@ import (
 	"code.gitea.io/gitea/modules/migrations"
 	"code.gitea.io/gitea/modules/notification"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/task"
 	"code.gitea.io/gitea/modules/util"
 
 	"github.com/Unknwon/com"
@@ -105,6 +106,31 @@ func getRepoPrivate(ctx *context.Context) bool {
 	}
 }
 
+// getTemplateRepo returns the template repository to generate a new repository from,
+// which must be readable by the signed user, and the permission of the user on it.
+func getTemplateRepo(ctx *context.Context, templateID int64) (*models.Repository, models.Permission) {
+	templateRepo, err := models.GetRepositoryByID(templateID)
+	if err != nil {
+		if models.IsErrRepoNotExist(err) {
+			ctx.NotFound("GetRepositoryByID", nil)
+		} else {
+			ctx.ServerError("GetRepositoryByID", err)
+		}
+		return nil, models.Permission{}
+	}
+
+	perm, err := models.GetUserRepoPermission(templateRepo, ctx.User)
+	if err != nil {
+		ctx.ServerError("GetUserRepoPermission", err)
+		return nil, perm
+	}
+	if !templateRepo.IsTemplate || !perm.CanRead(models.UnitTypeCode) {
+		ctx.NotFound("getTemplateRepo", nil)
+		return nil, perm
+	}
+	return templateRepo, perm
+}
+
 // Create render creating repository page
 func Create(ctx *context.Context) {
 	if !ctx.User.CanCreateRepo() {
@@ -127,6 +153,16 @@ func Create(ctx *context.Context) {
 	}
 	ctx.Data["ContextUser"] = ctxUser
 
+	if templateID := ctx.QueryInt64("template_id"); templateID > 0 {
+		templateRepo, _ := getTemplateRepo(ctx, templateID)
+		if ctx.Written() {
+			return
+		}
+		ctx.Data["repo_template"] = templateRepo.ID
+		ctx.Data["repo_template_name"] = templateRepo.FullName()
+		ctx.Data["git_content"] = true
+	}
+
 	ctx.HTML(200, tplCreate)
 }
 
@@ -164,20 +200,55 @@ func CreatePost(ctx *context.Context, form auth.CreateRepoForm) {
 	}
 	ctx.Data["ContextUser"] = ctxUser
 
+	var templateRepo *models.Repository
+	var templatePerm models.Permission
+	if form.RepoTemplate > 0 {
+		templateRepo, templatePerm = getTemplateRepo(ctx, form.RepoTemplate)
+		if ctx.Written() {
+			return
+		}
+		ctx.Data["repo_template_name"] = templateRepo.FullName()
+	}
+
 	if ctx.HasError() {
 		ctx.HTML(200, tplCreate)
 		return
 	}
 
-	repo, err := models.CreateRepository(ctx.User, ctxUser, models.CreateRepoOptions{
-		Name:        form.RepoName,
-		Description: form.Description,
-		Gitignores:  form.Gitignores,
-		License:     form.License,
-		Readme:      form.Readme,
-		IsPrivate:   form.Private || setting.Repository.ForcePrivate,
-		AutoInit:    form.AutoInit,
-	})
+	var repo *models.Repository
+	var err error
+	if templateRepo != nil {
+		opts := models.GenerateRepoOptions{
+			Name:        form.RepoName,
+			Description: form.Description,
+			Private:     form.Private || setting.Repository.ForcePrivate,
+			GitContent:  form.GitContent,
+			Topics:      form.Topics,
+			Webhooks:    form.Webhooks,
+			Avatar:      form.Avatar,
+			IssueLabels: form.Labels,
+		}
+		if !opts.IsValid() {
+			ctx.RenderWithErr(ctx.Tr("repo.template.one_item"), tplCreate, &form)
+			return
+		}
+		// the webhooks may deliver to the services of the template owner
+		if opts.Webhooks && !templatePerm.IsAdmin() {
+			ctx.RenderWithErr(ctx.Tr("repo.template.webhooks_admin_only"), tplCreate, &form)
+			return
+		}
+		repo, err = models.GenerateRepository(ctx.User, ctxUser, templateRepo, opts)
+	} else {
+		repo, err = models.CreateRepository(ctx.User, ctxUser, models.CreateRepoOptions{
+			Name:        form.RepoName,
+			Description: form.Description,
+			Gitignores:  form.Gitignores,
+			License:     form.License,
+			Readme:      form.Readme,
+			IsPrivate:   form.Private || setting.Repository.ForcePrivate,
+			AutoInit:    form.AutoInit,
+		})
+	}
 	if err == nil {
 		notification.NotifyCreateRepository(ctx.User, ctxUser, repo)
 
@@ -255,20 +326,21 @@ func MigratePost(ctx *context.Context, form auth.MigrateRepoForm) {
 	}
 
 	var opts = migrations.MigrateOptions{
-		RemoteURL:    remoteAddr,
-		Name:         form.RepoName,
-		Description:  form.Description,
-		Private:      form.Private || setting.Repository.ForcePrivate,
-		Mirror:       form.Mirror,
-		AuthUsername: form.AuthUsername,
-		AuthPassword: form.AuthPassword,
-		Wiki:         form.Wiki,
-		Issues:       form.Issues,
-		Milestones:   form.Milestones,
-		Labels:       form.Labels,
-		Comments:     true,
-		PullRequests: form.PullRequests,
-		Releases:     form.Releases,
+		RemoteURL:      remoteAddr,
+		Name:           form.RepoName,
+		Description:    form.Description,
+		Private:        form.Private || setting.Repository.ForcePrivate,
+		Mirror:         form.Mirror,
+		AuthUsername:   form.AuthUsername,
+		AuthPassword:   form.AuthPassword,
+		GitServiceType: form.Service,
+		Wiki:           form.Wiki,
+		Issues:         form.Issues,
+		Milestones:     form.Milestones,
+		Labels:         form.Labels,
+		Comments:       true,
+		PullRequests:   form.PullRequests,
+		Releases:       form.Releases,
 	}
 	if opts.Mirror {
 		opts.Issues = false
@@ -279,11 +351,9 @@ func MigratePost(ctx *context.Context, form auth.MigrateRepoForm) {
 		opts.Releases = false
 	}
 
-	repo, err := migrations.MigrateRepository(ctx.User, ctxUser.Name, opts)
+	repo, err := task.MigrateRepository(ctx.User, ctxUser, opts)
 	if err == nil {
-		notification.NotifyCreateRepository(ctx.User, ctxUser, repo)
-
-		log.Trace("Repository migrated [%d]: %s/%s successfully", repo.ID, ctxUser.Name, form.RepoName)
+		log.Trace("Repository migration queued [%d]: %s/%s", repo.ID, ctxUser.Name, form.RepoName)
 		ctx.Redirect(setting.AppSubURL + "/" + ctxUser.Name + "/" + form.RepoName)
 		return
 	}
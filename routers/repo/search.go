@@ -10,6 +10,7 @@ import (
 
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/indexer"
 	"code.gitea.io/gitea/modules/search"
 	"code.gitea.io/gitea/modules/setting"
 )
@@ -27,13 +28,23 @@ func Search(ctx *context.Context) {
 	if page <= 0 {
 		page = 1
 	}
-	total, searchResults, err := search.PerformSearch([]int64{ctx.Repo.Repository.ID},
-		keyword, page, setting.UI.RepoSearchPagingNum)
+	language := strings.TrimSpace(ctx.Query("l"))
+	filename := strings.TrimSpace(ctx.Query("path"))
+	total, searchResults, err := search.PerformSearch(&indexer.RepoSearchOptions{
+		RepoIDs:  []int64{ctx.Repo.Repository.ID},
+		Keyword:  keyword,
+		Language: language,
+		Filename: filename,
+		Page:     page,
+		PageSize: setting.UI.RepoSearchPagingNum,
+	})
 	if err != nil {
 		ctx.ServerError("SearchResults", err)
 		return
 	}
 	ctx.Data["Keyword"] = keyword
+	ctx.Data["Language"] = language
+	ctx.Data["Filename"] = filename
 	ctx.Data["SourcePath"] = setting.AppSubURL + "/" +
 		path.Join(ctx.Repo.Repository.Owner.Name, ctx.Repo.Repository.Name, "src", "branch", ctx.Repo.Repository.DefaultBranch)
 	ctx.Data["SearchResults"] = searchResults
@@ -42,6 +53,8 @@ func Search(ctx *context.Context) {
 
 	pager := context.NewPagination(total, setting.UI.RepoSearchPagingNum, page, 5)
 	pager.SetDefaultParams(ctx)
+	pager.AddParam(ctx, "l", "Language")
+	pager.AddParam(ctx, "path", "Filename")
 	ctx.Data["Page"] = pager
 
 	ctx.HTML(200, tplSearch)
@@ -14,7 +14,6 @@ import (
 	"strings"
 	"time"
 
-	"github.com/Unknwon/com"
 	"mvdan.cc/xurls/v2"
 
 	"code.gitea.io/gitea/models"
@@ -46,9 +45,41 @@ func Settings(ctx *context.Context) {
 	ctx.Data["Title"] = ctx.Tr("repo.settings")
 	ctx.Data["PageIsSettingsOptions"] = true
 	ctx.Data["ForcePrivate"] = setting.Repository.ForcePrivate
+
+	pushMirrors, err := models.GetPushMirrorsByRepoID(ctx.Repo.Repository.ID)
+	if err != nil {
+		ctx.ServerError("GetPushMirrorsByRepoID", err)
+		return
+	}
+	ctx.Data["PushMirrors"] = pushMirrors
+	ctx.Data["DefaultMirrorInterval"] = setting.Mirror.DefaultInterval
+
 	ctx.HTML(200, tplSettingsOptions)
 }
 
+// parseMirrorAddress validates a remote address for a pull or push mirror. It
+// returns the parsed URL or the locale key of the error to display.
+func parseMirrorAddress(address string) (*url.URL, string) {
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, "repo.mirror_address_url_invalid"
+	}
+
+	if u.Opaque != "" || !(u.Scheme == "http" || u.Scheme == "https" || u.Scheme == "git") {
+		return nil, "repo.mirror_address_protocol_invalid"
+	}
+
+	// Now use xurls
+	if validFormAddress.FindString(address) != address && address != "" {
+		return nil, "repo.mirror_address_url_invalid"
+	}
+
+	if u.EscapedPath() == "" || u.Host == "" || !u.IsAbs() {
+		return nil, "repo.mirror_address_url_invalid"
+	}
+	return u, ""
+}
+
 // SettingsPost response for changes of a repository
 func SettingsPost(ctx *context.Context, form auth.RepoSettingForm) {
 	ctx.Data["Title"] = ctx.Tr("repo.settings")
@@ -56,6 +87,14 @@ func SettingsPost(ctx *context.Context, form auth.RepoSettingForm) {
 
 	repo := ctx.Repo.Repository
 
+	pushMirrors, err := models.GetPushMirrorsByRepoID(repo.ID)
+	if err != nil {
+		ctx.ServerError("GetPushMirrorsByRepoID", err)
+		return
+	}
+	ctx.Data["PushMirrors"] = pushMirrors
+	ctx.Data["DefaultMirrorInterval"] = setting.Mirror.DefaultInterval
+
 	switch ctx.Query("action") {
 	case "update":
 		if ctx.HasError() {
@@ -111,6 +150,7 @@ func SettingsPost(ctx *context.Context, form auth.RepoSettingForm) {
 		}
 
 		repo.IsPrivate = form.Private
+		repo.IsTemplate = form.Template
 		if err := models.UpdateRepository(repo, visibilityChanged); err != nil {
 			ctx.ServerError("UpdateRepository", err)
 			return
@@ -156,51 +196,100 @@ func SettingsPost(ctx *context.Context, form auth.RepoSettingForm) {
 		}
 
 		// Validate the form.MirrorAddress
-		u, err := url.Parse(form.MirrorAddress)
-		if err != nil {
+		u, errKey := parseMirrorAddress(form.MirrorAddress)
+		if errKey != "" {
 			ctx.Data["Err_MirrorAddress"] = true
-			ctx.RenderWithErr(ctx.Tr("repo.mirror_address_url_invalid"), tplSettingsOptions, &form)
+			ctx.RenderWithErr(ctx.Tr(errKey), tplSettingsOptions, &form)
 			return
 		}
 
-		if u.Opaque != "" || !(u.Scheme == "http" || u.Scheme == "https" || u.Scheme == "git") {
-			ctx.Data["Err_MirrorAddress"] = true
-			ctx.RenderWithErr(ctx.Tr("repo.mirror_address_protocol_invalid"), tplSettingsOptions, &form)
+		if err := ctx.Repo.Mirror.SaveAddress(u.String()); err != nil {
+			ctx.ServerError("SaveAddress", err)
 			return
 		}
 
-		// Now use xurls
-		address := validFormAddress.FindString(form.MirrorAddress)
-		if address != form.MirrorAddress && form.MirrorAddress != "" {
-			ctx.Data["Err_MirrorAddress"] = true
-			ctx.RenderWithErr(ctx.Tr("repo.mirror_address_url_invalid"), tplSettingsOptions, &form)
+		ctx.Flash.Success(ctx.Tr("repo.settings.update_settings_success"))
+		ctx.Redirect(repo.Link() + "/settings")
+
+	case "mirror-sync":
+		if !repo.IsMirror {
+			ctx.NotFound("", nil)
 			return
 		}
 
-		if u.EscapedPath() == "" || u.Host == "" || !u.IsAbs() {
-			ctx.Data["Err_MirrorAddress"] = true
-			ctx.RenderWithErr(ctx.Tr("repo.mirror_address_url_invalid"), tplSettingsOptions, &form)
+		go models.MirrorQueue.Add(repo.ID)
+		ctx.Flash.Info(ctx.Tr("repo.settings.mirror_sync_in_progress"))
+		ctx.Redirect(repo.Link() + "/settings")
+
+	case "push-mirror-sync":
+		m, err := models.GetPushMirrorByRepoIDAndID(repo.ID, form.PushMirrorID)
+		if err != nil {
+			if models.IsErrPushMirrorNotExist(err) {
+				ctx.NotFound("GetPushMirrorByRepoIDAndID", err)
+			} else {
+				ctx.ServerError("GetPushMirrorByRepoIDAndID", err)
+			}
 			return
 		}
 
-		address = u.String()
+		go models.PushMirrorQueue.Add(m.ID)
+		ctx.Flash.Info(ctx.Tr("repo.settings.push_mirror_sync_in_progress", m.Address()))
+		ctx.Redirect(repo.Link() + "/settings")
 
-		if err := ctx.Repo.Mirror.SaveAddress(address); err != nil {
-			ctx.ServerError("SaveAddress", err)
+	case "push-mirror-remove":
+		m, err := models.GetPushMirrorByRepoIDAndID(repo.ID, form.PushMirrorID)
+		if err != nil {
+			if models.IsErrPushMirrorNotExist(err) {
+				ctx.NotFound("GetPushMirrorByRepoIDAndID", err)
+			} else {
+				ctx.ServerError("GetPushMirrorByRepoIDAndID", err)
+			}
+			return
+		}
+
+		if err = models.DeletePushMirror(m); err != nil {
+			ctx.ServerError("DeletePushMirror", err)
 			return
 		}
+		log.Trace("Push mirror removed: %s/%s -> %s", ctx.Repo.Owner.Name, repo.Name, m.RemoteName)
 
 		ctx.Flash.Success(ctx.Tr("repo.settings.update_settings_success"))
 		ctx.Redirect(repo.Link() + "/settings")
 
-	case "mirror-sync":
-		if !repo.IsMirror {
-			ctx.NotFound("", nil)
+	case "push-mirror-add":
+		// This section doesn't require repo_name/RepoName to be set in the form, don't show it
+		// as an error on the UI for this action
+		ctx.Data["Err_RepoName"] = nil
+
+		interval, err := time.ParseDuration(form.PushMirrorInterval)
+		if err != nil || (interval != 0 && interval < setting.Mirror.MinInterval) {
+			ctx.Data["Err_PushMirrorInterval"] = true
+			ctx.RenderWithErr(ctx.Tr("repo.mirror_interval_invalid"), tplSettingsOptions, &form)
 			return
 		}
 
-		go models.MirrorQueue.Add(repo.ID)
-		ctx.Flash.Info(ctx.Tr("repo.settings.mirror_sync_in_progress"))
+		u, errKey := parseMirrorAddress(form.PushMirrorAddress)
+		if errKey != "" {
+			ctx.Data["Err_PushMirrorAddress"] = true
+			ctx.RenderWithErr(ctx.Tr(errKey), tplSettingsOptions, &form)
+			return
+		}
+		if len(form.PushMirrorUsername)+len(form.PushMirrorPassword) > 0 {
+			u.User = url.UserPassword(form.PushMirrorUsername, form.PushMirrorPassword)
+		}
+
+		m := &models.PushMirror{
+			RepoID:   repo.ID,
+			Repo:     repo,
+			Interval: interval,
+		}
+		if err := models.InsertPushMirror(m, u.String()); err != nil {
+			ctx.ServerError("InsertPushMirror", err)
+			return
+		}
+		log.Trace("Push mirror added: %s/%s -> %s", ctx.Repo.Owner.Name, repo.Name, m.RemoteName)
+
+		ctx.Flash.Success(ctx.Tr("repo.settings.update_settings_success"))
 		ctx.Redirect(repo.Link() + "/settings")
 
 	case "advanced":
@@ -290,6 +379,24 @@ func SettingsPost(ctx *context.Context, form auth.RepoSettingForm) {
 			})
 		}
 
+		if form.EnableProjects {
+			units = append(units, models.RepoUnit{
+				RepoID: repo.ID,
+				Type:   models.UnitTypeProjects,
+				Config: new(models.UnitConfig),
+			})
+		}
+
+		// Keep the actions unit untouched when the form does not show it
+		if (setting.Actions.Enabled && form.EnableActions) ||
+			(!setting.Actions.Enabled && repo.UnitEnabled(models.UnitTypeActions)) {
+			units = append(units, models.RepoUnit{
+				RepoID: repo.ID,
+				Type:   models.UnitTypeActions,
+				Config: new(models.UnitConfig),
+			})
+		}
+
 		if err := models.UpdateRepositoryUnits(repo, units); err != nil {
 			ctx.ServerError("UpdateRepositoryUnits", err)
 			return
@@ -742,7 +849,7 @@ func UpdateAvatarSetting(ctx *context.Context, form auth.AvatarForm) error {
 		// No avatar is uploaded and we not removing it here.
 		// No random avatar generated here.
 		// Just exit, no action.
-		if !com.IsFile(ctxRepo.CustomAvatarPath()) {
+		if !ctxRepo.IsCustomAvatarExist() {
 			log.Trace("No avatar was uploaded for repo: %d. Default icon will appear instead.", ctxRepo.ID)
 		}
 		return nil
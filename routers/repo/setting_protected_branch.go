@@ -7,6 +7,7 @@ package repo
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/auth"
@@ -15,6 +16,8 @@ import (
 	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/Unknwon/com"
 )
 
 // ProtectedBranch render the page to protect the repository
@@ -138,6 +141,21 @@ func SettingsProtectedBranch(c *context.Context) {
 		c.Data["approvals_whitelist_teams"] = strings.Join(base.Int64sToStrings(protectBranch.ApprovalsWhitelistTeamIDs), ",")
 	}
 
+	contexts, err := models.FindRepoRecentCommitStatusContexts(c.Repo.Repository.ID, 7*24*time.Hour)
+	if err != nil {
+		c.ServerError("FindRepoRecentCommitStatusContexts", err)
+		return
+	}
+	requiredContexts := make(map[string]bool, len(protectBranch.StatusCheckContexts))
+	for _, statusContext := range protectBranch.StatusCheckContexts {
+		requiredContexts[statusContext] = true
+		if !com.IsSliceContainsStr(contexts, statusContext) {
+			contexts = append(contexts, statusContext)
+		}
+	}
+	c.Data["branch_status_check_contexts"] = contexts
+	c.Data["is_context_required"] = requiredContexts
+
 	c.Data["Branch"] = protectBranch
 	c.HTML(200, tplProtectedBranch)
 }
@@ -193,6 +211,14 @@ func SettingsProtectedBranchPost(ctx *context.Context, f auth.ProtectBranchForm)
 		if strings.TrimSpace(f.ApprovalsWhitelistTeams) != "" {
 			approvalsWhitelistTeams, _ = base.StringsToInt64s(strings.Split(f.ApprovalsWhitelistTeams, ","))
 		}
+		protectBranch.DismissStaleApprovals = f.DismissStaleApprovals
+		protectBranch.EnableStatusCheck = f.EnableStatusCheck
+		protectBranch.StatusCheckContexts = make([]string, 0, len(f.StatusCheckContexts))
+		for _, statusContext := range f.StatusCheckContexts {
+			if statusContext = strings.TrimSpace(statusContext); statusContext != "" && !com.IsSliceContainsStr(protectBranch.StatusCheckContexts, statusContext) {
+				protectBranch.StatusCheckContexts = append(protectBranch.StatusCheckContexts, statusContext)
+			}
+		}
 		err = models.UpdateProtectBranch(ctx.Repo.Repository, protectBranch, models.WhitelistOptions{
 			UserIDs:          whitelistUsers,
 			TeamIDs:          whitelistTeams,
@@ -8,7 +8,6 @@ import (
 	"bytes"
 	"encoding/gob"
 	"net/http"
-	"os"
 	"path"
 	"text/template"
 	"time"
@@ -23,10 +22,14 @@ import (
 	"code.gitea.io/gitea/modules/options"
 	"code.gitea.io/gitea/modules/public"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
 	"code.gitea.io/gitea/modules/templates"
 	"code.gitea.io/gitea/modules/validation"
 	"code.gitea.io/gitea/routers"
 	"code.gitea.io/gitea/routers/admin"
+	"code.gitea.io/gitea/routers/api/actions"
+	"code.gitea.io/gitea/routers/api/goproxy"
+	"code.gitea.io/gitea/routers/api/packages"
 	apiv1 "code.gitea.io/gitea/routers/api/v1"
 	"code.gitea.io/gitea/routers/dev"
 	"code.gitea.io/gitea/routers/org"
@@ -148,16 +151,18 @@ func NewMacaron() *macaron.Macaron {
 		},
 	))
 	m.Use(public.StaticHandler(
-		setting.AvatarUploadPath,
+		"",
 		&public.Options{
+			FileSystem:   storage.FileSystem(storage.Avatars),
 			Prefix:       "avatars",
 			SkipLogging:  setting.DisableRouterLog,
 			ExpiresAfter: time.Hour * 6,
 		},
 	))
 	m.Use(public.StaticHandler(
-		setting.RepositoryAvatarUploadPath,
+		"",
 		&public.Options{
+			FileSystem:   storage.FileSystem(storage.RepoAvatars),
 			Prefix:       "repo-avatars",
 			SkipLogging:  setting.DisableRouterLog,
 			ExpiresAfter: time.Hour * 6,
@@ -324,6 +329,11 @@ func RegisterRoutes(m *macaron.Macaron) {
 		m.Post("/authorize", bindIgnErr(auth.AuthorizationForm{}), user.AuthorizeOAuth)
 	}, ignSignInAndCsrf, reqSignIn)
 	m.Post("/login/oauth/access_token", bindIgnErr(auth.AccessTokenForm{}), ignSignInAndCsrf, user.AccessTokenOAuth)
+	if setting.OAuth2.Enable {
+		m.Combo("/login/oauth/userinfo", ignSignInAndCsrf).Get(user.InfoOAuth).Post(user.InfoOAuth)
+		m.Get("/login/oauth/keys", ignSignInAndCsrf, user.OIDCKeys)
+		m.Get("/.well-known/openid-configuration", ignSignInAndCsrf, user.OIDCWellKnown)
+	}
 
 	m.Group("/user/settings", func() {
 		m.Get("", userSetting.Profile)
@@ -446,6 +456,8 @@ func RegisterRoutes(m *macaron.Macaron) {
 			m.Post("/discord/new", bindIgnErr(auth.NewDiscordHookForm{}), repo.DiscordHooksNewPost)
 			m.Post("/dingtalk/new", bindIgnErr(auth.NewDingtalkHookForm{}), repo.DingtalkHooksNewPost)
 			m.Post("/msteams/new", bindIgnErr(auth.NewMSTeamsHookForm{}), repo.MSTeamsHooksNewPost)
+			m.Post("/matrix/new", bindIgnErr(auth.NewMatrixHookForm{}), repo.MatrixHooksNewPost)
+			m.Post("/custom/new", bindIgnErr(auth.NewCustomHookForm{}), repo.CustomHooksNewPost)
 			m.Get("/:id", repo.WebHooksEdit)
 			m.Post("/gitea/:id", bindIgnErr(auth.NewWebhookForm{}), repo.WebHooksEditPost)
 			m.Post("/gogs/:id", bindIgnErr(auth.NewWebhookForm{}), repo.GogsHooksEditPost)
@@ -453,6 +465,35 @@ func RegisterRoutes(m *macaron.Macaron) {
 			m.Post("/discord/:id", bindIgnErr(auth.NewDiscordHookForm{}), repo.DiscordHooksEditPost)
 			m.Post("/dingtalk/:id", bindIgnErr(auth.NewDingtalkHookForm{}), repo.DingtalkHooksEditPost)
 			m.Post("/msteams/:id", bindIgnErr(auth.NewMSTeamsHookForm{}), repo.MSTeamsHooksNewPost)
+			m.Post("/matrix/:id", bindIgnErr(auth.NewMatrixHookForm{}), repo.MatrixHooksEditPost)
+			m.Post("/custom/:id", bindIgnErr(auth.NewCustomHookForm{}), repo.CustomHooksEditPost)
+		})
+
+		m.Group("/system-hooks", func() {
+			m.Get("", admin.SystemWebhooks)
+			m.Post("/delete", admin.DeleteSystemWebhook)
+			m.Get("/:type/new", repo.WebhooksNew)
+			m.Post("/gitea/new", bindIgnErr(auth.NewWebhookForm{}), repo.WebHooksNewPost)
+			m.Post("/gogs/new", bindIgnErr(auth.NewWebhookForm{}), repo.GogsHooksNewPost)
+			m.Post("/slack/new", bindIgnErr(auth.NewSlackHookForm{}), repo.SlackHooksNewPost)
+			m.Post("/discord/new", bindIgnErr(auth.NewDiscordHookForm{}), repo.DiscordHooksNewPost)
+			m.Post("/dingtalk/new", bindIgnErr(auth.NewDingtalkHookForm{}), repo.DingtalkHooksNewPost)
+			m.Post("/telegram/new", bindIgnErr(auth.NewTelegramHookForm{}), repo.TelegramHooksNewPost)
+			m.Post("/msteams/new", bindIgnErr(auth.NewMSTeamsHookForm{}), repo.MSTeamsHooksNewPost)
+			m.Post("/matrix/new", bindIgnErr(auth.NewMatrixHookForm{}), repo.MatrixHooksNewPost)
+			m.Post("/custom/new", bindIgnErr(auth.NewCustomHookForm{}), repo.CustomHooksNewPost)
+			m.Get("/:id", repo.WebHooksEdit)
+			m.Post("/gitea/:id", bindIgnErr(auth.NewWebhookForm{}), repo.WebHooksEditPost)
+			m.Post("/gogs/:id", bindIgnErr(auth.NewWebhookForm{}), repo.GogsHooksEditPost)
+			m.Post("/slack/:id", bindIgnErr(auth.NewSlackHookForm{}), repo.SlackHooksEditPost)
+			m.Post("/discord/:id", bindIgnErr(auth.NewDiscordHookForm{}), repo.DiscordHooksEditPost)
+			m.Post("/dingtalk/:id", bindIgnErr(auth.NewDingtalkHookForm{}), repo.DingtalkHooksEditPost)
+			m.Post("/telegram/:id", bindIgnErr(auth.NewTelegramHookForm{}), repo.TelegramHooksEditPost)
+			m.Post("/msteams/:id", bindIgnErr(auth.NewMSTeamsHookForm{}), repo.MSTeamsHooksEditPost)
+			m.Post("/matrix/:id", bindIgnErr(auth.NewMatrixHookForm{}), repo.MatrixHooksEditPost)
+			m.Post("/custom/:id", bindIgnErr(auth.NewCustomHookForm{}), repo.CustomHooksEditPost)
+		}, func(ctx *context.Context) {
+			ctx.Data["PageIsAdminSystemHooks"] = true
 		})
 
 		m.Group("/auths", func() {
@@ -489,7 +530,7 @@ func RegisterRoutes(m *macaron.Macaron) {
 				return
 			}
 
-			fr, err := os.Open(attach.LocalPath())
+			fr, err := storage.Attachments.Open(attach.RelativePath())
 			if err != nil {
 				ctx.ServerError("Open", err)
 				return
@@ -516,6 +557,16 @@ func RegisterRoutes(m *macaron.Macaron) {
 		m.Get("/action/:action", user.Action)
 	}, reqSignIn)
 
+	if setting.Packages.Enabled {
+		m.Group("/:username/-/packages/:type/:name", func() {
+			m.Get("", user.ViewPackage)
+			m.Group("", func() {
+				m.Post("/settings", user.PackageSettingsPost)
+				m.Post("/delete", user.DeletePackageVersion)
+			}, reqSignIn, context.RequirePackageWriter())
+		}, ignSignIn, context.PackageAssignment())
+	}
+
 	if macaron.Env == macaron.DEV {
 		m.Get("/template/*", dev.TemplatePreview)
 	}
@@ -555,6 +606,24 @@ func RegisterRoutes(m *macaron.Macaron) {
 			m.Get("/teams", org.Teams)
 		}, context.OrgAssignment(true))
 
+		m.Group("/:org/projects", func() {
+			m.Get("", repo.Projects)
+			m.Get("/:id", repo.ViewProject)
+			m.Group("", func() {
+				m.Combo("/new").Get(repo.NewProject).
+					Post(bindIgnErr(auth.CreateProjectForm{}), repo.NewProjectPost)
+				m.Get("/:id/edit", repo.EditProject)
+				m.Post("/:id/edit", bindIgnErr(auth.CreateProjectForm{}), repo.EditProjectPost)
+				m.Get("/:id/:action", repo.ChangeProjectStatus)
+				m.Post("/delete", repo.DeleteProject)
+				m.Post("/:id/boards", bindIgnErr(auth.ProjectBoardForm{}), repo.NewProjectBoardPost)
+				m.Post("/:id/boards/:boardID", bindIgnErr(auth.ProjectBoardForm{}), repo.EditProjectBoardPost)
+				m.Post("/:id/boards/delete", repo.DeleteProjectBoard)
+				m.Post("/:id/move", repo.MoveProjectCard)
+				m.Post("/:id/remove", repo.RemoveProjectCard)
+			}, reqSignIn, repo.MustWriteProjects)
+		}, context.OrgAssignment(), repo.ProjectsAssignment)
+
 		m.Group("/:org", func() {
 			m.Get("/teams/:team", org.TeamMembers)
 			m.Get("/teams/:team/repositories", org.TeamRepositories)
@@ -585,13 +654,26 @@ func RegisterRoutes(m *macaron.Macaron) {
 					m.Post("/discord/new", bindIgnErr(auth.NewDiscordHookForm{}), repo.DiscordHooksNewPost)
 					m.Post("/dingtalk/new", bindIgnErr(auth.NewDingtalkHookForm{}), repo.DingtalkHooksNewPost)
 					m.Post("/telegram/new", bindIgnErr(auth.NewTelegramHookForm{}), repo.TelegramHooksNewPost)
+					m.Post("/matrix/new", bindIgnErr(auth.NewMatrixHookForm{}), repo.MatrixHooksNewPost)
+					m.Post("/custom/new", bindIgnErr(auth.NewCustomHookForm{}), repo.CustomHooksNewPost)
 					m.Get("/:id", repo.WebHooksEdit)
+					m.Post("/:id/deliveries/:uuid/redeliver", repo.RedeliverWebhook)
 					m.Post("/gitea/:id", bindIgnErr(auth.NewWebhookForm{}), repo.WebHooksEditPost)
 					m.Post("/gogs/:id", bindIgnErr(auth.NewGogshookForm{}), repo.GogsHooksEditPost)
 					m.Post("/slack/:id", bindIgnErr(auth.NewSlackHookForm{}), repo.SlackHooksEditPost)
 					m.Post("/discord/:id", bindIgnErr(auth.NewDiscordHookForm{}), repo.DiscordHooksEditPost)
 					m.Post("/dingtalk/:id", bindIgnErr(auth.NewDingtalkHookForm{}), repo.DingtalkHooksEditPost)
 					m.Post("/telegram/:id", bindIgnErr(auth.NewTelegramHookForm{}), repo.TelegramHooksEditPost)
+					m.Post("/matrix/:id", bindIgnErr(auth.NewMatrixHookForm{}), repo.MatrixHooksEditPost)
+					m.Post("/custom/:id", bindIgnErr(auth.NewCustomHookForm{}), repo.CustomHooksEditPost)
+				})
+
+				m.Group("/labels", func() {
+					m.Get("", org.Labels)
+					m.Post("/new", bindIgnErr(auth.CreateLabelForm{}), org.NewLabel)
+					m.Post("/edit", bindIgnErr(auth.CreateLabelForm{}), org.UpdateLabel)
+					m.Post("/delete", org.DeleteLabel)
+					m.Post("/initialize", bindIgnErr(auth.InitializeLabelsForm{}), org.InitializeLabels)
 				})
 
 				m.Route("/delete", "GET,POST", org.SettingsDelete)
@@ -644,8 +726,11 @@ func RegisterRoutes(m *macaron.Macaron) {
 				m.Post("/dingtalk/new", bindIgnErr(auth.NewDingtalkHookForm{}), repo.DingtalkHooksNewPost)
 				m.Post("/telegram/new", bindIgnErr(auth.NewTelegramHookForm{}), repo.TelegramHooksNewPost)
 				m.Post("/msteams/new", bindIgnErr(auth.NewMSTeamsHookForm{}), repo.MSTeamsHooksNewPost)
+				m.Post("/matrix/new", bindIgnErr(auth.NewMatrixHookForm{}), repo.MatrixHooksNewPost)
+				m.Post("/custom/new", bindIgnErr(auth.NewCustomHookForm{}), repo.CustomHooksNewPost)
 				m.Get("/:id", repo.WebHooksEdit)
 				m.Post("/:id/test", repo.TestWebhook)
+				m.Post("/:id/deliveries/:uuid/redeliver", repo.RedeliverWebhook)
 				m.Post("/gitea/:id", bindIgnErr(auth.NewWebhookForm{}), repo.WebHooksEditPost)
 				m.Post("/gogs/:id", bindIgnErr(auth.NewGogshookForm{}), repo.GogsHooksEditPost)
 				m.Post("/slack/:id", bindIgnErr(auth.NewSlackHookForm{}), repo.SlackHooksEditPost)
@@ -653,6 +738,8 @@ func RegisterRoutes(m *macaron.Macaron) {
 				m.Post("/dingtalk/:id", bindIgnErr(auth.NewDingtalkHookForm{}), repo.DingtalkHooksEditPost)
 				m.Post("/telegram/:id", bindIgnErr(auth.NewTelegramHookForm{}), repo.TelegramHooksEditPost)
 				m.Post("/msteams/:id", bindIgnErr(auth.NewMSTeamsHookForm{}), repo.MSTeamsHooksEditPost)
+				m.Post("/matrix/:id", bindIgnErr(auth.NewMatrixHookForm{}), repo.MatrixHooksEditPost)
+				m.Post("/custom/:id", bindIgnErr(auth.NewCustomHookForm{}), repo.CustomHooksEditPost)
 
 				m.Group("/git", func() {
 					m.Get("", repo.GitHooks)
@@ -667,6 +754,12 @@ func RegisterRoutes(m *macaron.Macaron) {
 				m.Post("/delete", repo.DeleteDeployKey)
 			})
 
+			m.Group("/runners", func() {
+				m.Get("", repo.SettingsRunners)
+				m.Post("/reset_token", repo.ResetRunnerTokenPost)
+				m.Post("/delete", repo.DeleteRunner)
+			}, repo.MustEnableActions, context.RequireRepoReader(models.UnitTypeActions))
+
 		}, func(ctx *context.Context) {
 			ctx.Data["PageIsSettings"] = true
 		})
@@ -674,6 +767,15 @@ func RegisterRoutes(m *macaron.Macaron) {
 
 	m.Get("/:username/:reponame/action/:action", reqSignIn, context.RepoAssignment(), context.UnitTypes(), repo.Action)
 
+	m.Group("/:username/:reponame/migrating", func() {
+		m.Get("", repo.Migrating)
+		m.Get("/status", repo.MigratingStatus)
+		m.Group("", func() {
+			m.Post("/retry", bindIgnErr(auth.MigrateRetryForm{}), repo.MigratingRetry)
+			m.Post("/delete", repo.MigratingDelete)
+		}, reqSignIn, reqRepoAdmin)
+	}, ignSignIn, context.RepoAssignment())
+
 	m.Group("/:username/:reponame", func() {
 		m.Group("/issues", func() {
 			m.Combo("/new").Get(context.RepoRef(), repo.NewIssue).
@@ -705,6 +807,7 @@ func RegisterRoutes(m *macaron.Macaron) {
 
 			m.Post("/labels", reqRepoIssuesOrPullsWriter, repo.UpdateIssueLabel)
 			m.Post("/milestone", reqRepoIssuesOrPullsWriter, repo.UpdateIssueMilestone)
+			m.Post("/projects", reqRepoIssuesOrPullsWriter, repo.UpdateIssueProjects)
 			m.Post("/assignee", reqRepoIssuesOrPullsWriter, repo.UpdateIssueAssignee)
 			m.Post("/status", reqRepoIssuesOrPullsWriter, repo.UpdateIssueStatus)
 		}, context.RepoMustNotBeArchived())
@@ -806,6 +909,24 @@ func RegisterRoutes(m *macaron.Macaron) {
 			m.Get("/milestones", reqRepoIssuesOrPullsReader, repo.Milestones)
 		}, context.RepoRef())
 
+		m.Group("/projects", func() {
+			m.Get("", repo.Projects)
+			m.Get("/:id", repo.ViewProject)
+			m.Group("", func() {
+				m.Combo("/new").Get(repo.NewProject).
+					Post(bindIgnErr(auth.CreateProjectForm{}), repo.NewProjectPost)
+				m.Get("/:id/edit", repo.EditProject)
+				m.Post("/:id/edit", bindIgnErr(auth.CreateProjectForm{}), repo.EditProjectPost)
+				m.Get("/:id/:action", repo.ChangeProjectStatus)
+				m.Post("/delete", repo.DeleteProject)
+				m.Post("/:id/boards", bindIgnErr(auth.ProjectBoardForm{}), repo.NewProjectBoardPost)
+				m.Post("/:id/boards/:boardID", bindIgnErr(auth.ProjectBoardForm{}), repo.EditProjectBoardPost)
+				m.Post("/:id/boards/delete", repo.DeleteProjectBoard)
+				m.Post("/:id/move", repo.MoveProjectCard)
+				m.Post("/:id/remove", repo.RemoveProjectCard)
+			}, reqSignIn, repo.MustWriteProjects)
+		}, context.RequireRepoReader(models.UnitTypeProjects), repo.ProjectsAssignment)
+
 		m.Group("/wiki", func() {
 			m.Get("/?:page", repo.Wiki)
 			m.Get("/_pages", repo.WikiPages)
@@ -824,6 +945,13 @@ func RegisterRoutes(m *macaron.Macaron) {
 			m.Get("/raw/*", repo.WikiRaw)
 		}, repo.MustEnableWiki)
 
+		m.Group("/actions", func() {
+			m.Get("", repo.Actions)
+			m.Get("/runs/:id", repo.ActionRunView)
+			m.Post("/runs/:id/cancel", reqSignIn, context.RequireRepoWriter(models.UnitTypeActions), repo.ActionRunCancel)
+			m.Post("/runs/:id/approve", reqSignIn, context.RequireRepoWriter(models.UnitTypeCode), repo.ActionRunApprove)
+		}, repo.MustEnableActions, context.RequireRepoReader(models.UnitTypeActions))
+
 		m.Group("/activity", func() {
 			m.Get("", repo.Activity)
 			m.Get("/:period", repo.Activity)
@@ -960,6 +1088,28 @@ func RegisterRoutes(m *macaron.Macaron) {
 		private.RegisterRoutes(m)
 	})
 
+	if setting.Actions.Enabled {
+		m.Group("/api/actions", func() {
+			actions.RegisterRoutes(m)
+		})
+	}
+
+	if setting.Packages.Enabled {
+		m.Group("/api/packages", func() {
+			packages.RegisterRoutes(m)
+		}, ignSignIn)
+		// the container registry authenticates its requests with its own bearer tokens
+		m.Group("/v2", func() {
+			packages.RegisterContainerRoutes(m)
+		})
+	}
+
+	if setting.Repository.GoProxy.Enabled {
+		m.Group("/api/go", func() {
+			goproxy.RegisterRoutes(m)
+		})
+	}
+
 	// robots.txt
 	m.Get("/robots.txt", func(ctx *context.Context) {
 		if setting.HasRobotsTxt {
@@ -275,7 +275,7 @@ func TwoFactorPost(ctx *context.Context, form auth.TwoFactorAuthForm) {
 				return
 			}
 
-			err = models.LinkAccountToUser(u, gothUser.(goth.User))
+			err = linkAccountToUser(u, gothUser.(goth.User))
 			if err != nil {
 				ctx.ServerError("UserSignIn", err)
 				return
@@ -450,7 +450,7 @@ func U2FSign(ctx *context.Context, signResp u2f.SignResponse) {
 					return
 				}
 
-				err = models.LinkAccountToUser(user, gothUser.(goth.User))
+				err = linkAccountToUser(user, gothUser.(goth.User))
 				if err != nil {
 					ctx.ServerError("UserSignIn", err)
 					return
@@ -673,6 +673,9 @@ func oAuth2UserLoginCallback(loginSource *models.LoginSource, request *http.Requ
 	}
 
 	if hasUser {
+		if err = models.SyncOAuth2User(user, loginSource, gothUser.RawData); err != nil {
+			return nil, goth.User{}, err
+		}
 		return user, goth.User{}, nil
 	}
 
@@ -687,7 +690,13 @@ func oAuth2UserLoginCallback(loginSource *models.LoginSource, request *http.Requ
 	}
 	if hasUser {
 		user, err = models.GetUserByID(externalLoginUser.UserID)
-		return user, goth.User{}, err
+		if err != nil {
+			return nil, goth.User{}, err
+		}
+		if err = models.SyncOAuth2User(user, loginSource, gothUser.RawData); err != nil {
+			return nil, goth.User{}, err
+		}
+		return user, goth.User{}, nil
 	}
 
 	// no user found to login
@@ -695,6 +704,20 @@ func oAuth2UserLoginCallback(loginSource *models.LoginSource, request *http.Requ
 
 }
 
+// linkAccountToUser links the external account to the user and syncs the user with its claims
+func linkAccountToUser(u *models.User, gothUser goth.User) error {
+	if err := models.LinkAccountToUser(u, gothUser); err != nil {
+		return err
+	}
+	loginSource, err := models.GetActiveOAuth2LoginSourceByName(gothUser.Provider)
+	if err != nil {
+		return err
+	} else if loginSource == nil {
+		return nil
+	}
+	return models.SyncOAuth2User(u, loginSource, gothUser.RawData)
+}
+
 // LinkAccount shows the page where the user can decide to login or create a new account
 func LinkAccount(ctx *context.Context) {
 	ctx.Data["DisablePassword"] = !setting.Service.RequireExternalRegistrationCaptcha || setting.Service.AllowOnlyExternalRegistration
@@ -788,7 +811,7 @@ func LinkAccountPostSignIn(ctx *context.Context, signInForm auth.SignInForm) {
 	_, err = models.GetTwoFactorByUID(u.ID)
 	if err != nil {
 		if models.IsErrTwoFactorNotEnrolled(err) {
-			err = models.LinkAccountToUser(u, gothUser.(goth.User))
+			err = linkAccountToUser(u, gothUser.(goth.User))
 			if err != nil {
 				ctx.ServerError("UserLinkAccount", err)
 			} else {
@@ -905,6 +928,7 @@ func LinkAccountPostRegister(ctx *context.Context, cpt *captcha.Captcha, form au
 
 	u := &models.User{
 		Name:        form.UserName,
+		FullName:    gothUser.(goth.User).Name,
 		Email:       form.Email,
 		Passwd:      form.Password,
 		IsActive:    !setting.Service.RegisterEmailConfirm,
@@ -945,6 +969,11 @@ func LinkAccountPostRegister(ctx *context.Context, cpt *captcha.Captcha, form au
 		}
 	}
 
+	if err := models.SyncOAuth2User(u, loginSource, gothUser.(goth.User).RawData); err != nil {
+		ctx.ServerError("SyncOAuth2User", err)
+		return
+	}
+
 	// Send confirmation email
 	if setting.Service.RegisterEmailConfirm && u.ID > 1 {
 		models.SendActivateAccountMail(ctx.Context, u)
@@ -252,14 +252,6 @@ func Issues(ctx *context.Context) {
 		opts.MentionedID = ctxUser.ID
 	}
 
-	counts, err := models.CountIssuesByRepo(opts)
-	if err != nil {
-		ctx.ServerError("CountIssuesByRepo", err)
-		return
-	}
-
-	opts.Page = page
-	opts.PageSize = setting.UI.IssuePagingNum
 	var labelIDs []int64
 	selectLabels := ctx.Query("labels")
 	if len(selectLabels) > 0 && selectLabels != "0" {
@@ -271,6 +263,15 @@ func Issues(ctx *context.Context) {
 	}
 	opts.LabelIDs = labelIDs
 
+	counts, err := models.CountIssuesByRepo(opts)
+	if err != nil {
+		ctx.ServerError("CountIssuesByRepo", err)
+		return
+	}
+
+	opts.Page = page
+	opts.PageSize = setting.UI.IssuePagingNum
+
 	issues, err := models.Issues(opts)
 	if err != nil {
 		ctx.ServerError("Issues", err)
@@ -342,6 +343,7 @@ func Issues(ctx *context.Context) {
 		UserID:      ctxUser.ID,
 		RepoID:      repoID,
 		UserRepoIDs: userRepoIDs,
+		LabelIDs:    labelIDs,
 		FilterMode:  filterMode,
 		IsPull:      isPullList,
 		IsClosed:    isShowClosed,
@@ -366,8 +368,23 @@ func Issues(ctx *context.Context) {
 	ctx.Data["ViewType"] = viewType
 	ctx.Data["SortType"] = sortType
 	ctx.Data["RepoID"] = repoID
+	ctx.Data["SelectLabels"] = selectLabels
 	ctx.Data["IsShowClosed"] = isShowClosed
 
+	// The labels of an organization are shared by its repositories, they can filter
+	// the issues of all the repositories.
+	if ctxUser.IsOrganization() {
+		labels, err := models.GetLabelsByOrgID(ctxUser.ID, "")
+		if err != nil {
+			ctx.ServerError("GetLabelsByOrgID", err)
+			return
+		}
+		for _, l := range labels {
+			l.LoadSelectedLabelsAfterClick(labelIDs)
+		}
+		ctx.Data["Labels"] = labels
+	}
+
 	if isShowClosed {
 		ctx.Data["State"] = "closed"
 	} else {
@@ -457,6 +474,7 @@ func showOrgProfile(ctx *context.Context) {
 	}
 
 	ctx.Data["Title"] = org.DisplayName()
+	ctx.Data["EnablePackages"] = setting.Packages.Enabled
 
 	var orderBy models.SearchOrderBy
 	ctx.Data["SortType"] = ctx.Query("sort")
@@ -494,37 +512,44 @@ func showOrgProfile(ctx *context.Context) {
 		page = 1
 	}
 
-	var (
-		repos []*models.Repository
-		count int64
-		err   error
-	)
-	repos, count, err = models.SearchRepositoryByName(&models.SearchRepoOptions{
-		Keyword:     keyword,
-		OwnerID:     org.ID,
-		OrderBy:     orderBy,
-		Private:     ctx.IsSigned,
-		UserIsAdmin: ctx.IsUserSiteAdmin(),
-		UserID:      ctx.Data["SignedUserID"].(int64),
-		Page:        page,
-		IsProfile:   true,
-		PageSize:    setting.UI.User.RepoPagingNum,
-	})
-	if err != nil {
-		ctx.ServerError("SearchRepositoryByName", err)
-		return
-	}
-
 	if err := org.GetMembers(); err != nil {
 		ctx.ServerError("GetMembers", err)
 		return
 	}
-
-	ctx.Data["Repos"] = repos
-	ctx.Data["Total"] = count
 	ctx.Data["Members"] = org.Members
 	ctx.Data["Teams"] = org.Teams
 
+	var count int64
+	if ctx.Query("tab") == "packages" && setting.Packages.Enabled {
+		ctx.Data["TabName"] = "packages"
+		count = retrievePackages(ctx, org, keyword, page)
+		if ctx.Written() {
+			return
+		}
+	} else {
+		var (
+			repos []*models.Repository
+			err   error
+		)
+		repos, count, err = models.SearchRepositoryByName(&models.SearchRepoOptions{
+			Keyword:     keyword,
+			OwnerID:     org.ID,
+			OrderBy:     orderBy,
+			Private:     ctx.IsSigned,
+			UserIsAdmin: ctx.IsUserSiteAdmin(),
+			UserID:      ctx.Data["SignedUserID"].(int64),
+			Page:        page,
+			IsProfile:   true,
+			PageSize:    setting.UI.User.RepoPagingNum,
+		})
+		if err != nil {
+			ctx.ServerError("SearchRepositoryByName", err)
+			return
+		}
+		ctx.Data["Repos"] = repos
+	}
+	ctx.Data["Total"] = count
+
 	pager := context.NewPagination(int(count), setting.UI.User.RepoPagingNum, page, 5)
 	pager.SetDefaultParams(ctx)
 	ctx.Data["Page"] = pager
@@ -9,10 +9,12 @@ import (
 	"fmt"
 	"github.com/go-macaron/binding"
 	"net/url"
+	"strconv"
 	"strings"
 
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/auth"
+	"code.gitea.io/gitea/modules/auth/oauth2"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/log"
@@ -106,9 +108,11 @@ type AccessTokenResponse struct {
 	TokenType    TokenType `json:"token_type"`
 	ExpiresIn    int64     `json:"expires_in"`
 	RefreshToken string    `json:"refresh_token"`
+	Scope        string    `json:"scope,omitempty"`
+	IDToken      string    `json:"id_token,omitempty"`
 }
 
-func newAccessTokenResponse(grant *models.OAuth2Grant) (*AccessTokenResponse, *AccessTokenError) {
+func newAccessTokenResponse(grant *models.OAuth2Grant, app *models.OAuth2Application, scope models.OAuth2Scope, nonce string) (*AccessTokenResponse, *AccessTokenError) {
 	if setting.OAuth2.InvalidateRefreshTokens {
 		if err := grant.IncreaseCounter(); err != nil {
 			return nil, &AccessTokenError{
@@ -122,6 +126,7 @@ func newAccessTokenResponse(grant *models.OAuth2Grant) (*AccessTokenResponse, *A
 	accessToken := &models.OAuth2Token{
 		GrantID: grant.ID,
 		Type:    models.TypeAccessToken,
+		Scope:   string(scope),
 		StandardClaims: jwt.StandardClaims{
 			ExpiresAt: expirationDate.AsTime().Unix(),
 		},
@@ -140,6 +145,7 @@ func newAccessTokenResponse(grant *models.OAuth2Grant) (*AccessTokenResponse, *A
 		GrantID: grant.ID,
 		Counter: grant.Counter,
 		Type:    models.TypeRefreshToken,
+		Scope:   string(scope),
 		StandardClaims: jwt.StandardClaims{
 			ExpiresAt: refreshExpirationDate,
 		},
@@ -152,11 +158,34 @@ func newAccessTokenResponse(grant *models.OAuth2Grant) (*AccessTokenResponse, *A
 		}
 	}
 
+	// generate the OpenID Connect id_token
+	var signedIDToken string
+	if scope.Has(models.OAuth2ScopeOpenID) {
+		idToken, err := models.NewOIDCToken(grant, app, scope, nonce)
+		if err != nil {
+			log.Error("NewOIDCToken: %v", err)
+			return nil, &AccessTokenError{
+				ErrorCode:        AccessTokenErrorCodeInvalidRequest,
+				ErrorDescription: "cannot create id_token",
+			}
+		}
+		signedIDToken, err = idToken.SignToken()
+		if err != nil {
+			log.Error("SignToken: %v", err)
+			return nil, &AccessTokenError{
+				ErrorCode:        AccessTokenErrorCodeInvalidRequest,
+				ErrorDescription: "cannot sign token",
+			}
+		}
+	}
+
 	return &AccessTokenResponse{
 		AccessToken:  signedAccessToken,
 		TokenType:    TokenTypeBearer,
 		ExpiresIn:    setting.OAuth2.AccessTokenExpirationTime,
 		RefreshToken: signedRefreshToken,
+		Scope:        string(scope),
+		IDToken:      signedIDToken,
 	}, nil
 }
 
@@ -209,10 +238,19 @@ func AuthorizeOAuth(ctx *context.Context, form auth.AuthorizationForm) {
 		return
 	}
 
+	scope, err := models.NormalizeOAuth2Scope(form.Scope)
+	if err != nil {
+		handleAuthorizeError(ctx, AuthorizeError{
+			ErrorCode:        ErrorCodeInvalidScope,
+			ErrorDescription: err.Error(),
+			State:            form.State,
+		}, form.RedirectURI)
+		return
+	}
+
 	// pkce support
 	switch form.CodeChallengeMethod {
-	case "S256":
-	case "plain":
+	case "S256", "plain":
 		if err := ctx.Session.Set("CodeChallengeMethod", form.CodeChallengeMethod); err != nil {
 			handleAuthorizeError(ctx, AuthorizeError{
 				ErrorCode:        ErrorCodeServerError,
@@ -221,7 +259,7 @@ func AuthorizeOAuth(ctx *context.Context, form auth.AuthorizationForm) {
 			}, form.RedirectURI)
 			return
 		}
-		if err := ctx.Session.Set("CodeChallengeMethod", form.CodeChallenge); err != nil {
+		if err := ctx.Session.Set("CodeChallenge", form.CodeChallenge); err != nil {
 			handleAuthorizeError(ctx, AuthorizeError{
 				ErrorCode:        ErrorCodeServerError,
 				ErrorDescription: "cannot set code challenge",
@@ -246,9 +284,9 @@ func AuthorizeOAuth(ctx *context.Context, form auth.AuthorizationForm) {
 		return
 	}
 
-	// Redirect if user already granted access
-	if grant != nil {
-		code, err := grant.GenerateNewAuthorizationCode(form.RedirectURI, form.CodeChallenge, form.CodeChallengeMethod)
+	// Redirect if user already granted access to the requested scope
+	if grant != nil && models.OAuth2Scope(grant.Scope).Includes(scope) {
+		code, err := grant.GenerateNewAuthorizationCode(form.RedirectURI, form.CodeChallenge, form.CodeChallengeMethod, scope, form.Nonce)
 		if err != nil {
 			handleServerError(ctx, form.State, form.RedirectURI)
 			return
@@ -266,6 +304,7 @@ func AuthorizeOAuth(ctx *context.Context, form auth.AuthorizationForm) {
 	ctx.Data["Application"] = app
 	ctx.Data["RedirectURI"] = form.RedirectURI
 	ctx.Data["State"] = form.State
+	ctx.Data["Scope"] = scope
 	ctx.Data["ApplicationUserLink"] = "<a href=\"" + setting.AppURL + app.User.LowerName + "\">@" + app.User.Name + "</a>"
 	ctx.Data["ApplicationRedirectDomainHTML"] = "<strong>" + form.RedirectURI + "</strong>"
 	// TODO document SESSION <=> FORM
@@ -287,6 +326,18 @@ func AuthorizeOAuth(ctx *context.Context, form auth.AuthorizationForm) {
 		log.Error(err.Error())
 		return
 	}
+	err = ctx.Session.Set("scope", string(scope))
+	if err != nil {
+		handleServerError(ctx, form.State, form.RedirectURI)
+		log.Error(err.Error())
+		return
+	}
+	err = ctx.Session.Set("nonce", form.Nonce)
+	if err != nil {
+		handleServerError(ctx, form.State, form.RedirectURI)
+		log.Error(err.Error())
+		return
+	}
 	ctx.HTML(200, tplGrantAccess)
 }
 
@@ -302,7 +353,17 @@ func GrantApplicationOAuth(ctx *context.Context, form auth.GrantApplicationForm)
 		ctx.ServerError("GetOAuth2ApplicationByClientID", err)
 		return
 	}
-	grant, err := app.CreateGrant(ctx.User.ID)
+	scope, _ := ctx.Session.Get("scope").(string)
+	nonce, _ := ctx.Session.Get("nonce").(string)
+
+	grant, err := app.GetGrantByUserID(ctx.User.ID)
+	if err == nil {
+		if grant == nil {
+			grant, err = app.CreateGrant(ctx.User.ID, models.OAuth2Scope(scope))
+		} else {
+			err = grant.AddScope(models.OAuth2Scope(scope))
+		}
+	}
 	if err != nil {
 		handleAuthorizeError(ctx, AuthorizeError{
 			State:            form.State,
@@ -316,7 +377,7 @@ func GrantApplicationOAuth(ctx *context.Context, form auth.GrantApplicationForm)
 	codeChallenge, _ = ctx.Session.Get("CodeChallenge").(string)
 	codeChallengeMethod, _ = ctx.Session.Get("CodeChallengeMethod").(string)
 
-	code, err := grant.GenerateNewAuthorizationCode(form.RedirectURI, codeChallenge, codeChallengeMethod)
+	code, err := grant.GenerateNewAuthorizationCode(form.RedirectURI, codeChallenge, codeChallengeMethod, models.OAuth2Scope(scope), nonce)
 	if err != nil {
 		handleServerError(ctx, form.State, form.RedirectURI)
 		return
@@ -371,8 +432,12 @@ func AccessTokenOAuth(ctx *context.Context, form auth.AccessTokenForm) {
 }
 
 func handleRefreshToken(ctx *context.Context, form auth.AccessTokenForm) {
+	app := loadAuthenticatedClient(ctx, form)
+	if app == nil {
+		return
+	}
 	token, err := models.ParseOAuth2Token(form.RefreshToken)
-	if err != nil {
+	if err != nil || token.Type != models.TypeRefreshToken {
 		handleAccessTokenError(ctx, AccessTokenError{
 			ErrorCode:        AccessTokenErrorCodeUnauthorizedClient,
 			ErrorDescription: "client is not authorized",
@@ -388,6 +453,14 @@ func handleRefreshToken(ctx *context.Context, form auth.AccessTokenForm) {
 		})
 		return
 	}
+	// check if the token was issued to this application
+	if grant.ApplicationID != app.ID {
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeInvalidGrant,
+			ErrorDescription: "invalid grant",
+		})
+		return
+	}
 
 	// check if token got already used
 	if setting.OAuth2.InvalidateRefreshTokens && (grant.Counter != token.Counter || token.Counter == 0) {
@@ -398,7 +471,7 @@ func handleRefreshToken(ctx *context.Context, form auth.AccessTokenForm) {
 		log.Warn("A client tried to use a refresh token for grant_id = %d was used twice!", grant.ID)
 		return
 	}
-	accessToken, tokenErr := newAccessTokenResponse(grant)
+	accessToken, tokenErr := newAccessTokenResponse(grant, app, models.OAuth2Scope(token.Scope), "")
 	if tokenErr != nil {
 		handleAccessTokenError(ctx, *tokenErr)
 		return
@@ -406,20 +479,30 @@ func handleRefreshToken(ctx *context.Context, form auth.AccessTokenForm) {
 	ctx.JSON(200, accessToken)
 }
 
-func handleAuthorizationCode(ctx *context.Context, form auth.AccessTokenForm) {
+// loadAuthenticatedClient returns the application authenticated by the client id and secret
+// of the request, the error response is sent when it returns nil
+func loadAuthenticatedClient(ctx *context.Context, form auth.AccessTokenForm) *models.OAuth2Application {
 	app, err := models.GetOAuth2ApplicationByClientID(form.ClientID)
 	if err != nil {
 		handleAccessTokenError(ctx, AccessTokenError{
 			ErrorCode:        AccessTokenErrorCodeInvalidClient,
 			ErrorDescription: fmt.Sprintf("cannot load client with client id: '%s'", form.ClientID),
 		})
-		return
+		return nil
 	}
 	if !app.ValidateClientSecret([]byte(form.ClientSecret)) {
 		handleAccessTokenError(ctx, AccessTokenError{
 			ErrorCode:        AccessTokenErrorCodeUnauthorizedClient,
 			ErrorDescription: "client is not authorized",
 		})
+		return nil
+	}
+	return app
+}
+
+func handleAuthorizationCode(ctx *context.Context, form auth.AccessTokenForm) {
+	app := loadAuthenticatedClient(ctx, form)
+	if app == nil {
 		return
 	}
 	if form.RedirectURI != "" && !app.ContainsRedirectURI(form.RedirectURI) {
@@ -460,7 +543,7 @@ func handleAuthorizationCode(ctx *context.Context, form auth.AccessTokenForm) {
 			ErrorDescription: "cannot proceed your request",
 		})
 	}
-	resp, tokenErr := newAccessTokenResponse(authorizationCode.Grant)
+	resp, tokenErr := newAccessTokenResponse(authorizationCode.Grant, app, models.OAuth2Scope(authorizationCode.Scope), authorizationCode.Nonce)
 	if tokenErr != nil {
 		handleAccessTokenError(ctx, *tokenErr)
 		return
@@ -469,6 +552,83 @@ func handleAuthorizationCode(ctx *context.Context, form auth.AccessTokenForm) {
 	ctx.JSON(200, resp)
 }
 
+// InfoOAuth returns the claims about the user of an OpenID Connect access token
+func InfoOAuth(ctx *context.Context) {
+	var grant *models.OAuth2Grant
+	var scope models.OAuth2Scope
+	auths := strings.Fields(ctx.Req.Header.Get("Authorization"))
+	if len(auths) == 2 && strings.ToLower(auths[0]) == "bearer" {
+		grant, scope = auth.ParseOAuthAccessToken(auths[1])
+	}
+	if grant == nil {
+		ctx.Resp.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		ctx.PlainText(401, []byte("invalid access token"))
+		return
+	}
+	if !scope.Has(models.OAuth2ScopeOpenID) {
+		ctx.Resp.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope"`)
+		ctx.PlainText(403, []byte("the openid scope is required"))
+		return
+	}
+
+	user, err := models.GetUserByID(grant.UserID)
+	if err != nil {
+		ctx.ServerError("GetUserByID", err)
+		return
+	}
+	info, err := models.GetOIDCUserInfo(user, scope)
+	if err != nil {
+		ctx.ServerError("GetOIDCUserInfo", err)
+		return
+	}
+	ctx.JSON(200, &struct {
+		Subject string `json:"sub"`
+		*models.OIDCUserInfo
+	}{
+		Subject:      strconv.FormatInt(user.ID, 10),
+		OIDCUserInfo: info,
+	})
+}
+
+// OIDCKeys returns the JSON Web Key Set of the keys signing the id_tokens
+func OIDCKeys(ctx *context.Context) {
+	if oauth2.DefaultSigningKey == nil {
+		ctx.NotFound("OIDCKeys", nil)
+		return
+	}
+	ctx.JSON(200, map[string]interface{}{
+		"keys": []map[string]string{oauth2.DefaultSigningKey.ToJWK()},
+	})
+}
+
+// OIDCWellKnown returns the OpenID Connect discovery document
+func OIDCWellKnown(ctx *context.Context) {
+	scopes := make([]string, 0, len(models.OpenIDConnectScopes)+len(models.AccessTokenScopes))
+	scopes = append(scopes, models.OpenIDConnectScopes...)
+	for _, scope := range models.AccessTokenScopes {
+		scopes = append(scopes, string(scope))
+	}
+	ctx.JSON(200, map[string]interface{}{
+		"issuer":                                models.OIDCIssuer(),
+		"authorization_endpoint":                setting.AppURL + "login/oauth/authorize",
+		"token_endpoint":                        setting.AppURL + "login/oauth/access_token",
+		"userinfo_endpoint":                     setting.AppURL + "login/oauth/userinfo",
+		"jwks_uri":                              setting.AppURL + "login/oauth/keys",
+		"scopes_supported":                      scopes,
+		"response_types_supported":              []string{"code"},
+		"grant_types_supported":                 []string{"authorization_code", "refresh_token"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post"},
+		"code_challenge_methods_supported":      []string{"plain", "S256"},
+		"claims_supported": []string{
+			"iss", "sub", "aud", "exp", "iat", "nonce",
+			"name", "preferred_username", "profile", "picture", "website", "locale", "updated_at",
+			"email", "email_verified", "groups",
+		},
+	})
+}
+
 func handleAccessTokenError(ctx *context.Context, acErr AccessTokenError) {
 	ctx.JSON(400, acErr)
 }
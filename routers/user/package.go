@@ -0,0 +1,168 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package user
+
+import (
+	"net/url"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/packages"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+const (
+	tplPackageView base.TplName = "package/view"
+)
+
+// retrievePackages loads a page of the packages of the owner matching the keyword
+// and returns the total count of the matching packages.
+func retrievePackages(ctx *context.Context, owner *models.User, keyword string, page int) int64 {
+	ctx.Data["PageIsPackages"] = true
+	pkgs, count, err := models.SearchPackages(&models.PackageSearchOptions{
+		OwnerID:  owner.ID,
+		Keyword:  keyword,
+		Page:     page,
+		PageSize: setting.UI.User.RepoPagingNum,
+	})
+	if err != nil {
+		ctx.ServerError("SearchPackages", err)
+		return 0
+	}
+	for _, p := range pkgs {
+		if err = hideUnreadableRepo(ctx, p); err != nil {
+			ctx.ServerError("hideUnreadableRepo", err)
+			return 0
+		}
+	}
+	ctx.Data["Packages"] = pkgs
+	return count
+}
+
+// hideUnreadableRepo removes the linked repository of the package, for display only,
+// when the signed in user can not read it.
+func hideUnreadableRepo(ctx *context.Context, p *models.Package) error {
+	if p.Repo == nil {
+		return nil
+	}
+	perm, err := models.GetUserRepoPermission(p.Repo, ctx.User)
+	if err != nil {
+		return err
+	}
+	if !perm.HasAccess() {
+		p.Repo = nil
+	}
+	return nil
+}
+
+// loadPackage loads the package of the request with its attributes
+func loadPackage(ctx *context.Context) *models.Package {
+	typ := models.PackageType(ctx.Params(":type"))
+	if !typ.IsValid() {
+		ctx.NotFound("PackageType", nil)
+		return nil
+	}
+	p, err := models.GetPackageByName(ctx.Package.Owner.ID, typ, ctx.Params(":name"))
+	if err != nil {
+		ctx.NotFoundOrServerError("GetPackageByName", models.IsErrPackageNotExist, err)
+		return nil
+	}
+	if err = p.LoadAttributes(); err != nil {
+		ctx.ServerError("LoadAttributes", err)
+		return nil
+	}
+	if err = hideUnreadableRepo(ctx, p); err != nil {
+		ctx.ServerError("hideUnreadableRepo", err)
+		return nil
+	}
+	return p
+}
+
+// ViewPackage renders the versions and the files of a package
+func ViewPackage(ctx *context.Context) {
+	p := loadPackage(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	versions, err := models.GetPackageVersions(p.ID)
+	if err != nil {
+		ctx.ServerError("GetPackageVersions", err)
+		return
+	}
+	for _, v := range versions {
+		if err = v.LoadAttributes(); err != nil {
+			ctx.ServerError("LoadAttributes", err)
+			return
+		}
+	}
+
+	if ctx.Package.CanWrite {
+		repos, err := models.GetUserRepositories(ctx.Package.Owner.ID, true, 1, ctx.Package.Owner.NumRepos, "lower_name")
+		if err != nil {
+			ctx.ServerError("GetUserRepositories", err)
+			return
+		}
+		ctx.Data["Repos"] = repos
+	}
+
+	if p.Type == models.PackageContainer {
+		// the images are named after the host serving the registry
+		if u, err := url.Parse(setting.AppURL); err == nil {
+			ctx.Data["RegistryHost"] = u.Host
+		}
+	}
+
+	ctx.Data["Title"] = p.Name
+	ctx.Data["PageIsPackages"] = true
+	ctx.Data["Package"] = p
+	ctx.Data["Versions"] = versions
+	ctx.HTML(200, tplPackageView)
+}
+
+// PackageSettingsPost links the package to a repository of its owner or unlinks it
+func PackageSettingsPost(ctx *context.Context) {
+	p := loadPackage(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if err := models.LinkPackageToRepo(p, ctx.QueryInt64("repo_id")); err != nil {
+		if !models.IsErrRepoNotExist(err) {
+			ctx.ServerError("LinkPackageToRepo", err)
+			return
+		}
+		ctx.Flash.Error(ctx.Tr("packages.settings.repo_not_exist"))
+	} else {
+		ctx.Flash.Success(ctx.Tr("packages.settings.update_success"))
+	}
+	ctx.Redirect(p.Link())
+}
+
+// DeletePackageVersion deletes a version of a package with its files
+func DeletePackageVersion(ctx *context.Context) {
+	p := loadPackage(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	redirect := p.Link()
+	v, err := models.GetPackageVersionByID(p.ID, ctx.QueryInt64("id"))
+	if err != nil {
+		ctx.Flash.Error("GetPackageVersionByID: " + err.Error())
+	} else if err = packages.DeleteVersion(p, v); err != nil {
+		ctx.Flash.Error("DeleteVersion: " + err.Error())
+	} else {
+		ctx.Flash.Success(ctx.Tr("packages.delete_version_success", v.Version))
+		if _, err = models.GetPackageByName(p.OwnerID, p.Type, p.Name); models.IsErrPackageNotExist(err) {
+			redirect = p.Owner.HomeLink() + "?tab=packages"
+		}
+	}
+
+	ctx.JSON(200, map[string]interface{}{
+		"redirect": redirect,
+	})
+}
@@ -100,6 +100,7 @@ func Profile(ctx *context.Context) {
 	ctx.Data["OpenIDs"] = openIDs
 	ctx.Data["EnableHeatmap"] = setting.Service.EnableUserHeatmap
 	ctx.Data["HeatmapUser"] = ctxUser.Name
+	ctx.Data["EnablePackages"] = setting.Packages.Enabled
 	showPrivate := ctx.IsSigned && (ctx.User.IsAdmin || ctx.User.ID == ctxUser.ID)
 
 	orgs, err := models.GetOrgsByUserID(ctxUser.ID, showPrivate)
@@ -187,6 +188,15 @@ func Profile(ctx *context.Context) {
 		}
 
 		total = int(count)
+	case "packages":
+		if !setting.Packages.Enabled {
+			ctx.NotFound("Packages", nil)
+			return
+		}
+		total = int(retrievePackages(ctx, ctxUser, keyword, page))
+		if ctx.Written() {
+			return
+		}
 	default:
 		repos, count, err = models.SearchRepositoryByName(&models.SearchRepoOptions{
 			Keyword:     keyword,
@@ -6,6 +6,8 @@
 package setting
 
 import (
+	"strings"
+
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/auth"
 	"code.gitea.io/gitea/modules/base"
@@ -39,9 +41,40 @@ func ApplicationsPost(ctx *context.Context, form auth.NewAccessTokenForm) {
 		return
 	}
 
+	scope, err := models.NormalizeAccessTokenScope(form.Scopes)
+	if err != nil {
+		loadApplicationsData(ctx)
+		ctx.RenderWithErr(ctx.Tr("settings.token_scope_invalid"), tplSettingsApplications, &form)
+		return
+	}
+
+	var repoNames []string
+	for _, name := range strings.Split(form.Repositories, ",") {
+		if name = strings.TrimSpace(name); len(name) > 0 {
+			repoNames = append(repoNames, name)
+		}
+	}
+	repoIDs, err := models.GetRepositoryIDsByFullNames(repoNames)
+	if err != nil {
+		if models.IsErrRepoNotExist(err) {
+			repoName := err.(models.ErrRepoNotExist).Name
+			if ownerName := err.(models.ErrRepoNotExist).OwnerName; len(ownerName) > 0 {
+				repoName = ownerName + "/" + repoName
+			}
+			loadApplicationsData(ctx)
+			ctx.Data["Err_Repositories"] = true
+			ctx.RenderWithErr(ctx.Tr("settings.token_repositories_invalid", repoName), tplSettingsApplications, &form)
+		} else {
+			ctx.ServerError("GetRepositoryIDsByFullNames", err)
+		}
+		return
+	}
+
 	t := &models.AccessToken{
-		UID:  ctx.User.ID,
-		Name: form.Name,
+		UID:     ctx.User.ID,
+		Name:    form.Name,
+		Scope:   scope,
+		RepoIDs: repoIDs,
 	}
 	if err := models.NewAccessToken(t); err != nil {
 		ctx.ServerError("NewAccessToken", err)
@@ -73,7 +106,14 @@ func loadApplicationsData(ctx *context.Context) {
 		ctx.ServerError("ListAccessTokens", err)
 		return
 	}
+	for _, t := range tokens {
+		if err = t.LoadRepos(); err != nil {
+			ctx.ServerError("LoadRepos", err)
+			return
+		}
+	}
 	ctx.Data["Tokens"] = tokens
+	ctx.Data["AccessTokenScopes"] = models.AccessTokenScopes
 	ctx.Data["EnableOAuth2"] = setting.OAuth2.Enable
 	if setting.OAuth2.Enable {
 		ctx.Data["Applications"], err = models.GetOAuth2ApplicationsByUserID(ctx.User.ID)